@@ -18,6 +18,7 @@ import (
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/temporal"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/protobuf/proto"
 
@@ -1109,6 +1110,57 @@ func (a *FlowableActivity) AddTablesToPublication(ctx context.Context, cfg *prot
 	return nil
 }
 
+// AddIncrementalSnapshots queues additional tables to be snapshotted by the running mirror through its cdc stream,
+// instead of a separate initial load
+func (a *FlowableActivity) AddIncrementalSnapshots(ctx context.Context, cfg *protos.FlowConnectionConfigs,
+	additionalTableMappings []*protos.TableMapping,
+) error {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, cfg.FlowJobName)
+	srcConn, err := connectors.GetByNameAs[connectors.IncrementalSnapshotConnector](ctx, cfg.Env, a.CatalogPool, cfg.SourceName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("incremental snapshots are not supported for source %s", cfg.SourceName),
+				"incrementalSnapshot", err)
+		}
+		return a.Alerter.LogFlowError(ctx, cfg.FlowJobName, fmt.Errorf("failed to get source connector: %w", err))
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+
+	tableNameSchemaMapping, err := a.getTableNameSchemaMapping(ctx, cfg.FlowJobName)
+	if err != nil {
+		return err
+	}
+	snapshots := make([]*utils.IncrementalSnapshot, 0, len(additionalTableMappings))
+	for _, tm := range additionalTableMappings {
+		schema, ok := tableNameSchemaMapping[tm.DestinationTableIdentifier]
+		if !ok || len(schema.PrimaryKeyColumns) == 0 {
+			err := fmt.Errorf("table %s needs a primary key to be snapshotted incrementally", tm.SourceTableIdentifier)
+			return temporal.NewNonRetryableApplicationError(err.Error(), "incrementalSnapshot", err)
+		}
+		snapshot := &utils.IncrementalSnapshot{
+			SourceTable:      tm.SourceTableIdentifier,
+			DestinationTable: tm.DestinationTableIdentifier,
+			Filter:           tm.RowFilter,
+		}
+		// chunks are read with the filter rendered for the source
+		if err := srcConn.ValidateIncrementalSnapshot(ctx, snapshot, schema); err != nil {
+			return temporal.NewNonRetryableApplicationError(err.Error(), "incrementalSnapshot", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	for _, snapshot := range snapshots {
+		if _, err := utils.AddIncrementalSnapshot(ctx, a.CatalogPool, cfg.FlowJobName, snapshot); err != nil {
+			return a.Alerter.LogFlowError(ctx, cfg.FlowJobName, err)
+		}
+	}
+
+	a.Alerter.LogFlowInfo(ctx, cfg.FlowJobName, fmt.Sprintf("added incremental snapshots of %d tables",
+		len(additionalTableMappings)))
+	return nil
+}
+
 func (a *FlowableActivity) RemoveTablesFromPublication(
 	ctx context.Context,
 	cfg *protos.FlowConnectionConfigs,
@@ -1195,11 +1247,20 @@ func (a *FlowableActivity) RemoveTablesFromCatalog(
 		removedTables = append(removedTables, tm.DestinationTableIdentifier)
 	}
 
-	_, err := a.CatalogPool.Exec(
+	if _, err := a.CatalogPool.Exec(
 		ctx,
 		"delete from table_schema_mapping where flow_name = $1 and table_name = ANY($2)",
 		cfg.FlowJobName,
 		removedTables,
+	); err != nil {
+		return err
+	}
+
+	_, err := a.CatalogPool.Exec(
+		ctx,
+		"delete from metadata_incremental_snapshots where job_name = $1 and destination_table = ANY($2)",
+		cfg.FlowJobName,
+		removedTables,
 	)
 
 	return err
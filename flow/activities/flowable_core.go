@@ -23,6 +23,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/connectors"
 	connmysql "github.com/PeerDB-io/peerdb/flow/connectors/mysql"
 	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils/monitoring"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
@@ -107,6 +108,42 @@ func (a *FlowableActivity) applySchemaDeltas(
 	return nil
 }
 
+// rowFiltersToApply parses row filters of table mappings that the source does not already apply,
+// Postgres 15+ filters tables whose publication was created with a row filter
+func rowFiltersToApply(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	options *protos.SyncFlowOptions,
+	srcConn connectors.CDCPullConnectorCore,
+) (map[string]*utils.RowFilter, error) {
+	predicates := make(map[string]string)
+	for _, tableMapping := range options.TableMappings {
+		if tableMapping.RowFilter != "" {
+			predicates[tableMapping.SourceTableIdentifier] = tableMapping.RowFilter
+		}
+	}
+	var sourceFiltered map[string]struct{}
+	if pgConn, isPg := srcConn.(*connpostgres.PostgresConnector); isPg {
+		// also checks that a publication does not filter tables without a row filter
+		var err error
+		if sourceFiltered, err = pgConn.RowFilteredTables(ctx, config.FlowJobName, config.PublicationName, predicates); err != nil {
+			return nil, err
+		}
+	}
+	rowFilters := make(map[string]*utils.RowFilter)
+	for sourceTable, predicate := range predicates {
+		if _, ok := sourceFiltered[sourceTable]; ok {
+			continue
+		}
+		rowFilter, err := utils.ParseRowFilter(predicate)
+		if err != nil {
+			return nil, temporal.NewNonRetryableApplicationError(err.Error(), "rowFilter", err)
+		}
+		rowFilters[sourceTable] = rowFilter
+	}
+	return rowFilters, nil
+}
+
 func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncConnectorCore, Items model.Items](
 	ctx context.Context,
 	a *FlowableActivity,
@@ -164,10 +201,18 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 		return nil, fmt.Errorf("failed to get CDC channel buffer size: %w", err)
 	}
 	recordBatchPull := model.NewCDCStream[Items](channelBufferSize)
+	recordBatchPull.SetDroppedColumnPolicy(config.DroppedColumnPolicy)
 	recordBatchSync := recordBatchPull
+	rowFilters, err := rowFiltersToApply(ctx, config, options, srcConn)
+	if err != nil {
+		return nil, err
+	}
+	if len(rowFilters) > 0 {
+		recordBatchSync = utils.FilterCDCStream(ctx, recordBatchSync, rowFilters)
+	}
 	if adaptStream != nil {
 		var err error
-		if recordBatchSync, err = adaptStream(recordBatchPull); err != nil {
+		if recordBatchSync, err = adaptStream(recordBatchSync); err != nil {
 			return nil, err
 		}
 	}
@@ -197,6 +242,7 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 			RecordStream:                recordBatchPull,
 			Env:                         config.Env,
 			InternalVersion:             config.Version,
+			Bidirectional:               config.Bidirectional,
 		})
 	})
 
@@ -265,9 +311,12 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 			TableMappings:          options.TableMappings,
 			StagingPath:            config.CdcStagingPath,
 			Script:                 config.Script,
+			QueueEncoding:          config.QueueEncoding,
 			TableNameSchemaMapping: tableNameSchemaMapping,
 			Env:                    config.Env,
 			Version:                config.Version,
+			Bidirectional:          config.Bidirectional,
+			ConflictResolution:     config.ConflictResolution,
 		})
 		if err != nil {
 			return a.Alerter.LogFlowError(ctx, flowName, fmt.Errorf("failed to push records: %w", err))
@@ -321,6 +370,12 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 
 	a.Alerter.LogFlowInfo(ctx, flowName, fmt.Sprintf("stored %d records into intermediate storage for batch %d in %v",
 		res.NumRecordsSynced, res.CurrentSyncBatchID, syncDuration.Truncate(time.Second)))
+	if config.TruncatePolicy == protos.TruncatePolicy_TRUNCATE_POLICY_IGNORE {
+		for _, truncatedTable := range recordBatchSync.TruncatedTables {
+			a.Alerter.LogFlowWarning(ctx, flowName,
+				fmt.Errorf("ignored truncate of source table replicated to %s, destination rows were kept", truncatedTable))
+		}
+	}
 
 	a.OtelManager.Metrics.CurrentBatchIdGauge.Record(ctx, res.CurrentSyncBatchID)
 
@@ -661,6 +716,9 @@ func (a *FlowableActivity) startNormalize(
 		SyncedAtColName:        config.SyncedAtColName,
 		SyncBatchID:            batchID,
 		Version:                config.Version,
+		TruncatePolicy:         config.TruncatePolicy,
+		Bidirectional:          config.Bidirectional,
+		ConflictResolution:     config.ConflictResolution,
 	})
 	if err != nil {
 		return a.Alerter.LogFlowError(ctx, config.FlowJobName,
@@ -154,6 +154,18 @@ func (a *Alerter) registerSendersFromPool(ctx context.Context) ([]AlertSenderCon
 			}
 			alertSenderConfig.Sender = alertSender
 
+			return alertSenderConfig, nil
+		case WEBHOOK:
+			var webhookServiceConfig webhookAlertConfig
+			if err := json.Unmarshal(serviceConfig, &webhookServiceConfig); err != nil {
+				return alertSenderConfig, fmt.Errorf("failed to unmarshal %s service config: %w", serviceType, err)
+			}
+
+			alertSender, err := newWebhookAlertSender(&webhookServiceConfig)
+			if err != nil {
+				return alertSenderConfig, fmt.Errorf("failed to initialize webhook alerter: %w", err)
+			}
+			alertSenderConfig.Sender = alertSender
 			return alertSenderConfig, nil
 		default:
 			return alertSenderConfig, fmt.Errorf("unknown service type: %s", serviceType)
@@ -3,6 +3,7 @@ package alerting
 type ServiceType string
 
 const (
-	SLACK ServiceType = "slack"
-	EMAIL ServiceType = "email"
+	SLACK   ServiceType = "slack"
+	EMAIL   ServiceType = "email"
+	WEBHOOK ServiceType = "webhook"
 )
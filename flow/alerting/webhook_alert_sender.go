@@ -0,0 +1,136 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+const (
+	webhookSignatureHeader = "X-PeerDB-Signature-256"
+	webhookTimestampHeader = "X-PeerDB-Timestamp"
+	defaultWebhookTemplate = `{"title":{{json .Title}},"message":{{json .Message}},` +
+		`"deployment_uid":{{json .DeploymentUID}},"timestamp":{{json .Timestamp}}}`
+)
+
+type webhookAlertConfig struct {
+	Headers                       map[string]string `json:"headers"`
+	URL                           string            `json:"url"`
+	SigningSecret                 string            `json:"signing_secret"`
+	BodyTemplate                  string            `json:"body_template"`
+	SlotLagMBAlertThreshold       uint32            `json:"slot_lag_mb_alert_threshold"`
+	OpenConnectionsAlertThreshold uint32            `json:"open_connections_alert_threshold"`
+}
+
+// webhookAlertPayload is the data available to the body template
+type webhookAlertPayload struct {
+	Timestamp     time.Time
+	Title         string
+	Message       string
+	DeploymentUID string
+}
+
+type WebhookAlertSender struct {
+	AlertSender
+	client                        *http.Client
+	bodyTemplate                  *template.Template
+	headers                       map[string]string
+	url                           string
+	signingSecret                 []byte
+	slotLagMBAlertThreshold       uint32
+	openConnectionsAlertThreshold uint32
+}
+
+func (w *WebhookAlertSender) getSlotLagMBAlertThreshold() uint32 {
+	return w.slotLagMBAlertThreshold
+}
+
+func (w *WebhookAlertSender) getOpenConnectionsAlertThreshold() uint32 {
+	return w.openConnectionsAlertThreshold
+}
+
+func newWebhookAlertSender(config *webhookAlertConfig) (*WebhookAlertSender, error) {
+	if config.URL == "" {
+		return nil, errors.New("missing url for webhook alerting service")
+	}
+	bodyTemplate := config.BodyTemplate
+	if bodyTemplate == "" {
+		bodyTemplate = defaultWebhookTemplate
+	}
+	tmpl, err := template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(bodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webhook body template: %w", err)
+	}
+	return &WebhookAlertSender{
+		client:                        &http.Client{Timeout: 30 * time.Second},
+		url:                           config.URL,
+		headers:                       config.Headers,
+		signingSecret:                 []byte(config.SigningSecret),
+		bodyTemplate:                  tmpl,
+		slotLagMBAlertThreshold:       config.SlotLagMBAlertThreshold,
+		openConnectionsAlertThreshold: config.OpenConnectionsAlertThreshold,
+	}, nil
+}
+
+// sign computes the hex encoded HMAC-SHA256 of "<timestamp>.<body>", so receivers can reject replayed requests
+func (w *WebhookAlertSender) sign(timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, w.signingSecret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (w *WebhookAlertSender) sendAlert(ctx context.Context, alertTitle string, alertMessage string) error {
+	now := time.Now().UTC()
+	var body bytes.Buffer
+	if err := w.bodyTemplate.Execute(&body, webhookAlertPayload{
+		Title:         alertTitle,
+		Message:       alertMessage,
+		DeploymentUID: internal.PeerDBDeploymentUID(),
+		Timestamp:     now,
+	}); err != nil {
+		return fmt.Errorf("failed to render webhook body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range w.headers {
+		req.Header.Set(key, value)
+	}
+	if len(w.signingSecret) > 0 {
+		timestamp := fmt.Sprint(now.Unix())
+		req.Header.Set(webhookTimestampHeader, timestamp)
+		req.Header.Set(webhookSignatureHeader, w.sign(timestamp, body.Bytes()))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook alert: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook alert returned status %d: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package alerting
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookAlertSender(t *testing.T) {
+	var body []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header.Clone()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender, err := newWebhookAlertSender(&webhookAlertConfig{
+		URL:                     server.URL,
+		Headers:                 map[string]string{"Authorization": "Token abc"},
+		SigningSecret:           "secret",
+		SlotLagMBAlertThreshold: 100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint32(100), sender.getSlotLagMBAlertThreshold())
+	require.NoError(t, sender.sendAlert(t.Context(), "Slot Lag", "slot `s` is \"lagging\""))
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(body, &payload))
+	require.Equal(t, "Slot Lag", payload["title"])
+	require.Equal(t, "slot `s` is \"lagging\"", payload["message"])
+	require.Equal(t, "Token abc", header.Get("Authorization"))
+	require.Equal(t, sender.sign(header.Get(webhookTimestampHeader), body), header.Get(webhookSignatureHeader))
+}
+
+func TestWebhookAlertSenderTemplate(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	sender, err := newWebhookAlertSender(&webhookAlertConfig{
+		URL:          server.URL,
+		BodyTemplate: `{"summary":{{json .Title}},"severity":"critical"}`,
+	})
+	require.NoError(t, err)
+	require.NoError(t, sender.sendAlert(t.Context(), "Bad WAL Status", "lost"))
+	require.JSONEq(t, `{"summary":"Bad WAL Status","severity":"critical"}`, string(body))
+	require.Empty(t, sender.signingSecret)
+}
+
+func TestWebhookAlertSenderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	sender, err := newWebhookAlertSender(&webhookAlertConfig{URL: server.URL})
+	require.NoError(t, err)
+	require.ErrorContains(t, sender.sendAlert(t.Context(), "title", "message"), "status 403")
+
+	_, err = newWebhookAlertSender(&webhookAlertConfig{URL: server.URL, BodyTemplate: "{{"})
+	require.Error(t, err)
+	_, err = newWebhookAlertSender(&webhookAlertConfig{})
+	require.Error(t, err)
+}
@@ -339,6 +339,35 @@ func (h *FlowRequestHandler) InitialLoadSummary(
 
 		cloneStatuses = append(cloneStatuses, &res)
 	}
+
+	// tables resynced or added later are snapshotted incrementally by cdc
+	snapshotRows, err := h.pool.Query(ctx,
+		`SELECT id, source_table, destination_table, rows_synced, start_time, end_time IS NOT NULL
+		FROM metadata_incremental_snapshots WHERE job_name = $1 ORDER BY id`, parentMirrorName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query incremental snapshots - %s: %w", parentMirrorName, err)
+	}
+	var snapshotID int64
+	var snapshotStartTime time.Time
+	var completed bool
+	if _, err := pgx.ForEachRow(snapshotRows, []any{
+		&snapshotID, &sourceTable, &destinationTable, &numRowsSynced, &snapshotStartTime, &completed,
+	}, func() error {
+		cloneStatuses = append(cloneStatuses, &protos.CloneTableSummary{
+			TableName:            destinationTable.String,
+			SourceTable:          sourceTable.String,
+			StartTime:            timestamppb.New(snapshotStartTime),
+			NumRowsSynced:        numRowsSynced.Int64,
+			FlowJobName:          fmt.Sprintf("%s-incremental-snapshot-%d", parentMirrorName, snapshotID),
+			FetchCompleted:       completed,
+			ConsolidateCompleted: completed,
+			MirrorName:           parentMirrorName,
+		})
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("unable to scan incremental snapshots - %s: %w", parentMirrorName, err)
+	}
+
 	return &protos.InitialLoadSummaryResponse{
 		TableSummaries: cloneStatuses,
 	}, nil
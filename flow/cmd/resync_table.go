@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// ResyncTable copies a table of a running cdc mirror again, or the rows matching a filter or within primary key bounds,
+// through an incremental snapshot upserted into the existing destination table while cdc goes on.
+// Rows deleted from the source outside of cdc are only removed when the whole table is resynced with
+// truncate_destination, which truncates the destination table first following the truncate policy of the mirror
+func (h *FlowRequestHandler) ResyncTable(
+	ctx context.Context, req *protos.ResyncTableRequest,
+) (*protos.ResyncTableResponse, error) {
+	if req.FlowJobName == "" {
+		return nil, errors.New("mirror name cannot be empty")
+	}
+	if req.SourceTableIdentifier == "" {
+		return nil, errors.New("source table cannot be empty")
+	}
+	logger := slog.With(slog.String(string(shared.FlowNameKey), req.FlowJobName),
+		slog.String("table", req.SourceTableIdentifier))
+
+	isCdc, err := h.isCDCFlow(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	if !isCdc {
+		return nil, fmt.Errorf("mirror %s is not a cdc mirror", req.FlowJobName)
+	}
+	config, err := h.getFlowConfigFromCatalog(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	if config.InitialSnapshotOnly {
+		return nil, fmt.Errorf("mirror %s is initial load only, tables can only be resynced through cdc", req.FlowJobName)
+	}
+
+	var tableMapping *protos.TableMapping
+	for _, tm := range config.TableMappings {
+		if tm.SourceTableIdentifier == req.SourceTableIdentifier {
+			tableMapping = tm
+			break
+		}
+	}
+	if tableMapping == nil {
+		return nil, fmt.Errorf("table %s is not part of mirror %s", req.SourceTableIdentifier, req.FlowJobName)
+	}
+
+	tableSchema, err := internal.LoadTableSchemaFromCatalog(ctx, h.pool, req.FlowJobName, tableMapping.DestinationTableIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load schema of table %s: %w", req.SourceTableIdentifier, err)
+	}
+	if len(tableSchema.PrimaryKeyColumns) == 0 {
+		return nil, fmt.Errorf("table %s needs a primary key to be resynced", req.SourceTableIdentifier)
+	}
+
+	// each filter is parsed on its own before being combined, so one cannot close the parentheses of the other
+	filter, err := utils.AndRowFilters(tableMapping.RowFilter, req.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+
+	if req.TruncateDestination {
+		if req.Filter != "" || len(req.StartPrimaryKey) > 0 || len(req.EndPrimaryKey) > 0 {
+			return nil, errors.New("truncating the destination table needs the whole table to be resynced, without a filter or bounds")
+		}
+		if config.TruncatePolicy == protos.TruncatePolicy_TRUNCATE_POLICY_IGNORE {
+			return nil, fmt.Errorf("mirror %s ignores truncates, set its truncate policy to truncate the destination table",
+				req.FlowJobName)
+		}
+		dstType, err := connectors.LoadPeerType(ctx, h.pool, config.DestinationName)
+		if err != nil {
+			return nil, err
+		}
+		switch dstType {
+		case protos.DBType_POSTGRES, protos.DBType_SNOWFLAKE, protos.DBType_BIGQUERY, protos.DBType_CLICKHOUSE:
+		default:
+			return nil, fmt.Errorf("truncates are not applied to destination %s", config.DestinationName)
+		}
+	}
+
+	srcConn, err := connectors.GetByNameAs[connectors.IncrementalSnapshotConnector](ctx, config.Env, h.pool, config.SourceName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil, fmt.Errorf("resyncing a table is not supported for source %s", config.SourceName)
+		}
+		return nil, fmt.Errorf("failed to get source connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+
+	snapshot := &utils.IncrementalSnapshot{
+		SourceTable:         tableMapping.SourceTableIdentifier,
+		DestinationTable:    tableMapping.DestinationTableIdentifier,
+		Filter:              filter,
+		StartPK:             req.StartPrimaryKey,
+		EndPK:               req.EndPrimaryKey,
+		TruncateDestination: req.TruncateDestination,
+	}
+	// runs the chunk query, rendering the filter for the source and binding the bounds with the types of their columns
+	if err := srcConn.ValidateIncrementalSnapshot(ctx, snapshot, tableSchema); err != nil {
+		return nil, err
+	}
+
+	pending, err := utils.GetIncrementalSnapshots(ctx, h.pool, req.FlowJobName, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range pending {
+		if snapshot.DestinationTable == tableMapping.DestinationTableIdentifier {
+			return nil, fmt.Errorf("table %s is already being resynced", req.SourceTableIdentifier)
+		}
+	}
+
+	snapshotID, err := utils.AddIncrementalSnapshot(ctx, h.pool, req.FlowJobName, snapshot)
+	if err != nil {
+		logger.Error("unable to resync table", slog.Any("error", err))
+		return nil, err
+	}
+
+	logger.Info("table resync started", slog.Int64("snapshot_id", snapshotID))
+	return &protos.ResyncTableResponse{SnapshotId: snapshotID}, nil
+}
@@ -10,8 +10,11 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors"
+	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
 	"github.com/PeerDB-io/peerdb/flow/shared/telemetry"
 )
@@ -81,6 +84,17 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 		return nil, fmt.Errorf("failed to validate source connector %s: %w", req.ConnectionConfigs.SourceName, err)
 	}
 
+	srcType, err := connectors.LoadPeerType(ctx, h.pool, req.ConnectionConfigs.SourceName)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRowFilters(ctx, srcType, srcConn, req.ConnectionConfigs); err != nil {
+		return nil, err
+	}
+	if err := validateConflictHandling(ctx, h.pool, srcType, req.ConnectionConfigs); err != nil {
+		return nil, err
+	}
+
 	dstConn, err := connectors.GetByNameAs[connectors.MirrorDestinationValidationConnector](
 		ctx, req.ConnectionConfigs.Env, h.pool, req.ConnectionConfigs.DestinationName,
 	)
@@ -121,3 +135,59 @@ func (h *FlowRequestHandler) CheckIfMirrorNameExists(ctx context.Context, mirror
 
 	return nameExists.Bool, nil
 }
+
+// validateRowFilters checks that row filters parse on every source, that sources filtering rows in their queries
+// can run them, and that a custom Postgres publication filters tables with the same predicates
+func validateRowFilters(
+	ctx context.Context, srcType protos.DBType, srcConn connectors.MirrorSourceValidationConnector, cfg *protos.FlowConnectionConfigs,
+) error {
+	predicates := make(map[string]string)
+	for _, tm := range cfg.TableMappings {
+		if tm.RowFilter == "" {
+			continue
+		}
+		rowFilter, err := utils.ParseRowFilter(tm.RowFilter)
+		if err != nil {
+			return fmt.Errorf("invalid row filter for table %s: %w", tm.SourceTableIdentifier, err)
+		}
+		switch srcType {
+		case protos.DBType_POSTGRES, protos.DBType_MYSQL, protos.DBType_SQLSERVER:
+			if _, err := rowFilter.SQL(srcType); err != nil {
+				return fmt.Errorf("invalid row filter for table %s: %w", tm.SourceTableIdentifier, err)
+			}
+		}
+		predicates[tm.SourceTableIdentifier] = tm.RowFilter
+	}
+	if pgConn, ok := srcConn.(*connpostgres.PostgresConnector); ok {
+		if err := pgConn.ValidateRowFilters(ctx, predicates); err != nil {
+			return err
+		}
+		if cfg.PublicationName != "" {
+			if _, err := pgConn.RowFilteredTables(ctx, cfg.FlowJobName, cfg.PublicationName, predicates); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateConflictHandling checks that bidirectional mirrors and last writer wins have the Postgres peers they need
+func validateConflictHandling(
+	ctx context.Context, pool shared.CatalogPool, srcType protos.DBType, cfg *protos.FlowConnectionConfigs,
+) error {
+	lastWriterWins := cfg.ConflictResolution == protos.ConflictResolution_CONFLICT_RESOLUTION_LAST_WRITER_WINS
+	if !cfg.Bidirectional && !lastWriterWins {
+		return nil
+	}
+	dstType, err := connectors.LoadPeerType(ctx, pool, cfg.DestinationName)
+	if err != nil {
+		return err
+	}
+	if cfg.Bidirectional && (srcType != protos.DBType_POSTGRES || dstType != protos.DBType_POSTGRES) {
+		return errors.New("bidirectional mirrors need Postgres source and destination")
+	}
+	if lastWriterWins && dstType != protos.DBType_POSTGRES {
+		return errors.New("last writer wins conflict resolution needs a Postgres destination")
+	}
+	return nil
+}
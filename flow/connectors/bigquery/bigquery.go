@@ -21,6 +21,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 const (
@@ -198,7 +199,7 @@ func (c *BigQueryConnector) waitForTableReady(ctx context.Context, datasetTable
 }
 
 // ReplayTableSchemaDeltas changes a destination table to match the schema at source
-// This could involve adding or dropping multiple columns.
+// This could involve adding, dropping or retyping multiple columns.
 func (c *BigQueryConnector) ReplayTableSchemaDeltas(
 	ctx context.Context,
 	env map[string]string,
@@ -207,7 +208,8 @@ func (c *BigQueryConnector) ReplayTableSchemaDeltas(
 	schemaDeltas []*protos.TableSchemaDelta,
 ) error {
 	for _, schemaDelta := range schemaDeltas {
-		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+		if schemaDelta == nil ||
+			(len(schemaDelta.AddedColumns) == 0 && len(schemaDelta.DroppedColumns) == 0 && len(schemaDelta.AlteredColumns) == 0) {
 			continue
 		}
 
@@ -246,8 +248,109 @@ func (c *BigQueryConnector) ReplayTableSchemaDeltas(
 			c.logger.Info(fmt.Sprintf("[schema delta replay] added column %s with data type %s to table %s",
 				addedColumn.Name, addedColumnBigQueryType, schemaDelta.DstTableName))
 		}
+
+		if len(schemaDelta.DroppedColumns) == 0 && len(schemaDelta.AlteredColumns) == 0 {
+			continue
+		}
+		if err := c.replayDroppedAndAlteredColumns(ctx, env, schemaDelta); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replayDroppedAndAlteredColumns applies the dropped column policy to columns dropped or retyped at source.
+// BigQuery cannot change most column types in place, so widened values are copied through a temporary column.
+func (c *BigQueryConnector) replayDroppedAndAlteredColumns(
+	ctx context.Context,
+	env map[string]string,
+	schemaDelta *protos.TableSchemaDelta,
+) error {
+	policy := schemaDelta.DroppedColumnPolicy
+	dstDatasetTable, err := c.convertToDatasetTable(schemaDelta.DstTableName)
+	if err != nil {
+		return err
+	}
+	runQueries := func(stmts ...string) error {
+		for _, stmt := range stmts {
+			query := c.queryWithLogging(stmt)
+			query.DefaultProjectID = c.projectID
+			query.DefaultDatasetID = dstDatasetTable.dataset
+			if _, err := query.Read(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, droppedColumn := range schemaDelta.DroppedColumns {
+		if err := runQueries(droppedColumnStmts(dstDatasetTable.table, droppedColumn.Name, policy)...); err != nil {
+			return fmt.Errorf("failed to replay dropped column %s for table %s: %w", droppedColumn.Name,
+				schemaDelta.DstTableName, err)
+		}
 	}
 
+	for _, alteredColumn := range schemaDelta.AlteredColumns {
+		previousType := qValueKindToBigQueryTypeString(alteredColumn.Previous, false, false)
+		currentType := qValueKindToBigQueryTypeString(alteredColumn.Current, false, false)
+		if previousType == currentType {
+			continue
+		}
+
+		columnName := alteredColumn.Current.Name
+		stmts := alteredColumnStmts(dstDatasetTable.table, alteredColumn, currentType, policy)
+		if len(stmts) == 0 {
+			c.logger.Warn(fmt.Sprintf("[schema delta replay] column %s in table %s changed type from %s to %s incompatibly, not propagating",
+				columnName, schemaDelta.DstTableName, previousType, currentType))
+			continue
+		}
+		if err := runQueries(stmts...); err != nil {
+			return fmt.Errorf("failed to alter column %s for table %s: %w", columnName, schemaDelta.DstTableName, err)
+		}
+		c.logger.Info(fmt.Sprintf("[schema delta replay] altered column %s from data type %s to %s in table %s",
+			columnName, previousType, currentType, schemaDelta.DstTableName))
+	}
+	return nil
+}
+
+// droppedColumnStmts returns the statements applying policy to a column dropped at source
+func droppedColumnStmts(table string, columnName string, policy protos.DroppedColumnPolicy) []string {
+	switch policy {
+	case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL:
+		return []string{
+			fmt.Sprintf("ALTER TABLE `%s` ALTER COLUMN `%s` DROP NOT NULL", table, columnName),
+			fmt.Sprintf("UPDATE `%s` SET `%s` = NULL WHERE TRUE", table, columnName),
+		}
+	case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP:
+		return []string{fmt.Sprintf("ALTER TABLE `%s` DROP COLUMN IF EXISTS `%s`", table, columnName)}
+	default:
+		return nil
+	}
+}
+
+// alteredColumnStmts returns the statements retyping a column to currentType, none if it is kept as is
+func alteredColumnStmts(
+	table string,
+	alteredColumn *protos.AlteredColumn,
+	currentType string,
+	policy protos.DroppedColumnPolicy,
+) []string {
+	columnName := alteredColumn.Current.Name
+	tmpColumnName := columnName + "_peerdb_retype"
+	if types.QValueKind(alteredColumn.Previous.Type).CanWidenTo(types.QValueKind(alteredColumn.Current.Type)) {
+		return []string{
+			fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s", table, tmpColumnName, currentType),
+			fmt.Sprintf("UPDATE `%s` SET `%s` = CAST(`%s` AS %s) WHERE TRUE", table, tmpColumnName, columnName, currentType),
+			fmt.Sprintf("ALTER TABLE `%s` DROP COLUMN `%s`", table, columnName),
+			fmt.Sprintf("ALTER TABLE `%s` RENAME COLUMN `%s` TO `%s`", table, tmpColumnName, columnName),
+		}
+	} else if policy != protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_KEEP {
+		return []string{
+			fmt.Sprintf("ALTER TABLE `%s` DROP COLUMN IF EXISTS `%s`", table, columnName),
+			fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s", table, columnName, currentType),
+		}
+	}
 	return nil
 }
 
@@ -296,6 +399,45 @@ func (c *BigQueryConnector) getDistinctTableNamesInBatch(
 	return distinctTableNames, nil
 }
 
+// getTruncatedTablesInBatch returns the time of the latest truncate of each table truncated in the batch
+func (c *BigQueryConnector) getTruncatedTablesInBatch(
+	ctx context.Context,
+	rawTableName string,
+	batchId int64,
+) (map[string]int64, error) {
+	query := fmt.Sprintf(`SELECT _peerdb_destination_table_name, MAX(_peerdb_timestamp) FROM %s
+	 WHERE _peerdb_batch_id = %d AND _peerdb_record_type = 3 GROUP BY _peerdb_destination_table_name`,
+		rawTableName, batchId)
+	q := c.client.Query(query)
+	q.DefaultProjectID = c.projectID
+	q.DefaultDatasetID = c.datasetID
+	it, err := q.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run query %s on BigQuery:\n %w", query, err)
+	}
+
+	truncatedTables := make(map[string]int64)
+	for {
+		var row []bigquery.Value
+		err := it.Next(&row)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(row) > 1 {
+			tableName, ok := row[0].(string)
+			truncatedAt, ok2 := row[1].(int64)
+			if !ok || !ok2 {
+				return nil, fmt.Errorf("unexpected truncate row %v", row)
+			}
+			truncatedTables[tableName] = truncatedAt
+		}
+	}
+	return truncatedTables, nil
+}
+
 func (c *BigQueryConnector) getTableNametoUnchangedCols(
 	ctx context.Context,
 	flowJobName string,
@@ -418,6 +560,7 @@ func (c *BigQueryConnector) NormalizeRecords(ctx context.Context, req *model.Nor
 		if err := c.mergeTablesInThisBatch(ctx, batchId,
 			req.FlowJobName, rawTableName, req.TableNameSchemaMapping, unchangedToastMergeChunking,
 			&protos.PeerDBColumns{SoftDeleteColName: req.SoftDeleteColName, SyncedAtColName: req.SyncedAtColName},
+			req.TruncatePolicy,
 		); err != nil {
 			return model.NormalizeResponse{}, err
 		}
@@ -451,6 +594,7 @@ func (c *BigQueryConnector) mergeTablesInThisBatch(
 	tableToSchema map[string]*protos.TableSchema,
 	unchangedToastMergeChunking uint32,
 	peerdbColumns *protos.PeerDBColumns,
+	truncatePolicy protos.TruncatePolicy,
 ) error {
 	tableNames, err := c.getDistinctTableNamesInBatch(
 		ctx,
@@ -471,6 +615,11 @@ func (c *BigQueryConnector) mergeTablesInThisBatch(
 		return fmt.Errorf("couldn't get tablename to unchanged cols mapping: %w", err)
 	}
 
+	truncatedTables, err := c.getTruncatedTablesInBatch(ctx, rawTableName, batchId)
+	if err != nil {
+		return fmt.Errorf("couldn't get truncated tables in batch: %w", err)
+	}
+
 	mergeGen := &mergeStmtGenerator{
 		rawDatasetTable: datasetTable{
 			project: c.projectID,
@@ -490,6 +639,13 @@ func (c *BigQueryConnector) mergeTablesInThisBatch(
 			return err
 		}
 
+		if truncatedAt, ok := truncatedTables[tableName]; ok {
+			if err := c.normalizeTruncate(ctx, batchId, rawTableName, tableName, dstDatasetTable,
+				truncatedAt, truncatePolicy, peerdbColumns); err != nil {
+				return err
+			}
+		}
+
 		// normalize anything between last normalized batch id to last sync batchid
 		if len(unchangedToastColumns) == 0 {
 			c.logger.Info("running single merge statement", slog.String("table", tableName))
@@ -518,6 +674,58 @@ func (c *BigQueryConnector) mergeTablesInThisBatch(
 	return nil
 }
 
+// normalizeTruncate handles the latest truncate of a table in the batch according to the truncate policy,
+// changes from before an applied truncate are dropped from the raw table so they are not merged
+func (c *BigQueryConnector) normalizeTruncate(
+	ctx context.Context,
+	batchId int64,
+	rawTableName string,
+	tableName string,
+	dstDatasetTable datasetTable,
+	truncatedAt int64,
+	truncatePolicy protos.TruncatePolicy,
+	peerdbColumns *protos.PeerDBColumns,
+) error {
+	runQuery := func(query string) (*bigquery.RowIterator, error) {
+		q := c.queryWithLogging(query)
+		q.DefaultProjectID = c.projectID
+		q.DefaultDatasetID = c.datasetID
+		return q.Read(ctx)
+	}
+
+	switch truncatePolicy {
+	case protos.TruncatePolicy_TRUNCATE_POLICY_APPLY:
+		if _, err := runQuery(fmt.Sprintf("TRUNCATE TABLE `%s`", dstDatasetTable.string())); err != nil {
+			return fmt.Errorf("failed to truncate table %s: %w", tableName, err)
+		}
+	case protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE:
+		if peerdbColumns.SoftDeleteColName == "" {
+			return fmt.Errorf("cannot soft delete rows of truncated table %s without a soft delete column", tableName)
+		}
+		softDeleteUpdate := fmt.Sprintf("`%s`=TRUE", peerdbColumns.SoftDeleteColName)
+		if peerdbColumns.SyncedAtColName != "" {
+			softDeleteUpdate += fmt.Sprintf(",`%s`=CURRENT_TIMESTAMP", peerdbColumns.SyncedAtColName)
+		}
+		if _, err := runQuery(fmt.Sprintf("UPDATE `%s` SET %s WHERE TRUE", dstDatasetTable.string(), softDeleteUpdate)); err != nil {
+			return fmt.Errorf("failed to soft delete rows of truncated table %s: %w", tableName, err)
+		}
+	default:
+		if _, err := runQuery(fmt.Sprintf("DELETE FROM `%s`"+
+			" WHERE _peerdb_batch_id=%d AND _peerdb_destination_table_name='%s' AND _peerdb_record_type=3",
+			rawTableName, batchId, tableName)); err != nil {
+			return fmt.Errorf("failed to remove ignored truncates of table %s: %w", tableName, err)
+		}
+		return nil
+	}
+
+	if _, err := runQuery(fmt.Sprintf("DELETE FROM `%s`"+
+		" WHERE _peerdb_batch_id=%d AND _peerdb_destination_table_name='%s' AND _peerdb_timestamp<=%d",
+		rawTableName, batchId, tableName, truncatedAt)); err != nil {
+		return fmt.Errorf("failed to remove truncated changes of table %s: %w", tableName, err)
+	}
+	return nil
+}
+
 // CreateRawTable creates a raw table, implementing the Connector interface.
 // create a table with the following schema
 // _peerdb_uid STRING
@@ -0,0 +1,48 @@
+package connbigquery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestDroppedColumnStmts(t *testing.T) {
+	require.Empty(t, droppedColumnStmts("t", "c", protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_KEEP))
+	require.Equal(t, []string{
+		"ALTER TABLE `t` ALTER COLUMN `c` DROP NOT NULL",
+		"UPDATE `t` SET `c` = NULL WHERE TRUE",
+	}, droppedColumnStmts("t", "c", protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL))
+	require.Equal(t, []string{"ALTER TABLE `t` DROP COLUMN IF EXISTS `c`"},
+		droppedColumnStmts("t", "c", protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP))
+}
+
+func TestAlteredColumnStmts(t *testing.T) {
+	widened := &protos.AlteredColumn{
+		Previous: &protos.FieldDescription{Name: "c", Type: string(types.QValueKindInt64)},
+		Current:  &protos.FieldDescription{Name: "c", Type: string(types.QValueKindNumeric)},
+	}
+	// widening is applied whatever the policy
+	require.Equal(t, []string{
+		"ALTER TABLE `t` ADD COLUMN `c_peerdb_retype` BIGNUMERIC",
+		"UPDATE `t` SET `c_peerdb_retype` = CAST(`c` AS BIGNUMERIC) WHERE TRUE",
+		"ALTER TABLE `t` DROP COLUMN `c`",
+		"ALTER TABLE `t` RENAME COLUMN `c_peerdb_retype` TO `c`",
+	}, alteredColumnStmts("t", widened, "BIGNUMERIC", protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_KEEP))
+
+	retyped := &protos.AlteredColumn{
+		Previous: &protos.FieldDescription{Name: "c", Type: string(types.QValueKindString)},
+		Current:  &protos.FieldDescription{Name: "c", Type: string(types.QValueKindBoolean)},
+	}
+	require.Empty(t, alteredColumnStmts("t", retyped, "BOOL", protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_KEEP))
+	for _, policy := range []protos.DroppedColumnPolicy{
+		protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL, protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP,
+	} {
+		require.Equal(t, []string{
+			"ALTER TABLE `t` DROP COLUMN IF EXISTS `c`",
+			"ALTER TABLE `t` ADD COLUMN `c` BOOL",
+		}, alteredColumnStmts("t", retyped, "BOOL", policy))
+	}
+}
@@ -8,6 +8,7 @@ import (
 	chproto "github.com/ClickHouse/ch-go/proto"
 	"github.com/ClickHouse/clickhouse-go/v2"
 	_ "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
@@ -172,7 +173,8 @@ func (c *ClickHouseConnector) ReplayTableSchemaDeltas(
 
 	onCluster := c.onCluster()
 	for _, schemaDelta := range schemaDeltas {
-		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+		if schemaDelta == nil ||
+			(len(schemaDelta.AddedColumns) == 0 && len(schemaDelta.DroppedColumns) == 0 && len(schemaDelta.AlteredColumns) == 0) {
 			continue
 		}
 
@@ -218,8 +220,183 @@ func (c *ClickHouseConnector) ReplayTableSchemaDeltas(
 				slog.String("destination table name", schemaDelta.DstTableName), slog.String("source table name", schemaDelta.SrcTableName),
 			)
 		}
+
+		if len(schemaDelta.DroppedColumns) == 0 && len(schemaDelta.AlteredColumns) == 0 {
+			continue
+		}
+		policy := schemaDelta.DroppedColumnPolicy
+
+		for _, droppedColumn := range schemaDelta.DroppedColumns {
+			if err := c.replayDroppedColumn(ctx, env, schemaDelta, tm, droppedColumn, policy); err != nil {
+				return fmt.Errorf("failed to replay dropped column %s for table %s: %w", droppedColumn.Name, schemaDelta.DstTableName, err)
+			}
+		}
+
+		for _, alteredColumn := range schemaDelta.AlteredColumns {
+			if err := c.replayAlteredColumn(ctx, env, schemaDelta, tm, alteredColumn, policy); err != nil {
+				return fmt.Errorf("failed to alter column %s for table %s: %w",
+					alteredColumn.Current.Name, schemaDelta.DstTableName, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// alterClause is a clause of ALTER TABLE, mutations rewrite rows of existing parts
+type alterClause struct {
+	clause   string
+	mutation bool
+}
+
+// alterTableAndShards runs ALTER TABLE clauses against a destination table and, on clusters, its shard tables.
+func (c *ClickHouseConnector) alterTableAndShards(
+	ctx context.Context,
+	tableName string,
+	tm *protos.TableMapping,
+	clauses []alterClause,
+) error {
+	for _, clause := range clauses {
+		for _, stmt := range c.alterTableAndShardsStmts(tableName, tm, clause) {
+			if err := c.execWithLogging(ctx, stmt); err != nil {
+				return err
+			}
+		}
 	}
+	return nil
+}
 
+// alterTableAndShardsStmts returns the statements applying an ALTER TABLE clause.
+// Mutations only apply to tables holding data, so they skip the distributed table and Null engine tables.
+func (c *ClickHouseConnector) alterTableAndShardsStmts(tableName string, tm *protos.TableMapping, clause alterClause) []string {
+	onCluster := c.onCluster()
+	isNullEngine := tm != nil && tm.Engine == protos.TableEngine_CH_ENGINE_NULL
+	var stmts []string
+	if c.config.Cluster != "" && !isNullEngine {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s%s %s",
+			peerdb_clickhouse.QuoteIdentifier(tableName+"_shard"), onCluster, clause.clause))
+	}
+	if clause.mutation && (c.config.Cluster != "" || isNullEngine) {
+		return stmts
+	}
+	return append(stmts, fmt.Sprintf("ALTER TABLE %s%s %s",
+		peerdb_clickhouse.QuoteIdentifier(tableName), onCluster, clause.clause))
+}
+
+func (c *ClickHouseConnector) replayDroppedColumn(
+	ctx context.Context,
+	env map[string]string,
+	schemaDelta *protos.TableSchemaDelta,
+	tm *protos.TableMapping,
+	droppedColumn *protos.FieldDescription,
+	policy protos.DroppedColumnPolicy,
+) error {
+	clauses, err := c.droppedColumnClauses(ctx, env, droppedColumn, policy)
+	if err != nil {
+		return err
+	}
+	if len(clauses) == 0 {
+		return nil
+	}
+	if err := c.alterTableAndShards(ctx, schemaDelta.DstTableName, tm, clauses); err != nil {
+		return err
+	}
+	c.logger.Info("[schema delta replay] replayed dropped column",
+		slog.String("column", droppedColumn.Name), slog.String("policy", policy.String()),
+		slog.String("destination table name", schemaDelta.DstTableName), slog.String("source table name", schemaDelta.SrcTableName))
+	return nil
+}
+
+// droppedColumnClauses returns the clauses applying policy to a column dropped at source
+func (c *ClickHouseConnector) droppedColumnClauses(
+	ctx context.Context,
+	env map[string]string,
+	droppedColumn *protos.FieldDescription,
+	policy protos.DroppedColumnPolicy,
+) ([]alterClause, error) {
+	columnName := peerdb_clickhouse.QuoteIdentifier(droppedColumn.Name)
+	switch policy {
+	case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL:
+		qvKind := types.QValueKind(droppedColumn.Type)
+		if qvKind.IsArray() {
+			c.logger.Warn("[schema delta replay] array columns cannot be nulled, keeping dropped column",
+				slog.String("column", droppedColumn.Name))
+			return nil, nil
+		}
+		nullableColumn := proto.CloneOf(droppedColumn)
+		nullableColumn.Nullable = true
+		clickHouseColType, err := qvalue.ToDWHColumnType(
+			ctx, qvKind, env, protos.DBType_CLICKHOUSE, c.chVersion, nullableColumn, true,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert column type %s to ClickHouse type: %w", droppedColumn.Type, err)
+		}
+		return []alterClause{
+			{clause: fmt.Sprintf("MODIFY COLUMN %s %s", columnName, clickHouseColType)},
+			{clause: fmt.Sprintf("UPDATE %s = NULL WHERE 1", columnName), mutation: true},
+		}, nil
+	case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP:
+		return []alterClause{{clause: "DROP COLUMN IF EXISTS " + columnName}}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (c *ClickHouseConnector) replayAlteredColumn(
+	ctx context.Context,
+	env map[string]string,
+	schemaDelta *protos.TableSchemaDelta,
+	tm *protos.TableMapping,
+	alteredColumn *protos.AlteredColumn,
+	policy protos.DroppedColumnPolicy,
+) error {
+	previousKind := types.QValueKind(alteredColumn.Previous.Type)
+	currentKind := types.QValueKind(alteredColumn.Current.Type)
+	previousType, err := qvalue.ToDWHColumnType(
+		ctx, previousKind, env, protos.DBType_CLICKHOUSE, c.chVersion, alteredColumn.Previous, schemaDelta.NullableEnabled,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to convert column type %s to ClickHouse type: %w", alteredColumn.Previous.Type, err)
+	}
+	currentType, err := qvalue.ToDWHColumnType(
+		ctx, currentKind, env, protos.DBType_CLICKHOUSE, c.chVersion, alteredColumn.Current, schemaDelta.NullableEnabled,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to convert column type %s to ClickHouse type: %w", alteredColumn.Current.Type, err)
+	}
+	if previousType == currentType {
+		return nil
+	}
+
+	clauses := alteredColumnClauses(alteredColumn, currentType, policy)
+	if len(clauses) == 0 {
+		c.logger.Warn("[schema delta replay] column type changed incompatibly, not propagating",
+			slog.String("column", alteredColumn.Current.Name),
+			slog.String("previous type", previousType), slog.String("type", currentType),
+			slog.String("destination table name", schemaDelta.DstTableName), slog.String("source table name", schemaDelta.SrcTableName))
+		return nil
+	}
+	if err := c.alterTableAndShards(ctx, schemaDelta.DstTableName, tm, clauses); err != nil {
+		return err
+	}
+	c.logger.Info("[schema delta replay] altered column",
+		slog.String("column", alteredColumn.Current.Name),
+		slog.String("previous type", previousType), slog.String("type", currentType),
+		slog.String("destination table name", schemaDelta.DstTableName), slog.String("source table name", schemaDelta.SrcTableName))
+	return nil
+}
+
+// alteredColumnClauses returns the clauses retyping a column to currentType, none if it is kept as is
+func alteredColumnClauses(alteredColumn *protos.AlteredColumn, currentType string, policy protos.DroppedColumnPolicy) []alterClause {
+	columnName := peerdb_clickhouse.QuoteIdentifier(alteredColumn.Current.Name)
+	if types.QValueKind(alteredColumn.Previous.Type).CanWidenTo(types.QValueKind(alteredColumn.Current.Type)) {
+		return []alterClause{{clause: fmt.Sprintf("MODIFY COLUMN %s %s", columnName, currentType)}}
+	} else if policy != protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_KEEP {
+		return []alterClause{
+			{clause: "DROP COLUMN IF EXISTS " + columnName},
+			{clause: fmt.Sprintf("ADD COLUMN %s %s", columnName, currentType)},
+		}
+	}
 	return nil
 }
 
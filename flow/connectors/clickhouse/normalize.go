@@ -439,6 +439,12 @@ func (c *ClickHouseConnector) NormalizeRecords(
 			continue
 		}
 
+		truncatedAt, err := c.normalizeTruncate(ctx, req, tbl, batchIdToLoadForTable)
+		if err != nil {
+			close(queries)
+			return model.NormalizeResponse{}, err
+		}
+
 		for numPart := range numParts {
 			queryGenerator := NewNormalizeQueryGenerator(
 				tbl,
@@ -454,6 +460,7 @@ func (c *ClickHouseConnector) NormalizeRecords(
 				rawTbl,
 				c.chVersion,
 				c.config.Cluster != "",
+				truncatedAt,
 			)
 			insertIntoSelectQuery, err := queryGenerator.BuildQuery(ctx)
 			if err != nil {
@@ -497,6 +504,61 @@ func (c *ClickHouseConnector) NormalizeRecords(
 	}, nil
 }
 
+// normalizeTruncate handles the latest truncate of a table in the batch range according to the truncate policy,
+// returning the timestamp of the applied truncate so only later changes are inserted, or 0 if there is none
+func (c *ClickHouseConnector) normalizeTruncate(
+	ctx context.Context,
+	req *model.NormalizeRecordsRequest,
+	tbl string,
+	batchIdToLoadForTable int64,
+) (int64, error) {
+	if req.TruncatePolicy == protos.TruncatePolicy_TRUNCATE_POLICY_IGNORE {
+		return 0, nil
+	}
+
+	var truncatedAt int64
+	if err := c.queryRow(ctx, fmt.Sprintf(
+		"SELECT max(_peerdb_timestamp) FROM %s WHERE _peerdb_batch_id>%d AND _peerdb_batch_id<=%d"+
+			" AND _peerdb_destination_table_name=%s AND _peerdb_record_type=3",
+		peerdb_clickhouse.QuoteIdentifier(c.GetRawTableName(req.FlowJobName)), batchIdToLoadForTable, req.SyncBatchID,
+		peerdb_clickhouse.QuoteLiteral(tbl)),
+	).Scan(&truncatedAt); err != nil {
+		return 0, fmt.Errorf("error while checking truncates of table %s: %w", tbl, err)
+	}
+	if truncatedAt == 0 {
+		return 0, nil
+	}
+
+	for _, tm := range req.TableMappings {
+		if tm.DestinationTableIdentifier == tbl && tm.Engine == protos.TableEngine_CH_ENGINE_NULL {
+			return truncatedAt, nil
+		}
+	}
+
+	switch req.TruncatePolicy {
+	case protos.TruncatePolicy_TRUNCATE_POLICY_APPLY:
+		truncateTable := tbl
+		if c.config.Cluster != "" {
+			truncateTable += "_shard"
+		}
+		if err := c.execWithLogging(ctx,
+			fmt.Sprintf("TRUNCATE TABLE %s%s", peerdb_clickhouse.QuoteIdentifier(truncateTable), c.onCluster()),
+		); err != nil {
+			return 0, fmt.Errorf("error while truncating table %s: %w", tbl, err)
+		}
+	case protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE:
+		// versioned before the changes which followed the truncate, so those replace the deleted rows
+		if err := c.execWithLogging(ctx, fmt.Sprintf(
+			"INSERT INTO %[1]s SELECT * REPLACE (1 AS %[2]s, %[3]d AS %[4]s) FROM %[1]s FINAL WHERE %[2]s = 0",
+			peerdb_clickhouse.QuoteIdentifier(tbl), peerdb_clickhouse.QuoteIdentifier(signColName),
+			truncatedAt, peerdb_clickhouse.QuoteIdentifier(versionColName)),
+		); err != nil {
+			return 0, fmt.Errorf("error while soft deleting rows of truncated table %s: %w", tbl, err)
+		}
+	}
+	return truncatedAt, nil
+}
+
 func (c *ClickHouseConnector) getDistinctTableNamesInBatch(
 	ctx context.Context,
 	flowJobName string,
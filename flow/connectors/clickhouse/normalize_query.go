@@ -26,6 +26,7 @@ type NormalizeQueryGenerator struct {
 	batchIDToLoadForTable           int64
 	numParts                        uint64
 	syncBatchID                     int64
+	truncatedAt                     int64
 	enablePrimaryUpdate             bool
 	sourceSchemaAsDestinationColumn bool
 	cluster                         bool
@@ -46,6 +47,7 @@ func NewNormalizeQueryGenerator(
 	rawTableName string,
 	chVersion *chproto.Version,
 	cluster bool,
+	truncatedAt int64,
 ) *NormalizeQueryGenerator {
 	return &NormalizeQueryGenerator{
 		TableName:                       tableName,
@@ -61,6 +63,7 @@ func NewNormalizeQueryGenerator(
 		rawTableName:                    rawTableName,
 		chVersion:                       chVersion,
 		cluster:                         cluster,
+		truncatedAt:                     truncatedAt,
 	}
 }
 
@@ -272,6 +275,11 @@ func (t *NormalizeQueryGenerator) BuildQuery(ctx context.Context) (string, error
 	fmt.Fprintf(&selectQuery,
 		" FROM %s WHERE _peerdb_batch_id > %d AND _peerdb_batch_id <= %d AND  _peerdb_destination_table_name = %s",
 		peerdb_clickhouse.QuoteIdentifier(t.rawTableName), t.batchIDToLoadForTable, t.syncBatchID, peerdb_clickhouse.QuoteLiteral(t.TableName))
+	// truncates are applied before the insert, only changes after the latest applied truncate are kept
+	selectQuery.WriteString(" AND _peerdb_record_type != 3")
+	if t.truncatedAt > 0 {
+		fmt.Fprintf(&selectQuery, " AND _peerdb_timestamp > %d", t.truncatedAt)
+	}
 	if t.numParts > 1 {
 		fmt.Fprintf(&selectQuery, " AND cityHash64(_peerdb_uid) %% %d = %d", t.numParts, t.Part)
 	}
@@ -294,6 +302,9 @@ func (t *NormalizeQueryGenerator) BuildQuery(ctx context.Context) (string, error
 				" AND  _peerdb_destination_table_name = %s AND _peerdb_record_type = 1",
 			peerdb_clickhouse.QuoteIdentifier(t.rawTableName),
 			t.batchIDToLoadForTable, t.syncBatchID, peerdb_clickhouse.QuoteLiteral(t.TableName))
+		if t.truncatedAt > 0 {
+			fmt.Fprintf(&selectQuery, " AND _peerdb_timestamp > %d", t.truncatedAt)
+		}
 		if t.numParts > 1 {
 			fmt.Fprintf(&selectQuery, " AND cityHash64(_peerdb_uid) %% %d = %d", t.numParts, t.Part)
 		}
@@ -191,6 +191,7 @@ func TestBuildQuery_Basic(t *testing.T) {
 		rawTableName,
 		nil,
 		false,
+		0,
 	)
 
 	query, err := g.BuildQuery(ctx)
@@ -247,6 +248,7 @@ func TestBuildQuery_WithPrimaryUpdate(t *testing.T) {
 		rawTableName,
 		nil,
 		false,
+		0,
 	)
 
 	query, err := g.BuildQuery(ctx)
@@ -300,6 +302,7 @@ func TestBuildQuery_WithSourceSchemaAsDestinationColumn(t *testing.T) {
 		rawTableName,
 		nil,
 		true,
+		0,
 	)
 
 	query, err := g.BuildQuery(ctx)
@@ -351,9 +354,43 @@ func TestBuildQuery_WithNumParts(t *testing.T) {
 		rawTableName,
 		nil,
 		false,
+		0,
 	)
 
 	query, err := g.BuildQuery(ctx)
 	require.NoError(t, err)
 	require.Contains(t, query, "cityHash64(_peerdb_uid) % 4 = 2")
 }
+
+func TestBuildQuery_WithTruncate(t *testing.T) {
+	ctx := t.Context()
+	tableName := "my_table"
+	tableSchema := &protos.TableSchema{
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(types.QValueKindInt64)},
+		},
+		NullableEnabled: false,
+	}
+
+	g := NewNormalizeQueryGenerator(
+		tableName,
+		0,
+		map[string]*protos.TableSchema{tableName: tableSchema},
+		nil,
+		10,
+		5,
+		1,
+		true,
+		false,
+		map[string]string{},
+		"raw_my_table",
+		nil,
+		false,
+		1700000000000000000,
+	)
+
+	query, err := g.BuildQuery(ctx)
+	require.NoError(t, err)
+	require.Contains(t, query, "_peerdb_record_type != 3 AND _peerdb_timestamp > 1700000000000000000")
+	require.Contains(t, query, "_peerdb_record_type = 1 AND _peerdb_timestamp > 1700000000000000000")
+}
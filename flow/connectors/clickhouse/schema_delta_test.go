@@ -0,0 +1,68 @@
+package connclickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestDroppedColumnClauses(t *testing.T) {
+	c := &ClickHouseConnector{config: &protos.ClickhouseConfig{}}
+	column := &protos.FieldDescription{Name: "c", Type: string(types.QValueKindString)}
+
+	clauses, err := c.droppedColumnClauses(t.Context(), nil, column, protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_KEEP)
+	require.NoError(t, err)
+	require.Empty(t, clauses)
+
+	clauses, err = c.droppedColumnClauses(t.Context(), nil, column, protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL)
+	require.NoError(t, err)
+	require.Equal(t, []alterClause{
+		{clause: "MODIFY COLUMN `c` Nullable(String)"},
+		{clause: "UPDATE `c` = NULL WHERE 1", mutation: true},
+	}, clauses)
+
+	clauses, err = c.droppedColumnClauses(t.Context(), nil, column, protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP)
+	require.NoError(t, err)
+	require.Equal(t, []alterClause{{clause: "DROP COLUMN IF EXISTS `c`"}}, clauses)
+}
+
+func TestAlteredColumnClauses(t *testing.T) {
+	widened := &protos.AlteredColumn{
+		Previous: &protos.FieldDescription{Name: "c", Type: string(types.QValueKindInt32)},
+		Current:  &protos.FieldDescription{Name: "c", Type: string(types.QValueKindInt64)},
+	}
+	require.Equal(t, []alterClause{{clause: "MODIFY COLUMN `c` Int64"}},
+		alteredColumnClauses(widened, "Int64", protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_KEEP))
+
+	retyped := &protos.AlteredColumn{
+		Previous: &protos.FieldDescription{Name: "c", Type: string(types.QValueKindString)},
+		Current:  &protos.FieldDescription{Name: "c", Type: string(types.QValueKindBoolean)},
+	}
+	require.Empty(t, alteredColumnClauses(retyped, "Bool", protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_KEEP))
+	require.Equal(t, []alterClause{
+		{clause: "DROP COLUMN IF EXISTS `c`"},
+		{clause: "ADD COLUMN `c` Bool"},
+	}, alteredColumnClauses(retyped, "Bool", protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL))
+}
+
+func TestAlterTableAndShardsStmts(t *testing.T) {
+	drop := alterClause{clause: "DROP COLUMN IF EXISTS `c`"}
+	update := alterClause{clause: "UPDATE `c` = NULL WHERE 1", mutation: true}
+
+	c := &ClickHouseConnector{config: &protos.ClickhouseConfig{}}
+	require.Equal(t, []string{"ALTER TABLE `t` DROP COLUMN IF EXISTS `c`"}, c.alterTableAndShardsStmts("t", nil, drop))
+	require.Equal(t, []string{"ALTER TABLE `t` UPDATE `c` = NULL WHERE 1"}, c.alterTableAndShardsStmts("t", nil, update))
+
+	// mutations skip the distributed table and null engine tables
+	c = &ClickHouseConnector{config: &protos.ClickhouseConfig{Cluster: "cl"}}
+	require.Equal(t, []string{
+		"ALTER TABLE `t_shard` ON CLUSTER `cl` DROP COLUMN IF EXISTS `c`",
+		"ALTER TABLE `t` ON CLUSTER `cl` DROP COLUMN IF EXISTS `c`",
+	}, c.alterTableAndShardsStmts("t", nil, drop))
+	require.Equal(t, []string{"ALTER TABLE `t_shard` ON CLUSTER `cl` UPDATE `c` = NULL WHERE 1"},
+		c.alterTableAndShardsStmts("t", nil, update))
+	require.Empty(t, c.alterTableAndShardsStmts("t", &protos.TableMapping{Engine: protos.TableEngine_CH_ENGINE_NULL}, update))
+}
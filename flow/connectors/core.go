@@ -10,15 +10,22 @@ import (
 
 	connbigquery "github.com/PeerDB-io/peerdb/flow/connectors/bigquery"
 	connclickhouse "github.com/PeerDB-io/peerdb/flow/connectors/clickhouse"
+	connduckdb "github.com/PeerDB-io/peerdb/flow/connectors/duckdb"
 	connelasticsearch "github.com/PeerDB-io/peerdb/flow/connectors/elasticsearch"
 	conneventhub "github.com/PeerDB-io/peerdb/flow/connectors/eventhub"
+	conniceberg "github.com/PeerDB-io/peerdb/flow/connectors/iceberg"
 	connkafka "github.com/PeerDB-io/peerdb/flow/connectors/kafka"
 	connmongo "github.com/PeerDB-io/peerdb/flow/connectors/mongo"
 	connmysql "github.com/PeerDB-io/peerdb/flow/connectors/mysql"
+	connnats "github.com/PeerDB-io/peerdb/flow/connectors/nats"
 	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
 	connpubsub "github.com/PeerDB-io/peerdb/flow/connectors/pubsub"
+	connredis "github.com/PeerDB-io/peerdb/flow/connectors/redis"
 	conns3 "github.com/PeerDB-io/peerdb/flow/connectors/s3"
 	connsnowflake "github.com/PeerDB-io/peerdb/flow/connectors/snowflake"
+	connsqlserver "github.com/PeerDB-io/peerdb/flow/connectors/sqlserver"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	connwebhook "github.com/PeerDB-io/peerdb/flow/connectors/webhook"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
@@ -295,6 +302,14 @@ type RenameTablesConnector interface {
 	RenameTables(context.Context, *protos.RenameTablesInput, map[string]*protos.TableSchema) (*protos.RenameTablesOutput, error)
 }
 
+type IncrementalSnapshotConnector interface {
+	CDCPullConnectorCore
+
+	// ValidateIncrementalSnapshot checks snapshot can be read through the change stream of the source,
+	// running its chunk query, with its filter and bounds, against the source table
+	ValidateIncrementalSnapshot(context.Context, *utils.IncrementalSnapshot, *protos.TableSchema) error
+}
+
 type GetVersionConnector interface {
 	Connector
 
@@ -422,6 +437,36 @@ func LoadPeer(ctx context.Context, catalogPool shared.CatalogPool, peerName stri
 			return nil, fmt.Errorf("failed to unmarshal Elasticsearch config: %w", err)
 		}
 		peer.Config = &protos.Peer_ElasticsearchConfig{ElasticsearchConfig: &config}
+	case protos.DBType_ICEBERG:
+		var config protos.IcebergConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal Iceberg config: %w", err)
+		}
+		peer.Config = &protos.Peer_IcebergConfig{IcebergConfig: &config}
+	case protos.DBType_NATS:
+		var config protos.NatsConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal NATS config: %w", err)
+		}
+		peer.Config = &protos.Peer_NatsConfig{NatsConfig: &config}
+	case protos.DBType_REDIS:
+		var config protos.RedisConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal Redis config: %w", err)
+		}
+		peer.Config = &protos.Peer_RedisConfig{RedisConfig: &config}
+	case protos.DBType_WEBHOOK:
+		var config protos.WebhookConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal Webhook config: %w", err)
+		}
+		peer.Config = &protos.Peer_WebhookConfig{WebhookConfig: &config}
+	case protos.DBType_DUCKDB:
+		var config protos.DuckDBConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal DuckDB config: %w", err)
+		}
+		peer.Config = &protos.Peer_DuckdbConfig{DuckdbConfig: &config}
 	default:
 		return nil, fmt.Errorf("unsupported peer type: %s", peer.Type)
 	}
@@ -445,6 +490,8 @@ func GetConnector(ctx context.Context, env map[string]string, config *protos.Pee
 		return connmongo.NewMongoConnector(ctx, inner.MongoConfig)
 	case *protos.Peer_MysqlConfig:
 		return connmysql.NewMySqlConnector(ctx, inner.MysqlConfig)
+	case *protos.Peer_SqlserverConfig:
+		return connsqlserver.NewSqlServerConnector(ctx, inner.SqlserverConfig)
 	case *protos.Peer_ClickhouseConfig:
 		return connclickhouse.NewClickHouseConnector(ctx, env, inner.ClickhouseConfig)
 	case *protos.Peer_KafkaConfig:
@@ -453,6 +500,16 @@ func GetConnector(ctx context.Context, env map[string]string, config *protos.Pee
 		return connpubsub.NewPubSubConnector(ctx, env, inner.PubsubConfig)
 	case *protos.Peer_ElasticsearchConfig:
 		return connelasticsearch.NewElasticsearchConnector(ctx, inner.ElasticsearchConfig)
+	case *protos.Peer_IcebergConfig:
+		return conniceberg.NewIcebergConnector(ctx, inner.IcebergConfig)
+	case *protos.Peer_NatsConfig:
+		return connnats.NewNatsConnector(ctx, inner.NatsConfig)
+	case *protos.Peer_RedisConfig:
+		return connredis.NewRedisConnector(ctx, inner.RedisConfig)
+	case *protos.Peer_WebhookConfig:
+		return connwebhook.NewWebhookConnector(ctx, inner.WebhookConfig)
+	case *protos.Peer_DuckdbConfig:
+		return connduckdb.NewDuckDBConnector(ctx, inner.DuckdbConfig)
 	default:
 		return nil, errors.ErrUnsupported
 	}
@@ -493,6 +550,8 @@ var (
 	_ CDCPullConnector = &connpostgres.PostgresConnector{}
 	_ CDCPullConnector = &connmysql.MySqlConnector{}
 	_ CDCPullConnector = &connmongo.MongoConnector{}
+	_ CDCPullConnector = &connsqlserver.SqlServerConnector{}
+	_ CDCPullConnector = &connkafka.KafkaConnector{}
 
 	_ CDCPullPgConnector = &connpostgres.PostgresConnector{}
 
@@ -505,6 +564,13 @@ var (
 	_ CDCSyncConnector = &conns3.S3Connector{}
 	_ CDCSyncConnector = &connclickhouse.ClickHouseConnector{}
 	_ CDCSyncConnector = &connelasticsearch.ElasticsearchConnector{}
+	_ CDCSyncConnector = &connmongo.MongoConnector{}
+	_ CDCSyncConnector = &connmysql.MySqlConnector{}
+	_ CDCSyncConnector = &connduckdb.DuckDBConnector{}
+	_ CDCSyncConnector = &conniceberg.IcebergConnector{}
+	_ CDCSyncConnector = &connnats.NatsConnector{}
+	_ CDCSyncConnector = &connredis.RedisConnector{}
+	_ CDCSyncConnector = &connwebhook.WebhookConnector{}
 
 	_ CDCSyncPgConnector = &connpostgres.PostgresConnector{}
 
@@ -512,23 +578,33 @@ var (
 	_ CDCNormalizeConnector = &connbigquery.BigQueryConnector{}
 	_ CDCNormalizeConnector = &connsnowflake.SnowflakeConnector{}
 	_ CDCNormalizeConnector = &connclickhouse.ClickHouseConnector{}
+	_ CDCNormalizeConnector = &connmysql.MySqlConnector{}
+	_ CDCNormalizeConnector = &connduckdb.DuckDBConnector{}
 
 	_ StatActivityConnector = &connpostgres.PostgresConnector{}
 	_ StatActivityConnector = &connmysql.MySqlConnector{}
 
 	_ GetTableSchemaConnector = &connpostgres.PostgresConnector{}
 	_ GetTableSchemaConnector = &connmysql.MySqlConnector{}
+	_ GetTableSchemaConnector = &connsqlserver.SqlServerConnector{}
 	_ GetTableSchemaConnector = &connsnowflake.SnowflakeConnector{}
 	_ GetTableSchemaConnector = &connclickhouse.ClickHouseConnector{}
+	_ GetTableSchemaConnector = &connkafka.KafkaConnector{}
 
 	_ GetSchemaConnector = &connpostgres.PostgresConnector{}
 	_ GetSchemaConnector = &connmysql.MySqlConnector{}
 	_ GetSchemaConnector = &connmongo.MongoConnector{}
+	_ GetSchemaConnector = &connsqlserver.SqlServerConnector{}
 
 	_ NormalizedTablesConnector = &connpostgres.PostgresConnector{}
 	_ NormalizedTablesConnector = &connbigquery.BigQueryConnector{}
 	_ NormalizedTablesConnector = &connsnowflake.SnowflakeConnector{}
 	_ NormalizedTablesConnector = &connclickhouse.ClickHouseConnector{}
+	_ NormalizedTablesConnector = &connmysql.MySqlConnector{}
+	_ NormalizedTablesConnector = &connduckdb.DuckDBConnector{}
+	_ NormalizedTablesConnector = &conniceberg.IcebergConnector{}
+	_ NormalizedTablesConnector = &connkafka.KafkaConnector{}
+	_ NormalizedTablesConnector = &connelasticsearch.ElasticsearchConnector{}
 
 	_ CreateTablesFromExistingConnector = &connbigquery.BigQueryConnector{}
 	_ CreateTablesFromExistingConnector = &connsnowflake.SnowflakeConnector{}
@@ -536,6 +612,7 @@ var (
 	_ QRepPullConnector = &connpostgres.PostgresConnector{}
 	_ QRepPullConnector = &connmysql.MySqlConnector{}
 	_ QRepPullConnector = &connmongo.MongoConnector{}
+	_ QRepPullConnector = &connsqlserver.SqlServerConnector{}
 
 	_ QRepPullPgConnector = &connpostgres.PostgresConnector{}
 
@@ -546,6 +623,13 @@ var (
 	_ QRepSyncConnector = &conns3.S3Connector{}
 	_ QRepSyncConnector = &connclickhouse.ClickHouseConnector{}
 	_ QRepSyncConnector = &connelasticsearch.ElasticsearchConnector{}
+	_ QRepSyncConnector = &connmongo.MongoConnector{}
+	_ QRepSyncConnector = &connmysql.MySqlConnector{}
+	_ QRepSyncConnector = &connduckdb.DuckDBConnector{}
+	_ QRepSyncConnector = &conniceberg.IcebergConnector{}
+	_ QRepSyncConnector = &connnats.NatsConnector{}
+	_ QRepSyncConnector = &connredis.RedisConnector{}
+	_ QRepSyncConnector = &connwebhook.WebhookConnector{}
 
 	_ QRepSyncPgConnector = &connpostgres.PostgresConnector{}
 
@@ -557,6 +641,9 @@ var (
 	_ RenameTablesConnector = &connpostgres.PostgresConnector{}
 	_ RenameTablesConnector = &connclickhouse.ClickHouseConnector{}
 
+	_ IncrementalSnapshotConnector = &connpostgres.PostgresConnector{}
+	_ IncrementalSnapshotConnector = &connmysql.MySqlConnector{}
+
 	_ RawTableConnector = &connclickhouse.ClickHouseConnector{}
 	_ RawTableConnector = &connbigquery.BigQueryConnector{}
 	_ RawTableConnector = &connsnowflake.SnowflakeConnector{}
@@ -568,14 +655,24 @@ var (
 	_ ValidationConnector = &connbigquery.BigQueryConnector{}
 	_ ValidationConnector = &conns3.S3Connector{}
 	_ ValidationConnector = &connmysql.MySqlConnector{}
+	_ ValidationConnector = &connduckdb.DuckDBConnector{}
+	_ ValidationConnector = &connsqlserver.SqlServerConnector{}
+	_ ValidationConnector = &connwebhook.WebhookConnector{}
+	_ ValidationConnector = &conniceberg.IcebergConnector{}
 
 	_ MirrorSourceValidationConnector = &connpostgres.PostgresConnector{}
 	_ MirrorSourceValidationConnector = &connmysql.MySqlConnector{}
+	_ MirrorSourceValidationConnector = &connsqlserver.SqlServerConnector{}
+	_ MirrorSourceValidationConnector = &connkafka.KafkaConnector{}
 
 	_ MirrorDestinationValidationConnector = &connclickhouse.ClickHouseConnector{}
+	_ MirrorDestinationValidationConnector = &connkafka.KafkaConnector{}
+	_ MirrorDestinationValidationConnector = &connredis.RedisConnector{}
 
 	_ GetVersionConnector = &connclickhouse.ClickHouseConnector{}
 	_ GetVersionConnector = &connpostgres.PostgresConnector{}
 	_ GetVersionConnector = &connmysql.MySqlConnector{}
+	_ GetVersionConnector = &connduckdb.DuckDBConnector{}
 	_ GetVersionConnector = &connmongo.MongoConnector{}
+	_ GetVersionConnector = &connsqlserver.SqlServerConnector{}
 )
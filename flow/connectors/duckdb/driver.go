@@ -0,0 +1,6 @@
+//go:build duckdb
+
+package connduckdb
+
+// the driver links libduckdb through cgo, so it is only built into workers that ask for it
+import _ "github.com/duckdb/duckdb-go/v2"
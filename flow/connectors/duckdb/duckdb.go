@@ -0,0 +1,151 @@
+package connduckdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sync"
+
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+// name the driver registers itself as, see driver.go
+const driverName = "duckdb"
+
+// a database file can only be opened once per process,
+// so connectors of the same path share one handle
+var (
+	databasesLock sync.Mutex
+	databases     = make(map[string]*sharedDatabase)
+)
+
+type sharedDatabase struct {
+	db   *sql.DB
+	refs int
+}
+
+type DuckDBConnector struct {
+	*metadataStore.PostgresMetadata
+	db     *sql.DB
+	dsn    string
+	logger log.Logger
+}
+
+func dataSourceName(config *protos.DuckDBConfig) string {
+	if config.MotherduckToken == nil {
+		return config.Path
+	}
+	return config.Path + "?motherduck_token=" + url.QueryEscape(config.GetMotherduckToken())
+}
+
+func openDatabase(dsn string) (*sql.DB, error) {
+	databasesLock.Lock()
+	defer databasesLock.Unlock()
+	if shared, ok := databases[dsn]; ok {
+		shared.refs += 1
+		return shared.db, nil
+	}
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	databases[dsn] = &sharedDatabase{db: db, refs: 1}
+	return db, nil
+}
+
+func closeDatabase(dsn string) error {
+	databasesLock.Lock()
+	defer databasesLock.Unlock()
+	shared, ok := databases[dsn]
+	if !ok {
+		return nil
+	}
+	shared.refs -= 1
+	if shared.refs > 0 {
+		return nil
+	}
+	delete(databases, dsn)
+	return shared.db.Close()
+}
+
+func NewDuckDBConnector(
+	ctx context.Context,
+	config *protos.DuckDBConfig,
+) (*DuckDBConnector, error) {
+	if config.Path == "" {
+		return nil, errors.New("duckdb path is required")
+	}
+	if !slices.Contains(sql.Drivers(), driverName) {
+		return nil, errors.New("duckdb driver is not available, flow workers need to be built with -tags duckdb")
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dsn := dataSourceName(config)
+	db, err := openDatabase(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open duckdb database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		_ = closeDatabase(dsn)
+		return nil, fmt.Errorf("failed to open duckdb database: %w", err)
+	}
+
+	return &DuckDBConnector{
+		PostgresMetadata: pgMetadata,
+		db:               db,
+		dsn:              dsn,
+		logger:           internal.LoggerFromCtx(ctx),
+	}, nil
+}
+
+func (c *DuckDBConnector) Close() error {
+	if c == nil || c.db == nil {
+		return nil
+	}
+	return closeDatabase(c.dsn)
+}
+
+func (c *DuckDBConnector) ConnectionActive(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
+func (c *DuckDBConnector) ValidateCheck(ctx context.Context) error {
+	if _, err := c.db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+metadataSchema); err != nil {
+		return fmt.Errorf("failed to create internal schema: %w", err)
+	}
+	return nil
+}
+
+func (c *DuckDBConnector) GetVersion(ctx context.Context) (string, error) {
+	var version string
+	if err := c.db.QueryRowContext(ctx, "SELECT version()").Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to get duckdb version: %w", err)
+	}
+	return version, nil
+}
+
+// inTx runs f in a transaction, committing if it succeeds
+func (c *DuckDBConnector) inTx(ctx context.Context, f func(*sql.Tx) error) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if err := f(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,310 @@
+package connduckdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+const (
+	getLatestTruncateTimestampSQL = "SELECT max(_peerdb_timestamp) FROM %s WHERE" +
+		" _peerdb_batch_id>? AND _peerdb_batch_id<=? AND _peerdb_destination_table_name=? AND _peerdb_record_type=3"
+	deleteTruncatedRawRowsSQL = "DELETE FROM %s WHERE" +
+		" _peerdb_batch_id>? AND _peerdb_batch_id<=? AND _peerdb_destination_table_name=? AND _peerdb_timestamp<=?"
+	deleteTruncateMarkersSQL = "DELETE FROM %s WHERE" +
+		" _peerdb_batch_id>? AND _peerdb_batch_id<=? AND _peerdb_destination_table_name=? AND _peerdb_record_type=3"
+)
+
+func (c *DuckDBConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (c *DuckDBConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+func (c *DuckDBConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+func (c *DuckDBConnector) tableExists(ctx context.Context, table *utils.SchemaTable) (bool, error) {
+	var exists bool
+	if err := c.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_schema=? AND table_name=?)", table.Schema, table.Table,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("error checking if table %s exists: %w", table, err)
+	}
+	return exists, nil
+}
+
+func (c *DuckDBConnector) SetupNormalizedTable(
+	ctx context.Context,
+	tx any,
+	config *protos.SetupNormalizedTableBatchInput,
+	destinationTableIdentifier string,
+	sourceTableSchema *protos.TableSchema,
+) (bool, error) {
+	dstTable, err := utils.ParseSchemaTable(destinationTableIdentifier)
+	if err != nil {
+		return false, fmt.Errorf("error while parsing table schema and name: %w", err)
+	}
+	tableAlreadyExists, err := c.tableExists(ctx, dstTable)
+	if err != nil {
+		return false, err
+	}
+	if tableAlreadyExists {
+		c.logger.Info("[duckdb] table already exists, skipping", slog.String("table", destinationTableIdentifier))
+		if !config.IsResync {
+			return true, nil
+		}
+		if _, err := c.db.ExecContext(ctx, "DROP TABLE IF EXISTS "+dstTable.String()); err != nil {
+			return false, fmt.Errorf("error while dropping _resync table: %w", err)
+		}
+		c.logger.Info("[duckdb] dropped resync table for resync", slog.String("resyncTable", destinationTableIdentifier))
+	}
+
+	createTableSQL, err := generateCreateTableSQLForNormalizedTable(ctx, config, dstTable, sourceTableSchema)
+	if err != nil {
+		return false, err
+	}
+	if _, err := c.db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+utils.QuoteIdentifier(dstTable.Schema)); err != nil {
+		return false, fmt.Errorf("error while creating schema %s: %w", dstTable.Schema, err)
+	}
+	if _, err := c.db.ExecContext(ctx, createTableSQL); err != nil {
+		return false, fmt.Errorf("error while creating normalized table: %w", err)
+	}
+	return false, nil
+}
+
+func generateCreateTableSQLForNormalizedTable(
+	ctx context.Context,
+	config *protos.SetupNormalizedTableBatchInput,
+	dstTable *utils.SchemaTable,
+	tableSchema *protos.TableSchema,
+) (string, error) {
+	createTableSQLArray := make([]string, 0, len(tableSchema.Columns)+3)
+	for _, column := range tableSchema.Columns {
+		columnType, err := qvalue.ToDWHColumnType(
+			ctx, types.QValueKind(column.Type), config.Env, protos.DBType_DUCKDB, nil, column, tableSchema.NullableEnabled,
+		)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert column type %s to DuckDB type: %w", column.Type, err)
+		}
+		createTableSQLArray = append(createTableSQLArray, utils.QuoteIdentifier(column.Name)+" "+columnType)
+	}
+
+	if config.SoftDeleteColName != "" {
+		createTableSQLArray = append(createTableSQLArray,
+			utils.QuoteIdentifier(config.SoftDeleteColName)+" BOOLEAN DEFAULT FALSE")
+	}
+
+	if config.SyncedAtColName != "" {
+		createTableSQLArray = append(createTableSQLArray,
+			utils.QuoteIdentifier(config.SyncedAtColName)+" TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP")
+	}
+
+	// INSERT OR REPLACE needs the primary key to find the row to replace
+	if hasKey(tableSchema) {
+		primaryKeyColsQuoted := make([]string, 0, len(tableSchema.PrimaryKeyColumns))
+		for _, primaryKeyCol := range tableSchema.PrimaryKeyColumns {
+			primaryKeyColsQuoted = append(primaryKeyColsQuoted, utils.QuoteIdentifier(primaryKeyCol))
+		}
+		createTableSQLArray = append(createTableSQLArray,
+			fmt.Sprintf("PRIMARY KEY(%s)", strings.Join(primaryKeyColsQuoted, ",")))
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", dstTable.String(), strings.Join(createTableSQLArray, ",")), nil
+}
+
+// getTableNametoUnchangedCols returns the distinct unchanged toast column combinations of every table in the batch range
+func (c *DuckDBConnector) getTableNametoUnchangedCols(
+	ctx context.Context,
+	flowJobName string,
+	syncBatchID int64,
+	normalizeBatchID int64,
+) (map[string][]string, error) {
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf("SELECT DISTINCT _peerdb_destination_table_name,_peerdb_unchanged_toast_columns"+
+		" FROM %s WHERE _peerdb_batch_id>? AND _peerdb_batch_id<=?", getRawTableIdentifier(flowJobName)),
+		normalizeBatchID, syncBatchID)
+	if err != nil {
+		return nil, fmt.Errorf("error while retrieving table names for normalization: %w", err)
+	}
+	defer rows.Close()
+
+	resultMap := make(map[string][]string)
+	for rows.Next() {
+		var destinationTableName string
+		var unchangedToastColumns string
+		if err := rows.Scan(&destinationTableName, &unchangedToastColumns); err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+		resultMap[destinationTableName] = append(resultMap[destinationTableName], unchangedToastColumns)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while retrieving table names for normalization: %w", err)
+	}
+	return resultMap, nil
+}
+
+func (c *DuckDBConnector) NormalizeRecords(
+	ctx context.Context,
+	req *model.NormalizeRecordsRequest,
+) (model.NormalizeResponse, error) {
+	normBatchID, err := c.GetLastNormalizeBatchID(ctx, req.FlowJobName)
+	if err != nil {
+		c.logger.Error("[duckdb] error while getting last normalize batch id", slog.Any("error", err))
+		return model.NormalizeResponse{}, err
+	}
+
+	// normalize has caught up with sync, chill until more records are loaded.
+	if normBatchID >= req.SyncBatchID {
+		return model.NormalizeResponse{
+			StartBatchID: normBatchID,
+			EndBatchID:   req.SyncBatchID,
+		}, nil
+	}
+
+	unchangedToastColumnsMap, err := c.getTableNametoUnchangedCols(ctx, req.FlowJobName, req.SyncBatchID, normBatchID)
+	if err != nil {
+		return model.NormalizeResponse{}, err
+	}
+
+	normalizeStmtGen := normalizeStmtGenerator{
+		rawTableName:             getRawTableIdentifier(req.FlowJobName),
+		tableSchemaMapping:       req.TableNameSchemaMapping,
+		unchangedToastColumnsMap: unchangedToastColumnsMap,
+		peerdbCols: &protos.PeerDBColumns{
+			SoftDeleteColName: req.SoftDeleteColName,
+			SyncedAtColName:   req.SyncedAtColName,
+		},
+		env:         req.Env,
+		syncBatchID: req.SyncBatchID,
+	}
+
+	for _, destinationTableName := range slices.Sorted(maps.Keys(unchangedToastColumnsMap)) {
+		if _, ok := req.TableNameSchemaMapping[destinationTableName]; !ok {
+			c.logger.Warn("table not found in table to schema mapping", slog.String("table", destinationTableName))
+			continue
+		}
+		dstTable, err := utils.ParseSchemaTable(destinationTableName)
+		if err != nil {
+			return model.NormalizeResponse{}, fmt.Errorf("error while parsing table schema and name: %w", err)
+		}
+
+		normalizeBatchIDForTable, err := c.GetLastNormalizedBatchIDForTable(ctx, req.FlowJobName, destinationTableName)
+		if err != nil {
+			return model.NormalizeResponse{}, err
+		}
+		batchIdToLoadForTable := max(normBatchID, normalizeBatchIDForTable)
+		if batchIdToLoadForTable >= req.SyncBatchID {
+			c.logger.Info("[duckdb] table already normalized for this batch, skipping",
+				slog.String("table", destinationTableName), slog.Int64("syncBatchID", req.SyncBatchID))
+			continue
+		}
+
+		normalizeStatements, err := normalizeStmtGen.generateNormalizeStatements(
+			ctx, destinationTableName, dstTable.String(), batchIdToLoadForTable)
+		if err != nil {
+			return model.NormalizeResponse{}, err
+		}
+		if err := c.inTx(ctx, func(tx *sql.Tx) error {
+			if err := c.normalizeTruncate(ctx, tx, req, batchIdToLoadForTable, destinationTableName, dstTable); err != nil {
+				return err
+			}
+			for _, normalizeStatement := range normalizeStatements {
+				if _, err := tx.ExecContext(ctx, normalizeStatement); err != nil {
+					c.logger.Error("error executing normalize statement",
+						slog.String("statement", normalizeStatement),
+						slog.Int64("normBatchID", batchIdToLoadForTable),
+						slog.Int64("syncBatchID", req.SyncBatchID),
+						slog.String("destinationTableName", destinationTableName),
+						slog.Any("error", err))
+					return fmt.Errorf("error executing normalize statement for table %s: %w", destinationTableName, err)
+				}
+			}
+			return nil
+		}); err != nil {
+			return model.NormalizeResponse{}, err
+		}
+
+		if err := c.SetLastNormalizedBatchIDForTable(ctx, req.FlowJobName, destinationTableName, req.SyncBatchID); err != nil {
+			return model.NormalizeResponse{}, fmt.Errorf("error while setting last normalized batch id for table %s: %w",
+				destinationTableName, err)
+		}
+	}
+
+	if err := c.UpdateNormalizeBatchID(ctx, req.FlowJobName, req.SyncBatchID); err != nil {
+		c.logger.Error("[duckdb] error while updating normalize batch id",
+			slog.Int64("BatchID", req.SyncBatchID), slog.Any("error", err))
+		return model.NormalizeResponse{}, err
+	}
+
+	return model.NormalizeResponse{
+		StartBatchID: normBatchID + 1,
+		EndBatchID:   req.SyncBatchID,
+	}, nil
+}
+
+// normalizeTruncate handles the latest truncate of a table in the batch range according to the truncate policy,
+// changes from before an applied truncate are dropped from the raw table so they are not normalized
+func (c *DuckDBConnector) normalizeTruncate(
+	ctx context.Context,
+	tx *sql.Tx,
+	req *model.NormalizeRecordsRequest,
+	normBatchID int64,
+	destinationTableName string,
+	dstTable *utils.SchemaTable,
+) error {
+	rawTableIdentifier := getRawTableIdentifier(req.FlowJobName)
+	var truncatedAt sql.NullInt64
+	if err := tx.QueryRowContext(ctx, fmt.Sprintf(getLatestTruncateTimestampSQL, rawTableIdentifier),
+		normBatchID, req.SyncBatchID, destinationTableName,
+	).Scan(&truncatedAt); err != nil {
+		return fmt.Errorf("error while checking truncates of table %s: %w", destinationTableName, err)
+	}
+	if !truncatedAt.Valid {
+		return nil
+	}
+
+	switch req.TruncatePolicy {
+	case protos.TruncatePolicy_TRUNCATE_POLICY_APPLY:
+		if _, err := tx.ExecContext(ctx, "TRUNCATE "+dstTable.String()); err != nil {
+			return fmt.Errorf("error truncating table %s: %w", destinationTableName, err)
+		}
+	case protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE:
+		if req.SoftDeleteColName == "" {
+			return fmt.Errorf("cannot soft delete rows of truncated table %s without a soft delete column", destinationTableName)
+		}
+		softDeleteUpdate := utils.QuoteIdentifier(req.SoftDeleteColName) + "=TRUE"
+		if req.SyncedAtColName != "" {
+			softDeleteUpdate += fmt.Sprintf(",%s=CURRENT_TIMESTAMP", utils.QuoteIdentifier(req.SyncedAtColName))
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s", dstTable.String(), softDeleteUpdate)); err != nil {
+			return fmt.Errorf("error soft deleting rows of truncated table %s: %w", destinationTableName, err)
+		}
+	default:
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(deleteTruncateMarkersSQL, rawTableIdentifier),
+			normBatchID, req.SyncBatchID, destinationTableName,
+		); err != nil {
+			return fmt.Errorf("error removing ignored truncates of table %s: %w", destinationTableName, err)
+		}
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(deleteTruncatedRawRowsSQL, rawTableIdentifier),
+		normBatchID, req.SyncBatchID, destinationTableName, truncatedAt.Int64,
+	); err != nil {
+		return fmt.Errorf("error removing truncated changes of table %s: %w", destinationTableName, err)
+	}
+	c.logger.Info("applied truncate", slog.String("table", destinationTableName), slog.String("policy", req.TruncatePolicy.String()))
+	return nil
+}
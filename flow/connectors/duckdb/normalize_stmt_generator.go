@@ -0,0 +1,185 @@
+package connduckdb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+type normalizeStmtGenerator struct {
+	// "_peerdb_internal"."_peerdb_raw_..."
+	rawTableName string
+	// the schema of the table to merge into
+	tableSchemaMapping map[string]*protos.TableSchema
+	// array of toast column combinations that are unchanged
+	unchangedToastColumnsMap map[string][]string
+	// _PEERDB_IS_DELETED and _SYNCED_AT columns
+	peerdbCols *protos.PeerDBColumns
+	env        map[string]string
+	// last batch id to merge
+	syncBatchID int64
+}
+
+// jsonPath addresses a top level key of _peerdb_data
+func jsonPath(column string) string {
+	return quoteLiteral(`$."` + strings.ReplaceAll(column, `"`, `\"`) + `"`)
+}
+
+// generateExpr converts a column of _peerdb_data, which was serialized by RecordItems.toMap,
+// to the column type picked by ToDWHColumnType, json null becoming sql NULL
+func (n *normalizeStmtGenerator) generateExpr(ctx context.Context, column *protos.FieldDescription) (string, error) {
+	qkind := types.QValueKind(column.Type)
+	columnType, err := qvalue.ToDWHColumnType(ctx, qkind, n.env, protos.DBType_DUCKDB, nil, column, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert column type %s to DuckDB type: %w", column.Type, err)
+	}
+	if qkind.IsArray() {
+		return fmt.Sprintf("CAST(json_extract(_peerdb_data,%s) AS %s)", jsonPath(column.Name), columnType), nil
+	}
+	value := fmt.Sprintf("json_extract_string(_peerdb_data,%s)", jsonPath(column.Name))
+	switch qkind {
+	case types.QValueKindBytes:
+		return fmt.Sprintf("from_base64(%s)", value), nil
+	case types.QValueKindTimestampTZ:
+		// serialized as 2006-01-02 15:04:05.999999-0700, fraction omitted when zero
+		return fmt.Sprintf("strptime(%s,['%%Y-%%m-%%d %%H:%%M:%%S.%%f%%z','%%Y-%%m-%%d %%H:%%M:%%S%%z'])", value), nil
+	default:
+		return fmt.Sprintf("CAST(%s AS %s)", value, columnType), nil
+	}
+}
+
+// hasKey is true when the destination table was created with a primary key, see generateCreateTableSQLForNormalizedTable
+func hasKey(tableSchema *protos.TableSchema) bool {
+	return len(tableSchema.PrimaryKeyColumns) > 0 && !tableSchema.IsReplicaIdentityFull
+}
+
+// rankedSource selects the latest change per primary key of dstTable in the batch range
+func (n *normalizeStmtGenerator) rankedSource(dstTable string, normalizedTableSchema *protos.TableSchema, normalizeBatchID int64) string {
+	partitionBy := make([]string, 0, len(normalizedTableSchema.PrimaryKeyColumns))
+	for _, pkey := range normalizedTableSchema.PrimaryKeyColumns {
+		partitionBy = append(partitionBy, fmt.Sprintf("json_extract_string(_peerdb_data,%s)", jsonPath(pkey)))
+	}
+	if len(partitionBy) == 0 {
+		partitionBy = append(partitionBy, "_peerdb_uid")
+	}
+	return fmt.Sprintf("(SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,"+
+		"ROW_NUMBER() OVER (PARTITION BY %s ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank"+
+		" FROM %s WHERE _peerdb_batch_id>%d AND _peerdb_batch_id<=%d AND _peerdb_destination_table_name=%s) AS _peerdb_src",
+		strings.Join(partitionBy, ","), n.rawTableName, normalizeBatchID, n.syncBatchID, quoteLiteral(dstTable))
+}
+
+// generateNormalizeStatements returns one INSERT per unchanged toast column combination, INSERT OR REPLACE when
+// every column changed, followed by a statement that deletes or soft deletes rows whose latest change was a delete
+func (n *normalizeStmtGenerator) generateNormalizeStatements(
+	ctx context.Context, dstTable string, quotedDstTable string, normalizeBatchID int64,
+) ([]string, error) {
+	normalizedTableSchema := n.tableSchemaMapping[dstTable]
+	source := n.rankedSource(dstTable, normalizedTableSchema, normalizeBatchID)
+	keyed := hasKey(normalizedTableSchema)
+
+	columnCount := len(normalizedTableSchema.Columns)
+	quotedColumnNames := make([]string, 0, columnCount+2)
+	selectExprs := make([]string, 0, columnCount+2)
+	columnExprs := make(map[string]string, columnCount)
+	for _, column := range normalizedTableSchema.Columns {
+		expr, err := n.generateExpr(ctx, column)
+		if err != nil {
+			return nil, err
+		}
+		quotedColumnNames = append(quotedColumnNames, utils.QuoteIdentifier(column.Name))
+		selectExprs = append(selectExprs, expr)
+		columnExprs[column.Name] = expr
+	}
+
+	unchangedToastColumns := n.unchangedToastColumnsMap[dstTable]
+	if !slices.Contains(unchangedToastColumns, "") {
+		unchangedToastColumns = append([]string{""}, unchangedToastColumns...)
+	}
+
+	statements := make([]string, 0, len(unchangedToastColumns)+1)
+	for _, unchangedToastCols := range unchangedToastColumns {
+		unchanged := strings.Split(unchangedToastCols, ",")
+		insertColumns := slices.Clone(quotedColumnNames)
+		insertExprs := slices.Clone(selectExprs)
+		// key columns cannot be assigned on conflict
+		updates := make([]string, 0, columnCount+2)
+		for _, column := range normalizedTableSchema.Columns {
+			if !slices.Contains(unchanged, column.Name) && !slices.Contains(normalizedTableSchema.PrimaryKeyColumns, column.Name) {
+				quotedCol := utils.QuoteIdentifier(column.Name)
+				updates = append(updates, fmt.Sprintf("%s=EXCLUDED.%s", quotedCol, quotedCol))
+			}
+		}
+		if n.peerdbCols.SoftDeleteColName != "" {
+			quotedCol := utils.QuoteIdentifier(n.peerdbCols.SoftDeleteColName)
+			insertColumns = append(insertColumns, quotedCol)
+			insertExprs = append(insertExprs, "FALSE")
+			updates = append(updates, fmt.Sprintf("%s=EXCLUDED.%s", quotedCol, quotedCol))
+		}
+		if n.peerdbCols.SyncedAtColName != "" {
+			quotedCol := utils.QuoteIdentifier(n.peerdbCols.SyncedAtColName)
+			insertColumns = append(insertColumns, quotedCol)
+			insertExprs = append(insertExprs, "CURRENT_TIMESTAMP")
+			updates = append(updates, fmt.Sprintf("%s=EXCLUDED.%s", quotedCol, quotedCol))
+		}
+
+		verb := "INSERT"
+		var onConflict string
+		if keyed {
+			if unchangedToastCols == "" {
+				verb = "INSERT OR REPLACE"
+			} else if len(updates) > 0 {
+				onConflict = " ON CONFLICT DO UPDATE SET " + strings.Join(updates, ",")
+			} else {
+				onConflict = " ON CONFLICT DO NOTHING"
+			}
+		}
+		statements = append(statements, fmt.Sprintf(
+			"%s INTO %s (%s) SELECT %s FROM %s WHERE _peerdb_rank=1 AND _peerdb_record_type!=2"+
+				" AND _peerdb_unchanged_toast_columns=%s%s",
+			verb, quotedDstTable, strings.Join(insertColumns, ","), strings.Join(insertExprs, ","), source,
+			quoteLiteral(unchangedToastCols), onConflict))
+	}
+
+	if n.peerdbCols.SoftDeleteColName != "" {
+		quotedSoftDeleteCol := utils.QuoteIdentifier(n.peerdbCols.SoftDeleteColName)
+		insertColumns := append(slices.Clone(quotedColumnNames), quotedSoftDeleteCol)
+		insertExprs := append(slices.Clone(selectExprs), "TRUE")
+		updates := []string{quotedSoftDeleteCol + "=TRUE"}
+		if n.peerdbCols.SyncedAtColName != "" {
+			quotedCol := utils.QuoteIdentifier(n.peerdbCols.SyncedAtColName)
+			insertColumns = append(insertColumns, quotedCol)
+			insertExprs = append(insertExprs, "CURRENT_TIMESTAMP")
+			updates = append(updates, fmt.Sprintf("%s=EXCLUDED.%s", quotedCol, quotedCol))
+		}
+		var onConflict string
+		if keyed {
+			onConflict = " ON CONFLICT DO UPDATE SET " + strings.Join(updates, ",")
+		}
+		statements = append(statements, fmt.Sprintf(
+			"INSERT INTO %s (%s) SELECT %s FROM %s WHERE _peerdb_rank=1 AND _peerdb_record_type=2%s",
+			quotedDstTable, strings.Join(insertColumns, ","), strings.Join(insertExprs, ","), source, onConflict))
+	} else if len(normalizedTableSchema.PrimaryKeyColumns) > 0 {
+		deleteKeys := make([]string, 0, len(normalizedTableSchema.PrimaryKeyColumns))
+		joinConditions := make([]string, 0, len(normalizedTableSchema.PrimaryKeyColumns))
+		for _, pkey := range normalizedTableSchema.PrimaryKeyColumns {
+			quotedCol := utils.QuoteIdentifier(pkey)
+			expr, ok := columnExprs[pkey]
+			if !ok {
+				expr = fmt.Sprintf("json_extract_string(_peerdb_data,%s)", jsonPath(pkey))
+			}
+			deleteKeys = append(deleteKeys, fmt.Sprintf("%s AS %s", expr, quotedCol))
+			joinConditions = append(joinConditions, fmt.Sprintf("%s.%s=_peerdb_del.%s", quotedDstTable, quotedCol, quotedCol))
+		}
+		statements = append(statements, fmt.Sprintf(
+			"DELETE FROM %s USING (SELECT %s FROM %s WHERE _peerdb_rank=1 AND _peerdb_record_type=2) AS _peerdb_del WHERE %s",
+			quotedDstTable, strings.Join(deleteKeys, ","), source, strings.Join(joinConditions, " AND ")))
+	}
+
+	return statements, nil
+}
@@ -0,0 +1,67 @@
+package connduckdb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestGenerateNormalizeStatements(t *testing.T) {
+	tableSchema := &protos.TableSchema{
+		PrimaryKeyColumns: []string{"id"},
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(types.QValueKindInt64)},
+			{Name: "name", Type: string(types.QValueKindString)},
+			{Name: "blob", Type: string(types.QValueKindBytes)},
+			{Name: "tags", Type: string(types.QValueKindArrayString)},
+		},
+	}
+	normalizeGen := normalizeStmtGenerator{
+		rawTableName:             `"_peerdb_internal"."_peerdb_raw_test"`,
+		tableSchemaMapping:       map[string]*protos.TableSchema{"s.t": tableSchema},
+		unchangedToastColumnsMap: map[string][]string{"s.t": {"blob"}},
+		peerdbCols:               &protos.PeerDBColumns{SyncedAtColName: "_peerdb_synced_at"},
+		syncBatchID:              5,
+	}
+
+	statements, err := normalizeGen.generateNormalizeStatements(t.Context(), "s.t", `"s"."t"`, 3)
+	require.NoError(t, err)
+	require.Len(t, statements, 3)
+	for _, stmt := range statements {
+		require.Contains(t, stmt, "_peerdb_batch_id>3 AND _peerdb_batch_id<=5 AND _peerdb_destination_table_name='s.t'")
+	}
+
+	// rows are replaced as a whole when no columns are unchanged
+	require.True(t, strings.HasPrefix(statements[0], `INSERT OR REPLACE INTO "s"."t"`))
+	require.Contains(t, statements[0], "_peerdb_unchanged_toast_columns=''")
+	require.Contains(t, statements[0], `CAST(json_extract_string(_peerdb_data,'$."id"') AS BIGINT)`)
+	require.Contains(t, statements[0], `from_base64(json_extract_string(_peerdb_data,'$."blob"'))`)
+	require.Contains(t, statements[0], `CAST(json_extract(_peerdb_data,'$."tags"') AS VARCHAR[])`)
+
+	// unchanged toast columns are left as is
+	require.Contains(t, statements[1], "_peerdb_unchanged_toast_columns='blob'")
+	require.True(t, strings.HasSuffix(statements[1],
+		`ON CONFLICT DO UPDATE SET "name"=EXCLUDED."name","tags"=EXCLUDED."tags","_peerdb_synced_at"=EXCLUDED."_peerdb_synced_at"`))
+
+	require.True(t, strings.HasPrefix(statements[2], `DELETE FROM "s"."t" USING`))
+	require.Contains(t, statements[2], `WHERE "s"."t"."id"=_peerdb_del."id"`)
+
+	normalizeGen.peerdbCols.SoftDeleteColName = "_peerdb_is_deleted"
+	statements, err = normalizeGen.generateNormalizeStatements(t.Context(), "s.t", `"s"."t"`, 3)
+	require.NoError(t, err)
+	require.Len(t, statements, 3)
+	require.Contains(t, statements[2], `_peerdb_record_type=2 ON CONFLICT DO UPDATE SET "_peerdb_is_deleted"=TRUE`)
+
+	// tables without a primary key are appended to
+	tableSchema.IsReplicaIdentityFull = true
+	statements, err = normalizeGen.generateNormalizeStatements(t.Context(), "s.t", `"s"."t"`, 3)
+	require.NoError(t, err)
+	for _, stmt := range statements {
+		require.True(t, strings.HasPrefix(stmt, `INSERT INTO "s"."t"`))
+		require.NotContains(t, stmt, "ON CONFLICT")
+	}
+}
@@ -0,0 +1,90 @@
+//go:build duckdb
+
+package connduckdb
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestNormalizeTruncate(t *testing.T) {
+	tableSchema := &protos.TableSchema{
+		PrimaryKeyColumns: []string{"id"},
+		Columns:           []*protos.FieldDescription{{Name: "id", Type: string(types.QValueKindInt64)}},
+	}
+	dstTable := &utils.SchemaTable{Schema: "main", Table: "t"}
+
+	for policy, expected := range map[protos.TruncatePolicy][]int64{
+		protos.TruncatePolicy_TRUNCATE_POLICY_IGNORE: {0, 1, 2},
+		protos.TruncatePolicy_TRUNCATE_POLICY_APPLY:  {2},
+	} {
+		t.Run(policy.String(), func(t *testing.T) {
+			db, err := sql.Open(driverName, "")
+			require.NoError(t, err)
+			defer db.Close()
+			c := &DuckDBConnector{db: db, logger: log.NewStructuredLogger(slog.Default())}
+
+			rawTable := getRawTableIdentifier("flow")
+			_, err = db.ExecContext(t.Context(), "CREATE SCHEMA "+metadataSchema)
+			require.NoError(t, err)
+			_, err = db.ExecContext(t.Context(), fmt.Sprintf(createRawTableSQL, rawTable))
+			require.NoError(t, err)
+			_, err = db.ExecContext(t.Context(), "CREATE TABLE main.t (id BIGINT PRIMARY KEY); INSERT INTO main.t VALUES (0)")
+			require.NoError(t, err)
+			// a change before the truncate, the truncate, and a change after it
+			_, err = db.ExecContext(t.Context(), "INSERT INTO "+rawTable+` VALUES
+				('a', 1, 'main.t', '{"id":1}', 0, '{}', 1, ''),
+				('b', 2, 'main.t', '{}', 3, '{}', 1, ''),
+				('c', 3, 'main.t', '{"id":2}', 0, '{}', 1, '')`)
+			require.NoError(t, err)
+
+			req := &model.NormalizeRecordsRequest{FlowJobName: "flow", SyncBatchID: 1, TruncatePolicy: policy}
+			gen := normalizeStmtGenerator{
+				rawTableName:             rawTable,
+				tableSchemaMapping:       map[string]*protos.TableSchema{"main.t": tableSchema},
+				unchangedToastColumnsMap: map[string][]string{"main.t": {""}},
+				peerdbCols:               &protos.PeerDBColumns{},
+				syncBatchID:              1,
+			}
+			statements, err := gen.generateNormalizeStatements(t.Context(), "main.t", dstTable.String(), 0)
+			require.NoError(t, err)
+			require.NoError(t, c.inTx(t.Context(), func(tx *sql.Tx) error {
+				if err := c.normalizeTruncate(t.Context(), tx, req, 0, "main.t", dstTable); err != nil {
+					return err
+				}
+				for _, statement := range statements {
+					if _, err := tx.ExecContext(t.Context(), statement); err != nil {
+						return err
+					}
+				}
+				return nil
+			}))
+
+			rows, err := db.QueryContext(t.Context(), "SELECT id FROM main.t ORDER BY id")
+			require.NoError(t, err)
+			var ids []int64
+			for rows.Next() {
+				var id int64
+				require.NoError(t, rows.Scan(&id))
+				ids = append(ids, id)
+			}
+			require.NoError(t, rows.Err())
+			require.Equal(t, expected, ids)
+
+			var markers int
+			require.NoError(t, db.QueryRowContext(t.Context(),
+				"SELECT count(*) FROM "+rawTable+" WHERE _peerdb_record_type=3").Scan(&markers))
+			require.Zero(t, markers)
+		})
+	}
+}
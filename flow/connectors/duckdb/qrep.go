@@ -0,0 +1,82 @@
+package connduckdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func (c *DuckDBConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+func (c *DuckDBConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+
+	dstTable, err := utils.ParseSchemaTable(config.DestinationTableIdentifier)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to parse destination table identifier: %w", err)
+	}
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+	columnNames := schema.GetColumnNames()
+
+	// upserts rely on the primary key of the destination table covering the upsert key columns
+	verb := "INSERT"
+	if config.WriteMode != nil && config.WriteMode.WriteType == protos.QRepWriteType_QREP_WRITE_MODE_UPSERT {
+		verb = "INSERT OR REPLACE"
+	}
+
+	var numRecords int64
+	if err := c.inTx(ctx, func(tx *sql.Tx) error {
+		rows := make([][]any, 0, insertBatchSize)
+		for qRecord := range stream.Records {
+			row := make([]any, 0, len(qRecord))
+			for idx, qv := range qRecord {
+				arg, err := duckdbArgFromQValue(qv)
+				if err != nil {
+					return fmt.Errorf("failed to convert value of column %s: %w", columnNames[idx], err)
+				}
+				row = append(row, arg)
+			}
+			rows = append(rows, row)
+			numRecords += 1
+			if len(rows) >= insertBatchSize {
+				if err := insertRows(ctx, tx, verb, dstTable.String(), columnNames, rows); err != nil {
+					return fmt.Errorf("failed to insert into %s: %w", config.DestinationTableIdentifier, err)
+				}
+				rows = rows[:0]
+			}
+		}
+		if err := stream.Err(); err != nil {
+			return fmt.Errorf("failed to get record from stream: %w", err)
+		}
+		if err := insertRows(ctx, tx, verb, dstTable.String(), columnNames, rows); err != nil {
+			return fmt.Errorf("failed to insert into %s: %w", config.DestinationTableIdentifier, err)
+		}
+		return nil
+	}); err != nil {
+		c.logger.Error("[duckdb] failed to sync qrep records", slog.Any("error", err))
+		return 0, nil, err
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		c.logger.Error("[duckdb] failed to log partition info", slog.Any("error", err))
+		return 0, nil, fmt.Errorf("[duckdb] failed to log partition info: %w", err)
+	}
+	return numRecords, nil, nil
+}
@@ -0,0 +1,61 @@
+package connduckdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// duckdbArgFromQValue converts a QValue into a statement argument the driver can bind,
+// values without a native encoding are sent as text, which DuckDB casts to the column type
+func duckdbArgFromQValue(qv types.QValue) (any, error) {
+	switch v := qv.(type) {
+	case types.QValueNull:
+		return nil, nil
+	case types.QValueFloat32:
+		if math.IsNaN(float64(v.Val)) || math.IsInf(float64(v.Val), 0) {
+			return nil, nil
+		}
+		return v.Val, nil
+	case types.QValueFloat64:
+		if math.IsNaN(v.Val) || math.IsInf(v.Val, 0) {
+			return nil, nil
+		}
+		return v.Val, nil
+	case types.QValueQChar:
+		return string(rune(v.Val)), nil
+	case types.QValueInt256:
+		return v.Val.String(), nil
+	case types.QValueUInt256:
+		return v.Val.String(), nil
+	case types.QValueNumeric:
+		return v.Val.String(), nil
+	case types.QValueTimestamp:
+		return v.Val, nil
+	case types.QValueTimestampTZ:
+		return v.Val.UTC(), nil
+	case types.QValueDate:
+		return v.Val.Format(time.DateOnly), nil
+	case types.QValueTime:
+		return time.Time{}.Add(v.Val).Format("15:04:05.999999"), nil
+	case types.QValueTimeTZ:
+		return time.Time{}.Add(v.Val).Format("15:04:05.999999"), nil
+	case types.QValueUUID:
+		return v.Val.String(), nil
+	case types.QValueHStore:
+		return datatypes.ParseHstore(v.Val)
+	default:
+		if qv.Kind().IsArray() {
+			arr, err := json.Marshal(qv.Value())
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal %s to json: %w", qv.Kind(), err)
+			}
+			return string(arr), nil
+		}
+		return qv.Value(), nil
+	}
+}
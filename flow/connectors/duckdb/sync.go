@@ -0,0 +1,354 @@
+package connduckdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+const (
+	// schema holding raw tables, created on first use
+	metadataSchema = `"_peerdb_internal"`
+	// upper bound on rows per INSERT statement
+	insertBatchSize = 1000
+
+	createRawTableSQL = `CREATE TABLE IF NOT EXISTS %s (
+		_peerdb_uid VARCHAR NOT NULL,
+		_peerdb_timestamp BIGINT NOT NULL,
+		_peerdb_destination_table_name VARCHAR NOT NULL,
+		_peerdb_data VARCHAR NOT NULL,
+		_peerdb_record_type INTEGER NOT NULL,
+		_peerdb_match_data VARCHAR,
+		_peerdb_batch_id BIGINT NOT NULL,
+		_peerdb_unchanged_toast_columns VARCHAR NOT NULL
+	)`
+)
+
+var rawTableColumns = []string{
+	"_peerdb_uid", "_peerdb_timestamp", "_peerdb_destination_table_name", "_peerdb_data",
+	"_peerdb_record_type", "_peerdb_match_data", "_peerdb_batch_id", "_peerdb_unchanged_toast_columns",
+}
+
+func getRawTableIdentifier(flowJobName string) string {
+	return metadataSchema + "." + utils.QuoteIdentifier("_peerdb_raw_"+shared.ReplaceIllegalCharactersWithUnderscores(flowJobName))
+}
+
+// quoteLiteral escapes quotes only, DuckDB string literals have no backslash escapes
+func quoteLiteral(literal string) string {
+	return "'" + strings.ReplaceAll(literal, "'", "''") + "'"
+}
+
+// insertRows writes rows to table with multi-row INSERT statements, verb being INSERT or INSERT OR REPLACE
+func insertRows(ctx context.Context, tx *sql.Tx, verb string, table string, columns []string, rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	quotedColumns := make([]string, 0, len(columns))
+	for _, col := range columns {
+		quotedColumns = append(quotedColumns, utils.QuoteIdentifier(col))
+	}
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")"
+
+	for batch := range slices.Chunk(rows, insertBatchSize) {
+		var query strings.Builder
+		query.WriteString(verb)
+		query.WriteString(" INTO ")
+		query.WriteString(table)
+		query.WriteString(" (")
+		query.WriteString(strings.Join(quotedColumns, ","))
+		query.WriteString(") VALUES ")
+		args := make([]any, 0, len(batch)*len(columns))
+		for i, row := range batch {
+			if i > 0 {
+				query.WriteByte(',')
+			}
+			query.WriteString(placeholders)
+			args = append(args, row...)
+		}
+		if _, err := tx.ExecContext(ctx, query.String(), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *DuckDBConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	if _, err := c.db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+metadataSchema); err != nil {
+		return nil, fmt.Errorf("failed to create internal schema: %w", err)
+	}
+	rawTableIdentifier := getRawTableIdentifier(req.FlowJobName)
+	if _, err := c.db.ExecContext(ctx, fmt.Sprintf(createRawTableSQL, rawTableIdentifier)); err != nil {
+		return nil, fmt.Errorf("failed to create raw table: %w", err)
+	}
+	return &protos.CreateRawTableOutput{TableIdentifier: rawTableIdentifier}, nil
+}
+
+func (c *DuckDBConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	rawTableIdentifier := getRawTableIdentifier(req.FlowJobName)
+	c.logger.Info("pushing records to DuckDB raw table", slog.String("table", rawTableIdentifier))
+
+	var numRecords int64
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	jsonOptions := model.ToJSONOptions{
+		UnnestColumns: nil,
+		HStoreAsJSON:  true,
+	}
+	if err := c.inTx(ctx, func(tx *sql.Tx) error {
+		// clear out rows of a previous attempt at this batch
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+rawTableIdentifier+" WHERE _peerdb_batch_id=?", req.SyncBatchID); err != nil {
+			return fmt.Errorf("failed to clear raw table: %w", err)
+		}
+
+		rows := make([][]any, 0, insertBatchSize)
+		for record := range req.Records.GetRecords() {
+			var row []any
+			switch typedRecord := record.(type) {
+			case *model.InsertRecord[model.RecordItems]:
+				itemsJSON, err := typedRecord.Items.ToJSONWithOptions(jsonOptions)
+				if err != nil {
+					return fmt.Errorf("failed to serialize insert record items to JSON: %w", err)
+				}
+				row = []any{
+					uuid.NewString(), time.Now().UnixNano(), typedRecord.DestinationTableName,
+					itemsJSON, 0, "{}", req.SyncBatchID, "",
+				}
+			case *model.UpdateRecord[model.RecordItems]:
+				newItemsJSON, err := typedRecord.NewItems.ToJSONWithOptions(jsonOptions)
+				if err != nil {
+					return fmt.Errorf("failed to serialize update record new items to JSON: %w", err)
+				}
+				oldItemsJSON, err := typedRecord.OldItems.ToJSONWithOptions(jsonOptions)
+				if err != nil {
+					return fmt.Errorf("failed to serialize update record old items to JSON: %w", err)
+				}
+				row = []any{
+					uuid.NewString(), time.Now().UnixNano(), typedRecord.DestinationTableName,
+					newItemsJSON, 1, oldItemsJSON, req.SyncBatchID, utils.KeysToString(typedRecord.UnchangedToastColumns),
+				}
+			case *model.DeleteRecord[model.RecordItems]:
+				itemsJSON, err := typedRecord.Items.ToJSONWithOptions(jsonOptions)
+				if err != nil {
+					return fmt.Errorf("failed to serialize delete record items to JSON: %w", err)
+				}
+				row = []any{
+					uuid.NewString(), time.Now().UnixNano(), typedRecord.DestinationTableName,
+					itemsJSON, 2, itemsJSON, req.SyncBatchID, "",
+				}
+			case *model.TruncateRecord[model.RecordItems]:
+				// applied by normalize according to the truncate policy
+				row = []any{
+					uuid.NewString(), time.Now().UnixNano(), typedRecord.DestinationTableName,
+					"{}", 3, "{}", req.SyncBatchID, "",
+				}
+			case *model.MessageRecord[model.RecordItems], *model.RelationRecord[model.RecordItems]:
+				continue
+			default:
+				return fmt.Errorf("unsupported record type for DuckDB flow connector: %T", typedRecord)
+			}
+
+			record.PopulateCountMap(tableNameRowsMapping)
+			numRecords += 1
+			rows = append(rows, row)
+			if len(rows) >= insertBatchSize {
+				if err := insertRows(ctx, tx, "INSERT", rawTableIdentifier, rawTableColumns, rows); err != nil {
+					return fmt.Errorf("failed to insert into raw table: %w", err)
+				}
+				rows = rows[:0]
+			}
+		}
+		if err := insertRows(ctx, tx, "INSERT", rawTableIdentifier, rawTableColumns, rows); err != nil {
+			return fmt.Errorf("failed to insert into raw table: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	c.logger.Info("synced records to DuckDB raw table",
+		slog.String("table", rawTableIdentifier), slog.Int64("numRecords", numRecords))
+
+	if err := c.ReplayTableSchemaDeltas(ctx, req.Env, req.FlowJobName, req.TableMappings, req.Records.SchemaDeltas); err != nil {
+		return nil, fmt.Errorf("failed to sync schema changes: %w", err)
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		c.logger.Error("failed to increment id", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &model.SyncResponse{
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords,
+		CurrentSyncBatchID:   req.SyncBatchID,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (c *DuckDBConnector) ReplayTableSchemaDeltas(
+	ctx context.Context,
+	env map[string]string,
+	flowJobName string,
+	_ []*protos.TableMapping,
+	schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	// statements are not run in a transaction, duckdb cannot commit updates of a table altered by the same transaction
+	for _, schemaDelta := range schemaDeltas {
+		if schemaDelta == nil ||
+			(len(schemaDelta.AddedColumns) == 0 && len(schemaDelta.DroppedColumns) == 0 && len(schemaDelta.AlteredColumns) == 0) {
+			continue
+		}
+		if err := c.replaySchemaDelta(ctx, env, schemaDelta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *DuckDBConnector) replaySchemaDelta(
+	ctx context.Context,
+	env map[string]string,
+	schemaDelta *protos.TableSchemaDelta,
+) error {
+	dstTable, err := utils.ParseSchemaTable(schemaDelta.DstTableName)
+	if err != nil {
+		return fmt.Errorf("error parsing destination table %s: %w", schemaDelta.DstTableName, err)
+	}
+
+	for _, addedColumn := range schemaDelta.AddedColumns {
+		columnType, err := qvalue.ToDWHColumnType(
+			ctx, types.QValueKind(addedColumn.Type), env, protos.DBType_DUCKDB, nil, addedColumn, schemaDelta.NullableEnabled,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to convert column type %s to DuckDB type: %w", addedColumn.Type, err)
+		}
+		// NOT NULL cannot be added to a table with rows
+		columnType = strings.TrimSuffix(columnType, " NOT NULL")
+		if _, err := c.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s",
+			dstTable.String(), utils.QuoteIdentifier(addedColumn.Name), columnType),
+		); err != nil {
+			return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name, schemaDelta.DstTableName, err)
+		}
+		c.logger.Info("[schema delta replay] added column",
+			slog.String("column", addedColumn.Name),
+			slog.String("type", columnType),
+			slog.String("destination table name", schemaDelta.DstTableName),
+			slog.String("source table name", schemaDelta.SrcTableName))
+	}
+
+	policy := schemaDelta.DroppedColumnPolicy
+	for _, droppedColumn := range schemaDelta.DroppedColumns {
+		for _, stmt := range droppedColumnStmts(dstTable.String(), droppedColumn.Name, policy) {
+			if _, err := c.db.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("failed to replay dropped column %s for table %s: %w",
+					droppedColumn.Name, schemaDelta.DstTableName, err)
+			}
+		}
+		c.logger.Info("[schema delta replay] replayed dropped column",
+			slog.String("column", droppedColumn.Name),
+			slog.String("policy", policy.String()),
+			slog.String("destination table name", schemaDelta.DstTableName),
+			slog.String("source table name", schemaDelta.SrcTableName))
+	}
+
+	for _, alteredColumn := range schemaDelta.AlteredColumns {
+		previousType, err := qvalue.ToDWHColumnType(
+			ctx, types.QValueKind(alteredColumn.Previous.Type), env, protos.DBType_DUCKDB, nil, alteredColumn.Previous, false,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to convert column type %s to DuckDB type: %w", alteredColumn.Previous.Type, err)
+		}
+		currentType, err := qvalue.ToDWHColumnType(
+			ctx, types.QValueKind(alteredColumn.Current.Type), env, protos.DBType_DUCKDB, nil, alteredColumn.Current, false,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to convert column type %s to DuckDB type: %w", alteredColumn.Current.Type, err)
+		}
+		if previousType == currentType {
+			continue
+		}
+		stmts := alteredColumnStmts(dstTable.String(), alteredColumn, currentType, policy)
+		if len(stmts) == 0 {
+			c.logger.Warn(fmt.Sprintf("[schema delta replay] column %s changed type from %s to %s incompatibly, not propagating",
+				alteredColumn.Current.Name, previousType, currentType),
+				slog.String("destination table name", schemaDelta.DstTableName),
+				slog.String("source table name", schemaDelta.SrcTableName))
+			continue
+		}
+		for _, stmt := range stmts {
+			if _, err := c.db.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("failed to alter column %s for table %s: %w",
+					alteredColumn.Current.Name, schemaDelta.DstTableName, err)
+			}
+		}
+		c.logger.Info(fmt.Sprintf("[schema delta replay] altered column %s from data type %s to %s",
+			alteredColumn.Current.Name, previousType, currentType),
+			slog.String("destination table name", schemaDelta.DstTableName),
+			slog.String("source table name", schemaDelta.SrcTableName))
+	}
+	return nil
+}
+
+func (c *DuckDBConnector) SyncFlowCleanup(ctx context.Context, jobName string) error {
+	if _, err := c.db.ExecContext(ctx, "DROP TABLE IF EXISTS "+getRawTableIdentifier(jobName)); err != nil {
+		return fmt.Errorf("[duckdb] unable to drop raw table: %w", err)
+	}
+	return c.PostgresMetadata.SyncFlowCleanup(ctx, jobName)
+}
+
+// droppedColumnStmts returns the statements applying policy to a column dropped at source
+func droppedColumnStmts(dstTable string, column string, policy protos.DroppedColumnPolicy) []string {
+	columnName := utils.QuoteIdentifier(column)
+	switch policy {
+	case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL:
+		return []string{
+			fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", dstTable, columnName),
+			fmt.Sprintf("UPDATE %s SET %s = NULL", dstTable, columnName),
+		}
+	case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP:
+		return []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s", dstTable, columnName)}
+	default:
+		return nil
+	}
+}
+
+// alteredColumnStmts returns the statements retyping a column to currentType, none if it is kept as is.
+// Widened columns are cast in place, other changes follow policy like a dropped column.
+func alteredColumnStmts(
+	dstTable string,
+	alteredColumn *protos.AlteredColumn,
+	currentType string,
+	policy protos.DroppedColumnPolicy,
+) []string {
+	columnName := utils.QuoteIdentifier(alteredColumn.Current.Name)
+	if types.QValueKind(alteredColumn.Previous.Type).CanWidenTo(types.QValueKind(alteredColumn.Current.Type)) {
+		return []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", dstTable, columnName, currentType)}
+	}
+	switch policy {
+	case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL:
+		return []string{
+			fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", dstTable, columnName),
+			fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING NULL", dstTable, columnName, currentType),
+		}
+	case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP:
+		return []string{
+			fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s", dstTable, columnName),
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", dstTable, columnName, currentType),
+		}
+	default:
+		return nil
+	}
+}
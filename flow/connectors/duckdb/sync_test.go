@@ -0,0 +1,73 @@
+//go:build duckdb
+
+package connduckdb
+
+import (
+	"database/sql"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestReplayTableSchemaDeltas(t *testing.T) {
+	db, err := sql.Open(driverName, "")
+	require.NoError(t, err)
+	defer db.Close()
+	c := &DuckDBConnector{db: db, logger: log.NewStructuredLogger(slog.Default())}
+
+	_, err = db.ExecContext(t.Context(), `CREATE TABLE main.t (id BIGINT PRIMARY KEY, a INTEGER NOT NULL, b VARCHAR NOT NULL, c VARCHAR);
+		INSERT INTO main.t VALUES (1, 2, 'x', 'y')`)
+	require.NoError(t, err)
+
+	column := func(name string, kind types.QValueKind) *protos.FieldDescription {
+		return &protos.FieldDescription{Name: name, Type: string(kind)}
+	}
+	require.NoError(t, c.ReplayTableSchemaDeltas(t.Context(), nil, "flow", nil, []*protos.TableSchemaDelta{
+		{
+			DstTableName:        "main.t",
+			DroppedColumnPolicy: protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL,
+			DroppedColumns:      []*protos.FieldDescription{column("b", types.QValueKindString)},
+			AlteredColumns: []*protos.AlteredColumn{{
+				Previous: column("a", types.QValueKindInt32),
+				Current:  column("a", types.QValueKindInt64),
+			}},
+		},
+		{
+			DstTableName:        "main.t",
+			DroppedColumnPolicy: protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP,
+			AlteredColumns: []*protos.AlteredColumn{{
+				Previous: column("c", types.QValueKindString),
+				Current:  column("c", types.QValueKindBoolean),
+			}},
+		},
+	}))
+
+	rows, err := db.QueryContext(t.Context(),
+		"SELECT column_name, data_type FROM information_schema.columns WHERE table_name = 't' ORDER BY column_name")
+	require.NoError(t, err)
+	columnTypes := make(map[string]string)
+	for rows.Next() {
+		var name, dataType string
+		require.NoError(t, rows.Scan(&name, &dataType))
+		columnTypes[name] = dataType
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, map[string]string{"id": "BIGINT", "a": "BIGINT", "b": "VARCHAR", "c": "BOOLEAN"}, columnTypes)
+
+	var a int64
+	var b sql.NullString
+	var cValue sql.NullBool
+	require.NoError(t, db.QueryRowContext(t.Context(), "SELECT a, b, c FROM main.t").Scan(&a, &b, &cValue))
+	require.Equal(t, int64(2), a)
+	require.False(t, b.Valid)
+	require.False(t, cValue.Valid)
+
+	// b is nullable now, so rows without it can be written
+	_, err = db.ExecContext(t.Context(), "INSERT INTO main.t (id, a) VALUES (2, 3)")
+	require.NoError(t, err)
+}
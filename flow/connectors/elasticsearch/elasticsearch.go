@@ -25,7 +25,6 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/shared"
-	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 const (
@@ -37,6 +36,21 @@ type ElasticsearchConnector struct {
 	*metadataStore.PostgresMetadata
 	client *elasticsearch.Client
 	logger log.Logger
+	flavor protos.ElasticsearchFlavor
+}
+
+// openSearchTransport marks OpenSearch responses as Elasticsearch ones,
+// the client refuses to talk to a server that does not identify as Elasticsearch
+type openSearchTransport struct {
+	http.RoundTripper
+}
+
+func (t openSearchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.RoundTripper.RoundTrip(req)
+	if err == nil && res.Header.Get("X-Elastic-Product") == "" {
+		res.Header.Set("X-Elastic-Product", "Elasticsearch")
+	}
+	return res, err
 }
 
 func NewElasticsearchConnector(ctx context.Context,
@@ -53,8 +67,15 @@ func NewElasticsearchConnector(ctx context.Context,
 		esCfg.Username = *config.Username
 		esCfg.Password = *config.Password
 	} else if config.AuthType == protos.ElasticsearchAuthType_APIKEY {
+		if config.Flavor == protos.ElasticsearchFlavor_ELASTICSEARCH_FLAVOR_OPENSEARCH {
+			return nil, errors.New("API key authentication is not supported by OpenSearch")
+		}
 		esCfg.APIKey = *config.ApiKey
 	}
+	if config.Flavor == protos.ElasticsearchFlavor_ELASTICSEARCH_FLAVOR_OPENSEARCH {
+		esCfg.Transport = openSearchTransport{RoundTripper: esCfg.Transport}
+		esCfg.DisableMetaHeader = true
+	}
 
 	esClient, err := elasticsearch.NewClient(*esCfg)
 	if err != nil {
@@ -69,6 +90,7 @@ func NewElasticsearchConnector(ctx context.Context,
 		PostgresMetadata: pgMetadata,
 		client:           esClient,
 		logger:           internal.LoggerFromCtx(ctx),
+		flavor:           config.Flavor,
 	}, nil
 }
 
@@ -92,28 +114,68 @@ func (esc *ElasticsearchConnector) CreateRawTable(ctx context.Context,
 	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
 }
 
-// we handle schema changes by not handling them since no mapping is being enforced right now
-func (esc *ElasticsearchConnector) ReplayTableSchemaDeltas(ctx context.Context, env map[string]string,
-	flowJobName string, _ []*protos.TableMapping, schemaDeltas []*protos.TableSchemaDelta,
+// mappedFields has the columns of each index that were mapped, or left to dynamic mapping
+type mappedFields map[string]map[string]struct{}
+
+// mapNewColumns maps columns of index that are not mapped yet like on index creation
+func (esc *ElasticsearchConnector) mapNewColumns(ctx context.Context, mapped mappedFields, index string,
+	columns []*protos.FieldDescription, tableMappings []*protos.TableMapping,
 ) error {
+	known, ok := mapped[index]
+	if !ok {
+		known = make(map[string]struct{}, len(columns))
+		mapped[index] = known
+	}
+	var added []*protos.FieldDescription
+	for _, column := range columns {
+		if _, ok := known[column.Name]; !ok {
+			known[column.Name] = struct{}{}
+			added = append(added, column)
+		}
+	}
+	properties := generateMappings(esc.flavor, added, findTableMapping(tableMappings, index).GetColumns())
+	if len(properties) == 0 {
+		return nil
+	}
+	if err := esc.putMappings(ctx, index, properties); err != nil {
+		return err
+	}
+	esc.logger.Info("[es] mapped added columns", slog.String("index", index), slog.Int("mappedFields", len(properties)))
+	return nil
+}
+
+// mapAddedColumns maps columns added by schema deltas that are not mapped yet
+func (esc *ElasticsearchConnector) mapAddedColumns(ctx context.Context, mapped mappedFields,
+	tableMappings []*protos.TableMapping, schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	for _, schemaDelta := range schemaDeltas {
+		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+			continue
+		}
+		if err := esc.mapNewColumns(ctx, mapped, schemaDelta.DstTableName, schemaDelta.AddedColumns, tableMappings); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func recordItemsProcessor(items model.RecordItems) ([]byte, error) {
+// added columns are mapped like on index creation, other schema changes need no mapping changes
+func (esc *ElasticsearchConnector) ReplayTableSchemaDeltas(ctx context.Context, env map[string]string,
+	flowJobName string, tableMappings []*protos.TableMapping, schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	return esc.mapAddedColumns(ctx, mappedFields{}, tableMappings, schemaDeltas)
+}
+
+// recordItemsProcessor encodes items as a document, columns named by their field
+func recordItemsProcessor(items model.RecordItems, settings []*protos.ColumnSetting) ([]byte, error) {
 	qRecordJsonMap := make(map[string]any)
 
 	for key, val := range items.ColToVal {
-		if r, ok := val.(types.QValueJSON); ok { // JSON is stored as a string, fix that
-			qRecordJsonMap[key] = json.RawMessage(
-				shared.UnsafeFastStringToReadOnlyBytes(r.Val))
-		} else {
-			qRecordJsonMap[key] = val.Value()
-		}
+		qRecordJsonMap[fieldName(settings, key)] = documentValue(val, columnSetting(settings, key))
 	}
 
 	return json.Marshal(qRecordJsonMap)
 }
-
 func (esc *ElasticsearchConnector) SyncRecords(ctx context.Context,
 	req *model.SyncRecordsRequest[model.RecordItems],
 ) (*model.SyncResponse, error) {
@@ -172,13 +234,16 @@ func (esc *ElasticsearchConnector) SyncRecords(ctx context.Context,
 		}
 	}()
 
+	mapped := make(mappedFields)
 	var docId string
 	var bulkIndexFatalError error
 	var bulkIndexErrors []error
 	var bulkIndexOnFailureMutex sync.Mutex
 
 	for record := range req.Records.GetRecords() {
-		if _, ok := record.(*model.MessageRecord[model.RecordItems]); ok {
+		switch record.(type) {
+		case *model.MessageRecord[model.RecordItems], *model.TruncateRecord[model.RecordItems],
+			*model.RelationRecord[model.RecordItems]:
 			continue
 		}
 
@@ -188,7 +253,27 @@ func (esc *ElasticsearchConnector) SyncRecords(ctx context.Context,
 
 		switch record.(type) {
 		case *model.InsertRecord[model.RecordItems], *model.UpdateRecord[model.RecordItems]:
-			bodyBytes, err = recordItemsProcessor(record.GetItems())
+			// columns added since the schema was loaded are mapped before the first document that has them
+			index := record.GetDestinationTableName()
+			if _, ok := mapped[index]; !ok {
+				known := make(map[string]struct{})
+				for _, column := range req.TableNameSchemaMapping[index].GetColumns() {
+					known[column.Name] = struct{}{}
+				}
+				mapped[index] = known
+			}
+			var columns []*protos.FieldDescription
+			for column, value := range record.GetItems().ColToVal {
+				if _, ok := mapped[index][column]; !ok {
+					columns = append(columns, &protos.FieldDescription{Name: column, Type: string(value.Kind())})
+				}
+			}
+			if len(columns) > 0 {
+				if err := esc.mapNewColumns(ctx, mapped, index, columns, req.TableMappings); err != nil {
+					return nil, err
+				}
+			}
+			bodyBytes, err = recordItemsProcessor(record.GetItems(), findTableMapping(req.TableMappings, index).GetColumns())
 			if err != nil {
 				esc.logger.Error("[es] failed to json.Marshal record", slog.Any("error", err))
 				return nil, fmt.Errorf("[es] failed to json.Marshal record: %w", err)
@@ -288,6 +373,11 @@ func (esc *ElasticsearchConnector) SyncRecords(ctx context.Context,
 		}
 	}
 
+	// added columns that no document of the batch had
+	if err := esc.mapAddedColumns(ctx, mapped, req.TableMappings, req.Records.SchemaDeltas); err != nil {
+		return nil, fmt.Errorf("failed to sync schema changes: %w", err)
+	}
+
 	lastCheckpoint := req.Records.GetLastCheckpoint()
 	if err := esc.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
 		return nil, err
@@ -0,0 +1,276 @@
+package connelasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+type fieldMapping map[string]any
+
+type indexMappings struct {
+	Dynamic    bool                    `json:"dynamic"`
+	Properties map[string]fieldMapping `json:"properties"`
+}
+
+// text with a keyword subfield, same as what dynamic mapping picks for strings
+var textFieldMapping = fieldMapping{
+	"type": "text",
+	"fields": map[string]any{
+		"keyword": map[string]any{"type": "keyword", "ignore_above": 256},
+	},
+}
+
+// field types of values as serialized by recordItemsProcessor, arrays map to their element type
+var qvalueKindToFieldType = map[types.QValueKind]string{
+	types.QValueKindBoolean:          "boolean",
+	types.QValueKindInt8:             "byte",
+	types.QValueKindInt16:            "short",
+	types.QValueKindInt32:            "integer",
+	types.QValueKindInt64:            "long",
+	types.QValueKindUInt8:            "short",
+	types.QValueKindUInt16:           "integer",
+	types.QValueKindUInt32:           "long",
+	types.QValueKindUInt64:           "unsigned_long",
+	types.QValueKindInt256:           "keyword",
+	types.QValueKindUInt256:          "keyword",
+	types.QValueKindFloat32:          "float",
+	types.QValueKindFloat64:          "double",
+	types.QValueKindNumeric:          "double",
+	types.QValueKindQChar:            "keyword",
+	types.QValueKindUUID:             "keyword",
+	types.QValueKindEnum:             "keyword",
+	types.QValueKindCIDR:             "keyword",
+	types.QValueKindINET:             "keyword",
+	types.QValueKindMacaddr:          "keyword",
+	types.QValueKindInterval:         "keyword",
+	types.QValueKindDate:             "date",
+	types.QValueKindTimestamp:        "date",
+	types.QValueKindTimestampTZ:      "date",
+	types.QValueKindTime:             "long",
+	types.QValueKindTimeTZ:           "long",
+	types.QValueKindBytes:            "binary",
+	types.QValueKindGeometry:         "geo_shape",
+	types.QValueKindGeography:        "geo_shape",
+	types.QValueKindPoint:            "geo_shape",
+	types.QValueKindArrayBoolean:     "boolean",
+	types.QValueKindArrayInt16:       "short",
+	types.QValueKindArrayInt32:       "integer",
+	types.QValueKindArrayInt64:       "long",
+	types.QValueKindArrayFloat32:     "float",
+	types.QValueKindArrayFloat64:     "double",
+	types.QValueKindArrayNumeric:     "double",
+	types.QValueKindArrayUUID:        "keyword",
+	types.QValueKindArrayEnum:        "keyword",
+	types.QValueKindArrayInterval:    "keyword",
+	types.QValueKindArrayDate:        "date",
+	types.QValueKindArrayTimestamp:   "date",
+	types.QValueKindArrayTimestampTZ: "date",
+}
+
+// fieldMappingForColumn returns nil for kinds left to dynamic mapping,
+// a non empty destination type of the column setting overrides the kind
+func fieldMappingForColumn(
+	flavor protos.ElasticsearchFlavor, column *protos.FieldDescription, setting *protos.ColumnSetting,
+) fieldMapping {
+	if setting != nil && setting.DestinationType != "" {
+		if setting.DestinationType == "text" {
+			return textFieldMapping
+		}
+		return fieldMapping{"type": setting.DestinationType}
+	}
+
+	switch kind := types.QValueKind(column.Type); kind {
+	case types.QValueKindString, types.QValueKindArrayString:
+		return textFieldMapping
+	case types.QValueKindJSON, types.QValueKindJSONB:
+		if flavor == protos.ElasticsearchFlavor_ELASTICSEARCH_FLAVOR_OPENSEARCH {
+			return fieldMapping{"type": "flat_object"}
+		}
+		return fieldMapping{"type": "flattened"}
+	default:
+		if fieldType, ok := qvalueKindToFieldType[kind]; ok {
+			return fieldMapping{"type": fieldType}
+		}
+		return nil
+	}
+}
+
+func columnSetting(settings []*protos.ColumnSetting, column string) *protos.ColumnSetting {
+	for _, setting := range settings {
+		if setting.SourceName == column {
+			return setting
+		}
+	}
+	return nil
+}
+
+// fieldName is the document field of a source column, its destination name when renamed
+func fieldName(settings []*protos.ColumnSetting, column string) string {
+	if setting := columnSetting(settings, column); setting != nil && setting.DestinationName != "" {
+		return setting.DestinationName
+	}
+	return column
+}
+
+// documentValue is the value of a column in a document. JSON is embedded rather than kept as a string,
+// geospatial values mapped to geo_shape lose the SRID prefix of their EWKT, which geo_shape rejects
+func documentValue(val types.QValue, setting *protos.ColumnSetting) any {
+	switch v := val.(type) {
+	case types.QValueJSON:
+		return json.RawMessage(shared.UnsafeFastStringToReadOnlyBytes(v.Val))
+	case types.QValueGeometry, types.QValueGeography, types.QValuePoint:
+		if setting != nil && setting.DestinationType != "" && setting.DestinationType != "geo_shape" {
+			return val.Value()
+		}
+		return geoShapeValue(v.Value().(string))
+	default:
+		return val.Value()
+	}
+}
+
+// geoShapeValue returns the WKT of a WGS84 value, geo_shape coordinates are longitude and latitude
+// so values of other reference systems are left out of the document rather than failing it
+func geoShapeValue(ewkt string) any {
+	srid, wkt, ok := strings.Cut(ewkt, ";")
+	if !ok {
+		if ewkt == "" {
+			return nil
+		}
+		return ewkt
+	}
+	if srid != "SRID=4326" {
+		return nil
+	}
+	return wkt
+}
+
+// generateMappings maps the field of every column that has an explicit field type,
+// columns of the table mapping are matched by source name
+func generateMappings(
+	flavor protos.ElasticsearchFlavor, columns []*protos.FieldDescription, settings []*protos.ColumnSetting,
+) map[string]fieldMapping {
+	properties := make(map[string]fieldMapping, len(columns))
+	for _, column := range columns {
+		if mapping := fieldMappingForColumn(flavor, column, columnSetting(settings, column.Name)); mapping != nil {
+			properties[fieldName(settings, column.Name)] = mapping
+		}
+	}
+	return properties
+}
+
+func findTableMapping(tableMappings []*protos.TableMapping, destinationTableIdentifier string) *protos.TableMapping {
+	for _, tableMapping := range tableMappings {
+		if tableMapping.DestinationTableIdentifier == destinationTableIdentifier {
+			return tableMapping
+		}
+	}
+	return nil
+}
+
+func (esc *ElasticsearchConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (esc *ElasticsearchConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+func (esc *ElasticsearchConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+// SetupNormalizedTable creates the index with explicit mappings generated from the source schema,
+// an existing index is left as is unless resyncing
+func (esc *ElasticsearchConnector) SetupNormalizedTable(
+	ctx context.Context,
+	_ any,
+	config *protos.SetupNormalizedTableBatchInput,
+	destinationTableIdentifier string,
+	tableSchema *protos.TableSchema,
+) (bool, error) {
+	res, err := esc.client.Indices.Exists([]string{destinationTableIdentifier}, esc.client.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return false, fmt.Errorf("failed to check if index %s exists: %w", destinationTableIdentifier, err)
+	}
+	res.Body.Close()
+	if res.StatusCode == http.StatusOK {
+		if !config.IsResync {
+			return true, nil
+		}
+		res, err := esc.client.Indices.Delete([]string{destinationTableIdentifier}, esc.client.Indices.Delete.WithContext(ctx))
+		if err != nil {
+			return false, fmt.Errorf("failed to delete index %s for resync: %w", destinationTableIdentifier, err)
+		}
+		if err := checkResponse(res); err != nil {
+			return false, fmt.Errorf("failed to delete index %s for resync: %w", destinationTableIdentifier, err)
+		}
+	} else if res.StatusCode != http.StatusNotFound {
+		return false, fmt.Errorf("failed to check if index %s exists: status %d", destinationTableIdentifier, res.StatusCode)
+	}
+
+	properties := generateMappings(
+		esc.flavor, tableSchema.Columns, findTableMapping(config.TableMappings, destinationTableIdentifier).GetColumns())
+	body, err := json.Marshal(map[string]any{
+		"mappings": indexMappings{Dynamic: true, Properties: properties},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal mappings for index %s: %w", destinationTableIdentifier, err)
+	}
+	res, err = esc.client.Indices.Create(destinationTableIdentifier,
+		esc.client.Indices.Create.WithContext(ctx), esc.client.Indices.Create.WithBody(bytes.NewReader(body)))
+	if err != nil {
+		return false, fmt.Errorf("failed to create index %s: %w", destinationTableIdentifier, err)
+	}
+	if err := checkResponse(res); err != nil {
+		return false, fmt.Errorf("failed to create index %s: %w", destinationTableIdentifier, err)
+	}
+	esc.logger.Info("[es] created index with mappings",
+		slog.String("index", destinationTableIdentifier), slog.Int("mappedFields", len(properties)))
+	return false, nil
+}
+
+// putMappings adds fields to the mappings of an existing index
+func (esc *ElasticsearchConnector) putMappings(ctx context.Context, index string, properties map[string]fieldMapping) error {
+	body, err := json.Marshal(indexMappings{Dynamic: true, Properties: properties})
+	if err != nil {
+		return fmt.Errorf("failed to marshal mappings for index %s: %w", index, err)
+	}
+	res, err := esc.client.Indices.PutMapping([]string{index}, bytes.NewReader(body),
+		esc.client.Indices.PutMapping.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to update mappings of index %s: %w", index, err)
+	}
+	if res.StatusCode == http.StatusNotFound {
+		// mirrors created before indexes were set up up front get their index on first write
+		res.Body.Close()
+		return nil
+	}
+	if err := checkResponse(res); err != nil {
+		return fmt.Errorf("failed to update mappings of index %s: %w", index, err)
+	}
+	return nil
+}
+
+// checkResponse closes res, returning status and body of non 2xx responses as an error
+func checkResponse(res *esapi.Response) error {
+	defer res.Body.Close()
+	if !res.IsError() {
+		return nil
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("status %d", res.StatusCode)
+	}
+	return fmt.Errorf("status %d: %s", res.StatusCode, body)
+}
@@ -0,0 +1,65 @@
+package connelasticsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestGenerateMappings(t *testing.T) {
+	columns := []*protos.FieldDescription{
+		{Name: "id", Type: string(types.QValueKindInt64)},
+		{Name: "amount", Type: string(types.QValueKindNumeric)},
+		{Name: "name", Type: string(types.QValueKindString)},
+		{Name: "code", Type: string(types.QValueKindString)},
+		{Name: "doc", Type: string(types.QValueKindJSONB)},
+		{Name: "location", Type: string(types.QValueKindGeometry)},
+		{Name: "tags", Type: string(types.QValueKindHStore)},
+	}
+	settings := []*protos.ColumnSetting{
+		{SourceName: "code", DestinationType: "keyword"},
+		{SourceName: "name", DestinationName: "title"},
+	}
+
+	properties := generateMappings(protos.ElasticsearchFlavor_ELASTICSEARCH_FLAVOR_ELASTICSEARCH, columns, settings)
+	require.Equal(t, map[string]fieldMapping{
+		"id":       {"type": "long"},
+		"amount":   {"type": "double"},
+		"title":    textFieldMapping,
+		"code":     {"type": "keyword"},
+		"doc":      {"type": "flattened"},
+		"location": {"type": "geo_shape"},
+	}, properties)
+
+	properties = generateMappings(protos.ElasticsearchFlavor_ELASTICSEARCH_FLAVOR_OPENSEARCH, columns, nil)
+	require.Equal(t, fieldMapping{"type": "flat_object"}, properties["doc"])
+	require.Equal(t, textFieldMapping, properties["code"])
+}
+
+func TestRecordItemsProcessor(t *testing.T) {
+	items := model.NewRecordItems(2)
+	items.AddColumn("id", types.QValueInt64{Val: 1})
+	items.AddColumn("name", types.QValueString{Val: "a"})
+
+	doc, err := recordItemsProcessor(items, []*protos.ColumnSetting{{SourceName: "name", DestinationName: "title"}})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1,"title":"a"}`, string(doc))
+}
+
+func TestRecordItemsProcessorGeo(t *testing.T) {
+	// EWKT as GeoValidate renders PostGIS values, geo_shape rejects the SRID prefix
+	items := model.NewRecordItems(4)
+	items.AddColumn("geog", types.QValueGeography{Val: "SRID=4326;POINT(1 2)"})
+	items.AddColumn("projected", types.QValueGeometry{Val: "SRID=3857;POINT(111319.49 222684.21)"})
+	items.AddColumn("plain", types.QValuePoint{Val: "POINT(1 2)"})
+	items.AddColumn("raw", types.QValueGeometry{Val: "SRID=3857;POINT(1 2)"})
+
+	doc, err := recordItemsProcessor(items, []*protos.ColumnSetting{{SourceName: "raw", DestinationType: "keyword"}})
+	require.NoError(t, err)
+	require.JSONEq(t,
+		`{"geog":"POINT(1 2)","projected":null,"plain":"POINT(1 2)","raw":"SRID=3857;POINT(1 2)"}`, string(doc))
+}
@@ -186,9 +186,14 @@ func (c *EventHubConnector) processBatch(
 
 	numRecords := atomic.Uint32{}
 
+	var encoder *utils.DebeziumEncoder
+	if req.QueueEncoding == protos.QueueEncoding_QUEUE_ENCODING_DEBEZIUM {
+		encoder = utils.NewDebeziumEncoder(req.FlowJobName, req.TableNameSchemaMapping, false)
+	}
+
 	var ls *lua.LState
 	var fn *lua.LFunction
-	if req.Script != "" {
+	if req.Script != "" && encoder == nil {
 		var err error
 		ls, err = utils.LoadScript(ctx, req.Script, utils.LuaPrintFn(func(s string) {
 			_ = c.LogFlowInfo(ctx, req.FlowJobName, s)
@@ -222,6 +227,11 @@ func (c *EventHubConnector) processBatch(
 				return currNumRecords, nil
 			}
 
+			if _, ok := record.(*model.RelationRecord[model.RecordItems]); ok {
+				// schema changes are not passed to scripts or encoders
+				continue
+			}
+
 			recordLSN := record.GetCheckpointID()
 			if recordLSN > lastSeenLSN {
 				lastSeenLSN = recordLSN
@@ -229,7 +239,23 @@ func (c *EventHubConnector) processBatch(
 
 			var events []ScopedEventhubData
 			destinationString := record.GetDestinationTableName()
-			if fn != nil {
+			if encoder != nil {
+				messages, err := encoder.Encode(record)
+				if err != nil {
+					return 0, err
+				}
+				scopedHub, err := NewScopedEventhub(destinationString)
+				if err != nil {
+					c.logger.Error("failed to get topic name", slog.Any("error", err))
+					return 0, err
+				}
+				for _, message := range messages {
+					// Event Hubs is not log compacted, so tombstones are dropped
+					if message.Value != nil {
+						events = append(events, ScopedEventhubData{Hub: scopedHub, Data: &azeventhubs.EventData{Body: message.Value}})
+					}
+				}
+			} else if fn != nil {
 				ls.Push(fn)
 				ls.Push(pua.LuaRecord.New(ls, record))
 				err := ls.PCall(1, -1, nil)
@@ -23,8 +23,9 @@ import (
 )
 
 const (
-	lastSyncStateTableName = "metadata_last_sync_state"
-	qrepTableName          = "metadata_qrep_partitions"
+	lastSyncStateTableName        = "metadata_last_sync_state"
+	qrepTableName                 = "metadata_qrep_partitions"
+	incrementalSnapshotsTableName = "metadata_incremental_snapshots"
 )
 
 type PostgresMetadata struct {
@@ -315,5 +316,9 @@ func SyncFlowCleanupInTx(ctx context.Context, tx pgx.Tx, jobName string) error {
 		return err
 	}
 
+	if _, err := tx.Exec(ctx, `DELETE FROM `+incrementalSnapshotsTableName+` WHERE job_name = $1`, jobName); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,120 @@
+package conniceberg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type catalog interface {
+	validate(ctx context.Context) error
+	// loadTable returns nil metadata if the table does not exist.
+	loadTable(ctx context.Context, namespace string, table string) (*tableMetadata, error)
+	createTable(
+		ctx context.Context, namespace string, table string, schema *icebergSchema, properties map[string]string,
+	) (*tableMetadata, error)
+	// commitTable atomically applies update on top of base, failing if the table changed since base was loaded.
+	commitTable(ctx context.Context, namespace string, table string, base *tableMetadata, update *tableUpdate) (*tableMetadata, error)
+}
+
+// filesystemCatalog keeps tables in the Hadoop layout directly in the warehouse:
+// <warehouse>/<namespace>/<table>/metadata/v<N>.metadata.json, with version-hint.text pointing at the latest version.
+// Commits rely on conditional writes so that only one writer can create a given version.
+type filesystemCatalog struct {
+	connector *IcebergConnector
+	warehouse string
+}
+
+func (f *filesystemCatalog) validate(context.Context) error {
+	return nil
+}
+
+func (f *filesystemCatalog) tableLocation(namespace string, table string) string {
+	return f.warehouse + "/" + namespace + "/" + table
+}
+
+func versionMetadataPath(location string, version int) string {
+	return metadataFilePath(location, fmt.Sprintf("v%d.metadata.json", version))
+}
+
+func (f *filesystemCatalog) loadTable(ctx context.Context, namespace string, table string) (*tableMetadata, error) {
+	location := f.tableLocation(namespace, table)
+	hint, err := f.connector.getObject(ctx, metadataFilePath(location, "version-hint.text"))
+	if errors.Is(err, errObjectNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(string(hint)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid version hint for %s.%s: %w", namespace, table, err)
+	}
+
+	data, err := f.connector.getObject(ctx, versionMetadataPath(location, version))
+	if err != nil {
+		return nil, err
+	}
+	// the hint is written after the metadata file, so it may lag behind a commit that failed midway
+	for {
+		next, err := f.connector.getObject(ctx, versionMetadataPath(location, version+1))
+		if errors.Is(err, errObjectNotFound) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		version++
+		data = next
+	}
+
+	var metadata tableMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata of %s.%s: %w", namespace, table, err)
+	}
+	metadata.version = version
+	metadata.metadataLocation = versionMetadataPath(location, version)
+	return &metadata, nil
+}
+
+func (f *filesystemCatalog) writeVersion(ctx context.Context, metadata *tableMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to serialize table metadata: %w", err)
+	}
+	path := versionMetadataPath(metadata.Location, metadata.version)
+	if err := f.connector.putObject(ctx, path, data, true); err != nil {
+		return fmt.Errorf("failed to commit table metadata version %d, table may have been modified concurrently: %w",
+			metadata.version, err)
+	}
+	metadata.metadataLocation = path
+	return f.connector.putObject(ctx, metadataFilePath(metadata.Location, "version-hint.text"),
+		[]byte(strconv.Itoa(metadata.version)), false)
+}
+
+func (f *filesystemCatalog) createTable(
+	ctx context.Context, namespace string, table string, schema *icebergSchema, properties map[string]string,
+) (*tableMetadata, error) {
+	metadata := newTableMetadata(uuid.NewString(), f.tableLocation(namespace, table), schema, properties)
+	metadata.version = 1
+	if err := f.writeVersion(ctx, metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func (f *filesystemCatalog) commitTable(
+	ctx context.Context, _ string, _ string, base *tableMetadata, update *tableUpdate,
+) (*tableMetadata, error) {
+	next, err := base.apply(update)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.writeVersion(ctx, next); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
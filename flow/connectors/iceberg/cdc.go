@@ -0,0 +1,519 @@
+package conniceberg
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func (c *IcebergConnector) CreateRawTable(_ context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	c.logger.Info("CreateRawTable for Iceberg is a no-op")
+	return nil, nil
+}
+
+func (c *IcebergConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (c *IcebergConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+func (c *IcebergConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+// SetupNormalizedTable creates the Iceberg table, with primary key columns as identifier fields
+// so that CDC updates and deletes can be written as equality deletes on them.
+func (c *IcebergConnector) SetupNormalizedTable(
+	ctx context.Context,
+	_ any,
+	config *protos.SetupNormalizedTableBatchInput,
+	destinationTableIdentifier string,
+	tableSchema *protos.TableSchema,
+) (bool, error) {
+	namespace, table, err := c.tableIdentifier(destinationTableIdentifier)
+	if err != nil {
+		return false, err
+	}
+	existing, err := c.catalog.loadTable(ctx, namespace, table)
+	if err != nil {
+		return false, fmt.Errorf("failed to load iceberg table %s: %w", destinationTableIdentifier, err)
+	} else if existing != nil {
+		return true, nil
+	}
+
+	var lastID int
+	nextID := func() int {
+		lastID++
+		return lastID
+	}
+	schema := &icebergSchema{Type: "struct"}
+	for _, column := range tableSchema.Columns {
+		id := nextID()
+		required := slices.Contains(tableSchema.PrimaryKeyColumns, column.Name)
+		schema.Fields = append(schema.Fields, icebergField{
+			ID:       id,
+			Name:     column.Name,
+			Required: required,
+			Type:     icebergTypeForKind(types.QValueKind(column.Type), column.TypeModifier, nextID),
+		})
+		if required {
+			schema.IdentifierFieldIDs = append(schema.IdentifierFieldIDs, id)
+		}
+	}
+
+	properties := map[string]string{
+		"write.format.default": "parquet",
+		"write.delete.mode":    "merge-on-read",
+		"write.update.mode":    "merge-on-read",
+	}
+	if config.SoftDeleteColName != "" {
+		schema.Fields = append(schema.Fields, icebergField{
+			ID: nextID(), Name: config.SoftDeleteColName, Type: primitiveType("boolean"),
+		})
+		properties[softDeleteColumnProperty] = config.SoftDeleteColName
+	}
+	if config.SyncedAtColName != "" {
+		schema.Fields = append(schema.Fields, icebergField{
+			ID: nextID(), Name: config.SyncedAtColName, Type: primitiveType("timestamptz"),
+		})
+		properties[syncedAtColumnProperty] = config.SyncedAtColName
+	}
+
+	if _, err := c.catalog.createTable(ctx, namespace, table, schema, properties); err != nil {
+		return false, fmt.Errorf("failed to create iceberg table %s: %w", destinationTableIdentifier, err)
+	}
+	c.logger.Info("created iceberg table", slog.String("table", destinationTableIdentifier))
+	return false, nil
+}
+
+type bufferedRow struct {
+	items   model.RecordItems
+	deleted bool
+}
+
+// tableBuffer holds the final state of each row touched in a batch. Every touched key is also written
+// as an equality delete, which removes rows from earlier snapshots but not those added in the same snapshot.
+type tableBuffer struct {
+	rows       map[string]*bufferedRow
+	deleteKeys map[string]model.RecordItems
+	// rows of tables without primary key, which are only ever appended
+	appended []model.RecordItems
+	order    []string
+	pkCols   []string
+}
+
+func newTableBuffer(pkCols []string) *tableBuffer {
+	return &tableBuffer{
+		rows:       make(map[string]*bufferedRow),
+		deleteKeys: make(map[string]model.RecordItems),
+		pkCols:     pkCols,
+	}
+}
+
+// key returns an encoding of the primary key values of items, or false if a key column is missing.
+func (b *tableBuffer) key(items model.RecordItems) (string, bool) {
+	var sb strings.Builder
+	for _, col := range b.pkCols {
+		qv, ok := items.ColToVal[col]
+		if !ok {
+			return "", false
+		}
+		value := stringValue(qv)
+		sb.WriteString(strconv.Itoa(len(value)))
+		sb.WriteByte(':')
+		sb.WriteString(value)
+	}
+	return sb.String(), true
+}
+
+func (b *tableBuffer) put(key string, row *bufferedRow) {
+	if _, ok := b.rows[key]; !ok {
+		b.order = append(b.order, key)
+	}
+	b.rows[key] = row
+	b.deleteKeys[key] = row.items
+}
+
+func (c *IcebergConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	buffers := make(map[string]*tableBuffer)
+	getBuffer := func(table string) *tableBuffer {
+		buffer, ok := buffers[table]
+		if !ok {
+			var pkCols []string
+			if schema, ok := req.TableNameSchemaMapping[table]; ok {
+				pkCols = schema.PrimaryKeyColumns
+			}
+			buffer = newTableBuffer(pkCols)
+			buffers[table] = buffer
+		}
+		return buffer
+	}
+
+	var numRecords int64
+	for record := range req.Records.GetRecords() {
+		record.PopulateCountMap(tableNameRowsMapping)
+		switch r := record.(type) {
+		case *model.InsertRecord[model.RecordItems]:
+			buffer := getBuffer(r.DestinationTableName)
+			if len(buffer.pkCols) == 0 {
+				buffer.appended = append(buffer.appended, r.Items)
+			} else if key, ok := buffer.key(r.Items); ok {
+				buffer.put(key, &bufferedRow{items: r.Items})
+			} else {
+				return nil, fmt.Errorf("insert into %s is missing primary key columns", r.DestinationTableName)
+			}
+		case *model.UpdateRecord[model.RecordItems]:
+			buffer := getBuffer(r.DestinationTableName)
+			if len(buffer.pkCols) == 0 {
+				return nil, fmt.Errorf("cannot apply update to %s, Iceberg destination tables require a primary key",
+					r.DestinationTableName)
+			}
+			newKey, ok := buffer.key(r.NewItems)
+			if !ok {
+				return nil, fmt.Errorf("update of %s is missing primary key columns", r.DestinationTableName)
+			}
+			if len(r.UnchangedToastColumns) > 0 {
+				if previous, ok := buffer.rows[newKey]; ok && !previous.deleted {
+					r.NewItems.UpdateIfNotExists(previous.items)
+				}
+				r.NewItems.UpdateIfNotExists(r.OldItems)
+				for col := range r.UnchangedToastColumns {
+					if _, ok := r.NewItems.ColToVal[col]; !ok {
+						c.logger.Warn("unchanged toast column not available, writing null",
+							slog.String("table", r.DestinationTableName), slog.String("column", col))
+					}
+				}
+			}
+			if oldKey, ok := buffer.key(r.OldItems); ok && oldKey != newKey {
+				buffer.put(oldKey, &bufferedRow{items: r.OldItems, deleted: true})
+			}
+			buffer.put(newKey, &bufferedRow{items: r.NewItems})
+		case *model.DeleteRecord[model.RecordItems]:
+			buffer := getBuffer(r.DestinationTableName)
+			if len(buffer.pkCols) == 0 {
+				return nil, fmt.Errorf("cannot apply delete to %s, Iceberg destination tables require a primary key",
+					r.DestinationTableName)
+			}
+			key, ok := buffer.key(r.Items)
+			if !ok {
+				return nil, fmt.Errorf("delete from %s is missing primary key columns", r.DestinationTableName)
+			}
+			if previous, ok := buffer.rows[key]; ok && !previous.deleted {
+				r.Items.UpdateIfNotExists(previous.items)
+			}
+			buffer.put(key, &bufferedRow{items: r.Items, deleted: true})
+		case *model.TruncateRecord[model.RecordItems]:
+			c.logger.Warn("truncate is not supported for Iceberg destinations, skipping",
+				slog.String("table", r.DestinationTableName))
+			continue
+		case *model.MessageRecord[model.RecordItems]:
+			continue
+		default:
+			continue
+		}
+		numRecords++
+	}
+
+	if err := c.ReplayTableSchemaDeltas(ctx, req.Env, req.FlowJobName, req.TableMappings, req.Records.SchemaDeltas); err != nil {
+		return nil, fmt.Errorf("failed to sync schema changes: %w", err)
+	}
+
+	syncedAt := time.Now()
+	for table, buffer := range buffers {
+		if err := c.commitTableBuffer(ctx, table, buffer, req.SyncBatchID, syncedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		c.logger.Error("failed to increment id", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &model.SyncResponse{
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords,
+		CurrentSyncBatchID:   req.SyncBatchID,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
+
+func snapshotWithSummary(metadata *tableMetadata, key string, value string) bool {
+	for _, snap := range metadata.Snapshots {
+		if snap.Summary[key] == value {
+			return true
+		}
+	}
+	return false
+}
+
+// buildRow converts items into values for each field of the schema, filling PeerDB's own columns.
+func buildRow(schema *icebergSchema, properties map[string]string, items model.RecordItems, deleted bool, syncedAt time.Time) []any {
+	softDeleteCol := properties[softDeleteColumnProperty]
+	syncedAtCol := properties[syncedAtColumnProperty]
+	row := make([]any, 0, len(schema.Fields))
+	for _, field := range schema.Fields {
+		switch {
+		case softDeleteCol != "" && field.Name == softDeleteCol:
+			row = append(row, deleted)
+		case syncedAtCol != "" && field.Name == syncedAtCol:
+			row = append(row, syncedAt)
+		default:
+			row = append(row, columnValue(items.GetColumnValue(field.Name), field.Type))
+		}
+	}
+	return row
+}
+
+func (c *IcebergConnector) commitTableBuffer(
+	ctx context.Context,
+	destinationTable string,
+	buffer *tableBuffer,
+	syncBatchID int64,
+	syncedAt time.Time,
+) error {
+	namespace, table, err := c.tableIdentifier(destinationTable)
+	if err != nil {
+		return err
+	}
+	metadata, err := c.catalog.loadTable(ctx, namespace, table)
+	if err != nil {
+		return fmt.Errorf("failed to load iceberg table %s: %w", destinationTable, err)
+	} else if metadata == nil {
+		return fmt.Errorf("iceberg table %s does not exist", destinationTable)
+	}
+	batchID := strconv.FormatInt(syncBatchID, 10)
+	if snapshotWithSummary(metadata, syncBatchIDSummaryKey, batchID) {
+		c.logger.Info("batch already committed to iceberg table, skipping",
+			slog.String("table", destinationTable), slog.Int64("batchId", syncBatchID))
+		return nil
+	}
+	schema, err := metadata.currentSchema()
+	if err != nil {
+		return err
+	}
+	hasSoftDelete := metadata.Properties[softDeleteColumnProperty] != ""
+
+	rows := make([][]any, 0, len(buffer.order)+len(buffer.appended))
+	for _, items := range buffer.appended {
+		rows = append(rows, buildRow(schema, metadata.Properties, items, false, syncedAt))
+	}
+	for _, key := range buffer.order {
+		row := buffer.rows[key]
+		if !row.deleted || hasSoftDelete {
+			rows = append(rows, buildRow(schema, metadata.Properties, row.items, row.deleted, syncedAt))
+		}
+	}
+
+	var dataFiles, deleteFiles []dataFile
+	var nulled int
+	if len(rows) > 0 {
+		file, n, err := c.writeParquetFile(ctx, metadata.Location, schema.Fields, rows, contentData, nil)
+		if err != nil {
+			return fmt.Errorf("failed to write data file for %s: %w", destinationTable, err)
+		}
+		dataFiles = append(dataFiles, file)
+		nulled += n
+	}
+	if len(buffer.deleteKeys) > 0 {
+		keyFields := make([]icebergField, 0, len(buffer.pkCols))
+		equalityIDs := make([]int32, 0, len(buffer.pkCols))
+		for _, col := range buffer.pkCols {
+			field := schema.field(col)
+			if field == nil {
+				return fmt.Errorf("primary key column %s not found in iceberg table %s", col, destinationTable)
+			}
+			keyFields = append(keyFields, *field)
+			equalityIDs = append(equalityIDs, int32(field.ID))
+		}
+		keyRows := make([][]any, 0, len(buffer.deleteKeys))
+		for _, key := range buffer.order {
+			items := buffer.deleteKeys[key]
+			keyRow := make([]any, 0, len(keyFields))
+			for _, field := range keyFields {
+				keyRow = append(keyRow, columnValue(items.GetColumnValue(field.Name), field.Type))
+			}
+			keyRows = append(keyRows, keyRow)
+		}
+		file, n, err := c.writeParquetFile(ctx, metadata.Location, keyFields, keyRows, contentEqualityDelete, equalityIDs)
+		if err != nil {
+			return fmt.Errorf("failed to write equality delete file for %s: %w", destinationTable, err)
+		}
+		deleteFiles = append(deleteFiles, file)
+		nulled += n
+	}
+	if nulled > 0 {
+		c.logger.Warn("values not representable in iceberg column types were written as null",
+			slog.String("table", destinationTable), slog.Int("count", nulled))
+	}
+	if len(dataFiles) == 0 && len(deleteFiles) == 0 {
+		return nil
+	}
+
+	operation := "append"
+	if len(deleteFiles) > 0 {
+		operation = "overwrite"
+	}
+	snap, err := c.commitFiles(ctx, metadata, schema, dataFiles, deleteFiles, map[string]string{
+		"operation":              operation,
+		"added-records":          strconv.Itoa(len(rows)),
+		"added-data-files":       strconv.Itoa(len(dataFiles)),
+		"added-delete-files":     strconv.Itoa(len(deleteFiles)),
+		"added-equality-deletes": strconv.Itoa(len(buffer.deleteKeys)),
+		syncBatchIDSummaryKey:    batchID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write manifests for %s: %w", destinationTable, err)
+	}
+	if _, err := c.catalog.commitTable(ctx, namespace, table, metadata, &tableUpdate{snapshot: snap}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *IcebergConnector) ReplayTableSchemaDeltas(
+	ctx context.Context,
+	env map[string]string,
+	flowJobName string,
+	_ []*protos.TableMapping,
+	schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	if len(schemaDeltas) == 0 {
+		return nil
+	}
+	for _, schemaDelta := range schemaDeltas {
+		if schemaDelta == nil {
+			continue
+		}
+		if err := c.replayTableSchemaDelta(ctx, schemaDelta); err != nil {
+			return fmt.Errorf("failed to replay schema delta for %s: %w", schemaDelta.DstTableName, err)
+		}
+	}
+	return nil
+}
+
+func (c *IcebergConnector) replayTableSchemaDelta(
+	ctx context.Context,
+	schemaDelta *protos.TableSchemaDelta,
+) error {
+	droppedColumnPolicy := schemaDelta.DroppedColumnPolicy
+	namespace, table, err := c.tableIdentifier(schemaDelta.DstTableName)
+	if err != nil {
+		return err
+	}
+	metadata, err := c.catalog.loadTable(ctx, namespace, table)
+	if err != nil {
+		return err
+	} else if metadata == nil {
+		return fmt.Errorf("iceberg table %s does not exist", schemaDelta.DstTableName)
+	}
+	current, err := metadata.currentSchema()
+	if err != nil {
+		return err
+	}
+
+	schema := current.clone()
+	lastID := metadata.LastColumnID
+	nextID := func() int {
+		lastID++
+		return lastID
+	}
+	changed := false
+	removeField := func(name string) {
+		schema.Fields = slices.DeleteFunc(schema.Fields, func(field icebergField) bool {
+			if field.Name != name {
+				return false
+			}
+			schema.IdentifierFieldIDs = slices.DeleteFunc(schema.IdentifierFieldIDs, func(id int) bool { return id == field.ID })
+			return true
+		})
+	}
+
+	for _, column := range schemaDelta.AddedColumns {
+		if schema.field(column.Name) != nil {
+			continue
+		}
+		schema.Fields = append(schema.Fields, icebergField{
+			ID:   nextID(),
+			Name: column.Name,
+			Type: icebergTypeForKind(types.QValueKind(column.Type), column.TypeModifier, nextID),
+		})
+		changed = true
+		c.logger.Info("[schema delta replay] added column", slog.String("column", column.Name),
+			slog.String("table", schemaDelta.DstTableName))
+	}
+
+	for _, column := range schemaDelta.DroppedColumns {
+		if schema.field(column.Name) == nil {
+			continue
+		}
+		switch droppedColumnPolicy {
+		case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP:
+			removeField(column.Name)
+			changed = true
+			c.logger.Info("[schema delta replay] dropped column", slog.String("column", column.Name),
+				slog.String("table", schemaDelta.DstTableName))
+		default:
+			// new rows have no value for the column, so it is null going forward either way
+			c.logger.Warn("[schema delta replay] keeping dropped column", slog.String("column", column.Name),
+				slog.String("table", schemaDelta.DstTableName))
+		}
+	}
+
+	for _, altered := range schemaDelta.AlteredColumns {
+		if altered.Current == nil {
+			continue
+		}
+		field := schema.field(altered.Current.Name)
+		if field == nil {
+			continue
+		}
+		newType := icebergTypeForKind(types.QValueKind(altered.Current.Type), altered.Current.TypeModifier, nextID)
+		if newType.String() == field.Type.String() {
+			continue
+		}
+		if canPromote(field.Type, newType) {
+			if field.Type.list != nil {
+				newType.list.ElementID = field.Type.list.ElementID
+			}
+			field.Type = newType
+			changed = true
+			c.logger.Info("[schema delta replay] promoted column type", slog.String("column", field.Name),
+				slog.String("type", newType.String()), slog.String("table", schemaDelta.DstTableName))
+			continue
+		}
+		switch droppedColumnPolicy {
+		case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL, protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP:
+			// Iceberg can't rewrite a column to an incompatible type, replace it with a new field under the same name
+			name := field.Name
+			removeField(name)
+			schema.Fields = append(schema.Fields, icebergField{ID: nextID(), Name: name, Type: newType})
+			changed = true
+			c.logger.Warn("[schema delta replay] replaced column with incompatible type change, previous values are no longer visible",
+				slog.String("column", name), slog.String("type", newType.String()), slog.String("table", schemaDelta.DstTableName))
+		default:
+			c.logger.Warn("[schema delta replay] keeping column type, values not representable will be written as null",
+				slog.String("column", field.Name), slog.String("type", field.Type.String()),
+				slog.String("table", schemaDelta.DstTableName))
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	_, err = c.catalog.commitTable(ctx, namespace, table, metadata, &tableUpdate{schema: schema, lastColumnID: lastID})
+	return err
+}
@@ -0,0 +1,147 @@
+package conniceberg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+var errObjectNotFound = errors.New("object not found")
+
+type IcebergConnector struct {
+	*metadataStore.PostgresMetadata
+	logger              log.Logger
+	credentialsProvider utils.AWSCredentialsProvider
+	catalog             catalog
+	client              *s3.Client
+	config              *protos.IcebergConfig
+}
+
+func NewIcebergConnector(
+	ctx context.Context,
+	config *protos.IcebergConfig,
+) (*IcebergConnector, error) {
+	logger := internal.LoggerFromCtx(ctx)
+
+	if config.S3 == nil {
+		return nil, errors.New("iceberg peer requires an S3 warehouse configuration")
+	}
+
+	provider, err := utils.GetAWSCredentialsProvider(ctx, "iceberg", utils.NewPeerAWSCredentials(config.S3))
+	if err != nil {
+		return nil, err
+	}
+
+	s3Client, err := utils.CreateS3Client(ctx, provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		logger.Error("failed to create postgres metadata store", "error", err)
+		return nil, err
+	}
+
+	c := &IcebergConnector{
+		PostgresMetadata:    pgMetadata,
+		logger:              logger,
+		credentialsProvider: provider,
+		client:              s3Client,
+		config:              config,
+	}
+	switch config.CatalogType {
+	case protos.IcebergCatalogType_ICEBERG_CATALOG_FILESYSTEM:
+		c.catalog = &filesystemCatalog{connector: c, warehouse: strings.TrimSuffix(config.S3.Url, "/")}
+	case protos.IcebergCatalogType_ICEBERG_CATALOG_REST:
+		c.catalog, err = newRESTCatalog(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported iceberg catalog type %s", config.CatalogType)
+	}
+	return c, nil
+}
+
+func (c *IcebergConnector) Close() error {
+	return nil
+}
+
+func (c *IcebergConnector) ConnectionActive(ctx context.Context) error {
+	return nil
+}
+
+func (c *IcebergConnector) ValidateCheck(ctx context.Context) error {
+	bucketPrefix, err := utils.NewS3BucketAndPrefix(c.config.S3.Url)
+	if err != nil {
+		return fmt.Errorf("failed to parse bucket url: %w", err)
+	}
+	if err := utils.PutAndRemoveS3(ctx, c.client, bucketPrefix.Bucket, bucketPrefix.Prefix); err != nil {
+		return err
+	}
+	return c.catalog.validate(ctx)
+}
+
+// tableIdentifier splits a destination table into namespace and table name,
+// falling back to the configured namespace for unqualified names.
+func (c *IcebergConnector) tableIdentifier(destinationTable string) (string, string, error) {
+	if namespace, table, ok := strings.Cut(destinationTable, "."); ok {
+		return namespace, table, nil
+	}
+	if c.config.Namespace == "" {
+		return "", "", fmt.Errorf("destination table %s has no namespace and peer has no default namespace", destinationTable)
+	}
+	return c.config.Namespace, destinationTable, nil
+}
+
+func (c *IcebergConnector) putObject(ctx context.Context, location string, body []byte, exclusive bool) error {
+	object, err := utils.NewS3BucketAndPrefix(location)
+	if err != nil {
+		return err
+	}
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(object.Bucket),
+		Key:    aws.String(object.Prefix),
+		Body:   bytes.NewReader(body),
+	}
+	if exclusive {
+		input.IfNoneMatch = aws.String("*")
+	}
+	if _, err := c.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("failed to write %s: %w", location, err)
+	}
+	return nil
+}
+
+func (c *IcebergConnector) getObject(ctx context.Context, location string) ([]byte, error) {
+	object, err := utils.NewS3BucketAndPrefix(location)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(object.Bucket),
+		Key:    aws.String(object.Prefix),
+	})
+	if err != nil {
+		var noSuchKey *s3types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, errObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", location, err)
+	}
+	defer output.Body.Close()
+	return io.ReadAll(output.Body)
+}
@@ -0,0 +1,282 @@
+package conniceberg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hamba/avro/v2"
+	"github.com/hamba/avro/v2/ocf"
+)
+
+const (
+	contentData           = 0
+	contentEqualityDelete = 2
+
+	manifestContentData    = 0
+	manifestContentDeletes = 1
+
+	manifestEntryAdded = 1
+)
+
+// manifestEntrySchema is the v2 manifest_entry schema restricted to the fields PeerDB writes, with Iceberg field ids.
+// Tables are unpartitioned, so the partition tuple is an empty record.
+const manifestEntrySchema = `{
+	"type": "record",
+	"name": "manifest_entry",
+	"fields": [
+		{"name": "status", "type": "int", "field-id": 0},
+		{"name": "snapshot_id", "type": ["null", "long"], "default": null, "field-id": 1},
+		{"name": "sequence_number", "type": ["null", "long"], "default": null, "field-id": 3},
+		{"name": "file_sequence_number", "type": ["null", "long"], "default": null, "field-id": 4},
+		{"name": "data_file", "field-id": 2, "type": {
+			"type": "record",
+			"name": "r2",
+			"fields": [
+				{"name": "content", "type": "int", "field-id": 134},
+				{"name": "file_path", "type": "string", "field-id": 100},
+				{"name": "file_format", "type": "string", "field-id": 101},
+				{"name": "partition", "field-id": 102, "type": {"type": "record", "name": "r102", "fields": []}},
+				{"name": "record_count", "type": "long", "field-id": 103},
+				{"name": "file_size_in_bytes", "type": "long", "field-id": 104},
+				{"name": "equality_ids", "type": ["null", {"type": "array", "items": "int", "element-id": 136}],
+					"default": null, "field-id": 135}
+			]
+		}}
+	]
+}`
+
+// manifestFileSchema is the v2 manifest_file schema used for manifest lists, without partition summaries.
+const manifestFileSchema = `{
+	"type": "record",
+	"name": "manifest_file",
+	"fields": [
+		{"name": "manifest_path", "type": "string", "field-id": 500},
+		{"name": "manifest_length", "type": "long", "field-id": 501},
+		{"name": "partition_spec_id", "type": "int", "field-id": 502},
+		{"name": "content", "type": "int", "field-id": 517},
+		{"name": "sequence_number", "type": "long", "field-id": 515},
+		{"name": "min_sequence_number", "type": "long", "field-id": 516},
+		{"name": "added_snapshot_id", "type": "long", "field-id": 503},
+		{"name": "added_files_count", "type": "int", "field-id": 504},
+		{"name": "existing_files_count", "type": "int", "field-id": 505},
+		{"name": "deleted_files_count", "type": "int", "field-id": 506},
+		{"name": "added_rows_count", "type": "long", "field-id": 512},
+		{"name": "existing_rows_count", "type": "long", "field-id": 513},
+		{"name": "deleted_rows_count", "type": "long", "field-id": 514}
+	]
+}`
+
+var (
+	manifestEntryAvroSchema = avro.MustParse(manifestEntrySchema)
+	manifestFileAvroSchema  = avro.MustParse(manifestFileSchema)
+)
+
+type dataFile struct {
+	Partition       struct{} `avro:"partition"`
+	FilePath        string   `avro:"file_path"`
+	FileFormat      string   `avro:"file_format"`
+	EqualityIDs     []int32  `avro:"equality_ids"`
+	RecordCount     int64    `avro:"record_count"`
+	FileSizeInBytes int64    `avro:"file_size_in_bytes"`
+	Content         int32    `avro:"content"`
+}
+
+type manifestEntry struct {
+	SnapshotID         *int64   `avro:"snapshot_id"`
+	SequenceNumber     *int64   `avro:"sequence_number"`
+	FileSequenceNumber *int64   `avro:"file_sequence_number"`
+	DataFile           dataFile `avro:"data_file"`
+	Status             int32    `avro:"status"`
+}
+
+type manifestFile struct {
+	ManifestPath       string `avro:"manifest_path"`
+	ManifestLength     int64  `avro:"manifest_length"`
+	SequenceNumber     int64  `avro:"sequence_number"`
+	MinSequenceNumber  int64  `avro:"min_sequence_number"`
+	AddedSnapshotID    int64  `avro:"added_snapshot_id"`
+	AddedRowsCount     int64  `avro:"added_rows_count"`
+	ExistingRowsCount  int64  `avro:"existing_rows_count"`
+	DeletedRowsCount   int64  `avro:"deleted_rows_count"`
+	PartitionSpecID    int32  `avro:"partition_spec_id"`
+	Content            int32  `avro:"content"`
+	AddedFilesCount    int32  `avro:"added_files_count"`
+	ExistingFilesCount int32  `avro:"existing_files_count"`
+	DeletedFilesCount  int32  `avro:"deleted_files_count"`
+}
+
+func encodeAvroFile(schema avro.Schema, metadata map[string][]byte, values []any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc, err := ocf.NewEncoderWithSchema(schema, &buf,
+		ocf.WithMetadata(metadata),
+		ocf.WithCodec(ocf.Deflate),
+		// keep field-id properties, which Iceberg readers use to resolve columns
+		ocf.WithSchemaMarshaler(ocf.FullSchemaMarshaler),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		if err := enc.Encode(value); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeManifest encodes a manifest adding the given files in a snapshot.
+// All files in a manifest must have the same content kind, data or deletes.
+func encodeManifest(
+	schema *icebergSchema,
+	snapshotID int64,
+	sequenceNumber int64,
+	content int32,
+	files []dataFile,
+) ([]byte, error) {
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize iceberg schema: %w", err)
+	}
+	manifestContent := "data"
+	if content == manifestContentDeletes {
+		manifestContent = "deletes"
+	}
+	entries := make([]any, 0, len(files))
+	for _, file := range files {
+		entries = append(entries, &manifestEntry{
+			Status:             manifestEntryAdded,
+			SnapshotID:         &snapshotID,
+			SequenceNumber:     &sequenceNumber,
+			FileSequenceNumber: &sequenceNumber,
+			DataFile:           file,
+		})
+	}
+	return encodeAvroFile(manifestEntryAvroSchema, map[string][]byte{
+		"schema":            schemaJSON,
+		"schema-id":         []byte(strconv.Itoa(schema.SchemaID)),
+		"partition-spec":    []byte("[]"),
+		"partition-spec-id": []byte("0"),
+		"format-version":    []byte(strconv.Itoa(icebergFormatVersion)),
+		"content":           []byte(manifestContent),
+	}, entries)
+}
+
+func encodeManifestList(snapshotID int64, parentSnapshotID *int64, sequenceNumber int64, manifests []manifestFile) ([]byte, error) {
+	parent := "null"
+	if parentSnapshotID != nil {
+		parent = strconv.FormatInt(*parentSnapshotID, 10)
+	}
+	values := make([]any, 0, len(manifests))
+	for i := range manifests {
+		values = append(values, &manifests[i])
+	}
+	return encodeAvroFile(manifestFileAvroSchema, map[string][]byte{
+		"snapshot-id":        []byte(strconv.FormatInt(snapshotID, 10)),
+		"parent-snapshot-id": []byte(parent),
+		"sequence-number":    []byte(strconv.FormatInt(sequenceNumber, 10)),
+		"format-version":     []byte(strconv.Itoa(icebergFormatVersion)),
+	}, values)
+}
+
+func decodeManifestList(data []byte) ([]manifestFile, error) {
+	dec, err := ocf.NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var manifests []manifestFile
+	for dec.HasNext() {
+		var manifest manifestFile
+		if err := dec.Decode(&manifest); err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, dec.Error()
+}
+
+// commitFiles writes manifests for the new data and delete files plus a manifest list carrying over
+// the manifests of the parent snapshot, and returns the snapshot to be committed.
+func (c *IcebergConnector) commitFiles(
+	ctx context.Context,
+	metadata *tableMetadata,
+	schema *icebergSchema,
+	dataFiles []dataFile,
+	deleteFiles []dataFile,
+	summary map[string]string,
+) (*snapshot, error) {
+	snapshotID := newSnapshotID()
+	sequenceNumber := metadata.LastSequenceNumber + 1
+
+	var manifests []manifestFile
+	var parentSnapshotID *int64
+	if parent := metadata.currentSnapshot(); parent != nil {
+		parentSnapshotID = &parent.SnapshotID
+		data, err := c.getObject(ctx, parent.ManifestList)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest list of snapshot %d: %w", parent.SnapshotID, err)
+		}
+		manifests, err = decodeManifestList(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode manifest list of snapshot %d: %w", parent.SnapshotID, err)
+		}
+	}
+
+	for _, group := range []struct {
+		files   []dataFile
+		content int32
+	}{
+		{files: dataFiles, content: manifestContentData},
+		{files: deleteFiles, content: manifestContentDeletes},
+	} {
+		if len(group.files) == 0 {
+			continue
+		}
+		encoded, err := encodeManifest(schema, snapshotID, sequenceNumber, group.content, group.files)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		path := metadataFilePath(metadata.Location, newFileName("m", "avro"))
+		if err := c.putObject(ctx, path, encoded, false); err != nil {
+			return nil, err
+		}
+		var rows int64
+		for _, file := range group.files {
+			rows += file.RecordCount
+		}
+		manifests = append(manifests, manifestFile{
+			ManifestPath:      path,
+			ManifestLength:    int64(len(encoded)),
+			Content:           group.content,
+			SequenceNumber:    sequenceNumber,
+			MinSequenceNumber: sequenceNumber,
+			AddedSnapshotID:   snapshotID,
+			AddedFilesCount:   int32(len(group.files)),
+			AddedRowsCount:    rows,
+		})
+	}
+
+	encoded, err := encodeManifestList(snapshotID, parentSnapshotID, sequenceNumber, manifests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest list: %w", err)
+	}
+	manifestListPath := metadataFilePath(metadata.Location, fmt.Sprintf("snap-%d-%s", snapshotID, newFileName("", "avro")))
+	if err := c.putObject(ctx, manifestListPath, encoded, false); err != nil {
+		return nil, err
+	}
+
+	return &snapshot{
+		SnapshotID:       snapshotID,
+		ParentSnapshotID: parentSnapshotID,
+		SequenceNumber:   sequenceNumber,
+		TimestampMs:      nowMillis(),
+		ManifestList:     manifestListPath,
+		Summary:          summary,
+		SchemaID:         schema.SchemaID,
+	}, nil
+}
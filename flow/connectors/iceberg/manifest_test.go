@@ -0,0 +1,126 @@
+package conniceberg
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hamba/avro/v2/ocf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestListRoundTrip(t *testing.T) {
+	parent := int64(7)
+	manifests := []manifestFile{
+		{
+			ManifestPath:      "s3://bucket/ns/t/metadata/m-1.avro",
+			ManifestLength:    1234,
+			Content:           manifestContentData,
+			SequenceNumber:    3,
+			MinSequenceNumber: 3,
+			AddedSnapshotID:   42,
+			AddedFilesCount:   1,
+			AddedRowsCount:    10,
+		},
+		{
+			ManifestPath:      "s3://bucket/ns/t/metadata/m-2.avro",
+			ManifestLength:    99,
+			Content:           manifestContentDeletes,
+			SequenceNumber:    3,
+			MinSequenceNumber: 3,
+			AddedSnapshotID:   42,
+			AddedFilesCount:   1,
+			AddedRowsCount:    2,
+		},
+	}
+	encoded, err := encodeManifestList(42, &parent, 3, manifests)
+	require.NoError(t, err)
+
+	decoded, err := decodeManifestList(encoded)
+	require.NoError(t, err)
+	require.Equal(t, manifests, decoded)
+}
+
+func TestManifestKeepsFieldIDs(t *testing.T) {
+	schema := &icebergSchema{
+		Type:               "struct",
+		Fields:             []icebergField{{ID: 1, Name: "id", Required: true, Type: primitiveType("long")}},
+		IdentifierFieldIDs: []int{1},
+	}
+	encoded, err := encodeManifest(schema, 42, 3, manifestContentDeletes, []dataFile{{
+		Content:         contentEqualityDelete,
+		FilePath:        "s3://bucket/ns/t/data/d.parquet",
+		FileFormat:      "PARQUET",
+		RecordCount:     2,
+		FileSizeInBytes: 512,
+		EqualityIDs:     []int32{1},
+	}})
+	require.NoError(t, err)
+
+	dec, err := ocf.NewDecoder(bytes.NewReader(encoded))
+	require.NoError(t, err)
+	metadata := dec.Metadata()
+	require.Equal(t, "deletes", string(metadata["content"]))
+	require.Contains(t, string(metadata["avro.schema"]), `"field-id":135`)
+	require.Contains(t, string(metadata["avro.schema"]), `"element-id":136`)
+
+	require.True(t, dec.HasNext())
+	var entry manifestEntry
+	require.NoError(t, dec.Decode(&entry))
+	require.Equal(t, int32(manifestEntryAdded), entry.Status)
+	require.Equal(t, []int32{1}, entry.DataFile.EqualityIDs)
+	require.Equal(t, int64(3), *entry.SequenceNumber)
+}
+
+func TestFieldTypeJSON(t *testing.T) {
+	field := icebergField{
+		ID:   3,
+		Name: "tags",
+		Type: fieldType{list: &listType{Type: "list", ElementID: 4, Element: primitiveType("string")}},
+	}
+	data, err := json.Marshal(field)
+	require.NoError(t, err)
+	require.JSONEq(t,
+		`{"id":3,"name":"tags","required":false,"type":{"type":"list","element-id":4,"element":"string","element-required":false}}`,
+		string(data))
+
+	var decoded icebergField
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, "list<string>", decoded.Type.String())
+	require.Equal(t, 4, decoded.Type.list.ElementID)
+}
+
+func TestCanPromote(t *testing.T) {
+	require.True(t, canPromote(primitiveType("int"), primitiveType("long")))
+	require.True(t, canPromote(primitiveType("float"), primitiveType("double")))
+	require.True(t, canPromote(primitiveType("decimal(10,2)"), primitiveType("decimal(38,2)")))
+	require.False(t, canPromote(primitiveType("decimal(10,2)"), primitiveType("decimal(38,4)")))
+	require.False(t, canPromote(primitiveType("long"), primitiveType("int")))
+	require.False(t, canPromote(primitiveType("string"), primitiveType("long")))
+}
+
+func TestMetadataApply(t *testing.T) {
+	schema := &icebergSchema{Type: "struct", Fields: []icebergField{{ID: 1, Name: "id", Type: primitiveType("int")}}}
+	base := newTableMetadata("uuid", "s3://bucket/ns/t/", schema, map[string]string{})
+	base.version = 1
+	base.metadataLocation = "s3://bucket/ns/t/metadata/v1.metadata.json"
+
+	evolved := schema.clone()
+	evolved.Fields = append(evolved.Fields, icebergField{ID: 2, Name: "name", Type: primitiveType("string")})
+	next, err := base.apply(&tableUpdate{schema: evolved, lastColumnID: 2})
+	require.NoError(t, err)
+	require.Equal(t, 1, next.CurrentSchemaID)
+	require.Equal(t, 2, next.LastColumnID)
+	require.Equal(t, 2, next.version)
+	require.Len(t, next.MetadataLog, 1)
+	require.Equal(t, "s3://bucket/ns/t", next.Location)
+
+	next, err = next.apply(&tableUpdate{snapshot: &snapshot{SnapshotID: 5, SequenceNumber: 1, Summary: map[string]string{}}})
+	require.NoError(t, err)
+	require.Equal(t, int64(5), next.currentSnapshot().SnapshotID)
+	require.Equal(t, 1, next.currentSnapshot().SchemaID)
+	require.Equal(t, int64(1), next.LastSequenceNumber)
+
+	_, err = next.apply(&tableUpdate{snapshot: &snapshot{SnapshotID: 6, SequenceNumber: 1}})
+	require.Error(t, err)
+}
@@ -0,0 +1,274 @@
+package conniceberg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	icebergFormatVersion = 2
+	mainBranch           = "main"
+
+	softDeleteColumnProperty = "peerdb.soft-delete-column"
+	syncedAtColumnProperty   = "peerdb.synced-at-column"
+	syncBatchIDSummaryKey    = "peerdb.sync-batch-id"
+	qrepPartitionSummaryKey  = "peerdb.qrep-partition-id"
+)
+
+// fieldType is either a primitive type name (e.g. "long", "decimal(38,20)") or a list type.
+type fieldType struct {
+	list      *listType
+	primitive string
+}
+
+type listType struct {
+	Element         fieldType `json:"element"`
+	Type            string    `json:"type"`
+	ElementID       int       `json:"element-id"`
+	ElementRequired bool      `json:"element-required"`
+}
+
+func (t fieldType) String() string {
+	if t.list != nil {
+		return "list<" + t.list.Element.String() + ">"
+	}
+	return t.primitive
+}
+
+func (t fieldType) MarshalJSON() ([]byte, error) {
+	if t.list != nil {
+		return json.Marshal(t.list)
+	}
+	return json.Marshal(t.primitive)
+}
+
+func (t *fieldType) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &t.primitive)
+	}
+	var nested struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &nested); err != nil {
+		return err
+	}
+	if nested.Type != "list" {
+		return fmt.Errorf("unsupported iceberg nested type %s", nested.Type)
+	}
+	t.list = &listType{}
+	return json.Unmarshal(data, t.list)
+}
+
+type icebergField struct {
+	Type     fieldType `json:"type"`
+	Doc      string    `json:"doc,omitempty"`
+	Name     string    `json:"name"`
+	ID       int       `json:"id"`
+	Required bool      `json:"required"`
+}
+
+type icebergSchema struct {
+	Type               string         `json:"type"`
+	Fields             []icebergField `json:"fields"`
+	IdentifierFieldIDs []int          `json:"identifier-field-ids,omitempty"`
+	SchemaID           int            `json:"schema-id"`
+}
+
+func (s *icebergSchema) field(name string) *icebergField {
+	for i := range s.Fields {
+		if s.Fields[i].Name == name {
+			return &s.Fields[i]
+		}
+	}
+	return nil
+}
+
+func (s *icebergSchema) clone() *icebergSchema {
+	fields := make([]icebergField, len(s.Fields))
+	copy(fields, s.Fields)
+	return &icebergSchema{
+		Type:               s.Type,
+		SchemaID:           s.SchemaID,
+		Fields:             fields,
+		IdentifierFieldIDs: slices.Clone(s.IdentifierFieldIDs),
+	}
+}
+
+type partitionSpec struct {
+	Fields []json.RawMessage `json:"fields"`
+	SpecID int               `json:"spec-id"`
+}
+
+type sortOrder struct {
+	Fields  []json.RawMessage `json:"fields"`
+	OrderID int               `json:"order-id"`
+}
+
+type snapshot struct {
+	ParentSnapshotID *int64            `json:"parent-snapshot-id,omitempty"`
+	Summary          map[string]string `json:"summary"`
+	ManifestList     string            `json:"manifest-list"`
+	SnapshotID       int64             `json:"snapshot-id"`
+	SequenceNumber   int64             `json:"sequence-number"`
+	TimestampMs      int64             `json:"timestamp-ms"`
+	SchemaID         int               `json:"schema-id"`
+}
+
+type snapshotRef struct {
+	Type       string `json:"type"`
+	SnapshotID int64  `json:"snapshot-id"`
+}
+
+type snapshotLogEntry struct {
+	SnapshotID  int64 `json:"snapshot-id"`
+	TimestampMs int64 `json:"timestamp-ms"`
+}
+
+type metadataLogEntry struct {
+	MetadataFile string `json:"metadata-file"`
+	TimestampMs  int64  `json:"timestamp-ms"`
+}
+
+// tableMetadata is the format version 2 table metadata file, see https://iceberg.apache.org/spec/#table-metadata
+type tableMetadata struct {
+	Properties         map[string]string      `json:"properties"`
+	Refs               map[string]snapshotRef `json:"refs"`
+	CurrentSnapshotID  *int64                 `json:"current-snapshot-id"`
+	TableUUID          string                 `json:"table-uuid"`
+	Location           string                 `json:"location"`
+	Schemas            []*icebergSchema       `json:"schemas"`
+	PartitionSpecs     []partitionSpec        `json:"partition-specs"`
+	SortOrders         []sortOrder            `json:"sort-orders"`
+	Snapshots          []*snapshot            `json:"snapshots"`
+	SnapshotLog        []snapshotLogEntry     `json:"snapshot-log"`
+	MetadataLog        []metadataLogEntry     `json:"metadata-log"`
+	FormatVersion      int                    `json:"format-version"`
+	LastSequenceNumber int64                  `json:"last-sequence-number"`
+	LastUpdatedMs      int64                  `json:"last-updated-ms"`
+	LastColumnID       int                    `json:"last-column-id"`
+	CurrentSchemaID    int                    `json:"current-schema-id"`
+	DefaultSpecID      int                    `json:"default-spec-id"`
+	LastPartitionID    int                    `json:"last-partition-id"`
+	DefaultSortOrderID int                    `json:"default-sort-order-id"`
+
+	// not part of the metadata file, tracked for commits
+	metadataLocation string
+	version          int
+}
+
+func newTableMetadata(tableUUID string, location string, schema *icebergSchema, properties map[string]string) *tableMetadata {
+	lastColumnID := 0
+	for _, field := range schema.Fields {
+		lastColumnID = max(lastColumnID, field.ID)
+		if field.Type.list != nil {
+			lastColumnID = max(lastColumnID, field.Type.list.ElementID)
+		}
+	}
+	return &tableMetadata{
+		FormatVersion:      icebergFormatVersion,
+		TableUUID:          tableUUID,
+		Location:           strings.TrimSuffix(location, "/"),
+		LastUpdatedMs:      time.Now().UnixMilli(),
+		LastColumnID:       lastColumnID,
+		Schemas:            []*icebergSchema{schema},
+		CurrentSchemaID:    schema.SchemaID,
+		PartitionSpecs:     []partitionSpec{{SpecID: 0, Fields: []json.RawMessage{}}},
+		LastPartitionID:    999,
+		SortOrders:         []sortOrder{{OrderID: 0, Fields: []json.RawMessage{}}},
+		Properties:         properties,
+		Refs:               map[string]snapshotRef{},
+		Snapshots:          []*snapshot{},
+		SnapshotLog:        []snapshotLogEntry{},
+		MetadataLog:        []metadataLogEntry{},
+		LastSequenceNumber: 0,
+	}
+}
+
+func (m *tableMetadata) currentSchema() (*icebergSchema, error) {
+	for _, schema := range m.Schemas {
+		if schema.SchemaID == m.CurrentSchemaID {
+			return schema, nil
+		}
+	}
+	return nil, fmt.Errorf("current schema %d not found in table metadata", m.CurrentSchemaID)
+}
+
+func (m *tableMetadata) currentSnapshot() *snapshot {
+	if m.CurrentSnapshotID == nil {
+		return nil
+	}
+	for _, snap := range m.Snapshots {
+		if snap.SnapshotID == *m.CurrentSnapshotID {
+			return snap
+		}
+	}
+	return nil
+}
+
+// tableUpdate is the set of changes made by one commit, applied by the catalog.
+type tableUpdate struct {
+	schema     *icebergSchema
+	snapshot   *snapshot
+	properties map[string]string
+	// lastColumnID is only meaningful when schema is set
+	lastColumnID int
+}
+
+// apply returns a copy of the metadata with the update applied, used by catalogs which write metadata files themselves.
+func (m *tableMetadata) apply(update *tableUpdate) (*tableMetadata, error) {
+	now := time.Now().UnixMilli()
+	next := *m
+	next.LastUpdatedMs = now
+	next.Schemas = slices.Clone(m.Schemas)
+	next.Snapshots = slices.Clone(m.Snapshots)
+	next.SnapshotLog = slices.Clone(m.SnapshotLog)
+	next.Refs = make(map[string]snapshotRef, len(m.Refs))
+	for name, ref := range m.Refs {
+		next.Refs[name] = ref
+	}
+	next.Properties = make(map[string]string, len(m.Properties)+len(update.properties))
+	for k, v := range m.Properties {
+		next.Properties[k] = v
+	}
+	for k, v := range update.properties {
+		next.Properties[k] = v
+	}
+	if m.metadataLocation != "" {
+		next.MetadataLog = append(slices.Clone(m.MetadataLog), metadataLogEntry{
+			MetadataFile: m.metadataLocation,
+			TimestampMs:  m.LastUpdatedMs,
+		})
+	}
+
+	if update.schema != nil {
+		schema := update.schema.clone()
+		schema.SchemaID = 0
+		for _, existing := range m.Schemas {
+			schema.SchemaID = max(schema.SchemaID, existing.SchemaID+1)
+		}
+		next.Schemas = append(next.Schemas, schema)
+		next.CurrentSchemaID = schema.SchemaID
+		next.LastColumnID = max(m.LastColumnID, update.lastColumnID)
+	}
+
+	if update.snapshot != nil {
+		snap := *update.snapshot
+		if snap.SequenceNumber <= m.LastSequenceNumber {
+			return nil, errors.New("iceberg snapshot sequence number must increase")
+		}
+		snap.SchemaID = next.CurrentSchemaID
+		next.Snapshots = append(next.Snapshots, &snap)
+		next.SnapshotLog = append(next.SnapshotLog, snapshotLogEntry{SnapshotID: snap.SnapshotID, TimestampMs: snap.TimestampMs})
+		next.Refs[mainBranch] = snapshotRef{Type: "branch", SnapshotID: snap.SnapshotID}
+		next.CurrentSnapshotID = &snap.SnapshotID
+		next.LastSequenceNumber = snap.SequenceNumber
+	}
+
+	next.version = m.version + 1
+	next.metadataLocation = ""
+	return &next, nil
+}
@@ -0,0 +1,106 @@
+package conniceberg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/google/uuid"
+)
+
+func newFileName(prefix string, extension string) string {
+	if prefix == "" {
+		return uuid.NewString() + "." + extension
+	}
+	return prefix + "-" + uuid.NewString() + "." + extension
+}
+
+func metadataFilePath(location string, name string) string {
+	return location + "/metadata/" + name
+}
+
+func dataFilePath(location string, name string) string {
+	return location + "/data/" + name
+}
+
+func newSnapshotID() int64 {
+	// snapshot ids only need to be unique within a table, keep them positive
+	return rand.Int64N(1 << 62)
+}
+
+func nowMillis() int64 {
+	return time.Now().UnixMilli()
+}
+
+// encodeParquet encodes rows, already converted with columnValue, as a Parquet file carrying Iceberg field ids.
+// Returns the number of values which could not be represented in their column and were written as null.
+func encodeParquet(fields []icebergField, rows [][]any) ([]byte, int, error) {
+	schema, err := arrowSchema(fields)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+	var nulled int
+	for _, row := range rows {
+		for i, value := range row {
+			if !appendValue(builder.Field(i), value) {
+				nulled++
+			}
+		}
+	}
+	record := builder.NewRecord()
+	defer record.Release()
+
+	var buf bytes.Buffer
+	writer, err := pqarrow.NewFileWriter(schema, &buf,
+		parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Zstd)),
+		pqarrow.DefaultWriterProps(),
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create parquet writer: %w", err)
+	}
+	if err := writer.Write(record); err != nil {
+		return nil, 0, fmt.Errorf("failed to write parquet file: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, 0, fmt.Errorf("failed to finish parquet file: %w", err)
+	}
+	return buf.Bytes(), nulled, nil
+}
+
+// writeParquetFile writes rows as one Parquet file under the table's data directory.
+func (c *IcebergConnector) writeParquetFile(
+	ctx context.Context,
+	location string,
+	fields []icebergField,
+	rows [][]any,
+	content int32,
+	equalityIDs []int32,
+) (dataFile, int, error) {
+	encoded, nulled, err := encodeParquet(fields, rows)
+	if err != nil {
+		return dataFile{}, 0, err
+	}
+
+	path := dataFilePath(location, newFileName("", "parquet"))
+	if err := c.putObject(ctx, path, encoded, false); err != nil {
+		return dataFile{}, 0, err
+	}
+	return dataFile{
+		Content:         content,
+		FilePath:        path,
+		FileFormat:      "PARQUET",
+		RecordCount:     int64(len(rows)),
+		FileSizeInBytes: int64(len(encoded)),
+		EqualityIDs:     equalityIDs,
+	}, nulled, nil
+}
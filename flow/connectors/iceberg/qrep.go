@@ -0,0 +1,141 @@
+package conniceberg
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func (c *IcebergConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	namespace, table, err := c.tableIdentifier(config.DestinationTableIdentifier)
+	if err != nil {
+		return 0, nil, err
+	}
+	metadata, err := c.catalog.loadTable(ctx, namespace, table)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to load iceberg table %s: %w", config.DestinationTableIdentifier, err)
+	}
+	if metadata == nil {
+		metadata, err = c.catalog.createTable(ctx, namespace, table, qrepTableSchema(schema), map[string]string{
+			"write.format.default": "parquet",
+		})
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to create iceberg table %s: %w", config.DestinationTableIdentifier, err)
+		}
+	}
+	if snapshotWithSummary(metadata, qrepPartitionSummaryKey, partition.PartitionId) {
+		// committed before a failure to record the partition as synced
+		c.logger.Info("partition already committed to iceberg table", slog.String("partitionId", partition.PartitionId))
+		return 0, nil, c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime)
+	}
+	tableSchema, err := metadata.currentSchema()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// map stream columns onto table columns by name, the table may have been created with a different column order
+	indexes := make([]int, len(tableSchema.Fields))
+	for i, field := range tableSchema.Fields {
+		indexes[i] = -1
+		for j, streamField := range schema.Fields {
+			if streamField.Name == field.Name {
+				indexes[i] = j
+				break
+			}
+		}
+	}
+	syncedAt := time.Now()
+	var rows [][]any
+	for record := range stream.Records {
+		row := make([]any, len(tableSchema.Fields))
+		for i, field := range tableSchema.Fields {
+			if indexes[i] >= 0 {
+				row[i] = columnValue(record[indexes[i]], field.Type)
+			} else if field.Name == metadata.Properties[softDeleteColumnProperty] {
+				row[i] = false
+			} else if field.Name == metadata.Properties[syncedAtColumnProperty] {
+				row[i] = syncedAt
+			}
+		}
+		rows = append(rows, row)
+	}
+	if err := stream.Err(); err != nil {
+		return 0, nil, fmt.Errorf("failed to read records for partition %s: %w", partition.PartitionId, err)
+	}
+	if len(rows) == 0 {
+		return 0, nil, c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime)
+	}
+
+	file, nulled, err := c.writeParquetFile(ctx, metadata.Location, tableSchema.Fields, rows, contentData, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to write data file for %s: %w", config.DestinationTableIdentifier, err)
+	}
+	snap, err := c.commitFiles(ctx, metadata, tableSchema, []dataFile{file}, nil, map[string]string{
+		"operation":             "append",
+		"added-records":         strconv.Itoa(len(rows)),
+		"added-data-files":      "1",
+		qrepPartitionSummaryKey: partition.PartitionId,
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to write manifests for %s: %w", config.DestinationTableIdentifier, err)
+	}
+	if _, err := c.catalog.commitTable(ctx, namespace, table, metadata, &tableUpdate{snapshot: snap}); err != nil {
+		return 0, nil, err
+	}
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, nil, err
+	}
+
+	var warnings shared.QRepWarnings
+	if nulled > 0 {
+		warnings = append(warnings, fmt.Errorf(
+			"%d values in partition %s could not be represented in their iceberg column type and were written as null",
+			nulled, partition.PartitionId))
+	}
+	return int64(len(rows)), warnings, nil
+}
+
+func qrepTableSchema(schema types.QRecordSchema) *icebergSchema {
+	var lastID int
+	nextID := func() int {
+		lastID++
+		return lastID
+	}
+	tableSchema := &icebergSchema{Type: "struct"}
+	for _, field := range schema.Fields {
+		typmod := int32(-1)
+		if field.Type == types.QValueKindNumeric {
+			typmod = datatypes.MakeNumericTypmod(int32(field.Precision), int32(field.Scale))
+		}
+		tableSchema.Fields = append(tableSchema.Fields, icebergField{
+			ID:   nextID(),
+			Name: field.Name,
+			Type: icebergTypeForKind(field.Type, typmod, nextID),
+		})
+	}
+	return tableSchema
+}
+
+// partitions are tracked in the PeerDB catalog through FinishQRepPartition
+func (c *IcebergConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
@@ -0,0 +1,218 @@
+package conniceberg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// restCatalog talks to an Iceberg REST catalog, see https://iceberg.apache.org/spec/#iceberg-rest-catalog
+type restCatalog struct {
+	client    *http.Client
+	baseURL   string
+	token     string
+	warehouse string
+	location  string
+}
+
+type restLoadTableResult struct {
+	Metadata         *tableMetadata `json:"metadata"`
+	MetadataLocation string         `json:"metadata-location"`
+}
+
+type restErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+func newRESTCatalog(ctx context.Context, config *protos.IcebergConfig) (*restCatalog, error) {
+	if config.RestUri == "" {
+		return nil, errors.New("iceberg REST catalog requires a uri")
+	}
+	r := &restCatalog{
+		client:    &http.Client{Timeout: time.Minute},
+		baseURL:   strings.TrimSuffix(config.RestUri, "/") + "/v1",
+		token:     config.GetRestToken(),
+		warehouse: config.RestWarehouse,
+		location:  strings.TrimSuffix(config.S3.Url, "/"),
+	}
+
+	configPath := "/config"
+	if r.warehouse != "" {
+		configPath += "?warehouse=" + url.QueryEscape(r.warehouse)
+	}
+	var catalogConfig struct {
+		Defaults  map[string]string `json:"defaults"`
+		Overrides map[string]string `json:"overrides"`
+	}
+	if _, err := r.do(ctx, http.MethodGet, configPath, nil, &catalogConfig); err != nil {
+		return nil, fmt.Errorf("failed to fetch iceberg REST catalog config: %w", err)
+	}
+	if prefix := catalogConfig.Overrides["prefix"]; prefix != "" {
+		r.baseURL += "/" + strings.Trim(prefix, "/")
+	} else if prefix := catalogConfig.Defaults["prefix"]; prefix != "" {
+		r.baseURL += "/" + strings.Trim(prefix, "/")
+	}
+	return r, nil
+}
+
+// do sends a request and decodes a JSON response into out, returning the status code.
+// Error statuses other than 404 and 409, which callers handle, are returned as errors.
+func (r *restCatalog) do(ctx context.Context, method string, path string, body any, out any) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusConflict {
+		return resp.StatusCode, nil
+	}
+	if resp.StatusCode >= 300 {
+		var errResp restErrorResponse
+		data, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(data, &errResp) == nil && errResp.Error.Message != "" {
+			return resp.StatusCode, fmt.Errorf("%s %s: %s: %s", method, path, errResp.Error.Type, errResp.Error.Message)
+		}
+		return resp.StatusCode, fmt.Errorf("%s %s: status %d: %s", method, path, resp.StatusCode, string(data))
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode response of %s %s: %w", method, path, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+func tablePath(namespace string, table string) string {
+	return "/namespaces/" + url.PathEscape(namespace) + "/tables/" + url.PathEscape(table)
+}
+
+func (r *restCatalog) validate(ctx context.Context) error {
+	_, err := r.do(ctx, http.MethodGet, "/namespaces", nil, nil)
+	return err
+}
+
+func (r *restCatalog) loadResult(result *restLoadTableResult) (*tableMetadata, error) {
+	if result.Metadata == nil {
+		return nil, errors.New("iceberg REST catalog returned no table metadata")
+	}
+	result.Metadata.metadataLocation = result.MetadataLocation
+	return result.Metadata, nil
+}
+
+func (r *restCatalog) loadTable(ctx context.Context, namespace string, table string) (*tableMetadata, error) {
+	var result restLoadTableResult
+	status, err := r.do(ctx, http.MethodGet, tablePath(namespace, table), nil, &result)
+	if err != nil {
+		return nil, err
+	} else if status == http.StatusNotFound {
+		return nil, nil
+	}
+	return r.loadResult(&result)
+}
+
+func (r *restCatalog) createTable(
+	ctx context.Context, namespace string, table string, schema *icebergSchema, properties map[string]string,
+) (*tableMetadata, error) {
+	// namespace may already exist, which is reported as a conflict
+	if _, err := r.do(ctx, http.MethodPost, "/namespaces", map[string]any{
+		"namespace": []string{namespace},
+	}, nil); err != nil {
+		return nil, fmt.Errorf("failed to create namespace %s: %w", namespace, err)
+	}
+
+	var result restLoadTableResult
+	status, err := r.do(ctx, http.MethodPost, "/namespaces/"+url.PathEscape(namespace)+"/tables", map[string]any{
+		"name":       table,
+		"location":   r.location + "/" + namespace + "/" + table,
+		"schema":     schema,
+		"properties": properties,
+	}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create table %s.%s: %w", namespace, table, err)
+	} else if status == http.StatusConflict {
+		return r.loadTable(ctx, namespace, table)
+	} else if status == http.StatusNotFound {
+		return nil, fmt.Errorf("failed to create table %s.%s: namespace not found", namespace, table)
+	}
+	return r.loadResult(&result)
+}
+
+func (r *restCatalog) commitTable(
+	ctx context.Context, namespace string, table string, base *tableMetadata, update *tableUpdate,
+) (*tableMetadata, error) {
+	requirements := []map[string]any{
+		{"type": "assert-table-uuid", "uuid": base.TableUUID},
+		{"type": "assert-current-schema-id", "current-schema-id": base.CurrentSchemaID},
+	}
+	var baseSnapshotID *int64
+	if ref, ok := base.Refs[mainBranch]; ok {
+		baseSnapshotID = &ref.SnapshotID
+	}
+	requirements = append(requirements, map[string]any{
+		"type": "assert-ref-snapshot-id", "ref": mainBranch, "snapshot-id": baseSnapshotID,
+	})
+
+	var updates []map[string]any
+	if update.schema != nil {
+		updates = append(updates,
+			map[string]any{"action": "add-schema", "schema": update.schema, "last-column-id": update.lastColumnID},
+			// -1 refers to the schema added by this commit
+			map[string]any{"action": "set-current-schema", "schema-id": -1},
+		)
+	}
+	if update.snapshot != nil {
+		updates = append(updates,
+			map[string]any{"action": "add-snapshot", "snapshot": update.snapshot},
+			map[string]any{
+				"action": "set-snapshot-ref", "ref-name": mainBranch, "type": "branch", "snapshot-id": update.snapshot.SnapshotID,
+			},
+		)
+	}
+	if len(update.properties) > 0 {
+		updates = append(updates, map[string]any{"action": "set-properties", "updates": update.properties})
+	}
+
+	var result restLoadTableResult
+	status, err := r.do(ctx, http.MethodPost, tablePath(namespace, table), map[string]any{
+		"identifier":   map[string]any{"namespace": []string{namespace}, "name": table},
+		"requirements": requirements,
+		"updates":      updates,
+	}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit table %s.%s: %w", namespace, table, err)
+	} else if status == http.StatusConflict {
+		return nil, fmt.Errorf("failed to commit table %s.%s: table was modified concurrently", namespace, table)
+	} else if status == http.StatusNotFound {
+		return nil, fmt.Errorf("failed to commit table %s.%s: table not found", namespace, table)
+	}
+	return r.loadResult(&result)
+}
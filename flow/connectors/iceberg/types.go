@@ -0,0 +1,422 @@
+package conniceberg
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func primitiveType(name string) fieldType {
+	return fieldType{primitive: name}
+}
+
+func decimalType(precision int16, scale int16) fieldType {
+	return primitiveType(fmt.Sprintf("decimal(%d,%d)", precision, scale))
+}
+
+// icebergTypeForKind maps a QValueKind to an Iceberg type, allocating element ids for lists through nextID.
+func icebergTypeForKind(kind types.QValueKind, typmod int32, nextID func() int) fieldType {
+	switch kind {
+	case types.QValueKindBoolean:
+		return primitiveType("boolean")
+	case types.QValueKindInt8, types.QValueKindInt16, types.QValueKindInt32, types.QValueKindUInt8, types.QValueKindUInt16:
+		return primitiveType("int")
+	case types.QValueKindInt64, types.QValueKindUInt32:
+		return primitiveType("long")
+	case types.QValueKindUInt64:
+		return decimalType(20, 0)
+	case types.QValueKindFloat32:
+		return primitiveType("float")
+	case types.QValueKindFloat64:
+		return primitiveType("double")
+	case types.QValueKindNumeric:
+		return decimalType(datatypes.GetNumericTypeForWarehouse(typmod, datatypes.DefaultNumericCompatibility{}))
+	case types.QValueKindDate:
+		return primitiveType("date")
+	case types.QValueKindTime:
+		return primitiveType("time")
+	case types.QValueKindTimestamp:
+		return primitiveType("timestamp")
+	case types.QValueKindTimestampTZ:
+		return primitiveType("timestamptz")
+	case types.QValueKindBytes:
+		return primitiveType("binary")
+	case types.QValueKindArrayFloat32:
+		return listOf(primitiveType("float"), nextID)
+	case types.QValueKindArrayFloat64:
+		return listOf(primitiveType("double"), nextID)
+	case types.QValueKindArrayInt16, types.QValueKindArrayInt32:
+		return listOf(primitiveType("int"), nextID)
+	case types.QValueKindArrayInt64:
+		return listOf(primitiveType("long"), nextID)
+	case types.QValueKindArrayBoolean:
+		return listOf(primitiveType("boolean"), nextID)
+	case types.QValueKindArrayDate:
+		return listOf(primitiveType("date"), nextID)
+	case types.QValueKindArrayTimestamp:
+		return listOf(primitiveType("timestamp"), nextID)
+	case types.QValueKindArrayTimestampTZ:
+		return listOf(primitiveType("timestamptz"), nextID)
+	case types.QValueKindArrayNumeric:
+		return listOf(decimalType(datatypes.DefaultNumericCompatibility{}.DefaultPrecisionAndScale()), nextID)
+	case types.QValueKindArrayString, types.QValueKindArrayEnum, types.QValueKindArrayInterval, types.QValueKindArrayUUID:
+		return listOf(primitiveType("string"), nextID)
+	default:
+		// int256, uuid, json, geospatial, network types and everything else are written as text
+		return primitiveType("string")
+	}
+}
+
+func listOf(element fieldType, nextID func() int) fieldType {
+	return fieldType{list: &listType{Type: "list", ElementID: nextID(), Element: element}}
+}
+
+func parseDecimal(primitive string) (int32, int32, bool) {
+	inner, ok := strings.CutPrefix(primitive, "decimal(")
+	if !ok {
+		return 0, 0, false
+	}
+	precision, scale, ok := strings.Cut(strings.TrimSuffix(inner, ")"), ",")
+	if !ok {
+		return 0, 0, false
+	}
+	p, err := strconv.ParseInt(strings.TrimSpace(precision), 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	s, err := strconv.ParseInt(strings.TrimSpace(scale), 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	return int32(p), int32(s), true
+}
+
+// canPromote reports whether Iceberg schema evolution allows changing a column from one type to another in place.
+func canPromote(from fieldType, to fieldType) bool {
+	if from.list != nil || to.list != nil {
+		return from.list != nil && to.list != nil && canPromote(from.list.Element, to.list.Element)
+	}
+	if from.primitive == to.primitive {
+		return true
+	}
+	switch from.primitive {
+	case "int":
+		return to.primitive == "long"
+	case "float":
+		return to.primitive == "double"
+	}
+	fromPrecision, fromScale, ok := parseDecimal(from.primitive)
+	if !ok {
+		return false
+	}
+	toPrecision, toScale, ok := parseDecimal(to.primitive)
+	return ok && fromScale == toScale && toPrecision >= fromPrecision
+}
+
+func fieldIDMetadata(id int) arrow.Metadata {
+	return arrow.NewMetadata([]string{"PARQUET:field_id"}, []string{strconv.Itoa(id)})
+}
+
+func arrowType(t fieldType) (arrow.DataType, error) {
+	if t.list != nil {
+		elem, err := arrowType(t.list.Element)
+		if err != nil {
+			return nil, err
+		}
+		return arrow.ListOfField(arrow.Field{
+			Name:     "element",
+			Type:     elem,
+			Nullable: !t.list.ElementRequired,
+			Metadata: fieldIDMetadata(t.list.ElementID),
+		}), nil
+	}
+	switch t.primitive {
+	case "boolean":
+		return arrow.FixedWidthTypes.Boolean, nil
+	case "int":
+		return arrow.PrimitiveTypes.Int32, nil
+	case "long":
+		return arrow.PrimitiveTypes.Int64, nil
+	case "float":
+		return arrow.PrimitiveTypes.Float32, nil
+	case "double":
+		return arrow.PrimitiveTypes.Float64, nil
+	case "date":
+		return arrow.FixedWidthTypes.Date32, nil
+	case "time":
+		return arrow.FixedWidthTypes.Time64us, nil
+	case "timestamp":
+		return &arrow.TimestampType{Unit: arrow.Microsecond}, nil
+	case "timestamptz":
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}, nil
+	case "string":
+		return arrow.BinaryTypes.String, nil
+	case "binary":
+		return arrow.BinaryTypes.Binary, nil
+	}
+	if precision, scale, ok := parseDecimal(t.primitive); ok {
+		return &arrow.Decimal128Type{Precision: precision, Scale: scale}, nil
+	}
+	return nil, fmt.Errorf("unsupported iceberg type %s", t.primitive)
+}
+
+// arrowSchema builds an Arrow schema for the given fields, carrying Iceberg field ids into the Parquet file.
+func arrowSchema(fields []icebergField) (*arrow.Schema, error) {
+	arrowFields := make([]arrow.Field, 0, len(fields))
+	for _, field := range fields {
+		dataType, err := arrowType(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", field.Name, err)
+		}
+		arrowFields = append(arrowFields, arrow.Field{
+			Name:     field.Name,
+			Type:     dataType,
+			Nullable: !field.Required,
+			Metadata: fieldIDMetadata(field.ID),
+		})
+	}
+	return arrow.NewSchema(arrowFields, nil), nil
+}
+
+func decimalToNum(val decimal.Decimal, dt *arrow.Decimal128Type) (decimal128.Num, bool) {
+	scaled := val.Shift(dt.Scale).Truncate(0).BigInt()
+	num := decimal128.FromBigInt(scaled)
+	if !num.FitsInPrecision(dt.Precision) {
+		return num, false
+	}
+	return num, true
+}
+
+func stringValue(qv types.QValue) string {
+	switch v := qv.(type) {
+	case types.QValueQChar:
+		return string(rune(v.Val))
+	case types.QValueInt256:
+		return v.Val.String()
+	case types.QValueUInt256:
+		return v.Val.String()
+	case types.QValueNumeric:
+		return v.Val.String()
+	case types.QValueTimestamp:
+		return v.Val.Format("2006-01-02 15:04:05.999999")
+	case types.QValueTimestampTZ:
+		return v.Val.UTC().Format(time.RFC3339Nano)
+	case types.QValueDate:
+		return v.Val.Format(time.DateOnly)
+	case types.QValueTime:
+		return time.Time{}.Add(v.Val).Format("15:04:05.999999")
+	case types.QValueTimeTZ:
+		return time.Time{}.Add(v.Val).Format("15:04:05.999999") + "+00"
+	case types.QValueBytes:
+		return string(v.Val)
+	default:
+		switch val := qv.Value().(type) {
+		case string:
+			return val
+		case fmt.Stringer:
+			return val.String()
+		case []string, []bool, []int16, []int32, []int64, []float32, []float64:
+			if data, err := json.Marshal(val); err == nil {
+				return string(data)
+			}
+		}
+		return fmt.Sprint(qv.Value())
+	}
+}
+
+// appendValue appends a Go value, as produced by QValue.Value() or an array element, to an Arrow builder.
+// Values which cannot be represented in the column type are appended as null; returns false in that case.
+func appendValue(builder array.Builder, value any) bool {
+	if value == nil {
+		builder.AppendNull()
+		return true
+	}
+	switch b := builder.(type) {
+	case *array.BooleanBuilder:
+		if v, ok := value.(bool); ok {
+			b.Append(v)
+			return true
+		}
+	case *array.Int32Builder:
+		if v, ok := toInt64(value); ok {
+			b.Append(int32(v))
+			return true
+		}
+	case *array.Int64Builder:
+		if v, ok := toInt64(value); ok {
+			b.Append(v)
+			return true
+		}
+	case *array.Float32Builder:
+		switch v := value.(type) {
+		case float32:
+			b.Append(v)
+			return true
+		case float64:
+			b.Append(float32(v))
+			return true
+		}
+	case *array.Float64Builder:
+		switch v := value.(type) {
+		case float32:
+			b.Append(float64(v))
+			return true
+		case float64:
+			b.Append(v)
+			return true
+		}
+	case *array.Date32Builder:
+		if v, ok := value.(time.Time); ok {
+			b.Append(arrow.Date32FromTime(v))
+			return true
+		}
+	case *array.Time64Builder:
+		if v, ok := value.(time.Duration); ok {
+			b.Append(arrow.Time64(v.Microseconds()))
+			return true
+		}
+	case *array.TimestampBuilder:
+		if v, ok := value.(time.Time); ok {
+			b.Append(arrow.Timestamp(v.UnixMicro()))
+			return true
+		}
+	case *array.Decimal128Builder:
+		if val, ok := toDecimal(value); ok {
+			if num, ok := decimalToNum(val, b.Type().(*arrow.Decimal128Type)); ok {
+				b.Append(num)
+				return true
+			}
+		}
+	case *array.BinaryBuilder:
+		switch v := value.(type) {
+		case []byte:
+			b.Append(v)
+			return true
+		case string:
+			b.AppendString(v)
+			return true
+		}
+	case *array.StringBuilder:
+		switch v := value.(type) {
+		case string:
+			b.Append(v)
+		case fmt.Stringer:
+			b.Append(v.String())
+		default:
+			b.Append(fmt.Sprint(v))
+		}
+		return true
+	case *array.ListBuilder:
+		return appendList(b, value)
+	}
+	builder.AppendNull()
+	return false
+}
+
+func appendList(b *array.ListBuilder, value any) bool {
+	valueBuilder := b.ValueBuilder()
+	appendAll := func(n int, at func(int) any) bool {
+		b.Append(true)
+		ok := true
+		for i := range n {
+			ok = appendValue(valueBuilder, at(i)) && ok
+		}
+		return ok
+	}
+	switch v := value.(type) {
+	case []float32:
+		return appendAll(len(v), func(i int) any { return v[i] })
+	case []float64:
+		return appendAll(len(v), func(i int) any { return v[i] })
+	case []int16:
+		return appendAll(len(v), func(i int) any { return v[i] })
+	case []int32:
+		return appendAll(len(v), func(i int) any { return v[i] })
+	case []int64:
+		return appendAll(len(v), func(i int) any { return v[i] })
+	case []bool:
+		return appendAll(len(v), func(i int) any { return v[i] })
+	case []string:
+		return appendAll(len(v), func(i int) any { return v[i] })
+	case []time.Time:
+		return appendAll(len(v), func(i int) any { return v[i] })
+	case []decimal.Decimal:
+		return appendAll(len(v), func(i int) any { return v[i] })
+	case []any:
+		return appendAll(len(v), func(i int) any { return v[i] })
+	}
+	b.AppendNull()
+	return false
+}
+
+func toDecimal(value any) (decimal.Decimal, bool) {
+	switch v := value.(type) {
+	case decimal.Decimal:
+		return v, true
+	case uint64:
+		return decimal.NewFromBigInt(new(big.Int).SetUint64(v), 0), true
+	}
+	if i, ok := toInt64(value); ok {
+		return decimal.NewFromInt(i), true
+	}
+	return decimal.Decimal{}, false
+}
+
+func toInt64(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// columnValue converts a QValue to the Go value appended for a column of the given Iceberg type.
+func columnValue(qv types.QValue, t fieldType) any {
+	if qv == nil {
+		return nil
+	}
+	if _, isNull := qv.(types.QValueNull); isNull {
+		return nil
+	}
+	if t.list == nil && t.primitive == "string" {
+		return stringValue(qv)
+	}
+	if t.list != nil && t.list.Element.primitive == "string" {
+		if v, ok := qv.Value().([]string); ok {
+			return v
+		}
+		if v, ok := qv.(types.QValueArrayUUID); ok {
+			strs := make([]string, 0, len(v.Val))
+			for _, u := range v.Val {
+				strs = append(strs, u.String())
+			}
+			return strs
+		}
+	}
+	return qv.Value()
+}
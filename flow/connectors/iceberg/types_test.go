@@ -0,0 +1,71 @@
+package conniceberg
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestEncodeParquetFieldIDs(t *testing.T) {
+	var lastID int
+	nextID := func() int {
+		lastID++
+		return lastID
+	}
+	fields := []icebergField{
+		{ID: nextID(), Name: "id", Required: true, Type: icebergTypeForKind(types.QValueKindInt64, -1, nextID)},
+		{ID: nextID(), Name: "amount", Type: decimalType(10, 2)},
+		{ID: nextID(), Name: "created", Type: icebergTypeForKind(types.QValueKindTimestampTZ, -1, nextID)},
+		{ID: nextID(), Name: "tags", Type: icebergTypeForKind(types.QValueKindArrayString, -1, nextID)},
+	}
+	rows := [][]any{
+		{
+			columnValue(types.QValueInt64{Val: 1}, fields[0].Type),
+			columnValue(types.QValueNumeric{Val: decimal.RequireFromString("12.34")}, fields[1].Type),
+			columnValue(types.QValueTimestampTZ{Val: time.Unix(1700000000, 0)}, fields[2].Type),
+			columnValue(types.QValueArrayString{Val: []string{"a", "b"}}, fields[3].Type),
+		},
+		{
+			columnValue(types.QValueInt64{Val: 2}, fields[0].Type),
+			// does not fit in decimal(10,2)
+			columnValue(types.QValueNumeric{Val: decimal.RequireFromString("123456789012.5")}, fields[1].Type),
+			columnValue(types.QValueNull(types.QValueKindTimestampTZ), fields[2].Type),
+			columnValue(types.QValueNull(types.QValueKindArrayString), fields[3].Type),
+		},
+	}
+
+	encoded, nulled, err := encodeParquet(fields, rows)
+	require.NoError(t, err)
+	require.Equal(t, 1, nulled)
+
+	reader, err := file.NewParquetReader(bytes.NewReader(encoded))
+	require.NoError(t, err)
+	defer reader.Close()
+	require.Equal(t, int64(2), reader.NumRows())
+	root := reader.MetaData().Schema.Root()
+	for i, field := range fields {
+		require.Equal(t, field.Name, root.Field(i).Name())
+		require.Equal(t, int32(field.ID), root.Field(i).FieldID())
+	}
+	// list element id is on the element node nested under the repeated group
+	require.Equal(t, int32(5), reader.MetaData().Schema.Column(3).SchemaNode().FieldID())
+}
+
+func TestAppendValueIntoString(t *testing.T) {
+	fieldType := icebergTypeForKind(types.QValueKindUUID, -1, nil)
+	require.Equal(t, "string", fieldType.String())
+	value := columnValue(types.QValueInt256{Val: decimal.RequireFromString("123456789012345678901234567890").BigInt()}, fieldType)
+	require.Equal(t, "123456789012345678901234567890", value)
+
+	builder := array.NewStringBuilder(memory.DefaultAllocator)
+	defer builder.Release()
+	require.True(t, appendValue(builder, value))
+}
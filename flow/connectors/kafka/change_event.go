@@ -0,0 +1,759 @@
+package connkafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hamba/avro/v2"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// placeholder Debezium uses for unchanged TOAST columns
+const debeziumUnavailableValue = "__debezium_unavailable_value"
+
+// changeField describes a row field of a change event, as far as its schema is known
+type changeField struct {
+	name string
+	kind types.QValueKind
+	// Kafka Connect or Avro logical type, decides how integers encode times and bytes encode decimals
+	logical   string
+	precision int32
+	scale     int32
+	optional  bool
+}
+
+func (f changeField) fieldDescription() *protos.FieldDescription {
+	typmod := int32(-1)
+	if f.kind == types.QValueKindNumeric && f.precision > 0 {
+		typmod = datatypes.MakeNumericTypmod(f.precision, f.scale)
+	}
+	return &protos.FieldDescription{
+		Name:         f.name,
+		Type:         string(f.kind),
+		TypeModifier: typmod,
+		Nullable:     f.optional,
+	}
+}
+
+// changeEvent is a Debezium change event, rows are nil when absent from the event
+type changeEvent struct {
+	before map[string]any
+	after  map[string]any
+	// fields of the row schema when the event carries one, otherwise nil and kinds are inferred from values
+	fields map[string]changeField
+	// order of fields in the row schema
+	fieldNames []string
+	op         string
+	tsMs       int64
+}
+
+// changeDecoder decodes Debezium envelopes serialized as JSON, with or without an embedded schema,
+// or as Avro framed with a schema registry id
+type changeDecoder struct {
+	registry *schemaRegistryClient
+	// registry schema id -> parsed schema
+	avroSchemas map[int]avro.Schema
+	// embedded JSON schema -> row fields, Kafka Connect repeats the schema in every message
+	jsonSchemas map[string]jsonRowSchema
+}
+
+type jsonRowSchema struct {
+	fields map[string]changeField
+	names  []string
+}
+
+type connectSchema struct {
+	Type       string            `json:"type"`
+	Name       string            `json:"name"`
+	Field      string            `json:"field"`
+	Parameters map[string]string `json:"parameters"`
+	Fields     []connectSchema   `json:"fields"`
+	Optional   bool              `json:"optional"`
+}
+
+func newChangeDecoder(registry *schemaRegistryClient) *changeDecoder {
+	return &changeDecoder{
+		registry:    registry,
+		avroSchemas: make(map[int]avro.Schema),
+		jsonSchemas: make(map[string]jsonRowSchema),
+	}
+}
+
+// decode returns nil for tombstones and messages that are not change events
+func (d *changeDecoder) decode(ctx context.Context, value []byte) (*changeEvent, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	var event *changeEvent
+	if value[0] == 0 && len(value) > 5 {
+		decoded, err := d.decodeAvro(ctx, value)
+		if err != nil {
+			return nil, err
+		}
+		event = decoded
+	} else {
+		decoded, err := d.decodeJSON(value)
+		if err != nil {
+			return nil, err
+		}
+		event = decoded
+	}
+	if event == nil || event.op == "" {
+		return nil, nil
+	}
+	return event, nil
+}
+
+// decodeKey returns the field names of a message key, which Debezium derives from the primary key
+func (d *changeDecoder) decodeKey(ctx context.Context, key []byte) ([]string, error) {
+	if len(key) == 0 {
+		return nil, nil
+	}
+	if key[0] == 0 && len(key) > 5 {
+		schema, err := d.avroSchema(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		record, ok := schema.(*avro.RecordSchema)
+		if !ok {
+			return nil, nil
+		}
+		names := make([]string, 0, len(record.Fields()))
+		for _, field := range record.Fields() {
+			names = append(names, field.Name())
+		}
+		return names, nil
+	}
+
+	var message struct {
+		Schema  *connectSchema  `json:"schema"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(key, &message); err != nil {
+		// keys are not necessarily JSON, such keys do not name any column
+		return nil, nil
+	}
+	if message.Schema != nil {
+		names := make([]string, 0, len(message.Schema.Fields))
+		for _, field := range message.Schema.Fields {
+			names = append(names, field.Field)
+		}
+		return names, nil
+	}
+	var row map[string]json.RawMessage
+	if err := json.Unmarshal(key, &row); err != nil {
+		return nil, nil
+	}
+	return orderedKeys(key, row), nil
+}
+
+func (d *changeDecoder) avroSchema(ctx context.Context, value []byte) (avro.Schema, error) {
+	if d.registry == nil {
+		return nil, errors.New("message is framed with a schema registry id but no schema registry is configured")
+	}
+	id := int(binary.BigEndian.Uint32(value[1:5]))
+	if schema, ok := d.avroSchemas[id]; ok {
+		return schema, nil
+	}
+	registered, err := d.registry.schemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if registered.SchemaType != "" && registered.SchemaType != "AVRO" {
+		return nil, fmt.Errorf("schema %d is %s, only Avro is supported", id, registered.SchemaType)
+	}
+	schema, err := avro.Parse(registered.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema %d: %w", id, err)
+	}
+	d.avroSchemas[id] = schema
+	return schema, nil
+}
+
+func (d *changeDecoder) decodeAvro(ctx context.Context, value []byte) (*changeEvent, error) {
+	schema, err := d.avroSchema(ctx, value)
+	if err != nil {
+		return nil, err
+	}
+	var envelope map[string]any
+	if err := avro.Unmarshal(schema, value[5:], &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode avro change event: %w", err)
+	}
+
+	event := &changeEvent{op: stringValue(envelope["op"]), tsMs: eventTsMs(envelope)}
+	event.before, _ = envelope["before"].(map[string]any)
+	event.after, _ = envelope["after"].(map[string]any)
+	if record, ok := schema.(*avro.RecordSchema); ok {
+		event.fields, event.fieldNames = avroRowFields(record)
+	}
+	return event, nil
+}
+
+func (d *changeDecoder) decodeJSON(value []byte) (*changeEvent, error) {
+	var message map[string]json.RawMessage
+	if err := json.Unmarshal(value, &message); err != nil {
+		return nil, fmt.Errorf("failed to decode change event: %w", err)
+	}
+
+	var rowSchema *jsonRowSchema
+	if rawSchema, ok := message["schema"]; ok {
+		// Kafka Connect JsonConverter with schemas.enable wraps the envelope
+		if payload, ok := message["payload"]; ok {
+			if bytes.Equal(payload, []byte("null")) {
+				return nil, nil
+			}
+			message = nil
+			if err := json.Unmarshal(payload, &message); err != nil {
+				return nil, fmt.Errorf("failed to decode change event payload: %w", err)
+			}
+			parsed, ok := d.jsonSchemas[string(rawSchema)]
+			if !ok {
+				var schema connectSchema
+				if err := json.Unmarshal(rawSchema, &schema); err != nil {
+					return nil, fmt.Errorf("failed to decode change event schema: %w", err)
+				}
+				parsed.fields, parsed.names = connectRowFields(schema)
+				d.jsonSchemas[string(rawSchema)] = parsed
+			}
+			rowSchema = &parsed
+		}
+	}
+
+	var op string
+	if rawOp, ok := message["op"]; !ok {
+		return nil, nil
+	} else if err := json.Unmarshal(rawOp, &op); err != nil {
+		return nil, fmt.Errorf("failed to decode change event op: %w", err)
+	}
+	event := &changeEvent{op: op}
+	if rawTsMs, ok := message["ts_ms"]; ok {
+		_ = json.Unmarshal(rawTsMs, &event.tsMs)
+	}
+	if rawSource, ok := message["source"]; ok {
+		var source struct {
+			TsMs int64 `json:"ts_ms"`
+		}
+		if json.Unmarshal(rawSource, &source) == nil && source.TsMs != 0 {
+			event.tsMs = source.TsMs
+		}
+	}
+
+	var beforeNames, afterNames []string
+	var err error
+	if event.before, beforeNames, err = decodeJSONRow(message["before"]); err != nil {
+		return nil, err
+	}
+	if event.after, afterNames, err = decodeJSONRow(message["after"]); err != nil {
+		return nil, err
+	}
+	if rowSchema != nil {
+		event.fields, event.fieldNames = rowSchema.fields, rowSchema.names
+	} else if afterNames != nil {
+		event.fieldNames = afterNames
+	} else {
+		event.fieldNames = beforeNames
+	}
+	return event, nil
+}
+
+func decodeJSONRow(raw json.RawMessage) (map[string]any, []string, error) {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var row map[string]any
+	if err := decoder.Decode(&row); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode change event row: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, nil, err
+	}
+	return row, orderedKeys(raw, fields), nil
+}
+
+// orderedKeys returns the keys of a JSON object in the order they appear in raw
+func orderedKeys(raw []byte, object map[string]json.RawMessage) []string {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	keys := make([]string, 0, len(object))
+	if _, err := decoder.Token(); err != nil {
+		return keys
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return keys
+		}
+		if key, ok := token.(string); ok {
+			keys = append(keys, key)
+		}
+		var skip json.RawMessage
+		if err := decoder.Decode(&skip); err != nil {
+			return keys
+		}
+	}
+	return keys
+}
+
+func eventTsMs(envelope map[string]any) int64 {
+	if source, ok := envelope["source"].(map[string]any); ok {
+		if tsMs, ok := source["ts_ms"].(int64); ok && tsMs != 0 {
+			return tsMs
+		}
+	}
+	tsMs, _ := envelope["ts_ms"].(int64)
+	return tsMs
+}
+
+func stringValue(value any) string {
+	s, _ := value.(string)
+	return s
+}
+
+// connectRowFields returns the fields of the before/after struct of a Kafka Connect envelope schema
+func connectRowFields(envelope connectSchema) (map[string]changeField, []string) {
+	for _, field := range envelope.Fields {
+		if (field.Field == "after" || field.Field == "before") && field.Type == "struct" {
+			fields := make(map[string]changeField, len(field.Fields))
+			names := make([]string, 0, len(field.Fields))
+			for _, column := range field.Fields {
+				f := changeField{
+					name:     column.Field,
+					kind:     connectKind(column.Type, column.Name),
+					logical:  column.Name,
+					optional: column.Optional,
+				}
+				if scale, err := strconv.ParseInt(column.Parameters["scale"], 10, 32); err == nil {
+					f.scale = int32(scale)
+				}
+				if precision, err := strconv.ParseInt(column.Parameters["connect.decimal.precision"], 10, 32); err == nil {
+					f.precision = int32(precision)
+				}
+				fields[f.name] = f
+				names = append(names, f.name)
+			}
+			return fields, names
+		}
+	}
+	return nil, nil
+}
+
+// avroRowFields returns the fields of the before/after record of an Avro envelope schema
+func avroRowFields(envelope *avro.RecordSchema) (map[string]changeField, []string) {
+	for _, field := range envelope.Fields() {
+		if field.Name() != "after" && field.Name() != "before" {
+			continue
+		}
+		row, _ := unwrapNullable(field.Type())
+		record, ok := row.(*avro.RecordSchema)
+		if !ok {
+			continue
+		}
+		fields := make(map[string]changeField, len(record.Fields()))
+		names := make([]string, 0, len(record.Fields()))
+		for _, column := range record.Fields() {
+			schema, optional := unwrapNullable(column.Type())
+			f := avroField(column.Name(), schema)
+			f.optional = optional
+			fields[f.name] = f
+			names = append(names, f.name)
+		}
+		return fields, names
+	}
+	return nil, nil
+}
+
+func unwrapNullable(schema avro.Schema) (avro.Schema, bool) {
+	union, ok := schema.(*avro.UnionSchema)
+	if !ok || !union.Nullable() {
+		return schema, false
+	}
+	for _, typ := range union.Types() {
+		if typ.Type() != avro.Null {
+			return typ, true
+		}
+	}
+	return schema, true
+}
+
+func avroField(name string, schema avro.Schema) changeField {
+	f := changeField{name: name}
+	if props, ok := schema.(avro.PropertySchema); ok {
+		f.logical, _ = props.Prop("connect.name").(string)
+	}
+	var logical avro.LogicalSchema
+	switch s := schema.(type) {
+	case *avro.PrimitiveSchema:
+		logical = s.Logical()
+	case *avro.FixedSchema:
+		logical = s.Logical()
+	}
+	if decimalSchema, ok := logical.(*avro.DecimalLogicalSchema); ok {
+		f.precision, f.scale = int32(decimalSchema.Precision()), int32(decimalSchema.Scale())
+	}
+	if f.logical == "" && logical != nil {
+		f.logical = string(logical.Type())
+	}
+
+	var connectType string
+	switch schema.Type() {
+	case avro.Int:
+		connectType = "int32"
+	case avro.Long:
+		connectType = "int64"
+	case avro.Float:
+		connectType = "float32"
+	case avro.Double:
+		connectType = "float64"
+	case avro.Boolean:
+		connectType = "boolean"
+	case avro.String, avro.Enum:
+		connectType = "string"
+	case avro.Bytes, avro.Fixed:
+		connectType = "bytes"
+	default:
+		connectType = "struct"
+	}
+	f.kind = connectKind(connectType, f.logical)
+	return f
+}
+
+// connectKind maps a Kafka Connect type, refined by its logical type name, to a QValueKind
+func connectKind(connectType string, logical string) types.QValueKind {
+	switch logical {
+	case "io.debezium.time.Date", "org.apache.kafka.connect.data.Date", "date":
+		return types.QValueKindDate
+	case "io.debezium.time.Timestamp", "io.debezium.time.MicroTimestamp", "io.debezium.time.NanoTimestamp",
+		"org.apache.kafka.connect.data.Timestamp", "timestamp-millis", "timestamp-micros",
+		"local-timestamp-millis", "local-timestamp-micros":
+		return types.QValueKindTimestamp
+	case "io.debezium.time.ZonedTimestamp":
+		return types.QValueKindTimestampTZ
+	case "io.debezium.time.Time", "io.debezium.time.MicroTime", "io.debezium.time.NanoTime",
+		"org.apache.kafka.connect.data.Time", "time-millis", "time-micros":
+		return types.QValueKindTime
+	case "org.apache.kafka.connect.data.Decimal", "io.debezium.data.VariableScaleDecimal", "decimal":
+		return types.QValueKindNumeric
+	case "io.debezium.data.Json":
+		return types.QValueKindJSON
+	case "io.debezium.data.Uuid", "uuid":
+		return types.QValueKindUUID
+	}
+	switch connectType {
+	case "int8", "int16":
+		return types.QValueKindInt16
+	case "int32":
+		return types.QValueKindInt32
+	case "int64":
+		return types.QValueKindInt64
+	case "float32", "float":
+		return types.QValueKindFloat32
+	case "float64", "double":
+		return types.QValueKindFloat64
+	case "boolean":
+		return types.QValueKindBoolean
+	case "bytes":
+		return types.QValueKindBytes
+	case "struct", "map", "array":
+		return types.QValueKindJSON
+	default:
+		return types.QValueKindString
+	}
+}
+
+// inferField guesses the field of a schemaless JSON value, false for nulls which do not reveal a type
+func inferField(name string, value any) (changeField, bool) {
+	f := changeField{name: name, optional: true}
+	switch v := value.(type) {
+	case nil:
+		return f, false
+	case bool:
+		f.kind = types.QValueKindBoolean
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			f.kind = types.QValueKindInt64
+		} else {
+			f.kind = types.QValueKindFloat64
+		}
+	case map[string]any, []any:
+		f.kind = types.QValueKindJSON
+	default:
+		f.kind = types.QValueKindString
+	}
+	return f, true
+}
+
+// row returns the items of a row of the table schema, leaving out unchanged TOAST columns
+func (e *changeEvent) row(
+	values map[string]any, schema *protos.TableSchema, unchangedToastColumns map[string]struct{},
+) (model.RecordItems, error) {
+	items := model.NewRecordItems(len(schema.Columns))
+	for _, column := range schema.Columns {
+		value, ok := values[column.Name]
+		if !ok {
+			continue
+		}
+		if s, isString := value.(string); isString && s == debeziumUnavailableValue {
+			if unchangedToastColumns != nil {
+				unchangedToastColumns[column.Name] = struct{}{}
+			}
+			continue
+		}
+		field, ok := e.fields[column.Name]
+		if !ok {
+			field = changeField{name: column.Name}
+		}
+		field.kind = types.QValueKind(column.Type)
+		qv, err := qvalueFromChange(field, value)
+		if err != nil {
+			return items, fmt.Errorf("failed to convert column %s: %w", column.Name, err)
+		}
+		items.AddColumn(column.Name, qv)
+	}
+	return items, nil
+}
+
+func toInt64(value any) (int64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := v.Float64()
+		return int64(f), err
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float32:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("unexpected %T for integer", value)
+	}
+}
+
+func toFloat64(value any) (float64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.Float64()
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		i, err := toInt64(value)
+		return float64(i), err
+	}
+}
+
+func toBytes(value any) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		// Kafka Connect's JSON converter encodes bytes as base64
+		return base64.StdEncoding.DecodeString(v)
+	default:
+		return nil, fmt.Errorf("unexpected %T for bytes", value)
+	}
+}
+
+func toDecimal(field changeField, value any) (decimal.Decimal, error) {
+	switch v := value.(type) {
+	case *big.Rat:
+		return decimal.NewFromString(v.FloatString(int(max(field.scale, 0))))
+	case json.Number:
+		return decimal.NewFromString(v.String())
+	case float32:
+		return decimal.NewFromFloat32(v), nil
+	case float64:
+		return decimal.NewFromFloat(v), nil
+	case int32, int64:
+		i, err := toInt64(v)
+		return decimal.NewFromInt(i), err
+	case map[string]any:
+		// io.debezium.data.VariableScaleDecimal
+		scale, err := toInt64(v["scale"])
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+		return toDecimal(changeField{logical: "org.apache.kafka.connect.data.Decimal", scale: int32(scale)}, v["value"])
+	case string:
+		if field.logical != "org.apache.kafka.connect.data.Decimal" {
+			return decimal.NewFromString(v)
+		}
+	}
+	unscaled, err := toBytes(value)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	// big-endian two's complement unscaled value
+	i := new(big.Int).SetBytes(unscaled)
+	if len(unscaled) > 0 && unscaled[0]&0x80 != 0 {
+		i.Sub(i, new(big.Int).Lsh(big.NewInt(1), uint(len(unscaled)*8)))
+	}
+	return decimal.NewFromBigInt(i, -field.scale), nil
+}
+
+func toTime(field changeField, value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999Z07:00",
+			"2006-01-02 15:04:05.999999999", time.DateOnly} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unexpected time %s", v)
+	}
+	i, err := toInt64(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch field.logical {
+	case "io.debezium.time.Date", "org.apache.kafka.connect.data.Date", "date":
+		return time.Unix(0, 0).UTC().AddDate(0, 0, int(i)), nil
+	case "io.debezium.time.MicroTimestamp", "timestamp-micros", "local-timestamp-micros":
+		return time.UnixMicro(i).UTC(), nil
+	case "io.debezium.time.NanoTimestamp":
+		return time.Unix(0, i).UTC(), nil
+	default:
+		return time.UnixMilli(i).UTC(), nil
+	}
+}
+
+func toDuration(field changeField, value any) (time.Duration, error) {
+	switch v := value.(type) {
+	case time.Duration:
+		return v, nil
+	case time.Time:
+		return v.Sub(v.Truncate(24 * time.Hour)), nil
+	case string:
+		t, err := time.Parse("15:04:05.999999999", strings.TrimSuffix(v, "Z"))
+		if err != nil {
+			return 0, err
+		}
+		return t.Sub(t.Truncate(24 * time.Hour)), nil
+	}
+	i, err := toInt64(value)
+	if err != nil {
+		return 0, err
+	}
+	switch field.logical {
+	case "io.debezium.time.MicroTime", "time-micros":
+		return time.Duration(i) * time.Microsecond, nil
+	case "io.debezium.time.NanoTime":
+		return time.Duration(i), nil
+	default:
+		return time.Duration(i) * time.Millisecond, nil
+	}
+}
+
+func toJSONString(value any) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	return string(encoded), err
+}
+
+// qvalueFromChange converts a decoded field value to the field's kind
+func qvalueFromChange(field changeField, value any) (types.QValue, error) {
+	if value == nil {
+		return types.QValueNull(field.kind), nil
+	}
+	switch field.kind {
+	case types.QValueKindInt16:
+		i, err := toInt64(value)
+		return types.QValueInt16{Val: int16(i)}, err
+	case types.QValueKindInt32:
+		i, err := toInt64(value)
+		return types.QValueInt32{Val: int32(i)}, err
+	case types.QValueKindInt64:
+		i, err := toInt64(value)
+		return types.QValueInt64{Val: i}, err
+	case types.QValueKindFloat32:
+		f, err := toFloat64(value)
+		return types.QValueFloat32{Val: float32(f)}, err
+	case types.QValueKindFloat64:
+		f, err := toFloat64(value)
+		return types.QValueFloat64{Val: f}, err
+	case types.QValueKindBoolean:
+		if b, ok := value.(bool); ok {
+			return types.QValueBoolean{Val: b}, nil
+		}
+		i, err := toInt64(value)
+		return types.QValueBoolean{Val: i != 0}, err
+	case types.QValueKindBytes:
+		b, err := toBytes(value)
+		return types.QValueBytes{Val: b}, err
+	case types.QValueKindNumeric:
+		d, err := toDecimal(field, value)
+		return types.QValueNumeric{Val: d, Precision: int16(field.precision), Scale: int16(field.scale)}, err
+	case types.QValueKindDate:
+		t, err := toTime(field, value)
+		return types.QValueDate{Val: t}, err
+	case types.QValueKindTimestamp:
+		t, err := toTime(field, value)
+		return types.QValueTimestamp{Val: t}, err
+	case types.QValueKindTimestampTZ:
+		t, err := toTime(field, value)
+		return types.QValueTimestampTZ{Val: t}, err
+	case types.QValueKindTime:
+		d, err := toDuration(field, value)
+		return types.QValueTime{Val: d}, err
+	case types.QValueKindUUID:
+		s, err := toJSONString(value)
+		if err != nil {
+			return nil, err
+		}
+		u, err := uuid.Parse(s)
+		return types.QValueUUID{Val: u}, err
+	case types.QValueKindJSON:
+		s, err := toJSONString(value)
+		return types.QValueJSON{Val: s}, err
+	default:
+		switch v := value.(type) {
+		case string:
+			return types.QValueString{Val: v}, nil
+		case json.Number:
+			return types.QValueString{Val: v.String()}, nil
+		case time.Time:
+			return types.QValueString{Val: v.Format(time.RFC3339Nano)}, nil
+		default:
+			s, err := toJSONString(v)
+			return types.QValueString{Val: s}, err
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package connkafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestDecodeChangeEventWithSchema(t *testing.T) {
+	value := `{"schema":{"type":"struct","fields":[
+		{"type":"struct","field":"before","optional":true,"fields":[
+			{"type":"int32","field":"id"},
+			{"type":"bytes","field":"amount","optional":true,"name":"org.apache.kafka.connect.data.Decimal",
+				"parameters":{"scale":"2","connect.decimal.precision":"10"}},
+			{"type":"int64","field":"updated_at","optional":true,"name":"io.debezium.time.MicroTimestamp"},
+			{"type":"string","field":"notes","optional":true}]},
+		{"type":"struct","field":"after","optional":true,"fields":[
+			{"type":"int32","field":"id"},
+			{"type":"bytes","field":"amount","optional":true,"name":"org.apache.kafka.connect.data.Decimal",
+				"parameters":{"scale":"2","connect.decimal.precision":"10"}},
+			{"type":"int64","field":"updated_at","optional":true,"name":"io.debezium.time.MicroTimestamp"},
+			{"type":"string","field":"notes","optional":true}]},
+		{"type":"string","field":"op"}]},
+		"payload":{"before":null,
+			"after":{"id":1,"amount":"MDk=","updated_at":1700000000000000,"notes":"__debezium_unavailable_value"},
+			"op":"u","source":{"ts_ms":1700000000123}}}`
+
+	event, err := newChangeDecoder(nil).decode(t.Context(), []byte(value))
+	require.NoError(t, err)
+	require.Equal(t, "u", event.op)
+	require.Equal(t, int64(1700000000123), event.tsMs)
+	require.Equal(t, []string{"id", "amount", "updated_at", "notes"}, event.fieldNames)
+	require.Nil(t, event.before)
+
+	schema := &protos.TableSchema{}
+	for _, name := range event.fieldNames {
+		schema.Columns = append(schema.Columns, event.fields[name].fieldDescription())
+	}
+	require.Equal(t, string(types.QValueKindNumeric), schema.Columns[1].Type)
+	require.Equal(t, string(types.QValueKindTimestamp), schema.Columns[2].Type)
+
+	unchangedToastColumns := make(map[string]struct{})
+	items, err := event.row(event.after, schema, unchangedToastColumns)
+	require.NoError(t, err)
+	require.Equal(t, map[string]struct{}{"notes": {}}, unchangedToastColumns)
+	require.Equal(t, types.QValueInt32{Val: 1}, items.GetColumnValue("id"))
+	require.True(t, decimal.RequireFromString("123.45").Equal(items.GetColumnValue("amount").Value().(decimal.Decimal)))
+	require.Equal(t, time.Unix(1700000000, 0).UTC(), items.GetColumnValue("updated_at").Value().(time.Time).UTC())
+	require.Nil(t, items.GetColumnValue("notes"))
+}
+
+func TestDecodeSchemalessChangeEvent(t *testing.T) {
+	decoder := newChangeDecoder(nil)
+	event, err := decoder.decode(t.Context(),
+		[]byte(`{"before":{"id":7,"name":"a","tags":null},"after":null,"op":"d","ts_ms":1700000000000}`))
+	require.NoError(t, err)
+	require.Equal(t, "d", event.op)
+	require.Nil(t, event.fields)
+	require.Equal(t, []string{"id", "name", "tags"}, event.fieldNames)
+
+	field, ok := inferField("id", event.before["id"])
+	require.True(t, ok)
+	require.Equal(t, types.QValueKindInt64, field.kind)
+	_, ok = inferField("tags", event.before["tags"])
+	require.False(t, ok)
+
+	keyColumns, err := decoder.decodeKey(t.Context(), []byte(`{"tenant":1,"id":7}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"tenant", "id"}, keyColumns)
+
+	event, err = decoder.decode(t.Context(), nil)
+	require.NoError(t, err)
+	require.Nil(t, event)
+}
+
+func TestSourceOffsets(t *testing.T) {
+	offsets, err := parseSourceOffsets("")
+	require.NoError(t, err)
+	require.Empty(t, offsets)
+
+	offsets.set("users", 0, 10)
+	offsets.set("users", 3, 42)
+	offsets.set("users", 0, 11)
+
+	parsed, err := parseSourceOffsets(offsets.String())
+	require.NoError(t, err)
+	require.Equal(t, sourceOffsets{"users": {0: 11, 3: 42}}, parsed)
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"sync/atomic"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/twmb/franz-go/plugin/kslog"
 	lua "github.com/yuin/gopher-lua"
 	"go.temporal.io/sdk/log"
+	"google.golang.org/protobuf/proto"
 
 	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
@@ -30,7 +32,14 @@ import (
 type KafkaConnector struct {
 	*metadataStore.PostgresMetadata
 	client *kgo.Client
-	logger log.Logger
+	// nil unless a schema registry is configured
+	registry *schemaRegistryClient
+	logger   log.Logger
+	opts     []kgo.Opt
+	// identifies the peer config a pooled transactional client was created with
+	configKey    string
+	schemaFormat protos.KafkaSchemaFormat
+	exactlyOnce  bool
 }
 
 type kgoTemporalLogger struct {
@@ -119,10 +128,26 @@ func NewKafkaConnector(
 		return nil, fmt.Errorf("failed to create kafka client: %w", err)
 	}
 
+	configKey, err := proto.MarshalOptions{Deterministic: true}.Marshal(config)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to marshal kafka config: %w", err)
+	}
+
+	var registry *schemaRegistryClient
+	if config.SchemaRegistryUrl != "" {
+		registry = newSchemaRegistryClient(config.SchemaRegistryUrl, config.SchemaRegistryUsername, config.SchemaRegistryPassword)
+	}
+
 	return &KafkaConnector{
 		PostgresMetadata: pgMetadata,
 		client:           client,
+		registry:         registry,
 		logger:           logger,
+		opts:             optionalOpts,
+		configKey:        string(configKey),
+		schemaFormat:     config.SchemaFormat,
+		exactlyOnce:      config.ExactlyOnce,
 	}, nil
 }
 
@@ -134,16 +159,52 @@ func (c *KafkaConnector) Close() error {
 }
 
 func (c *KafkaConnector) ConnectionActive(ctx context.Context) error {
-	return c.client.Ping(ctx)
+	if err := c.client.Ping(ctx); err != nil {
+		return err
+	}
+	if c.registry != nil {
+		if _, err := c.registry.do(ctx, http.MethodGet, "/subjects", nil, nil); err != nil {
+			return fmt.Errorf("failed to reach schema registry: %w", err)
+		}
+	}
+	return nil
 }
 
 func (c *KafkaConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
 	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
 }
 
-func (c *KafkaConnector) ReplayTableSchemaDeltas(_ context.Context, _ map[string]string,
+// ReplayTableSchemaDeltas registers new schema versions when a schema registry is configured,
+// deltas arriving with records are registered as they are encountered in SyncRecords
+func (c *KafkaConnector) ReplayTableSchemaDeltas(ctx context.Context, env map[string]string,
 	flowJobName string, _ []*protos.TableMapping, schemaDeltas []*protos.TableSchemaDelta,
 ) error {
+	if c.registry == nil || len(schemaDeltas) == 0 {
+		return nil
+	}
+
+	pool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+	if err != nil {
+		return err
+	}
+	tableSchemas := make(map[string]*protos.TableSchema, len(schemaDeltas))
+	for _, delta := range schemaDeltas {
+		if _, ok := tableSchemas[delta.DstTableName]; ok {
+			continue
+		}
+		tableSchema, err := internal.LoadTableSchemaFromCatalog(ctx, pool, flowJobName, delta.DstTableName)
+		if err != nil {
+			return fmt.Errorf("failed to load table schema of %s: %w", delta.DstTableName, err)
+		}
+		tableSchemas[delta.DstTableName] = tableSchema
+	}
+
+	serializer := newRegistrySerializer(c.registry, c.schemaFormat, env, tableSchemas)
+	for _, delta := range schemaDeltas {
+		if err := serializer.applyDelta(ctx, delta); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -202,8 +263,10 @@ type poolResult struct {
 
 func (c *KafkaConnector) createPool(
 	ctx context.Context,
+	client *kgo.Client,
 	env map[string]string,
 	script string,
+	encoder *utils.DebeziumEncoder,
 	flowJobName string,
 	lastSeenLSN *atomic.Int64,
 	queueErr func(error),
@@ -220,7 +283,9 @@ func (c *KafkaConnector) createPool(
 		if err != nil {
 			return nil, err
 		}
-		if script == "" {
+		if encoder != nil {
+			ls.Env.RawSetString("onRecord", ls.NewFunction(utils.DebeziumOnRecord(encoder, true)))
+		} else if script == "" {
 			ls.Env.RawSetString("onRecord", ls.NewFunction(utils.DefaultOnRecord))
 		}
 		return ls, nil
@@ -241,7 +306,7 @@ func (c *KafkaConnector) createPool(
 						force, envErr := internal.PeerDBQueueForceTopicCreation(ctx, env)
 						if envErr == nil && force {
 							c.logger.Info("[kafka] force topic creation", slog.String("topic", kr.Topic))
-							_, err := kadm.NewClient(c.client).CreateTopic(ctx, 1, 3, nil, kr.Topic)
+							_, err := kadm.NewClient(client).CreateTopic(ctx, 1, 3, nil, kr.Topic)
 							if err != nil && !errors.Is(err, kerr.TopicAlreadyExists) {
 								c.logger.Warn("[kafka] topic create error", slog.Any("error", err))
 								queueErr(err)
@@ -254,7 +319,7 @@ func (c *KafkaConnector) createPool(
 					}
 					if success {
 						time.Sleep(time.Second) // topic creation can take time to propagate, throttle
-						c.client.Produce(ctx, kr, handler)
+						client.Produce(ctx, kr, handler)
 					} else {
 						queueErr(err)
 					}
@@ -263,7 +328,7 @@ func (c *KafkaConnector) createPool(
 				}
 			}
 			for _, kr := range result.records {
-				c.client.Produce(ctx, kr, handler)
+				client.Produce(ctx, kr, handler)
 			}
 		}
 	})
@@ -273,9 +338,50 @@ func (c *KafkaConnector) SyncRecords(ctx context.Context, req *model.SyncRecords
 	numRecords := atomic.Int64{}
 	lastSeenLSN := atomic.Int64{}
 
+	var encoder *utils.DebeziumEncoder
+	if req.QueueEncoding == protos.QueueEncoding_QUEUE_ENCODING_DEBEZIUM {
+		encoder = utils.NewDebeziumEncoder(req.FlowJobName, req.TableNameSchemaMapping, false)
+	}
+	var serializer *registrySerializer
+	if c.registry != nil {
+		serializer = newRegistrySerializer(c.registry, c.schemaFormat, req.Env, req.TableNameSchemaMapping)
+	}
+	// in exactly once mode the checkpoint is committed with the transaction instead of periodically
+	var commit func(model.CdcCheckpoint) error
+	client := c.client
+	if c.exactlyOnce {
+		if err := c.ensureOffsetsTopic(ctx); err != nil {
+			return nil, err
+		}
+		txnClient, err := c.transactionalClient(req.FlowJobName)
+		if err != nil {
+			return nil, err
+		}
+		if err := txnClient.BeginTransaction(); err != nil {
+			discardTransactionalClient(req.FlowJobName)
+			return nil, fmt.Errorf("[kafka] failed to begin transaction: %w", err)
+		}
+		committed := false
+		defer func() {
+			if !committed {
+				c.abortTransaction(context.WithoutCancel(ctx), txnClient)
+				// a fenced or failed producer cannot be reused
+				discardTransactionalClient(req.FlowJobName)
+			}
+		}()
+		client = txnClient
+		commit = func(checkpoint model.CdcCheckpoint) error {
+			if err := c.commitTransaction(ctx, txnClient, req.FlowJobName, req.SyncBatchID, checkpoint); err != nil {
+				return err
+			}
+			committed = true
+			return nil
+		}
+	}
+
 	queueCtx, queueErr := context.WithCancelCause(ctx)
 
-	pool, err := c.createPool(queueCtx, req.Env, req.Script, req.FlowJobName, &lastSeenLSN, queueErr)
+	pool, err := c.createPool(queueCtx, client, req.Env, req.Script, encoder, req.FlowJobName, &lastSeenLSN, queueErr)
 	if err != nil {
 		return nil, err
 	}
@@ -284,6 +390,9 @@ func (c *KafkaConnector) SyncRecords(ctx context.Context, req *model.SyncRecords
 	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
 	flushLoopDone := make(chan struct{})
 	go func() {
+		if commit != nil {
+			return
+		}
 		flushTimeout, err := internal.PeerDBQueueFlushTimeoutSeconds(ctx, req.Env)
 		if err != nil {
 			c.logger.Warn("[kafka] failed to get flush timeout, no periodic flushing", slog.Any("error", err))
@@ -301,7 +410,7 @@ func (c *KafkaConnector) SyncRecords(ctx context.Context, req *model.SyncRecords
 			// flush loop doesn't block processing new messages
 			case <-ticker.C:
 				lastSeen := lastSeenLSN.Load()
-				if err := c.client.Flush(ctx); err != nil {
+				if err := client.Flush(ctx); err != nil {
 					c.logger.Warn("[kafka] flush error", slog.Any("error", err))
 					continue
 				} else if lastSeen > req.ConsumedOffset.Load() {
@@ -325,7 +434,34 @@ Loop:
 				break Loop
 			}
 
+			if relation, ok := record.(*model.RelationRecord[model.RecordItems]); ok {
+				// register before records with the new schema are encoded, schema changes are not passed to scripts
+				if serializer != nil {
+					if err := serializer.applyDelta(queueCtx, relation.TableSchemaDelta); err != nil {
+						queueErr(err)
+						break Loop
+					}
+				}
+				continue
+			}
+
 			pool.Run(func(ls *lua.LState) poolResult {
+				if serializer != nil {
+					results, err := serializer.records(queueCtx, record)
+					if err != nil {
+						queueErr(err)
+						return poolResult{}
+					}
+					if len(results) > 0 {
+						record.PopulateCountMap(tableNameRowsMapping)
+					}
+					numRecords.Add(1)
+					return poolResult{
+						records: results,
+						lsn:     record.GetCheckpointID(),
+					}
+				}
+
 				lfn := ls.Env.RawGetString("onRecord")
 				fn, ok := lfn.(*lua.LFunction)
 				if !ok {
@@ -374,11 +510,16 @@ Loop:
 	if err := pool.Wait(queueCtx); err != nil {
 		return nil, err
 	}
-	if err := c.client.Flush(queueCtx); err != nil {
+	if err := client.Flush(queueCtx); err != nil {
 		return nil, fmt.Errorf("[kafka] final flush error: %w", err)
 	}
 
 	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if commit != nil {
+		if err := commit(lastCheckpoint); err != nil {
+			return nil, err
+		}
+	}
 	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
 		return nil, err
 	}
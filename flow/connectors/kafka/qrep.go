@@ -9,6 +9,7 @@ import (
 	"github.com/twmb/franz-go/pkg/kgo"
 	lua "github.com/yuin/gopher-lua"
 
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/pua"
@@ -33,7 +34,17 @@ func (c *KafkaConnector) SyncQRepRecords(
 	}
 
 	queueCtx, queueErr := context.WithCancelCause(ctx)
-	pool, err := c.createPool(queueCtx, config.Env, config.Script, config.FlowJobName, nil, queueErr)
+	var encoder *utils.DebeziumEncoder
+	if config.QueueEncoding == protos.QueueEncoding_QUEUE_ENCODING_DEBEZIUM {
+		encoder = utils.NewQRepDebeziumEncoder(ctx, config, schema)
+	}
+	var serializer *registrySerializer
+	if c.registry != nil {
+		serializer = newRegistrySerializer(c.registry, c.schemaFormat, config.Env, map[string]*protos.TableSchema{
+			config.DestinationTableIdentifier: utils.QRepTableSchema(ctx, config, schema),
+		})
+	}
+	pool, err := c.createPool(queueCtx, c.client, config.Env, config.Script, encoder, config.FlowJobName, nil, queueErr)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -66,6 +77,16 @@ Loop:
 					CommitID:             0,
 				}
 
+				if serializer != nil {
+					results, err := serializer.records(queueCtx, record)
+					if err != nil {
+						queueErr(err)
+						return poolResult{}
+					}
+					numRecords.Add(1)
+					return poolResult{records: results}
+				}
+
 				lfn := ls.Env.RawGetString("onRecord")
 				fn, ok := lfn.(*lua.LFunction)
 				if !ok {
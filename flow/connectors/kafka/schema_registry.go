@@ -0,0 +1,132 @@
+package connkafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const schemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+
+// schemaRegistryClient speaks the subset of the Confluent Schema Registry REST API needed to register schemas
+type schemaRegistryClient struct {
+	client   *http.Client
+	url      string
+	username string
+	password string
+	// subject + schema -> id, registering the same schema again returns the same id
+	ids sync.Map
+}
+
+type registeredSchema struct {
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType,omitempty"`
+	ID         int    `json:"id"`
+	Version    int    `json:"version"`
+}
+
+type schemaRegistryError struct {
+	Message   string `json:"message"`
+	ErrorCode int    `json:"error_code"`
+}
+
+func newSchemaRegistryClient(registryURL string, username string, password string) *schemaRegistryClient {
+	return &schemaRegistryClient{
+		client:   &http.Client{Timeout: 30 * time.Second},
+		url:      strings.TrimSuffix(registryURL, "/"),
+		username: username,
+		password: password,
+	}
+}
+
+func (c *schemaRegistryClient) do(ctx context.Context, method string, path string, body any, out any) (int, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, reqBody)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create schema registry request: %w", err)
+	}
+	req.Header.Set("Accept", schemaRegistryContentType)
+	if body != nil {
+		req.Header.Set("Content-Type", schemaRegistryContentType)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("schema registry request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var registryErr schemaRegistryError
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(respBody, &registryErr) == nil && registryErr.Message != "" {
+			return resp.StatusCode, fmt.Errorf("schema registry returned status %d: %s (%d)",
+				resp.StatusCode, registryErr.Message, registryErr.ErrorCode)
+		}
+		return resp.StatusCode, fmt.Errorf("schema registry returned status %d: %s", resp.StatusCode, respBody)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode schema registry response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// register adds schema as a new version of subject, failing if the registry deems it incompatible
+func (c *schemaRegistryClient) register(ctx context.Context, subject string, schemaType string, schema string) (int, error) {
+	cacheKey := subject + "\x00" + schema
+	if id, ok := c.ids.Load(cacheKey); ok {
+		return id.(int), nil
+	}
+
+	body := map[string]string{"schema": schema}
+	if schemaType != "AVRO" {
+		body["schemaType"] = schemaType
+	}
+	var registered registeredSchema
+	if _, err := c.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", body, &registered); err != nil {
+		return 0, fmt.Errorf("failed to register schema for subject %s: %w", subject, err)
+	}
+	c.ids.Store(cacheKey, registered.ID)
+	return registered.ID, nil
+}
+
+// latest returns the latest schema of subject, or nil if the subject does not exist
+func (c *schemaRegistryClient) latest(ctx context.Context, subject string) (*registeredSchema, error) {
+	var registered registeredSchema
+	status, err := c.do(ctx, http.MethodGet, "/subjects/"+url.PathEscape(subject)+"/versions/latest", nil, &registered)
+	if status == http.StatusNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get latest schema for subject %s: %w", subject, err)
+	}
+	return &registered, nil
+}
+
+// schemaByID returns the schema registered under id, schemas are immutable so callers may cache them
+func (c *schemaRegistryClient) schemaByID(ctx context.Context, id int) (*registeredSchema, error) {
+	var registered registeredSchema
+	if _, err := c.do(ctx, http.MethodGet, "/schemas/ids/"+strconv.Itoa(id), nil, &registered); err != nil {
+		return nil, fmt.Errorf("failed to get schema %d: %w", id, err)
+	}
+	registered.ID = id
+	return &registered, nil
+}
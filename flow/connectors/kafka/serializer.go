@@ -0,0 +1,480 @@
+package connkafka
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hamba/avro/v2"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// matches the field lines of protobuf schemas generated by protobufDefinition
+var protobufFieldRe = regexp.MustCompile(`(?m)^\s*(?:optional\s+)?\w+\s+(\w+)\s*=\s*(\d+)\s*;`)
+
+// registrySchema is a registered key or value schema of a topic
+type registrySchema struct {
+	avro avro.Schema
+	// column names, fields are named after qvalue.ConvertToAvroCompatibleName of these
+	fields []types.QField
+	// protobuf field numbers, parallel to fields
+	numbers []protowire.Number
+	id      int
+}
+
+type registryTable struct {
+	// nil when the table has no primary key
+	key   *registrySchema
+	value *registrySchema
+}
+
+// registrySerializer encodes rows in the Confluent wire format with schemas registered per topic,
+// subjects follow the default TopicNameStrategy. Keys hold the primary key columns and deletes are tombstones.
+type registrySerializer struct {
+	registry     *schemaRegistryClient
+	env          map[string]string
+	tableSchemas map[string]*protos.TableSchema
+	tables       map[string]*registryTable
+	toJSONOpts   model.ToJSONOptions
+	mu           sync.Mutex
+	format       protos.KafkaSchemaFormat
+}
+
+func newRegistrySerializer(
+	registry *schemaRegistryClient,
+	format protos.KafkaSchemaFormat,
+	env map[string]string,
+	tableSchemas map[string]*protos.TableSchema,
+) *registrySerializer {
+	return &registrySerializer{
+		registry:     registry,
+		format:       format,
+		env:          env,
+		tableSchemas: maps.Clone(tableSchemas),
+		tables:       make(map[string]*registryTable, len(tableSchemas)),
+		toJSONOpts:   model.NewToJSONOptions(nil, true),
+	}
+}
+
+func qFieldFromDescription(column *protos.FieldDescription) types.QField {
+	field := types.QField{
+		Name:     column.Name,
+		Type:     types.QValueKind(column.Type),
+		Nullable: column.Nullable,
+	}
+	if field.Type == types.QValueKindNumeric {
+		field.Precision, field.Scale = datatypes.ParseNumericTypmod(column.TypeModifier)
+	}
+	return field
+}
+
+func (s *registrySerializer) schemaType() string {
+	if s.format == protos.KafkaSchemaFormat_KAFKA_SCHEMA_FORMAT_PROTOBUF {
+		return "PROTOBUF"
+	}
+	return "AVRO"
+}
+
+func (s *registrySerializer) avroDefinition(ctx context.Context, name string, fields []types.QField) (avro.Schema, error) {
+	avroFields := make([]*avro.Field, 0, len(fields))
+	for _, field := range fields {
+		avroType, err := qvalue.GetAvroSchemaFromQValueKind(ctx, s.env, field.Type, protos.DBType_KAFKA, field.Precision, field.Scale)
+		if err != nil {
+			return nil, err
+		}
+		var opts []avro.SchemaOption
+		if field.Nullable {
+			avroType, err = avro.NewUnionSchema([]avro.Schema{avro.NewNullSchema(), avroType})
+			if err != nil {
+				return nil, err
+			}
+			// a default lets readers on either side of a version with this field added or removed resolve it
+			opts = append(opts, avro.WithDefault(nil))
+		}
+		avroField, err := avro.NewField(qvalue.ConvertToAvroCompatibleName(field.Name), avroType, opts...)
+		if err != nil {
+			return nil, err
+		}
+		avroFields = append(avroFields, avroField)
+	}
+	return avro.NewRecordSchema(qvalue.ConvertToAvroCompatibleName(name), "peerdb", avroFields)
+}
+
+func protobufType(kind types.QValueKind) string {
+	switch kind {
+	case types.QValueKindBoolean:
+		return "bool"
+	case types.QValueKindInt8, types.QValueKindInt16, types.QValueKindInt32:
+		return "int32"
+	case types.QValueKindInt64:
+		return "int64"
+	case types.QValueKindUInt8, types.QValueKindUInt16, types.QValueKindUInt32:
+		return "uint32"
+	case types.QValueKindUInt64:
+		return "uint64"
+	case types.QValueKindFloat32:
+		return "float"
+	case types.QValueKindFloat64:
+		return "double"
+	case types.QValueKindBytes:
+		return "bytes"
+	default:
+		// everything else is rendered as it would be in JSON
+		return "string"
+	}
+}
+
+// protobufDefinition renders a proto3 message for fields, previous lists the field numbers
+// of the latest registered version so that columns keep their numbers across versions
+func protobufDefinition(name string, fields []types.QField, previous map[string]protowire.Number) (string, []protowire.Number) {
+	next := protowire.Number(1)
+	for _, number := range previous {
+		next = max(next, number+1)
+	}
+	numbers := make([]protowire.Number, 0, len(fields))
+	var sb strings.Builder
+	sb.WriteString("syntax = \"proto3\";\n\nmessage ")
+	sb.WriteString(qvalue.ConvertToAvroCompatibleName(name))
+	sb.WriteString(" {\n")
+	for _, field := range fields {
+		fieldName := qvalue.ConvertToAvroCompatibleName(field.Name)
+		number, ok := previous[fieldName]
+		if !ok {
+			number = next
+			next += 1
+		}
+		numbers = append(numbers, number)
+		fmt.Fprintf(&sb, "  optional %s %s = %d;\n", protobufType(field.Type), fieldName, number)
+	}
+	sb.WriteString("}\n")
+	return sb.String(), numbers
+}
+
+func (s *registrySerializer) register(ctx context.Context, subject string, name string, fields []types.QField) (*registrySchema, error) {
+	schema := &registrySchema{fields: fields}
+	var definition string
+	if s.format == protos.KafkaSchemaFormat_KAFKA_SCHEMA_FORMAT_PROTOBUF {
+		previous := make(map[string]protowire.Number)
+		latest, err := s.registry.latest(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		if latest != nil {
+			for _, match := range protobufFieldRe.FindAllStringSubmatch(latest.Schema, -1) {
+				if number, err := strconv.ParseInt(match[2], 10, 32); err == nil {
+					previous[match[1]] = protowire.Number(number)
+				}
+			}
+		}
+		definition, schema.numbers = protobufDefinition(name, fields, previous)
+	} else {
+		var err error
+		schema.avro, err = s.avroDefinition(ctx, name, fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build avro schema for subject %s: %w", subject, err)
+		}
+		definition = schema.avro.String()
+	}
+
+	id, err := s.registry.register(ctx, subject, s.schemaType(), definition)
+	if err != nil {
+		return nil, err
+	}
+	schema.id = id
+	return schema, nil
+}
+
+// table registers the key and value schemas of a table on first use
+func (s *registrySerializer) table(ctx context.Context, name string) (*registryTable, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if table, ok := s.tables[name]; ok {
+		return table, nil
+	}
+	tableSchema, ok := s.tableSchemas[name]
+	if !ok {
+		return nil, fmt.Errorf("no table schema for %s", name)
+	}
+
+	valueFields := make([]types.QField, 0, len(tableSchema.Columns))
+	for _, column := range tableSchema.Columns {
+		valueFields = append(valueFields, qFieldFromDescription(column))
+	}
+	table := &registryTable{}
+	if len(tableSchema.PrimaryKeyColumns) > 0 {
+		keyFields := make([]types.QField, 0, len(tableSchema.PrimaryKeyColumns))
+		for _, column := range tableSchema.PrimaryKeyColumns {
+			idx := slices.IndexFunc(valueFields, func(field types.QField) bool { return field.Name == column })
+			if idx == -1 {
+				return nil, fmt.Errorf("primary key column %s missing from table schema of %s", column, name)
+			}
+			keyFields = append(keyFields, valueFields[idx])
+		}
+		var err error
+		if table.key, err = s.register(ctx, name+"-key", name+"_key", keyFields); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if table.value, err = s.register(ctx, name+"-value", name, valueFields); err != nil {
+		return nil, err
+	}
+	s.tables[name] = table
+	return table, nil
+}
+
+// applyDelta registers new schema versions for a table after a schema change
+func (s *registrySerializer) applyDelta(ctx context.Context, delta *protos.TableSchemaDelta) error {
+	s.mu.Lock()
+	tableSchema, ok := s.tableSchemas[delta.DstTableName]
+	if !ok {
+		s.mu.Unlock()
+		return nil
+	}
+	columns := slices.Clone(tableSchema.Columns)
+	for _, column := range delta.AddedColumns {
+		if !slices.ContainsFunc(columns, func(fd *protos.FieldDescription) bool { return fd.Name == column.Name }) {
+			// rows written before the column existed have no value for it
+			added := &protos.FieldDescription{Name: column.Name, Type: column.Type, TypeModifier: column.TypeModifier, Nullable: true}
+			columns = append(columns, added)
+		}
+	}
+	for _, column := range delta.DroppedColumns {
+		if delta.DroppedColumnPolicy == protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP {
+			columns = slices.DeleteFunc(columns, func(fd *protos.FieldDescription) bool { return fd.Name == column.Name })
+		} else if idx := slices.IndexFunc(columns, func(fd *protos.FieldDescription) bool { return fd.Name == column.Name }); idx != -1 {
+			// new rows have no value for the column
+			kept := proto.CloneOf(columns[idx])
+			kept.Nullable = true
+			columns[idx] = kept
+		}
+	}
+	for _, altered := range delta.AlteredColumns {
+		if altered.Current == nil {
+			continue
+		}
+		if idx := slices.IndexFunc(columns, func(fd *protos.FieldDescription) bool {
+			return fd.Name == altered.Current.Name
+		}); idx != -1 {
+			retyped := proto.CloneOf(columns[idx])
+			retyped.Type = altered.Current.Type
+			retyped.TypeModifier = altered.Current.TypeModifier
+			columns[idx] = retyped
+		}
+	}
+
+	updated := proto.CloneOf(tableSchema)
+	updated.Columns = columns
+	s.tableSchemas[delta.DstTableName] = updated
+	delete(s.tables, delta.DstTableName)
+	s.mu.Unlock()
+
+	internal.LoggerFromCtx(ctx).Info("[kafka] registering schema change", slog.String("table", delta.DstTableName))
+	_, err := s.table(ctx, delta.DstTableName)
+	return err
+}
+
+// records encodes a change as a kafka record, other kinds of records are skipped
+func (s *registrySerializer) records(ctx context.Context, record model.Record[model.RecordItems]) ([]*kgo.Record, error) {
+	var items model.RecordItems
+	var tombstone bool
+	switch r := record.(type) {
+	case *model.InsertRecord[model.RecordItems]:
+		items = r.Items
+	case *model.UpdateRecord[model.RecordItems]:
+		// unchanged TOAST columns are absent from new items and encoded as null
+		items = r.NewItems
+	case *model.DeleteRecord[model.RecordItems]:
+		items = r.Items
+		tombstone = true
+	default:
+		return nil, nil
+	}
+
+	topic := record.GetDestinationTableName()
+	table, err := s.table(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	var key []byte
+	if table.key != nil {
+		if key, err = s.encode(ctx, table.key, items); err != nil {
+			return nil, fmt.Errorf("failed to encode key for %s: %w", topic, err)
+		}
+	}
+	if tombstone {
+		if key == nil {
+			return nil, nil
+		}
+		return []*kgo.Record{{Topic: topic, Key: key}}, nil
+	}
+	value, err := s.encode(ctx, table.value, items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value for %s: %w", topic, err)
+	}
+	return []*kgo.Record{{Topic: topic, Key: key, Value: value}}, nil
+}
+
+// encode prefixes the serialized items with the magic byte and schema id of the Confluent wire format
+func (s *registrySerializer) encode(ctx context.Context, schema *registrySchema, items model.RecordItems) ([]byte, error) {
+	buf := make([]byte, 5, 64)
+	binary.BigEndian.PutUint32(buf[1:], uint32(schema.id))
+	if schema.avro != nil {
+		return s.encodeAvro(ctx, buf, schema, items)
+	}
+	// message indexes, a single 0 refers to the first message of the schema
+	buf = append(buf, 0)
+	return s.encodeProtobuf(buf, schema, items)
+}
+
+func (s *registrySerializer) encodeAvro(
+	ctx context.Context, buf []byte, schema *registrySchema, items model.RecordItems,
+) ([]byte, error) {
+	logger := internal.LoggerFromCtx(ctx)
+	row := make(map[string]any, len(schema.fields))
+	for idx := range schema.fields {
+		field := &schema.fields[idx]
+		var value any
+		if qv := items.GetColumnValue(field.Name); qv != nil {
+			stat := qvalue.NewNumericStat("", field.Name)
+			var err error
+			if value, err = qvalue.QValueToAvro(
+				ctx, qv, field, protos.DBType_KAFKA, logger, false, &stat, internal.BinaryFormatRaw,
+			); err != nil {
+				return nil, err
+			}
+		}
+		if value == nil && !field.Nullable {
+			return nil, fmt.Errorf("no value for non-nullable column %s", field.Name)
+		}
+		row[qvalue.ConvertToAvroCompatibleName(field.Name)] = value
+	}
+	data, err := avro.Marshal(schema.avro, row)
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, data...), nil
+}
+
+func (s *registrySerializer) encodeProtobuf(buf []byte, schema *registrySchema, items model.RecordItems) ([]byte, error) {
+	var rowJSON map[string]json.RawMessage
+	for idx, field := range schema.fields {
+		qv := items.GetColumnValue(field.Name)
+		if qv == nil || qv.Value() == nil {
+			continue
+		}
+		number := schema.numbers[idx]
+		switch protobufType(field.Type) {
+		case "bool":
+			v, ok := qv.Value().(bool)
+			if !ok {
+				return nil, fmt.Errorf("column %s: expected bool, got %T", field.Name, qv.Value())
+			}
+			buf = protowire.AppendTag(buf, number, protowire.VarintType)
+			buf = protowire.AppendVarint(buf, protowire.EncodeBool(v))
+		case "int32", "int64":
+			v, err := signedValue(qv.Value())
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", field.Name, err)
+			}
+			buf = protowire.AppendTag(buf, number, protowire.VarintType)
+			buf = protowire.AppendVarint(buf, uint64(v))
+		case "uint32", "uint64":
+			v, err := unsignedValue(qv.Value())
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", field.Name, err)
+			}
+			buf = protowire.AppendTag(buf, number, protowire.VarintType)
+			buf = protowire.AppendVarint(buf, v)
+		case "float":
+			v, ok := qv.Value().(float32)
+			if !ok {
+				return nil, fmt.Errorf("column %s: expected float32, got %T", field.Name, qv.Value())
+			}
+			buf = protowire.AppendTag(buf, number, protowire.Fixed32Type)
+			buf = protowire.AppendFixed32(buf, math.Float32bits(v))
+		case "double":
+			v, ok := qv.Value().(float64)
+			if !ok {
+				return nil, fmt.Errorf("column %s: expected float64, got %T", field.Name, qv.Value())
+			}
+			buf = protowire.AppendTag(buf, number, protowire.Fixed64Type)
+			buf = protowire.AppendFixed64(buf, math.Float64bits(v))
+		case "bytes":
+			v, ok := qv.Value().([]byte)
+			if !ok {
+				return nil, fmt.Errorf("column %s: expected bytes, got %T", field.Name, qv.Value())
+			}
+			buf = protowire.AppendTag(buf, number, protowire.BytesType)
+			buf = protowire.AppendBytes(buf, v)
+		default:
+			if rowJSON == nil {
+				data, err := items.MarshalJSONWithOptions(s.toJSONOpts)
+				if err != nil {
+					return nil, err
+				}
+				if err := json.Unmarshal(data, &rowJSON); err != nil {
+					return nil, err
+				}
+			}
+			raw := rowJSON[field.Name]
+			v := string(raw)
+			if len(raw) > 0 && raw[0] == '"' {
+				if err := json.Unmarshal(raw, &v); err != nil {
+					return nil, err
+				}
+			}
+			buf = protowire.AppendTag(buf, number, protowire.BytesType)
+			buf = protowire.AppendString(buf, v)
+		}
+	}
+	return buf, nil
+}
+
+func signedValue(value any) (int64, error) {
+	switch v := value.(type) {
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("expected signed integer, got %T", value)
+	}
+}
+
+func unsignedValue(value any) (uint64, error) {
+	switch v := value.(type) {
+	case uint8:
+		return uint64(v), nil
+	case uint16:
+		return uint64(v), nil
+	case uint32:
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("expected unsigned integer, got %T", value)
+	}
+}
@@ -0,0 +1,208 @@
+package connkafka
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hamba/avro/v2"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// fakeSchemaRegistry stands in for a schema registry, assigning a new id to every distinct schema
+type fakeSchemaRegistry struct {
+	subjects map[string][]registeredSchema
+	ids      map[string]int
+	mu       sync.Mutex
+}
+
+func newFakeSchemaRegistry(t *testing.T) (*fakeSchemaRegistry, *schemaRegistryClient) {
+	t.Helper()
+	registry := &fakeSchemaRegistry{subjects: make(map[string][]registeredSchema), ids: make(map[string]int)}
+	server := httptest.NewServer(registry)
+	t.Cleanup(server.Close)
+	return registry, newSchemaRegistryClient(server.URL, "user", "pass")
+}
+
+func (r *fakeSchemaRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, pass, ok := req.BasicAuth(); !ok || user != "user" || pass != "pass" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	path := strings.TrimPrefix(req.URL.Path, "/subjects/")
+	switch {
+	case req.Method == http.MethodPost && strings.HasSuffix(path, "/versions"):
+		subject := strings.TrimSuffix(path, "/versions")
+		var body map[string]string
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		id, ok := r.ids[body["schema"]]
+		if !ok {
+			id = len(r.ids) + 1
+			r.ids[body["schema"]] = id
+		}
+		versions := r.subjects[subject]
+		if len(versions) == 0 || versions[len(versions)-1].Schema != body["schema"] {
+			r.subjects[subject] = append(versions, registeredSchema{Schema: body["schema"], ID: id, Version: len(versions) + 1})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+	case req.Method == http.MethodGet && strings.HasSuffix(path, "/versions/latest"):
+		versions := r.subjects[strings.TrimSuffix(path, "/versions/latest")]
+		if len(versions) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(schemaRegistryError{Message: "Subject not found.", ErrorCode: 40401})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(versions[len(versions)-1])
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func testTableSchemas() map[string]*protos.TableSchema {
+	return map[string]*protos.TableSchema{
+		"orders": {
+			TableIdentifier:   "public.orders",
+			PrimaryKeyColumns: []string{"id"},
+			Columns: []*protos.FieldDescription{
+				{Name: "id", Type: string(types.QValueKindInt64)},
+				{Name: "note", Type: string(types.QValueKindString), Nullable: true},
+				{Name: "flag", Type: string(types.QValueKindBoolean), Nullable: true},
+			},
+		},
+	}
+}
+
+func testInsert(items model.RecordItems) *model.InsertRecord[model.RecordItems] {
+	return &model.InsertRecord[model.RecordItems]{
+		Items:                items,
+		SourceTableName:      "public.orders",
+		DestinationTableName: "orders",
+	}
+}
+
+func TestRegistrySerializerAvro(t *testing.T) {
+	registry, client := newFakeSchemaRegistry(t)
+	serializer := newRegistrySerializer(client, protos.KafkaSchemaFormat_KAFKA_SCHEMA_FORMAT_AVRO, nil, testTableSchemas())
+
+	items := model.NewRecordItems(2)
+	items.AddColumn("id", types.QValueInt64{Val: 7})
+	items.AddColumn("note", types.QValueString{Val: "hi"})
+	records, err := serializer.records(t.Context(), testInsert(items))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "orders", records[0].Topic)
+
+	value := records[0].Value
+	require.Equal(t, byte(0), value[0])
+	valueID := int(binary.BigEndian.Uint32(value[1:5]))
+	require.Equal(t, registry.subjects["orders-value"][0].ID, valueID)
+	valueSchema, err := avro.Parse(registry.subjects["orders-value"][0].Schema)
+	require.NoError(t, err)
+	var row map[string]any
+	require.NoError(t, avro.Unmarshal(valueSchema, value[5:], &row))
+	require.Equal(t, int64(7), row["id"])
+	require.Equal(t, "hi", row["note"])
+	require.Nil(t, row["flag"])
+
+	keySchema, err := avro.Parse(registry.subjects["orders-key"][0].Schema)
+	require.NoError(t, err)
+	var key map[string]any
+	require.NoError(t, avro.Unmarshal(keySchema, records[0].Key[5:], &key))
+	require.Equal(t, map[string]any{"id": int64(7)}, key)
+
+	records, err = serializer.records(t.Context(), &model.DeleteRecord[model.RecordItems]{
+		Items:                items,
+		SourceTableName:      "public.orders",
+		DestinationTableName: "orders",
+	})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Nil(t, records[0].Value)
+	require.NotNil(t, records[0].Key)
+
+	require.NoError(t, serializer.applyDelta(t.Context(), &protos.TableSchemaDelta{
+		DstTableName:        "orders",
+		AddedColumns:        []*protos.FieldDescription{{Name: "total", Type: string(types.QValueKindFloat64)}},
+		DroppedColumns:      []*protos.FieldDescription{{Name: "flag", Type: string(types.QValueKindBoolean)}},
+		DroppedColumnPolicy: protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP,
+	}))
+	require.Len(t, registry.subjects["orders-value"], 2)
+	require.Len(t, registry.subjects["orders-key"], 1)
+	valueSchema, err = avro.Parse(registry.subjects["orders-value"][1].Schema)
+	require.NoError(t, err)
+	var names []string
+	for _, field := range valueSchema.(*avro.RecordSchema).Fields() {
+		names = append(names, field.Name())
+	}
+	require.Equal(t, []string{"id", "note", "total"}, names)
+}
+
+func TestRegistrySerializerProtobuf(t *testing.T) {
+	registry, client := newFakeSchemaRegistry(t)
+	serializer := newRegistrySerializer(client, protos.KafkaSchemaFormat_KAFKA_SCHEMA_FORMAT_PROTOBUF, nil, testTableSchemas())
+
+	require.NoError(t, serializer.applyDelta(t.Context(), &protos.TableSchemaDelta{
+		DstTableName:        "orders",
+		AddedColumns:        []*protos.FieldDescription{{Name: "total", Type: string(types.QValueKindFloat64)}},
+		DroppedColumns:      []*protos.FieldDescription{{Name: "note", Type: string(types.QValueKindString)}},
+		DroppedColumnPolicy: protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP,
+	}))
+	require.Len(t, registry.subjects["orders-value"], 1)
+	require.Contains(t, registry.subjects["orders-value"][0].Schema, "optional double total = 3;")
+
+	// registering again, e.g. after a restart, keeps field numbers of existing columns
+	serializer = newRegistrySerializer(client, protos.KafkaSchemaFormat_KAFKA_SCHEMA_FORMAT_PROTOBUF, nil, testTableSchemas())
+	require.NoError(t, serializer.applyDelta(t.Context(), &protos.TableSchemaDelta{
+		DstTableName:        "orders",
+		AddedColumns:        []*protos.FieldDescription{{Name: "total", Type: string(types.QValueKindFloat64)}},
+		DroppedColumns:      []*protos.FieldDescription{{Name: "flag", Type: string(types.QValueKindBoolean)}},
+		DroppedColumnPolicy: protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP,
+	}))
+	require.Contains(t, registry.subjects["orders-value"][1].Schema, "optional string note = 4;")
+
+	items := model.NewRecordItems(3)
+	items.AddColumn("id", types.QValueInt64{Val: -1})
+	items.AddColumn("note", types.QValueString{Val: "hi"})
+	items.AddColumn("total", types.QValueFloat64{Val: 1.5})
+	records, err := serializer.records(t.Context(), testInsert(items))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	value := records[0].Value
+	require.Equal(t, registry.subjects["orders-value"][1].ID, int(binary.BigEndian.Uint32(value[1:5])))
+	require.Equal(t, byte(0), value[5])
+	fields := make(map[protowire.Number]any)
+	for b := value[6:]; len(b) > 0; {
+		number, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			fields[number], b = int64(v), b[n:]
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			fields[number], b = v, b[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			fields[number], b = v, b[n:]
+		}
+	}
+	require.Equal(t, int64(-1), fields[1])
+	require.Equal(t, "hi", fields[4])
+	require.Len(t, fields, 3)
+}
@@ -0,0 +1,654 @@
+package connkafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/hamba/avro/v2"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+const (
+	// number of records read from the end of every partition when inferring a topic's schema
+	schemaSampleSize = 16
+	// how long to wait for more records while sampling before assuming the end of a partition was reached
+	samplePollTimeout = 5 * time.Second
+)
+
+// sourceOffsets is the next offset to consume per topic and partition,
+// mirrors from Kafka store it as the text of their checkpoint
+type sourceOffsets map[string]map[int32]int64
+
+func parseSourceOffsets(text string) (sourceOffsets, error) {
+	offsets := make(sourceOffsets)
+	if text == "" {
+		return offsets, nil
+	}
+	if err := json.Unmarshal([]byte(text), &offsets); err != nil {
+		return nil, fmt.Errorf("failed to parse kafka offsets from checkpoint: %w", err)
+	}
+	return offsets, nil
+}
+
+func (o sourceOffsets) set(topic string, partition int32, offset int64) {
+	partitions, ok := o[topic]
+	if !ok {
+		partitions = make(map[int32]int64)
+		o[topic] = partitions
+	}
+	partitions[partition] = offset
+}
+
+func (o sourceOffsets) String() string {
+	text, _ := json.Marshal(o)
+	return string(text)
+}
+
+// sourceConsumerGroup is the consumer group of a mirror from Kafka, offsets are committed to it for monitoring
+// but the mirror's checkpoint is what consumption resumes from
+func sourceConsumerGroup(flowJobName string) string {
+	return "peerdb-" + flowJobName
+}
+
+// GetTableSchema reads the schema of a topic's rows from the schema registry,
+// or else from the latest change event in the topic
+func (c *KafkaConnector) GetTableSchema(
+	ctx context.Context,
+	env map[string]string,
+	_ uint32,
+	system protos.TypeSystem,
+	tableMappings []*protos.TableMapping,
+) (map[string]*protos.TableSchema, error) {
+	nullableEnabled, err := internal.PeerDBNullable(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := newChangeDecoder(c.registry)
+	res := make(map[string]*protos.TableSchema, len(tableMappings))
+	for _, tm := range tableMappings {
+		fields, keyColumns, err := c.topicFields(ctx, decoder, tm.SourceTableIdentifier)
+		if err != nil {
+			c.logger.Info("error fetching schema", slog.String("topic", tm.SourceTableIdentifier), slog.Any("error", err))
+			return nil, err
+		}
+		columns := make([]*protos.FieldDescription, 0, len(fields))
+		for _, field := range fields {
+			if !slices.Contains(tm.Exclude, field.name) {
+				columns = append(columns, field.fieldDescription())
+			}
+		}
+		primaryKey := make([]string, 0, len(keyColumns))
+		for _, column := range keyColumns {
+			if slices.ContainsFunc(columns, func(fd *protos.FieldDescription) bool { return fd.Name == column }) {
+				primaryKey = append(primaryKey, column)
+			}
+		}
+		res[tm.SourceTableIdentifier] = &protos.TableSchema{
+			TableIdentifier:       tm.SourceTableIdentifier,
+			PrimaryKeyColumns:     primaryKey,
+			IsReplicaIdentityFull: false,
+			System:                system,
+			NullableEnabled:       nullableEnabled,
+			Columns:               columns,
+		}
+		c.logger.Info("fetched schema", slog.String("topic", tm.SourceTableIdentifier))
+	}
+	return res, nil
+}
+
+// topicFields returns the row fields and key columns of a topic's change events
+func (c *KafkaConnector) topicFields(
+	ctx context.Context, decoder *changeDecoder, topic string,
+) ([]changeField, []string, error) {
+	if c.registry != nil {
+		fields, keyColumns, err := c.registryFields(ctx, topic)
+		if err != nil || fields != nil {
+			return fields, keyColumns, err
+		}
+	}
+
+	records, err := c.readTail(ctx, topic, schemaSampleSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	// latest event first, schemaless events are typed by their non-null values
+	slices.SortFunc(records, func(a *kgo.Record, b *kgo.Record) int {
+		return b.Timestamp.Compare(a.Timestamp)
+	})
+	var fields []changeField
+	var keyColumns []string
+	inferred := make(map[string]int)
+	for _, record := range records {
+		event, err := decoder.decode(ctx, record.Value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode change event from topic %s: %w", topic, err)
+		} else if event == nil {
+			continue
+		}
+		if keyColumns == nil {
+			if keyColumns, err = decoder.decodeKey(ctx, record.Key); err != nil {
+				return nil, nil, err
+			}
+		}
+		if event.fields != nil {
+			fields = make([]changeField, 0, len(event.fieldNames))
+			for _, name := range event.fieldNames {
+				fields = append(fields, event.fields[name])
+			}
+			return fields, keyColumns, nil
+		}
+		for _, name := range event.fieldNames {
+			idx, seen := inferred[name]
+			if !seen {
+				idx = len(fields)
+				inferred[name] = idx
+				fields = append(fields, changeField{name: name, kind: types.QValueKindString, optional: true})
+			} else if fields[idx].kind != types.QValueKindString {
+				continue
+			}
+			value, ok := event.after[name]
+			if !ok || value == nil {
+				value = event.before[name]
+			}
+			if field, ok := inferField(name, value); ok {
+				fields[idx] = field
+			}
+		}
+	}
+	if fields == nil {
+		return nil, nil, fmt.Errorf("topic %s has no change events to read its schema from, "+
+			"configure a schema registry or produce an event first", topic)
+	}
+	return fields, keyColumns, nil
+}
+
+// registryFields returns nil fields if the topic has no Avro schema in the registry
+func (c *KafkaConnector) registryFields(ctx context.Context, topic string) ([]changeField, []string, error) {
+	value, err := c.registry.latest(ctx, topic+"-value")
+	if err != nil || value == nil || (value.SchemaType != "" && value.SchemaType != "AVRO") {
+		return nil, nil, err
+	}
+	schema, err := avro.Parse(value.Schema)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse schema of topic %s: %w", topic, err)
+	}
+	envelope, ok := schema.(*avro.RecordSchema)
+	if !ok {
+		return nil, nil, nil
+	}
+	byName, names := avroRowFields(envelope)
+	if byName == nil {
+		return nil, nil, nil
+	}
+	fields := make([]changeField, 0, len(names))
+	for _, name := range names {
+		fields = append(fields, byName[name])
+	}
+
+	var keyColumns []string
+	key, err := c.registry.latest(ctx, topic+"-key")
+	if err != nil {
+		return nil, nil, err
+	} else if key != nil {
+		if keySchema, err := avro.Parse(key.Schema); err == nil {
+			if record, ok := keySchema.(*avro.RecordSchema); ok {
+				for _, field := range record.Fields() {
+					keyColumns = append(keyColumns, field.Name())
+				}
+			}
+		}
+	}
+	return fields, keyColumns, nil
+}
+
+// readTail returns up to n of the last records of every partition of topic
+func (c *KafkaConnector) readTail(ctx context.Context, topic string, n int64) ([]*kgo.Record, error) {
+	adm := kadm.NewClient(c.client)
+	starts, err := adm.ListStartOffsets(ctx, topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets of topic %s: %w", topic, err)
+	}
+	ends, err := adm.ListEndOffsets(ctx, topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets of topic %s: %w", topic, err)
+	}
+	if err := errors.Join(starts.Error(), ends.Error()); err != nil {
+		return nil, fmt.Errorf("failed to list offsets of topic %s: %w", topic, err)
+	}
+
+	partitions := make(map[int32]kgo.Offset)
+	remaining := make(map[int32]int64)
+	ends.Each(func(end kadm.ListedOffset) {
+		start, _ := starts.Lookup(topic, end.Partition)
+		if end.Offset > start.Offset {
+			partitions[end.Partition] = kgo.NewOffset().At(max(end.Offset-n, start.Offset))
+			remaining[end.Partition] = end.Offset
+		}
+	})
+	if len(partitions) == 0 {
+		return nil, nil
+	}
+
+	consumer, err := kgo.NewClient(append(slices.Clone(c.opts),
+		kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{topic: partitions}),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+	)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	var records []*kgo.Record
+	for len(remaining) > 0 {
+		pollCtx, cancel := context.WithTimeout(ctx, samplePollTimeout)
+		fetches := consumer.PollFetches(pollCtx)
+		cancel()
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := fetchesError(fetches); err != nil {
+			return nil, fmt.Errorf("failed to read topic %s: %w", topic, err)
+		}
+		if fetches.NumRecords() == 0 {
+			// control records of transactions may end a partition
+			break
+		}
+		fetches.EachRecord(func(record *kgo.Record) {
+			records = append(records, record)
+			if record.Offset >= remaining[record.Partition]-1 {
+				delete(remaining, record.Partition)
+			}
+		})
+	}
+	return records, nil
+}
+
+// fetchesError returns the first error of fetches other than the poll context ending
+func fetchesError(fetches kgo.Fetches) error {
+	var fetchErr error
+	fetches.EachError(func(_ string, _ int32, err error) {
+		if fetchErr == nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+			fetchErr = err
+		}
+	})
+	return fetchErr
+}
+
+func (c *KafkaConnector) ValidateMirrorSource(ctx context.Context, cfg *protos.FlowConnectionConfigs) error {
+	if cfg.DoInitialSnapshot {
+		return errors.New("initial snapshot is not supported from Kafka, topics are consumed from their earliest retained offset " +
+			"unless PEERDB_KAFKA_SOURCE_START_AT_LATEST is set")
+	}
+	if cfg.System != protos.TypeSystem_Q {
+		return errors.New("mirrors from Kafka only support the Q type system")
+	}
+
+	topics := make([]string, 0, len(cfg.TableMappings))
+	for _, tm := range cfg.TableMappings {
+		topics = append(topics, tm.SourceTableIdentifier)
+	}
+	if len(topics) == 0 {
+		return nil
+	}
+	details, err := kadm.NewClient(c.client).ListTopics(ctx, topics...)
+	if err != nil {
+		return fmt.Errorf("failed to list topics: %w", err)
+	}
+	for _, topic := range topics {
+		if detail, ok := details[topic]; !ok || detail.Err != nil {
+			return fmt.Errorf("topic %s does not exist", topic)
+		}
+	}
+	return nil
+}
+
+// SetupReplication records where consumption starts, which is the start of every partition unless configured otherwise
+func (c *KafkaConnector) SetupReplication(ctx context.Context, input *protos.SetupReplicationInput) (model.SetupReplicationResult, error) {
+	startAtLatest, err := internal.PeerDBKafkaSourceStartAtLatest(ctx, input.Env)
+	if err != nil || !startAtLatest {
+		return model.SetupReplicationResult{}, err
+	}
+
+	ends, err := kadm.NewClient(c.client).ListEndOffsets(ctx, slices.Collect(maps.Keys(input.TableNameMapping))...)
+	if err == nil {
+		err = ends.Error()
+	}
+	if err != nil {
+		return model.SetupReplicationResult{}, fmt.Errorf("failed to list end offsets: %w", err)
+	}
+	offsets := make(sourceOffsets)
+	ends.Each(func(end kadm.ListedOffset) {
+		offsets.set(end.Topic, end.Partition, end.Offset)
+	})
+	if err := c.SetLastOffset(ctx, input.FlowJobName, model.CdcCheckpoint{Text: offsets.String()}); err != nil {
+		return model.SetupReplicationResult{}, fmt.Errorf("failed to store initial offsets: %w", err)
+	}
+	c.logger.Info("SetupReplication completed, stored latest offsets", slog.String("flowJobName", input.FlowJobName))
+	return model.SetupReplicationResult{}, nil
+}
+
+// stubs for CDCPullConnectorCore
+
+func (c *KafkaConnector) EnsurePullability(ctx context.Context, req *protos.EnsurePullabilityBatchInput) (
+	*protos.EnsurePullabilityBatchOutput, error,
+) {
+	return nil, nil
+}
+
+func (c *KafkaConnector) ExportTxSnapshot(context.Context, map[string]string) (*protos.ExportTxSnapshotOutput, any, error) {
+	return nil, nil, nil
+}
+
+func (c *KafkaConnector) FinishExport(any) error {
+	return nil
+}
+
+func (c *KafkaConnector) SetupReplConn(context.Context) error {
+	return nil
+}
+
+func (c *KafkaConnector) ReplPing(context.Context) error {
+	return nil
+}
+
+// end stubs
+
+// UpdateReplStateLastOffset commits synced offsets to the mirror's consumer group, so lag shows up in Kafka tooling
+func (c *KafkaConnector) UpdateReplStateLastOffset(ctx context.Context, lastOffset model.CdcCheckpoint) error {
+	offsets, err := parseSourceOffsets(lastOffset.Text)
+	if err != nil || len(offsets) == 0 {
+		return err
+	}
+	flowJobName, _ := ctx.Value(shared.FlowNameKey).(string)
+	if flowJobName == "" {
+		return nil
+	}
+
+	var commit kadm.Offsets
+	for topic, partitions := range offsets {
+		for partition, offset := range partitions {
+			commit.AddOffset(topic, partition, offset, -1)
+		}
+	}
+	if err := kadm.NewClient(c.client).CommitAllOffsets(ctx, sourceConsumerGroup(flowJobName), commit); err != nil {
+		c.logger.Warn("[kafka] failed to commit offsets to consumer group", slog.Any("error", err))
+	}
+	return nil
+}
+
+func (c *KafkaConnector) PullFlowCleanup(ctx context.Context, jobName string) error {
+	if _, err := kadm.NewClient(c.client).DeleteGroup(ctx, sourceConsumerGroup(jobName)); err != nil &&
+		!errors.Is(err, kerr.GroupIDNotFound) {
+		return fmt.Errorf("failed to delete consumer group: %w", err)
+	}
+	return nil
+}
+
+func (c *KafkaConnector) PullRecords(
+	ctx context.Context,
+	catalogPool shared.CatalogPool,
+	otelManager *otel_metrics.OtelManager,
+	req *model.PullRecordsRequest[model.RecordItems],
+) error {
+	defer req.RecordStream.Close()
+	c.logger.Info("[started] PullRecords for mirror "+req.FlowJobName,
+		slog.Any("table_mapping", req.TableNameMapping),
+		slog.Uint64("max_batch_size", uint64(req.MaxBatchSize)),
+		slog.Duration("idle_timeout", req.IdleTimeout))
+
+	offsets, err := parseSourceOffsets(req.LastOffset.Text)
+	if err != nil {
+		return err
+	}
+	// partitions are assigned explicitly, the checkpoint decides where each one resumes
+	topics := slices.Collect(maps.Keys(req.TableNameMapping))
+	starts, err := kadm.NewClient(c.client).ListStartOffsets(ctx, topics...)
+	if err == nil {
+		err = starts.Error()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list partitions: %w", err)
+	}
+	partitions := make(map[string]map[int32]kgo.Offset, len(topics))
+	starts.Each(func(start kadm.ListedOffset) {
+		if partitions[start.Topic] == nil {
+			partitions[start.Topic] = make(map[int32]kgo.Offset)
+		}
+		offset := kgo.NewOffset().AtStart()
+		if checkpointed, ok := offsets[start.Topic][start.Partition]; ok {
+			offset = kgo.NewOffset().At(checkpointed)
+		}
+		partitions[start.Topic][start.Partition] = offset
+	})
+	consumer, err := kgo.NewClient(append(slices.Clone(c.opts),
+		kgo.ConsumePartitions(partitions),
+		// records past the checkpoint may have been removed by retention
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+	)...)
+	if err != nil {
+		return fmt.Errorf("failed to create kafka consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	decoder := newChangeDecoder(c.registry)
+	// columns added during the batch are tracked here, the destination reads the schemas of the request
+	schemas := maps.Clone(req.TableNameSchemaMapping)
+	checkpointID := req.LastOffset.ID
+	var recordCount uint32
+	defer func() {
+		if recordCount == 0 {
+			req.RecordStream.SignalAsEmpty()
+		}
+		c.logger.Info(fmt.Sprintf("[finished] PullRecords streamed %d records", recordCount))
+	}()
+	// before first record, we wait indefinitely
+	// after first record, we wait for idle timeout
+	getCtx, cancelGet := context.WithCancel(ctx)
+	defer cancelGet()
+	var idleTimer *time.Timer
+	defer func() {
+		if idleTimer != nil {
+			idleTimer.Stop()
+		}
+	}()
+
+	for recordCount < req.MaxBatchSize {
+		fetches := consumer.PollRecords(getCtx, int(req.MaxBatchSize-recordCount))
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fetchesError(fetches); err != nil {
+			return fmt.Errorf("failed to consume records: %w", err)
+		}
+
+		for _, kr := range fetches.Records() {
+			checkpointID += 1
+			offsets.set(kr.Topic, kr.Partition, kr.Offset+1)
+			record, err := c.changeRecord(ctx, catalogPool, req, schemas, decoder, kr, checkpointID)
+			if err != nil {
+				return err
+			} else if record == nil {
+				continue
+			}
+
+			recordCount += 1
+			if err := req.RecordStream.AddRecord(ctx, record); err != nil {
+				return err
+			}
+			if recordCount == 1 {
+				req.RecordStream.SignalAsNotEmpty()
+				idleTimer = time.AfterFunc(req.IdleTimeout, cancelGet)
+			}
+		}
+		if getCtx.Err() != nil {
+			break
+		}
+	}
+
+	req.RecordStream.UpdateLatestCheckpointText(offsets.String())
+	req.RecordStream.UpdateLatestCheckpointID(checkpointID)
+	return nil
+}
+
+// changeRecord converts a change event to a record, nil for tombstones and events without rows
+func (c *KafkaConnector) changeRecord(
+	ctx context.Context,
+	catalogPool shared.CatalogPool,
+	req *model.PullRecordsRequest[model.RecordItems],
+	schemas map[string]*protos.TableSchema,
+	decoder *changeDecoder,
+	kr *kgo.Record,
+	checkpointID int64,
+) (model.Record[model.RecordItems], error) {
+	event, err := decoder.decode(ctx, kr.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode change event at %s/%d@%d: %w", kr.Topic, kr.Partition, kr.Offset, err)
+	} else if event == nil {
+		return nil, nil
+	}
+	nameAndExclude, ok := req.TableNameMapping[kr.Topic]
+	if !ok {
+		return nil, nil
+	}
+	schema, ok := schemas[nameAndExclude.Name]
+	if !ok {
+		return nil, fmt.Errorf("schema not found for %s", nameAndExclude.Name)
+	}
+	schema, err = c.addNewColumns(ctx, catalogPool, req, kr.Topic, nameAndExclude, schema, event)
+	if err != nil {
+		return nil, err
+	}
+	schemas[nameAndExclude.Name] = schema
+
+	commitTime := kr.Timestamp
+	if event.tsMs != 0 {
+		commitTime = time.UnixMilli(event.tsMs)
+	}
+	baseRecord := model.BaseRecord{CheckpointID: checkpointID, CommitTimeNano: commitTime.UnixNano()}
+	switch event.op {
+	case "c", "r":
+		items, err := event.row(event.after, schema, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &model.InsertRecord[model.RecordItems]{
+			BaseRecord:           baseRecord,
+			Items:                items,
+			SourceTableName:      kr.Topic,
+			DestinationTableName: nameAndExclude.Name,
+		}, nil
+	case "u":
+		unchangedToastColumns := make(map[string]struct{})
+		newItems, err := event.row(event.after, schema, unchangedToastColumns)
+		if err != nil {
+			return nil, err
+		}
+		oldItems, err := event.row(event.before, schema, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &model.UpdateRecord[model.RecordItems]{
+			BaseRecord:            baseRecord,
+			OldItems:              oldItems,
+			NewItems:              newItems,
+			UnchangedToastColumns: unchangedToastColumns,
+			SourceTableName:       kr.Topic,
+			DestinationTableName:  nameAndExclude.Name,
+		}, nil
+	case "d":
+		if event.before == nil {
+			c.logger.Warn("[kafka] skipping delete without the deleted row, set REPLICA IDENTITY on the source table",
+				slog.String("topic", kr.Topic), slog.Int64("offset", kr.Offset))
+			return nil, nil
+		}
+		unchangedToastColumns := make(map[string]struct{})
+		items, err := event.row(event.before, schema, unchangedToastColumns)
+		if err != nil {
+			return nil, err
+		}
+		return &model.DeleteRecord[model.RecordItems]{
+			BaseRecord:            baseRecord,
+			Items:                 items,
+			UnchangedToastColumns: unchangedToastColumns,
+			SourceTableName:       kr.Topic,
+			DestinationTableName:  nameAndExclude.Name,
+		}, nil
+	case "t":
+		return &model.TruncateRecord[model.RecordItems]{
+			BaseRecord:           baseRecord,
+			SourceTableName:      kr.Topic,
+			DestinationTableName: nameAndExclude.Name,
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// addNewColumns adds fields of an event missing from the table schema as columns
+func (c *KafkaConnector) addNewColumns(
+	ctx context.Context,
+	catalogPool shared.CatalogPool,
+	req *model.PullRecordsRequest[model.RecordItems],
+	topic string,
+	nameAndExclude model.NameAndExclude,
+	schema *protos.TableSchema,
+	event *changeEvent,
+) (*protos.TableSchema, error) {
+	var added []*protos.FieldDescription
+	for _, name := range event.fieldNames {
+		if _, excluded := nameAndExclude.Exclude[name]; excluded {
+			continue
+		}
+		if slices.ContainsFunc(schema.Columns, func(column *protos.FieldDescription) bool { return column.Name == name }) {
+			continue
+		}
+		field, ok := event.fields[name]
+		if !ok {
+			value, hasValue := event.after[name]
+			if !hasValue || value == nil {
+				value = event.before[name]
+			}
+			// a schemaless field is added once it has a value revealing its type
+			if field, ok = inferField(name, value); !ok {
+				continue
+			}
+		}
+		added = append(added, field.fieldDescription())
+	}
+	if len(added) == 0 {
+		return schema, nil
+	}
+	updated := proto.CloneOf(schema)
+	updated.Columns = append(updated.Columns, added...)
+
+	tableSchemaDelta := &protos.TableSchemaDelta{
+		SrcTableName:    topic,
+		DstTableName:    nameAndExclude.Name,
+		AddedColumns:    added,
+		System:          protos.TypeSystem_Q,
+		NullableEnabled: schema.NullableEnabled,
+	}
+	c.logger.Info("Column change detected",
+		slog.String("table", nameAndExclude.Name), slog.Any("addedColumns", tableSchemaDelta.AddedColumns))
+	req.RecordStream.AddSchemaDelta(req.TableNameMapping, tableSchemaDelta)
+	return updated, monitoring.AuditSchemaDelta(ctx, catalogPool.Pool, req.FlowJobName, tableSchemaDelta)
+}
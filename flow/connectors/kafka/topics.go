@@ -0,0 +1,237 @@
+package connkafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// topicSettings applies the table mapping's overrides on top of the mirror's settings
+func topicSettings(mirror *protos.TopicSettings, table *protos.TopicSettings) *protos.TopicSettings {
+	settings := &protos.TopicSettings{
+		Partitions:        mirror.GetPartitions(),
+		ReplicationFactor: mirror.GetReplicationFactor(),
+		RetentionMs:       mirror.GetRetentionMs(),
+		MinInsyncReplicas: mirror.GetMinInsyncReplicas(),
+	}
+	if mirror != nil && mirror.Compact != nil {
+		settings.Compact = mirror.Compact
+	}
+	if table == nil {
+		return settings
+	}
+	if table.Partitions > 0 {
+		settings.Partitions = table.Partitions
+	}
+	if table.ReplicationFactor > 0 {
+		settings.ReplicationFactor = table.ReplicationFactor
+	}
+	if table.Compact != nil {
+		settings.Compact = table.Compact
+	}
+	if table.RetentionMs != 0 {
+		settings.RetentionMs = table.RetentionMs
+	}
+	if table.MinInsyncReplicas > 0 {
+		settings.MinInsyncReplicas = table.MinInsyncReplicas
+	}
+	return settings
+}
+
+// topicConfigs converts settings to topic configs, leaving out unset ones so the broker defaults apply
+func topicConfigs(settings *protos.TopicSettings) map[string]*string {
+	configs := make(map[string]*string)
+	if settings.Compact != nil {
+		if *settings.Compact {
+			configs["cleanup.policy"] = kadm.StringPtr("compact")
+		} else {
+			configs["cleanup.policy"] = kadm.StringPtr("delete")
+		}
+	}
+	if settings.RetentionMs != 0 {
+		configs["retention.ms"] = kadm.StringPtr(strconv.FormatInt(settings.RetentionMs, 10))
+	}
+	if settings.MinInsyncReplicas > 0 {
+		configs["min.insync.replicas"] = kadm.StringPtr(strconv.FormatInt(int64(settings.MinInsyncReplicas), 10))
+	}
+	return configs
+}
+
+func tableTopicSettings(
+	mirror *protos.TopicSettings, tableMappings []*protos.TableMapping, topic string,
+) *protos.TopicSettings {
+	for _, tm := range tableMappings {
+		if tm.DestinationTableIdentifier == topic {
+			return topicSettings(mirror, tm.TopicSettings)
+		}
+	}
+	return topicSettings(mirror, nil)
+}
+
+// provisionsTopic reports whether the destination of a table mapping is a topic to set up,
+// scripts route rows themselves so only mappings with topic settings name a topic then
+func provisionsTopic(script string, tm *protos.TableMapping) bool {
+	return script == "" || tm.TopicSettings != nil
+}
+
+// validateTopic checks an existing topic against settings, returning an error describing the first mismatch
+func validateTopic(detail kadm.TopicDetail, configs kadm.ResourceConfig, settings *protos.TopicSettings) error {
+	if settings.Partitions > 0 && len(detail.Partitions) != int(settings.Partitions) {
+		return fmt.Errorf("topic %s has %d partitions, expected %d",
+			detail.Topic, len(detail.Partitions), settings.Partitions)
+	}
+	if settings.ReplicationFactor > 0 {
+		if replicationFactor := detail.Partitions.NumReplicas(); replicationFactor != int(settings.ReplicationFactor) {
+			return fmt.Errorf("topic %s has replication factor %d, expected %d",
+				detail.Topic, replicationFactor, settings.ReplicationFactor)
+		}
+	}
+
+	current := make(map[string]string, len(configs.Configs))
+	for _, config := range configs.Configs {
+		if config.Value != nil {
+			current[config.Key] = *config.Value
+		}
+	}
+	for key, expected := range topicConfigs(settings) {
+		actual := current[key]
+		if key == "cleanup.policy" {
+			// compact,delete both compacts and expires
+			if strings.Contains(actual, "compact") == (*expected == "compact") {
+				continue
+			}
+		} else if actual == *expected {
+			continue
+		}
+		return fmt.Errorf("topic %s has %s=%s, expected %s", detail.Topic, key, actual, *expected)
+	}
+	return nil
+}
+
+func (c *KafkaConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (c *KafkaConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+func (c *KafkaConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+// SetupNormalizedTable creates the topic of a table mapping, existing topics are left as is
+func (c *KafkaConnector) SetupNormalizedTable(
+	ctx context.Context,
+	tx any,
+	config *protos.SetupNormalizedTableBatchInput,
+	destinationTableIdentifier string,
+	sourceTableSchema *protos.TableSchema,
+) (bool, error) {
+	if !slices.ContainsFunc(config.TableMappings, func(tm *protos.TableMapping) bool {
+		return tm.DestinationTableIdentifier == destinationTableIdentifier && provisionsTopic(config.Script, tm)
+	}) {
+		return false, nil
+	}
+	settings := tableTopicSettings(config.TopicSettings, config.TableMappings, destinationTableIdentifier)
+	partitions, replicationFactor := int32(-1), int16(-1)
+	if settings.Partitions > 0 {
+		partitions = settings.Partitions
+	}
+	if settings.ReplicationFactor > 0 {
+		replicationFactor = int16(settings.ReplicationFactor)
+	}
+
+	if _, err := kadm.NewClient(c.client).CreateTopic(
+		ctx, partitions, replicationFactor, topicConfigs(settings), destinationTableIdentifier,
+	); errors.Is(err, kerr.TopicAlreadyExists) {
+		c.logger.Info("[kafka] topic already exists, skipping", slog.String("topic", destinationTableIdentifier))
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to create topic %s: %w", destinationTableIdentifier, err)
+	}
+	return false, nil
+}
+
+func (c *KafkaConnector) ValidateMirrorDestination(
+	ctx context.Context,
+	cfg *protos.FlowConnectionConfigs,
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+) error {
+	adm := kadm.NewClient(c.client)
+	brokers, err := adm.ListBrokers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list brokers: %w", err)
+	}
+
+	topics := make([]string, 0, len(cfg.TableMappings))
+	settings := make(map[string]*protos.TopicSettings, len(cfg.TableMappings))
+	for _, tm := range cfg.TableMappings {
+		if !provisionsTopic(cfg.Script, tm) {
+			continue
+		}
+		tableSettings := topicSettings(cfg.TopicSettings, tm.TopicSettings)
+		if tableSettings.ReplicationFactor > int32(len(brokers)) {
+			return fmt.Errorf("replication factor %d of topic %s exceeds the %d available brokers",
+				tableSettings.ReplicationFactor, tm.DestinationTableIdentifier, len(brokers))
+		}
+		if tableSettings.ReplicationFactor > 0 && tableSettings.MinInsyncReplicas > tableSettings.ReplicationFactor {
+			return fmt.Errorf("min insync replicas %d of topic %s exceeds its replication factor %d",
+				tableSettings.MinInsyncReplicas, tm.DestinationTableIdentifier, tableSettings.ReplicationFactor)
+		}
+		// compaction keeps the last record per key, records are keyed by primary key
+		if tableSettings.GetCompact() && cfg.Script == "" {
+			if schema, ok := tableNameSchemaMapping[tm.SourceTableIdentifier]; ok && len(schema.PrimaryKeyColumns) == 0 {
+				return fmt.Errorf("topic %s is compacted but table %s has no primary key",
+					tm.DestinationTableIdentifier, tm.SourceTableIdentifier)
+			}
+		}
+		if _, ok := settings[tm.DestinationTableIdentifier]; !ok {
+			topics = append(topics, tm.DestinationTableIdentifier)
+		}
+		settings[tm.DestinationTableIdentifier] = tableSettings
+	}
+
+	if len(topics) == 0 {
+		return nil
+	}
+	details, err := adm.ListTopics(ctx, topics...)
+	if err != nil {
+		return fmt.Errorf("failed to list topics: %w", err)
+	}
+	var existing []string
+	for _, topic := range topics {
+		if detail, ok := details[topic]; ok && detail.Err == nil {
+			existing = append(existing, topic)
+		}
+	}
+	if len(existing) == 0 {
+		return nil
+	}
+
+	configs, err := adm.DescribeTopicConfigs(ctx, existing...)
+	if err != nil {
+		return fmt.Errorf("failed to describe topic configs: %w", err)
+	}
+	for _, topic := range existing {
+		config, err := configs.On(topic, nil)
+		if err == nil {
+			err = config.Err
+		}
+		if err != nil {
+			return fmt.Errorf("failed to describe config of topic %s: %w", topic, err)
+		}
+		if err := validateTopic(details[topic], config, settings[topic]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package connkafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestTopicSettings(t *testing.T) {
+	compact, noCompact := true, false
+	mirror := &protos.TopicSettings{Partitions: 6, ReplicationFactor: 3, Compact: &compact, MinInsyncReplicas: 2}
+	tableMappings := []*protos.TableMapping{
+		{DestinationTableIdentifier: "orders", TopicSettings: &protos.TopicSettings{Partitions: 12, Compact: &noCompact}},
+		{DestinationTableIdentifier: "users"},
+	}
+
+	settings := tableTopicSettings(mirror, tableMappings, "orders")
+	require.Equal(t, int32(12), settings.Partitions)
+	require.Equal(t, int32(3), settings.ReplicationFactor)
+	require.False(t, settings.GetCompact())
+	require.Equal(t, map[string]*string{
+		"cleanup.policy":      kadm.StringPtr("delete"),
+		"min.insync.replicas": kadm.StringPtr("2"),
+	}, topicConfigs(settings))
+
+	settings = tableTopicSettings(mirror, tableMappings, "users")
+	require.Equal(t, int32(6), settings.Partitions)
+	require.True(t, settings.GetCompact())
+	require.Empty(t, topicConfigs(tableTopicSettings(nil, tableMappings, "users")))
+
+	detail := kadm.TopicDetail{Topic: "users", Partitions: kadm.PartitionDetails{
+		0: {Replicas: []int32{1, 2, 3}}, 1: {Replicas: []int32{1, 2, 3}},
+	}}
+	config := func(policy string) kadm.ResourceConfig {
+		return kadm.ResourceConfig{Name: "users", Configs: []kadm.Config{
+			{Key: "cleanup.policy", Value: kadm.StringPtr(policy)},
+			{Key: "min.insync.replicas", Value: kadm.StringPtr("2")},
+		}}
+	}
+	require.ErrorContains(t, validateTopic(detail, config("compact"), settings), "has 2 partitions, expected 6")
+	settings.Partitions = 2
+	require.NoError(t, validateTopic(detail, config("compact,delete"), settings))
+	require.ErrorContains(t, validateTopic(detail, config("delete"), settings), "cleanup.policy=delete")
+
+	// scripted mirrors only provision topics of mappings with topic settings
+	require.True(t, provisionsTopic("", tableMappings[1]))
+	require.True(t, provisionsTopic("function onRecord(r) end", tableMappings[0]))
+	require.False(t, provisionsTopic("function onRecord(r) end", tableMappings[1]))
+}
@@ -0,0 +1,237 @@
+package connkafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+const (
+	// offsetsTopic holds the last committed checkpoint of each mirror in exactly once mode, keyed by mirror name.
+	// It is written in the same transaction as the batch, so it is never behind or ahead of the records.
+	offsetsTopic = "__peerdb_offsets"
+	// brokers cap this with transaction.max.timeout.ms, which defaults to 15 minutes
+	transactionTimeout = 10 * time.Minute
+	// reading offsetsTopic up to its last stable offset takes longer only when the broker is unreachable
+	offsetsReadTimeout = time.Minute
+)
+
+// transactional producers outlive connectors, which only last one batch, so the transactional id of a mirror
+// is initialized once per worker instead of fencing the previous producer and waiting it out every batch
+var transactionalClients = struct {
+	sync.Mutex
+	clients map[string]*pooledTransactionalClient
+}{clients: make(map[string]*pooledTransactionalClient)}
+
+type pooledTransactionalClient struct {
+	client    *kgo.Client
+	configKey string
+}
+
+type transactionalOffset struct {
+	Text        string `json:"text,omitempty"`
+	ID          int64  `json:"id"`
+	SyncBatchID int64  `json:"sync_batch_id"`
+}
+
+// transactionalClient is a producer whose transactional id is stable per mirror,
+// so a restarted sync fences off and aborts whatever its predecessor left open
+func (c *KafkaConnector) transactionalClient(flowJobName string) (*kgo.Client, error) {
+	transactionalClients.Lock()
+	defer transactionalClients.Unlock()
+	if pooled, ok := transactionalClients.clients[flowJobName]; ok {
+		if pooled.configKey == c.configKey {
+			return pooled.client, nil
+		}
+		// peer was edited
+		pooled.client.Close()
+		delete(transactionalClients.clients, flowJobName)
+	}
+
+	client, err := kgo.NewClient(append(slices.Clone(c.opts),
+		kgo.TransactionalID("peerdb-"+flowJobName),
+		kgo.TransactionTimeout(transactionTimeout),
+	)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transactional kafka client: %w", err)
+	}
+	transactionalClients.clients[flowJobName] = &pooledTransactionalClient{client: client, configKey: c.configKey}
+	return client, nil
+}
+
+// discardTransactionalClient closes the producer of a mirror, the next batch starts with a new one
+func discardTransactionalClient(flowJobName string) {
+	transactionalClients.Lock()
+	defer transactionalClients.Unlock()
+	if pooled, ok := transactionalClients.clients[flowJobName]; ok {
+		pooled.client.Close()
+		delete(transactionalClients.clients, flowJobName)
+	}
+}
+
+func (c *KafkaConnector) ensureOffsetsTopic(ctx context.Context) error {
+	if _, err := kadm.NewClient(c.client).CreateTopic(
+		ctx, 1, -1, map[string]*string{"cleanup.policy": kadm.StringPtr("compact")}, offsetsTopic,
+	); err != nil && !errors.Is(err, kerr.TopicAlreadyExists) {
+		return fmt.Errorf("failed to create %s topic: %w", offsetsTopic, err)
+	}
+	return nil
+}
+
+// commitTransaction records the batch's checkpoint and commits it together with the batch's records
+func (c *KafkaConnector) commitTransaction(
+	ctx context.Context, client *kgo.Client, flowJobName string, syncBatchID int64, checkpoint model.CdcCheckpoint,
+) error {
+	value, err := json.Marshal(transactionalOffset{Text: checkpoint.Text, ID: checkpoint.ID, SyncBatchID: syncBatchID})
+	if err != nil {
+		return err
+	}
+	if err := client.ProduceSync(ctx, &kgo.Record{
+		Topic: offsetsTopic,
+		Key:   []byte(flowJobName),
+		Value: value,
+	}).FirstErr(); err != nil {
+		return fmt.Errorf("[kafka] failed to produce checkpoint: %w", err)
+	}
+	if err := client.Flush(ctx); err != nil {
+		return fmt.Errorf("[kafka] final flush error: %w", err)
+	}
+	if err := client.EndTransaction(ctx, kgo.TryCommit); err != nil {
+		return fmt.Errorf("[kafka] failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (c *KafkaConnector) abortTransaction(ctx context.Context, client *kgo.Client) {
+	if err := client.AbortBufferedRecords(ctx); err != nil {
+		c.logger.Warn("[kafka] failed to abort buffered records", slog.Any("error", err))
+	}
+	if err := client.EndTransaction(ctx, kgo.TryAbort); err != nil {
+		c.logger.Warn("[kafka] failed to abort transaction", slog.Any("error", err))
+	}
+}
+
+// readTransactionalOffset reads the last committed checkpoint of a mirror from offsetsTopic, nil if there is none.
+// The topic is read up to its last stable offset, past which no transaction has committed yet.
+func (c *KafkaConnector) readTransactionalOffset(ctx context.Context, flowJobName string) (*transactionalOffset, error) {
+	admin := kadm.NewClient(c.client)
+	listed, err := admin.ListCommittedOffsets(ctx, offsetsTopic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets of %s: %w", offsetsTopic, err)
+	}
+	end, ok := listed.Lookup(offsetsTopic, 0)
+	if !ok || errors.Is(end.Err, kerr.UnknownTopicOrPartition) {
+		return nil, nil
+	} else if end.Err != nil {
+		return nil, fmt.Errorf("failed to list offsets of %s: %w", offsetsTopic, end.Err)
+	}
+	listed, err = admin.ListStartOffsets(ctx, offsetsTopic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets of %s: %w", offsetsTopic, err)
+	}
+	start, ok := listed.Lookup(offsetsTopic, 0)
+	if !ok || start.Err != nil {
+		return nil, fmt.Errorf("failed to list start offset of %s: %w", offsetsTopic, start.Err)
+	}
+	if start.Offset >= end.Offset {
+		return nil, nil
+	}
+
+	consumer, err := kgo.NewClient(append(slices.Clone(c.opts),
+		kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{offsetsTopic: {0: kgo.NewOffset().At(start.Offset)}}),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+		// commit and abort markers are the last offsets of transactions, they are needed to tell the end was reached
+		kgo.KeepControlRecords(),
+	)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	readCtx, cancel := context.WithTimeout(ctx, offsetsReadTimeout)
+	defer cancel()
+	var offset *transactionalOffset
+	for next := start.Offset; next < end.Offset; {
+		fetches := consumer.PollFetches(readCtx)
+		if err := readCtx.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read %s up to offset %d, stopped at %d: %w", offsetsTopic, end.Offset, next, err)
+		}
+		if err := fetchesError(fetches); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", offsetsTopic, err)
+		}
+
+		var decodeErr error
+		fetches.EachRecord(func(record *kgo.Record) {
+			if record.Offset >= end.Offset {
+				return
+			}
+			next = record.Offset + 1
+			if record.Attrs.IsControl() || string(record.Key) != flowJobName {
+				return
+			}
+			if record.Value == nil {
+				offset = nil
+				return
+			}
+			var decoded transactionalOffset
+			if err := json.Unmarshal(record.Value, &decoded); err != nil {
+				decodeErr = err
+				return
+			}
+			offset = &decoded
+		})
+		if decodeErr != nil {
+			return nil, fmt.Errorf("failed to decode checkpoint from %s: %w", offsetsTopic, decodeErr)
+		}
+	}
+	return offset, nil
+}
+
+// GetLastOffset prefers the checkpoint committed to Kafka in exactly once mode,
+// the catalog falls behind when a worker stops between committing a transaction and finishing the batch
+func (c *KafkaConnector) GetLastOffset(ctx context.Context, jobName string) (model.CdcCheckpoint, error) {
+	offset, err := c.PostgresMetadata.GetLastOffset(ctx, jobName)
+	if err != nil || !c.exactlyOnce {
+		return offset, err
+	}
+
+	committed, err := c.readTransactionalOffset(ctx, jobName)
+	if err != nil {
+		return offset, err
+	}
+	if committed != nil && committed.ID > offset.ID {
+		checkpoint := model.CdcCheckpoint{ID: committed.ID, Text: committed.Text}
+		c.logger.Info("[kafka] restoring checkpoint committed with last transaction",
+			slog.Int64("offset", committed.ID), slog.Int64("syncBatchID", committed.SyncBatchID))
+		if err := c.FinishBatch(ctx, jobName, committed.SyncBatchID, checkpoint); err != nil {
+			return offset, err
+		}
+		return checkpoint, nil
+	}
+	return offset, nil
+}
+
+func (c *KafkaConnector) SyncFlowCleanup(ctx context.Context, jobName string) error {
+	discardTransactionalClient(jobName)
+	if c.exactlyOnce {
+		if err := c.ensureOffsetsTopic(ctx); err != nil {
+			return err
+		}
+		// tombstone lets compaction remove the mirror's checkpoint
+		if err := c.client.ProduceSync(ctx, &kgo.Record{Topic: offsetsTopic, Key: []byte(jobName)}).FirstErr(); err != nil {
+			return fmt.Errorf("failed to remove checkpoint from %s: %w", offsetsTopic, err)
+		}
+	}
+	return c.PostgresMetadata.SyncFlowCleanup(ctx, jobName)
+}
@@ -0,0 +1,69 @@
+package connkafka
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// needs the broker the e2e tests run against
+func TestTransactionalOffset(t *testing.T) {
+	opts := []kgo.Opt{kgo.SeedBrokers("localhost:9092"), kgo.AllowAutoTopicCreation()}
+	client, err := kgo.NewClient(opts...)
+	require.NoError(t, err)
+	c := &KafkaConnector{client: client, opts: opts, logger: internal.LoggerFromCtx(t.Context()), exactlyOnce: true}
+	t.Cleanup(func() { require.NoError(t, c.Close()) })
+	require.NoError(t, c.ensureOffsetsTopic(t.Context()))
+
+	flowName := "txn_" + strings.ToLower(shared.RandomString(8))
+	t.Cleanup(func() { discardTransactionalClient(flowName) })
+	batch := func(syncBatchID int64, id int64, commit bool) {
+		t.Helper()
+		txnClient, err := c.transactionalClient(flowName)
+		require.NoError(t, err)
+		require.NoError(t, txnClient.BeginTransaction())
+		if commit {
+			require.NoError(t, c.commitTransaction(t.Context(), txnClient, flowName, syncBatchID,
+				model.CdcCheckpoint{ID: id}))
+			return
+		}
+		require.NoError(t, txnClient.ProduceSync(t.Context(), &kgo.Record{
+			Topic: offsetsTopic, Key: []byte(flowName), Value: []byte(`{"id":-1}`),
+		}).FirstErr())
+		c.abortTransaction(t.Context(), txnClient)
+	}
+	read := func() *transactionalOffset {
+		t.Helper()
+		offset, err := c.readTransactionalOffset(t.Context(), flowName)
+		require.NoError(t, err)
+		return offset
+	}
+
+	require.Nil(t, read())
+
+	batch(1, 10, true)
+	first, err := c.transactionalClient(flowName)
+	require.NoError(t, err)
+	batch(2, 20, true)
+	second, err := c.transactionalClient(flowName)
+	require.NoError(t, err)
+	require.Same(t, first, second, "transactional client should be reused across batches")
+	require.Equal(t, &transactionalOffset{ID: 20, SyncBatchID: 2}, read())
+
+	// aborted checkpoints are never read
+	batch(3, 30, false)
+	require.Equal(t, &transactionalOffset{ID: 20, SyncBatchID: 2}, read())
+
+	// a tombstone outside of any transaction ends the topic without a commit marker
+	require.NoError(t, c.client.ProduceSync(t.Context(), &kgo.Record{Topic: offsetsTopic, Key: []byte(flowName)}).FirstErr())
+	require.Nil(t, read())
+
+	batch(4, 40, true)
+	require.Equal(t, &transactionalOffset{ID: 40, SyncBatchID: 4}, read())
+}
@@ -15,6 +15,7 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"go.mongodb.org/mongo-driver/v2/mongo/readconcern"
 	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+	"go.mongodb.org/mongo-driver/v2/mongo/writeconcern"
 	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/connstring"
 	"go.temporal.io/sdk/log"
 
@@ -409,7 +410,9 @@ func parseAsClientOptions(config *protos.MongoConfig) (*options.ClientOptions, e
 		// always use compression
 		SetCompressors([]string{"zstd", "snappy"}).
 		// always use majority read concern for correctness
-		SetReadConcern(readconcern.Majority())
+		SetReadConcern(readconcern.Majority()).
+		// synced batches are checkpointed, so writes must survive a failover
+		SetWriteConcern(writeconcern.Majority())
 
 	// TODO: once it's wired through the UI, indicate with a clear error if this field is empty
 	if config.ReadPreference == "" {
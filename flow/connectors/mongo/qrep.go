@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
@@ -56,7 +57,15 @@ func (c *MongoConnector) PullQRepRecords(
 	}
 	collection := c.client.Database(parseWatermarkTable.Schema).Collection(parseWatermarkTable.Table)
 
-	stream.SetSchema(GetDefaultSchema())
+	schema := GetDefaultSchema()
+	stream.SetSchema(schema)
+
+	var rowFilter *utils.RowFilter
+	if config.RowFilter != "" {
+		if rowFilter, err = utils.ParseRowFilter(config.RowFilter); err != nil {
+			return 0, 0, err
+		}
+	}
 
 	c.bytesRead.Store(0)
 	shutDown := shared.Interval(ctx, time.Minute, func() {
@@ -100,9 +109,12 @@ func (c *MongoConnector) PullQRepRecords(
 		if err != nil {
 			return 0, 0, fmt.Errorf("failed to convert record: %w", err)
 		}
+		c.bytesRead.Add(bytes)
+		if rowFilter != nil && rowFilter.MatchQRecord(schema, record) != utils.RowFilterMatch {
+			continue
+		}
 		stream.Records <- record
 		totalRecords += 1
-		c.bytesRead.Add(bytes)
 	}
 	close(stream.Records)
 	if err := cursor.Err(); err != nil {
@@ -122,6 +134,83 @@ func (c *MongoConnector) PullQRepRecords(
 	return totalRecords, c.bytesRead.Swap(0), nil
 }
 
+func (c *MongoConnector) SetupQRepMetadataTables(ctx context.Context, config *protos.QRepConfig) error {
+	return nil
+}
+
+func (c *MongoConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// without upsert key columns documents are inserted and mongo generates _id
+	var upsertKeyColumns []string
+	if config.WriteMode.WriteType == protos.QRepWriteType_QREP_WRITE_MODE_UPSERT {
+		upsertKeyColumns = config.WriteMode.UpsertKeyColumns
+	}
+	schemaColNames := schema.GetColumnNames()
+
+	writer := newBulkWriter(c.client)
+	var numRecords int64
+	for qRecord := range stream.Records {
+		items := model.NewRecordItems(len(qRecord))
+		for i, val := range qRecord {
+			items.AddColumn(schemaColNames[i], val)
+		}
+
+		if len(upsertKeyColumns) == 0 {
+			doc, err := documentFromItems(nil, schemaColNames, items)
+			if err != nil {
+				return 0, nil, fmt.Errorf("[mongo] failed to build document: %w", err)
+			}
+			writer.add(config.DestinationTableIdentifier, mongo.NewInsertOneModel().SetDocument(doc))
+		} else {
+			id, err := documentId(upsertKeyColumns, items.GetValueByColName)
+			if err != nil {
+				return 0, nil, fmt.Errorf("[mongo] failed to get document id: %w", err)
+			}
+			doc, err := documentFromItems(id, schemaColNames, items)
+			if err != nil {
+				return 0, nil, fmt.Errorf("[mongo] failed to build document: %w", err)
+			}
+			writer.add(config.DestinationTableIdentifier, mongo.NewReplaceOneModel().
+				SetFilter(bson.D{{Key: DefaultDocumentKeyColumnName, Value: id}}).
+				SetReplacement(doc).
+				SetUpsert(true))
+		}
+		numRecords += 1
+
+		if writer.pending >= bulkWriteBatchSize {
+			if err := writer.flush(ctx); err != nil {
+				c.logger.Error("[mongo] failed to write records", slog.Any("error", err))
+				return 0, nil, err
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		c.logger.Error("[mongo] failed to get record from stream", slog.Any("error", err))
+		return 0, nil, fmt.Errorf("[mongo] failed to get record from stream: %w", err)
+	}
+	if err := writer.flush(ctx); err != nil {
+		c.logger.Error("[mongo] failed to write records", slog.Any("error", err))
+		return 0, nil, err
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		c.logger.Error("[mongo] failed to log partition info", slog.Any("error", err))
+		return 0, nil, fmt.Errorf("[mongo] failed to log partition info: %w", err)
+	}
+	return numRecords, nil, nil
+}
+
 func GetDefaultSchema() types.QRecordSchema {
 	schema := make([]types.QField, 0, 2)
 	schema = append(schema,
@@ -3,6 +3,13 @@ package connmongo
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/v2/bson"
 
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
@@ -22,3 +29,72 @@ func qValueJSONFromDocument(document interface{}) (types.QValueJSON, error) {
 	}
 	return types.QValueJSON{Val: string(jsonb), IsArray: false}, nil
 }
+
+func bsonUUID(val uuid.UUID) bson.Binary {
+	return bson.Binary{Subtype: bson.TypeBinaryUUID, Data: val[:]}
+}
+
+func bsonDecimal(val decimal.Decimal) (bson.Decimal128, error) {
+	dec, err := bson.ParseDecimal128(val.String())
+	if err != nil {
+		return bson.Decimal128{}, fmt.Errorf("error converting %s to decimal128: %w", val.String(), err)
+	}
+	return dec, nil
+}
+
+func bsonFromJSON(val string) (any, error) {
+	// extended json can only be unmarshalled into a document, so wrap to also accept arrays and scalars
+	var wrapper bson.D
+	if err := bson.UnmarshalExtJSON([]byte(`{"v":`+val+`}`), false, &wrapper); err != nil {
+		return nil, fmt.Errorf("error converting json to bson: %w", err)
+	}
+	return wrapper[0].Value, nil
+}
+
+// bsonValueFromQValue converts a QValue into a value the bson encoder stores natively,
+// types without a bson equivalent are stored as strings
+func bsonValueFromQValue(qv types.QValue) (any, error) {
+	switch v := qv.(type) {
+	case types.QValueNull:
+		return nil, nil
+	case types.QValueUInt64:
+		if v.Val > math.MaxInt64 {
+			return bson.ParseDecimal128(strconv.FormatUint(v.Val, 10))
+		}
+		return int64(v.Val), nil
+	case types.QValueInt256:
+		return v.Val.String(), nil
+	case types.QValueUInt256:
+		return v.Val.String(), nil
+	case types.QValueQChar:
+		return string(rune(v.Val)), nil
+	case types.QValueTime:
+		return time.Time{}.Add(v.Val).Format("15:04:05.999999"), nil
+	case types.QValueTimeTZ:
+		return time.Time{}.Add(v.Val).Format("15:04:05.999999"), nil
+	case types.QValueNumeric:
+		return bsonDecimal(v.Val)
+	case types.QValueArrayNumeric:
+		arr := make(bson.A, 0, len(v.Val))
+		for _, val := range v.Val {
+			dec, err := bsonDecimal(val)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, dec)
+		}
+		return arr, nil
+	case types.QValueUUID:
+		return bsonUUID(v.Val), nil
+	case types.QValueArrayUUID:
+		arr := make(bson.A, 0, len(v.Val))
+		for _, val := range v.Val {
+			arr = append(arr, bsonUUID(val))
+		}
+		return arr, nil
+	case types.QValueJSON:
+		return bsonFromJSON(v.Val)
+	default:
+		return qv.Value(), nil
+	}
+}
@@ -0,0 +1,60 @@
+package connmongo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestBsonValueFromQValue(t *testing.T) {
+	id := uuid.New()
+	bigUint, err := bson.ParseDecimal128("18446744073709551615")
+	require.NoError(t, err)
+	numeric, err := bson.ParseDecimal128("123.45")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		in  types.QValue
+		out any
+	}{
+		{types.QValueNull(types.QValueKindString), nil},
+		{types.QValueInt32{Val: 7}, int32(7)},
+		{types.QValueUInt64{Val: 7}, int64(7)},
+		{types.QValueUInt64{Val: math.MaxUint64}, bigUint},
+		{types.QValueNumeric{Val: decimal.RequireFromString("123.45")}, numeric},
+		{types.QValueUUID{Val: id}, bson.Binary{Subtype: bson.TypeBinaryUUID, Data: id[:]}},
+		{types.QValueJSON{Val: `{"a":1}`}, bson.D{{Key: "a", Value: int32(1)}}},
+		{types.QValueJSON{Val: `[1,"b"]`, IsArray: true}, bson.A{int32(1), "b"}},
+	} {
+		val, err := bsonValueFromQValue(tc.in)
+		require.NoError(t, err)
+		require.Equal(t, tc.out, val)
+	}
+}
+
+func TestDocumentId(t *testing.T) {
+	items := map[string]types.QValue{
+		"a": types.QValueInt64{Val: 1},
+		"b": types.QValueString{Val: "x"},
+	}
+	getValue := func(col string) (types.QValue, error) {
+		return items[col], nil
+	}
+
+	id, err := documentId([]string{"a"}, getValue)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), id)
+
+	id, err = documentId([]string{"b", "a"}, getValue)
+	require.NoError(t, err)
+	require.Equal(t, bson.D{{Key: "b", Value: "x"}, {Key: "a", Value: int64(1)}}, id)
+
+	_, err = documentId(nil, getValue)
+	require.Error(t, err)
+}
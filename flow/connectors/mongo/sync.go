@@ -0,0 +1,285 @@
+package connmongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// number of pending writes across all collections before they are flushed
+const bulkWriteBatchSize = 1000
+
+// bulkWriter buffers write models per collection, flushing them as ordered bulk writes
+type bulkWriter struct {
+	client  *mongo.Client
+	models  map[string][]mongo.WriteModel
+	pending int
+}
+
+func newBulkWriter(client *mongo.Client) *bulkWriter {
+	return &bulkWriter{
+		client: client,
+		models: make(map[string][]mongo.WriteModel),
+	}
+}
+
+func (w *bulkWriter) add(table string, model mongo.WriteModel) {
+	w.models[table] = append(w.models[table], model)
+	w.pending += 1
+}
+
+func (w *bulkWriter) flush(ctx context.Context) error {
+	for table, models := range w.models {
+		if len(models) == 0 {
+			continue
+		}
+		parsedTable, err := utils.ParseSchemaTable(table)
+		if err != nil {
+			return fmt.Errorf("invalid destination collection %s: %w", table, err)
+		}
+		collection := w.client.Database(parsedTable.Schema).Collection(parsedTable.Table)
+		if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(true)); err != nil {
+			return fmt.Errorf("failed to bulk write to %s: %w", table, err)
+		}
+		w.models[table] = models[:0]
+	}
+	w.pending = 0
+	return nil
+}
+
+// documentId builds the _id of the destination document, the value of the key column
+// when there is a single one, otherwise a sub-document of all key columns
+func documentId(keyColumns []string, getValue func(string) (types.QValue, error)) (any, error) {
+	if len(keyColumns) == 0 {
+		return nil, errors.New("primary key is required to sync to mongo")
+	}
+	if len(keyColumns) == 1 {
+		qv, err := getValue(keyColumns[0])
+		if err != nil {
+			return nil, err
+		}
+		return bsonValueFromQValue(qv)
+	}
+	id := make(bson.D, 0, len(keyColumns))
+	for _, col := range keyColumns {
+		qv, err := getValue(col)
+		if err != nil {
+			return nil, err
+		}
+		val, err := bsonValueFromQValue(qv)
+		if err != nil {
+			return nil, err
+		}
+		id = append(id, bson.E{Key: col, Value: val})
+	}
+	return id, nil
+}
+
+// documentFromItems builds a document from record items in column order, skipping columns
+// not present in items such as unchanged toast columns. A nil id leaves _id to the server.
+func documentFromItems(id any, columns []string, items model.RecordItems) (bson.D, error) {
+	doc := make(bson.D, 0, len(columns)+1)
+	if id != nil {
+		doc = append(doc, bson.E{Key: DefaultDocumentKeyColumnName, Value: id})
+	}
+	for _, col := range columns {
+		if id != nil && col == DefaultDocumentKeyColumnName {
+			continue
+		}
+		qv, ok := items.ColToVal[col]
+		if !ok {
+			continue
+		}
+		val, err := bsonValueFromQValue(qv)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert column %s: %w", col, err)
+		}
+		doc = append(doc, bson.E{Key: col, Value: val})
+	}
+	return doc, nil
+}
+
+// Mongo is schemaless, no raw table staging needed
+func (c *MongoConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+// Mongo is schemaless, new columns show up in documents written after the change
+func (c *MongoConnector) ReplayTableSchemaDeltas(ctx context.Context, env map[string]string,
+	flowJobName string, _ []*protos.TableMapping, schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	return nil
+}
+
+func (c *MongoConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	var lastSeenLSN atomic.Int64
+	var numRecords int64
+
+	flushLoopDone := make(chan struct{})
+	defer close(flushLoopDone)
+	go func() {
+		flushTimeout, err := internal.PeerDBQueueFlushTimeoutSeconds(ctx, req.Env)
+		if err != nil {
+			c.logger.Warn("[mongo] failed to get flush timeout, no periodic flushing", slog.Any("error", err))
+			return
+		}
+		ticker := time.NewTicker(flushTimeout)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-flushLoopDone:
+				return
+			case <-ticker.C:
+				lastSeen := lastSeenLSN.Load()
+				if lastSeen > req.ConsumedOffset.Load() {
+					if err := c.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{ID: lastSeen}); err != nil {
+						c.logger.Warn("[mongo] SetLastOffset error", slog.Any("error", err))
+					} else {
+						shared.AtomicInt64Max(req.ConsumedOffset, lastSeen)
+						c.logger.Info("processBatch", slog.Int64("updated last offset", lastSeen))
+					}
+				}
+			}
+		}
+	}()
+
+	writer := newBulkWriter(c.client)
+	tableColumns := make(map[string][]string, len(req.TableNameSchemaMapping))
+	for tableName, schema := range req.TableNameSchemaMapping {
+		columns := make([]string, 0, len(schema.Columns))
+		for _, col := range schema.Columns {
+			columns = append(columns, col.Name)
+		}
+		tableColumns[tableName] = columns
+	}
+	var pendingCheckpoint int64
+	flush := func() error {
+		if err := writer.flush(ctx); err != nil {
+			return err
+		}
+		// records are only acknowledged once every collection has been written up to them
+		shared.AtomicInt64Max(&lastSeenLSN, pendingCheckpoint)
+		return nil
+	}
+
+	for record := range req.Records.GetRecords() {
+		if _, ok := record.(*model.MessageRecord[model.RecordItems]); ok {
+			continue
+		}
+
+		destinationTable := record.GetDestinationTableName()
+		schema, ok := req.TableNameSchemaMapping[destinationTable]
+		if !ok {
+			return nil, fmt.Errorf("[mongo] schema not found for %s", destinationTable)
+		}
+
+		switch r := record.(type) {
+		case *model.InsertRecord[model.RecordItems]:
+			id, err := documentId(schema.PrimaryKeyColumns, r.Items.GetValueByColName)
+			if err != nil {
+				return nil, fmt.Errorf("[mongo] failed to get document id for %s: %w", destinationTable, err)
+			}
+			doc, err := documentFromItems(id, tableColumns[destinationTable], r.Items)
+			if err != nil {
+				return nil, fmt.Errorf("[mongo] failed to build document for %s: %w", destinationTable, err)
+			}
+			writer.add(destinationTable, mongo.NewReplaceOneModel().
+				SetFilter(bson.D{{Key: DefaultDocumentKeyColumnName, Value: id}}).
+				SetReplacement(doc).
+				SetUpsert(true))
+		case *model.UpdateRecord[model.RecordItems]:
+			id, err := documentId(schema.PrimaryKeyColumns, r.NewItems.GetValueByColName)
+			if err != nil {
+				return nil, fmt.Errorf("[mongo] failed to get document id for %s: %w", destinationTable, err)
+			}
+			// primary key changed, remove the document stored under the old key
+			if r.OldItems.Len() > 0 {
+				oldId, err := documentId(schema.PrimaryKeyColumns, r.OldItems.GetValueByColName)
+				if err != nil {
+					return nil, fmt.Errorf("[mongo] failed to get old document id for %s: %w", destinationTable, err)
+				}
+				if oldBytes, newBytes := bsonKeyBytes(oldId), bsonKeyBytes(id); oldBytes != newBytes {
+					writer.add(destinationTable, mongo.NewDeleteOneModel().
+						SetFilter(bson.D{{Key: DefaultDocumentKeyColumnName, Value: oldId}}))
+				}
+			}
+			doc, err := documentFromItems(id, tableColumns[destinationTable], r.NewItems)
+			if err != nil {
+				return nil, fmt.Errorf("[mongo] failed to build document for %s: %w", destinationTable, err)
+			}
+			filter := bson.D{{Key: DefaultDocumentKeyColumnName, Value: id}}
+			if len(r.UnchangedToastColumns) == 0 {
+				writer.add(destinationTable, mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(doc).SetUpsert(true))
+			} else {
+				// unchanged toast columns are not part of the record, only set the columns we have
+				writer.add(destinationTable, mongo.NewUpdateOneModel().
+					SetFilter(filter).
+					SetUpdate(bson.D{{Key: "$set", Value: doc}}).
+					SetUpsert(true))
+			}
+		case *model.DeleteRecord[model.RecordItems]:
+			id, err := documentId(schema.PrimaryKeyColumns, r.Items.GetValueByColName)
+			if err != nil {
+				return nil, fmt.Errorf("[mongo] failed to get document id for %s: %w", destinationTable, err)
+			}
+			writer.add(destinationTable, mongo.NewDeleteOneModel().
+				SetFilter(bson.D{{Key: DefaultDocumentKeyColumnName, Value: id}}))
+		default:
+			continue
+		}
+
+		record.PopulateCountMap(tableNameRowsMapping)
+		numRecords += 1
+		pendingCheckpoint = max(pendingCheckpoint, record.GetCheckpointID())
+		if writer.pending >= bulkWriteBatchSize {
+			if err := flush(); err != nil {
+				c.logger.Error("[mongo] failed to write records", slog.Any("error", err))
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		c.logger.Error("[mongo] failed to write records", slog.Any("error", err))
+		return nil, err
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, err
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:   req.SyncBatchID,
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
+
+// bsonKeyBytes is used to compare document ids, ids that cannot be marshalled compare as equal
+func bsonKeyBytes(id any) string {
+	_, raw, err := bson.MarshalValue(id)
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
@@ -318,11 +318,24 @@ func (c *MySqlConnector) PullRecords(
 		return err
 	}
 
+	if c.incrementalSnapshots == nil {
+		c.incrementalSnapshots = utils.NewIncrementalSnapshots(catalogPool, req.FlowJobName)
+	}
+	if err := c.incrementalSnapshots.Refresh(ctx); err != nil {
+		return err
+	}
+	snapshotChunkSize, err := internal.PeerDBIncrementalSnapshotChunkSize(ctx, req.Env)
+	if err != nil {
+		return fmt.Errorf("failed to get setting for incremental snapshot chunk size: %w", err)
+	}
+
 	syncer, mystream, gset, pos, err := c.startStreaming(ctx, req.LastOffset.Text)
 	if err != nil {
 		return err
 	}
 	defer syncer.Close()
+	// position of the last event streamed, also tracked with gtid to compare against snapshot watermarks
+	binlogPos := pos
 
 	var skewLossReported bool
 	var updatedOffset string
@@ -344,6 +357,9 @@ func (c *MySqlConnector) PullRecords(
 	}()
 
 	addRecord := func(ctx context.Context, record model.Record[model.RecordItems]) error {
+		if err := utils.DropChunkRows(c.incrementalSnapshots, record, req.TableNameSchemaMapping); err != nil {
+			return err
+		}
 		recordCount += 1
 		if err := req.RecordStream.AddRecord(ctx, record); err != nil {
 			return err
@@ -358,6 +374,15 @@ func (c *MySqlConnector) PullRecords(
 
 	var mysqlParser *parser.Parser
 	for inTx || (!overtime && recordCount < req.MaxBatchSize) {
+		if err := c.startSnapshotChunk(ctx, req, addRecord, int(snapshotChunkSize), sourceSchemaAsDestinationColumn); err != nil {
+			return err
+		}
+		for _, record := range c.flushSnapshotChunk(binlogPos) {
+			if err := addRecord(ctx, record); err != nil {
+				return err
+			}
+		}
+
 		var event *replication.BinlogEvent
 		// don't gamble on closed timeoutCtx.Done() being prioritized over event backlog channel
 		err := timeoutCtx.Err()
@@ -403,6 +428,16 @@ func (c *MySqlConnector) PullRecords(
 			return err
 		}
 
+		if rotate, ok := event.Event.(*replication.RotateEvent); ok {
+			binlogPos = mysql.Position{Name: string(rotate.NextLogName), Pos: uint32(rotate.Position)}
+		} else if event.Header.LogPos > 0 {
+			binlogPos.Pos = event.Header.LogPos
+		}
+		if watermarks := c.snapshotWatermarks; watermarks != nil && binlogPos.Name != "" &&
+			binlogPos.Compare(watermarks.low) > 0 {
+			c.incrementalSnapshots.OpenWindow(watermarks.id)
+		}
+
 		switch ev := event.Event.(type) {
 		case *replication.GTIDEvent:
 			if ev.ImmediateCommitTimestamp > 0 {
@@ -446,10 +481,18 @@ func (c *MySqlConnector) PullRecords(
 				c.logger.Warn("processing QueryEvent with logged warnings", slog.Any("warns", warns))
 			}
 			for _, stmt := range stmts {
-				if alterTableStmt, ok := stmt.(*ast.AlterTableStmt); ok {
-					if err := c.processAlterTableQuery(ctx, catalogPool, req, alterTableStmt, string(ev.Schema)); err != nil {
+				switch typedStmt := stmt.(type) {
+				case *ast.AlterTableStmt:
+					if err := c.processAlterTableQuery(ctx, catalogPool, req, typedStmt, string(ev.Schema)); err != nil {
 						return fmt.Errorf("failed to process ALTER TABLE query: %w", err)
 					}
+				case *ast.TruncateTableStmt:
+					if truncateRecord := c.processTruncateTableQuery(req, typedStmt, string(ev.Schema)); truncateRecord != nil {
+						truncateRecord.CommitTimeNano = int64(event.Header.Timestamp) * 1e9
+						if err := addRecord(ctx, truncateRecord); err != nil {
+							return err
+						}
+					}
 				}
 			}
 		case *replication.RowsEvent:
@@ -618,6 +661,27 @@ func (c *MySqlConnector) PullRecords(
 	return nil
 }
 
+func (c *MySqlConnector) processTruncateTableQuery(
+	req *model.PullRecordsRequest[model.RecordItems], stmt *ast.TruncateTableStmt, stmtSchema string,
+) *model.TruncateRecord[model.RecordItems] {
+	// if TRUNCATE TABLE doesn't have database/schema name, use one attached to event
+	sourceSchemaName := stmt.Table.Schema.String()
+	if sourceSchemaName == "" {
+		sourceSchemaName = stmtSchema
+	}
+	sourceTableName := sourceSchemaName + "." + stmt.Table.Name.String()
+
+	destinationTableName := req.TableNameMapping[sourceTableName].Name
+	if destinationTableName == "" {
+		return nil
+	}
+	c.logger.Info("[mysql] truncate of replicated table", slog.String("table", sourceTableName))
+	return &model.TruncateRecord[model.RecordItems]{
+		SourceTableName:      sourceTableName,
+		DestinationTableName: destinationTableName,
+	}
+}
+
 func (c *MySqlConnector) processAlterTableQuery(ctx context.Context, catalogPool shared.CatalogPool,
 	req *model.PullRecordsRequest[model.RecordItems], stmt *ast.AlterTableStmt, stmtSchema string,
 ) error {
@@ -636,74 +700,128 @@ func (c *MySqlConnector) processAlterTableQuery(ctx context.Context, catalogPool
 		return nil
 	}
 	currentSchema := req.TableNameSchemaMapping[destinationTableName]
+	if currentSchema == nil {
+		c.logger.Warn("table schema not found, ignoring ALTER TABLE", slog.String("table", destinationTableName))
+		return nil
+	}
 
 	tableSchemaDelta := &protos.TableSchemaDelta{
 		SrcTableName:    sourceTableName,
 		DstTableName:    destinationTableName,
 		AddedColumns:    nil,
 		System:          protos.TypeSystem_Q,
-		NullableEnabled: currentSchema != nil && currentSchema.NullableEnabled,
+		NullableEnabled: currentSchema.NullableEnabled,
 	}
 
 	for _, spec := range stmt.Specs {
-		if spec.NewColumns != nil {
-			// these are added columns
+		switch spec.Tp {
+		case ast.AlterTableAddColumns:
 			for _, col := range spec.NewColumns {
-				if col.Tp == nil {
-					// ignore, can be plain ALTER TABLE ... ALTER COLUMN ... DEFAULT ...
-					c.logger.Warn("ALTER TABLE with no column type detected, ignoring",
-						slog.String("columnName", col.Name.String()),
-						slog.String("tableName", sourceTableName))
-					continue
-				}
-				qkind, err := qmysql.QkindFromMysqlColumnType(col.Tp.InfoSchemaStr())
+				fd, err := c.columnDefToFieldDescription(col, sourceTableName)
 				if err != nil {
 					return err
-				}
-
-				nullable := true
-				for _, option := range col.Options {
-					if option.Tp == ast.ColumnOptionNotNull {
-						nullable = false
-					}
-				}
-
-				precision := col.Tp.GetFlen()
-				scale := col.Tp.GetDecimal()
-				typmod := int32(-1)
-				if scale >= 0 || precision >= 0 {
-					typmod = datatypes.MakeNumericTypmod(int32(precision), int32(scale))
-				}
-
-				fd := &protos.FieldDescription{
-					Name:         col.Name.OrigColName(),
-					Type:         string(qkind),
-					TypeModifier: typmod,
-					Nullable:     nullable,
+				} else if fd == nil {
+					continue
 				}
 				tableSchemaDelta.AddedColumns = append(tableSchemaDelta.AddedColumns, fd)
 				// current assumption is the columns will be ordered like this
 				currentSchema.Columns = append(currentSchema.Columns, fd)
 			}
-		} else if spec.OldColumnName != nil {
-			// this could be dropped or renamed column
-			if spec.NewColumnName != nil {
+		case ast.AlterTableModifyColumn, ast.AlterTableChangeColumn:
+			col := spec.NewColumns[0]
+			if spec.OldColumnName != nil && !strings.EqualFold(spec.OldColumnName.Name.O, col.Name.Name.O) {
 				c.logger.Warn("renamed column detected but not propagating",
-					slog.String("columnOldName", spec.OldColumnName.String()), slog.String("columnNewName", spec.NewColumnName.String()))
-			} else {
-				c.logger.Warn("dropped column detected but not propagating", slog.String("columnName", spec.OldColumnName.String()))
+					slog.String("columnOldName", spec.OldColumnName.String()), slog.String("columnNewName", col.Name.String()))
+				continue
+			}
+			fd, err := c.columnDefToFieldDescription(col, sourceTableName)
+			if err != nil {
+				return err
+			} else if fd == nil {
+				continue
 			}
+			idx := slices.IndexFunc(currentSchema.Columns, func(column *protos.FieldDescription) bool {
+				return strings.EqualFold(column.Name, fd.Name)
+			})
+			if idx == -1 {
+				continue
+			}
+			if currentSchema.Columns[idx].Type != fd.Type {
+				tableSchemaDelta.AlteredColumns = append(tableSchemaDelta.AlteredColumns, &protos.AlteredColumn{
+					Previous: proto.CloneOf(currentSchema.Columns[idx]),
+					Current:  fd,
+				})
+			}
+			currentSchema.Columns[idx] = fd
+		case ast.AlterTableDropColumn:
+			idx := slices.IndexFunc(currentSchema.Columns, func(column *protos.FieldDescription) bool {
+				return strings.EqualFold(column.Name, spec.OldColumnName.Name.O)
+			})
+			if idx == -1 {
+				continue
+			}
+			tableSchemaDelta.DroppedColumns = append(tableSchemaDelta.DroppedColumns, currentSchema.Columns[idx])
+			// row events are decoded positionally, so the dropped column must go from the schema too
+			currentSchema.Columns = slices.Delete(currentSchema.Columns, idx, idx+1)
+		case ast.AlterTableRenameColumn:
+			c.logger.Warn("renamed column detected but not propagating",
+				slog.String("columnOldName", spec.OldColumnName.String()), slog.String("columnNewName", spec.NewColumnName.String()))
 		}
 	}
-	if tableSchemaDelta.AddedColumns != nil {
-		c.logger.Info("Column added detected",
-			slog.String("table", destinationTableName), slog.Any("columns", tableSchemaDelta.AddedColumns))
+	if tableSchemaDelta.AddedColumns != nil || tableSchemaDelta.DroppedColumns != nil || tableSchemaDelta.AlteredColumns != nil {
+		c.logger.Info("Column change detected",
+			slog.String("table", destinationTableName),
+			slog.Any("addedColumns", tableSchemaDelta.AddedColumns),
+			slog.Any("droppedColumns", tableSchemaDelta.DroppedColumns),
+			slog.Any("alteredColumns", tableSchemaDelta.AlteredColumns))
 		req.RecordStream.AddSchemaDelta(req.TableNameMapping, tableSchemaDelta)
+		// destinations encoding rows with a schema of their own, like kafka, apply it ahead of the rows that follow
+		if err := req.RecordStream.AddRecord(ctx, &model.RelationRecord[model.RecordItems]{
+			TableSchemaDelta: tableSchemaDelta,
+		}); err != nil {
+			return err
+		}
 		return monitoring.AuditSchemaDelta(ctx, catalogPool.Pool, req.FlowJobName, tableSchemaDelta)
 	}
 	return nil
 }
 
+// columnDefToFieldDescription returns nil for column definitions without a type
+func (c *MySqlConnector) columnDefToFieldDescription(col *ast.ColumnDef, sourceTableName string) (*protos.FieldDescription, error) {
+	if col.Tp == nil {
+		// ignore, can be plain ALTER TABLE ... ALTER COLUMN ... DEFAULT ...
+		c.logger.Warn("ALTER TABLE with no column type detected, ignoring",
+			slog.String("columnName", col.Name.String()),
+			slog.String("tableName", sourceTableName))
+		return nil, nil
+	}
+	qkind, err := qmysql.QkindFromMysqlColumnType(col.Tp.InfoSchemaStr())
+	if err != nil {
+		return nil, err
+	}
+
+	nullable := true
+	for _, option := range col.Options {
+		if option.Tp == ast.ColumnOptionNotNull {
+			nullable = false
+		}
+	}
+
+	precision := col.Tp.GetFlen()
+	scale := col.Tp.GetDecimal()
+	typmod := int32(-1)
+	if scale >= 0 || precision >= 0 {
+		typmod = datatypes.MakeNumericTypmod(int32(precision), int32(scale))
+	}
+
+	return &protos.FieldDescription{
+		Name:         col.Name.OrigColName(),
+		Type:         string(qkind),
+		TypeModifier: typmod,
+		Nullable:     nullable,
+	}, nil
+}
+
 func posToOffsetText(pos mysql.Position) string {
 	return fmt.Sprintf("!f:%s,%x", pos.Name, pos.Pos)
 }
@@ -0,0 +1,293 @@
+package connmysql
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// snapshotWatermarks are the binlog positions read before and after the chunk in flight,
+// nothing is written to the source: changes streamed between them may be newer than what the chunk read
+type snapshotWatermarks struct {
+	id   string
+	low  mysql.Position
+	high mysql.Position
+}
+
+// ValidateIncrementalSnapshot runs the chunk query of snapshot without reading rows,
+// watermarks are binlog positions read from the source so nothing else needs checking
+func (c *MySqlConnector) ValidateIncrementalSnapshot(
+	ctx context.Context,
+	snapshot *utils.IncrementalSnapshot,
+	schema *protos.TableSchema,
+) error {
+	srcTable, err := utils.ParseSchemaTable(snapshot.SourceTable)
+	if err != nil {
+		return err
+	}
+	columns, pkeyIdx, err := snapshotColumns(snapshot, schema, nil)
+	if err != nil {
+		return err
+	}
+	query, args, err := snapshotChunkQuery(srcTable, snapshot, columns, pkeyIdx, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := c.Execute(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to query %s for incremental snapshot: %w", snapshot.SourceTable, err)
+	}
+	return nil
+}
+
+// startSnapshotChunk reads the next chunk of a pending incremental snapshot between two binlog positions
+func (c *MySqlConnector) startSnapshotChunk(
+	ctx context.Context,
+	req *model.PullRecordsRequest[model.RecordItems],
+	addRecord func(context.Context, model.Record[model.RecordItems]) error,
+	chunkSize int,
+	sourceSchemaAsDestinationColumn bool,
+) error {
+	snapshot := c.incrementalSnapshots.Next(req.TableNameSchemaMapping)
+	if snapshot == nil {
+		return nil
+	}
+
+	if truncate := utils.TruncateBeforeChunk[model.RecordItems](snapshot); truncate != nil {
+		if err := addRecord(ctx, truncate); err != nil {
+			return err
+		}
+	}
+
+	id := c.incrementalSnapshots.NextChunkID(snapshot)
+	low, err := c.GetMasterPos(ctx)
+	if err != nil {
+		return err
+	}
+	rows, lastPK, err := c.readSnapshotChunk(ctx, req, snapshot, chunkSize, sourceSchemaAsDestinationColumn)
+	if err != nil {
+		return err
+	}
+	needsHighWatermark, err := utils.AddChunk(c.incrementalSnapshots, snapshot, id, rows, lastPK,
+		req.TableNameSchemaMapping, chunkSize)
+	if err != nil {
+		return err
+	}
+	c.logger.Info("[mysql] read incremental snapshot chunk",
+		slog.String("table", snapshot.SourceTable), slog.String("chunk", id), slog.Int("rows", len(rows)))
+	if needsHighWatermark {
+		high, err := c.GetMasterPos(ctx)
+		if err != nil {
+			return err
+		}
+		c.snapshotWatermarks = &snapshotWatermarks{id: id, low: low, high: high}
+	}
+	return nil
+}
+
+// flushSnapshotChunk returns rows left in the chunk in flight once the stream reached its high watermark
+func (c *MySqlConnector) flushSnapshotChunk(pos mysql.Position) []model.Record[model.RecordItems] {
+	watermarks := c.snapshotWatermarks
+	if watermarks == nil || pos.Name == "" || pos.Compare(watermarks.high) < 0 {
+		return nil
+	}
+	c.snapshotWatermarks = nil
+	return utils.FlushChunk[model.RecordItems](c.incrementalSnapshots, watermarks.id,
+		model.BaseRecord{CommitTimeNano: time.Now().UnixNano()})
+}
+
+// readSnapshotChunk reads rows following the last primary key of snapshot
+func (c *MySqlConnector) readSnapshotChunk(
+	ctx context.Context,
+	req *model.PullRecordsRequest[model.RecordItems],
+	snapshot *utils.IncrementalSnapshot,
+	chunkSize int,
+	sourceSchemaAsDestinationColumn bool,
+) ([]model.RecordItems, []string, error) {
+	srcTable, err := utils.ParseSchemaTable(snapshot.SourceTable)
+	if err != nil {
+		return nil, nil, err
+	}
+	schema := req.TableNameSchemaMapping[snapshot.DestinationTable]
+	exclude := req.TableNameMapping[snapshot.SourceTable].Exclude
+
+	columns, pkeyIdx, err := snapshotColumns(snapshot, schema, exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+	query, args, err := snapshotChunkQuery(srcTable, snapshot, columns, pkeyIdx, chunkSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rs, err := c.Execute(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read incremental snapshot chunk of %s: %w", snapshot.SourceTable, err)
+	}
+
+	rows := make([]model.RecordItems, 0, len(rs.Values))
+	for _, row := range rs.Values {
+		items := model.NewRecordItems(len(row) + 1)
+		for idx, val := range row {
+			qv, err := QValueFromMysqlFieldValue(types.QValueKind(columns[idx].Type), rs.Fields[idx].Type, val)
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not convert mysql value for %s: %w", columns[idx].Name, err)
+			}
+			items.AddColumn(columns[idx].Name, qv)
+		}
+		if sourceSchemaAsDestinationColumn {
+			items.AddColumn("_peerdb_source_schema", types.QValueString{Val: srcTable.Schema})
+		}
+		rows = append(rows, items)
+	}
+
+	var lastPK []string
+	if len(rs.Values) > 0 {
+		lastRow := rs.Values[len(rs.Values)-1]
+		lastPK = make([]string, 0, len(pkeyIdx))
+		for _, idx := range pkeyIdx {
+			lastPK = append(lastPK, snapshotPKText(columns[idx], lastRow[idx]))
+		}
+	}
+	return rows, lastPK, nil
+}
+
+// snapshotColumns returns the columns read by snapshot and the indexes of its primary key among them
+func snapshotColumns(
+	snapshot *utils.IncrementalSnapshot,
+	schema *protos.TableSchema,
+	exclude map[string]struct{},
+) ([]*protos.FieldDescription, []int, error) {
+	columns := make([]*protos.FieldDescription, 0, len(schema.Columns))
+	for _, col := range schema.Columns {
+		if _, excluded := exclude[col.Name]; !excluded && col.Name != "_peerdb_source_schema" {
+			columns = append(columns, col)
+		}
+	}
+	pkeyIdx := make([]int, 0, len(schema.PrimaryKeyColumns))
+	for _, pkeyCol := range schema.PrimaryKeyColumns {
+		idx := slices.IndexFunc(columns, func(col *protos.FieldDescription) bool { return col.Name == pkeyCol })
+		if idx == -1 {
+			return nil, nil, fmt.Errorf("primary key column %s of %s is excluded", pkeyCol, snapshot.SourceTable)
+		}
+		pkeyIdx = append(pkeyIdx, idx)
+	}
+	return columns, pkeyIdx, nil
+}
+
+// snapshotChunkQuery selects the next chunk of snapshot, bounds are bound with the types of the primary key columns
+func snapshotChunkQuery(
+	srcTable *utils.SchemaTable,
+	snapshot *utils.IncrementalSnapshot,
+	columns []*protos.FieldDescription,
+	pkeyIdx []int,
+	chunkSize int,
+) (string, []any, error) {
+	quotedColumns := make([]string, 0, len(columns))
+	for _, col := range columns {
+		quotedColumns = append(quotedColumns, quoteIdentifier(col.Name))
+	}
+	pkeyCols := make([]string, 0, len(pkeyIdx))
+	for _, idx := range pkeyIdx {
+		pkeyCols = append(pkeyCols, quotedColumns[idx])
+	}
+	pkey := strings.Join(pkeyCols, ",")
+
+	var conditions []string
+	var args []any
+	bound := func(op string, values []string) error {
+		if len(values) != len(pkeyIdx) {
+			return fmt.Errorf("expected %d primary key values for incremental snapshot of %s, got %d",
+				len(pkeyIdx), snapshot.SourceTable, len(values))
+		}
+		placeholders := make([]string, 0, len(values))
+		for i, value := range values {
+			placeholder, arg, err := snapshotPKArg(columns[pkeyIdx[i]], value)
+			if err != nil {
+				return err
+			}
+			placeholders = append(placeholders, placeholder)
+			args = append(args, arg)
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s)%s(%s)", pkey, op, strings.Join(placeholders, ",")))
+		return nil
+	}
+	if snapshot.LastPK != nil {
+		if err := bound(">", snapshot.LastPK); err != nil {
+			return "", nil, err
+		}
+	} else if snapshot.StartPK != nil {
+		if err := bound(">=", snapshot.StartPK); err != nil {
+			return "", nil, err
+		}
+	}
+	if snapshot.EndPK != nil {
+		if err := bound("<=", snapshot.EndPK); err != nil {
+			return "", nil, err
+		}
+	}
+	if snapshot.Filter != "" {
+		filter, err := utils.RowFilterSQL(snapshot.Filter, protos.DBType_MYSQL)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid filter of incremental snapshot of %s: %w", snapshot.SourceTable, err)
+		}
+		conditions = append(conditions, filter)
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(quotedColumns, ","), quoteTable(srcTable))
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", pkey, chunkSize)
+	return query, args, nil
+}
+
+// snapshotPKText keeps a primary key value as text, binary keys are hex encoded as the catalog stores text
+func snapshotPKText(col *protos.FieldDescription, fv mysql.FieldValue) string {
+	if fv.Type == mysql.FieldValueTypeString {
+		if types.QValueKind(col.Type) == types.QValueKindBytes {
+			return hex.EncodeToString(fv.AsString())
+		}
+		return string(fv.AsString())
+	}
+	return fv.String()
+}
+
+// snapshotPKArg binds a primary key value kept as text with the type of its column,
+// MySQL compares strings with numbers as doubles, which loses precision of large keys
+func snapshotPKArg(col *protos.FieldDescription, value string) (string, any, error) {
+	var arg any
+	var err error
+	switch types.QValueKind(col.Type) {
+	case types.QValueKindInt8, types.QValueKindInt16, types.QValueKindInt32, types.QValueKindInt64:
+		arg, err = strconv.ParseInt(value, 10, 64)
+	case types.QValueKindUInt8, types.QValueKindUInt16, types.QValueKindUInt32, types.QValueKindUInt64:
+		arg, err = strconv.ParseUint(value, 10, 64)
+	case types.QValueKindFloat32, types.QValueKindFloat64:
+		arg, err = strconv.ParseFloat(value, 64)
+	case types.QValueKindBytes:
+		arg, err = hex.DecodeString(value)
+	case types.QValueKindNumeric:
+		if precision, scale := datatypes.ParseNumericTypmod(col.TypeModifier); precision > 0 {
+			return fmt.Sprintf("CAST(? AS DECIMAL(%d,%d))", precision, scale), value, nil
+		}
+		arg = value
+	default:
+		arg = value
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid primary key value %q of %s: %w", value, col.Name, err)
+	}
+	return "?", arg, nil
+}
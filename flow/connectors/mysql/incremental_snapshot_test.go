@@ -0,0 +1,90 @@
+package connmysql
+
+import (
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestSnapshotPKArg(t *testing.T) {
+	//nolint:govet
+	for _, tc := range []struct {
+		col         *protos.FieldDescription
+		fv          mysql.FieldValue
+		text        string
+		placeholder string
+		arg         any
+	}{
+		{
+			&protos.FieldDescription{Name: "id", Type: string(types.QValueKindUInt64)},
+			mysql.NewFieldValue(mysql.FieldValueTypeUnsigned, 18446744073709551615, nil),
+			"18446744073709551615", "?", uint64(18446744073709551615),
+		},
+		{
+			&protos.FieldDescription{Name: "id", Type: string(types.QValueKindInt64)},
+			mysql.NewFieldValue(mysql.FieldValueTypeString, 0, []byte("-9223372036854775808")),
+			"-9223372036854775808", "?", int64(-9223372036854775808),
+		},
+		{
+			&protos.FieldDescription{Name: "k", Type: string(types.QValueKindString)},
+			mysql.NewFieldValue(mysql.FieldValueTypeString, 0, []byte("it's")),
+			"it's", "?", "it's",
+		},
+		{
+			&protos.FieldDescription{Name: "b", Type: string(types.QValueKindBytes)},
+			mysql.NewFieldValue(mysql.FieldValueTypeString, 0, []byte{0xff, 0x00, '\''}),
+			"ff0027", "?", []byte{0xff, 0x00, '\''},
+		},
+		{
+			&protos.FieldDescription{Name: "d", Type: string(types.QValueKindNumeric), TypeModifier: datatypes.MakeNumericTypmod(30, 2)},
+			mysql.NewFieldValue(mysql.FieldValueTypeString, 0, []byte("1234567890123456789012.34")),
+			"1234567890123456789012.34", "CAST(? AS DECIMAL(30,2))", "1234567890123456789012.34",
+		},
+	} {
+		text := snapshotPKText(tc.col, tc.fv)
+		require.Equal(t, tc.text, text)
+		placeholder, arg, err := snapshotPKArg(tc.col, text)
+		require.NoError(t, err)
+		require.Equal(t, tc.placeholder, placeholder)
+		require.Equal(t, tc.arg, arg)
+	}
+
+	_, _, err := snapshotPKArg(&protos.FieldDescription{Name: "id", Type: string(types.QValueKindInt32)}, "'1'")
+	require.Error(t, err)
+}
+
+func TestSnapshotChunkQuery(t *testing.T) {
+	columns := []*protos.FieldDescription{
+		{Name: "val", Type: string(types.QValueKindString)},
+		{Name: "id", Type: string(types.QValueKindInt64)},
+	}
+	srcTable := &utils.SchemaTable{Schema: "db", Table: "t"}
+	snapshot := &utils.IncrementalSnapshot{
+		SourceTable: "db.t",
+		Filter:      "val <> 'x'",
+		StartPK:     []string{"2"},
+		EndPK:       []string{"3"},
+	}
+
+	query, args, err := snapshotChunkQuery(srcTable, snapshot, columns, []int{1}, 10)
+	require.NoError(t, err)
+	require.Equal(t, "SELECT `val`,`id` FROM `db`.`t` WHERE (`id`)>=(?) AND (`id`)<=(?) AND (`val` <> 'x') ORDER BY `id` LIMIT 10", query)
+	require.Equal(t, []any{int64(2), int64(3)}, args)
+
+	// the last primary key read replaces the start bound
+	snapshot.LastPK = []string{"2"}
+	query, args, err = snapshotChunkQuery(srcTable, snapshot, columns, []int{1}, 10)
+	require.NoError(t, err)
+	require.Equal(t, "SELECT `val`,`id` FROM `db`.`t` WHERE (`id`)>(?) AND (`id`)<=(?) AND (`val` <> 'x') ORDER BY `id` LIMIT 10", query)
+	require.Equal(t, []any{int64(2), int64(3)}, args)
+
+	snapshot.EndPK = []string{"3", "4"}
+	_, _, err = snapshotChunkQuery(srcTable, snapshot, columns, []int{1}, 10)
+	require.Error(t, err)
+}
@@ -33,6 +33,9 @@ type MySqlConnector struct {
 	rdsAuth       *utils.RDSAuth
 	serverVersion string
 	bytesRead     atomic.Int64
+	// incremental snapshots of the mirror and the watermarks of their chunk in flight, kept across pulls
+	incrementalSnapshots *utils.IncrementalSnapshots
+	snapshotWatermarks   *snapshotWatermarks
 }
 
 func NewMySqlConnector(ctx context.Context, config *protos.MySqlConfig) (*MySqlConnector, error) {
@@ -245,6 +248,36 @@ func (c *MySqlConnector) Execute(ctx context.Context, cmd string, args ...any) (
 	return nil, connectionErr
 }
 
+// ExecuteInTx runs fn inside a transaction, only beginning the transaction is retried
+// as fn may not be safe to run twice
+func (c *MySqlConnector) ExecuteInTx(ctx context.Context, fn func(conn *client.Conn) error) error {
+	var connectionErr error
+	for conn, err := range c.withRetries(ctx) {
+		if err != nil {
+			return err
+		}
+
+		if err := conn.Begin(); err != nil {
+			if mysql.ErrorEqual(err, mysql.ErrBadConn) {
+				connectionErr = err
+				continue
+			}
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		if err := fn(conn); err != nil {
+			if rollbackErr := conn.Rollback(); rollbackErr != nil {
+				c.logger.Error("failed to rollback transaction", slog.Any("error", rollbackErr))
+			}
+			return err
+		}
+		if err := conn.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	}
+	return connectionErr
+}
+
 func (c *MySqlConnector) ExecuteSelectStreaming(ctx context.Context, cmd string, result *mysql.Result,
 	rowCb client.SelectPerRowCallback,
 	resultCb client.SelectPerResultCallback,
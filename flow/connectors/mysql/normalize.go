@@ -0,0 +1,241 @@
+package connmysql
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/go-mysql-org/go-mysql/client"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func (c *MySqlConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (c *MySqlConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+func (c *MySqlConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+func (c *MySqlConnector) tableExists(ctx context.Context, table *utils.SchemaTable) (bool, error) {
+	rs, err := c.Execute(ctx,
+		"SELECT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_schema=? AND table_name=?)", table.Schema, table.Table)
+	if err != nil {
+		return false, fmt.Errorf("error checking if table %s exists: %w", table.MySQL(), err)
+	}
+	exists, err := rs.GetInt(0, 0)
+	if err != nil {
+		return false, fmt.Errorf("error reading if table %s exists: %w", table.MySQL(), err)
+	}
+	return exists == 1, nil
+}
+
+func (c *MySqlConnector) SetupNormalizedTable(
+	ctx context.Context,
+	tx any,
+	config *protos.SetupNormalizedTableBatchInput,
+	destinationTableIdentifier string,
+	sourceTableSchema *protos.TableSchema,
+) (bool, error) {
+	dstTable, err := utils.ParseSchemaTable(destinationTableIdentifier)
+	if err != nil {
+		return false, fmt.Errorf("error while parsing table schema and name: %w", err)
+	}
+	tableAlreadyExists, err := c.tableExists(ctx, dstTable)
+	if err != nil {
+		return false, err
+	}
+	if tableAlreadyExists {
+		c.logger.Info("[mysql] table already exists, skipping", slog.String("table", destinationTableIdentifier))
+		if !config.IsResync {
+			return true, nil
+		}
+		if _, err := c.Execute(ctx, "DROP TABLE IF EXISTS "+quoteTable(dstTable)); err != nil {
+			return false, fmt.Errorf("error while dropping _resync table: %w", err)
+		}
+		c.logger.Info("[mysql] dropped resync table for resync", slog.String("resyncTable", destinationTableIdentifier))
+	}
+
+	createTableSQL, err := generateCreateTableSQLForNormalizedTable(ctx, config, dstTable, sourceTableSchema)
+	if err != nil {
+		return false, err
+	}
+	if _, err := c.Execute(ctx, createTableSQL); err != nil {
+		return false, fmt.Errorf("error while creating normalized table: %w", err)
+	}
+	return false, nil
+}
+
+func generateCreateTableSQLForNormalizedTable(
+	ctx context.Context,
+	config *protos.SetupNormalizedTableBatchInput,
+	dstTable *utils.SchemaTable,
+	tableSchema *protos.TableSchema,
+) (string, error) {
+	createTableSQLArray := make([]string, 0, len(tableSchema.Columns)+3)
+	for _, column := range tableSchema.Columns {
+		columnType, err := qvalue.ToDWHColumnType(
+			ctx, types.QValueKind(column.Type), config.Env, protos.DBType_MYSQL, nil, column, tableSchema.NullableEnabled,
+		)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert column type %s to MySQL type: %w", column.Type, err)
+		}
+		// TEXT, BLOB and JSON columns cannot be part of a key without a prefix length
+		if slices.Contains(tableSchema.PrimaryKeyColumns, column.Name) {
+			if rest, ok := strings.CutPrefix(columnType, "LONGTEXT"); ok {
+				columnType = "VARCHAR(255)" + rest
+			} else if rest, ok := strings.CutPrefix(columnType, "JSON"); ok {
+				columnType = "VARCHAR(255)" + rest
+			} else if rest, ok := strings.CutPrefix(columnType, "LONGBLOB"); ok {
+				columnType = "VARBINARY(255)" + rest
+			}
+		}
+		createTableSQLArray = append(createTableSQLArray, quoteIdentifier(column.Name)+" "+columnType)
+	}
+
+	if config.SoftDeleteColName != "" {
+		createTableSQLArray = append(createTableSQLArray,
+			quoteIdentifier(config.SoftDeleteColName)+" BOOLEAN DEFAULT FALSE")
+	}
+
+	if config.SyncedAtColName != "" {
+		createTableSQLArray = append(createTableSQLArray,
+			quoteIdentifier(config.SyncedAtColName)+" DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6)")
+	}
+
+	if len(tableSchema.PrimaryKeyColumns) > 0 && !tableSchema.IsReplicaIdentityFull {
+		primaryKeyColsQuoted := make([]string, 0, len(tableSchema.PrimaryKeyColumns))
+		for _, primaryKeyCol := range tableSchema.PrimaryKeyColumns {
+			primaryKeyColsQuoted = append(primaryKeyColsQuoted, quoteIdentifier(primaryKeyCol))
+		}
+		createTableSQLArray = append(createTableSQLArray,
+			fmt.Sprintf("PRIMARY KEY(%s)", strings.Join(primaryKeyColsQuoted, ",")))
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", quoteTable(dstTable), strings.Join(createTableSQLArray, ",")), nil
+}
+
+// getTableNametoUnchangedCols returns the distinct unchanged toast column combinations of every table in the batch range
+func (c *MySqlConnector) getTableNametoUnchangedCols(
+	ctx context.Context,
+	flowJobName string,
+	syncBatchID int64,
+	normalizeBatchID int64,
+) (map[string][]string, error) {
+	rs, err := c.Execute(ctx, fmt.Sprintf("SELECT DISTINCT _peerdb_destination_table_name,_peerdb_unchanged_toast_columns"+
+		" FROM %s WHERE _peerdb_batch_id>? AND _peerdb_batch_id<=?", getRawTableIdentifier(flowJobName)),
+		normalizeBatchID, syncBatchID)
+	if err != nil {
+		return nil, fmt.Errorf("error while retrieving table names for normalization: %w", err)
+	}
+
+	resultMap := make(map[string][]string)
+	for _, row := range rs.Values {
+		destinationTableName := string(row[0].AsString())
+		resultMap[destinationTableName] = append(resultMap[destinationTableName], string(row[1].AsString()))
+	}
+	return resultMap, nil
+}
+
+func (c *MySqlConnector) NormalizeRecords(
+	ctx context.Context,
+	req *model.NormalizeRecordsRequest,
+) (model.NormalizeResponse, error) {
+	normBatchID, err := c.GetLastNormalizeBatchID(ctx, req.FlowJobName)
+	if err != nil {
+		c.logger.Error("[mysql] error while getting last normalize batch id", slog.Any("error", err))
+		return model.NormalizeResponse{}, err
+	}
+
+	// normalize has caught up with sync, chill until more records are loaded.
+	if normBatchID >= req.SyncBatchID {
+		return model.NormalizeResponse{
+			StartBatchID: normBatchID,
+			EndBatchID:   req.SyncBatchID,
+		}, nil
+	}
+
+	unchangedToastColumnsMap, err := c.getTableNametoUnchangedCols(ctx, req.FlowJobName, req.SyncBatchID, normBatchID)
+	if err != nil {
+		return model.NormalizeResponse{}, err
+	}
+
+	normalizeStmtGen := normalizeStmtGenerator{
+		rawTableName:             getRawTableIdentifier(req.FlowJobName),
+		tableSchemaMapping:       req.TableNameSchemaMapping,
+		unchangedToastColumnsMap: unchangedToastColumnsMap,
+		peerdbCols: &protos.PeerDBColumns{
+			SoftDeleteColName: req.SoftDeleteColName,
+			SyncedAtColName:   req.SyncedAtColName,
+		},
+		syncBatchID: req.SyncBatchID,
+	}
+
+	for _, destinationTableName := range slices.Sorted(maps.Keys(unchangedToastColumnsMap)) {
+		if _, ok := req.TableNameSchemaMapping[destinationTableName]; !ok {
+			c.logger.Warn("table not found in table to schema mapping", slog.String("table", destinationTableName))
+			continue
+		}
+		dstTable, err := utils.ParseSchemaTable(destinationTableName)
+		if err != nil {
+			return model.NormalizeResponse{}, fmt.Errorf("error while parsing table schema and name: %w", err)
+		}
+
+		normalizeBatchIDForTable, err := c.GetLastNormalizedBatchIDForTable(ctx, req.FlowJobName, destinationTableName)
+		if err != nil {
+			return model.NormalizeResponse{}, err
+		}
+		batchIdToLoadForTable := max(normBatchID, normalizeBatchIDForTable)
+		if batchIdToLoadForTable >= req.SyncBatchID {
+			c.logger.Info("[mysql] table already normalized for this batch, skipping",
+				slog.String("table", destinationTableName), slog.Int64("syncBatchID", req.SyncBatchID))
+			continue
+		}
+
+		normalizeStatements := normalizeStmtGen.generateNormalizeStatements(
+			destinationTableName, quoteTable(dstTable), batchIdToLoadForTable)
+		if err := c.ExecuteInTx(ctx, func(conn *client.Conn) error {
+			for _, normalizeStatement := range normalizeStatements {
+				if _, err := conn.Execute(normalizeStatement); err != nil {
+					c.logger.Error("error executing normalize statement",
+						slog.String("statement", normalizeStatement),
+						slog.Int64("normBatchID", batchIdToLoadForTable),
+						slog.Int64("syncBatchID", req.SyncBatchID),
+						slog.String("destinationTableName", destinationTableName),
+						slog.Any("error", err))
+					return fmt.Errorf("error executing normalize statement for table %s: %w", destinationTableName, err)
+				}
+			}
+			return nil
+		}); err != nil {
+			return model.NormalizeResponse{}, err
+		}
+
+		if err := c.SetLastNormalizedBatchIDForTable(ctx, req.FlowJobName, destinationTableName, req.SyncBatchID); err != nil {
+			return model.NormalizeResponse{}, fmt.Errorf("error while setting last normalized batch id for table %s: %w",
+				destinationTableName, err)
+		}
+	}
+
+	if err := c.UpdateNormalizeBatchID(ctx, req.FlowJobName, req.SyncBatchID); err != nil {
+		c.logger.Error("[mysql] error while updating normalize batch id",
+			slog.Int64("BatchID", req.SyncBatchID), slog.Any("error", err))
+		return model.NormalizeResponse{}, err
+	}
+
+	return model.NormalizeResponse{
+		StartBatchID: normBatchID + 1,
+		EndBatchID:   req.SyncBatchID,
+	}, nil
+}
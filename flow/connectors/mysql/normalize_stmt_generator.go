@@ -0,0 +1,158 @@
+package connmysql
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+type normalizeStmtGenerator struct {
+	// `_peerdb_internal`.`_peerdb_raw_...`
+	rawTableName string
+	// the schema of the table to merge into
+	tableSchemaMapping map[string]*protos.TableSchema
+	// array of toast column combinations that are unchanged
+	unchangedToastColumnsMap map[string][]string
+	// _PEERDB_IS_DELETED and _SYNCED_AT columns
+	peerdbCols *protos.PeerDBColumns
+	// last batch id to merge
+	syncBatchID int64
+}
+
+// jsonValueExpr extracts a column from _peerdb_data, mapping json null to sql NULL
+func jsonValueExpr(column string, unquote bool) string {
+	path := quoteLiteral(`$."` + strings.ReplaceAll(column, `"`, `\"`) + `"`)
+	extract := fmt.Sprintf("JSON_EXTRACT(_peerdb_data,%s)", path)
+	value := extract
+	if unquote {
+		value = fmt.Sprintf("JSON_UNQUOTE(%s)", extract)
+	}
+	return fmt.Sprintf("IF(JSON_TYPE(%s)='NULL',NULL,%s)", extract, value)
+}
+
+// generateExpr converts a column of _peerdb_data, which was serialized by RecordItems.toMap,
+// to a value MySQL implicitly casts to the column type picked by ToDWHColumnType
+func (n *normalizeStmtGenerator) generateExpr(column *protos.FieldDescription) string {
+	qkind := types.QValueKind(column.Type)
+	if qkind.IsArray() {
+		return jsonValueExpr(column.Name, false)
+	}
+	value := jsonValueExpr(column.Name, true)
+	switch qkind {
+	case types.QValueKindBoolean:
+		return fmt.Sprintf("(%s='true')", value)
+	case types.QValueKindBytes:
+		return fmt.Sprintf("FROM_BASE64(%s)", value)
+	case types.QValueKindTimestampTZ:
+		// serialized as 2006-01-02 15:04:05.999999-0700, stored as UTC
+		return fmt.Sprintf("CONVERT_TZ(LEFT(%[1]s,LENGTH(%[1]s)-5),INSERT(RIGHT(%[1]s,5),4,0,':'),'+00:00')", value)
+	default:
+		return value
+	}
+}
+
+// rankedSource selects the latest change per primary key of dstTable in the batch range
+func (n *normalizeStmtGenerator) rankedSource(dstTable string, normalizedTableSchema *protos.TableSchema, normalizeBatchID int64) string {
+	partitionBy := make([]string, 0, len(normalizedTableSchema.PrimaryKeyColumns))
+	for _, pkey := range normalizedTableSchema.PrimaryKeyColumns {
+		partitionBy = append(partitionBy, jsonValueExpr(pkey, true))
+	}
+	if len(partitionBy) == 0 {
+		partitionBy = append(partitionBy, "_peerdb_uid")
+	}
+	return fmt.Sprintf("(SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,"+
+		"ROW_NUMBER() OVER (PARTITION BY %s ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank"+
+		" FROM %s WHERE _peerdb_batch_id>%d AND _peerdb_batch_id<=%d AND _peerdb_destination_table_name=%s) AS _peerdb_src",
+		strings.Join(partitionBy, ","), n.rawTableName, normalizeBatchID, n.syncBatchID, quoteLiteral(dstTable))
+}
+
+// generateNormalizeStatements returns one INSERT ... ON DUPLICATE KEY UPDATE per unchanged toast column combination,
+// followed by a statement that deletes or soft deletes rows whose latest change was a delete
+func (n *normalizeStmtGenerator) generateNormalizeStatements(dstTable string, quotedDstTable string, normalizeBatchID int64) []string {
+	normalizedTableSchema := n.tableSchemaMapping[dstTable]
+	source := n.rankedSource(dstTable, normalizedTableSchema, normalizeBatchID)
+
+	columnCount := len(normalizedTableSchema.Columns)
+	quotedColumnNames := make([]string, 0, columnCount+2)
+	selectExprs := make([]string, 0, columnCount+2)
+	for _, column := range normalizedTableSchema.Columns {
+		quotedColumnNames = append(quotedColumnNames, quoteIdentifier(column.Name))
+		selectExprs = append(selectExprs, n.generateExpr(column))
+	}
+
+	unchangedToastColumns := n.unchangedToastColumnsMap[dstTable]
+	if !slices.Contains(unchangedToastColumns, "") {
+		unchangedToastColumns = append([]string{""}, unchangedToastColumns...)
+	}
+
+	statements := make([]string, 0, len(unchangedToastColumns)+1)
+	for _, unchangedToastCols := range unchangedToastColumns {
+		unchanged := strings.Split(unchangedToastCols, ",")
+		insertColumns := slices.Clone(quotedColumnNames)
+		insertExprs := slices.Clone(selectExprs)
+		updates := make([]string, 0, columnCount+2)
+		for _, column := range normalizedTableSchema.Columns {
+			if !slices.Contains(unchanged, column.Name) {
+				quotedCol := quoteIdentifier(column.Name)
+				updates = append(updates, fmt.Sprintf("%s=VALUES(%s)", quotedCol, quotedCol))
+			}
+		}
+		if n.peerdbCols.SoftDeleteColName != "" {
+			quotedCol := quoteIdentifier(n.peerdbCols.SoftDeleteColName)
+			insertColumns = append(insertColumns, quotedCol)
+			insertExprs = append(insertExprs, "FALSE")
+			updates = append(updates, fmt.Sprintf("%s=VALUES(%s)", quotedCol, quotedCol))
+		}
+		if n.peerdbCols.SyncedAtColName != "" {
+			quotedCol := quoteIdentifier(n.peerdbCols.SyncedAtColName)
+			insertColumns = append(insertColumns, quotedCol)
+			insertExprs = append(insertExprs, "CURRENT_TIMESTAMP(6)")
+			updates = append(updates, fmt.Sprintf("%s=VALUES(%s)", quotedCol, quotedCol))
+		}
+		statements = append(statements, fmt.Sprintf(
+			"INSERT INTO %s (%s) SELECT %s FROM %s WHERE _peerdb_rank=1 AND _peerdb_record_type!=2"+
+				" AND _peerdb_unchanged_toast_columns=%s ON DUPLICATE KEY UPDATE %s",
+			quotedDstTable, strings.Join(insertColumns, ","), strings.Join(insertExprs, ","), source,
+			quoteLiteral(unchangedToastCols), strings.Join(updates, ",")))
+	}
+
+	if n.peerdbCols.SoftDeleteColName != "" {
+		quotedSoftDeleteCol := quoteIdentifier(n.peerdbCols.SoftDeleteColName)
+		insertColumns := append(slices.Clone(quotedColumnNames), quotedSoftDeleteCol)
+		insertExprs := append(slices.Clone(selectExprs), "TRUE")
+		updates := []string{quotedSoftDeleteCol + "=TRUE"}
+		if n.peerdbCols.SyncedAtColName != "" {
+			quotedCol := quoteIdentifier(n.peerdbCols.SyncedAtColName)
+			insertColumns = append(insertColumns, quotedCol)
+			insertExprs = append(insertExprs, "CURRENT_TIMESTAMP(6)")
+			updates = append(updates, fmt.Sprintf("%s=VALUES(%s)", quotedCol, quotedCol))
+		}
+		statements = append(statements, fmt.Sprintf(
+			"INSERT INTO %s (%s) SELECT %s FROM %s WHERE _peerdb_rank=1 AND _peerdb_record_type=2 ON DUPLICATE KEY UPDATE %s",
+			quotedDstTable, strings.Join(insertColumns, ","), strings.Join(insertExprs, ","), source, strings.Join(updates, ",")))
+	} else if len(normalizedTableSchema.PrimaryKeyColumns) > 0 {
+		deleteKeys := make([]string, 0, len(normalizedTableSchema.PrimaryKeyColumns))
+		joinConditions := make([]string, 0, len(normalizedTableSchema.PrimaryKeyColumns))
+		for _, pkey := range normalizedTableSchema.PrimaryKeyColumns {
+			quotedCol := quoteIdentifier(pkey)
+			expr := jsonValueExpr(pkey, true)
+			for _, column := range normalizedTableSchema.Columns {
+				if column.Name == pkey {
+					expr = n.generateExpr(column)
+					break
+				}
+			}
+			deleteKeys = append(deleteKeys, fmt.Sprintf("%s AS %s", expr, quotedCol))
+			joinConditions = append(joinConditions, fmt.Sprintf("_peerdb_dst.%s=_peerdb_del.%s", quotedCol, quotedCol))
+		}
+		statements = append(statements, fmt.Sprintf(
+			"DELETE _peerdb_dst FROM %s AS _peerdb_dst INNER JOIN (SELECT %s FROM %s WHERE _peerdb_rank=1 AND _peerdb_record_type=2)"+
+				" AS _peerdb_del ON %s",
+			quotedDstTable, strings.Join(deleteKeys, ","), source, strings.Join(joinConditions, " AND ")))
+	}
+
+	return statements
+}
@@ -0,0 +1,54 @@
+package connmysql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestGenerateNormalizeStatements(t *testing.T) {
+	tableSchema := &protos.TableSchema{
+		PrimaryKeyColumns: []string{"id"},
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(types.QValueKindInt64)},
+			{Name: "name", Type: string(types.QValueKindString)},
+			{Name: "blob", Type: string(types.QValueKindBytes)},
+		},
+	}
+	normalizeGen := normalizeStmtGenerator{
+		rawTableName:             "`_peerdb_internal`.`_peerdb_raw_test`",
+		tableSchemaMapping:       map[string]*protos.TableSchema{"db.t": tableSchema},
+		unchangedToastColumnsMap: map[string][]string{"db.t": {"blob"}},
+		peerdbCols:               &protos.PeerDBColumns{SyncedAtColName: "_peerdb_synced_at"},
+		syncBatchID:              5,
+	}
+
+	statements := normalizeGen.generateNormalizeStatements("db.t", "`db`.`t`", 3)
+	require.Len(t, statements, 3)
+	for _, stmt := range statements {
+		require.Contains(t, stmt, "_peerdb_batch_id>3 AND _peerdb_batch_id<=5 AND _peerdb_destination_table_name='db.t'")
+	}
+
+	// all columns are updated when none are unchanged
+	require.Contains(t, statements[0], "_peerdb_unchanged_toast_columns=''")
+	require.Contains(t, statements[0],
+		"ON DUPLICATE KEY UPDATE `id`=VALUES(`id`),`name`=VALUES(`name`),`blob`=VALUES(`blob`),"+
+			"`_peerdb_synced_at`=VALUES(`_peerdb_synced_at`)")
+	require.Contains(t, statements[0], `FROM_BASE64(IF(JSON_TYPE(JSON_EXTRACT(_peerdb_data,'$."blob"'))='NULL'`)
+
+	// unchanged toast columns are left as is
+	require.Contains(t, statements[1], "_peerdb_unchanged_toast_columns='blob'")
+	require.False(t, strings.Contains(statements[1], "`blob`=VALUES(`blob`)"))
+
+	require.True(t, strings.HasPrefix(statements[2], "DELETE _peerdb_dst FROM `db`.`t` AS _peerdb_dst"))
+	require.Contains(t, statements[2], "ON _peerdb_dst.`id`=_peerdb_del.`id`")
+
+	normalizeGen.peerdbCols.SoftDeleteColName = "_peerdb_is_deleted"
+	statements = normalizeGen.generateNormalizeStatements("db.t", "`db`.`t`", 3)
+	require.Len(t, statements, 3)
+	require.Contains(t, statements[2], "_peerdb_record_type=2 ON DUPLICATE KEY UPDATE `_peerdb_is_deleted`=TRUE")
+}
@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"log/slog"
 	"strconv"
+	"strings"
 	"text/template"
 	"time"
 
+	"github.com/go-mysql-org/go-mysql/client"
 	"github.com/go-mysql-org/go-mysql/mysql"
 	"go.temporal.io/sdk/log"
 
@@ -353,3 +355,76 @@ func BuildQuery(logger log.Logger, query string, start string, end string) (stri
 	logger.Info("[mysql] templated query", slog.String("query", res))
 	return res, nil
 }
+
+func (c *MySqlConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+func (c *MySqlConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+
+	dstTable, err := utils.ParseSchemaTable(config.DestinationTableIdentifier)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to parse destination table identifier: %w", err)
+	}
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+	columnNames := schema.GetColumnNames()
+
+	// upserts rely on the unique key of the destination table covering the upsert key columns
+	var onDuplicate string
+	if config.WriteMode != nil && config.WriteMode.WriteType == protos.QRepWriteType_QREP_WRITE_MODE_UPSERT {
+		updates := make([]string, 0, len(columnNames))
+		for _, col := range columnNames {
+			quotedCol := quoteIdentifier(col)
+			updates = append(updates, fmt.Sprintf("%s=VALUES(%s)", quotedCol, quotedCol))
+		}
+		onDuplicate = " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ",")
+	}
+
+	var numRecords int64
+	if err := c.ExecuteInTx(ctx, func(conn *client.Conn) error {
+		rows := make([][]any, 0, insertBatchSize)
+		for qRecord := range stream.Records {
+			row := make([]any, 0, len(qRecord))
+			for idx, qv := range qRecord {
+				arg, err := mysqlArgFromQValue(qv)
+				if err != nil {
+					return fmt.Errorf("failed to convert value of column %s: %w", columnNames[idx], err)
+				}
+				row = append(row, arg)
+			}
+			rows = append(rows, row)
+			numRecords += 1
+			if len(rows) >= insertBatchSize {
+				if err := insertRows(conn, quoteTable(dstTable), columnNames, rows, onDuplicate); err != nil {
+					return fmt.Errorf("failed to insert into %s: %w", config.DestinationTableIdentifier, err)
+				}
+				rows = rows[:0]
+			}
+		}
+		if err := stream.Err(); err != nil {
+			return fmt.Errorf("failed to get record from stream: %w", err)
+		}
+		if err := insertRows(conn, quoteTable(dstTable), columnNames, rows, onDuplicate); err != nil {
+			return fmt.Errorf("failed to insert into %s: %w", config.DestinationTableIdentifier, err)
+		}
+		return nil
+	}); err != nil {
+		c.logger.Error("[mysql] failed to sync qrep records", slog.Any("error", err))
+		return 0, nil, err
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		c.logger.Error("[mysql] failed to log partition info", slog.Any("error", err))
+		return 0, nil, fmt.Errorf("[mysql] failed to log partition info: %w", err)
+	}
+	return numRecords, nil, nil
+}
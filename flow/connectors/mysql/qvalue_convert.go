@@ -2,6 +2,7 @@ package connmysql
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"math"
 	"math/bits"
@@ -496,3 +497,53 @@ func QValueFromMysqlRowEvent(
 	}
 	return nil, fmt.Errorf("unexpected type %T for mysql type %d, qkind %s", val, mytype, qkind)
 }
+
+// mysqlArgFromQValue converts a QValue into a statement argument go-mysql can encode,
+// values without a native MySQL encoding are sent as their text representation
+func mysqlArgFromQValue(qv types.QValue) (any, error) {
+	switch v := qv.(type) {
+	case types.QValueNull:
+		return nil, nil
+	case types.QValueFloat32:
+		if math.IsNaN(float64(v.Val)) || math.IsInf(float64(v.Val), 0) {
+			return nil, nil
+		}
+		return v.Val, nil
+	case types.QValueFloat64:
+		if math.IsNaN(v.Val) || math.IsInf(v.Val, 0) {
+			return nil, nil
+		}
+		return v.Val, nil
+	case types.QValueQChar:
+		return string(rune(v.Val)), nil
+	case types.QValueInt256:
+		return v.Val.String(), nil
+	case types.QValueUInt256:
+		return v.Val.String(), nil
+	case types.QValueNumeric:
+		return v.Val.String(), nil
+	case types.QValueTimestamp:
+		return v.Val.Format("2006-01-02 15:04:05.999999"), nil
+	case types.QValueTimestampTZ:
+		return v.Val.UTC().Format("2006-01-02 15:04:05.999999"), nil
+	case types.QValueDate:
+		return v.Val.Format("2006-01-02"), nil
+	case types.QValueTime:
+		return time.Time{}.Add(v.Val).Format("15:04:05.999999"), nil
+	case types.QValueTimeTZ:
+		return time.Time{}.Add(v.Val).Format("15:04:05.999999"), nil
+	case types.QValueUUID:
+		return v.Val.String(), nil
+	case types.QValueHStore:
+		return datatypes.ParseHstore(v.Val)
+	default:
+		if qv.Kind().IsArray() {
+			arr, err := json.Marshal(qv.Value())
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal %s to json: %w", qv.Kind(), err)
+			}
+			return string(arr), nil
+		}
+		return qv.Value(), nil
+	}
+}
@@ -0,0 +1,260 @@
+package connmysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-mysql-org/go-mysql/client"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/google/uuid"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+const (
+	// database holding raw tables, created on first use
+	metadataDatabase = "_peerdb_internal"
+	// prepared statements are limited to 65535 placeholders
+	maxPlaceholders = 65535
+	// upper bound on rows per INSERT statement
+	insertBatchSize = 1000
+
+	createRawTableSQL = `CREATE TABLE IF NOT EXISTS %s (
+		_peerdb_uid CHAR(36) NOT NULL,
+		_peerdb_timestamp BIGINT NOT NULL,
+		_peerdb_destination_table_name VARCHAR(255) NOT NULL,
+		_peerdb_data JSON NOT NULL,
+		_peerdb_record_type INT NOT NULL,
+		_peerdb_match_data JSON,
+		_peerdb_batch_id BIGINT NOT NULL,
+		_peerdb_unchanged_toast_columns TEXT NOT NULL,
+		PRIMARY KEY (_peerdb_uid),
+		INDEX (_peerdb_batch_id, _peerdb_destination_table_name)
+	)`
+)
+
+var rawTableColumns = []string{
+	"_peerdb_uid", "_peerdb_timestamp", "_peerdb_destination_table_name", "_peerdb_data",
+	"_peerdb_record_type", "_peerdb_match_data", "_peerdb_batch_id", "_peerdb_unchanged_toast_columns",
+}
+
+func quoteIdentifier(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+// quoteLiteral relies on the NO_BACKSLASH_ESCAPES sql_mode set on every connection
+func quoteLiteral(literal string) string {
+	return "'" + strings.ReplaceAll(literal, "'", "''") + "'"
+}
+
+func quoteTable(table *utils.SchemaTable) string {
+	return quoteIdentifier(table.Schema) + "." + quoteIdentifier(table.Table)
+}
+
+func getRawTableIdentifier(flowJobName string) string {
+	return quoteIdentifier(metadataDatabase) + "." +
+		quoteIdentifier("_peerdb_raw_"+shared.ReplaceIllegalCharactersWithUnderscores(flowJobName))
+}
+
+// insertRows writes rows to table with multi-row INSERT statements,
+// suffix is appended to every statement, e.g. an ON DUPLICATE KEY UPDATE clause
+func insertRows(conn *client.Conn, table string, columns []string, rows [][]any, suffix string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	quotedColumns := make([]string, 0, len(columns))
+	for _, col := range columns {
+		quotedColumns = append(quotedColumns, quoteIdentifier(col))
+	}
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")"
+	batchSize := min(insertBatchSize, maxPlaceholders/max(len(columns), 1))
+
+	for batch := range slices.Chunk(rows, batchSize) {
+		var query strings.Builder
+		query.WriteString("INSERT INTO ")
+		query.WriteString(table)
+		query.WriteString(" (")
+		query.WriteString(strings.Join(quotedColumns, ","))
+		query.WriteString(") VALUES ")
+		args := make([]any, 0, len(batch)*len(columns))
+		for i, row := range batch {
+			if i > 0 {
+				query.WriteByte(',')
+			}
+			query.WriteString(placeholders)
+			args = append(args, row...)
+		}
+		query.WriteString(suffix)
+		if _, err := conn.Execute(query.String(), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *MySqlConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	if _, err := c.Execute(ctx, "CREATE DATABASE IF NOT EXISTS "+quoteIdentifier(metadataDatabase)); err != nil {
+		return nil, fmt.Errorf("failed to create internal database: %w", err)
+	}
+	rawTableIdentifier := getRawTableIdentifier(req.FlowJobName)
+	if _, err := c.Execute(ctx, fmt.Sprintf(createRawTableSQL, rawTableIdentifier)); err != nil {
+		return nil, fmt.Errorf("failed to create raw table: %w", err)
+	}
+	return &protos.CreateRawTableOutput{TableIdentifier: rawTableIdentifier}, nil
+}
+
+func (c *MySqlConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	rawTableIdentifier := getRawTableIdentifier(req.FlowJobName)
+	c.logger.Info("pushing records to MySQL raw table", slog.String("table", rawTableIdentifier))
+
+	var numRecords int64
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	jsonOptions := model.ToJSONOptions{
+		UnnestColumns: nil,
+		HStoreAsJSON:  true,
+	}
+	if err := c.ExecuteInTx(ctx, func(conn *client.Conn) error {
+		// clear out rows of a previous attempt at this batch
+		if _, err := conn.Execute("DELETE FROM "+rawTableIdentifier+" WHERE _peerdb_batch_id=?", req.SyncBatchID); err != nil {
+			return fmt.Errorf("failed to clear raw table: %w", err)
+		}
+
+		rows := make([][]any, 0, insertBatchSize)
+		for record := range req.Records.GetRecords() {
+			var row []any
+			switch typedRecord := record.(type) {
+			case *model.InsertRecord[model.RecordItems]:
+				itemsJSON, err := typedRecord.Items.ToJSONWithOptions(jsonOptions)
+				if err != nil {
+					return fmt.Errorf("failed to serialize insert record items to JSON: %w", err)
+				}
+				row = []any{
+					uuid.NewString(), time.Now().UnixNano(), typedRecord.DestinationTableName,
+					itemsJSON, 0, "{}", req.SyncBatchID, "",
+				}
+			case *model.UpdateRecord[model.RecordItems]:
+				newItemsJSON, err := typedRecord.NewItems.ToJSONWithOptions(jsonOptions)
+				if err != nil {
+					return fmt.Errorf("failed to serialize update record new items to JSON: %w", err)
+				}
+				oldItemsJSON, err := typedRecord.OldItems.ToJSONWithOptions(jsonOptions)
+				if err != nil {
+					return fmt.Errorf("failed to serialize update record old items to JSON: %w", err)
+				}
+				row = []any{
+					uuid.NewString(), time.Now().UnixNano(), typedRecord.DestinationTableName,
+					newItemsJSON, 1, oldItemsJSON, req.SyncBatchID, utils.KeysToString(typedRecord.UnchangedToastColumns),
+				}
+			case *model.DeleteRecord[model.RecordItems]:
+				itemsJSON, err := typedRecord.Items.ToJSONWithOptions(jsonOptions)
+				if err != nil {
+					return fmt.Errorf("failed to serialize delete record items to JSON: %w", err)
+				}
+				row = []any{
+					uuid.NewString(), time.Now().UnixNano(), typedRecord.DestinationTableName,
+					itemsJSON, 2, itemsJSON, req.SyncBatchID, "",
+				}
+			case *model.MessageRecord[model.RecordItems], *model.TruncateRecord[model.RecordItems],
+				*model.RelationRecord[model.RecordItems]:
+				continue
+			default:
+				return fmt.Errorf("unsupported record type for MySQL flow connector: %T", typedRecord)
+			}
+
+			record.PopulateCountMap(tableNameRowsMapping)
+			numRecords += 1
+			rows = append(rows, row)
+			if len(rows) >= insertBatchSize {
+				if err := insertRows(conn, rawTableIdentifier, rawTableColumns, rows, ""); err != nil {
+					return fmt.Errorf("failed to insert into raw table: %w", err)
+				}
+				rows = rows[:0]
+			}
+		}
+		if err := insertRows(conn, rawTableIdentifier, rawTableColumns, rows, ""); err != nil {
+			return fmt.Errorf("failed to insert into raw table: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	c.logger.Info("synced records to MySQL raw table",
+		slog.String("table", rawTableIdentifier), slog.Int64("numRecords", numRecords))
+
+	if err := c.ReplayTableSchemaDeltas(ctx, req.Env, req.FlowJobName, req.TableMappings, req.Records.SchemaDeltas); err != nil {
+		return nil, fmt.Errorf("failed to sync schema changes: %w", err)
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		c.logger.Error("failed to increment id", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &model.SyncResponse{
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords,
+		CurrentSyncBatchID:   req.SyncBatchID,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (c *MySqlConnector) ReplayTableSchemaDeltas(
+	ctx context.Context,
+	env map[string]string,
+	flowJobName string,
+	_ []*protos.TableMapping,
+	schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	for _, schemaDelta := range schemaDeltas {
+		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+			continue
+		}
+		dstTable, err := utils.ParseSchemaTable(schemaDelta.DstTableName)
+		if err != nil {
+			return fmt.Errorf("error parsing destination table %s: %w", schemaDelta.DstTableName, err)
+		}
+
+		for _, addedColumn := range schemaDelta.AddedColumns {
+			columnType, err := qvalue.ToDWHColumnType(
+				ctx, types.QValueKind(addedColumn.Type), env, protos.DBType_MYSQL, nil, addedColumn, schemaDelta.NullableEnabled,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to convert column type %s to MySQL type: %w", addedColumn.Type, err)
+			}
+			// MySQL has no ADD COLUMN IF NOT EXISTS, a replayed delta fails with a duplicate column
+			if _, err := c.Execute(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s",
+				quoteTable(dstTable), quoteIdentifier(addedColumn.Name), columnType),
+			); err != nil {
+				var myErr *mysql.MyError
+				if !errors.As(err, &myErr) || myErr.Code != mysql.ER_DUP_FIELDNAME {
+					return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name, schemaDelta.DstTableName, err)
+				}
+			}
+			c.logger.Info("[schema delta replay] added column",
+				slog.String("column", addedColumn.Name),
+				slog.String("type", columnType),
+				slog.String("destination table name", schemaDelta.DstTableName),
+				slog.String("source table name", schemaDelta.SrcTableName))
+		}
+	}
+	return nil
+}
+
+func (c *MySqlConnector) SyncFlowCleanup(ctx context.Context, jobName string) error {
+	if _, err := c.Execute(ctx, "DROP TABLE IF EXISTS "+getRawTableIdentifier(jobName)); err != nil {
+		return fmt.Errorf("[mysql] unable to drop raw table: %w", err)
+	}
+	return c.PostgresMetadata.SyncFlowCleanup(ctx, jobName)
+}
@@ -0,0 +1,446 @@
+package connnats
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	lua "github.com/yuin/gopher-lua"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/pua"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+type NatsConnector struct {
+	*metadataStore.PostgresMetadata
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	logger log.Logger
+}
+
+func NewNatsConnector(
+	ctx context.Context,
+	config *protos.NatsConfig,
+) (*NatsConnector, error) {
+	opts := []nats.Option{nats.Name("peerdb")}
+	if config.User != nil {
+		opts = append(opts, nats.UserInfo(config.GetUser(), config.GetPassword()))
+	}
+	if config.Token != nil {
+		opts = append(opts, nats.Token(config.GetToken()))
+	}
+	if config.UserJwt != nil {
+		opts = append(opts, nats.UserJWTAndSeed(config.GetUserJwt(), config.GetNkeySeed()))
+	}
+	if config.RootCa != nil {
+		// server name is left to the client, which takes it from the url it connects to
+		tlsConfig, err := shared.CreateTlsConfig(tls.VersionTLS12, config.RootCa, "", "", false)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, nats.Secure(tlsConfig))
+	}
+
+	conn, err := nats.Connect(strings.Join(config.Servers, ","), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &NatsConnector{
+		PostgresMetadata: pgMetadata,
+		conn:             conn,
+		js:               js,
+		logger:           internal.LoggerFromCtx(ctx),
+	}, nil
+}
+
+func (c *NatsConnector) Close() error {
+	if c != nil {
+		c.conn.Close()
+	}
+	return nil
+}
+
+func (c *NatsConnector) ConnectionActive(ctx context.Context) error {
+	if _, err := c.js.AccountInfo(ctx); err != nil {
+		return fmt.Errorf("nats jetstream connection active check failure: %w", err)
+	}
+	return nil
+}
+
+func (c *NatsConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+func (c *NatsConnector) ReplayTableSchemaDeltas(_ context.Context, _ map[string]string,
+	flowJobName string, _ []*protos.TableMapping, schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	return nil
+}
+
+// lvalueToNatsMsg converts a value returned by onRecord, a string payload or a table of
+// subject/payload/headers/id, id being the Nats-Msg-Id JetStream deduplicates publishes by.
+// topic and value are accepted as aliases of subject and payload, as returned by the Debezium encoder
+func lvalueToNatsMsg(ls *lua.LState, value lua.LValue) (*nats.Msg, error) {
+	switch v := value.(type) {
+	case lua.LString:
+		msg := nats.NewMsg("")
+		msg.Data = shared.UnsafeFastStringToReadOnlyBytes(string(v))
+		return msg, nil
+	case *lua.LTable:
+		lsubject := ls.GetField(v, "subject")
+		if lsubject == lua.LNil {
+			lsubject = ls.GetField(v, "topic")
+		}
+		subject, err := utils.LVAsStringOrNil(ls, lsubject)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subject, %w", err)
+		}
+		lpayload := ls.GetField(v, "payload")
+		if lpayload == lua.LNil {
+			lpayload = ls.GetField(v, "value")
+		}
+		payload, err := utils.LVAsReadOnlyBytes(ls, lpayload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload, %w", err)
+		}
+		id, err := utils.LVAsStringOrNil(ls, ls.GetField(v, "id"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid id, %w", err)
+		}
+
+		msg := nats.NewMsg(subject)
+		msg.Data = payload
+		lheaders := ls.GetField(v, "headers")
+		if headers, ok := lheaders.(*lua.LTable); ok {
+			headers.ForEach(func(k, v lua.LValue) {
+				msg.Header.Add(k.String(), v.String())
+			})
+		} else if lua.LVAsBool(lheaders) {
+			return nil, fmt.Errorf("invalid headers, must be nil or table: %s", lheaders)
+		}
+		if id != "" {
+			msg.Header.Set(nats.MsgIdHdr, id)
+		}
+		return msg, nil
+	case *lua.LNilType:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("script returned invalid value: %s", value)
+	}
+}
+
+// streamName derives the name of a stream created for a subject, stream names cannot contain . * or >
+func streamName(subject string) string {
+	return strings.NewReplacer(".", "_", "*", "_", ">", "_").Replace(subject)
+}
+
+type poolResult struct {
+	messages []*nats.Msg
+	lsn      int64
+}
+
+type publishResult struct {
+	future jetstream.PubAckFuture
+	lsn    int64
+}
+
+// msgIDs assigns default Nats-Msg-Id headers, base identifies the record so a retried batch
+// publishes the same ids and JetStream drops the duplicates within the stream's window
+func msgIDs(messages []*nats.Msg, base string) {
+	for i, msg := range messages {
+		if msg.Header.Get(nats.MsgIdHdr) == "" {
+			msg.Header.Set(nats.MsgIdHdr, base+"-"+strconv.Itoa(i))
+		}
+	}
+}
+
+func (c *NatsConnector) createPool(
+	ctx context.Context,
+	env map[string]string,
+	script string,
+	encoder *utils.DebeziumEncoder,
+	flowJobName string,
+	publish chan<- publishResult,
+	queueErr func(error),
+) (*utils.LPool[poolResult], error) {
+	maxSize, err := internal.PeerDBQueueParallelism(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parallelism: %w", err)
+	}
+	force, err := internal.PeerDBQueueForceTopicCreation(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	// subjects known to be captured by a stream, only accessed by merge which runs on one goroutine
+	streamSubjects := make(map[string]struct{})
+
+	return utils.LuaPool(int(maxSize), func() (*lua.LState, error) {
+		ls, err := utils.LoadScript(ctx, script, utils.LuaPrintFn(func(s string) {
+			_ = c.LogFlowInfo(ctx, flowJobName, s)
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("[nats] error loading script: %w", err)
+		}
+		if encoder != nil {
+			ls.Env.RawSetString("onRecord", ls.NewFunction(utils.DebeziumOnRecord(encoder, false)))
+		} else if script == "" {
+			ls.Env.RawSetString("onRecord", ls.NewFunction(utils.DefaultOnRecord))
+		}
+		return ls, nil
+	}, func(result poolResult) {
+		for _, msg := range result.messages {
+			if _, ok := streamSubjects[msg.Subject]; force && !ok {
+				if err := c.ensureStream(ctx, msg.Subject); err != nil {
+					queueErr(err)
+					return
+				}
+				streamSubjects[msg.Subject] = struct{}{}
+			}
+
+			future, err := c.js.PublishMsgAsync(msg)
+			if err != nil {
+				queueErr(fmt.Errorf("[nats] error publishing message to %s: %w", msg.Subject, err))
+				return
+			}
+			select {
+			case publish <- publishResult{future: future}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case publish <- publishResult{lsn: result.lsn}:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// ensureStream creates a stream capturing subject unless one already does
+func (c *NatsConnector) ensureStream(ctx context.Context, subject string) error {
+	if _, err := c.js.StreamNameBySubject(ctx, subject); err == nil {
+		return nil
+	} else if !errors.Is(err, jetstream.ErrStreamNotFound) {
+		return fmt.Errorf("[nats] error looking up stream of subject %s: %w", subject, err)
+	}
+	c.logger.Info("[nats] force stream creation", slog.String("subject", subject))
+	if _, err := c.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     streamName(subject),
+		Subjects: []string{subject},
+	}); err != nil {
+		return fmt.Errorf("[nats] error creating stream for subject %s: %w", subject, err)
+	}
+	return nil
+}
+
+// awaitAcks waits for publishes in order, lastSeenLSN advances once every message before it is acknowledged
+func awaitAcks(ctx context.Context, publish <-chan publishResult, lastSeenLSN *atomic.Int64, queueErr func(error)) {
+	failed := false
+	for pub := range publish {
+		// keep draining after a failure so publishers are not blocked
+		if failed {
+			continue
+		}
+		if pub.future == nil {
+			if lastSeenLSN != nil {
+				shared.AtomicInt64Max(lastSeenLSN, pub.lsn)
+			}
+			continue
+		}
+		select {
+		case <-pub.future.Ok():
+		case err := <-pub.future.Err():
+			queueErr(fmt.Errorf("[nats] error publishing message to %s: %w", pub.future.Msg().Subject, err))
+			failed = true
+		case <-ctx.Done():
+			failed = true
+		}
+	}
+}
+
+func (c *NatsConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	numRecords := atomic.Int64{}
+	lastSeenLSN := atomic.Int64{}
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	publish := make(chan publishResult, 32)
+	waitChan := make(chan struct{})
+
+	queueCtx, queueErr := context.WithCancelCause(ctx)
+
+	var encoder *utils.DebeziumEncoder
+	if req.QueueEncoding == protos.QueueEncoding_QUEUE_ENCODING_DEBEZIUM {
+		encoder = utils.NewDebeziumEncoder(req.FlowJobName, req.TableNameSchemaMapping, false)
+	}
+	pool, err := c.createPool(queueCtx, req.Env, req.Script, encoder, req.FlowJobName, publish, queueErr)
+	if err != nil {
+		queueErr(nil)
+		return nil, err
+	}
+	closePublish := sync.OnceFunc(func() { close(publish) })
+	defer func() {
+		// on errors, stop publishing and let awaitAcks return once the pool no longer sends to publish
+		queueErr(nil)
+		_ = pool.Wait(context.Background())
+		closePublish()
+	}()
+
+	go func() {
+		awaitAcks(queueCtx, publish, &lastSeenLSN, queueErr)
+		close(waitChan)
+	}()
+
+	flushLoopDone := make(chan struct{})
+	go func() {
+		flushTimeout, err := internal.PeerDBQueueFlushTimeoutSeconds(ctx, req.Env)
+		if err != nil {
+			c.logger.Warn("[nats] failed to get flush timeout, no periodic flushing", slog.Any("error", err))
+			return
+		}
+		ticker := time.NewTicker(flushTimeout)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-flushLoopDone:
+				return
+			// flush loop doesn't block processing new messages
+			case <-ticker.C:
+				lastSeen := lastSeenLSN.Load()
+				if lastSeen > req.ConsumedOffset.Load() {
+					if err := c.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{ID: lastSeen}); err != nil {
+						c.logger.Warn("[nats] SetLastOffset error", slog.Any("error", err))
+					} else {
+						shared.AtomicInt64Max(req.ConsumedOffset, lastSeen)
+						c.logger.Info("processBatch", slog.Int64("updated last offset", lastSeen))
+					}
+				}
+			}
+		}
+	}()
+
+	// records sharing a checkpoint are told apart by their position among them
+	var lastCheckpointID int64
+	var checkpointOrdinal int
+Loop:
+	for {
+		select {
+		case record, ok := <-req.Records.GetRecords():
+			if !ok {
+				c.logger.Info("flushing batches because no more records")
+				break Loop
+			}
+
+			if _, ok := record.(*model.RelationRecord[model.RecordItems]); ok {
+				// schema changes are not passed to scripts
+				continue
+			}
+
+			checkpointID := record.GetCheckpointID()
+			if checkpointID == lastCheckpointID {
+				checkpointOrdinal += 1
+			} else {
+				lastCheckpointID, checkpointOrdinal = checkpointID, 0
+			}
+			msgIDBase := fmt.Sprintf("%s-%d-%d", req.FlowJobName, checkpointID, checkpointOrdinal)
+
+			pool.Run(func(ls *lua.LState) poolResult {
+				lfn := ls.Env.RawGetString("onRecord")
+				fn, ok := lfn.(*lua.LFunction)
+				if !ok {
+					queueErr(fmt.Errorf("script should define `onRecord` as function, not %s", lfn))
+					return poolResult{}
+				}
+
+				ls.Push(fn)
+				ls.Push(pua.LuaRecord.New(ls, record))
+				err := ls.PCall(1, -1, nil)
+				if err != nil {
+					queueErr(fmt.Errorf("script failed: %w", err))
+					return poolResult{}
+				}
+
+				args := ls.GetTop()
+				results := make([]*nats.Msg, 0, args)
+				for i := range args {
+					msg, err := lvalueToNatsMsg(ls, ls.Get(i-args))
+					if err != nil {
+						queueErr(fmt.Errorf("[nats] error creating message: %w", err))
+						return poolResult{}
+					}
+					if msg != nil {
+						if msg.Subject == "" {
+							msg.Subject = record.GetDestinationTableName()
+						}
+						results = append(results, msg)
+						record.PopulateCountMap(tableNameRowsMapping)
+					}
+				}
+				ls.SetTop(0)
+				msgIDs(results, msgIDBase)
+				numRecords.Add(1)
+				return poolResult{
+					messages: results,
+					lsn:      checkpointID,
+				}
+			})
+
+		case <-queueCtx.Done():
+			break Loop
+		}
+	}
+
+	close(flushLoopDone)
+	if err := pool.Wait(queueCtx); err != nil {
+		return nil, fmt.Errorf("[nats] pool.Wait error: %w", err)
+	}
+	closePublish()
+	select {
+	case <-queueCtx.Done():
+		return nil, fmt.Errorf("[nats] queueCtx.Done: %w", context.Cause(queueCtx))
+	case <-waitChan:
+	}
+	if err := context.Cause(queueCtx); err != nil {
+		return nil, fmt.Errorf("[nats] queueCtx.Done: %w", err)
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, fmt.Errorf("[nats] FinishBatch error: %w", err)
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:   req.SyncBatchID,
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords.Load(),
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package connnats
+
+import (
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestLValueToNatsMsg(t *testing.T) {
+	ls := lua.NewState()
+	defer ls.Close()
+	require.NoError(t, ls.DoString(`
+		plain = "row"
+		full = { subject = "orders.created", payload = "row", headers = { source = "pg" }, id = "order-1" }
+		debezium = { topic = "orders", value = "envelope", key = "1" }
+	`))
+
+	msg, err := lvalueToNatsMsg(ls, ls.GetGlobal("plain"))
+	require.NoError(t, err)
+	require.Empty(t, msg.Subject)
+	require.Equal(t, "row", string(msg.Data))
+
+	msg, err = lvalueToNatsMsg(ls, ls.GetGlobal("full"))
+	require.NoError(t, err)
+	require.Equal(t, "orders.created", msg.Subject)
+	require.Equal(t, "pg", msg.Header.Get("source"))
+	msgIDs([]*nats.Msg{msg}, "flow-1-0")
+	require.Equal(t, "order-1", msg.Header.Get(nats.MsgIdHdr))
+
+	msg, err = lvalueToNatsMsg(ls, ls.GetGlobal("debezium"))
+	require.NoError(t, err)
+	require.Equal(t, "orders", msg.Subject)
+	require.Equal(t, "envelope", string(msg.Data))
+	msgIDs([]*nats.Msg{msg}, "flow-1-0")
+	require.Equal(t, "flow-1-0-0", msg.Header.Get(nats.MsgIdHdr))
+
+	msg, err = lvalueToNatsMsg(ls, lua.LNil)
+	require.NoError(t, err)
+	require.Nil(t, msg)
+	_, err = lvalueToNatsMsg(ls, lua.LNumber(1))
+	require.Error(t, err)
+
+	require.Equal(t, "orders_created__", streamName("orders.created.>"))
+}
@@ -0,0 +1,144 @@
+package connnats
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/pua"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func (*NatsConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+func (c *NatsConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+	numRecords := atomic.Int64{}
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+	publish := make(chan publishResult, 32)
+	waitChan := make(chan struct{})
+
+	queueCtx, queueErr := context.WithCancelCause(ctx)
+	var encoder *utils.DebeziumEncoder
+	if config.QueueEncoding == protos.QueueEncoding_QUEUE_ENCODING_DEBEZIUM {
+		encoder = utils.NewQRepDebeziumEncoder(ctx, config, schema)
+	}
+	pool, err := c.createPool(queueCtx, config.Env, config.Script, encoder, config.FlowJobName, publish, queueErr)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer pool.Close()
+
+	go func() {
+		awaitAcks(queueCtx, publish, nil, queueErr)
+		close(waitChan)
+	}()
+
+	shutdown := shared.Interval(ctx, time.Minute, func() {
+		c.logger.Info(fmt.Sprintf("sent %d records", numRecords.Load()))
+	})
+	defer shutdown()
+
+	var rowIndex int64
+Loop:
+	for {
+		select {
+		case qrecord, ok := <-stream.Records:
+			if !ok {
+				c.logger.Info("flushing batches because no more records")
+				break Loop
+			}
+
+			// partitions are retried as a whole, so the row's position identifies it
+			msgIDBase := fmt.Sprintf("%s-%s-%d", config.FlowJobName, partition.PartitionId, rowIndex)
+			rowIndex += 1
+
+			pool.Run(func(ls *lua.LState) poolResult {
+				items := model.NewRecordItems(len(qrecord))
+				for i, val := range qrecord {
+					items.AddColumn(schema.Fields[i].Name, val)
+				}
+				record := &model.InsertRecord[model.RecordItems]{
+					BaseRecord:           model.BaseRecord{},
+					Items:                items,
+					SourceTableName:      config.WatermarkTable,
+					DestinationTableName: config.DestinationTableIdentifier,
+					CommitID:             0,
+				}
+
+				lfn := ls.Env.RawGetString("onRecord")
+				fn, ok := lfn.(*lua.LFunction)
+				if !ok {
+					queueErr(fmt.Errorf("script should define `onRecord` as function, not %s", lfn))
+					return poolResult{}
+				}
+
+				ls.Push(fn)
+				ls.Push(pua.LuaRecord.New(ls, record))
+				err := ls.PCall(1, -1, nil)
+				if err != nil {
+					queueErr(fmt.Errorf("script failed: %w", err))
+					return poolResult{}
+				}
+
+				args := ls.GetTop()
+				results := make([]*nats.Msg, 0, args)
+				for i := range args {
+					msg, err := lvalueToNatsMsg(ls, ls.Get(i-args))
+					if err != nil {
+						queueErr(fmt.Errorf("[nats] error creating message: %w", err))
+						return poolResult{}
+					}
+					if msg != nil {
+						if msg.Subject == "" {
+							msg.Subject = record.GetDestinationTableName()
+						}
+						results = append(results, msg)
+					}
+				}
+				ls.SetTop(0)
+				msgIDs(results, msgIDBase)
+				numRecords.Add(1)
+				return poolResult{messages: results}
+			})
+
+		case <-queueCtx.Done():
+			break Loop
+		}
+	}
+
+	if err := pool.Wait(queueCtx); err != nil {
+		return 0, nil, err
+	}
+	close(publish)
+	select {
+	case <-queueCtx.Done():
+		return 0, nil, fmt.Errorf("[nats] queueCtx.Done: %w", context.Cause(queueCtx))
+	case <-waitChan:
+	}
+	if err := context.Cause(queueCtx); err != nil {
+		return 0, nil, fmt.Errorf("[nats] queueCtx.Done: %w", err)
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, nil, err
+	}
+	return numRecords.Load(), nil, nil
+}
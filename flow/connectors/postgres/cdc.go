@@ -19,6 +19,7 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/pgvector/pgvector-go"
 	"go.temporal.io/sdk/log"
+	"google.golang.org/protobuf/proto"
 
 	connmetadata "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
@@ -44,6 +45,10 @@ type PostgresCDCSource struct {
 	slot                   string
 	publication            string
 	commitLock             *pglogrepl.BeginMessage
+	// set when a streamed transaction committed, its buffered changes are yet to be added to the batch
+	streamCommit *pglogrepl.StreamCommitMessageV2
+	// tables of the last truncate message, a single message can truncate many tables
+	truncatedTables []truncatedTable
 
 	// for partitioned tables, maps child relid to parent relid
 	childToParentRelIDMapping map[uint32]uint32
@@ -58,6 +63,12 @@ type PostgresCDCSource struct {
 	internalVersion                          uint32
 }
 
+type truncatedTable struct {
+	srcTableName string
+	dstTableName string
+	baseRecord   model.BaseRecord
+}
+
 type PostgresCDCConfig struct {
 	CatalogPool                              shared.CatalogPool
 	OtelManager                              *otel_metrics.OtelManager
@@ -92,7 +103,7 @@ func (c *PostgresConnector) NewPostgresCDCSource(ctx context.Context, cdcConfig
 		srcTableIDNameMapping:                    cdcConfig.SrcTableIDNameMapping,
 		schemaNameForRelID:                       schemaNameForRelID,
 		tableNameMapping:                         cdcConfig.TableNameMapping,
-		tableNameSchemaMapping:                   cdcConfig.TableNameSchemaMapping,
+		tableNameSchemaMapping:                   maps.Clone(cdcConfig.TableNameSchemaMapping),
 		relationMessageMapping:                   cdcConfig.RelationMessageMapping,
 		slot:                                     cdcConfig.Slot,
 		publication:                              cdcConfig.Publication,
@@ -451,6 +462,133 @@ func PullCdcRecords[Items model.Items](
 		return nil
 	}
 
+	// handleRecord adds a change to the batch, backfilling unchanged toast columns and deleted rows from the cdc store
+	handleRecord := func(rec model.Record[Items]) error {
+		if err := utils.DropChunkRows(p.replState.IncrementalSnapshots, rec, req.TableNameSchemaMapping); err != nil {
+			return err
+		}
+		tableName := rec.GetDestinationTableName()
+		switch r := rec.(type) {
+		case *model.UpdateRecord[Items]:
+			// tableName here is destination tableName.
+			// should be ideally sourceTableName as we are in PullRecords.
+			// will change in future
+			// TODO: replident is cached here, should not cache since it can change
+			isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
+			if isFullReplica {
+				if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
+					return err
+				}
+			} else {
+				tablePkeyVal, err := model.RecToTablePKey(req.TableNameSchemaMapping, rec)
+				if err != nil {
+					return err
+				}
+
+				latestRecord, ok, err := cdcRecordsStorage.Get(tablePkeyVal)
+				if err != nil {
+					return err
+				}
+				if ok {
+					// iterate through unchanged toast cols and set them in new record
+					updatedCols := r.NewItems.UpdateIfNotExists(latestRecord.GetItems())
+					for _, col := range updatedCols {
+						delete(r.UnchangedToastColumns, col)
+					}
+				}
+				if err := addRecordWithKey(tablePkeyVal, rec); err != nil {
+					return err
+				}
+			}
+
+		case *model.InsertRecord[Items]:
+			isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
+			if isFullReplica {
+				if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
+					return err
+				}
+			} else {
+				tablePkeyVal, err := model.RecToTablePKey(req.TableNameSchemaMapping, rec)
+				if err != nil {
+					return err
+				}
+
+				if err := addRecordWithKey(tablePkeyVal, rec); err != nil {
+					return err
+				}
+			}
+		case *model.DeleteRecord[Items]:
+			isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
+			if isFullReplica {
+				if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
+					return err
+				}
+			} else {
+				tablePkeyVal, err := model.RecToTablePKey(req.TableNameSchemaMapping, rec)
+				if err != nil {
+					return err
+				}
+
+				latestRecord, ok, err := cdcRecordsStorage.Get(tablePkeyVal)
+				if err != nil {
+					return err
+				}
+				if ok {
+					r.Items = latestRecord.GetItems()
+					if updateRecord, ok := latestRecord.(*model.UpdateRecord[Items]); ok {
+						r.UnchangedToastColumns = updateRecord.UnchangedToastColumns
+					}
+				} else {
+					// there is nothing to backfill the items in the delete record with,
+					// so don't update the row with this record
+					// add sentinel value to prevent update statements from selecting
+					r.UnchangedToastColumns = map[string]struct{}{
+						"_peerdb_not_backfilled_delete": {},
+					}
+				}
+
+				// A delete can only be followed by an INSERT, which does not need backfilling
+				// No need to store DeleteRecords in memory or disk.
+				if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
+					return err
+				}
+			}
+
+		case *model.TruncateRecord[Items]:
+			if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
+				return err
+			}
+
+		case *model.RelationRecord[Items]:
+			tableSchemaDelta := r.TableSchemaDelta
+			if len(tableSchemaDelta.AddedColumns) > 0 || len(tableSchemaDelta.DroppedColumns) > 0 ||
+				len(tableSchemaDelta.AlteredColumns) > 0 {
+				logger.Info(fmt.Sprintf("Detected schema change for table %s, addedColumns: %v, droppedColumns: %v, alteredColumns: %v",
+					tableSchemaDelta.SrcTableName, tableSchemaDelta.AddedColumns,
+					tableSchemaDelta.DroppedColumns, tableSchemaDelta.AlteredColumns))
+				records.AddSchemaDelta(req.TableNameMapping, tableSchemaDelta)
+				// destinations encoding rows with a schema of their own, like kafka, apply it ahead of the rows that follow
+				if err := records.AddRecord(ctx, rec); err != nil {
+					return err
+				}
+			}
+
+		case *model.MessageRecord[Items]:
+			// if cdc store empty, we can move lsn,
+			// otherwise push to records so destination can ack once all previous messages processed
+			if cdcRecordsStorage.IsEmpty() {
+				if int64(clientXLogPos) > req.ConsumedOffset.Load() {
+					if err := p.updateConsumedOffset(ctx, logger, req.FlowJobName, req.ConsumedOffset, clientXLogPos); err != nil {
+						return err
+					}
+				}
+			} else if err := records.AddRecord(ctx, rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	pkmRequiresResponse := false
 	waitingForCommit := false
 
@@ -459,7 +597,17 @@ func PullCdcRecords[Items model.Items](
 		logger.Error("failed to get PeerDBPKMEmptyBatchThrottleThresholdSeconds", slog.Any("error", err))
 	}
 	lastEmptyBatchPkmSentTime := time.Now()
+	var snapshotChunkSize uint32
+	if p.replState.IncrementalSnapshots != nil {
+		if snapshotChunkSize, err = internal.PeerDBIncrementalSnapshotChunkSize(ctx, req.Env); err != nil {
+			return fmt.Errorf("failed to get setting for incremental snapshot chunk size: %w", err)
+		}
+	}
 	for {
+		if err := startSnapshotChunk(ctx, p, req, processor, handleRecord, int(snapshotChunkSize)); err != nil {
+			return err
+		}
+
 		if pkmRequiresResponse {
 			if cdcRecordsStorage.IsEmpty() && int64(clientXLogPos) > req.ConsumedOffset.Load() {
 				err := p.updateConsumedOffset(ctx, logger, req.FlowJobName, req.ConsumedOffset, clientXLogPos)
@@ -579,115 +727,44 @@ func PullCdcRecords[Items model.Items](
 					clientXLogPos = xld.WALStart
 				}
 
-				if rec != nil {
-					tableName := rec.GetDestinationTableName()
-					switch r := rec.(type) {
-					case *model.UpdateRecord[Items]:
-						// tableName here is destination tableName.
-						// should be ideally sourceTableName as we are in PullRecords.
-						// will change in future
-						// TODO: replident is cached here, should not cache since it can change
-						isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
-						if isFullReplica {
-							if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
-								return err
-							}
-						} else {
-							tablePkeyVal, err := model.RecToTablePKey(req.TableNameSchemaMapping, rec)
-							if err != nil {
-								return err
-							}
-
-							latestRecord, ok, err := cdcRecordsStorage.Get(tablePkeyVal)
-							if err != nil {
-								return err
-							}
-							if ok {
-								// iterate through unchanged toast cols and set them in new record
-								updatedCols := r.NewItems.UpdateIfNotExists(latestRecord.GetItems())
-								for _, col := range updatedCols {
-									delete(r.UnchangedToastColumns, col)
-								}
-							}
-							if err := addRecordWithKey(tablePkeyVal, rec); err != nil {
-								return err
-							}
+				if msg, ok := rec.(*model.MessageRecord[Items]); ok && msg.Prefix == incrementalSnapshotMessagePrefix {
+					for _, chunkRec := range handleSnapshotWatermark(p.replState.IncrementalSnapshots, msg) {
+						if err := handleRecord(chunkRec); err != nil {
+							return err
 						}
+					}
+				} else if rec != nil {
+					if err := handleRecord(rec); err != nil {
+						return err
+					}
+				}
 
-					case *model.InsertRecord[Items]:
-						isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
-						if isFullReplica {
-							if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
-								return err
-							}
-						} else {
-							tablePkeyVal, err := model.RecToTablePKey(req.TableNameSchemaMapping, rec)
-							if err != nil {
-								return err
-							}
-
-							if err := addRecordWithKey(tablePkeyVal, rec); err != nil {
-								return err
-							}
-						}
-					case *model.DeleteRecord[Items]:
-						isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
-						if isFullReplica {
-							if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
-								return err
-							}
-						} else {
-							tablePkeyVal, err := model.RecToTablePKey(req.TableNameSchemaMapping, rec)
-							if err != nil {
-								return err
-							}
-
-							latestRecord, ok, err := cdcRecordsStorage.Get(tablePkeyVal)
-							if err != nil {
-								return err
-							}
-							if ok {
-								r.Items = latestRecord.GetItems()
-								if updateRecord, ok := latestRecord.(*model.UpdateRecord[Items]); ok {
-									r.UnchangedToastColumns = updateRecord.UnchangedToastColumns
-								}
-							} else {
-								// there is nothing to backfill the items in the delete record with,
-								// so don't update the row with this record
-								// add sentinel value to prevent update statements from selecting
-								r.UnchangedToastColumns = map[string]struct{}{
-									"_peerdb_not_backfilled_delete": {},
-								}
-							}
-
-							// A delete can only be followed by an INSERT, which does not need backfilling
-							// No need to store DeleteRecords in memory or disk.
-							if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
-								return err
-							}
-						}
+				for _, truncated := range p.truncatedTables {
+					if err := handleRecord(&model.TruncateRecord[Items]{
+						BaseRecord:           truncated.baseRecord,
+						SourceTableName:      truncated.srcTableName,
+						DestinationTableName: truncated.dstTableName,
+					}); err != nil {
+						return err
+					}
+				}
+				p.truncatedTables = p.truncatedTables[:0]
 
-					case *model.RelationRecord[Items]:
-						tableSchemaDelta := r.TableSchemaDelta
-						if len(tableSchemaDelta.AddedColumns) > 0 {
-							logger.Info(fmt.Sprintf("Detected schema change for table %s, addedColumns: %v",
-								tableSchemaDelta.SrcTableName, tableSchemaDelta.AddedColumns))
-							records.AddSchemaDelta(req.TableNameMapping, tableSchemaDelta)
+				if commit := p.streamCommit; commit != nil {
+					p.streamCommit = nil
+					for rec, err := range utils.TxnRecords[Items](p.replState.TxnStore, commit.Xid) {
+						if err != nil {
+							return err
 						}
-
-					case *model.MessageRecord[Items]:
-						// if cdc store empty, we can move lsn,
-						// otherwise push to records so destination can ack once all previous messages processed
-						if cdcRecordsStorage.IsEmpty() {
-							if int64(clientXLogPos) > req.ConsumedOffset.Load() {
-								if err := p.updateConsumedOffset(ctx, logger, req.FlowJobName, req.ConsumedOffset, clientXLogPos); err != nil {
-									return err
-								}
-							}
-						} else if err := records.AddRecord(ctx, rec); err != nil {
+						setCommitTime(rec, commit.CommitTime)
+						if err := handleRecord(rec); err != nil {
 							return err
 						}
 					}
+					if err := p.replState.TxnStore.Discard(commit.Xid); err != nil {
+						return err
+					}
+					records.UpdateLatestCheckpointID(int64(commit.CommitLSN))
 				}
 			}
 		}
@@ -730,7 +807,13 @@ func processMessage[Items model.Items](
 	processor replProcessor[Items],
 ) (model.Record[Items], error) {
 	logger := internal.LoggerFromCtx(ctx)
-	logicalMsg, err := pglogrepl.Parse(xld.WALData)
+	var logicalMsg pglogrepl.Message
+	var err error
+	if p.replState.Streaming {
+		logicalMsg, err = pglogrepl.ParseV2(xld.WALData, p.replState.InStream)
+	} else {
+		logicalMsg, err = pglogrepl.Parse(xld.WALData)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error parsing logical message: %w", err)
 	}
@@ -739,16 +822,82 @@ func processMessage[Items model.Items](
 		return nil, err
 	}
 
+	// protocol version 2 wraps changes to carry the xid of the (sub)transaction they belong to while streaming
+	var subXid uint32
 	switch msg := logicalMsg.(type) {
+	case *pglogrepl.InsertMessageV2:
+		logicalMsg, subXid = &msg.InsertMessage, msg.Xid
+	case *pglogrepl.UpdateMessageV2:
+		logicalMsg, subXid = &msg.UpdateMessage, msg.Xid
+	case *pglogrepl.DeleteMessageV2:
+		logicalMsg, subXid = &msg.DeleteMessage, msg.Xid
+	case *pglogrepl.LogicalDecodingMessageV2:
+		logicalMsg, subXid = &msg.LogicalDecodingMessage, msg.Xid
+	case *pglogrepl.RelationMessageV2:
+		logicalMsg = &msg.RelationMessage
+	case *pglogrepl.TypeMessageV2:
+		logicalMsg = &msg.TypeMessage
+	case *pglogrepl.TruncateMessageV2:
+		logicalMsg, subXid = &msg.TruncateMessage, msg.Xid
+	}
+
+	if p.replState.SkipOrigin && p.inOriginTxn() {
+		switch msg := logicalMsg.(type) {
+		case *pglogrepl.InsertMessage, *pglogrepl.UpdateMessage, *pglogrepl.DeleteMessage, *pglogrepl.TruncateMessage:
+			return nil, nil
+		case *pglogrepl.LogicalDecodingMessage:
+			if msg.Transactional {
+				return nil, nil
+			}
+		}
+	}
+
+	switch msg := logicalMsg.(type) {
+	case *pglogrepl.StreamStartMessageV2:
+		logger.Debug("StreamStartMessage", slog.Uint64("XID", uint64(msg.Xid)), slog.Bool("FirstSegment", msg.FirstSegment == 1))
+		p.replState.InStream = true
+		p.replState.StreamXid = msg.Xid
+	case *pglogrepl.StreamStopMessageV2:
+		logger.Debug("StreamStopMessage", slog.Uint64("XID", uint64(p.replState.StreamXid)))
+		p.replState.InStream = false
+	case *pglogrepl.StreamAbortMessageV2:
+		logger.Debug("StreamAbortMessage", slog.Uint64("XID", uint64(msg.Xid)), slog.Uint64("SubXID", uint64(msg.SubXid)))
+		if msg.SubXid == msg.Xid {
+			delete(p.replState.OriginStreamXids, msg.Xid)
+			return nil, p.replState.TxnStore.Discard(msg.Xid)
+		}
+		return nil, p.replState.TxnStore.AbortSubTxn(msg.Xid, msg.SubXid)
+	case *pglogrepl.StreamCommitMessageV2:
+		// buffered changes are added to the batch by the caller, followed by the checkpoint update
+		logger.Debug("StreamCommitMessage",
+			slog.Uint64("XID", uint64(msg.Xid)),
+			slog.Any("CommitLSN", msg.CommitLSN),
+			slog.Any("TransactionEndLSN", msg.TransactionEndLSN))
+		p.otelManager.Metrics.CommitLagGauge.Record(ctx, time.Now().UTC().Sub(msg.CommitTime).Microseconds())
+		p.streamCommit = msg
+		delete(p.replState.OriginStreamXids, msg.Xid)
 	case *pglogrepl.BeginMessage:
 		logger.Debug("BeginMessage", slog.Any("FinalLSN", msg.FinalLSN), slog.Uint64("XID", uint64(msg.Xid)))
 		p.commitLock = msg
+		p.replState.InOriginTxn = false
+	case *pglogrepl.OriginMessage:
+		logger.Debug("OriginMessage", slog.String("Name", msg.Name), slog.Any("CommitLSN", msg.CommitLSN))
+		if p.replState.SkipOrigin {
+			if p.replState.InStream {
+				p.replState.OriginStreamXids[p.replState.StreamXid] = struct{}{}
+			} else {
+				p.replState.InOriginTxn = true
+			}
+		}
 	case *pglogrepl.InsertMessage:
-		return processInsertMessage(p, xld.WALStart, msg, processor, customTypeMapping)
+		rec, err := processInsertMessage(p, xld.WALStart, msg, processor, customTypeMapping)
+		return bufferStreamedRecord(p, subXid, rec, err)
 	case *pglogrepl.UpdateMessage:
-		return processUpdateMessage(p, xld.WALStart, msg, processor, customTypeMapping)
+		rec, err := processUpdateMessage(p, xld.WALStart, msg, processor, customTypeMapping)
+		return bufferStreamedRecord(p, subXid, rec, err)
 	case *pglogrepl.DeleteMessage:
-		return processDeleteMessage(p, xld.WALStart, msg, processor, customTypeMapping)
+		rec, err := processDeleteMessage(p, xld.WALStart, msg, processor, customTypeMapping)
+		return bufferStreamedRecord(p, subXid, rec, err)
 	case *pglogrepl.CommitMessage:
 		// for a commit message, update the last checkpoint id for the record batch.
 		logger.Debug("CommitMessage",
@@ -757,6 +906,9 @@ func processMessage[Items model.Items](
 		batch.UpdateLatestCheckpointID(int64(msg.CommitLSN))
 		p.otelManager.Metrics.CommitLagGauge.Record(ctx, time.Now().UTC().Sub(msg.CommitTime).Microseconds())
 		p.commitLock = nil
+		p.replState.InOriginTxn = false
+	case *pglogrepl.TruncateMessage:
+		return nil, processTruncateMessage[Items](p, xld.WALStart, subXid, msg)
 	case *pglogrepl.RelationMessage:
 		// treat all relation messages as corresponding to parent if partitioned.
 		msg.RelationID, err = p.checkIfUnknownTableInherits(ctx, msg.RelationID)
@@ -783,11 +935,11 @@ func processMessage[Items model.Items](
 		if !msg.Transactional {
 			batch.UpdateLatestCheckpointID(int64(msg.LSN))
 		}
-		return &model.MessageRecord[Items]{
+		return bufferStreamedRecord[Items](p, subXid, &model.MessageRecord[Items]{
 			BaseRecord: p.baseRecord(msg.LSN),
 			Prefix:     msg.Prefix,
 			Content:    string(msg.Content),
-		}, nil
+		}, nil)
 	default:
 		if _, ok := p.hushWarnUnhandledMessageType[msg.Type()]; !ok {
 			logger.Warn(fmt.Sprintf("Unhandled message type: %T", msg))
@@ -798,6 +950,91 @@ func processMessage[Items model.Items](
 	return nil, nil
 }
 
+// inOriginTxn is true for changes of a transaction written under a replication origin,
+// which another mirror replicated here and must not be replicated back
+func (p *PostgresCDCSource) inOriginTxn() bool {
+	if p.replState.InStream {
+		_, ok := p.replState.OriginStreamXids[p.replState.StreamXid]
+		return ok
+	}
+	return p.replState.InOriginTxn
+}
+
+// bufferStreamedRecord holds back changes of a transaction being streamed until it commits
+func bufferStreamedRecord[Items model.Items](
+	p *PostgresCDCSource,
+	subXid uint32,
+	rec model.Record[Items],
+	err error,
+) (model.Record[Items], error) {
+	if err != nil || rec == nil || !p.replState.InStream {
+		return rec, err
+	}
+	if err := utils.AddTxnRecord(p.replState.TxnStore, p.replState.StreamXid, subXid, rec); err != nil {
+		return nil, fmt.Errorf("failed to buffer change of streamed transaction %d: %w", p.replState.StreamXid, err)
+	}
+	return nil, nil
+}
+
+// setCommitTime sets the commit time of a buffered change, which is only known once its transaction commits
+func setCommitTime[Items model.Items](rec model.Record[Items], commitTime time.Time) {
+	switch r := rec.(type) {
+	case *model.InsertRecord[Items]:
+		r.CommitTimeNano = commitTime.UnixNano()
+	case *model.UpdateRecord[Items]:
+		r.CommitTimeNano = commitTime.UnixNano()
+	case *model.DeleteRecord[Items]:
+		r.CommitTimeNano = commitTime.UnixNano()
+	case *model.TruncateRecord[Items]:
+		r.CommitTimeNano = commitTime.UnixNano()
+	case *model.MessageRecord[Items]:
+		r.CommitTimeNano = commitTime.UnixNano()
+	}
+}
+
+// processTruncateMessage collects the replicated tables truncated by msg,
+// they are added to the batch by the caller unless the transaction is being streamed
+func processTruncateMessage[Items model.Items](
+	p *PostgresCDCSource,
+	lsn pglogrepl.LSN,
+	subXid uint32,
+	msg *pglogrepl.TruncateMessage,
+) error {
+	for _, relID := range msg.RelationIDs {
+		if parentRelID, ok := p.childToParentRelIDMapping[relID]; ok {
+			// truncating a partition only removes part of the rows of the replicated table
+			p.logger.Warn("ignoring truncate of child table",
+				slog.Uint64("childRelID", uint64(relID)),
+				slog.String("parentTableName", p.srcTableIDNameMapping[parentRelID]))
+			continue
+		}
+		tableName, exists := p.srcTableIDNameMapping[relID]
+		if !exists {
+			continue
+		}
+
+		p.logger.Info("TruncateMessage", slog.Any("LSN", lsn), slog.Uint64("RelationID", uint64(relID)), slog.String("Relation Name", tableName))
+
+		truncated := truncatedTable{
+			srcTableName: tableName,
+			dstTableName: p.tableNameMapping[tableName].Name,
+			baseRecord:   p.baseRecord(lsn),
+		}
+		if p.replState.InStream {
+			if err := utils.AddTxnRecord[Items](p.replState.TxnStore, p.replState.StreamXid, subXid, &model.TruncateRecord[Items]{
+				BaseRecord:           truncated.baseRecord,
+				SourceTableName:      truncated.srcTableName,
+				DestinationTableName: truncated.dstTableName,
+			}); err != nil {
+				return fmt.Errorf("failed to buffer truncate of streamed transaction %d: %w", p.replState.StreamXid, err)
+			}
+		} else {
+			p.truncatedTables = append(p.truncatedTables, truncated)
+		}
+	}
+	return nil
+}
+
 func processInsertMessage[Items model.Items](
 	p *PostgresCDCSource,
 	lsn pglogrepl.LSN,
@@ -1041,17 +1278,56 @@ func processRelationMessage[Items model.Items](
 					column.Name, schemaDelta.SrcTableName))
 			}
 			// present in previous and current relation messages, but data types have changed.
-			// so we add it to AddedColumns and DroppedColumns, knowing that we process DroppedColumns first.
 		} else if prevRelMap[column.Name] != currRelMap[column.Name] {
-			p.logger.Warn(fmt.Sprintf("Detected column %s with type changed from %s to %s in table %s, but not propagating",
-				column.Name, prevRelMap[column.Name], currRelMap[column.Name], schemaDelta.SrcTableName))
+			prevColumnIdx := slices.IndexFunc(prevSchema.Columns, func(fd *protos.FieldDescription) bool {
+				return fd.Name == column.Name
+			})
+			prevColumn := prevSchema.Columns[prevColumnIdx]
+			schemaDelta.AlteredColumns = append(schemaDelta.AlteredColumns, &protos.AlteredColumn{
+				Previous: prevColumn,
+				Current: &protos.FieldDescription{
+					Name:         column.Name,
+					Type:         currRelMap[column.Name],
+					TypeModifier: column.TypeModifier,
+					Nullable:     prevColumn.Nullable,
+				},
+			})
+			p.logger.Info("Detected altered column",
+				slog.String("columnName", column.Name),
+				slog.String("previousType", prevRelMap[column.Name]),
+				slog.String("columnType", currRelMap[column.Name]),
+				slog.String("relationName", schemaDelta.SrcTableName))
 		}
 	}
+	var missingColumns []*protos.FieldDescription
 	for _, column := range prevSchema.Columns {
 		// present in previous relation message, but not in current one, so dropped.
 		if _, ok := currRelMap[column.Name]; !ok {
-			p.logger.Warn(fmt.Sprintf("Detected dropped column %s in table %s, but not propagating", column,
-				schemaDelta.SrcTableName))
+			missingColumns = append(missingColumns, column)
+		}
+	}
+	if len(missingColumns) > 0 {
+		// pgoutput leaves generated columns out of relation messages before Postgres 18,
+		// the schema read from the table has them, so their absence is no drop
+		rows, err := p.conn.Query(ctx,
+			"select attname from pg_attribute where attrelid=$1 and attgenerated <> '' and not attisdropped",
+			currRel.RelationID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error looking up generated columns for schema change: %w", err)
+		}
+		generatedColumns, err := pgx.CollectRows[string](rows, pgx.RowTo)
+		if err != nil {
+			return nil, fmt.Errorf("error collecting rows for generated columns for schema change: %w", err)
+		}
+		for _, column := range missingColumns {
+			if slices.Contains(generatedColumns, column.Name) {
+				continue
+			}
+			schemaDelta.DroppedColumns = append(schemaDelta.DroppedColumns, column)
+			p.logger.Info("Detected dropped column",
+				slog.String("columnName", column.Name),
+				slog.String("relationName", schemaDelta.SrcTableName))
 		}
 	}
 	if len(potentiallyNullableAddedColumns) > 0 {
@@ -1086,7 +1362,9 @@ func processRelationMessage[Items model.Items](
 
 	p.relationMessageMapping[currRel.RelationID] = currRel
 	// only log audit if there is actionable delta
-	if len(schemaDelta.AddedColumns) > 0 {
+	if len(schemaDelta.AddedColumns) > 0 || len(schemaDelta.DroppedColumns) > 0 || len(schemaDelta.AlteredColumns) > 0 {
+		// later relation messages of this batch are diffed against the changed schema
+		p.tableNameSchemaMapping[currRelDstInfo.Name] = applySchemaDelta(prevSchema, schemaDelta)
 		return &model.RelationRecord[Items]{
 			BaseRecord:       p.baseRecord(lsn),
 			TableSchemaDelta: schemaDelta,
@@ -1095,6 +1373,27 @@ func processRelationMessage[Items model.Items](
 	return nil, nil
 }
 
+// applySchemaDelta returns a copy of schema with the columns of delta added, retyped and removed
+func applySchemaDelta(schema *protos.TableSchema, delta *protos.TableSchemaDelta) *protos.TableSchema {
+	updated := proto.CloneOf(schema)
+	for _, column := range delta.DroppedColumns {
+		updated.Columns = slices.DeleteFunc(updated.Columns, func(fd *protos.FieldDescription) bool {
+			return fd.Name == column.Name
+		})
+	}
+	for _, column := range delta.AlteredColumns {
+		if idx := slices.IndexFunc(updated.Columns, func(fd *protos.FieldDescription) bool {
+			return fd.Name == column.Current.Name
+		}); idx != -1 {
+			updated.Columns[idx] = proto.CloneOf(column.Current)
+		}
+	}
+	for _, column := range delta.AddedColumns {
+		updated.Columns = append(updated.Columns, proto.CloneOf(column))
+	}
+	return updated
+}
+
 // getParentRelIDIfPartitioned checks if the relation ID is a child table
 // and returns the parent relation ID if it is.
 // If the relation ID is not a child table, it returns the original relation ID.
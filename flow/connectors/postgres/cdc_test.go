@@ -0,0 +1,37 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestApplySchemaDelta(t *testing.T) {
+	schema := &protos.TableSchema{
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(types.QValueKindInt32)},
+			{Name: "retyped", Type: string(types.QValueKindInt32)},
+			{Name: "dropped", Type: string(types.QValueKindString)},
+		},
+	}
+	updated := applySchemaDelta(schema, &protos.TableSchemaDelta{
+		AddedColumns:   []*protos.FieldDescription{{Name: "added", Type: string(types.QValueKindBoolean)}},
+		DroppedColumns: []*protos.FieldDescription{{Name: "dropped", Type: string(types.QValueKindString)}},
+		AlteredColumns: []*protos.AlteredColumn{{
+			Previous: &protos.FieldDescription{Name: "retyped", Type: string(types.QValueKindInt32)},
+			Current:  &protos.FieldDescription{Name: "retyped", Type: string(types.QValueKindInt64)},
+		}},
+	})
+
+	var columns []string
+	for _, column := range updated.Columns {
+		columns = append(columns, column.Name+" "+column.Type)
+	}
+	require.Equal(t, []string{"id int32", "retyped int64", "added bool"}, columns)
+	// the cached schema other batches read stays untouched
+	require.Len(t, schema.Columns, 3)
+	require.Equal(t, string(types.QValueKindInt32), schema.Columns[1].Type)
+}
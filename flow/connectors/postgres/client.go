@@ -13,12 +13,14 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
+	"go.temporal.io/sdk/temporal"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	numeric "github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
 )
 
 const (
@@ -30,7 +32,7 @@ const (
 	createRawTableSQL = `CREATE TABLE IF NOT EXISTS %s.%s(_peerdb_uid uuid NOT NULL,
 		_peerdb_timestamp BIGINT NOT NULL,_peerdb_destination_table_name TEXT NOT NULL,_peerdb_data JSONB NOT NULL,
 		_peerdb_record_type INTEGER NOT NULL, _peerdb_match_data JSONB,_peerdb_batch_id INTEGER,
-		_peerdb_unchanged_toast_columns TEXT)`
+		_peerdb_unchanged_toast_columns TEXT,_peerdb_commit_time BIGINT)`
 	createRawTableBatchIDIndexSQL  = "CREATE INDEX IF NOT EXISTS %s_batchid_idx ON %s.%s(_peerdb_batch_id)"
 	createRawTableDstTableIndexSQL = "CREATE INDEX IF NOT EXISTS %s_dst_table_idx ON %s.%s(_peerdb_destination_table_name)"
 
@@ -51,17 +53,23 @@ const (
 	getTableNameToUnchangedToastColsSQL = `SELECT _peerdb_destination_table_name,
 	ARRAY_AGG(DISTINCT _peerdb_unchanged_toast_columns) FROM %s.%s WHERE
 	_peerdb_batch_id>$1 AND _peerdb_batch_id<=$2 AND _peerdb_record_type!=2 GROUP BY _peerdb_destination_table_name`
+	getLatestTruncateTimestampSQL = `SELECT max(_peerdb_timestamp) FROM %s.%s WHERE
+	_peerdb_batch_id>$1 AND _peerdb_batch_id<=$2 AND _peerdb_destination_table_name=$3 AND _peerdb_record_type=3`
+	deleteTruncatedRawRowsSQL = `DELETE FROM %s.%s WHERE
+	_peerdb_batch_id>$1 AND _peerdb_batch_id<=$2 AND _peerdb_destination_table_name=$3 AND _peerdb_timestamp<=$4`
+	deleteTruncateMarkersSQL = `DELETE FROM %s.%s WHERE
+	_peerdb_batch_id>$1 AND _peerdb_batch_id<=$2 AND _peerdb_destination_table_name=$3 AND _peerdb_record_type=3`
 	mergeStatementSQL = `WITH src_rank AS (
-		SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,
-		RANK() OVER (PARTITION BY %s ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank
-		FROM %s.%s WHERE _peerdb_batch_id>$1 AND _peerdb_batch_id<=$2 AND _peerdb_destination_table_name=$3
+		SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns%[1]s,
+		RANK() OVER (PARTITION BY %[2]s ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank
+		FROM %[3]s.%[4]s WHERE _peerdb_batch_id>$1 AND _peerdb_batch_id<=$2 AND _peerdb_destination_table_name=$3
 	)
-	MERGE INTO %s dst
-	USING (SELECT %s,_peerdb_record_type,_peerdb_unchanged_toast_columns FROM src_rank WHERE _peerdb_rank=1) src
-	ON %s
+	MERGE INTO %[5]s dst
+	USING (SELECT %[6]s,_peerdb_record_type,_peerdb_unchanged_toast_columns%[1]s FROM src_rank WHERE _peerdb_rank=1) src
+	ON %[7]s
 	WHEN NOT MATCHED AND src._peerdb_record_type!=2 THEN
-	INSERT (%s) VALUES (%s) %s
-	WHEN MATCHED AND src._peerdb_record_type=2 THEN %s`
+	INSERT (%[8]s) VALUES (%[9]s) %[10]s
+	WHEN MATCHED AND src._peerdb_record_type=2%[11]s THEN %[12]s`
 	fallbackUpsertStatementSQL = `WITH src_rank AS (
 		SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,
 		RANK() OVER (PARTITION BY %s ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank
@@ -82,6 +90,12 @@ const (
 	 AND application_name LIKE 'peerdb%' AND client_addr IS NOT NULL`
 	getNumReplicationConnections = `select COUNT(*) from pg_stat_replication WHERE usename = $1
 	 AND application_name LIKE 'peerdb%' AND client_addr IS NOT NULL`
+
+	createReplicationOriginSQL = "SELECT pg_replication_origin_create($1) WHERE pg_replication_origin_oid($1) IS NULL"
+	dropReplicationOriginSQL   = "SELECT pg_replication_origin_drop($1) WHERE pg_replication_origin_oid($1) IS NOT NULL"
+	// commit timestamp of normalize transactions, see normalizeStmtGenerator.lastWriterWins
+	setupOriginCommitTimeSQL = `SELECT pg_replication_origin_xact_setup('0/0',to_timestamp(max(_peerdb_commit_time)/1e9))
+	FROM %s.%s WHERE _peerdb_batch_id>$1 AND _peerdb_batch_id<=$2 HAVING max(_peerdb_commit_time) IS NOT NULL`
 )
 
 type (
@@ -242,12 +256,22 @@ func (c *PostgresConnector) checkSlotAndPublication(ctx context.Context, slot st
 	slotExists := false
 	publicationExists := false
 
-	// Check if the replication slot exists
-	var slotName pgtype.Text
-	err := c.conn.QueryRow(ctx,
-		"SELECT slot_name FROM pg_replication_slots WHERE slot_name = $1",
-		slot).Scan(&slotName)
+	pgversion, err := c.MajorVersion(ctx)
 	if err != nil {
+		return SlotCheckResult{}, fmt.Errorf("error getting PG version: %w", err)
+	}
+	// failover slots were added in Postgres 17
+	slotColumns := "false,false"
+	if pgversion >= shared.POSTGRES_17 {
+		slotColumns = "failover,synced"
+	}
+
+	// Check if the replication slot exists
+	var slotFailover bool
+	var slotSynced bool
+	if err := c.conn.QueryRow(ctx,
+		"SELECT "+slotColumns+" FROM pg_replication_slots WHERE slot_name = $1",
+		slot).Scan(&slotFailover, &slotSynced); err != nil {
 		// check if the error is a "no rows" error
 		if !errors.Is(err, pgx.ErrNoRows) {
 			return SlotCheckResult{}, fmt.Errorf("error checking for replication slot - %s: %w", slot, err)
@@ -272,6 +296,8 @@ func (c *PostgresConnector) checkSlotAndPublication(ctx context.Context, slot st
 	return SlotCheckResult{
 		SlotExists:        slotExists,
 		PublicationExists: publicationExists,
+		SlotFailover:      slotFailover,
+		SlotSynced:        slotSynced,
 	}, nil
 }
 
@@ -334,12 +360,14 @@ func (c *PostgresConnector) GetSlotInfo(ctx context.Context, slotName string) ([
 	return getSlotInfo(ctx, c.conn, slotName, c.Config.Database)
 }
 
+// CreatePublication creates publication for srcTableNames,
+// rowFilters keyed by quoted table name are rendered into publication row filters on Postgres 15+
 func (c *PostgresConnector) CreatePublication(
 	ctx context.Context,
 	srcTableNames []string,
+	rowFilters map[string]string,
 	publication string,
 ) error {
-	tableNameString := strings.Join(srcTableNames, ", ")
 	// check and enable publish_via_partition_root
 	pgversion, err := c.MajorVersion(ctx)
 	if err != nil {
@@ -349,6 +377,27 @@ func (c *PostgresConnector) CreatePublication(
 	if pgversion >= shared.POSTGRES_13 {
 		pubViaRootString = " WITH(publish_via_partition_root=true)"
 	}
+	tables := srcTableNames
+	if len(rowFilters) > 0 {
+		if pgversion < shared.POSTGRES_15 {
+			// syncs fall back to filtering changes after decoding, see RowFilteredTables
+			c.logger.Info("[publication-creation] row filters need Postgres 15, not adding them to publication",
+				slog.String("publication", publication))
+		} else {
+			tables = make([]string, 0, len(srcTableNames))
+			for _, table := range srcTableNames {
+				if rowFilter, ok := rowFilters[table]; ok {
+					rowFilterSQL, err := utils.RowFilterSQL(rowFilter, protos.DBType_POSTGRES)
+					if err != nil {
+						return exceptions.NewPostgresSetupError(err)
+					}
+					table += " WHERE (" + rowFilterSQL + ")"
+				}
+				tables = append(tables, table)
+			}
+		}
+	}
+	tableNameString := strings.Join(tables, ", ")
 	// Create the publication to help filter changes only for the given tables
 	stmt := fmt.Sprintf("CREATE PUBLICATION %s FOR TABLE %s%s", publication, tableNameString, pubViaRootString)
 	if _, err = c.execWithLogging(ctx, stmt); err != nil {
@@ -365,6 +414,7 @@ func (c *PostgresConnector) createSlotAndPublication(
 	slot string,
 	publication string,
 	tableNameMapping map[string]model.NameAndExclude,
+	rowFilters map[string]string,
 	doInitialCopy bool,
 	skipSnapshotExport bool,
 ) (model.SetupReplicationResult, error) {
@@ -372,14 +422,18 @@ func (c *PostgresConnector) createSlotAndPublication(
 	// expecting tablenames to be schema qualified
 	if !s.PublicationExists {
 		srcTableNames := make([]string, 0, len(tableNameMapping))
+		quotedRowFilters := make(map[string]string, len(rowFilters))
 		for srcTableName := range tableNameMapping {
 			parsedSrcTableName, err := utils.ParseSchemaTable(srcTableName)
 			if err != nil {
 				return model.SetupReplicationResult{}, fmt.Errorf("[publication-creation] source table identifier %s is invalid", srcTableName)
 			}
 			srcTableNames = append(srcTableNames, parsedSrcTableName.String())
+			if rowFilter, ok := rowFilters[srcTableName]; ok {
+				quotedRowFilters[parsedSrcTableName.String()] = rowFilter
+			}
 		}
-		if err := c.CreatePublication(ctx, srcTableNames, publication); err != nil {
+		if err := c.CreatePublication(ctx, srcTableNames, quotedRowFilters, publication); err != nil {
 			return model.SetupReplicationResult{}, err
 		}
 	}
@@ -408,12 +462,23 @@ func (c *PostgresConnector) createSlotAndPublication(
 			return model.SetupReplicationResult{}, fmt.Errorf("[slot] error getting PG version: %w", err)
 		}
 
+		inRecovery, err := c.isInRecovery(ctx)
+		if err != nil {
+			conn.Close(ctx)
+			return model.SetupReplicationResult{}, fmt.Errorf("[slot] error checking if Postgres is in recovery: %w", err)
+		}
+
 		c.logger.Info(fmt.Sprintf("Creating replication slot '%s'", slot))
-		opts := pglogrepl.CreateReplicationSlotOptions{
-			Temporary: false,
-			Mode:      pglogrepl.LogicalReplication,
+		if inRecovery {
+			// a slot on a standby waits for the primary to log running transactions,
+			// running SELECT pg_log_standby_snapshot() on the primary avoids waiting for the next checkpoint
+			c.logger.Info("creating replication slot on standby, waiting for running transactions from primary",
+				slog.String("slot", slot))
 		}
-		res, err := pglogrepl.CreateReplicationSlot(ctx, conn.PgConn(), slot, "pgoutput", opts)
+		// synchronized to standbys with sync_replication_slots, so the slot survives a failover
+		failover := c.Config.FailoverSlots && !inRecovery && pgversion >= shared.POSTGRES_17
+		res, err := pglogrepl.ParseCreateReplicationSlot(
+			conn.PgConn().Exec(ctx, createReplicationSlotCommand(slot, failover)))
 		if err != nil {
 			conn.Close(ctx)
 			return model.SetupReplicationResult{}, fmt.Errorf("[slot] error creating replication slot: %w", err)
@@ -445,6 +510,56 @@ func (c *PostgresConnector) createSlotAndPublication(
 	}
 }
 
+// isInRecovery is true on standbys, which can create and decode from slots since Postgres 16
+func (c *PostgresConnector) isInRecovery(ctx context.Context) (bool, error) {
+	var inRecovery bool
+	if err := c.conn.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		return false, err
+	}
+	return inRecovery, nil
+}
+
+// createReplicationSlotCommand exports a snapshot like pglogrepl.CreateReplicationSlot,
+// failover needs the option list syntax of Postgres 15+ which cannot be combined with the legacy keywords
+func createReplicationSlotCommand(slot string, failover bool) string {
+	if failover {
+		return "CREATE_REPLICATION_SLOT " + utils.QuoteIdentifier(slot) + " LOGICAL pgoutput (SNAPSHOT 'export', FAILOVER true)"
+	}
+	return "CREATE_REPLICATION_SLOT " + utils.QuoteIdentifier(slot) + " LOGICAL pgoutput"
+}
+
+// checkFailoverSlot rejects slots synchronized to a standby that was not promoted yet,
+// on a primary with failover slots enabled existing slots are made failover slots so that they survive the next failover
+func (c *PostgresConnector) checkFailoverSlot(ctx context.Context, slot string, s SlotCheckResult) error {
+	if s.SlotFailover && !s.SlotSynced {
+		return nil
+	}
+	// synced stays true once a standby is promoted, only a standby still in recovery cannot decode from it
+	inRecovery, err := c.isInRecovery(ctx)
+	if err != nil {
+		return fmt.Errorf("error checking if Postgres is in recovery: %w", err)
+	}
+	if inRecovery {
+		if s.SlotSynced {
+			c.logger.Warn("slot is synchronized from primary, standby not promoted yet", slog.String("slot", slot))
+			return temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("replication slot %s is synchronized from the primary, reconnecting until standby is promoted", slot),
+				"disconnect", nil)
+		}
+		return nil
+	}
+	if !s.SlotFailover && c.Config.FailoverSlots {
+		if _, err := c.replConn.PgConn().Exec(ctx,
+			"ALTER_REPLICATION_SLOT "+utils.QuoteIdentifier(slot)+" (FAILOVER true)",
+		).ReadAll(); err != nil {
+			c.logger.Warn("failed to make replication slot a failover slot", slog.String("slot", slot), slog.Any("error", err))
+		} else {
+			c.logger.Info("made replication slot a failover slot", slog.String("slot", slot))
+		}
+	}
+	return nil
+}
+
 func (c *PostgresConnector) createMetadataSchema(ctx context.Context) error {
 	if _, err := c.execWithLogging(ctx,
 		fmt.Sprintf(createSchemaSQL, c.metadataSchema),
@@ -665,6 +780,168 @@ func (c *PostgresConnector) getCurrentLSN(ctx context.Context) (NullableLSN, err
 	return NullableLSN{LSN: lsn}, nil
 }
 
+// RowFilteredTables returns tables of the publication that Postgres filters itself, nil before Postgres 15.
+// rowFilters are row filters of table mappings keyed by source table, a publication filter
+// has to be the same predicate so tables are not filtered differently by syncs and snapshots
+func (c *PostgresConnector) RowFilteredTables(
+	ctx context.Context, flowJobName string, overridePublication string, rowFilters map[string]string,
+) (map[string]struct{}, error) {
+	pgversion, err := c.MajorVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Postgres version: %w", err)
+	}
+	if pgversion < shared.POSTGRES_15 {
+		return nil, nil
+	}
+	publication := overridePublication
+	if publication == "" {
+		publication = c.getDefaultPublicationName(flowJobName)
+	}
+	rows, err := c.conn.Query(ctx,
+		"SELECT schemaname, tablename, rowfilter FROM pg_publication_tables WHERE pubname=$1 AND rowfilter IS NOT NULL", publication)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query row filters of publication %s: %w", publication, err)
+	}
+	// collected first, conn is needed to deparse filters
+	var schemaName, tableName, publicationFilter string
+	var publicationTables [][3]string
+	if _, err := pgx.ForEachRow(rows, []any{&schemaName, &tableName, &publicationFilter}, func() error {
+		publicationTables = append(publicationTables, [3]string{schemaName, tableName, publicationFilter})
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to query row filters of publication %s: %w", publication, err)
+	}
+	tables := make(map[string]struct{}, len(publicationTables))
+	for _, table := range publicationTables {
+		sourceTable := table[0] + "." + table[1]
+		rowFilter, ok := rowFilters[sourceTable]
+		if !ok {
+			return nil, exceptions.NewPostgresSetupError(fmt.Errorf(
+				"publication %s filters table %s with %s but its table mapping has no row filter", publication, sourceTable, table[2]))
+		}
+		expected, err := c.deparseRowFilter(ctx, &utils.SchemaTable{Schema: table[0], Table: table[1]}, rowFilter)
+		if err != nil {
+			return nil, err
+		}
+		if expected != table[2] {
+			return nil, exceptions.NewPostgresSetupError(fmt.Errorf(
+				"publication %s filters table %s with %s but its table mapping filters with %s", publication, sourceTable, table[2], expected))
+		}
+		tables[sourceTable] = struct{}{}
+	}
+	return tables, nil
+}
+
+// ValidateRowFilters checks that row filters keyed by source table are predicates over their tables,
+// Postgres versions before 15 evaluate them in flow workers but snapshots still query with them
+func (c *PostgresConnector) ValidateRowFilters(ctx context.Context, rowFilters map[string]string) error {
+	for sourceTable, rowFilter := range rowFilters {
+		table, err := utils.ParseSchemaTable(sourceTable)
+		if err != nil {
+			return err
+		}
+		if _, err := c.deparseRowFilter(ctx, table, rowFilter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deparseRowFilter prints a row filter of table the way pg_publication_tables does,
+// through a check constraint on a temporary copy of the table that is rolled back
+func (c *PostgresConnector) deparseRowFilter(ctx context.Context, table *utils.SchemaTable, rowFilter string) (string, error) {
+	key := [2]string{table.String(), rowFilter}
+	if deparsed, ok := c.deparsedRowFilters[key]; ok {
+		return deparsed, nil
+	}
+	rowFilterSQL, err := utils.RowFilterSQL(rowFilter, protos.DBType_POSTGRES)
+	if err != nil {
+		return "", exceptions.NewPostgresSetupError(err)
+	}
+	tx, err := c.conn.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction for row filter: %w", err)
+	}
+	defer shared.RollbackTx(tx, c.logger)
+	if _, err := tx.Exec(ctx, "CREATE TEMPORARY TABLE _peerdb_row_filter (LIKE "+table.String()+") ON COMMIT DROP"); err != nil {
+		return "", fmt.Errorf("failed to copy table %s for row filter: %w", table, err)
+	}
+	if _, err := tx.Exec(ctx,
+		"ALTER TABLE pg_temp._peerdb_row_filter ADD CONSTRAINT _peerdb_row_filter CHECK ("+rowFilterSQL+") NOT VALID"); err != nil {
+		return "", exceptions.NewPostgresSetupError(fmt.Errorf("invalid row filter for table %s: %w", table, err))
+	}
+	var deparsed string
+	if err := tx.QueryRow(ctx, "SELECT pg_get_expr(conbin, conrelid) FROM pg_constraint "+
+		"WHERE conrelid='pg_temp._peerdb_row_filter'::regclass AND conname='_peerdb_row_filter'").Scan(&deparsed); err != nil {
+		return "", fmt.Errorf("failed to print row filter for table %s: %w", table, err)
+	}
+	if c.deparsedRowFilters == nil {
+		c.deparsedRowFilters = make(map[[2]string]string)
+	}
+	c.deparsedRowFilters[key] = deparsed
+	return deparsed, nil
+}
+
+// replicationOriginName names the origin that writes of a mirror's sync or normalize are tagged with,
+// they run in separate sessions and an origin can only be set up in one session at a time
+func replicationOriginName(jobName string, stage string) string {
+	return "peerdb_" + stage + "_" + strings.ToLower(shared.ReplaceIllegalCharactersWithUnderscores(jobName))
+}
+
+// setupReplicationOrigin tags transactions of this session with a replication origin,
+// so a mirror replicating from this database in the other direction skips them, see ReplState.SkipOrigin
+func (c *PostgresConnector) setupReplicationOrigin(ctx context.Context, originName string) error {
+	if c.replOrigin == originName {
+		return nil
+	}
+	if c.replOrigin != "" {
+		if _, err := c.conn.Exec(ctx, "SELECT pg_replication_origin_session_reset()"); err != nil {
+			return fmt.Errorf("failed to reset replication origin %s: %w", c.replOrigin, err)
+		}
+		c.replOrigin = ""
+	}
+	if _, err := c.conn.Exec(ctx, createReplicationOriginSQL, originName); err != nil &&
+		!shared.IsSQLStateError(err, pgerrcode.DuplicateObject) {
+		return fmt.Errorf("failed to create replication origin %s: %w", originName, err)
+	}
+	if _, err := c.conn.Exec(ctx, "SELECT pg_replication_origin_session_setup($1)", originName); err != nil {
+		return fmt.Errorf("failed to set up replication origin %s: %w", originName, err)
+	}
+	c.replOrigin = originName
+	return nil
+}
+
+// dropReplicationOrigins drops origins of a bidirectional mirror,
+// without privileges on the origin functions the mirror could not have created any
+func (c *PostgresConnector) dropReplicationOrigins(ctx context.Context, jobName string) {
+	for _, stage := range []string{"sync", "normalize"} {
+		originName := replicationOriginName(jobName, stage)
+		if _, err := c.conn.Exec(ctx, dropReplicationOriginSQL, originName); err != nil &&
+			!shared.IsSQLStateError(err, pgerrcode.InsufficientPrivilege) {
+			c.logger.Warn("failed to drop replication origin", slog.String("origin", originName), slog.Any("error", err))
+		}
+	}
+}
+
+// ensureCommitTimeColumn adds _peerdb_commit_time to raw tables created before it was part of createRawTableSQL
+func (c *PostgresConnector) ensureCommitTimeColumn(ctx context.Context, rawTableIdentifier string) error {
+	var exists bool
+	if err := c.conn.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM information_schema.columns WHERE table_schema=$1 AND table_name=$2 AND column_name='_peerdb_commit_time')",
+		c.metadataSchema, rawTableIdentifier,
+	).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check for commit time column of raw table: %w", err)
+	}
+	if exists {
+		return nil
+	}
+	if _, err := c.execWithLogging(ctx, fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN IF NOT EXISTS _peerdb_commit_time BIGINT",
+		c.metadataSchema, rawTableIdentifier)); err != nil {
+		return fmt.Errorf("failed to add commit time column to raw table: %w", err)
+	}
+	return nil
+}
+
 func (c *PostgresConnector) getDefaultPublicationName(jobName string) string {
 	return "peerflow_pub_" + jobName
 }
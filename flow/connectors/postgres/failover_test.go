@@ -0,0 +1,150 @@
+package connpostgres
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func TestParseConfigWithFailoverHosts(t *testing.T) {
+	pgConfig := &protos.PostgresConfig{
+		Host:          "primary.example.com",
+		Port:          5433,
+		User:          "peerdb",
+		Database:      "db",
+		RequireTls:    true,
+		FailoverHosts: []string{"standby1.example.com", "standby2.example.com:6543"},
+	}
+	connConfig, err := ParseConfig(internal.GetPGConnectionString(pgConfig, ""), pgConfig)
+	require.NoError(t, err)
+	// target_session_attrs=primary validates that the connected server is not in recovery
+	require.NotNil(t, connConfig.ValidateConnect)
+
+	require.Equal(t, "primary.example.com", connConfig.Host)
+	require.Equal(t, "primary.example.com", connConfig.TLSConfig.ServerName)
+	require.Len(t, connConfig.Fallbacks, 2)
+	require.Equal(t, "standby1.example.com", connConfig.Fallbacks[0].Host)
+	require.Equal(t, uint16(5433), connConfig.Fallbacks[0].Port)
+	require.Equal(t, "standby1.example.com", connConfig.Fallbacks[0].TLSConfig.ServerName)
+	require.Equal(t, "standby2.example.com", connConfig.Fallbacks[1].Host)
+	require.Equal(t, uint16(6543), connConfig.Fallbacks[1].Port)
+	require.Equal(t, "standby2.example.com", connConfig.Fallbacks[1].TLSConfig.ServerName)
+}
+
+func TestCreateReplicationSlotCommand(t *testing.T) {
+	require.Equal(t, `CREATE_REPLICATION_SLOT "peerflow_slot_a" LOGICAL pgoutput`,
+		createReplicationSlotCommand("peerflow_slot_a", false))
+	require.Equal(t, `CREATE_REPLICATION_SLOT "peerflow_slot_a" LOGICAL pgoutput (SNAPSHOT 'export', FAILOVER true)`,
+		createReplicationSlotCommand("peerflow_slot_a", true))
+}
+
+func TestIsRetryableConnectError(t *testing.T) {
+	for _, err := range []error{
+		&pgconn.PgError{Code: pgerrcode.CannotConnectNow},
+		&pgconn.PgError{Code: pgerrcode.AdminShutdown},
+		&pgconn.PgError{Code: pgerrcode.ConnectionFailure},
+		&net.OpError{Op: "dial", Err: errors.New("connection refused")},
+	} {
+		require.True(t, isRetryableConnectError(err), err.Error())
+	}
+	for _, err := range []error{
+		&pgconn.PgError{Code: pgerrcode.InvalidPassword},
+		&pgconn.PgError{Code: pgerrcode.InvalidAuthorizationSpecification},
+		&pgconn.PgError{Code: pgerrcode.UndefinedObject},
+		errors.New("replication slot does not exist"),
+	} {
+		require.False(t, isRetryableConnectError(err), err.Error())
+	}
+}
+
+func TestRetryConnectWithBackoff(t *testing.T) {
+	logger := internal.LoggerFromCtx(t.Context())
+
+	calls := 0
+	err := retryConnectWithBackoff(t.Context(), logger, func() error {
+		calls += 1
+		return &pgconn.PgError{Code: pgerrcode.InvalidPassword}
+	}, 5, time.Millisecond)
+	require.Error(t, err)
+	require.Equal(t, 1, calls, "authentication errors should not be retried")
+
+	calls = 0
+	err = retryConnectWithBackoff(t.Context(), logger, func() error {
+		calls += 1
+		if calls < 3 {
+			return &pgconn.PgError{Code: pgerrcode.CannotConnectNow}
+		}
+		return nil
+	}, 5, time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	calls = 0
+	err = retryConnectWithBackoff(ctx, logger, func() error {
+		calls += 1
+		cancel()
+		return &pgconn.PgError{Code: pgerrcode.CannotConnectNow}
+	}, 5, time.Hour)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 1, calls)
+}
+
+func TestFailoverSlots(t *testing.T) {
+	ctx := t.Context()
+	connector, schemaName := setupDB(t, "failover_slots")
+	defer connector.Close()
+	defer teardownDB(t, connector.conn, schemaName)
+
+	pgversion, err := connector.MajorVersion(ctx)
+	require.NoError(t, err)
+	if pgversion < shared.POSTGRES_17 {
+		t.Skip("failover slots need Postgres 17")
+	}
+
+	failover := func(slot string) bool {
+		t.Helper()
+		res, err := connector.checkSlotAndPublication(ctx, slot, "")
+		require.NoError(t, err)
+		require.True(t, res.SlotExists)
+		return res.SlotFailover
+	}
+	createSlot := func(slot string) {
+		t.Helper()
+		_, err := connector.createSlotAndPublication(ctx, SlotCheckResult{PublicationExists: true},
+			slot, "", nil, nil, false, true)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_, err := connector.conn.Exec(context.Background(), "SELECT pg_drop_replication_slot($1)", slot)
+			require.NoError(t, err)
+		})
+	}
+
+	createSlot(schemaName + "_plain")
+	require.False(t, failover(schemaName+"_plain"))
+
+	connector.Config.FailoverSlots = true
+	createSlot(schemaName + "_failover")
+	require.True(t, failover(schemaName+"_failover"))
+
+	// existing slots are only altered when failover slots are enabled
+	require.NoError(t, connector.SetupReplConn(ctx))
+	connector.Config.FailoverSlots = false
+	res, err := connector.checkSlotAndPublication(ctx, schemaName+"_plain", "")
+	require.NoError(t, err)
+	require.NoError(t, connector.checkFailoverSlot(ctx, schemaName+"_plain", res))
+	require.False(t, failover(schemaName+"_plain"))
+	connector.Config.FailoverSlots = true
+	require.NoError(t, connector.checkFailoverSlot(ctx, schemaName+"_plain", res))
+	require.True(t, failover(schemaName+"_plain"))
+}
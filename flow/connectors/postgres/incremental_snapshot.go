@@ -0,0 +1,286 @@
+package connpostgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pglogrepl"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// prefix of the logical decoding messages written as watermarks of incremental snapshot chunks,
+// messages of every mirror reading the database are received, chunk ids tell them apart
+const incrementalSnapshotMessagePrefix = "peerdb_incremental_snapshot"
+
+// ValidateIncrementalSnapshot checks watermarks can be written, which takes Postgres 14+ and a primary,
+// then runs the chunk query of snapshot without reading rows
+func (c *PostgresConnector) ValidateIncrementalSnapshot(
+	ctx context.Context,
+	snapshot *utils.IncrementalSnapshot,
+	schema *protos.TableSchema,
+) error {
+	pgVersion, err := c.MajorVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if pgVersion < shared.POSTGRES_14 {
+		return errors.New("incremental snapshots require Postgres 14+ to receive logical decoding messages")
+	}
+	inRecovery, err := c.isInRecovery(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check if source is a standby: %w", err)
+	}
+	if inRecovery {
+		return errors.New("incremental snapshots write watermarks to the source, which is not possible on a standby")
+	}
+
+	srcTable, err := utils.ParseSchemaTable(snapshot.SourceTable)
+	if err != nil {
+		return err
+	}
+	query, params, err := snapshotChunkQuery(srcTable, snapshot, schema, 0)
+	if err != nil {
+		return err
+	}
+	if err := c.conn.PgConn().ExecParams(ctx, query, params, nil, nil, nil).Read().Err; err != nil {
+		return fmt.Errorf("failed to query %s for incremental snapshot: %w", snapshot.SourceTable, err)
+	}
+	return nil
+}
+
+func (c *PostgresConnector) emitSnapshotWatermark(ctx context.Context, content string) error {
+	if _, err := c.conn.Exec(ctx, "SELECT pg_logical_emit_message(false,$1,$2)",
+		incrementalSnapshotMessagePrefix, content,
+	); err != nil {
+		return fmt.Errorf("failed to write incremental snapshot watermark: %w", err)
+	}
+	return nil
+}
+
+// startSnapshotChunk reads the next chunk of a pending incremental snapshot between its watermarks
+func startSnapshotChunk[Items model.Items](
+	ctx context.Context,
+	p *PostgresCDCSource,
+	req *model.PullRecordsRequest[Items],
+	processor replProcessor[Items],
+	handleRecord func(model.Record[Items]) error,
+	chunkSize int,
+) error {
+	snapshots := p.replState.IncrementalSnapshots
+	if snapshots == nil {
+		return nil
+	}
+	snapshot := snapshots.Next(req.TableNameSchemaMapping)
+	if snapshot == nil {
+		return nil
+	}
+
+	if truncate := utils.TruncateBeforeChunk[Items](snapshot); truncate != nil {
+		if err := handleRecord(truncate); err != nil {
+			return err
+		}
+	}
+	id := snapshots.NextChunkID(snapshot)
+	if err := p.emitSnapshotWatermark(ctx, "low:"+id); err != nil {
+		return err
+	}
+	rows, lastPK, err := readSnapshotChunk(ctx, p, processor, snapshot,
+		req.TableNameSchemaMapping[snapshot.DestinationTable], chunkSize)
+	if err != nil {
+		return err
+	}
+	needsHighWatermark, err := utils.AddChunk(snapshots, snapshot, id, rows, lastPK, req.TableNameSchemaMapping, chunkSize)
+	if err != nil {
+		return err
+	}
+	p.logger.Info("read incremental snapshot chunk",
+		slog.String("table", snapshot.SourceTable), slog.String("chunk", id), slog.Int("rows", len(rows)))
+	if needsHighWatermark {
+		return p.emitSnapshotWatermark(ctx, "high:"+id)
+	}
+	return nil
+}
+
+// readSnapshotChunk reads rows following the last primary key of snapshot in text format,
+// like pgoutput sends them, so that chunk rows have the same primary key bytes as changes of cdc
+func readSnapshotChunk[Items model.Items](
+	ctx context.Context,
+	p *PostgresCDCSource,
+	processor replProcessor[Items],
+	snapshot *utils.IncrementalSnapshot,
+	schema *protos.TableSchema,
+	chunkSize int,
+) ([]Items, []string, error) {
+	srcTable, err := utils.ParseSchemaTable(snapshot.SourceTable)
+	if err != nil {
+		return nil, nil, err
+	}
+	customTypeMapping, err := p.fetchCustomTypeMapping(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query, params, err := snapshotChunkQuery(srcTable, snapshot, schema, chunkSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := p.conn.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start transaction for incremental snapshot: %w", err)
+	}
+	defer shared.RollbackTx(tx, p.logger)
+	// output settings of the replication connection
+	if _, err := tx.Exec(ctx, "SET LOCAL bytea_output='hex'; SET LOCAL intervalstyle='postgres'"); err != nil {
+		return nil, nil, fmt.Errorf("failed to set output settings for incremental snapshot: %w", err)
+	}
+	result := tx.Conn().PgConn().ExecParams(ctx, query, params, nil, nil, nil).Read()
+	if result.Err != nil {
+		return nil, nil, fmt.Errorf("failed to read incremental snapshot chunk of %s: %w", snapshot.SourceTable, result.Err)
+	}
+
+	rel := &pglogrepl.RelationMessage{
+		Namespace:    srcTable.Schema,
+		RelationName: srcTable.Table,
+		Columns:      make([]*pglogrepl.RelationMessageColumn, 0, len(result.FieldDescriptions)),
+	}
+	pkeyIdx := make([]int, 0, len(schema.PrimaryKeyColumns))
+	for _, fd := range result.FieldDescriptions {
+		rel.Columns = append(rel.Columns, &pglogrepl.RelationMessageColumn{
+			Name:         fd.Name,
+			DataType:     fd.DataTypeOID,
+			TypeModifier: fd.TypeModifier,
+		})
+	}
+	for _, col := range schema.PrimaryKeyColumns {
+		for idx, fd := range result.FieldDescriptions {
+			if fd.Name == col {
+				pkeyIdx = append(pkeyIdx, idx)
+			}
+		}
+	}
+	var schemaName string
+	if p.schemaNameForRelID != nil {
+		schemaName = srcTable.Schema
+	}
+
+	rows := make([]Items, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		tuple := &pglogrepl.TupleData{Columns: make([]*pglogrepl.TupleDataColumn, 0, len(row))}
+		for _, value := range row {
+			if value == nil {
+				tuple.Columns = append(tuple.Columns, &pglogrepl.TupleDataColumn{DataType: 'n'})
+			} else {
+				tuple.Columns = append(tuple.Columns, &pglogrepl.TupleDataColumn{DataType: 't', Data: value})
+			}
+		}
+		items, _, err := processTuple(processor, p, tuple, rel, p.tableNameMapping[snapshot.SourceTable],
+			customTypeMapping, schemaName)
+		if err != nil {
+			return nil, nil, err
+		}
+		rows = append(rows, items)
+	}
+
+	var lastPK []string
+	if len(result.Rows) > 0 {
+		lastRow := result.Rows[len(result.Rows)-1]
+		lastPK = make([]string, 0, len(pkeyIdx))
+		for _, idx := range pkeyIdx {
+			lastPK = append(lastPK, string(lastRow[idx]))
+		}
+	}
+	return rows, lastPK, nil
+}
+
+// snapshotChunkQuery selects the next chunk of snapshot, bounds are bound as text parameters
+// which take the type of the primary key column they are compared with
+func snapshotChunkQuery(
+	srcTable *utils.SchemaTable,
+	snapshot *utils.IncrementalSnapshot,
+	schema *protos.TableSchema,
+	chunkSize int,
+) (string, [][]byte, error) {
+	columns := make([]string, 0, len(schema.Columns))
+	for _, col := range schema.Columns {
+		if col.Name != "_peerdb_source_schema" {
+			columns = append(columns, utils.QuoteIdentifier(col.Name))
+		}
+	}
+	pkeyCols := make([]string, 0, len(schema.PrimaryKeyColumns))
+	for _, col := range schema.PrimaryKeyColumns {
+		pkeyCols = append(pkeyCols, utils.QuoteIdentifier(col))
+	}
+	pkey := strings.Join(pkeyCols, ",")
+
+	var conditions []string
+	var params [][]byte
+	bound := func(op string, values []string) error {
+		if len(values) != len(pkeyCols) {
+			return fmt.Errorf("expected %d primary key values for incremental snapshot of %s, got %d",
+				len(pkeyCols), snapshot.SourceTable, len(values))
+		}
+		placeholders := make([]string, 0, len(values))
+		for _, value := range values {
+			params = append(params, []byte(value))
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(params)))
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s)%s(%s)", pkey, op, strings.Join(placeholders, ",")))
+		return nil
+	}
+	if snapshot.LastPK != nil {
+		if err := bound(">", snapshot.LastPK); err != nil {
+			return "", nil, err
+		}
+	} else if snapshot.StartPK != nil {
+		if err := bound(">=", snapshot.StartPK); err != nil {
+			return "", nil, err
+		}
+	}
+	if snapshot.EndPK != nil {
+		if err := bound("<=", snapshot.EndPK); err != nil {
+			return "", nil, err
+		}
+	}
+	if snapshot.Filter != "" {
+		filter, err := utils.RowFilterSQL(snapshot.Filter, protos.DBType_POSTGRES)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid filter of incremental snapshot of %s: %w", snapshot.SourceTable, err)
+		}
+		conditions = append(conditions, filter)
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ","), srcTable.String())
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", pkey, chunkSize)
+	return query, params, nil
+}
+
+// handleSnapshotWatermark opens the window of a chunk at its low watermark,
+// at its high watermark rows left in the chunk are returned to be added to the batch
+func handleSnapshotWatermark[Items model.Items](
+	snapshots *utils.IncrementalSnapshots,
+	msg *model.MessageRecord[Items],
+) []model.Record[Items] {
+	if snapshots == nil {
+		return nil
+	}
+	if id, ok := strings.CutPrefix(msg.Content, "low:"); ok {
+		snapshots.OpenWindow(id)
+	} else if id, ok := strings.CutPrefix(msg.Content, "high:"); ok {
+		return utils.FlushChunk[Items](snapshots, id, model.BaseRecord{
+			CheckpointID:   msg.CheckpointID,
+			CommitTimeNano: time.Now().UnixNano(),
+		})
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestSnapshotChunkQuery(t *testing.T) {
+	schema := &protos.TableSchema{
+		Columns: []*protos.FieldDescription{
+			{Name: "a", Type: string(types.QValueKindInt32)},
+			{Name: "b", Type: string(types.QValueKindString)},
+			{Name: "_peerdb_source_schema", Type: string(types.QValueKindString)},
+		},
+		PrimaryKeyColumns: []string{"a", "b"},
+	}
+	srcTable := &utils.SchemaTable{Schema: "public", Table: "t"}
+	snapshot := &utils.IncrementalSnapshot{
+		SourceTable: "public.t",
+		Filter:      "b <> 'x'",
+		StartPK:     []string{"1", "it's"},
+		EndPK:       []string{"2", "z"},
+	}
+
+	query, params, err := snapshotChunkQuery(srcTable, snapshot, schema, 10)
+	require.NoError(t, err)
+	require.Equal(t, `SELECT "a","b" FROM "public"."t" WHERE ("a","b")>=($1,$2) AND ("a","b")<=($3,$4) AND ("b" <> 'x')`+
+		` ORDER BY "a","b" LIMIT 10`, query)
+	require.Equal(t, [][]byte{[]byte("1"), []byte("it's"), []byte("2"), []byte("z")}, params)
+
+	// the last primary key read replaces the start bound
+	snapshot.LastPK = []string{"1", "y"}
+	snapshot.EndPK = nil
+	query, params, err = snapshotChunkQuery(srcTable, snapshot, schema, 10)
+	require.NoError(t, err)
+	require.Equal(t, `SELECT "a","b" FROM "public"."t" WHERE ("a","b")>($1,$2) AND ("b" <> 'x') ORDER BY "a","b" LIMIT 10`, query)
+	require.Equal(t, [][]byte{[]byte("1"), []byte("y")}, params)
+
+	snapshot.LastPK = []string{"1"}
+	_, _, err = snapshotChunkQuery(srcTable, snapshot, schema, 10)
+	require.Error(t, err)
+}
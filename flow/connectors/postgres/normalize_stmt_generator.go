@@ -29,6 +29,23 @@ type normalizeStmtGenerator struct {
 	metadataSchema string
 	// Postgres version 15 introduced MERGE, fallback statements before that
 	supportsMerge bool
+	// conflict policy for rows also changed at destination, as with mirrors running in both directions.
+	// By default changes from source always win. With last writer wins a change only overwrites or deletes
+	// a destination row that committed before the change committed at source, comparing _peerdb_commit_time
+	// to pg_xact_commit_timestamp of the row's xmin, so destination needs track_commit_timestamp.
+	// Rows without a commit timestamp, frozen or written before it was tracked, are always overwritten.
+	// Normalize commits under its replication origin with the latest source commit time of its batch,
+	// so rows it wrote compare by when they changed at source rather than when they were normalized.
+	lastWriterWins bool
+}
+
+// lastWriterWinsCondition restricts WHEN MATCHED clauses of the merge to changes newer than the destination row
+func (n *normalizeStmtGenerator) lastWriterWinsCondition() string {
+	if !n.lastWriterWins {
+		return ""
+	}
+	return " AND (src._peerdb_commit_time IS NULL OR pg_xact_commit_timestamp(dst.xmin) IS NULL" +
+		" OR pg_xact_commit_timestamp(dst.xmin)<=to_timestamp(src._peerdb_commit_time/1e9))"
 }
 
 func (n *normalizeStmtGenerator) columnTypeToPg(schema *protos.TableSchema, columnType string) string {
@@ -70,6 +87,9 @@ func (n *normalizeStmtGenerator) generateNormalizeStatements(dstTable string) []
 	if n.peerdbCols.SoftDeleteColName != "" {
 		n.Warn("soft delete enabled with fallback statements! this combination is unsupported")
 	}
+	if n.lastWriterWins {
+		n.Warn("last writer wins needs MERGE, fallback statements apply every change from source")
+	}
 	return n.generateFallbackStatements(dstTable, normalizedTableSchema)
 }
 
@@ -194,8 +214,14 @@ func (n *normalizeStmtGenerator) generateMergeStatement(
 		}
 	}
 
+	var commitTimeColumn string
+	if n.lastWriterWins {
+		commitTimeColumn = ",_peerdb_commit_time"
+	}
+
 	mergeStmt := fmt.Sprintf(
 		mergeStatementSQL,
+		commitTimeColumn,
 		strings.Join(slices.Collect(maps.Values(primaryKeyColumnCasts)), ","),
 		n.metadataSchema,
 		n.rawTableName,
@@ -205,6 +231,7 @@ func (n *normalizeStmtGenerator) generateMergeStatement(
 		insertColumnsSQL,
 		insertValuesSQL,
 		updateStringToastCols,
+		n.lastWriterWinsCondition(),
 		conflictPart,
 	)
 
@@ -241,8 +268,8 @@ func (n *normalizeStmtGenerator) generateUpdateStatements(quotedCols []string, u
 		quotedCols := utils.QuoteLiteral(cols)
 		ssep := strings.Join(tmpArray, ",")
 		updateStmt := fmt.Sprintf(`WHEN MATCHED AND
-			src._peerdb_record_type!=2 AND _peerdb_unchanged_toast_columns=%s
-			THEN UPDATE SET %s`, quotedCols, ssep)
+			src._peerdb_record_type!=2 AND _peerdb_unchanged_toast_columns=%s%s
+			THEN UPDATE SET %s`, quotedCols, n.lastWriterWinsCondition(), ssep)
 		updateStmts = append(updateStmts, updateStmt)
 
 		// generates update statements for the case where updates and deletes happen in the same branch
@@ -252,8 +279,8 @@ func (n *normalizeStmtGenerator) generateUpdateStatements(quotedCols []string, u
 			tmpArray[len(tmpArray)-1] = utils.QuoteIdentifier(n.peerdbCols.SoftDeleteColName) + `=TRUE`
 			ssep := strings.Join(tmpArray, ", ")
 			updateStmt := fmt.Sprintf(`WHEN MATCHED AND
-			src._peerdb_record_type=2 AND _peerdb_unchanged_toast_columns=%s
-			THEN UPDATE SET %s`, quotedCols, ssep)
+			src._peerdb_record_type=2 AND _peerdb_unchanged_toast_columns=%s%s
+			THEN UPDATE SET %s`, quotedCols, n.lastWriterWinsCondition(), ssep)
 			updateStmts = append(updateStmts, updateStmt)
 		}
 	}
@@ -33,10 +33,26 @@ import (
 )
 
 type ReplState struct {
+	// buffers changes of streamed transactions until commit, only set when Streaming
+	TxnStore    *utils.CDCTxnStore
 	Slot        string
 	Publication string
 	Offset      int64
 	LastOffset  atomic.Int64
+	// top level xid of the transaction being streamed, valid while InStream
+	StreamXid uint32
+	// replication was started with pgoutput protocol version 2 and streaming on
+	Streaming bool
+	// between StreamStart and StreamStop messages
+	InStream bool
+	// skip transactions written under a replication origin, Postgres 16+ skips them itself
+	SkipOrigin bool
+	// between the origin message and commit of a transaction to skip that is not streamed
+	InOriginTxn bool
+	// streamed transactions to skip, their origin message only comes with the first segment
+	OriginStreamXids map[uint32]struct{}
+	// incremental snapshots of the mirror, nil before Postgres 14 as watermarks are not received
+	IncrementalSnapshots *utils.IncrementalSnapshots
 }
 
 type PostgresConnector struct {
@@ -55,6 +71,10 @@ type PostgresConnector struct {
 	metadataSchema         string
 	replLock               sync.Mutex
 	pgVersion              shared.PGVersion
+	// replication origin set up for the session of conn, see setupReplicationOrigin
+	replOrigin string
+	// row filters as Postgres prints them, keyed by table and filter, see RowFilteredTables
+	deparsedRowFilters map[[2]string]string
 }
 
 func NewPostgresConnector(ctx context.Context, env map[string]string, pgConfig *protos.PostgresConfig) (*PostgresConnector, error) {
@@ -136,6 +156,16 @@ func ParseConfig(connectionString string, pgConfig *protos.PostgresConfig) (*pgx
 			return nil, err
 		}
 		connConfig.TLSConfig = tlsConfig
+		// failover hosts verify their own hostname unless tls host overrides it
+		for _, fallback := range connConfig.Fallbacks {
+			if fallback.TLSConfig != nil {
+				if fallback.TLSConfig, err = shared.CreateTlsConfig(
+					tls.VersionTLS12, pgConfig.RootCa, fallback.Host, pgConfig.TlsHost, false,
+				); err != nil {
+					return nil, err
+				}
+			}
+		}
 	}
 	return connConfig, nil
 }
@@ -176,9 +206,15 @@ func (c *PostgresConnector) CreateReplConn(ctx context.Context) (*pgx.Conn, erro
 	return conn, nil
 }
 
+// SetupReplConn retries for about a minute since the server is unreachable while failing over,
+// afterwards failover hosts or DNS lead to the promoted primary and replication resumes from its failover slot
 func (c *PostgresConnector) SetupReplConn(ctx context.Context) error {
-	conn, err := c.CreateReplConn(ctx)
-	if err != nil {
+	var conn *pgx.Conn
+	if err := retryConnectWithBackoff(ctx, c.logger, func() error {
+		var err error
+		conn, err = c.CreateReplConn(ctx)
+		return err
+	}, 6, 10*time.Second); err != nil {
 		return err
 	}
 	c.replConn = conn
@@ -207,6 +243,8 @@ func (c *PostgresConnector) MaybeStartReplication(
 	publicationName string,
 	lastOffset int64,
 	pgVersion shared.PGVersion,
+	streaming bool,
+	skipOrigin bool,
 ) error {
 	if c.replState != nil && (c.replState.Offset != lastOffset ||
 		c.replState.Slot != slotName ||
@@ -219,7 +257,7 @@ func (c *PostgresConnector) MaybeStartReplication(
 	}
 
 	if c.replState == nil {
-		replicationOpts, err := c.replicationOptions(publicationName, pgVersion)
+		replicationOpts, err := c.replicationOptions(publicationName, pgVersion, streaming, skipOrigin)
 		if err != nil {
 			return fmt.Errorf("error getting replication options: %w", err)
 		}
@@ -243,15 +281,29 @@ func (c *PostgresConnector) MaybeStartReplication(
 			Publication: publicationName,
 			Offset:      lastOffset,
 			LastOffset:  atomic.Int64{},
+			Streaming:   streaming,
+			SkipOrigin:  skipOrigin && pgVersion < shared.POSTGRES_16,
 		}
 		c.replState.LastOffset.Store(lastOffset)
+		if streaming {
+			c.replState.TxnStore = utils.NewCDCTxnStore(slotName)
+		}
+		if c.replState.SkipOrigin {
+			c.replState.OriginStreamXids = make(map[uint32]struct{})
+		}
 	}
 	return nil
 }
 
-func (c *PostgresConnector) replicationOptions(publicationName string, pgVersion shared.PGVersion,
+func (c *PostgresConnector) replicationOptions(publicationName string, pgVersion shared.PGVersion, streaming bool, skipOrigin bool,
 ) (pglogrepl.StartReplicationOptions, error) {
-	pluginArguments := append(make([]string, 0, 3), "proto_version '1'")
+	pluginArguments := make([]string, 0, 4)
+	if streaming {
+		// in-progress transactions are sent in chunks as they are decoded instead of at commit
+		pluginArguments = append(pluginArguments, "proto_version '2'", "streaming 'on'")
+	} else {
+		pluginArguments = append(pluginArguments, "proto_version '1'")
+	}
 
 	if publicationName != "" {
 		pubOpt := "publication_names " + utils.QuoteLiteral(publicationName)
@@ -264,12 +316,17 @@ func (c *PostgresConnector) replicationOptions(publicationName string, pgVersion
 		pluginArguments = append(pluginArguments, "messages 'true'")
 	}
 
+	if skipOrigin && pgVersion >= shared.POSTGRES_16 {
+		// older versions send an origin message, see processMessage
+		pluginArguments = append(pluginArguments, "origin 'none'")
+	}
+
 	return pglogrepl.StartReplicationOptions{PluginArgs: pluginArguments}, nil
 }
 
 // Close closes all connections.
 func (c *PostgresConnector) Close() error {
-	var connerr, replerr error
+	var connerr, replerr, txnerr error
 	if c != nil {
 		timeout, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
@@ -281,9 +338,13 @@ func (c *PostgresConnector) Close() error {
 			replerr = c.replConn.Close(timeout)
 		}
 
+		if c.replState != nil && c.replState.TxnStore != nil {
+			txnerr = c.replState.TxnStore.Close()
+		}
+
 		c.ssh.Close()
 	}
-	return errors.Join(connerr, replerr)
+	return errors.Join(connerr, replerr, txnerr)
 }
 
 func (c *PostgresConnector) Conn() *pgx.Conn {
@@ -417,14 +478,28 @@ func pullCore[Items model.Items](
 			fmt.Sprintf("replication slot %s does not exist, restarting workflow", slotName), "disconnect", nil)
 	}
 
-	c.logger.Info("PullRecords: performed checks for slot and publication")
-
 	// cached, since this connector is reused
 	pgVersion, err := c.MajorVersion(ctx)
 	if err != nil {
 		return err
 	}
-	if err := c.MaybeStartReplication(ctx, slotName, publicationName, req.LastOffset.ID, pgVersion); err != nil {
+	if pgVersion >= shared.POSTGRES_17 && c.replState == nil {
+		if err := c.checkFailoverSlot(ctx, slotName, exists); err != nil {
+			return err
+		}
+	}
+
+	c.logger.Info("PullRecords: performed checks for slot and publication")
+	streaming, err := internal.PeerDBPostgresCDCStreaming(ctx, req.Env)
+	if err != nil {
+		return fmt.Errorf("failed to get setting for streaming: %w", err)
+	}
+	if streaming && pgVersion < shared.POSTGRES_14 {
+		c.logger.Warn("streaming of in-progress transactions requires Postgres 14+, falling back to protocol version 1")
+		streaming = false
+	}
+	if err := c.MaybeStartReplication(ctx, slotName, publicationName, req.LastOffset.ID, pgVersion, streaming,
+		req.Bidirectional); err != nil {
 		// in case of Aurora error ERROR: replication slots cannot be used on RO (Read Only) node (SQLSTATE 55000)
 		if shared.IsSQLStateError(err, pgerrcode.ObjectNotInPrerequisiteState) &&
 			strings.Contains(err.Error(), "replication slots cannot be used on RO (Read Only) node") {
@@ -433,6 +508,14 @@ func pullCore[Items model.Items](
 		c.logger.Error("error starting replication", slog.Any("error", err))
 		return err
 	}
+	if pgVersion >= shared.POSTGRES_14 {
+		if c.replState.IncrementalSnapshots == nil {
+			c.replState.IncrementalSnapshots = utils.NewIncrementalSnapshots(catalogPool, req.FlowJobName)
+		}
+		if err := c.replState.IncrementalSnapshots.Refresh(ctx); err != nil {
+			return err
+		}
+	}
 	handleInheritanceForNonPartitionedTables, err := internal.PeerDBPostgresCDCHandleInheritanceForNonPartitionedTables(ctx, req.Env)
 	if err != nil {
 		return fmt.Errorf("failed to get get setting for handleInheritanceForNonPartitionedTables: %w", err)
@@ -502,6 +585,24 @@ func syncRecordsCore[Items model.Items](
 	rawTableIdentifier := getRawTableIdentifier(req.FlowJobName)
 	c.logger.Info(fmt.Sprintf("pushing records to Postgres table %s via COPY", rawTableIdentifier))
 
+	if req.Bidirectional {
+		if err := c.setupReplicationOrigin(ctx, replicationOriginName(req.FlowJobName, "sync")); err != nil {
+			return nil, err
+		}
+	}
+	rawColumns := []string{
+		"_peerdb_uid", "_peerdb_timestamp", "_peerdb_destination_table_name", "_peerdb_data",
+		"_peerdb_record_type", "_peerdb_match_data", "_peerdb_batch_id", "_peerdb_unchanged_toast_columns",
+	}
+	// commit times are compared to those of destination rows when normalizing
+	lastWriterWins := req.ConflictResolution == protos.ConflictResolution_CONFLICT_RESOLUTION_LAST_WRITER_WINS
+	if lastWriterWins {
+		if err := c.ensureCommitTimeColumn(ctx, rawTableIdentifier); err != nil {
+			return nil, err
+		}
+		rawColumns = append(rawColumns, "_peerdb_commit_time")
+	}
+
 	numRecords := int64(0)
 	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
 	streamReadFunc := func() ([]any, error) {
@@ -575,13 +676,33 @@ func syncRecordsCore[Items model.Items](
 					"",
 				}
 
-			case *model.MessageRecord[Items]:
+			case *model.TruncateRecord[Items]:
+				row = []any{
+					uuid.New(),
+					time.Now().UnixNano(),
+					typedRecord.DestinationTableName,
+					"{}",
+					3,
+					"{}",
+					req.SyncBatchID,
+					"",
+				}
+
+			case *model.MessageRecord[Items], *model.RelationRecord[Items]:
 				continue
 
 			default:
 				return nil, fmt.Errorf("unsupported record type for Postgres flow connector: %T", typedRecord)
 			}
 
+			if lastWriterWins {
+				var commitTime any
+				if commitTimeNano := record.GetCommitTime().UnixNano(); commitTimeNano != 0 {
+					commitTime = commitTimeNano
+				}
+				row = append(row, commitTime)
+			}
+
 			record.PopulateCountMap(tableNameRowsMapping)
 			numRecords += 1
 			return row, nil
@@ -597,11 +718,7 @@ func syncRecordsCore[Items model.Items](
 	defer shared.RollbackTx(syncRecordsTx, c.logger)
 
 	syncedRecordsCount, err := syncRecordsTx.CopyFrom(ctx, pgx.Identifier{c.metadataSchema, rawTableIdentifier},
-		[]string{
-			"_peerdb_uid", "_peerdb_timestamp", "_peerdb_destination_table_name", "_peerdb_data",
-			"_peerdb_record_type", "_peerdb_match_data", "_peerdb_batch_id", "_peerdb_unchanged_toast_columns",
-		},
-		pgx.CopyFromFunc(streamReadFunc))
+		rawColumns, pgx.CopyFromFunc(streamReadFunc))
 	if err != nil {
 		return nil, fmt.Errorf("error syncing records: %w", err)
 	}
@@ -678,12 +795,40 @@ func (c *PostgresConnector) NormalizeRecords(
 		return model.NormalizeResponse{}, err
 	}
 
+	lastWriterWins := req.ConflictResolution == protos.ConflictResolution_CONFLICT_RESOLUTION_LAST_WRITER_WINS
+	// last writer wins needs the origin to commit with the source commit time, see setupOriginCommitTimeSQL
+	if req.Bidirectional || lastWriterWins {
+		if err := c.setupReplicationOrigin(ctx, replicationOriginName(req.FlowJobName, "normalize")); err != nil {
+			return model.NormalizeResponse{}, err
+		}
+	}
+	if lastWriterWins {
+		var trackCommitTimestamp string
+		if err := c.conn.QueryRow(ctx, "SHOW track_commit_timestamp").Scan(&trackCommitTimestamp); err != nil {
+			return model.NormalizeResponse{}, fmt.Errorf("failed to check track_commit_timestamp: %w", err)
+		}
+		if trackCommitTimestamp != "on" {
+			return model.NormalizeResponse{}, errors.New("last writer wins conflict resolution requires track_commit_timestamp=on")
+		}
+		if err := c.ensureCommitTimeColumn(ctx, rawTableIdentifier); err != nil {
+			return model.NormalizeResponse{}, err
+		}
+	}
+
 	normalizeRecordsTx, err := c.conn.Begin(ctx)
 	if err != nil {
 		return model.NormalizeResponse{}, fmt.Errorf("error starting transaction for normalizing records: %w", err)
 	}
 	defer shared.RollbackTx(normalizeRecordsTx, c.logger)
 
+	if lastWriterWins {
+		if _, err := normalizeRecordsTx.Exec(ctx,
+			fmt.Sprintf(setupOriginCommitTimeSQL, c.metadataSchema, rawTableIdentifier), normBatchID, req.SyncBatchID,
+		); err != nil {
+			return model.NormalizeResponse{}, fmt.Errorf("failed to set commit time of normalize transaction: %w", err)
+		}
+	}
+
 	pgversion, err := c.MajorVersion(ctx)
 	if err != nil {
 		return model.NormalizeResponse{}, err
@@ -700,9 +845,13 @@ func (c *PostgresConnector) NormalizeRecords(
 		},
 		supportsMerge:  pgversion >= shared.POSTGRES_15,
 		metadataSchema: c.metadataSchema,
+		lastWriterWins: lastWriterWins,
 	}
 
 	for _, destinationTableName := range destinationTableNames {
+		if err := c.normalizeTruncate(ctx, normalizeRecordsTx, req, normBatchID, destinationTableName); err != nil {
+			return model.NormalizeResponse{}, err
+		}
 		normalizeStatements := normalizeStmtGen.generateNormalizeStatements(destinationTableName)
 		for _, normalizeStatement := range normalizeStatements {
 			ct, err := normalizeRecordsTx.Exec(ctx, normalizeStatement, normBatchID, req.SyncBatchID, destinationTableName)
@@ -736,9 +885,71 @@ func (c *PostgresConnector) NormalizeRecords(
 	}, nil
 }
 
+// normalizeTruncate handles the latest truncate of a table in the batch range according to the truncate policy,
+// changes from before an applied truncate are dropped from the raw table so they are not normalized
+func (c *PostgresConnector) normalizeTruncate(
+	ctx context.Context,
+	tx pgx.Tx,
+	req *model.NormalizeRecordsRequest,
+	normBatchID int64,
+	destinationTableName string,
+) error {
+	rawTableIdentifier := getRawTableIdentifier(req.FlowJobName)
+	var truncatedAt pgtype.Int8
+	if err := tx.QueryRow(ctx, fmt.Sprintf(getLatestTruncateTimestampSQL, c.metadataSchema, rawTableIdentifier),
+		normBatchID, req.SyncBatchID, destinationTableName,
+	).Scan(&truncatedAt); err != nil {
+		return fmt.Errorf("error while checking truncates of table %s: %w", destinationTableName, err)
+	}
+	if !truncatedAt.Valid {
+		return nil
+	}
+
+	parsedDstTable, err := utils.ParseSchemaTable(destinationTableName)
+	if err != nil {
+		return fmt.Errorf("error while parsing table schema and name: %w", err)
+	}
+	switch req.TruncatePolicy {
+	case protos.TruncatePolicy_TRUNCATE_POLICY_APPLY:
+		if _, err := tx.Exec(ctx, "TRUNCATE "+parsedDstTable.String()); err != nil {
+			return fmt.Errorf("error truncating table %s: %w", destinationTableName, err)
+		}
+	case protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE:
+		if req.SoftDeleteColName == "" {
+			return fmt.Errorf("cannot soft delete rows of truncated table %s without a soft delete column", destinationTableName)
+		}
+		softDeleteUpdate := utils.QuoteIdentifier(req.SoftDeleteColName) + "=TRUE"
+		if req.SyncedAtColName != "" {
+			softDeleteUpdate += fmt.Sprintf(",%s=CURRENT_TIMESTAMP", utils.QuoteIdentifier(req.SyncedAtColName))
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf("UPDATE %s SET %s", parsedDstTable.String(), softDeleteUpdate)); err != nil {
+			return fmt.Errorf("error soft deleting rows of truncated table %s: %w", destinationTableName, err)
+		}
+	default:
+		if _, err := tx.Exec(ctx, fmt.Sprintf(deleteTruncateMarkersSQL, c.metadataSchema, rawTableIdentifier),
+			normBatchID, req.SyncBatchID, destinationTableName,
+		); err != nil {
+			return fmt.Errorf("error removing ignored truncates of table %s: %w", destinationTableName, err)
+		}
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(deleteTruncatedRawRowsSQL, c.metadataSchema, rawTableIdentifier),
+		normBatchID, req.SyncBatchID, destinationTableName, truncatedAt.Int64,
+	); err != nil {
+		return fmt.Errorf("error removing truncated changes of table %s: %w", destinationTableName, err)
+	}
+	c.logger.Info("applied truncate", slog.String("table", destinationTableName), slog.String("policy", req.TruncatePolicy.String()))
+	return nil
+}
+
 type SlotCheckResult struct {
 	SlotExists        bool
 	PublicationExists bool
+	// Postgres 17+ failover slots are synchronized to standbys so they survive a failover
+	SlotFailover bool
+	// copy of a failover slot on a standby, usable only once the standby is promoted
+	SlotSynced bool
 }
 
 // CreateRawTable creates a raw table, implementing the Connector interface.
@@ -1086,10 +1297,10 @@ func (c *PostgresConnector) SetupNormalizedTable(
 }
 
 // replayTableSchemaDeltaCore changes a destination table to match the schema at source
-// This could involve adding or dropping multiple columns.
+// This could involve adding, dropping or retyping multiple columns.
 func (c *PostgresConnector) ReplayTableSchemaDeltas(
 	ctx context.Context,
-	_ map[string]string,
+	env map[string]string,
 	flowJobName string,
 	_ []*protos.TableMapping,
 	schemaDeltas []*protos.TableSchemaDelta,
@@ -1107,26 +1318,26 @@ func (c *PostgresConnector) ReplayTableSchemaDeltas(
 	defer shared.RollbackTx(tableSchemaModifyTx, c.logger)
 
 	for _, schemaDelta := range schemaDeltas {
-		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+		if schemaDelta == nil ||
+			(len(schemaDelta.AddedColumns) == 0 && len(schemaDelta.DroppedColumns) == 0 && len(schemaDelta.AlteredColumns) == 0) {
 			continue
 		}
 
+		dstSchemaTable, err := utils.ParseSchemaTable(schemaDelta.DstTableName)
+		if err != nil {
+			return fmt.Errorf("error parsing schema and table for %s: %w", schemaDelta.DstTableName, err)
+		}
+		dstTable := utils.QuoteIdentifier(dstSchemaTable.Schema) + "." + utils.QuoteIdentifier(dstSchemaTable.Table)
+
 		for _, addedColumn := range schemaDelta.AddedColumns {
 			columnType := addedColumn.Type
 			if schemaDelta.System == protos.TypeSystem_Q {
 				columnType = qValueKindToPostgresType(columnType)
 			}
 
-			dstSchemaTable, err := utils.ParseSchemaTable(schemaDelta.DstTableName)
-			if err != nil {
-				return fmt.Errorf("error parsing schema and table for %s: %w", schemaDelta.DstTableName, err)
-			}
-
 			_, err = c.execWithLoggingTx(ctx, fmt.Sprintf(
-				"ALTER TABLE %s.%s ADD COLUMN IF NOT EXISTS %s %s",
-				utils.QuoteIdentifier(dstSchemaTable.Schema),
-				utils.QuoteIdentifier(dstSchemaTable.Table),
-				utils.QuoteIdentifier(addedColumn.Name), columnType), tableSchemaModifyTx)
+				"ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s",
+				dstTable, utils.QuoteIdentifier(addedColumn.Name), columnType), tableSchemaModifyTx)
 			if err != nil {
 				return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name,
 					schemaDelta.DstTableName, err)
@@ -1137,6 +1348,66 @@ func (c *PostgresConnector) ReplayTableSchemaDeltas(
 				slog.String("dstTableName", schemaDelta.DstTableName),
 			)
 		}
+
+		if len(schemaDelta.DroppedColumns) == 0 && len(schemaDelta.AlteredColumns) == 0 {
+			continue
+		}
+		policy := schemaDelta.DroppedColumnPolicy
+
+		for _, droppedColumn := range schemaDelta.DroppedColumns {
+			if err := c.replayDroppedColumn(ctx, tableSchemaModifyTx, dstTable, droppedColumn.Name, policy); err != nil {
+				return fmt.Errorf("failed to replay dropped column %s for table %s: %w", droppedColumn.Name,
+					schemaDelta.DstTableName, err)
+			}
+			c.logger.Info("[schema delta replay] replayed dropped column",
+				slog.String("columnName", droppedColumn.Name),
+				slog.String("policy", policy.String()),
+				slog.String("srcTableName", schemaDelta.SrcTableName),
+				slog.String("dstTableName", schemaDelta.DstTableName))
+		}
+
+		for _, alteredColumn := range schemaDelta.AlteredColumns {
+			columnName := utils.QuoteIdentifier(alteredColumn.Current.Name)
+			columnType := alteredColumn.Current.Type
+			if schemaDelta.System == protos.TypeSystem_Q {
+				columnType = qValueKindToPostgresType(columnType)
+			}
+
+			var stmts []string
+			if canWidenColumnType(schemaDelta.System, alteredColumn.Previous.Type, alteredColumn.Current.Type) {
+				stmts = []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", dstTable, columnName, columnType)}
+			} else {
+				switch policy {
+				case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL:
+					stmts = []string{
+						fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", dstTable, columnName),
+						fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING NULL", dstTable, columnName, columnType),
+					}
+				case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP:
+					stmts = []string{
+						fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s", dstTable, columnName),
+						fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", dstTable, columnName, columnType),
+					}
+				default:
+					c.logger.Warn(fmt.Sprintf("[schema delta replay] column %s changed type from %s to %s incompatibly, not propagating",
+						alteredColumn.Current.Name, alteredColumn.Previous.Type, alteredColumn.Current.Type),
+						slog.String("srcTableName", schemaDelta.SrcTableName),
+						slog.String("dstTableName", schemaDelta.DstTableName))
+				}
+			}
+			for _, stmt := range stmts {
+				if _, err := c.execWithLoggingTx(ctx, stmt, tableSchemaModifyTx); err != nil {
+					return fmt.Errorf("failed to alter column %s for table %s: %w", alteredColumn.Current.Name,
+						schemaDelta.DstTableName, err)
+				}
+			}
+			if len(stmts) > 0 {
+				c.logger.Info(fmt.Sprintf("[schema delta replay] altered column %s from data type %s to %s",
+					alteredColumn.Current.Name, alteredColumn.Previous.Type, alteredColumn.Current.Type),
+					slog.String("srcTableName", schemaDelta.SrcTableName),
+					slog.String("dstTableName", schemaDelta.DstTableName))
+			}
+		}
 	}
 
 	if err := tableSchemaModifyTx.Commit(ctx); err != nil {
@@ -1145,6 +1416,31 @@ func (c *PostgresConnector) ReplayTableSchemaDeltas(
 	return nil
 }
 
+// replayDroppedColumn applies policy to a destination column that no longer exists at source
+func (c *PostgresConnector) replayDroppedColumn(
+	ctx context.Context,
+	tx pgx.Tx,
+	dstTable string,
+	columnName string,
+	policy protos.DroppedColumnPolicy,
+) error {
+	quotedColumnName := utils.QuoteIdentifier(columnName)
+	switch policy {
+	case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL:
+		if _, err := c.execWithLoggingTx(ctx,
+			fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", dstTable, quotedColumnName), tx); err != nil {
+			return err
+		}
+		_, err := c.execWithLoggingTx(ctx, fmt.Sprintf("UPDATE %s SET %s = NULL", dstTable, quotedColumnName), tx)
+		return err
+	case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP:
+		_, err := c.execWithLoggingTx(ctx, fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s", dstTable, quotedColumnName), tx)
+		return err
+	default:
+		return nil
+	}
+}
+
 // EnsurePullability ensures that a table is pullable, implementing the Connector interface.
 func (c *PostgresConnector) EnsurePullability(
 	ctx context.Context,
@@ -1290,7 +1586,8 @@ func (c *PostgresConnector) SetupReplication(
 		}
 	}
 	// Create the replication slot and publication
-	return c.createSlotAndPublication(ctx, exists, slotName, publicationName, tableNameMapping, req.DoInitialSnapshot, skipSnapshotExport)
+	return c.createSlotAndPublication(ctx, exists, slotName, publicationName, tableNameMapping, req.RowFilters,
+		req.DoInitialSnapshot, skipSnapshotExport)
 }
 
 func (c *PostgresConnector) PullFlowCleanup(ctx context.Context, jobName string) error {
@@ -1357,6 +1654,7 @@ func (c *PostgresConnector) SyncFlowCleanup(ctx context.Context, jobName string)
 		return fmt.Errorf("unable to commit transaction for sync flow cleanup: %w", err)
 	}
 
+	c.dropReplicationOrigins(ctx, jobName)
 	return nil
 }
 
@@ -1531,14 +1829,27 @@ func (c *PostgresConnector) AddTablesToPublication(ctx context.Context, req *pro
 				strings.Join(notPresentTables, ",")))
 		}
 	} else {
-		for _, additionalSrcTable := range additionalSrcTables {
+		pgversion, err := c.MajorVersion(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get Postgres version: %w", err)
+		}
+		for _, additionalTableMapping := range req.AdditionalTables {
+			additionalSrcTable := additionalTableMapping.SourceTableIdentifier
 			schemaTable, err := utils.ParseSchemaTable(additionalSrcTable)
 			if err != nil {
 				return err
 			}
+			tableString := schemaTable.String()
+			if additionalTableMapping.RowFilter != "" && pgversion >= shared.POSTGRES_15 {
+				rowFilterSQL, err := utils.RowFilterSQL(additionalTableMapping.RowFilter, protos.DBType_POSTGRES)
+				if err != nil {
+					return exceptions.NewPostgresSetupError(err)
+				}
+				tableString += " WHERE (" + rowFilterSQL + ")"
+			}
 			_, err = c.execWithLogging(ctx, fmt.Sprintf("ALTER PUBLICATION %s ADD TABLE %s",
 				utils.QuoteIdentifier(c.getDefaultPublicationName(req.FlowJobName)),
-				schemaTable.String()))
+				tableString))
 			// don't error out if table is already added to our publication
 			if err != nil && !shared.IsSQLStateError(err, pgerrcode.DuplicateObject) {
 				return fmt.Errorf("failed to alter publication: %w", err)
@@ -185,6 +185,48 @@ func (s PostgresSchemaDeltaTestSuite) TestAddDropWhitespaceColumnNames() {
 	require.Equal(s.t, expectedTableSchema, output[tableName])
 }
 
+func (s PostgresSchemaDeltaTestSuite) TestDropAndAlterColumns() {
+	tableName := s.schema + ".drop_alter_columns"
+	_, err := s.connector.conn.Exec(s.t.Context(),
+		fmt.Sprintf("CREATE TABLE %s(id INT PRIMARY KEY, widened INT, retyped TEXT, dropped TEXT)", tableName))
+	require.NoError(s.t, err)
+
+	require.NoError(s.t, s.connector.ReplayTableSchemaDeltas(s.t.Context(),
+		nil, "schema_delta_flow", nil, []*protos.TableSchemaDelta{{
+			SrcTableName:        tableName,
+			DstTableName:        tableName,
+			System:              protos.TypeSystem_Q,
+			DroppedColumnPolicy: protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP,
+			DroppedColumns: []*protos.FieldDescription{
+				{Name: "dropped", Type: string(types.QValueKindString), TypeModifier: -1, Nullable: true},
+			},
+			AlteredColumns: []*protos.AlteredColumn{
+				{
+					Previous: &protos.FieldDescription{Name: "widened", Type: string(types.QValueKindInt32), TypeModifier: -1, Nullable: true},
+					Current:  &protos.FieldDescription{Name: "widened", Type: string(types.QValueKindInt64), TypeModifier: -1, Nullable: true},
+				},
+				{
+					Previous: &protos.FieldDescription{Name: "retyped", Type: string(types.QValueKindString), TypeModifier: -1, Nullable: true},
+					Current:  &protos.FieldDescription{Name: "retyped", Type: string(types.QValueKindBoolean), TypeModifier: -1, Nullable: true},
+				},
+			},
+		}}))
+
+	output, err := s.connector.GetTableSchema(s.t.Context(), nil, shared.InternalVersion_Latest, protos.TypeSystem_Q,
+		[]*protos.TableMapping{{SourceTableIdentifier: tableName}})
+	require.NoError(s.t, err)
+	require.Equal(s.t, &protos.TableSchema{
+		TableIdentifier:   tableName,
+		PrimaryKeyColumns: []string{"id"},
+		System:            protos.TypeSystem_Q,
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(types.QValueKindInt32), TypeModifier: -1},
+			{Name: "widened", Type: string(types.QValueKindInt64), TypeModifier: -1, Nullable: true},
+			{Name: "retyped", Type: string(types.QValueKindBoolean), TypeModifier: -1, Nullable: true},
+		},
+	}, output[tableName])
+}
+
 func TestPostgresSchemaDeltaTestSuite(t *testing.T) {
 	e2eshared.RunSuite(t, SetupSuite)
 }
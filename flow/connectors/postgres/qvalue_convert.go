@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"net"
 	"net/netip"
+	"slices"
 	"strings"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/pgvector/pgvector-go"
 	"github.com/shopspring/decimal"
 
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
 	"github.com/PeerDB-io/peerdb/flow/shared/postgres"
@@ -154,6 +156,24 @@ func qValueKindToPostgresType(colTypeStr string) string {
 	}
 }
 
+// postgres types each type can be converted to without losing values
+var postgresTypeWidenings = map[string][]string{
+	"int2":    {"int4", "int8", "numeric"},
+	"int4":    {"int8", "numeric"},
+	"int8":    {"numeric"},
+	"float4":  {"float8"},
+	"varchar": {"text"},
+	"bpchar":  {"varchar", "text"},
+	"date":    {"timestamp", "timestamptz"},
+}
+
+func canWidenColumnType(system protos.TypeSystem, previousType string, currentType string) bool {
+	if system == protos.TypeSystem_Q {
+		return types.QValueKind(previousType).CanWidenTo(types.QValueKind(currentType))
+	}
+	return previousType == currentType || slices.Contains(postgresTypeWidenings[previousType], currentType)
+}
+
 func parseJSON(value any, isArray bool) (types.QValue, error) {
 	jsonVal, err := json.Marshal(value)
 	if err != nil {
@@ -0,0 +1,50 @@
+package connpostgres
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func TestRowFilteredTables(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	connector, schemaName := setupDB(t, "row_filter")
+	defer connector.Close()
+	defer teardownDB(t, connector.conn, schemaName)
+
+	pgversion, err := connector.MajorVersion(ctx)
+	require.NoError(t, err)
+	if pgversion < shared.POSTGRES_15 {
+		t.Skip("publication row filters need Postgres 15")
+	}
+
+	table := schemaName + ".t"
+	quotedTable := (&utils.SchemaTable{Schema: schemaName, Table: "t"}).String()
+	_, err = connector.conn.Exec(ctx, fmt.Sprintf("CREATE TABLE %s(id INT PRIMARY KEY, region TEXT)", quotedTable))
+	require.NoError(t, err)
+
+	require.NoError(t, connector.ValidateRowFilters(ctx, map[string]string{table: "region = 'eu' AND id > 1"}))
+	require.Error(t, connector.ValidateRowFilters(ctx, map[string]string{table: "missing = 1"}))
+
+	publication := schemaName + "_pub"
+	require.NoError(t, connector.CreatePublication(ctx, []string{quotedTable},
+		map[string]string{quotedTable: "region = 'eu' AND id > 1"}, publication))
+	defer func() {
+		_, err := connector.conn.Exec(ctx, "DROP PUBLICATION "+utils.QuoteIdentifier(publication))
+		require.NoError(t, err)
+	}()
+
+	tables, err := connector.RowFilteredTables(ctx, "", publication, map[string]string{table: "region = 'eu' AND id > 1"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]struct{}{table: {}}, tables)
+
+	_, err = connector.RowFilteredTables(ctx, "", publication, map[string]string{table: "region = 'us'"})
+	require.ErrorContains(t, err, "its table mapping filters with")
+	_, err = connector.RowFilteredTables(ctx, "", publication, nil)
+	require.ErrorContains(t, err, "its table mapping has no row filter")
+}
@@ -2,12 +2,15 @@ package connpostgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"time"
 
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"go.temporal.io/sdk/log"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
@@ -93,6 +96,39 @@ func retryWithBackoff(logger log.Logger, fn retryFunc, maxRetries int, backoff t
 	}
 }
 
+// retryConnectWithBackoff only retries errors seen while a server restarts or fails over,
+// rejected credentials and other errors from the server are returned right away
+func retryConnectWithBackoff(ctx context.Context, logger log.Logger, fn retryFunc, maxRetries int, backoff time.Duration) error {
+	for i := 1; ; i++ {
+		err := fn()
+		if err == nil || i >= maxRetries || !isRetryableConnectError(err) {
+			return err
+		}
+		logger.Info(fmt.Sprintf("Attempt #%d failed, retrying in %s", i, backoff), slog.Any("error", err))
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func isRetryableConnectError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgerrcode.IsConnectionException(pgErr.Code) ||
+			pgErr.Code == pgerrcode.AdminShutdown ||
+			pgErr.Code == pgerrcode.CrashShutdown ||
+			pgErr.Code == pgerrcode.CannotConnectNow
+	}
+	// dial errors and hosts rejected by target_session_attrs while no host is the primary yet
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.As(err, &connectErr) || errors.As(err, &netErr)
+}
+
 // see: https://github.com/jackc/pgx/issues/382#issuecomment-1496586216
 type noDeadlineConn struct{ net.Conn }
 
@@ -165,8 +165,8 @@ func (c *PostgresConnector) CheckReplicationPermissions(ctx context.Context, use
 		return fmt.Errorf("failed to get server version: %w", err)
 	}
 
-	var recoveryRes bool
-	if err := c.conn.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&recoveryRes); err != nil {
+	recoveryRes, err := c.isInRecovery(ctx)
+	if err != nil {
 		return fmt.Errorf("failed to check if Postgres is in recovery: %w", err)
 	}
 	if recoveryRes {
@@ -205,7 +205,7 @@ func (c *PostgresConnector) CheckReplicationConnectivity(ctx context.Context) er
 
 func (c *PostgresConnector) CheckPublicationCreationPermissions(ctx context.Context, srcTableNames []string) error {
 	pubName := "_peerdb_tmp_test_publication_" + shared.RandomString(5)
-	if err := c.CreatePublication(ctx, srcTableNames, pubName); err != nil {
+	if err := c.CreatePublication(ctx, srcTableNames, nil, pubName); err != nil {
 		return err
 	}
 
@@ -241,6 +241,15 @@ func (c *PostgresConnector) ValidateMirrorSource(ctx context.Context, cfg *proto
 
 	pubName := cfg.PublicationName
 	if pubName == "" && !noCDC {
+		// standbys are read only, so the publication has to be created on the primary
+		inRecovery, err := c.isInRecovery(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to check if Postgres is in recovery: %w", err)
+		}
+		if inRecovery {
+			return errors.New("publication must be created on the primary and provided when replicating from a standby")
+		}
+
 		srcTableNames := make([]string, 0, len(sourceTables))
 		for _, srcTable := range sourceTables {
 			srcTableNames = append(srcTableNames, srcTable.String())
@@ -138,6 +138,7 @@ func (c *PubSubConnector) createPool(
 	ctx context.Context,
 	env map[string]string,
 	script string,
+	encoder *utils.DebeziumEncoder,
 	flowJobName string,
 	topiccache *topicCache,
 	publish chan<- publishResult,
@@ -155,7 +156,9 @@ func (c *PubSubConnector) createPool(
 		if err != nil {
 			return nil, fmt.Errorf("[pubsub] error loading script: %w", err)
 		}
-		if script == "" {
+		if encoder != nil {
+			ls.Env.RawSetString("onRecord", ls.NewFunction(utils.DebeziumOnRecord(encoder, false)))
+		} else if script == "" {
 			ls.Env.RawSetString("onRecord", ls.NewFunction(utils.DefaultOnRecord))
 		}
 		return ls, nil
@@ -248,7 +251,11 @@ func (c *PubSubConnector) SyncRecords(ctx context.Context, req *model.SyncRecord
 
 	queueCtx, queueErr := context.WithCancelCause(ctx)
 
-	pool, err := c.createPool(queueCtx, req.Env, req.Script, req.FlowJobName, &topiccache, publish, queueErr)
+	var encoder *utils.DebeziumEncoder
+	if req.QueueEncoding == protos.QueueEncoding_QUEUE_ENCODING_DEBEZIUM {
+		encoder = utils.NewDebeziumEncoder(req.FlowJobName, req.TableNameSchemaMapping, false)
+	}
+	pool, err := c.createPool(queueCtx, req.Env, req.Script, encoder, req.FlowJobName, &topiccache, publish, queueErr)
 	if err != nil {
 		return nil, err
 	}
@@ -306,6 +313,11 @@ Loop:
 				break Loop
 			}
 
+			if _, ok := record.(*model.RelationRecord[model.RecordItems]); ok {
+				// schema changes are not passed to scripts
+				continue
+			}
+
 			pool.Run(func(ls *lua.LState) poolResult {
 				lfn := ls.Env.RawGetString("onRecord")
 				fn, ok := lfn.(*lua.LFunction)
@@ -9,6 +9,7 @@ import (
 	"cloud.google.com/go/pubsub"
 	lua "github.com/yuin/gopher-lua"
 
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/pua"
@@ -36,7 +37,11 @@ func (c *PubSubConnector) SyncQRepRecords(
 	numRecords := atomic.Int64{}
 
 	queueCtx, queueErr := context.WithCancelCause(ctx)
-	pool, err := c.createPool(queueCtx, config.Env, config.Script, config.FlowJobName, &topiccache, publish, queueErr)
+	var encoder *utils.DebeziumEncoder
+	if config.QueueEncoding == protos.QueueEncoding_QUEUE_ENCODING_DEBEZIUM {
+		encoder = utils.NewQRepDebeziumEncoder(ctx, config, schema)
+	}
+	pool, err := c.createPool(queueCtx, config.Env, config.Script, encoder, config.FlowJobName, &topiccache, publish, queueErr)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -0,0 +1,101 @@
+package connredis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func (*RedisConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+func (c *RedisConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+	var numRecords int64
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+	tableSchema := utils.QRepTableSchema(ctx, config, schema)
+
+	queueCtx, queueErr := context.WithCancelCause(ctx)
+	pipe := c.newPipeliner()
+	pool, err := c.createPool(queueCtx, config.Env, config.Script, config.FlowJobName, pipe, nil, queueErr)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer pool.Close()
+
+	shutdown := shared.Interval(ctx, time.Minute, func() {
+		c.logger.Info(fmt.Sprintf("wrote %d records", numRecords))
+	})
+	defer shutdown()
+
+Loop:
+	for {
+		select {
+		case qrecord, ok := <-stream.Records:
+			if !ok {
+				c.logger.Info("flushing batches because no more records")
+				break Loop
+			}
+
+			pool.Run(func(ls *lua.LState) poolResult {
+				items := model.NewRecordItems(len(qrecord))
+				for i, val := range qrecord {
+					items.AddColumn(schema.Fields[i].Name, val)
+				}
+				record := &model.InsertRecord[model.RecordItems]{
+					BaseRecord:           model.BaseRecord{},
+					Items:                items,
+					SourceTableName:      config.WatermarkTable,
+					DestinationTableName: config.DestinationTableIdentifier,
+					CommitID:             0,
+				}
+
+				var writes []redisWrite
+				var entries []streamEntry
+				var err error
+				if config.Script != "" {
+					writes, err = c.runScript(ls, record, tableSchema)
+				} else {
+					writes, entries, err = c.recordWrites(record, tableSchema)
+				}
+				if err != nil {
+					queueErr(err)
+					return poolResult{}
+				}
+				return poolResult{writes: writes, entries: entries}
+			})
+			numRecords += 1
+
+		case <-queueCtx.Done():
+			break Loop
+		}
+	}
+
+	if err := pool.Wait(queueCtx); err != nil {
+		return 0, nil, err
+	}
+	if _, err := pipe.flush(queueCtx); err != nil {
+		return 0, nil, err
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, nil, err
+	}
+	return numRecords, nil, nil
+}
@@ -0,0 +1,585 @@
+package connredis
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	lua "github.com/yuin/gopher-lua"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/pua"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// commands are sent in pipelines of up to this many
+const pipelineSize = 1000
+
+var keyPlaceholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+type RedisConnector struct {
+	*metadataStore.PostgresMetadata
+	client redis.UniversalClient
+	config *protos.RedisConfig
+	logger log.Logger
+}
+
+func NewRedisConnector(
+	ctx context.Context,
+	config *protos.RedisConfig,
+) (*RedisConnector, error) {
+	if len(config.Addresses) == 0 {
+		return nil, errors.New("no redis addresses specified")
+	}
+	opts := &redis.UniversalOptions{
+		Addrs:         config.Addresses,
+		Username:      config.GetUsername(),
+		Password:      config.GetPassword(),
+		DB:            int(config.Database),
+		IsClusterMode: config.Cluster,
+	}
+	if config.Tls {
+		host, _, err := net.SplitHostPort(config.Addresses[0])
+		if err != nil {
+			host = config.Addresses[0]
+		}
+		tlsConfig, err := shared.CreateTlsConfig(tls.VersionTLS12, config.RootCa, host, "", false)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsConfig
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisConnector{
+		PostgresMetadata: pgMetadata,
+		client:           redis.NewUniversalClient(opts),
+		config:           config,
+		logger:           internal.LoggerFromCtx(ctx),
+	}, nil
+}
+
+func (c *RedisConnector) Close() error {
+	if c != nil {
+		return c.client.Close()
+	}
+	return nil
+}
+
+func (c *RedisConnector) ConnectionActive(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis connection active check failure: %w", err)
+	}
+	return nil
+}
+
+func (c *RedisConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+func (c *RedisConnector) ReplayTableSchemaDeltas(_ context.Context, _ map[string]string,
+	flowJobName string, _ []*protos.TableMapping, schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	return nil
+}
+
+// keyTemplate returns the key template of a table, by default its primary key columns prefixed by the table
+func (c *RedisConnector) keyTemplate(schema *protos.TableSchema) (string, error) {
+	if c.config.KeyTemplate != "" {
+		return c.config.KeyTemplate, nil
+	}
+	if schema == nil || len(schema.PrimaryKeyColumns) == 0 {
+		return "", errors.New("table has no primary key to build redis keys from, configure a key template")
+	}
+	var sb strings.Builder
+	sb.WriteString("{table}")
+	for _, column := range schema.PrimaryKeyColumns {
+		sb.WriteString(":{")
+		sb.WriteString(column)
+		sb.WriteByte('}')
+	}
+	return sb.String(), nil
+}
+
+// expandKey replaces the placeholders of template with the table and the row's values
+func expandKey(template string, table string, fields map[string]string) (string, error) {
+	var missing string
+	key := keyPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		if name == "table" {
+			return table
+		}
+		value, ok := fields[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("column %s of key template %s is null or missing from row of %s", missing, template, table)
+	}
+	return key, nil
+}
+
+// rowFields converts a row to hash fields, values are formatted like in JSON with strings unquoted,
+// null columns are returned separately
+func rowFields(items model.RecordItems) (map[string]string, []string, error) {
+	data, err := items.MarshalJSONWithOptions(model.NewToJSONOptions(nil, true))
+	if err != nil {
+		return nil, nil, err
+	}
+	var columns map[string]json.RawMessage
+	if err := json.Unmarshal(data, &columns); err != nil {
+		return nil, nil, err
+	}
+	fields := make(map[string]string, len(columns))
+	var nulls []string
+	for column, value := range columns {
+		switch {
+		case string(value) == "null":
+			nulls = append(nulls, column)
+		case len(value) > 0 && value[0] == '"':
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				return nil, nil, err
+			}
+			fields[column] = s
+		default:
+			fields[column] = string(value)
+		}
+	}
+	slices.Sort(nulls)
+	return fields, nulls, nil
+}
+
+// redisWrite is the change of one key
+type redisWrite struct {
+	// nil deletes the key, string values are SET, field maps are written to a hash
+	value any
+	key   string
+	// hash fields removed because the column is null
+	nullFields []string
+	// the hash is deleted first, so fields missing from value do not linger
+	replace bool
+}
+
+type streamEntry struct {
+	values map[string]any
+	stream string
+}
+
+type poolResult struct {
+	writes  []redisWrite
+	entries []streamEntry
+	lsn     int64
+}
+
+// rowWrite builds the write of a row in the configured value format
+func (c *RedisConnector) rowWrite(key string, items model.RecordItems, replace bool) (redisWrite, error) {
+	if c.config.ValueFormat == protos.RedisValueFormat_REDIS_VALUE_FORMAT_JSON {
+		// values are replaced as a whole, updates with unchanged TOAST columns are rejected by recordWrites
+		value, err := items.ToJSONWithOptions(model.NewToJSONOptions(nil, true))
+		if err != nil {
+			return redisWrite{}, err
+		}
+		return redisWrite{key: key, value: value}, nil
+	}
+	fields, nulls, err := rowFields(items)
+	if err != nil {
+		return redisWrite{}, err
+	}
+	hash := make(map[string]any, len(fields))
+	for field, value := range fields {
+		hash[field] = value
+	}
+	return redisWrite{key: key, value: hash, nullFields: nulls, replace: replace}, nil
+}
+
+// recordWrites returns the default writes of a change: rows are stored at their key and deleted with it
+func (c *RedisConnector) recordWrites(
+	record model.Record[model.RecordItems], schema *protos.TableSchema,
+) ([]redisWrite, []streamEntry, error) {
+	template, err := c.keyTemplate(schema)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", record.GetDestinationTableName(), err)
+	}
+	table := record.GetDestinationTableName()
+	rowKey := func(items model.RecordItems) (string, error) {
+		fields, _, err := rowFields(items)
+		if err != nil {
+			return "", err
+		}
+		return expandKey(template, table, fields)
+	}
+
+	var writes []redisWrite
+	var op string
+	var key string
+	var items model.RecordItems
+	switch r := record.(type) {
+	case *model.InsertRecord[model.RecordItems]:
+		op, items = "insert", r.Items
+		if key, err = rowKey(items); err != nil {
+			return nil, nil, err
+		}
+		write, err := c.rowWrite(key, items, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		writes = append(writes, write)
+	case *model.UpdateRecord[model.RecordItems]:
+		op, items = "update", r.NewItems
+		if c.config.ValueFormat == protos.RedisValueFormat_REDIS_VALUE_FORMAT_JSON && len(r.UnchangedToastColumns) > 0 {
+			// the JSON value would be replaced without these columns, losing their stored values
+			return nil, nil, fmt.Errorf(
+				"[redis] update of %s has unchanged TOAST columns %v which the JSON value format cannot keep, "+
+					"set REPLICA IDENTITY FULL on the source table or use the hash value format",
+				table, slices.Sorted(maps.Keys(r.UnchangedToastColumns)))
+		}
+		if key, err = rowKey(items); err != nil {
+			return nil, nil, err
+		}
+		// a changed key moves the row, old items may only have the key when replica identity is default
+		if r.OldItems.Len() > 0 {
+			if oldKey, err := rowKey(r.OldItems); err == nil && oldKey != key {
+				writes = append(writes, redisWrite{key: oldKey})
+			}
+		}
+		write, err := c.rowWrite(key, items, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		writes = append(writes, write)
+	case *model.DeleteRecord[model.RecordItems]:
+		op, items = "delete", r.Items
+		if key, err = rowKey(items); err != nil {
+			return nil, nil, err
+		}
+		writes = append(writes, redisWrite{key: key})
+	default:
+		return nil, nil, nil
+	}
+
+	if c.config.StreamKey == "" {
+		return writes, nil, nil
+	}
+	row, err := items.ToJSONWithOptions(model.NewToJSONOptions(nil, true))
+	if err != nil {
+		return nil, nil, err
+	}
+	return writes, []streamEntry{{
+		stream: strings.ReplaceAll(c.config.StreamKey, "{table}", table),
+		values: map[string]any{"op": op, "table": table, "key": key, "row": row, "checkpoint": record.GetCheckpointID()},
+	}}, nil
+}
+
+// lvalueToRedisWrite converts a value returned by onRecord, a string stored at the row's default key
+// or a table of key and value, where a string value is SET, a table value replaces a hash and nil deletes the key
+func lvalueToRedisWrite(ls *lua.LState, value lua.LValue, defaultKey func() (string, error)) (redisWrite, bool, error) {
+	switch v := value.(type) {
+	case lua.LString:
+		key, err := defaultKey()
+		if err != nil {
+			return redisWrite{}, false, err
+		}
+		return redisWrite{key: key, value: string(v)}, true, nil
+	case *lua.LTable:
+		key, err := utils.LVAsStringOrNil(ls, ls.GetField(v, "key"))
+		if err != nil {
+			return redisWrite{}, false, fmt.Errorf("invalid key, %w", err)
+		}
+		if key == "" {
+			if key, err = defaultKey(); err != nil {
+				return redisWrite{}, false, err
+			}
+		}
+		write := redisWrite{key: key}
+		switch lvalue := ls.GetField(v, "value").(type) {
+		case lua.LString:
+			write.value = string(lvalue)
+		case *lua.LTable:
+			hash := make(map[string]any)
+			lvalue.ForEach(func(k, v lua.LValue) {
+				hash[k.String()] = v.String()
+			})
+			write.value = hash
+			write.replace = true
+		case *lua.LNilType:
+		default:
+			return redisWrite{}, false, fmt.Errorf("invalid value, must be nil, string or table: %s", lvalue)
+		}
+		return write, true, nil
+	case *lua.LNilType:
+		return redisWrite{}, false, nil
+	default:
+		return redisWrite{}, false, fmt.Errorf("script returned invalid value: %s", value)
+	}
+}
+
+// pipeliner queues writes, sending them in a transaction once enough are queued,
+// so a replaced hash is never seen deleted but not yet set
+type pipeliner struct {
+	pipe      redis.Pipeliner
+	maxLen    int64
+	queuedLSN int64
+}
+
+func (c *RedisConnector) newPipeliner() *pipeliner {
+	return &pipeliner{pipe: c.client.TxPipeline(), maxLen: c.config.StreamMaxLen}
+}
+
+func (p *pipeliner) add(ctx context.Context, result poolResult) {
+	for _, write := range result.writes {
+		switch value := write.value.(type) {
+		case nil:
+			p.pipe.Del(ctx, write.key)
+		case string:
+			p.pipe.Set(ctx, write.key, value, 0)
+		case map[string]any:
+			if write.replace {
+				p.pipe.Del(ctx, write.key)
+			}
+			if len(value) > 0 {
+				p.pipe.HSet(ctx, write.key, value)
+			}
+			if len(write.nullFields) > 0 && !write.replace {
+				p.pipe.HDel(ctx, write.key, write.nullFields...)
+			}
+		}
+	}
+	for _, entry := range result.entries {
+		p.pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: entry.stream,
+			MaxLen: p.maxLen,
+			Approx: p.maxLen > 0,
+			Values: entry.values,
+		})
+	}
+	p.queuedLSN = max(p.queuedLSN, result.lsn)
+}
+
+// flush sends queued writes, returning the checkpoint they cover
+func (p *pipeliner) flush(ctx context.Context) (int64, error) {
+	if p.pipe.Len() > 0 {
+		if _, err := p.pipe.Exec(ctx); err != nil {
+			return 0, fmt.Errorf("[redis] failed to write pipeline: %w", err)
+		}
+	}
+	return p.queuedLSN, nil
+}
+
+func (c *RedisConnector) createPool(
+	ctx context.Context,
+	env map[string]string,
+	script string,
+	flowJobName string,
+	pipe *pipeliner,
+	lastSeenLSN func(int64),
+	queueErr func(error),
+) (*utils.LPool[poolResult], error) {
+	maxSize, err := internal.PeerDBQueueParallelism(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parallelism: %w", err)
+	}
+
+	return utils.LuaPool(int(maxSize), func() (*lua.LState, error) {
+		if script == "" {
+			return lua.NewState(lua.Options{SkipOpenLibs: true}), nil
+		}
+		ls, err := utils.LoadScript(ctx, script, utils.LuaPrintFn(func(s string) {
+			_ = c.LogFlowInfo(ctx, flowJobName, s)
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("[redis] error loading script: %w", err)
+		}
+		return ls, nil
+	}, func(result poolResult) {
+		// writes are queued in record order, so later changes of a key win
+		pipe.add(ctx, result)
+		if pipe.pipe.Len() >= pipelineSize {
+			lsn, err := pipe.flush(ctx)
+			if err != nil {
+				queueErr(err)
+				return
+			}
+			if lastSeenLSN != nil {
+				lastSeenLSN(lsn)
+			}
+		}
+	})
+}
+
+// runScript calls onRecord, rows without a key from the script are stored at their default key
+func (c *RedisConnector) runScript(
+	ls *lua.LState, record model.Record[model.RecordItems], schema *protos.TableSchema,
+) ([]redisWrite, error) {
+	lfn := ls.Env.RawGetString("onRecord")
+	fn, ok := lfn.(*lua.LFunction)
+	if !ok {
+		return nil, fmt.Errorf("script should define `onRecord` as function, not %s", lfn)
+	}
+
+	ls.Push(fn)
+	ls.Push(pua.LuaRecord.New(ls, record))
+	if err := ls.PCall(1, -1, nil); err != nil {
+		return nil, fmt.Errorf("script failed: %w", err)
+	}
+
+	defaultKey := func() (string, error) {
+		template, err := c.keyTemplate(schema)
+		if err != nil {
+			return "", err
+		}
+		fields, _, err := rowFields(record.GetItems())
+		if err != nil {
+			return "", err
+		}
+		return expandKey(template, record.GetDestinationTableName(), fields)
+	}
+	args := ls.GetTop()
+	writes := make([]redisWrite, 0, args)
+	for i := range args {
+		write, ok, err := lvalueToRedisWrite(ls, ls.Get(i-args), defaultKey)
+		if err != nil {
+			return nil, fmt.Errorf("[redis] error creating write: %w", err)
+		}
+		if ok {
+			writes = append(writes, write)
+		}
+	}
+	ls.SetTop(0)
+	return writes, nil
+}
+
+func (c *RedisConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	var numRecords int64
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	queueCtx, queueErr := context.WithCancelCause(ctx)
+
+	pipe := c.newPipeliner()
+	pool, err := c.createPool(queueCtx, req.Env, req.Script, req.FlowJobName, pipe, func(lsn int64) {
+		// keys are set idempotently, but stream entries of changes after the checkpoint are appended again
+		// when a batch is retried, consumers can skip entries whose checkpoint was already seen
+		if lsn > req.ConsumedOffset.Load() {
+			if err := c.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{ID: lsn}); err != nil {
+				c.logger.Warn("[redis] SetLastOffset error", slog.Any("error", err))
+			} else {
+				shared.AtomicInt64Max(req.ConsumedOffset, lsn)
+			}
+		}
+	}, queueErr)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+
+Loop:
+	for {
+		select {
+		case record, ok := <-req.Records.GetRecords():
+			if !ok {
+				c.logger.Info("flushing batches because no more records")
+				break Loop
+			}
+			switch record.(type) {
+			case *model.InsertRecord[model.RecordItems], *model.UpdateRecord[model.RecordItems],
+				*model.DeleteRecord[model.RecordItems]:
+			default:
+				continue
+			}
+			schema := req.TableNameSchemaMapping[record.GetDestinationTableName()]
+
+			pool.Run(func(ls *lua.LState) poolResult {
+				var writes []redisWrite
+				var entries []streamEntry
+				var err error
+				if req.Script != "" {
+					writes, err = c.runScript(ls, record, schema)
+					if err == nil && c.config.StreamKey != "" {
+						_, entries, err = c.recordWrites(record, schema)
+					}
+				} else {
+					writes, entries, err = c.recordWrites(record, schema)
+				}
+				if err != nil {
+					queueErr(err)
+					return poolResult{}
+				}
+				record.PopulateCountMap(tableNameRowsMapping)
+				return poolResult{writes: writes, entries: entries, lsn: record.GetCheckpointID()}
+			})
+			numRecords += 1
+
+		case <-queueCtx.Done():
+			break Loop
+		}
+	}
+
+	if err := pool.Wait(queueCtx); err != nil {
+		return nil, fmt.Errorf("[redis] pool.Wait error: %w", err)
+	}
+	if _, err := pipe.flush(queueCtx); err != nil {
+		return nil, err
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, fmt.Errorf("[redis] FinishBatch error: %w", err)
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:   req.SyncBatchID,
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (c *RedisConnector) ValidateMirrorDestination(
+	_ context.Context,
+	cfg *protos.FlowConnectionConfigs,
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+) error {
+	for _, tm := range cfg.TableMappings {
+		schema, ok := tableNameSchemaMapping[tm.SourceTableIdentifier]
+		if !ok {
+			continue
+		}
+		template, err := c.keyTemplate(schema)
+		if err != nil {
+			if cfg.Script != "" {
+				// scripts may key every row themselves
+				continue
+			}
+			return fmt.Errorf("%s: %w", tm.SourceTableIdentifier, err)
+		}
+		for _, match := range keyPlaceholder.FindAllStringSubmatch(template, -1) {
+			if name := match[1]; name != "table" && !slices.ContainsFunc(schema.Columns, func(fd *protos.FieldDescription) bool {
+				return fd.Name == name
+			}) {
+				return fmt.Errorf("key template %s references column %s missing from table %s",
+					template, name, tm.SourceTableIdentifier)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package connredis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestKeyTemplate(t *testing.T) {
+	c := &RedisConnector{config: &protos.RedisConfig{}}
+	template, err := c.keyTemplate(&protos.TableSchema{PrimaryKeyColumns: []string{"tenant", "id"}})
+	require.NoError(t, err)
+	require.Equal(t, "{table}:{tenant}:{id}", template)
+
+	_, err = c.keyTemplate(&protos.TableSchema{})
+	require.Error(t, err)
+
+	key, err := expandKey(template, "users", map[string]string{"tenant": "a", "id": "7"})
+	require.NoError(t, err)
+	require.Equal(t, "users:a:7", key)
+
+	_, err = expandKey(template, "users", map[string]string{"id": "7"})
+	require.ErrorContains(t, err, "tenant")
+}
+
+func TestRecordWrites(t *testing.T) {
+	c := &RedisConnector{config: &protos.RedisConfig{StreamKey: "changes:{table}"}}
+	schema := &protos.TableSchema{PrimaryKeyColumns: []string{"id"}}
+
+	oldItems := model.NewRecordItems(2)
+	oldItems.AddColumn("id", types.QValueInt64{Val: 1})
+	oldItems.AddColumn("name", types.QValueString{Val: "a"})
+	newItems := model.NewRecordItems(2)
+	newItems.AddColumn("id", types.QValueInt64{Val: 2})
+	newItems.AddColumn("name", types.QValueNull(types.QValueKindString))
+
+	writes, entries, err := c.recordWrites(&model.UpdateRecord[model.RecordItems]{
+		OldItems:             oldItems,
+		BaseRecord:           model.BaseRecord{CheckpointID: 42},
+		NewItems:             newItems,
+		DestinationTableName: "users",
+	}, schema)
+	require.NoError(t, err)
+	require.Equal(t, []redisWrite{
+		{key: "users:1"},
+		{key: "users:2", value: map[string]any{"id": "2"}, nullFields: []string{"name"}},
+	}, writes)
+	require.Len(t, entries, 1)
+	require.Equal(t, "changes:users", entries[0].stream)
+	require.Equal(t, "update", entries[0].values["op"])
+	require.Equal(t, "users:2", entries[0].values["key"])
+	require.Equal(t, int64(42), entries[0].values["checkpoint"])
+
+	writes, _, err = c.recordWrites(&model.DeleteRecord[model.RecordItems]{
+		Items:                oldItems,
+		DestinationTableName: "users",
+	}, schema)
+	require.NoError(t, err)
+	require.Equal(t, []redisWrite{{key: "users:1"}}, writes)
+}
+
+func TestRecordWritesJSONUnchangedToast(t *testing.T) {
+	c := &RedisConnector{config: &protos.RedisConfig{ValueFormat: protos.RedisValueFormat_REDIS_VALUE_FORMAT_JSON}}
+	schema := &protos.TableSchema{PrimaryKeyColumns: []string{"id"}}
+
+	items := model.NewRecordItems(1)
+	items.AddColumn("id", types.QValueInt64{Val: 1})
+	update := &model.UpdateRecord[model.RecordItems]{
+		NewItems:             items,
+		DestinationTableName: "users",
+	}
+	writes, _, err := c.recordWrites(update, schema)
+	require.NoError(t, err)
+	require.Len(t, writes, 1)
+
+	update.UnchangedToastColumns = map[string]struct{}{"body": {}}
+	_, _, err = c.recordWrites(update, schema)
+	require.ErrorContains(t, err, "body")
+}
+
+func TestLValueToRedisWrite(t *testing.T) {
+	ls := lua.NewState()
+	defer ls.Close()
+	defaultKey := func() (string, error) { return "users:1", nil }
+
+	require.NoError(t, ls.DoString(`
+		s = "cached"
+		h = { key = "profile:1", value = { name = "a" } }
+		d = { key = "profile:2" }
+	`))
+
+	write, ok, err := lvalueToRedisWrite(ls, ls.GetGlobal("s"), defaultKey)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, redisWrite{key: "users:1", value: "cached"}, write)
+
+	write, ok, err = lvalueToRedisWrite(ls, ls.GetGlobal("h"), defaultKey)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, redisWrite{key: "profile:1", value: map[string]any{"name": "a"}, replace: true}, write)
+
+	write, ok, err = lvalueToRedisWrite(ls, ls.GetGlobal("d"), defaultKey)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, redisWrite{key: "profile:2"}, write)
+
+	_, ok, err = lvalueToRedisWrite(ls, lua.LNil, defaultKey)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
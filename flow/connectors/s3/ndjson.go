@@ -0,0 +1,138 @@
+package conns3
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/hamba/avro/v2"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+// jsonValueForAvro turns a value produced by QValueToAvro into its JSON representation,
+// using the Avro schema of the column to render logical types
+func jsonValueForAvro(schema avro.Schema, value any) any {
+	value = derefAvroValue(value)
+	if value == nil {
+		return nil
+	}
+	if union, ok := schema.(*avro.UnionSchema); ok {
+		for _, typ := range union.Types() {
+			if typ.Type() != avro.Null {
+				return jsonValueForAvro(typ, value)
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case big.Rat:
+		scale := 0
+		if primitive, ok := schema.(*avro.PrimitiveSchema); ok {
+			if decimal, ok := primitive.Logical().(*avro.DecimalLogicalSchema); ok {
+				scale = decimal.Scale()
+			}
+		}
+		return json.Number(v.FloatString(scale))
+	case time.Time:
+		if primitive, ok := schema.(*avro.PrimitiveSchema); ok && primitive.Logical() != nil &&
+			primitive.Logical().Type() == avro.Date {
+			return v.Format(time.DateOnly)
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	case time.Duration:
+		return time.Time{}.Add(v).Format("15:04:05.999999")
+	case [32]uint8:
+		// little endian two's complement, see QValueAvroConverter.processInt256
+		be := make([]byte, len(v))
+		for i, b := range v {
+			be[len(v)-1-i] = b
+		}
+		num := new(big.Int).SetBytes(be)
+		if fixed, ok := schema.(*avro.FixedSchema); ok && fixed.Name() == "int256" && v[len(v)-1]&0x80 != 0 {
+			num.Sub(num, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		return json.Number(num.String())
+	case float64:
+		// JSON has no NaN or infinities, written as null like other JSON of records
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+		return v
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return nil
+		}
+		return v
+	case []byte, string:
+		return v
+	}
+
+	if array, ok := schema.(*avro.ArraySchema); ok {
+		if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice {
+			values := make([]any, rv.Len())
+			for i := range values {
+				values[i] = jsonValueForAvro(array.Items(), rv.Index(i).Interface())
+			}
+			return values
+		}
+	}
+	return value
+}
+
+// writeNDJSON writes one gzip compressed JSON object per record
+func (c *S3Connector) writeNDJSON(
+	ctx context.Context,
+	env map[string]string,
+	w io.Writer,
+	stream *model.QRecordStream,
+	avroSchema *model.QRecordAvroSchemaDefinition,
+) (int64, error) {
+	converter, err := newS3AvroConverter(ctx, env, avroSchema, c.logger)
+	if err != nil {
+		return 0, err
+	}
+	format, err := internal.PeerDBBinaryFormat(ctx, env)
+	if err != nil {
+		return 0, err
+	}
+
+	gz := gzip.NewWriter(w)
+	defer gz.Close()
+	encoder := json.NewEncoder(gz)
+	encoder.SetEscapeHTML(false)
+
+	fields := avroSchema.Schema.Fields()
+	var numRows int64
+	for qrecord := range stream.Records {
+		if err := ctx.Err(); err != nil {
+			return numRows, err
+		}
+		avroMap, err := converter.Convert(ctx, env, qrecord, nil, nil, format)
+		if err != nil {
+			return numRows, fmt.Errorf("failed to convert QRecord to Avro compatible map: %w", err)
+		}
+		row := make(map[string]any, len(fields))
+		for _, field := range fields {
+			row[field.Name()] = jsonValueForAvro(field.Type(), avroMap[field.Name()])
+		}
+		if err := encoder.Encode(row); err != nil {
+			return numRows, fmt.Errorf("failed to write record as JSON: %w", err)
+		}
+		numRows += 1
+	}
+	if err := stream.Err(); err != nil {
+		return numRows, fmt.Errorf("failed to get record from stream: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return numRows, fmt.Errorf("failed to close gzip writer: %w", err)
+	}
+	return numRows, nil
+}
@@ -0,0 +1,316 @@
+package conns3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/decimal256"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/hamba/avro/v2"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+// rows buffered per Parquet row group
+const parquetRowGroupSize = 1 << 16
+
+// arrowTypeForAvro maps the Avro schema generated for a QRecordSchema onto Arrow,
+// so Parquet files get the same column types as the Avro files written for the same stream.
+func arrowTypeForAvro(schema avro.Schema) (arrow.DataType, bool, error) {
+	if union, ok := schema.(*avro.UnionSchema); ok {
+		for _, typ := range union.Types() {
+			if typ.Type() != avro.Null {
+				dataType, _, err := arrowTypeForAvro(typ)
+				return dataType, true, err
+			}
+		}
+		return nil, false, fmt.Errorf("unsupported avro union %s", schema.String())
+	}
+
+	switch s := schema.(type) {
+	case *avro.PrimitiveSchema:
+		var logicalType avro.LogicalType
+		if s.Logical() != nil {
+			logicalType = s.Logical().Type()
+		}
+		switch s.Type() {
+		case avro.Boolean:
+			return arrow.FixedWidthTypes.Boolean, false, nil
+		case avro.Int:
+			if logicalType == avro.Date {
+				return arrow.FixedWidthTypes.Date32, false, nil
+			}
+			return arrow.PrimitiveTypes.Int32, false, nil
+		case avro.Long:
+			switch logicalType {
+			case avro.TimeMicros:
+				return arrow.FixedWidthTypes.Time64us, false, nil
+			case avro.TimestampMicros:
+				return arrow.FixedWidthTypes.Timestamp_us, false, nil
+			}
+			return arrow.PrimitiveTypes.Int64, false, nil
+		case avro.Float:
+			return arrow.PrimitiveTypes.Float32, false, nil
+		case avro.Double:
+			return arrow.PrimitiveTypes.Float64, false, nil
+		case avro.String:
+			return arrow.BinaryTypes.String, false, nil
+		case avro.Bytes:
+			if decimal, ok := s.Logical().(*avro.DecimalLogicalSchema); ok {
+				if decimal.Precision() > decimal128.MaxPrecision {
+					return &arrow.Decimal256Type{Precision: int32(decimal.Precision()), Scale: int32(decimal.Scale())}, false, nil
+				}
+				return &arrow.Decimal128Type{Precision: int32(decimal.Precision()), Scale: int32(decimal.Scale())}, false, nil
+			}
+			return arrow.BinaryTypes.Binary, false, nil
+		}
+	case *avro.FixedSchema:
+		return &arrow.FixedSizeBinaryType{ByteWidth: s.Size()}, false, nil
+	case *avro.ArraySchema:
+		elemType, _, err := arrowTypeForAvro(s.Items())
+		if err != nil {
+			return nil, false, err
+		}
+		return arrow.ListOf(elemType), false, nil
+	}
+	return nil, false, fmt.Errorf("unsupported avro type %s for parquet", schema.String())
+}
+
+func arrowSchemaForAvro(schema *avro.RecordSchema) (*arrow.Schema, error) {
+	fields := make([]arrow.Field, 0, len(schema.Fields()))
+	for _, field := range schema.Fields() {
+		dataType, nullable, err := arrowTypeForAvro(field.Type())
+		if err != nil {
+			return nil, fmt.Errorf("failed to map column %s: %w", field.Name(), err)
+		}
+		fields = append(fields, arrow.Field{Name: field.Name(), Type: dataType, Nullable: nullable})
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+func parquetCompression(codec protos.AvroCodec) (compress.Compression, error) {
+	switch codec {
+	case protos.AvroCodec_Null:
+		return compress.Codecs.Uncompressed, nil
+	case protos.AvroCodec_Deflate:
+		return compress.Codecs.Gzip, nil
+	case protos.AvroCodec_Snappy:
+		return compress.Codecs.Snappy, nil
+	case protos.AvroCodec_ZStandard:
+		return compress.Codecs.Zstd, nil
+	default:
+		return compress.Codecs.Uncompressed, fmt.Errorf("unsupported codec %s", codec)
+	}
+}
+
+// derefAvroValue unwraps the pointers QValueToAvro returns for nullable columns
+func derefAvroValue(value any) any {
+	if value == nil {
+		return nil
+	}
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		if rat, ok := value.(*big.Rat); ok {
+			return *rat
+		}
+		return rv.Elem().Interface()
+	}
+	return value
+}
+
+// appendAvroValue appends a value produced by QValueToAvro to the builder of its column
+func appendAvroValue(builder array.Builder, value any) error {
+	value = derefAvroValue(value)
+	if value == nil {
+		builder.AppendNull()
+		return nil
+	}
+
+	switch b := builder.(type) {
+	case *array.BooleanBuilder:
+		if v, ok := value.(bool); ok {
+			b.Append(v)
+			return nil
+		}
+	case *array.Int32Builder:
+		if v, ok := value.(int32); ok {
+			b.Append(v)
+			return nil
+		}
+	case *array.Int64Builder:
+		if v, ok := value.(int64); ok {
+			b.Append(v)
+			return nil
+		}
+	case *array.Float32Builder:
+		if v, ok := value.(float32); ok {
+			b.Append(v)
+			return nil
+		}
+	case *array.Float64Builder:
+		switch v := value.(type) {
+		case float64:
+			b.Append(v)
+			return nil
+		case float32:
+			b.Append(float64(v))
+			return nil
+		}
+	case *array.StringBuilder:
+		if v, ok := value.(string); ok {
+			b.Append(v)
+			return nil
+		}
+	case *array.BinaryBuilder:
+		if v, ok := value.([]byte); ok {
+			b.Append(v)
+			return nil
+		}
+	case *array.FixedSizeBinaryBuilder:
+		if v, ok := value.([32]uint8); ok {
+			b.Append(v[:])
+			return nil
+		}
+	case *array.Date32Builder:
+		if v, ok := value.(time.Time); ok {
+			b.Append(arrow.Date32FromTime(v))
+			return nil
+		}
+	case *array.Time64Builder:
+		if v, ok := value.(time.Duration); ok {
+			b.Append(arrow.Time64(v.Microseconds()))
+			return nil
+		}
+	case *array.TimestampBuilder:
+		if v, ok := value.(time.Time); ok {
+			b.Append(arrow.Timestamp(v.UnixMicro()))
+			return nil
+		}
+	case *array.Decimal128Builder:
+		if v, ok := value.(big.Rat); ok {
+			dt := b.Type().(*arrow.Decimal128Type)
+			num, err := decimal128.FromString(v.FloatString(int(dt.Scale)), dt.Precision, dt.Scale)
+			if err != nil {
+				return err
+			}
+			b.Append(num)
+			return nil
+		}
+	case *array.Decimal256Builder:
+		if v, ok := value.(big.Rat); ok {
+			dt := b.Type().(*arrow.Decimal256Type)
+			num, err := decimal256.FromString(v.FloatString(int(dt.Scale)), dt.Precision, dt.Scale)
+			if err != nil {
+				return err
+			}
+			b.Append(num)
+			return nil
+		}
+	case *array.ListBuilder:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() == reflect.Slice {
+			b.Append(true)
+			for i := range rv.Len() {
+				if err := appendAvroValue(b.ValueBuilder(), rv.Index(i).Interface()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected value of type %T for parquet column of type %s", value, builder.Type())
+}
+
+func (c *S3Connector) writeParquet(
+	ctx context.Context,
+	env map[string]string,
+	w io.Writer,
+	stream *model.QRecordStream,
+	avroSchema *model.QRecordAvroSchemaDefinition,
+) (int64, error) {
+	arrowSchema, err := arrowSchemaForAvro(avroSchema.Schema)
+	if err != nil {
+		return 0, err
+	}
+	compression, err := parquetCompression(c.codec)
+	if err != nil {
+		return 0, err
+	}
+	converter, err := newS3AvroConverter(ctx, env, avroSchema, c.logger)
+	if err != nil {
+		return 0, err
+	}
+	format, err := internal.PeerDBBinaryFormat(ctx, env)
+	if err != nil {
+		return 0, err
+	}
+
+	writer, err := pqarrow.NewFileWriter(arrowSchema, w,
+		parquet.NewWriterProperties(
+			parquet.WithCompression(compression),
+			parquet.WithStats(true),
+			parquet.WithMaxRowGroupLength(parquetRowGroupSize),
+		),
+		pqarrow.DefaultWriterProps(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create parquet writer: %w", err)
+	}
+	defer writer.Close()
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, arrowSchema)
+	defer builder.Release()
+	flush := func() error {
+		record := builder.NewRecord()
+		defer record.Release()
+		return writer.Write(record)
+	}
+
+	var numRows int64
+	for qrecord := range stream.Records {
+		if err := ctx.Err(); err != nil {
+			return numRows, err
+		}
+		avroMap, err := converter.Convert(ctx, env, qrecord, nil, nil, format)
+		if err != nil {
+			return numRows, fmt.Errorf("failed to convert QRecord to Avro compatible map: %w", err)
+		}
+		for i, field := range arrowSchema.Fields() {
+			if err := appendAvroValue(builder.Field(i), avroMap[field.Name]); err != nil {
+				return numRows, fmt.Errorf("failed to write column %s: %w", field.Name, err)
+			}
+		}
+		numRows += 1
+		if numRows%parquetRowGroupSize == 0 {
+			if err := flush(); err != nil {
+				return numRows, fmt.Errorf("failed to write parquet row group: %w", err)
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return numRows, fmt.Errorf("failed to get record from stream: %w", err)
+	}
+	if numRows%parquetRowGroupSize != 0 || numRows == 0 {
+		if err := flush(); err != nil {
+			return numRows, fmt.Errorf("failed to write parquet row group: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return numRows, fmt.Errorf("failed to close parquet writer: %w", err)
+	}
+	return numRows, nil
+}
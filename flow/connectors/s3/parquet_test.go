@@ -0,0 +1,120 @@
+package conns3
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/hamba/avro/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func testAvroSchema(t *testing.T) *avro.RecordSchema {
+	t.Helper()
+	nullable := func(schema avro.Schema) avro.Schema {
+		union, err := avro.NewUnionSchema([]avro.Schema{avro.NewNullSchema(), schema})
+		require.NoError(t, err)
+		return union
+	}
+	var fields []*avro.Field
+	for name, typ := range map[string]avro.Schema{
+		"id":     avro.NewPrimitiveSchema(avro.Long, nil),
+		"amount": nullable(avro.NewPrimitiveSchema(avro.Bytes, avro.NewDecimalLogicalSchema(10, 2))),
+		"day":    avro.NewPrimitiveSchema(avro.Int, avro.NewPrimitiveLogicalSchema(avro.Date)),
+		"tags":   avro.NewArraySchema(avro.NewPrimitiveSchema(avro.String, nil)),
+	} {
+		field, err := avro.NewField(name, typ)
+		require.NoError(t, err)
+		fields = append(fields, field)
+	}
+	schema, err := avro.NewRecordSchema("t", "", fields)
+	require.NoError(t, err)
+	return schema
+}
+
+func TestArrowSchemaForAvro(t *testing.T) {
+	arrowSchema, err := arrowSchemaForAvro(testAvroSchema(t))
+	require.NoError(t, err)
+
+	types := make(map[string]arrow.Field)
+	for _, field := range arrowSchema.Fields() {
+		types[field.Name] = field
+	}
+	require.Equal(t, arrow.PrimitiveTypes.Int64, types["id"].Type)
+	require.False(t, types["id"].Nullable)
+	require.Equal(t, &arrow.Decimal128Type{Precision: 10, Scale: 2}, types["amount"].Type)
+	require.True(t, types["amount"].Nullable)
+	require.Equal(t, arrow.FixedWidthTypes.Date32, types["day"].Type)
+	require.Equal(t, arrow.ListOf(arrow.BinaryTypes.String), types["tags"].Type)
+}
+
+func TestAppendAvroValue(t *testing.T) {
+	arrowSchema, err := arrowSchemaForAvro(testAvroSchema(t))
+	require.NoError(t, err)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, arrowSchema)
+	defer builder.Release()
+
+	amount := big.NewRat(12345, 100)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	values := map[string]any{"id": int64(7), "amount": amount, "day": day, "tags": []string{"a", "b"}}
+	for i, field := range arrowSchema.Fields() {
+		require.NoError(t, appendAvroValue(builder.Field(i), values[field.Name]))
+	}
+	for i, field := range arrowSchema.Fields() {
+		if field.Name == "amount" {
+			require.NoError(t, appendAvroValue(builder.Field(i), nil))
+		} else {
+			require.NoError(t, appendAvroValue(builder.Field(i), values[field.Name]))
+		}
+	}
+	record := builder.NewRecord()
+	defer record.Release()
+	require.Equal(t, int64(2), record.NumRows())
+
+	for i, field := range arrowSchema.Fields() {
+		if field.Name == "amount" {
+			col := record.Column(i).(*array.Decimal128)
+			require.Equal(t, "123.45", col.Value(0).ToString(2))
+			require.True(t, col.IsNull(1))
+		}
+	}
+
+	require.Error(t, appendAvroValue(builder.Field(0), "not a number"))
+}
+
+func TestJSONValueForAvro(t *testing.T) {
+	schema := testAvroSchema(t)
+	row := make(map[string]any)
+	values := map[string]any{
+		"id":     int64(7),
+		"amount": big.NewRat(12345, 100),
+		"day":    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		"tags":   []string{"a"},
+	}
+	for _, field := range schema.Fields() {
+		row[field.Name()] = jsonValueForAvro(field.Type(), values[field.Name()])
+	}
+	data, err := json.Marshal(row)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":7,"amount":123.45,"day":"2024-03-01","tags":["a"]}`, string(data))
+}
+
+func TestJSONValueForAvroFloats(t *testing.T) {
+	double := avro.NewPrimitiveSchema(avro.Double, nil)
+	float := avro.NewPrimitiveSchema(avro.Float, nil)
+	row := map[string]any{
+		"nan":   jsonValueForAvro(double, math.NaN()),
+		"inf":   jsonValueForAvro(double, math.Inf(-1)),
+		"f32":   jsonValueForAvro(float, float32(math.Inf(1))),
+		"value": jsonValueForAvro(double, 1.5),
+		"array": jsonValueForAvro(avro.NewArraySchema(double), []float64{1, math.NaN()}),
+	}
+	data, err := json.Marshal(row)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"nan":null,"inf":null,"f32":null,"value":1.5,"array":[1,null]}`, string(data))
+}
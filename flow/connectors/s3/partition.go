@@ -0,0 +1,116 @@
+package conns3
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// hivePartition is the dt=YYYY-MM-DD/hr=HH partition of one destination table
+type hivePartition struct {
+	table string
+	dt    string
+	hr    string
+}
+
+func newHivePartition(table string, t time.Time) hivePartition {
+	t = t.UTC()
+	return hivePartition{
+		table: table,
+		dt:    t.Format(time.DateOnly),
+		hr:    fmt.Sprintf("%02d", t.Hour()),
+	}
+}
+
+// objectPath is <table>/dt=YYYY-MM-DD/hr=HH/batch_<id>, under mirrorName when it is not empty
+func (p hivePartition) objectPath(mirrorName string, batchID int64) string {
+	path := fmt.Sprintf("%s/dt=%s/hr=%s/batch_%d", p.table, p.dt, p.hr, batchID)
+	if mirrorName != "" {
+		return mirrorName + "/" + path
+	}
+	return path
+}
+
+// partitionTime is the value of the partition column, or the commit time when no column is configured
+// or the record has no value for it, e.g. truncates
+func (c *S3Connector) partitionTime(record model.Record[model.RecordItems]) (time.Time, error) {
+	if c.partitionColumn == "" {
+		return record.GetCommitTime(), nil
+	}
+	items := record.GetItems()
+	if items.ColToVal == nil {
+		return record.GetCommitTime(), nil
+	}
+	switch v := items.GetColumnValue(c.partitionColumn).(type) {
+	case nil, types.QValueNull:
+		return record.GetCommitTime(), nil
+	case types.QValueTimestamp:
+		return v.Val, nil
+	case types.QValueTimestampTZ:
+		return v.Val, nil
+	case types.QValueDate:
+		return v.Val, nil
+	default:
+		return time.Time{}, fmt.Errorf("partition column %s of %s has unsupported type %s",
+			c.partitionColumn, record.GetDestinationTableName(), v.Kind())
+	}
+}
+
+// syncPartitionedRecords splits a batch by destination table and hour, writing each split to its own object
+func (c *S3Connector) syncPartitionedRecords(
+	ctx context.Context,
+	req *model.SyncRecordsRequest[model.RecordItems],
+	tableNameRowsMapping map[string]*model.RecordTypeCounts,
+) (int64, error) {
+	partitions := make(map[hivePartition][]model.Record[model.RecordItems])
+	var order []hivePartition
+	for record := range req.Records.GetRecords() {
+		switch record.(type) {
+		case *model.MessageRecord[model.RecordItems], *model.RelationRecord[model.RecordItems]:
+			continue
+		}
+		t, err := c.partitionTime(record)
+		if err != nil {
+			return 0, err
+		}
+		partition := newHivePartition(record.GetDestinationTableName(), t)
+		if _, ok := partitions[partition]; !ok {
+			order = append(order, partition)
+		}
+		partitions[partition] = append(partitions[partition], record)
+	}
+
+	var numRecords int64
+	for _, partition := range order {
+		records := partitions[partition]
+		recordsChan := make(chan model.Record[model.RecordItems], len(records))
+		for _, record := range records {
+			recordsChan <- record
+		}
+		close(recordsChan)
+
+		streamReq := model.NewRecordsToStreamRequest(
+			recordsChan, tableNameRowsMapping, req.SyncBatchID, false, protos.DBType_S3,
+		)
+		recordStream, err := utils.RecordsToRawTableStream(streamReq, nil)
+		if err != nil {
+			return 0, fmt.Errorf("failed to convert records to raw table stream: %w", err)
+		}
+		var mirrorName string
+		if c.prefixMirrorName {
+			mirrorName = req.FlowJobName
+		}
+		objectPath := partition.objectPath(mirrorName, req.SyncBatchID)
+		written, err := c.writeStream(ctx, req.Env, recordStream, "raw_table_"+req.FlowJobName, objectPath)
+		if err != nil {
+			return 0, fmt.Errorf("failed to write partition %s: %w", objectPath, err)
+		}
+		numRecords += written
+	}
+	return numRecords, nil
+}
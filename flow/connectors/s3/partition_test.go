@@ -0,0 +1,50 @@
+package conns3
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestHivePartitionObjectPath(t *testing.T) {
+	commitTime := time.Date(2024, 3, 1, 23, 30, 0, 0, time.FixedZone("", -2*60*60))
+	partition := newHivePartition("public.orders", commitTime)
+	require.Equal(t, "public.orders/dt=2024-03-02/hr=01/batch_12", partition.objectPath("", 12))
+	require.Equal(t, "mirror/public.orders/dt=2024-03-02/hr=01/batch_12", partition.objectPath("mirror", 12))
+}
+
+func TestPartitionTime(t *testing.T) {
+	commitTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2023, 12, 31, 5, 0, 0, 0, time.UTC)
+	items := model.NewRecordItems(2)
+	items.AddColumn("id", types.QValueInt64{Val: 1})
+	items.AddColumn("created_at", types.QValueTimestamp{Val: createdAt})
+	record := &model.InsertRecord[model.RecordItems]{
+		BaseRecord:           model.BaseRecord{CommitTimeNano: commitTime.UnixNano()},
+		DestinationTableName: "orders",
+		Items:                items,
+	}
+
+	c := &S3Connector{}
+	partitionTime, err := c.partitionTime(record)
+	require.NoError(t, err)
+	require.True(t, commitTime.Equal(partitionTime))
+
+	c.partitionColumn = "created_at"
+	partitionTime, err = c.partitionTime(record)
+	require.NoError(t, err)
+	require.Equal(t, createdAt, partitionTime)
+
+	items.AddColumn("created_at", types.QValueNull(types.QValueKindTimestamp))
+	partitionTime, err = c.partitionTime(record)
+	require.NoError(t, err)
+	require.True(t, commitTime.Equal(partitionTime))
+
+	c.partitionColumn = "id"
+	_, err = c.partitionTime(record)
+	require.Error(t, err)
+}
@@ -2,12 +2,21 @@ package conns3
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"runtime/debug"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/hamba/avro/v2/ocf"
+	"go.temporal.io/sdk/log"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
@@ -19,23 +28,47 @@ func (c *S3Connector) SyncQRepRecords(
 	partition *protos.QRepPartition,
 	stream *model.QRecordStream,
 ) (int64, shared.QRepWarnings, error) {
-	schema, err := stream.Schema()
+	numRecords, err := c.writeStream(ctx, config.Env, stream, config.DestinationTableIdentifier,
+		config.FlowJobName+"/"+partition.PartitionId)
 	if err != nil {
 		return 0, nil, err
 	}
 
-	dstTableName := config.DestinationTableIdentifier
-	avroSchema, err := getAvroSchema(ctx, config.Env, dstTableName, schema)
+	return numRecords, nil, nil
+}
+
+// writeStream writes the stream as a single object at <prefix>/<objectPath>, with the extension of the configured format
+func (c *S3Connector) writeStream(
+	ctx context.Context,
+	env map[string]string,
+	stream *model.QRecordStream,
+	dstTableName string,
+	objectPath string,
+) (int64, error) {
+	schema, err := stream.Schema()
 	if err != nil {
-		return 0, nil, err
+		return 0, err
 	}
 
-	numRecords, err := c.writeToAvroFile(ctx, config.Env, stream, avroSchema, partition.PartitionId, config.FlowJobName)
+	avroSchema, err := getAvroSchema(ctx, env, dstTableName, schema)
 	if err != nil {
-		return 0, nil, err
+		return 0, err
 	}
 
-	return numRecords, nil, nil
+	switch c.format {
+	case protos.S3Format_S3_FORMAT_AVRO:
+		return c.writeToAvroFile(ctx, env, stream, avroSchema, objectPath)
+	case protos.S3Format_S3_FORMAT_PARQUET:
+		return c.writeToS3File(ctx, env, objectPath+".parquet", func(w io.Writer) (int64, error) {
+			return c.writeParquet(ctx, env, w, stream, avroSchema)
+		})
+	case protos.S3Format_S3_FORMAT_NDJSON:
+		return c.writeToS3File(ctx, env, objectPath+".ndjson.gz", func(w io.Writer) (int64, error) {
+			return c.writeNDJSON(ctx, env, w, stream, avroSchema)
+		})
+	default:
+		return 0, fmt.Errorf("unsupported format %s", c.format)
+	}
 }
 
 func getAvroSchema(
@@ -58,15 +91,14 @@ func (c *S3Connector) writeToAvroFile(
 	env map[string]string,
 	stream *model.QRecordStream,
 	avroSchema *model.QRecordAvroSchemaDefinition,
-	partitionID string,
-	jobName string,
+	objectPath string,
 ) (int64, error) {
 	s3o, err := utils.NewS3BucketAndPrefix(c.url)
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse bucket path: %w", err)
 	}
 
-	s3AvroFileKey := fmt.Sprintf("%s/%s/%s.avro", s3o.Prefix, jobName, partitionID)
+	s3AvroFileKey := fmt.Sprintf("%s/%s.avro", s3o.Prefix, objectPath)
 
 	var codec ocf.CodecName
 	switch c.codec {
@@ -92,6 +124,84 @@ func (c *S3Connector) writeToAvroFile(
 	return avroFile.NumRecords, nil
 }
 
+func newS3AvroConverter(
+	ctx context.Context,
+	env map[string]string,
+	avroSchema *model.QRecordAvroSchemaDefinition,
+	logger log.Logger,
+) (*model.QRecordAvroConverter, error) {
+	fields := avroSchema.Schema.Fields()
+	colNames := make([]string, 0, len(fields))
+	for _, field := range fields {
+		colNames = append(colNames, field.Name())
+	}
+	return model.NewQRecordAvroConverter(ctx, env, avroSchema, protos.DBType_S3, colNames, logger)
+}
+
+// writeToS3File streams the output of write to <prefix>/<fileName>
+func (c *S3Connector) writeToS3File(
+	ctx context.Context,
+	env map[string]string,
+	fileName string,
+	write func(io.Writer) (int64, error),
+) (int64, error) {
+	s3o, err := utils.NewS3BucketAndPrefix(c.url)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse bucket path: %w", err)
+	}
+	key := fmt.Sprintf("%s/%s", s3o.Prefix, fileName)
+
+	partSize, err := internal.PeerDBS3PartSize(ctx, env)
+	if err != nil {
+		return 0, fmt.Errorf("could not get s3 part size config: %w", err)
+	}
+
+	r, w := io.Pipe()
+	type writeResult struct {
+		err     error
+		numRows int64
+	}
+	written := make(chan writeResult, 1)
+	go func() {
+		var res writeResult
+		defer func() {
+			if r := recover(); r != nil {
+				res.err = fmt.Errorf("panic occurred while writing %s: %v", fileName, r)
+				c.logger.Error("panic while writing S3 file", slog.Any("error", res.err), slog.String("stack", string(debug.Stack())))
+			}
+			// ends the upload with the error instead of a truncated file
+			w.CloseWithError(res.err)
+			written <- res
+		}()
+		res.numRows, res.err = write(w)
+	}()
+
+	uploader := manager.NewUploader(&c.client, func(u *manager.Uploader) {
+		if partSize > 0 {
+			u.PartSize = partSize
+			if partSize > 268435455 { // 256MiB
+				u.Concurrency = 1
+			}
+		}
+	})
+	_, uploadErr := uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s3o.Bucket),
+		Key:    aws.String(key),
+		Body:   r,
+	})
+	// unblocks the writer when the upload stopped reading early
+	r.CloseWithError(uploadErr)
+	res := <-written
+	// a writer failing on the closed pipe only echoes the upload error
+	if res.err != nil && (uploadErr == nil || !errors.Is(res.err, uploadErr)) {
+		return 0, fmt.Errorf("failed to write %s: %w", fileName, res.err)
+	}
+	if uploadErr != nil {
+		return 0, fmt.Errorf("failed to upload file to s3://%s/%s: %w", s3o.Bucket, key, uploadErr)
+	}
+	return res.numRows, nil
+}
+
 // S3 just sets up destination, not metadata tables
 func (c *S3Connector) SetupQRepMetadataTables(_ context.Context, config *protos.QRepConfig) error {
 	c.logger.Info("QRep metadata setup not needed for S3.")
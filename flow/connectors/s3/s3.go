@@ -2,6 +2,7 @@ package conns3
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 
@@ -22,6 +23,10 @@ type S3Connector struct {
 	client              s3.Client
 	url                 string
 	codec               protos.AvroCodec
+	partitionColumn     string
+	format              protos.S3Format
+	layout              protos.S3Layout
+	prefixMirrorName    bool
 }
 
 func NewS3Connector(
@@ -51,6 +56,10 @@ func NewS3Connector(
 		logger:              logger,
 		url:                 config.Url,
 		codec:               config.Codec,
+		format:              config.Format,
+		layout:              config.Layout,
+		partitionColumn:     config.PartitionColumn,
+		prefixMirrorName:    config.PrefixMirrorName,
 	}, nil
 }
 
@@ -64,6 +73,13 @@ func (c *S3Connector) Close() error {
 }
 
 func (c *S3Connector) ValidateCheck(ctx context.Context) error {
+	if c.partitionColumn != "" && c.layout != protos.S3Layout_S3_LAYOUT_HIVE {
+		return errors.New("partition column is only used with the hive layout")
+	}
+	if c.prefixMirrorName && c.layout != protos.S3Layout_S3_LAYOUT_HIVE {
+		return errors.New("mirror name prefix is only used with the hive layout")
+	}
+
 	bucketPrefix, parseErr := utils.NewS3BucketAndPrefix(c.url)
 	if parseErr != nil {
 		return fmt.Errorf("failed to parse bucket url: %w", parseErr)
@@ -78,23 +94,13 @@ func (c *S3Connector) ConnectionActive(ctx context.Context) error {
 
 func (c *S3Connector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
 	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
-	streamReq := model.NewRecordsToStreamRequest(
-		req.Records.GetRecords(), tableNameRowsMapping, req.SyncBatchID, false, protos.DBType_S3,
-	)
-	recordStream, err := utils.RecordsToRawTableStream(streamReq, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert records to raw table stream: %w", err)
+	var numRecords int64
+	var err error
+	if c.layout == protos.S3Layout_S3_LAYOUT_HIVE {
+		numRecords, err = c.syncPartitionedRecords(ctx, req, tableNameRowsMapping)
+	} else {
+		numRecords, err = c.syncRawRecords(ctx, req, tableNameRowsMapping)
 	}
-	qrepConfig := &protos.QRepConfig{
-		FlowJobName:                req.FlowJobName,
-		DestinationTableIdentifier: "raw_table_" + req.FlowJobName,
-		Env:                        req.Env,
-		Version:                    req.Version,
-	}
-	partition := &protos.QRepPartition{
-		PartitionId: strconv.FormatInt(req.SyncBatchID, 10),
-	}
-	numRecords, _, err := c.SyncQRepRecords(ctx, qrepConfig, partition, recordStream)
 	if err != nil {
 		return nil, err
 	}
@@ -115,6 +121,32 @@ func (c *S3Connector) SyncRecords(ctx context.Context, req *model.SyncRecordsReq
 	}, nil
 }
 
+// syncRawRecords writes the whole batch as a single object under <prefix>/<flow>/
+func (c *S3Connector) syncRawRecords(
+	ctx context.Context,
+	req *model.SyncRecordsRequest[model.RecordItems],
+	tableNameRowsMapping map[string]*model.RecordTypeCounts,
+) (int64, error) {
+	streamReq := model.NewRecordsToStreamRequest(
+		req.Records.GetRecords(), tableNameRowsMapping, req.SyncBatchID, false, protos.DBType_S3,
+	)
+	recordStream, err := utils.RecordsToRawTableStream(streamReq, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert records to raw table stream: %w", err)
+	}
+	qrepConfig := &protos.QRepConfig{
+		FlowJobName:                req.FlowJobName,
+		DestinationTableIdentifier: "raw_table_" + req.FlowJobName,
+		Env:                        req.Env,
+		Version:                    req.Version,
+	}
+	partition := &protos.QRepPartition{
+		PartitionId: strconv.FormatInt(req.SyncBatchID, 10),
+	}
+	numRecords, _, err := c.SyncQRepRecords(ctx, qrepConfig, partition, recordStream)
+	return numRecords, err
+}
+
 func (c *S3Connector) ReplayTableSchemaDeltas(_ context.Context, _ map[string]string,
 	flowJobName string, _ []*protos.TableMapping, schemaDeltas []*protos.TableSchemaDelta,
 ) error {
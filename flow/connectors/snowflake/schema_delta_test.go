@@ -0,0 +1,44 @@
+package connsnowflake
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestDroppedColumnStmts(t *testing.T) {
+	require.Empty(t, droppedColumnStmts("S.T", "c", protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_KEEP))
+	require.Equal(t, []string{
+		`ALTER TABLE S.T ALTER COLUMN "C" DROP NOT NULL`,
+		`UPDATE S.T SET "C" = NULL`,
+	}, droppedColumnStmts("S.T", "c", protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL))
+	require.Equal(t, []string{`ALTER TABLE S.T DROP COLUMN IF EXISTS "C"`},
+		droppedColumnStmts("S.T", "c", protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP))
+}
+
+func TestAlteredColumnStmts(t *testing.T) {
+	widened := &protos.AlteredColumn{
+		Previous: &protos.FieldDescription{Name: "c", Type: string(types.QValueKindInt64)},
+		Current:  &protos.FieldDescription{Name: "c", Type: string(types.QValueKindNumeric)},
+	}
+	// widening is applied whatever the policy
+	require.Equal(t, []string{
+		`ALTER TABLE S.T ADD COLUMN "C_PEERDB_RETYPE" NUMBER(38,9)`,
+		`UPDATE S.T SET "C_PEERDB_RETYPE" = "C"::NUMBER(38,9)`,
+		`ALTER TABLE S.T DROP COLUMN "C"`,
+		`ALTER TABLE S.T RENAME COLUMN "C_PEERDB_RETYPE" TO "C"`,
+	}, alteredColumnStmts("S.T", widened, "NUMBER(38,9)", protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_KEEP))
+
+	retyped := &protos.AlteredColumn{
+		Previous: &protos.FieldDescription{Name: "c", Type: string(types.QValueKindString)},
+		Current:  &protos.FieldDescription{Name: "c", Type: string(types.QValueKindBoolean)},
+	}
+	require.Empty(t, alteredColumnStmts("S.T", retyped, "BOOLEAN", protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_KEEP))
+	require.Equal(t, []string{
+		`ALTER TABLE S.T DROP COLUMN IF EXISTS "C"`,
+		`ALTER TABLE S.T ADD COLUMN "C" BOOLEAN`,
+	}, alteredColumnStmts("S.T", retyped, "BOOLEAN", protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP))
+}
@@ -61,6 +61,12 @@ const (
 	 ARRAY_AGG(DISTINCT _PEERDB_UNCHANGED_TOAST_COLUMNS) FROM %s.%s WHERE
 	 _PEERDB_BATCH_ID = %d AND _PEERDB_RECORD_TYPE != 2
 	 GROUP BY _PEERDB_DESTINATION_TABLE_NAME`
+	getTruncatedTablesSQL = `SELECT _PEERDB_DESTINATION_TABLE_NAME, MAX(_PEERDB_TIMESTAMP) FROM %s.%s WHERE
+	 _PEERDB_BATCH_ID = %d AND _PEERDB_RECORD_TYPE = 3 GROUP BY _PEERDB_DESTINATION_TABLE_NAME`
+	deleteTruncatedRawRowsSQL = `DELETE FROM %s.%s WHERE
+	 _PEERDB_BATCH_ID = %d AND _PEERDB_DESTINATION_TABLE_NAME = ? AND _PEERDB_TIMESTAMP <= ?`
+	deleteTruncateMarkersSQL = `DELETE FROM %s.%s WHERE
+	 _PEERDB_BATCH_ID = %d AND _PEERDB_DESTINATION_TABLE_NAME = ? AND _PEERDB_RECORD_TYPE = 3`
 	getTableSchemaSQL = `SELECT COLUMN_NAME, DATA_TYPE, NUMERIC_PRECISION, NUMERIC_SCALE FROM INFORMATION_SCHEMA.COLUMNS
 	 WHERE UPPER(TABLE_SCHEMA)=? AND UPPER(TABLE_NAME)=? ORDER BY ORDINAL_POSITION`
 
@@ -256,6 +262,36 @@ func (c *SnowflakeConnector) getDistinctTableNamesInBatch(
 	return destinationTableNames, nil
 }
 
+// getTruncatedTablesInBatch returns the time of the latest truncate of each table truncated in the batch
+func (c *SnowflakeConnector) getTruncatedTablesInBatch(
+	ctx context.Context,
+	flowJobName string,
+	batchId int64,
+) (map[string]int64, error) {
+	rawTableIdentifier := getRawTableIdentifier(flowJobName)
+
+	rows, err := c.QueryContext(ctx, fmt.Sprintf(getTruncatedTablesSQL, c.rawSchema, rawTableIdentifier, batchId))
+	if err != nil {
+		return nil, fmt.Errorf("error while retrieving truncated tables for normalization: %w", err)
+	}
+	defer rows.Close()
+
+	truncatedTables := make(map[string]int64)
+	var tableName string
+	var truncatedAt int64
+	for rows.Next() {
+		if err := rows.Scan(&tableName, &truncatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+		truncatedTables[tableName] = truncatedAt
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return truncatedTables, nil
+}
+
 func (c *SnowflakeConnector) getTableNameToUnchangedCols(
 	ctx context.Context,
 	flowJobName string,
@@ -331,7 +367,7 @@ func (c *SnowflakeConnector) SetupNormalizedTable(
 }
 
 // ReplayTableSchemaDeltas changes a destination table to match the schema at source
-// This could involve adding or dropping multiple columns.
+// This could involve adding, dropping or retyping multiple columns.
 func (c *SnowflakeConnector) ReplayTableSchemaDeltas(
 	ctx context.Context,
 	env map[string]string,
@@ -356,7 +392,8 @@ func (c *SnowflakeConnector) ReplayTableSchemaDeltas(
 	}()
 
 	for _, schemaDelta := range schemaDeltas {
-		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+		if schemaDelta == nil ||
+			(len(schemaDelta.AddedColumns) == 0 && len(schemaDelta.DroppedColumns) == 0 && len(schemaDelta.AlteredColumns) == 0) {
 			continue
 		}
 
@@ -382,6 +419,27 @@ func (c *SnowflakeConnector) ReplayTableSchemaDeltas(
 				"destination table name", schemaDelta.DstTableName,
 				"source table name", schemaDelta.SrcTableName)
 		}
+
+		if len(schemaDelta.DroppedColumns) == 0 && len(schemaDelta.AlteredColumns) == 0 {
+			continue
+		}
+		policy := schemaDelta.DroppedColumnPolicy
+
+		for _, droppedColumn := range schemaDelta.DroppedColumns {
+			for _, stmt := range droppedColumnStmts(schemaDelta.DstTableName, droppedColumn.Name, policy) {
+				if _, err := c.execWithLoggingTx(ctx, stmt, tableSchemaModifyTx); err != nil {
+					return fmt.Errorf("failed to replay dropped column %s for table %s: %w", droppedColumn.Name,
+						schemaDelta.DstTableName, err)
+				}
+			}
+		}
+
+		for _, alteredColumn := range schemaDelta.AlteredColumns {
+			if err := c.replayAlteredColumn(ctx, env, tableSchemaModifyTx, schemaDelta, alteredColumn, policy); err != nil {
+				return fmt.Errorf("failed to alter column %s for table %s: %w", alteredColumn.Current.Name,
+					schemaDelta.DstTableName, err)
+			}
+		}
 	}
 
 	if err := tableSchemaModifyTx.Commit(); err != nil {
@@ -392,6 +450,94 @@ func (c *SnowflakeConnector) ReplayTableSchemaDeltas(
 	return nil
 }
 
+// replayAlteredColumn retypes a destination column whose type changed at source.
+// Snowflake can only alter the length or precision of a column in place,
+// so widened values are copied through a temporary column instead.
+// Retyped columns are always created nullable, since existing rows are backfilled after the column is added.
+func (c *SnowflakeConnector) replayAlteredColumn(
+	ctx context.Context,
+	env map[string]string,
+	tx *sql.Tx,
+	schemaDelta *protos.TableSchemaDelta,
+	alteredColumn *protos.AlteredColumn,
+	policy protos.DroppedColumnPolicy,
+) error {
+	previousType, err := qvalue.ToDWHColumnType(ctx, types.QValueKind(alteredColumn.Previous.Type), env,
+		protos.DBType_SNOWFLAKE, nil, alteredColumn.Previous, false)
+	if err != nil {
+		return err
+	}
+	currentType, err := qvalue.ToDWHColumnType(ctx, types.QValueKind(alteredColumn.Current.Type), env,
+		protos.DBType_SNOWFLAKE, nil, alteredColumn.Current, false)
+	if err != nil {
+		return err
+	}
+	if previousType == currentType {
+		return nil
+	}
+
+	stmts := alteredColumnStmts(schemaDelta.DstTableName, alteredColumn, currentType, policy)
+	if len(stmts) == 0 {
+		c.logger.Warn(fmt.Sprintf("[schema delta replay] column %s changed type from %s to %s incompatibly, not propagating",
+			alteredColumn.Current.Name, previousType, currentType),
+			"destination table name", schemaDelta.DstTableName,
+			"source table name", schemaDelta.SrcTableName)
+		return nil
+	}
+
+	for _, stmt := range stmts {
+		if _, err := c.execWithLoggingTx(ctx, stmt, tx); err != nil {
+			return err
+		}
+	}
+	c.logger.Info(fmt.Sprintf("[schema delta replay] altered column %s from data type %s to %s",
+		alteredColumn.Current.Name, previousType, currentType),
+		"destination table name", schemaDelta.DstTableName,
+		"source table name", schemaDelta.SrcTableName)
+	return nil
+}
+
+// droppedColumnStmts returns the statements applying policy to a column dropped at source
+func droppedColumnStmts(dstTableName string, column string, policy protos.DroppedColumnPolicy) []string {
+	columnName := strings.ToUpper(column)
+	switch policy {
+	case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL:
+		return []string{
+			fmt.Sprintf("ALTER TABLE %s ALTER COLUMN \"%s\" DROP NOT NULL", dstTableName, columnName),
+			fmt.Sprintf("UPDATE %s SET \"%s\" = NULL", dstTableName, columnName),
+		}
+	case protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP:
+		return []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS \"%s\"", dstTableName, columnName)}
+	default:
+		return nil
+	}
+}
+
+// alteredColumnStmts returns the statements retyping a column to currentType, none if it is kept as is
+func alteredColumnStmts(
+	dstTableName string,
+	alteredColumn *protos.AlteredColumn,
+	currentType string,
+	policy protos.DroppedColumnPolicy,
+) []string {
+	columnName := strings.ToUpper(alteredColumn.Current.Name)
+	tmpColumnName := columnName + "_PEERDB_RETYPE"
+	if types.QValueKind(alteredColumn.Previous.Type).CanWidenTo(types.QValueKind(alteredColumn.Current.Type)) {
+		return []string{
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN \"%s\" %s", dstTableName, tmpColumnName, currentType),
+			fmt.Sprintf("UPDATE %s SET \"%s\" = \"%s\"::%s", dstTableName, tmpColumnName, columnName, currentType),
+			fmt.Sprintf("ALTER TABLE %s DROP COLUMN \"%s\"", dstTableName, columnName),
+			fmt.Sprintf("ALTER TABLE %s RENAME COLUMN \"%s\" TO \"%s\"", dstTableName, tmpColumnName, columnName),
+		}
+	} else if policy != protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_KEEP {
+		return []string{
+			fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS \"%s\"", dstTableName, columnName),
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN \"%s\" %s", dstTableName, columnName, currentType),
+		}
+	}
+	return nil
+}
+
 func (c *SnowflakeConnector) withMirrorNameQueryTag(ctx context.Context, mirrorName string) context.Context {
 	return gosnowflake.WithQueryTag(ctx, "peerdb-mirror-"+mirrorName)
 }
@@ -485,6 +631,7 @@ func (c *SnowflakeConnector) NormalizeRecords(ctx context.Context, req *model.No
 				SoftDeleteColName: req.SoftDeleteColName,
 				SyncedAtColName:   req.SyncedAtColName,
 			},
+			req.TruncatePolicy,
 		)
 		if mergeErr != nil {
 			return model.NormalizeResponse{}, mergeErr
@@ -508,6 +655,7 @@ func (c *SnowflakeConnector) mergeTablesForBatch(
 	env map[string]string,
 	tableToSchema map[string]*protos.TableSchema,
 	peerdbCols *protos.PeerDBColumns,
+	truncatePolicy protos.TruncatePolicy,
 ) error {
 	destinationTableNames, err := c.getDistinctTableNamesInBatch(ctx, flowName, batchId, tableToSchema)
 	if err != nil {
@@ -519,6 +667,11 @@ func (c *SnowflakeConnector) mergeTablesForBatch(
 		return fmt.Errorf("couldn't tablename to unchanged cols mapping: %w", err)
 	}
 
+	truncatedTables, err := c.getTruncatedTablesInBatch(ctx, flowName, batchId)
+	if err != nil {
+		return fmt.Errorf("couldn't get truncated tables in batch: %w", err)
+	}
+
 	var totalRowsAffected int64 = 0
 	g, gCtx := errgroup.WithContext(ctx)
 	mergeParallelism, err := internal.PeerDBSnowflakeMergeParallelism(ctx, env)
@@ -541,6 +694,12 @@ func (c *SnowflakeConnector) mergeTablesForBatch(
 		}
 
 		g.Go(func() error {
+			if truncatedAt, ok := truncatedTables[tableName]; ok {
+				if err := c.normalizeTruncate(gCtx, flowName, batchId, tableName, truncatedAt, truncatePolicy, peerdbCols); err != nil {
+					return err
+				}
+			}
+
 			mergeStatement, err := mergeGen.generateMergeStmt(gCtx, env, tableName)
 			if err != nil {
 				return err
@@ -579,6 +738,58 @@ func (c *SnowflakeConnector) mergeTablesForBatch(
 	return nil
 }
 
+// normalizeTruncate handles the latest truncate of a table in the batch according to the truncate policy,
+// changes from before an applied truncate are dropped from the raw table so they are not merged
+func (c *SnowflakeConnector) normalizeTruncate(
+	ctx context.Context,
+	flowName string,
+	batchId int64,
+	tableName string,
+	truncatedAt int64,
+	truncatePolicy protos.TruncatePolicy,
+	peerdbCols *protos.PeerDBColumns,
+) error {
+	rawTableIdentifier := getRawTableIdentifier(flowName)
+
+	parsedDstTable, err := utils.ParseSchemaTable(tableName)
+	if err != nil {
+		return fmt.Errorf("unable to parse destination table '%s': %w", tableName, err)
+	}
+	switch truncatePolicy {
+	case protos.TruncatePolicy_TRUNCATE_POLICY_APPLY:
+		if _, err := c.execWithLogging(ctx, "TRUNCATE TABLE "+snowflakeSchemaTableNormalize(parsedDstTable)); err != nil {
+			return fmt.Errorf("failed to truncate table %s: %w", tableName, err)
+		}
+	case protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE:
+		if peerdbCols.SoftDeleteColName == "" {
+			return fmt.Errorf("cannot soft delete rows of truncated table %s without a soft delete column", tableName)
+		}
+		softDeleteUpdate := peerdbCols.SoftDeleteColName + " = TRUE"
+		if peerdbCols.SyncedAtColName != "" {
+			softDeleteUpdate = fmt.Sprintf("%s, %s = CURRENT_TIMESTAMP", softDeleteUpdate, peerdbCols.SyncedAtColName)
+		}
+		if _, err := c.execWithLogging(ctx,
+			fmt.Sprintf("UPDATE %s SET %s", snowflakeSchemaTableNormalize(parsedDstTable), softDeleteUpdate),
+		); err != nil {
+			return fmt.Errorf("failed to soft delete rows of truncated table %s: %w", tableName, err)
+		}
+	default:
+		if _, err := c.ExecContext(ctx, fmt.Sprintf(deleteTruncateMarkersSQL, c.rawSchema, rawTableIdentifier, batchId),
+			tableName,
+		); err != nil {
+			return fmt.Errorf("failed to remove ignored truncates of table %s: %w", tableName, err)
+		}
+		return nil
+	}
+
+	if _, err := c.ExecContext(ctx, fmt.Sprintf(deleteTruncatedRawRowsSQL, c.rawSchema, rawTableIdentifier, batchId),
+		tableName, truncatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to remove truncated changes of table %s: %w", tableName, err)
+	}
+	return nil
+}
+
 func (c *SnowflakeConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
 	ctx = c.withMirrorNameQueryTag(ctx, req.FlowJobName)
 
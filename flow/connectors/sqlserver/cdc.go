@@ -0,0 +1,553 @@
+package connsqlserver
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// __$operation values of cdc.fn_cdc_get_all_changes_<capture_instance>
+const (
+	cdcOperationDelete       = 1
+	cdcOperationInsert       = 2
+	cdcOperationUpdateBefore = 3
+	cdcOperationUpdateAfter  = 4
+)
+
+// how often to look for a new max LSN when caught up, capture job itself polls every 5 seconds by default
+const cdcPollInterval = time.Second
+
+type captureInstance struct {
+	name    string
+	columns []capturedColumn
+}
+
+type capturedColumn struct {
+	name     string
+	dataType string
+}
+
+type cdcChange struct {
+	lsn    []byte
+	seqval []byte
+	record model.Record[model.RecordItems]
+}
+
+// LSNs are binary(10), stored hex encoded in CdcCheckpoint.Text
+func lsnToOffsetText(lsn []byte) string {
+	return hex.EncodeToString(lsn)
+}
+
+func offsetTextToLsn(text string) ([]byte, error) {
+	lsn, err := hex.DecodeString(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LSN offset %s: %w", text, err)
+	}
+	if len(lsn) != 10 {
+		return nil, fmt.Errorf("invalid LSN offset %s: expected 10 bytes", text)
+	}
+	return lsn, nil
+}
+
+// getCaptureInstances returns the capture instance for each CDC enabled table, keyed by schema.table.
+// A table can have two capture instances while its schema is being migrated, the most recent one wins.
+func (c *SqlServerConnector) getCaptureInstances(ctx context.Context) (map[string]*captureInstance, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT s.name, t.name, ct.capture_instance, cc.column_name, cc.column_type
+		FROM cdc.change_tables ct
+		JOIN sys.tables t ON ct.source_object_id = t.object_id
+		JOIN sys.schemas s ON t.schema_id = s.schema_id
+		JOIN cdc.captured_columns cc ON cc.object_id = ct.object_id
+		ORDER BY ct.create_date, ct.capture_instance, cc.column_ordinal`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query capture instances: %w", err)
+	}
+	defer rows.Close()
+
+	instances := make(map[string]*captureInstance)
+	for rows.Next() {
+		var schemaName, tableName, instanceName, columnName, columnType string
+		if err := rows.Scan(&schemaName, &tableName, &instanceName, &columnName, &columnType); err != nil {
+			return nil, err
+		}
+		key := schemaName + "." + tableName
+		instance, ok := instances[key]
+		if !ok || instance.name != instanceName {
+			instance = &captureInstance{name: instanceName}
+			instances[key] = instance
+		}
+		instance.columns = append(instance.columns, capturedColumn{name: columnName, dataType: columnType})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
+
+func (c *SqlServerConnector) getMaxLsn(ctx context.Context) ([]byte, error) {
+	var lsn []byte
+	if err := c.db.QueryRowContext(ctx, "SELECT sys.fn_cdc_get_max_lsn()").Scan(&lsn); err != nil {
+		return nil, fmt.Errorf("failed to get max LSN: %w", err)
+	}
+	if lsn == nil {
+		return nil, errors.New("max LSN is null, check that change data capture is enabled and SQL Server Agent is running")
+	}
+	return lsn, nil
+}
+
+func (c *SqlServerConnector) EnsurePullability(
+	ctx context.Context, req *protos.EnsurePullabilityBatchInput,
+) (*protos.EnsurePullabilityBatchOutput, error) {
+	instances, err := c.getCaptureInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, tableName := range req.SourceTableIdentifiers {
+		if _, ok := instances[tableName]; !ok {
+			return nil, fmt.Errorf("change data capture is not enabled for table %s", tableName)
+		}
+	}
+	return nil, nil
+}
+
+func (c *SqlServerConnector) ExportTxSnapshot(context.Context, map[string]string) (*protos.ExportTxSnapshotOutput, any, error) {
+	// changes since SetupReplication are replayed on top of the initial load
+	return nil, nil, nil
+}
+
+func (c *SqlServerConnector) FinishExport(any) error {
+	return nil
+}
+
+func (c *SqlServerConnector) SetupReplication(
+	ctx context.Context,
+	req *protos.SetupReplicationInput,
+) (model.SetupReplicationResult, error) {
+	lsn, err := c.getMaxLsn(ctx)
+	if err != nil {
+		return model.SetupReplicationResult{}, fmt.Errorf("[sqlserver] SetupReplication failed to get max LSN: %w", err)
+	}
+	if err := c.SetLastOffset(
+		ctx, req.FlowJobName, model.CdcCheckpoint{Text: lsnToOffsetText(lsn)},
+	); err != nil {
+		return model.SetupReplicationResult{}, fmt.Errorf("[sqlserver] SetupReplication failed to SetLastOffset: %w", err)
+	}
+
+	return model.SetupReplicationResult{}, nil
+}
+
+func (c *SqlServerConnector) SetupReplConn(context.Context) error {
+	// change tables are polled over regular connections
+	return nil
+}
+
+func (c *SqlServerConnector) ReplPing(context.Context) error {
+	return nil
+}
+
+func (c *SqlServerConnector) UpdateReplStateLastOffset(ctx context.Context, lastOffset model.CdcCheckpoint) error {
+	flowName := ctx.Value(shared.FlowNameKey).(string)
+	return c.SetLastOffset(ctx, flowName, lastOffset)
+}
+
+func (c *SqlServerConnector) PullFlowCleanup(ctx context.Context, jobName string) error {
+	return nil
+}
+
+func (c *SqlServerConnector) PullRecords(
+	ctx context.Context,
+	catalogPool shared.CatalogPool,
+	otelManager *otel_metrics.OtelManager,
+	req *model.PullRecordsRequest[model.RecordItems],
+) error {
+	defer req.RecordStream.Close()
+
+	sourceSchemaAsDestinationColumn, err := internal.PeerDBSourceSchemaAsDestinationColumn(ctx, req.Env)
+	if err != nil {
+		return err
+	}
+
+	lastLsn, err := offsetTextToLsn(req.LastOffset.Text)
+	if err != nil {
+		return err
+	}
+
+	instances, err := c.getCaptureInstances(ctx)
+	if err != nil {
+		return err
+	}
+	for sourceTableName := range req.TableNameMapping {
+		if _, ok := instances[sourceTableName]; !ok {
+			return fmt.Errorf("change data capture is not enabled for table %s", sourceTableName)
+		}
+	}
+
+	var recordCount uint32
+	defer func() {
+		if recordCount == 0 {
+			req.RecordStream.SignalAsEmpty()
+		}
+		c.logger.Info("[sqlserver] PullRecords finished streaming", slog.Uint64("records", uint64(recordCount)))
+	}()
+
+	c.bytesRead.Store(0)
+	shutDown := shared.Interval(ctx, time.Minute, func() {
+		if read := c.bytesRead.Swap(0); read != 0 {
+			otelManager.Metrics.FetchedBytesCounter.Add(ctx, read)
+		}
+	})
+	defer shutDown()
+
+	// before first record, we wait indefinitely
+	// after first record, we wait for idle timeout
+	var idleDeadline time.Time
+	lastOffsetUpdate := time.Now()
+	for recordCount < req.MaxBatchSize {
+		if recordCount > 0 && time.Now().After(idleDeadline) {
+			return nil
+		}
+
+		maxLsn, err := c.getMaxLsn(ctx)
+		if err != nil {
+			return err
+		}
+		if bytes.Compare(maxLsn, lastLsn) <= 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(cdcPollInterval):
+			}
+			continue
+		}
+		windowLsn, err := c.getWindowEndLsn(ctx, lastLsn, maxLsn, req.MaxBatchSize-recordCount)
+		if err != nil {
+			return err
+		}
+
+		cursors, err := c.openChangeCursors(ctx, req, instances, lastLsn, windowLsn, sourceSchemaAsDestinationColumn)
+		if err != nil {
+			return err
+		}
+		stopLsn, err := mergeChanges(cursors, func() bool {
+			return recordCount >= req.MaxBatchSize
+		}, func(change cdcChange) error {
+			if err := req.RecordStream.AddRecord(ctx, change.record); err != nil {
+				return err
+			}
+			recordCount += 1
+			if recordCount == 1 {
+				req.RecordStream.SignalAsNotEmpty()
+				idleDeadline = time.Now().Add(req.IdleTimeout)
+			}
+			return nil
+		})
+		for _, cursor := range cursors {
+			cursor.Close()
+		}
+		if err != nil {
+			return err
+		}
+		if stopLsn != nil {
+			req.RecordStream.UpdateLatestCheckpointText(lsnToOffsetText(stopLsn))
+			return nil
+		}
+
+		lastLsn = windowLsn
+		req.RecordStream.UpdateLatestCheckpointText(lsnToOffsetText(lastLsn))
+		if recordCount == 0 && time.Since(lastOffsetUpdate) > time.Hour {
+			// progress offset while no records read to avoid falling behind change table cleanup
+			c.logger.Info("[sqlserver] updating inactive offset", slog.String("lsn", lsnToOffsetText(lastLsn)))
+			if err := c.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{Text: lsnToOffsetText(lastLsn)}); err != nil {
+				c.logger.Error("[sqlserver] failed to update offset, ignoring", slog.Any("error", err))
+			} else {
+				lastOffsetUpdate = time.Now()
+			}
+		}
+	}
+
+	return nil
+}
+
+// getWindowEndLsn limits the LSN range (fromLsn, toLsn] read at once to its first maxTransactions transactions,
+// a transaction having at least one change this keeps each window within what is left of the batch
+func (c *SqlServerConnector) getWindowEndLsn(
+	ctx context.Context, fromLsn []byte, toLsn []byte, maxTransactions uint32,
+) ([]byte, error) {
+	var windowLsn []byte
+	if err := c.db.QueryRowContext(ctx, `SELECT MAX(start_lsn) FROM (SELECT TOP (@p1) start_lsn
+		FROM cdc.lsn_time_mapping WHERE start_lsn > @p2 AND start_lsn <= @p3 AND tran_id <> 0x00
+		ORDER BY start_lsn) t`, int64(maxTransactions), fromLsn, toLsn).Scan(&windowLsn); err != nil {
+		return nil, fmt.Errorf("failed to get LSN window: %w", err)
+	}
+	if windowLsn == nil {
+		return toLsn, nil
+	}
+	return windowLsn, nil
+}
+
+// changeCursor reads changes of one table in the order they were committed
+type changeCursor interface {
+	// Next returns the next change, nil once there are none left
+	Next() (*cdcChange, error)
+	Close() error
+}
+
+// mergeChanges passes changes of all cursors to add in commit order, holding only one change per cursor.
+// stop is asked before the first change of each LSN, as all changes of a transaction share the same start LSN.
+// The last LSN added is returned when stopped, nil once every cursor is exhausted
+func mergeChanges(cursors []changeCursor, stop func() bool, add func(cdcChange) error) ([]byte, error) {
+	heads := make([]*cdcChange, len(cursors))
+	for idx, cursor := range cursors {
+		head, err := cursor.Next()
+		if err != nil {
+			return nil, err
+		}
+		heads[idx] = head
+	}
+
+	var prevLsn []byte
+	for {
+		next := -1
+		for idx, head := range heads {
+			if head != nil && (next == -1 || compareChanges(head, heads[next]) < 0) {
+				next = idx
+			}
+		}
+		if next == -1 {
+			return nil, nil
+		}
+
+		change := heads[next]
+		if prevLsn != nil && !bytes.Equal(change.lsn, prevLsn) && stop() {
+			return prevLsn, nil
+		}
+		if err := add(*change); err != nil {
+			return nil, err
+		}
+		prevLsn = change.lsn
+
+		head, err := cursors[next].Next()
+		if err != nil {
+			return nil, err
+		}
+		heads[next] = head
+	}
+}
+
+func compareChanges(a *cdcChange, b *cdcChange) int {
+	if cmp := bytes.Compare(a.lsn, b.lsn); cmp != 0 {
+		return cmp
+	}
+	return bytes.Compare(a.seqval, b.seqval)
+}
+
+// openChangeCursors starts reading changes of all mirrored tables in the LSN range (fromLsn, toLsn],
+// cursors already opened are closed on error
+func (c *SqlServerConnector) openChangeCursors(
+	ctx context.Context,
+	req *model.PullRecordsRequest[model.RecordItems],
+	instances map[string]*captureInstance,
+	fromLsn []byte,
+	toLsn []byte,
+	sourceSchemaAsDestinationColumn bool,
+) ([]changeCursor, error) {
+	var startLsn []byte
+	if err := c.db.QueryRowContext(ctx, "SELECT sys.fn_cdc_increment_lsn(@p1)", fromLsn).Scan(&startLsn); err != nil {
+		return nil, fmt.Errorf("failed to increment LSN: %w", err)
+	}
+
+	cursors := make([]changeCursor, 0, len(req.TableNameMapping))
+	closeCursors := func() {
+		for _, cursor := range cursors {
+			cursor.Close()
+		}
+	}
+	for sourceTableName, nameAndExclude := range req.TableNameMapping {
+		instance := instances[sourceTableName]
+		schema := req.TableNameSchemaMapping[nameAndExclude.Name]
+		if schema == nil {
+			continue
+		}
+
+		var minLsn []byte
+		if err := c.db.QueryRowContext(ctx, "SELECT sys.fn_cdc_get_min_lsn(@p1)", instance.name).Scan(&minLsn); err != nil {
+			closeCursors()
+			return nil, fmt.Errorf("failed to get min LSN for %s: %w", instance.name, err)
+		}
+		if bytes.Compare(minLsn, toLsn) > 0 {
+			// capture instance created after this window
+			continue
+		}
+		tableStartLsn := startLsn
+		if bytes.Compare(minLsn, startLsn) > 0 {
+			// fn_cdc_get_all_changes errors when asked for changes before the low water mark,
+			// which happens for new capture instances but also when cleanup removed unread changes
+			c.logger.Warn("[sqlserver] starting LSN is older than capture instance, changes may have been cleaned up",
+				slog.String("captureInstance", instance.name),
+				slog.String("startLsn", lsnToOffsetText(startLsn)),
+				slog.String("minLsn", lsnToOffsetText(minLsn)))
+			tableStartLsn = minLsn
+		}
+
+		cursor, err := c.openTableChangeCursor(ctx, sourceTableName, nameAndExclude, schema, instance,
+			tableStartLsn, toLsn, sourceSchemaAsDestinationColumn)
+		if err != nil {
+			closeCursors()
+			return nil, err
+		}
+		cursors = append(cursors, cursor)
+	}
+	return cursors, nil
+}
+
+type tableChangeCursor struct {
+	rows             *sql.Rows
+	nameAndExclude   model.NameAndExclude
+	sourceTableName  string
+	sourceSchemaName string
+	fields           []*protos.FieldDescription
+	values           []any
+	scanArgs         []any
+}
+
+func (c *SqlServerConnector) openTableChangeCursor(
+	ctx context.Context,
+	sourceTableName string,
+	nameAndExclude model.NameAndExclude,
+	schema *protos.TableSchema,
+	instance *captureInstance,
+	startLsn []byte,
+	toLsn []byte,
+	sourceSchemaAsDestinationColumn bool,
+) (*tableChangeCursor, error) {
+	fields := make([]*protos.FieldDescription, 0, len(instance.columns))
+	quotedColumns := make([]string, 0, len(instance.columns))
+	for _, col := range instance.columns {
+		if _, excluded := nameAndExclude.Exclude[col.name]; excluded {
+			continue
+		}
+		idx := slices.IndexFunc(schema.Columns, func(fd *protos.FieldDescription) bool {
+			return fd.Name == col.name
+		})
+		if idx == -1 {
+			continue
+		}
+		fields = append(fields, schema.Columns[idx])
+		quotedColumns = append(quotedColumns, quoteIdentifier(col.name))
+	}
+
+	var sourceSchemaName string
+	if sourceSchemaAsDestinationColumn {
+		sourceSchemaName, _, _ = strings.Cut(sourceTableName, ".")
+	}
+
+	// lsn_time_mapping is in server local time
+	query := fmt.Sprintf(`SELECT __$start_lsn, __$seqval, __$operation,
+		DATEADD(second, DATEDIFF(second, GETDATE(), GETUTCDATE()), sys.fn_cdc_map_lsn_to_time(__$start_lsn)), %s
+		FROM cdc.%s(@p1, @p2, N'all update old')
+		ORDER BY __$start_lsn, __$seqval, __$operation`,
+		strings.Join(quotedColumns, ", "), quoteIdentifier("fn_cdc_get_all_changes_"+instance.name))
+	rows, err := c.db.QueryContext(ctx, query, startLsn, toLsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query changes for %s: %w", sourceTableName, err)
+	}
+
+	cursor := &tableChangeCursor{
+		rows:             rows,
+		nameAndExclude:   nameAndExclude,
+		sourceTableName:  sourceTableName,
+		sourceSchemaName: sourceSchemaName,
+		fields:           fields,
+		values:           make([]any, len(fields)),
+		scanArgs:         make([]any, 4+len(fields)),
+	}
+	for idx := range fields {
+		cursor.scanArgs[4+idx] = &cursor.values[idx]
+	}
+	return cursor, nil
+}
+
+func (tc *tableChangeCursor) Next() (*cdcChange, error) {
+	var beforeItems model.RecordItems
+	var beforeSeqval []byte
+	for tc.rows.Next() {
+		var lsn, seqval []byte
+		var operation int32
+		var commitTime time.Time
+		tc.scanArgs[0], tc.scanArgs[1], tc.scanArgs[2], tc.scanArgs[3] = &lsn, &seqval, &operation, &commitTime
+		if err := tc.rows.Scan(tc.scanArgs...); err != nil {
+			return nil, fmt.Errorf("failed to scan change for %s: %w", tc.sourceTableName, err)
+		}
+
+		items := model.NewRecordItems(len(tc.fields) + 1)
+		for idx, fd := range tc.fields {
+			qv, err := qvalueFromSqlServer(types.QValueKind(fd.Type), tc.values[idx])
+			if err != nil {
+				return nil, fmt.Errorf("could not convert sqlserver value for %s: %w", fd.Name, err)
+			}
+			items.AddColumn(fd.Name, qv)
+		}
+		if tc.sourceSchemaName != "" {
+			items.AddColumn("_peerdb_source_schema", types.QValueString{Val: tc.sourceSchemaName})
+		}
+
+		baseRecord := model.BaseRecord{CommitTimeNano: commitTime.UnixNano()}
+		var record model.Record[model.RecordItems]
+		switch operation {
+		case cdcOperationDelete:
+			record = &model.DeleteRecord[model.RecordItems]{
+				BaseRecord:           baseRecord,
+				Items:                items,
+				SourceTableName:      tc.sourceTableName,
+				DestinationTableName: tc.nameAndExclude.Name,
+			}
+		case cdcOperationInsert:
+			record = &model.InsertRecord[model.RecordItems]{
+				BaseRecord:           baseRecord,
+				Items:                items,
+				SourceTableName:      tc.sourceTableName,
+				DestinationTableName: tc.nameAndExclude.Name,
+			}
+		case cdcOperationUpdateBefore:
+			beforeItems = items
+			beforeSeqval = seqval
+			continue
+		case cdcOperationUpdateAfter:
+			oldItems := model.NewRecordItems(0)
+			if bytes.Equal(beforeSeqval, seqval) {
+				oldItems = beforeItems
+			}
+			record = &model.UpdateRecord[model.RecordItems]{
+				BaseRecord:           baseRecord,
+				OldItems:             oldItems,
+				NewItems:             items,
+				SourceTableName:      tc.sourceTableName,
+				DestinationTableName: tc.nameAndExclude.Name,
+			}
+		default:
+			return nil, fmt.Errorf("unknown change operation %d for %s", operation, tc.sourceTableName)
+		}
+		return &cdcChange{lsn: lsn, seqval: seqval, record: record}, nil
+	}
+	if err := tc.rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read changes for %s: %w", tc.sourceTableName, err)
+	}
+	return nil, nil
+}
+
+func (tc *tableChangeCursor) Close() error {
+	return tc.rows.Close()
+}
@@ -0,0 +1,76 @@
+package connsqlserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+type sliceCursor struct {
+	changes []cdcChange
+	closed  bool
+}
+
+func (sc *sliceCursor) Next() (*cdcChange, error) {
+	if len(sc.changes) == 0 {
+		return nil, nil
+	}
+	change := sc.changes[0]
+	sc.changes = sc.changes[1:]
+	return &change, nil
+}
+
+func (sc *sliceCursor) Close() error {
+	sc.closed = true
+	return nil
+}
+
+func testChange(lsn byte, seqval byte, table string) cdcChange {
+	return cdcChange{
+		lsn:    []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, lsn},
+		seqval: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, seqval},
+		record: &model.InsertRecord[model.RecordItems]{DestinationTableName: table},
+	}
+}
+
+func TestMergeChanges(t *testing.T) {
+	newCursors := func() []changeCursor {
+		return []changeCursor{
+			&sliceCursor{changes: []cdcChange{testChange(1, 1, "a"), testChange(2, 2, "a"), testChange(4, 1, "a")}},
+			&sliceCursor{},
+			&sliceCursor{changes: []cdcChange{testChange(1, 2, "b"), testChange(2, 1, "b"), testChange(3, 1, "b")}},
+		}
+	}
+	var added []string
+	add := func(change cdcChange) error {
+		added = append(added, change.record.GetDestinationTableName()+lsnToOffsetText(change.lsn)[18:])
+		return nil
+	}
+
+	stopLsn, err := mergeChanges(newCursors(), func() bool { return false }, add)
+	require.NoError(t, err)
+	require.Nil(t, stopLsn)
+	require.Equal(t, []string{"a01", "b01", "b02", "a02", "b03", "a04"}, added)
+
+	// changes of an LSN are never split across batches
+	added = nil
+	stopLsn, err = mergeChanges(newCursors(), func() bool { return len(added) >= 3 }, add)
+	require.NoError(t, err)
+	require.Equal(t, testChange(2, 0, "").lsn, stopLsn)
+	require.Equal(t, []string{"a01", "b01", "b02", "a02"}, added)
+}
+
+func TestLsnOffsetText(t *testing.T) {
+	lsn := []byte{0, 0, 0, 0x2a, 0, 0, 0x01, 0xf0, 0, 0x03}
+	require.Equal(t, "0000002a000001f00003", lsnToOffsetText(lsn))
+	parsed, err := offsetTextToLsn(lsnToOffsetText(lsn))
+	require.NoError(t, err)
+	require.Equal(t, lsn, parsed)
+
+	_, err = offsetTextToLsn("0102")
+	require.Error(t, err)
+	_, err = offsetTextToLsn("not hex")
+	require.Error(t, err)
+}
@@ -0,0 +1,261 @@
+package connsqlserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"text/template"
+	"time"
+
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+const SqlServerFullTablePartitionId = "sqlserver-full-table-partition-id"
+
+func (c *SqlServerConnector) getDataTypeOfWatermarkColumn(
+	ctx context.Context,
+	watermarkTable *utils.SchemaTable,
+	watermarkColumn string,
+) (types.QValueKind, error) {
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf("SELECT TOP 0 %s FROM %s.%s",
+		quoteIdentifier(watermarkColumn), quoteIdentifier(watermarkTable.Schema), quoteIdentifier(watermarkTable.Table)))
+	if err != nil {
+		return "", fmt.Errorf("failed to execute query for watermark column type: %w", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return "", err
+	}
+	if len(columnTypes) == 0 {
+		return "", fmt.Errorf("no columns returned for watermark column %s", watermarkColumn)
+	}
+	return qkindFromSqlServerType(columnTypes[0].DatabaseTypeName()), nil
+}
+
+func (c *SqlServerConnector) GetQRepPartitions(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	last *protos.QRepPartition,
+) ([]*protos.QRepPartition, error) {
+	if config.WatermarkColumn == "" {
+		// if no watermark column is specified, return a single partition
+		return []*protos.QRepPartition{
+			{
+				PartitionId:        SqlServerFullTablePartitionId,
+				Range:              nil,
+				FullTablePartition: true,
+			},
+		}, nil
+	}
+
+	if config.NumRowsPerPartition <= 0 {
+		return nil, errors.New("num rows per partition must be greater than 0")
+	}
+
+	parsedWatermarkTable, err := utils.ParseSchemaTable(config.WatermarkTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse watermark table %s: %w", config.WatermarkTable, err)
+	}
+	quotedWatermarkTable := quoteIdentifier(parsedWatermarkTable.Schema) + "." + quoteIdentifier(parsedWatermarkTable.Table)
+	quotedWatermarkColumn := quoteIdentifier(config.WatermarkColumn)
+
+	watermarkQKind, err := c.getDataTypeOfWatermarkColumn(ctx, parsedWatermarkTable, config.WatermarkColumn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data type of watermark column %s: %w", config.WatermarkColumn, err)
+	}
+	switch watermarkQKind {
+	case types.QValueKindUInt8, types.QValueKindInt16, types.QValueKindInt32, types.QValueKindInt64,
+		types.QValueKindDate, types.QValueKindTimestamp, types.QValueKindTimestampTZ:
+	default:
+		return nil, fmt.Errorf("unsupported watermark column type %s", watermarkQKind)
+	}
+
+	// count query binds the lower bound as @p1, partitions query as @p2 after the number of partitions
+	countWhereClause := ""
+	partitionsWhereClause := ""
+	var args []any
+	if last != nil && last.Range != nil {
+		countWhereClause = fmt.Sprintf("WHERE %s > @p1", quotedWatermarkColumn)
+		partitionsWhereClause = fmt.Sprintf("WHERE %s > @p2", quotedWatermarkColumn)
+		switch lastRange := last.Range.Range.(type) {
+		case *protos.PartitionRange_IntRange:
+			args = append(args, lastRange.IntRange.End)
+		case *protos.PartitionRange_UintRange:
+			args = append(args, int64(lastRange.UintRange.End))
+		case *protos.PartitionRange_TimestampRange:
+			args = append(args, lastRange.TimestampRange.End.AsTime())
+		}
+	}
+
+	var totalRows int64
+	countQuery := fmt.Sprintf("SELECT COUNT_BIG(*) FROM %s %s", quotedWatermarkTable, countWhereClause)
+	if err := c.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalRows); err != nil {
+		return nil, fmt.Errorf("failed to query for total rows: %w", err)
+	}
+
+	if totalRows == 0 {
+		c.logger.Warn("no records to replicate, returning")
+		return make([]*protos.QRepPartition, 0), nil
+	}
+
+	// Calculate the number of partitions
+	numRowsPerPartition := int64(config.NumRowsPerPartition)
+	numPartitions := totalRows / numRowsPerPartition
+	if totalRows%numRowsPerPartition != 0 {
+		numPartitions++
+	}
+	c.logger.Info(fmt.Sprintf("total rows: %d, num partitions: %d, num rows per partition: %d",
+		totalRows, numPartitions, numRowsPerPartition))
+
+	partitionsQuery := fmt.Sprintf(`SELECT bucket, MIN(watermark) AS start, MAX(watermark) AS finish
+		FROM (SELECT %[1]s AS watermark, NTILE(@p1) OVER (ORDER BY %[1]s) AS bucket FROM %[2]s %[3]s) t
+		GROUP BY bucket ORDER BY start`, quotedWatermarkColumn, quotedWatermarkTable, partitionsWhereClause)
+	c.logger.Info("partitions query", slog.String("query", partitionsQuery))
+	rows, err := c.db.QueryContext(ctx, partitionsQuery, append([]any{numPartitions}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for partitions: %w", err)
+	}
+	defer rows.Close()
+
+	partitionHelper := utils.NewPartitionHelper(c.logger)
+	for rows.Next() {
+		var bucket int64
+		var start, end any
+		if err := rows.Scan(&bucket, &start, &end); err != nil {
+			return nil, err
+		}
+		val1, err := qvalueFromSqlServer(watermarkQKind, start)
+		if err != nil {
+			return nil, err
+		}
+		val2, err := qvalueFromSqlServer(watermarkQKind, end)
+		if err != nil {
+			return nil, err
+		}
+		if err := partitionHelper.AddPartition(val1.Value(), val2.Value()); err != nil {
+			return nil, fmt.Errorf("failed to add partition: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read partitions: %w", err)
+	}
+
+	return partitionHelper.GetPartitions(), nil
+}
+
+func (c *SqlServerConnector) PullQRepRecords(
+	ctx context.Context,
+	otelManager *otel_metrics.OtelManager,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, int64, error) {
+	tableSchema, err := c.getTableSchemaForTable(ctx, config.Env,
+		&protos.TableMapping{SourceTableIdentifier: config.WatermarkTable}, protos.TypeSystem_Q)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get schema for watermark table %s: %w", config.WatermarkTable, err)
+	}
+
+	c.bytesRead.Store(0)
+	shutDown := shared.Interval(ctx, time.Minute, func() {
+		if read := c.bytesRead.Swap(0); read != 0 {
+			otelManager.Metrics.FetchedBytesCounter.Add(ctx, read)
+		}
+	})
+	defer shutDown()
+
+	query := config.Query
+	var args []any
+	if !partition.FullTablePartition {
+		// Depending on the type of the range, convert the range into the correct type
+		switch x := partition.Range.Range.(type) {
+		case *protos.PartitionRange_IntRange:
+			args = []any{x.IntRange.Start, x.IntRange.End}
+		case *protos.PartitionRange_UintRange:
+			args = []any{int64(x.UintRange.Start), int64(x.UintRange.End)}
+		case *protos.PartitionRange_TimestampRange:
+			args = []any{x.TimestampRange.Start.AsTime(), x.TimestampRange.End.AsTime()}
+		default:
+			return 0, 0, fmt.Errorf("unknown range type: %v", x)
+		}
+
+		// range bounds are passed as parameters so they compare correctly against any temporal type
+		query, err = BuildQuery(c.logger, config.Query, "@p1", "@p2")
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, 0, err
+	}
+	schema := qRecordSchemaFromColumnTypes(tableSchema, columnTypes)
+	stream.SetSchema(schema)
+
+	var totalRecords int64
+	values := make([]any, len(columnTypes))
+	scanArgs := make([]any, len(columnTypes))
+	for idx := range values {
+		scanArgs[idx] = &values[idx]
+	}
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return 0, 0, fmt.Errorf("failed to scan row: %w", err)
+		}
+		record := make([]types.QValue, 0, len(values))
+		for idx, val := range values {
+			qv, err := qvalueFromSqlServer(schema.Fields[idx].Type, val)
+			if err != nil {
+				return 0, 0, fmt.Errorf("could not convert sqlserver value for %s: %w", schema.Fields[idx].Name, err)
+			}
+			record = append(record, qv)
+		}
+		stream.Records <- record
+		totalRecords += 1
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	close(stream.Records)
+	return totalRecords, c.bytesRead.Swap(0), nil
+}
+
+func BuildQuery(logger log.Logger, query string, start string, end string) (string, error) {
+	tmpl, err := template.New("query").Parse(query)
+	if err != nil {
+		return "", err
+	}
+
+	data := map[string]any{
+		"start": start,
+		"end":   end,
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	res := buf.String()
+
+	logger.Info("[sqlserver] templated query", slog.String("query", res))
+	return res, nil
+}
@@ -0,0 +1,207 @@
+package connsqlserver
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// qkindFromSqlServerType maps a SQL Server data type name, as found in INFORMATION_SCHEMA.COLUMNS
+// or returned by the driver, to a QValueKind.
+// CLR types (geometry, geography, hierarchyid) are passed through in their native binary serialization.
+func qkindFromSqlServerType(dataType string) types.QValueKind {
+	switch strings.ToLower(dataType) {
+	case "bit":
+		return types.QValueKindBoolean
+	case "tinyint":
+		return types.QValueKindUInt8
+	case "smallint":
+		return types.QValueKindInt16
+	case "int":
+		return types.QValueKindInt32
+	case "bigint":
+		return types.QValueKindInt64
+	case "real":
+		return types.QValueKindFloat32
+	case "float":
+		return types.QValueKindFloat64
+	case "decimal", "numeric", "money", "smallmoney":
+		return types.QValueKindNumeric
+	case "char", "varchar", "nchar", "nvarchar", "text", "ntext", "sysname", "xml", "sql_variant":
+		return types.QValueKindString
+	case "uniqueidentifier":
+		return types.QValueKindUUID
+	case "date":
+		return types.QValueKindDate
+	case "time":
+		return types.QValueKindTime
+	case "datetime", "datetime2", "smalldatetime":
+		return types.QValueKindTimestamp
+	case "datetimeoffset":
+		return types.QValueKindTimestampTZ
+	case "binary", "varbinary", "image", "timestamp", "rowversion", "geometry", "geography", "hierarchyid":
+		return types.QValueKindBytes
+	default:
+		return types.QValueKindInvalid
+	}
+}
+
+// numericTypmod returns the typmod for numeric columns, money types have a fixed precision and scale
+func numericTypmod(dataType string, precision int32, scale int32) int32 {
+	switch strings.ToLower(dataType) {
+	case "money":
+		return datatypes.MakeNumericTypmod(19, 4)
+	case "smallmoney":
+		return datatypes.MakeNumericTypmod(10, 4)
+	case "decimal", "numeric":
+		return datatypes.MakeNumericTypmod(precision, scale)
+	default:
+		return -1
+	}
+}
+
+func qRecordSchemaFromColumnTypes(tableSchema *protos.TableSchema, columnTypes []*sql.ColumnType) types.QRecordSchema {
+	tableColumns := make(map[string]*protos.FieldDescription, len(tableSchema.Columns))
+	for _, col := range tableSchema.Columns {
+		tableColumns[col.Name] = col
+	}
+
+	schema := make([]types.QField, 0, len(columnTypes))
+	for _, ct := range columnTypes {
+		var precision int16
+		var scale int16
+		var qkind types.QValueKind
+		if col, ok := tableColumns[ct.Name()]; ok {
+			qkind = types.QValueKind(col.Type)
+			if qkind == types.QValueKindNumeric {
+				precision, scale = datatypes.ParseNumericTypmod(col.TypeModifier)
+			}
+		} else {
+			qkind = qkindFromSqlServerType(ct.DatabaseTypeName())
+			if qkind == types.QValueKindNumeric {
+				if p, s, ok := ct.DecimalSize(); ok {
+					precision, scale = int16(p), int16(s)
+				}
+			}
+		}
+		nullable, ok := ct.Nullable()
+		schema = append(schema, types.QField{
+			Name:      ct.Name(),
+			Type:      qkind,
+			Precision: precision,
+			Scale:     scale,
+			Nullable:  nullable || !ok,
+		})
+	}
+	return types.QRecordSchema{Fields: schema}
+}
+
+func qvalueFromSqlServer(qkind types.QValueKind, val any) (types.QValue, error) {
+	if val == nil {
+		return types.QValueNull(qkind), nil
+	}
+
+	switch qkind {
+	case types.QValueKindBoolean:
+		if v, ok := val.(bool); ok {
+			return types.QValueBoolean{Val: v}, nil
+		}
+	case types.QValueKindUInt8:
+		if v, ok := val.(int64); ok {
+			return types.QValueUInt8{Val: uint8(v)}, nil
+		}
+	case types.QValueKindInt16:
+		if v, ok := val.(int64); ok {
+			return types.QValueInt16{Val: int16(v)}, nil
+		}
+	case types.QValueKindInt32:
+		if v, ok := val.(int64); ok {
+			return types.QValueInt32{Val: int32(v)}, nil
+		}
+	case types.QValueKindInt64:
+		if v, ok := val.(int64); ok {
+			return types.QValueInt64{Val: v}, nil
+		}
+	case types.QValueKindFloat32:
+		switch v := val.(type) {
+		case float32:
+			return types.QValueFloat32{Val: v}, nil
+		case float64:
+			return types.QValueFloat32{Val: float32(v)}, nil
+		}
+	case types.QValueKindFloat64:
+		switch v := val.(type) {
+		case float32:
+			return types.QValueFloat64{Val: float64(v)}, nil
+		case float64:
+			return types.QValueFloat64{Val: v}, nil
+		}
+	case types.QValueKindNumeric:
+		// decimal and money are returned as their textual representation
+		var s string
+		switch v := val.(type) {
+		case []byte:
+			s = string(v)
+		case string:
+			s = v
+		default:
+			return nil, fmt.Errorf("unexpected type %T for numeric", val)
+		}
+		num, err := decimal.NewFromString(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse numeric %s: %w", s, err)
+		}
+		return types.QValueNumeric{Val: num}, nil
+	case types.QValueKindString:
+		switch v := val.(type) {
+		case string:
+			return types.QValueString{Val: v}, nil
+		case []byte:
+			return types.QValueString{Val: string(v)}, nil
+		case time.Time:
+			// sql_variant holding a temporal value
+			return types.QValueString{Val: v.Format(time.RFC3339Nano)}, nil
+		default:
+			return types.QValueString{Val: fmt.Sprint(v)}, nil
+		}
+	case types.QValueKindUUID:
+		// uniqueidentifier is stored mixed-endian, let the driver swap bytes around
+		var uid mssql.UniqueIdentifier
+		if err := uid.Scan(val); err != nil {
+			return nil, fmt.Errorf("failed to parse uniqueidentifier: %w", err)
+		}
+		return types.QValueUUID{Val: uuid.UUID(uid)}, nil
+	case types.QValueKindDate:
+		if v, ok := val.(time.Time); ok {
+			return types.QValueDate{Val: v}, nil
+		}
+	case types.QValueKindTime:
+		if v, ok := val.(time.Time); ok {
+			midnight := time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, v.Location())
+			return types.QValueTime{Val: v.Sub(midnight)}, nil
+		}
+	case types.QValueKindTimestamp:
+		if v, ok := val.(time.Time); ok {
+			return types.QValueTimestamp{Val: v}, nil
+		}
+	case types.QValueKindTimestampTZ:
+		if v, ok := val.(time.Time); ok {
+			return types.QValueTimestampTZ{Val: v.UTC()}, nil
+		}
+	case types.QValueKindBytes:
+		if v, ok := val.([]byte); ok {
+			return types.QValueBytes{Val: v}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected type %T for %s", val, qkind)
+}
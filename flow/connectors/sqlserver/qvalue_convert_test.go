@@ -0,0 +1,52 @@
+package connsqlserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestQValueFromSqlServer(t *testing.T) {
+	for _, tc := range []struct {
+		in       any
+		expected types.QValue
+		dataType string
+	}{
+		{dataType: "bit", in: true, expected: types.QValueBoolean{Val: true}},
+		{dataType: "tinyint", in: int64(200), expected: types.QValueUInt8{Val: 200}},
+		{dataType: "int", in: int64(-7), expected: types.QValueInt32{Val: -7}},
+		{dataType: "nvarchar", in: "abc", expected: types.QValueString{Val: "abc"}},
+		{dataType: "varbinary", in: []byte{1, 2}, expected: types.QValueBytes{Val: []byte{1, 2}}},
+		{dataType: "time", in: time.Date(1, 1, 1, 13, 4, 5, 0, time.UTC), expected: types.QValueTime{
+			Val: 13*time.Hour + 4*time.Minute + 5*time.Second,
+		}},
+		{dataType: "bigint", in: nil, expected: types.QValueNull(types.QValueKindInt64)},
+	} {
+		qv, err := qvalueFromSqlServer(qkindFromSqlServerType(tc.dataType), tc.in)
+		require.NoError(t, err, tc.dataType)
+		require.Equal(t, tc.expected, qv, tc.dataType)
+	}
+
+	money, err := qvalueFromSqlServer(qkindFromSqlServerType("money"), []byte("12.3400"))
+	require.NoError(t, err)
+	require.Equal(t, "12.34", money.(types.QValueNumeric).Val.String())
+
+	// uniqueidentifier bytes are mixed-endian on the wire
+	uid, err := qvalueFromSqlServer(types.QValueKindUUID,
+		[]byte{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff})
+	require.NoError(t, err)
+	require.Equal(t, uuid.MustParse("00112233-4455-6677-8899-aabbccddeeff"), uid.(types.QValueUUID).Val)
+
+	_, err = qvalueFromSqlServer(types.QValueKindInt32, "not a number")
+	require.Error(t, err)
+	require.Equal(t, types.QValueKindInvalid, qkindFromSqlServerType("cursor"))
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	require.Equal(t, "[dbo]", quoteIdentifier("dbo"))
+	require.Equal(t, "[we]]ird]", quoteIdentifier("we]ird"))
+}
@@ -0,0 +1,229 @@
+package connsqlserver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared/mysql"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// schemas created by SQL Server itself, including the cdc schema holding change tables
+const systemSchemasFilter = "('cdc', 'sys', 'INFORMATION_SCHEMA', 'guest')"
+
+func (c *SqlServerConnector) GetAllTables(ctx context.Context) (*protos.AllTablesResponse, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT TABLE_SCHEMA + '.' + TABLE_NAME FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_TYPE = 'BASE TABLE' AND TABLE_SCHEMA NOT IN `+systemSchemasFilter)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := scanStrings(rows)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.AllTablesResponse{Tables: tables}, nil
+}
+
+func (c *SqlServerConnector) GetSchemas(ctx context.Context) (*protos.PeerSchemasResponse, error) {
+	// schema ids from 16384 onwards belong to fixed database roles
+	rows, err := c.db.QueryContext(ctx, `SELECT name FROM sys.schemas
+		WHERE schema_id < 16384 AND name NOT IN `+systemSchemasFilter+` ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := scanStrings(rows)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.PeerSchemasResponse{Schemas: schemas}, nil
+}
+
+func (c *SqlServerConnector) GetTablesInSchema(
+	ctx context.Context, schema string, cdcEnabled bool,
+) (*protos.SchemaTablesResponse, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT t.name, t.is_tracked_by_cdc,
+		ISNULL((SELECT SUM(ps.used_page_count) FROM sys.dm_db_partition_stats ps WHERE ps.object_id = t.object_id), 0) * 8192
+		FROM sys.tables t JOIN sys.schemas s ON t.schema_id = s.schema_id
+		WHERE s.name = @p1 ORDER BY t.name`, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make([]*protos.TableResponse, 0)
+	for rows.Next() {
+		var tableName string
+		var trackedByCdc bool
+		var tableSizeInBytes int64
+		if err := rows.Scan(&tableName, &trackedByCdc, &tableSizeInBytes); err != nil {
+			return nil, err
+		}
+		tables = append(tables, &protos.TableResponse{
+			TableName: tableName,
+			CanMirror: !cdcEnabled || trackedByCdc,
+			TableSize: mysql.PrettyBytes(tableSizeInBytes),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &protos.SchemaTablesResponse{Tables: tables}, nil
+}
+
+func (c *SqlServerConnector) GetColumns(ctx context.Context, version uint32, schema string, table string) (*protos.TableColumnsResponse, error) {
+	primaryKeyColumns, err := c.getPrimaryKeyColumns(ctx, schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.db.QueryContext(ctx, `SELECT COLUMN_NAME, DATA_TYPE FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_SCHEMA = @p1 AND TABLE_NAME = @p2 ORDER BY COLUMN_NAME`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]*protos.ColumnsItem, 0)
+	for rows.Next() {
+		var columnName string
+		var dataType string
+		if err := rows.Scan(&columnName, &dataType); err != nil {
+			return nil, err
+		}
+		columns = append(columns, &protos.ColumnsItem{
+			Name:  columnName,
+			Type:  dataType,
+			IsKey: slices.Contains(primaryKeyColumns, columnName),
+			Qkind: string(qkindFromSqlServerType(dataType)),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &protos.TableColumnsResponse{Columns: columns}, nil
+}
+
+func (c *SqlServerConnector) GetTableSchema(
+	ctx context.Context,
+	env map[string]string,
+	version uint32,
+	system protos.TypeSystem,
+	tableMappings []*protos.TableMapping,
+) (map[string]*protos.TableSchema, error) {
+	res := make(map[string]*protos.TableSchema, len(tableMappings))
+	for _, tm := range tableMappings {
+		tableSchema, err := c.getTableSchemaForTable(ctx, env, tm, system)
+		if err != nil {
+			c.logger.Info("error fetching schema", slog.String("table", tm.SourceTableIdentifier), slog.Any("error", err))
+			return nil, err
+		}
+		res[tm.SourceTableIdentifier] = tableSchema
+		c.logger.Info("fetched schema", slog.String("table", tm.SourceTableIdentifier))
+	}
+
+	return res, nil
+}
+
+func (c *SqlServerConnector) getTableSchemaForTable(
+	ctx context.Context,
+	env map[string]string,
+	tm *protos.TableMapping,
+	system protos.TypeSystem,
+) (*protos.TableSchema, error) {
+	schemaTable, err := utils.ParseSchemaTable(tm.SourceTableIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	nullableEnabled, err := internal.PeerDBNullable(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	primary, err := c.getPrimaryKeyColumns(ctx, schemaTable.Schema, schemaTable.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.db.QueryContext(ctx, `SELECT COLUMN_NAME, DATA_TYPE, IS_NULLABLE,
+		ISNULL(NUMERIC_PRECISION, 0), ISNULL(NUMERIC_SCALE, 0)
+		FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_SCHEMA = @p1 AND TABLE_NAME = @p2 ORDER BY ORDINAL_POSITION`, schemaTable.Schema, schemaTable.Table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]*protos.FieldDescription, 0)
+	for rows.Next() {
+		var columnName string
+		var dataType string
+		var isNullable string
+		var numericPrecision int32
+		var numericScale int32
+		if err := rows.Scan(&columnName, &dataType, &isNullable, &numericPrecision, &numericScale); err != nil {
+			return nil, err
+		}
+		if slices.Contains(tm.Exclude, columnName) {
+			continue
+		}
+
+		qkind := qkindFromSqlServerType(dataType)
+		if qkind == types.QValueKindInvalid {
+			return nil, fmt.Errorf("unsupported SQL Server type %s for column %s", dataType, columnName)
+		}
+		columns = append(columns, &protos.FieldDescription{
+			Name:         columnName,
+			Type:         string(qkind),
+			TypeModifier: numericTypmod(dataType, numericPrecision, numericScale),
+			Nullable:     isNullable == "YES",
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s not found", tm.SourceTableIdentifier)
+	}
+
+	return &protos.TableSchema{
+		TableIdentifier:       tm.SourceTableIdentifier,
+		PrimaryKeyColumns:     primary,
+		IsReplicaIdentityFull: false,
+		System:                system,
+		NullableEnabled:       nullableEnabled,
+		Columns:               columns,
+	}, nil
+}
+
+func (c *SqlServerConnector) getPrimaryKeyColumns(ctx context.Context, schema string, table string) ([]string, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT ku.COLUMN_NAME
+		FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc
+		JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE ku
+		ON tc.CONSTRAINT_SCHEMA = ku.CONSTRAINT_SCHEMA AND tc.CONSTRAINT_NAME = ku.CONSTRAINT_NAME
+		WHERE tc.CONSTRAINT_TYPE = 'PRIMARY KEY' AND tc.TABLE_SCHEMA = @p1 AND tc.TABLE_NAME = @p2
+		ORDER BY ku.ORDINAL_POSITION`, schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get primary key columns: %w", err)
+	}
+	return scanStrings(rows)
+}
+
+func scanStrings(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+	result := make([]string, 0)
+	for rows.Next() {
+		var val string
+		if err := rows.Scan(&val); err != nil {
+			return nil, err
+		}
+		result = append(result, val)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,126 @@
+package connsqlserver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+type SqlServerConnector struct {
+	*metadataStore.PostgresMetadata
+	config    *protos.SqlServerConfig
+	db        *sql.DB
+	logger    log.Logger
+	bytesRead atomic.Int64
+}
+
+func NewSqlServerConnector(ctx context.Context, config *protos.SqlServerConfig) (*SqlServerConnector, error) {
+	logger := internal.LoggerFromCtx(ctx)
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	connector, err := mssql.NewConnector(connectionString(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SQL Server connection string: %w", err)
+	}
+	c := &SqlServerConnector{
+		PostgresMetadata: pgMetadata,
+		config:           config,
+		logger:           logger,
+	}
+	connector.Dialer = &meteredDialer{bytesRead: &c.bytesRead, dialer: &net.Dialer{Timeout: time.Minute}}
+	c.db = sql.OpenDB(connector)
+	if err := c.db.PingContext(ctx); err != nil {
+		c.db.Close()
+		return nil, fmt.Errorf("failed to connect to SQL Server: %w", err)
+	}
+
+	return c, nil
+}
+
+// meteredDialer counts bytes read from SQL Server, database/sql does not expose raw sizes
+type meteredDialer struct {
+	bytesRead *atomic.Int64
+	dialer    *net.Dialer
+}
+
+type meteredConn struct {
+	net.Conn
+	bytesRead *atomic.Int64
+}
+
+func (d *meteredDialer) DialContext(ctx context.Context, network string, addr string) (net.Conn, error) {
+	conn, err := d.dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return conn, err
+	}
+	return &meteredConn{Conn: conn, bytesRead: d.bytesRead}, nil
+}
+
+func (mc *meteredConn) Read(b []byte) (int, error) {
+	read, err := mc.Conn.Read(b)
+	mc.bytesRead.Add(int64(read))
+	return read, err
+}
+
+func connectionString(config *protos.SqlServerConfig) string {
+	query := url.Values{}
+	query.Set("database", config.Database)
+	query.Set("app name", "PeerDB")
+	port := config.Port
+	if port == 0 {
+		port = 1433
+	}
+	connURL := &url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(config.User, config.Password),
+		Host:     config.Server + ":" + strconv.FormatUint(uint64(port), 10),
+		RawQuery: query.Encode(),
+	}
+	return connURL.String()
+}
+
+func (c *SqlServerConnector) Close() error {
+	if c != nil && c.db != nil {
+		return c.db.Close()
+	}
+	return nil
+}
+
+func (c *SqlServerConnector) ConnectionActive(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := c.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping SQL Server: %w", err)
+	}
+	return nil
+}
+
+func (c *SqlServerConnector) GetVersion(ctx context.Context) (string, error) {
+	var version string
+	if err := c.db.QueryRowContext(ctx, "SELECT CAST(SERVERPROPERTY('ProductVersion') AS NVARCHAR(128))").Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to get SQL Server version: %w", err)
+	}
+	return version, nil
+}
+
+// quoteIdentifier quotes an identifier with brackets, escaping any closing bracket
+func quoteIdentifier(identifier string) string {
+	return "[" + strings.ReplaceAll(identifier, "]", "]]") + "]"
+}
@@ -0,0 +1,60 @@
+package connsqlserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func (c *SqlServerConnector) ValidateCheck(ctx context.Context) error {
+	if _, err := c.GetVersion(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *SqlServerConnector) ValidateMirrorSource(ctx context.Context, cfg *protos.FlowConnectionConfigs) error {
+	for _, tableMapping := range cfg.TableMappings {
+		parsedTable, err := utils.ParseSchemaTable(tableMapping.SourceTableIdentifier)
+		if err != nil {
+			return fmt.Errorf("invalid source table identifier: %w", err)
+		}
+		if _, err := c.db.ExecContext(ctx, fmt.Sprintf("SELECT TOP 0 * FROM %s.%s",
+			quoteIdentifier(parsedTable.Schema), quoteIdentifier(parsedTable.Table))); err != nil {
+			return fmt.Errorf("error checking table %s: %w", tableMapping.SourceTableIdentifier, err)
+		}
+	}
+	// no need to check change data capture for initial snapshot only mirrors
+	if cfg.DoInitialSnapshot && cfg.InitialSnapshotOnly {
+		return nil
+	}
+
+	var cdcEnabled bool
+	if err := c.db.QueryRowContext(ctx,
+		"SELECT is_cdc_enabled FROM sys.databases WHERE name = DB_NAME()",
+	).Scan(&cdcEnabled); err != nil {
+		return fmt.Errorf("failed to check if change data capture is enabled: %w", err)
+	} else if !cdcEnabled {
+		return errors.New("change data capture is not enabled for database, run sys.sp_cdc_enable_db")
+	}
+
+	instances, err := c.getCaptureInstances(ctx)
+	if err != nil {
+		return err
+	}
+	for _, tableMapping := range cfg.TableMappings {
+		if _, ok := instances[tableMapping.SourceTableIdentifier]; !ok {
+			return fmt.Errorf("change data capture is not enabled for table %s, run sys.sp_cdc_enable_table",
+				tableMapping.SourceTableIdentifier)
+		}
+	}
+
+	if _, err := c.getMaxLsn(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -128,16 +128,21 @@ func init() {
 	gob.Register(types.QValueArrayNumeric{})
 }
 
-func (c *cdcStore[T]) initPebbleDB() error {
-	if c.pebbleDB != nil {
-		return nil
-	}
-
+func registerRecordTypes[T model.Items]() {
 	gob.Register(&model.InsertRecord[T]{})
 	gob.Register(&model.UpdateRecord[T]{})
 	gob.Register(&model.DeleteRecord[T]{})
 	gob.Register(&model.RelationRecord[T]{})
 	gob.Register(&model.MessageRecord[T]{})
+	gob.Register(&model.TruncateRecord[T]{})
+}
+
+func (c *cdcStore[T]) initPebbleDB() error {
+	if c.pebbleDB != nil {
+		return nil
+	}
+
+	registerRecordTypes[T]()
 
 	var err error
 	// we don't want a WAL since cache, we don't want to overwrite another DB either
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"iter"
+	"math"
+	"os"
+
+	"github.com/cockroachdb/pebble/v2"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// CDCTxnStore buffers changes of in-progress transactions streamed by the source,
+// keyed by top level xid and arrival order, until the transaction commits or aborts.
+// Unlike cdcStore it outlives a single pull, since a transaction can be streamed across many batches.
+type CDCTxnStore struct {
+	pebbleDB     *pebble.DB
+	dbFolderName string
+	seq          uint64
+}
+
+func NewCDCTxnStore(flowJobName string) *CDCTxnStore {
+	return &CDCTxnStore{
+		pebbleDB:     nil,
+		dbFolderName: fmt.Sprintf("%s/%s_txn_%s", os.TempDir(), flowJobName, shared.RandomString(8)),
+	}
+}
+
+func (s *CDCTxnStore) initPebbleDB() error {
+	if s.pebbleDB != nil {
+		return nil
+	}
+
+	var err error
+	// transactions are streamed again by the source after a reconnect, no need for a WAL
+	s.pebbleDB, err = pebble.Open(s.dbFolderName, &pebble.Options{
+		DisableWAL:         true,
+		ErrorIfExists:      true,
+		FormatMajorVersion: pebble.FormatNewest,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize Pebble database: %w", err)
+	}
+	return nil
+}
+
+// txnKeyBounds returns the range of keys holding changes of xid
+func txnKeyBounds(xid uint32) ([]byte, []byte) {
+	lower := binary.BigEndian.AppendUint32(make([]byte, 0, 4), xid)
+	// sorts after every 12 byte key of xid
+	upper := append(binary.BigEndian.AppendUint64(binary.BigEndian.AppendUint32(make([]byte, 0, 13), xid), math.MaxUint64), 0)
+	return lower, upper
+}
+
+// AddTxnRecord stores rec as the next change of transaction xid, made by its subtransaction subXid
+func AddTxnRecord[T model.Items](s *CDCTxnStore, xid uint32, subXid uint32, rec model.Record[T]) error {
+	if err := s.initPebbleDB(); err != nil {
+		return err
+	}
+	registerRecordTypes[T]()
+
+	key := binary.BigEndian.AppendUint32(make([]byte, 0, 12), xid)
+	key = binary.BigEndian.AppendUint64(key, s.seq)
+	// necessary to point pointer to interface so the interface is exposed
+	// instead of the underlying type
+	encodedRec, err := encVal(&rec)
+	if err != nil {
+		return err
+	}
+	value := append(binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(encodedRec)), subXid), encodedRec...)
+	if err := s.pebbleDB.Set(key, value, &pebble.WriteOptions{Sync: false}); err != nil {
+		return fmt.Errorf("unable to store value in Pebble: %w", err)
+	}
+	s.seq += 1
+	return nil
+}
+
+// TxnRecords iterates over the changes of transaction xid in the order they were added
+func TxnRecords[T model.Items](s *CDCTxnStore, xid uint32) iter.Seq2[model.Record[T], error] {
+	return func(yield func(model.Record[T], error) bool) {
+		if s.pebbleDB == nil {
+			return
+		}
+		lower, upper := txnKeyBounds(xid)
+		it, err := s.pebbleDB.NewIter(&pebble.IterOptions{LowerBound: lower, UpperBound: upper})
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to create iterator: %w", err))
+			return
+		}
+		defer it.Close()
+
+		for it.First(); it.Valid(); it.Next() {
+			var rec model.Record[T]
+			if err := gob.NewDecoder(bytes.NewReader(it.Value()[4:])).Decode(&rec); err != nil {
+				yield(nil, fmt.Errorf("failed to decode record: %w", err))
+				return
+			}
+			if !yield(rec, nil) {
+				return
+			}
+		}
+		if err := it.Error(); err != nil {
+			yield(nil, fmt.Errorf("failed to iterate records of transaction %d: %w", xid, err))
+		}
+	}
+}
+
+// AbortSubTxn drops the changes of transaction xid made by its subtransaction subXid
+func (s *CDCTxnStore) AbortSubTxn(xid uint32, subXid uint32) error {
+	if s.pebbleDB == nil {
+		return nil
+	}
+	lower, upper := txnKeyBounds(xid)
+	it, err := s.pebbleDB.NewIter(&pebble.IterOptions{LowerBound: lower, UpperBound: upper})
+	if err != nil {
+		return fmt.Errorf("failed to create iterator: %w", err)
+	}
+	defer it.Close()
+
+	batch := s.pebbleDB.NewBatch()
+	defer batch.Close()
+	for it.First(); it.Valid(); it.Next() {
+		if binary.BigEndian.Uint32(it.Value()) == subXid {
+			if err := batch.Delete(it.Key(), nil); err != nil {
+				return fmt.Errorf("failed to delete record of subtransaction %d: %w", subXid, err)
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return fmt.Errorf("failed to iterate records of transaction %d: %w", xid, err)
+	}
+	return batch.Commit(&pebble.WriteOptions{Sync: false})
+}
+
+// Discard drops all changes of transaction xid, after it was committed or aborted
+func (s *CDCTxnStore) Discard(xid uint32) error {
+	if s.pebbleDB == nil {
+		return nil
+	}
+	lower, upper := txnKeyBounds(xid)
+	if err := s.pebbleDB.DeleteRange(lower, upper, &pebble.WriteOptions{Sync: false}); err != nil {
+		return fmt.Errorf("failed to discard records of transaction %d: %w", xid, err)
+	}
+	return nil
+}
+
+func (s *CDCTxnStore) Close() error {
+	if s.pebbleDB != nil {
+		if err := s.pebbleDB.Close(); err != nil {
+			return fmt.Errorf("failed to close database: %w", err)
+		}
+		s.pebbleDB = nil
+	}
+	if err := os.RemoveAll(s.dbFolderName); err != nil {
+		return fmt.Errorf("failed to delete database file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+func collectTxnRecords(t *testing.T, store *CDCTxnStore, xid uint32) []int64 {
+	t.Helper()
+
+	var checkpoints []int64
+	for rec, err := range TxnRecords[model.RecordItems](store, xid) {
+		require.NoError(t, err)
+		checkpoints = append(checkpoints, rec.GetCheckpointID())
+	}
+	return checkpoints
+}
+
+func TestTxnStoreSubTxnAbort(t *testing.T) {
+	t.Parallel()
+	store := NewCDCTxnStore("test_txn_store")
+	t.Cleanup(func() { require.NoError(t, store.Close()) })
+
+	for i, subXid := range []uint32{10, 11, 10, 11, 12} {
+		_, rec := genKeyAndRec(t)
+		rec.(*model.InsertRecord[model.RecordItems]).CheckpointID = int64(i)
+		require.NoError(t, AddTxnRecord(store, 10, subXid, rec))
+	}
+	_, other := genKeyAndRec(t)
+	require.NoError(t, AddTxnRecord(store, 20, 20, other))
+
+	require.Equal(t, []int64{0, 1, 2, 3, 4}, collectTxnRecords(t, store, 10))
+
+	require.NoError(t, store.AbortSubTxn(10, 11))
+	require.Equal(t, []int64{0, 2, 4}, collectTxnRecords(t, store, 10))
+
+	require.NoError(t, store.Discard(10))
+	require.Empty(t, collectTxnRecords(t, store, 10))
+	require.Len(t, collectTxnRecords(t, store, 20), 1)
+}
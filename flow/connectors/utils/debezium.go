@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/pua"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// placeholder Debezium uses for unchanged TOAST columns
+const debeziumUnavailableValue = "__debezium_unavailable_value"
+
+type debeziumSource struct {
+	Version   string `json:"version"`
+	Connector string `json:"connector"`
+	Name      string `json:"name"`
+	Snapshot  string `json:"snapshot"`
+	Schema    string `json:"schema,omitempty"`
+	Table     string `json:"table"`
+	TsMs      int64  `json:"ts_ms"`
+	LSN       int64  `json:"lsn"`
+}
+
+type debeziumEnvelope struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+	Op     string          `json:"op"`
+	Source debeziumSource  `json:"source"`
+	TsMs   int64           `json:"ts_ms"`
+}
+
+// DebeziumMessage is a key and value for a queue, Value is nil for tombstones
+type DebeziumMessage struct {
+	Key   []byte
+	Value []byte
+}
+
+// DebeziumEncoder renders records as Debezium change events, see
+// https://debezium.io/documentation/reference/stable/connectors/postgresql.html#postgresql-events
+type DebeziumEncoder struct {
+	// destination table name -> schema, columns are rendered in schema order and keyed by primary key
+	tableSchemas map[string]*protos.TableSchema
+	flowJobName  string
+	toJSONOpts   model.ToJSONOptions
+	snapshot     bool
+}
+
+func NewDebeziumEncoder(flowJobName string, tableSchemas map[string]*protos.TableSchema, snapshot bool) *DebeziumEncoder {
+	return &DebeziumEncoder{
+		tableSchemas: tableSchemas,
+		flowJobName:  flowJobName,
+		toJSONOpts:   model.NewToJSONOptions(nil, true),
+		snapshot:     snapshot,
+	}
+}
+
+// NewQRepDebeziumEncoder encodes snapshot rows as read events
+func NewQRepDebeziumEncoder(ctx context.Context, config *protos.QRepConfig, schema types.QRecordSchema) *DebeziumEncoder {
+	return NewDebeziumEncoder(config.FlowJobName, map[string]*protos.TableSchema{
+		config.DestinationTableIdentifier: QRepTableSchema(ctx, config, schema),
+	}, true)
+}
+
+// renderRow renders items as a JSON object with the columns of the table schema,
+// columns missing from items are null unless they are unchanged TOAST columns
+func (e *DebeziumEncoder) renderRow(
+	items model.RecordItems, tableSchema *protos.TableSchema, unchangedToastColumns map[string]struct{},
+) (json.RawMessage, error) {
+	if items.ColToVal == nil {
+		return nil, nil
+	}
+	row := items
+	if tableSchema != nil {
+		row = model.NewRecordItems(len(tableSchema.Columns))
+		for _, column := range tableSchema.Columns {
+			if value := items.GetColumnValue(column.Name); value != nil {
+				row.AddColumn(column.Name, value)
+			} else if _, ok := unchangedToastColumns[column.Name]; ok {
+				row.AddColumn(column.Name, types.QValueString{Val: debeziumUnavailableValue})
+			} else {
+				row.AddColumn(column.Name, types.QValueNull(types.QValueKind(column.Type)))
+			}
+		}
+	}
+	return row.MarshalJSONWithOptions(e.toJSONOpts)
+}
+
+func (e *DebeziumEncoder) renderKey(items model.RecordItems, tableSchema *protos.TableSchema) ([]byte, error) {
+	if tableSchema == nil || len(tableSchema.PrimaryKeyColumns) == 0 || items.ColToVal == nil {
+		return nil, nil
+	}
+	key := model.NewRecordItems(len(tableSchema.PrimaryKeyColumns))
+	for _, column := range tableSchema.PrimaryKeyColumns {
+		value := items.GetColumnValue(column)
+		if value == nil {
+			return nil, nil
+		}
+		key.AddColumn(column, value)
+	}
+	return key.MarshalJSONWithOptions(e.toJSONOpts)
+}
+
+func (e *DebeziumEncoder) source(record model.Record[model.RecordItems]) debeziumSource {
+	snapshot := "false"
+	if e.snapshot {
+		snapshot = "true"
+	}
+	schema, table, hasDot := strings.Cut(record.GetSourceTableName(), ".")
+	if !hasDot {
+		schema, table = "", schema
+	}
+	return debeziumSource{
+		Version:   "peerdb",
+		Connector: "peerdb",
+		Name:      e.flowJobName,
+		Snapshot:  snapshot,
+		Schema:    schema,
+		Table:     table,
+		TsMs:      record.GetCommitTime().UnixMilli(),
+		LSN:       record.GetCheckpointID(),
+	}
+}
+
+// Encode returns the change event for a record, followed by a tombstone for deletes of keyed tables
+func (e *DebeziumEncoder) Encode(record model.Record[model.RecordItems]) ([]DebeziumMessage, error) {
+	tableSchema := e.tableSchemas[record.GetDestinationTableName()]
+	envelope := debeziumEnvelope{
+		Source: e.source(record),
+		TsMs:   time.Now().UnixMilli(),
+	}
+
+	var keyItems model.RecordItems
+	var err error
+	switch r := record.(type) {
+	case *model.InsertRecord[model.RecordItems]:
+		envelope.Op = "c"
+		if e.snapshot {
+			envelope.Op = "r"
+		}
+		keyItems = r.Items
+		envelope.After, err = e.renderRow(r.Items, tableSchema, nil)
+	case *model.UpdateRecord[model.RecordItems]:
+		envelope.Op = "u"
+		keyItems = r.NewItems
+		if r.OldItems.Len() > 0 {
+			envelope.Before, err = e.renderRow(r.OldItems, tableSchema, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		envelope.After, err = e.renderRow(r.NewItems, tableSchema, r.UnchangedToastColumns)
+	case *model.DeleteRecord[model.RecordItems]:
+		envelope.Op = "d"
+		keyItems = r.Items
+		envelope.Before, err = e.renderRow(r.Items, tableSchema, r.UnchangedToastColumns)
+	case *model.TruncateRecord[model.RecordItems]:
+		envelope.Op = "t"
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s record for %s: %w", record.Kind(), record.GetDestinationTableName(), err)
+	}
+
+	key, err := e.renderKey(keyItems, tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render key for %s: %w", record.GetDestinationTableName(), err)
+	}
+	value, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+	messages := []DebeziumMessage{{Key: key, Value: value}}
+	if envelope.Op == "d" && key != nil {
+		messages = append(messages, DebeziumMessage{Key: key})
+	}
+	return messages, nil
+}
+
+// DebeziumOnRecord is an onRecord function returning {key, value} tables for the encoded record.
+// Tombstones only make sense for log compacted queues, they are skipped unless tombstones is set.
+func DebeziumOnRecord(encoder *DebeziumEncoder, tombstones bool) lua.LGFunction {
+	return func(ls *lua.LState) int {
+		_, record := pua.LuaRecord.Check(ls, 1)
+		messages, err := encoder.Encode(record)
+		if err != nil {
+			ls.RaiseError("%s", err.Error())
+			return 0
+		}
+		pushed := 0
+		for _, message := range messages {
+			if message.Value == nil && !tombstones {
+				continue
+			}
+			tbl := ls.NewTable()
+			if message.Key != nil {
+				tbl.RawSetString("key", lua.LString(message.Key))
+			}
+			if message.Value != nil {
+				tbl.RawSetString("value", lua.LString(message.Value))
+			}
+			ls.Push(tbl)
+			pushed += 1
+		}
+		return pushed
+	}
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestDebeziumEncoder(t *testing.T) {
+	encoder := NewDebeziumEncoder("mirror", map[string]*protos.TableSchema{
+		"orders": {
+			TableIdentifier:   "public.orders",
+			PrimaryKeyColumns: []string{"id"},
+			Columns: []*protos.FieldDescription{
+				{Name: "id", Type: string(types.QValueKindInt64)},
+				{Name: "note", Type: string(types.QValueKindString)},
+				{Name: "body", Type: string(types.QValueKindString)},
+			},
+		},
+	}, false)
+	base := model.BaseRecord{CheckpointID: 42, CommitTimeNano: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).UnixNano()}
+
+	items := model.NewRecordItems(2)
+	items.AddColumn("id", types.QValueInt64{Val: 1})
+	items.AddColumn("note", types.QValueString{Val: "hi"})
+	messages, err := encoder.Encode(&model.InsertRecord[model.RecordItems]{
+		Items:                items,
+		SourceTableName:      "public.orders",
+		DestinationTableName: "orders",
+		BaseRecord:           base,
+	})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.JSONEq(t, `{"id":1}`, string(messages[0].Key))
+
+	var event map[string]any
+	require.NoError(t, json.Unmarshal(messages[0].Value, &event))
+	require.Equal(t, "c", event["op"])
+	require.Nil(t, event["before"])
+	require.Equal(t, map[string]any{"id": float64(1), "note": "hi", "body": nil}, event["after"])
+	source := event["source"].(map[string]any)
+	require.Equal(t, "public", source["schema"])
+	require.Equal(t, "orders", source["table"])
+	require.Equal(t, "mirror", source["name"])
+	require.Equal(t, float64(42), source["lsn"])
+	require.Equal(t, float64(base.GetCommitTime().UnixMilli()), source["ts_ms"])
+
+	messages, err = encoder.Encode(&model.DeleteRecord[model.RecordItems]{
+		Items:                 items,
+		UnchangedToastColumns: map[string]struct{}{"body": {}},
+		SourceTableName:       "public.orders",
+		DestinationTableName:  "orders",
+		BaseRecord:            base,
+	})
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	require.NoError(t, json.Unmarshal(messages[0].Value, &event))
+	require.Equal(t, "d", event["op"])
+	require.Nil(t, event["after"])
+	require.Equal(t, debeziumUnavailableValue, event["before"].(map[string]any)["body"])
+	require.JSONEq(t, `{"id":1}`, string(messages[1].Key))
+	require.Nil(t, messages[1].Value)
+}
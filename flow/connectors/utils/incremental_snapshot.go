@@ -0,0 +1,323 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+const incrementalSnapshotsTableName = "metadata_incremental_snapshots"
+
+// IncrementalSnapshot re-reads a table of a running mirror in primary key order, one chunk at a time.
+// A chunk is read between a low and a high watermark written to the change stream:
+// changes seen between the watermarks may be newer than what the chunk read, so their rows are dropped from the chunk,
+// rows left are added to the batch as inserts when the high watermark is seen, after every change that preceded it
+type IncrementalSnapshot struct {
+	SourceTable      string
+	DestinationTable string
+	Filter           string
+	// text of the primary key values of the last row read, bound as parameters, nil before the first chunk
+	LastPK []string
+	// inclusive bounds of the primary key values read, nil for no bound
+	StartPK    []string
+	EndPK      []string
+	ID         int64
+	RowsSynced int64
+	// the destination table is truncated ahead of the first chunk
+	TruncateDestination bool
+}
+
+type snapshotChunk struct {
+	snapshot *IncrementalSnapshot
+	index    map[model.TableWithPkey]int
+	// dropped rows are nil
+	rows   []model.Items
+	lastPK []string
+	id     string
+	open   bool
+	last   bool
+}
+
+type snapshotProgress struct {
+	lastPK     []string
+	id         int64
+	rowsSynced int64
+	done       bool
+}
+
+// IncrementalSnapshots holds the incremental snapshots of a mirror for a connector, across pulls.
+// Only one chunk is in flight at a time, its window can span batches.
+// Progress of a chunk is saved once the batch it was added to is synced,
+// a chunk lost before that, e.g. when the connector is reset, is read again
+type IncrementalSnapshots struct {
+	pool        shared.CatalogPool
+	chunk       *snapshotChunk
+	flowJobName string
+	// tells watermarks written before a reset apart from those of this connector
+	instance  string
+	snapshots []*IncrementalSnapshot
+	// chunks added to the batch of the last pull
+	flushed []snapshotProgress
+	seq     uint64
+}
+
+func NewIncrementalSnapshots(pool shared.CatalogPool, flowJobName string) *IncrementalSnapshots {
+	return &IncrementalSnapshots{
+		pool:        pool,
+		flowJobName: flowJobName,
+		instance:    shared.RandomString(8),
+	}
+}
+
+// Refresh is called at the start of a pull, once the batch of the previous pull is synced:
+// progress of chunks added to that batch is saved, then snapshots are reloaded unless a chunk is in flight
+func (s *IncrementalSnapshots) Refresh(ctx context.Context) error {
+	for len(s.flushed) > 0 {
+		progress := s.flushed[0]
+		if _, err := s.pool.Exec(ctx, "UPDATE "+incrementalSnapshotsTableName+
+			" SET last_pk=$2,rows_synced=$3,end_time=CASE WHEN $4 THEN NOW() END WHERE id=$1",
+			progress.id, progress.lastPK, progress.rowsSynced, progress.done,
+		); err != nil {
+			return fmt.Errorf("failed to save progress of incremental snapshot %d: %w", progress.id, err)
+		}
+		s.flushed = s.flushed[1:]
+	}
+	if s.chunk != nil {
+		return nil
+	}
+
+	snapshots, err := GetIncrementalSnapshots(ctx, s.pool, s.flowJobName, true)
+	if err != nil {
+		return err
+	}
+	s.snapshots = snapshots
+	return nil
+}
+
+// Next returns the snapshot to read a chunk of, nil while a chunk is in flight.
+// Snapshots of tables without a primary key in tableNameSchemaMapping are skipped
+func (s *IncrementalSnapshots) Next(tableNameSchemaMapping map[string]*protos.TableSchema) *IncrementalSnapshot {
+	if s.chunk != nil {
+		return nil
+	}
+	for _, snapshot := range s.snapshots {
+		if schema, ok := tableNameSchemaMapping[snapshot.DestinationTable]; ok && len(schema.PrimaryKeyColumns) > 0 {
+			return snapshot
+		}
+	}
+	return nil
+}
+
+// NextChunkID names the next chunk of snapshot, the name is carried by its watermarks
+func (s *IncrementalSnapshots) NextChunkID(snapshot *IncrementalSnapshot) string {
+	s.seq += 1
+	return fmt.Sprintf("%s:%s:%d:%d", s.flowJobName, s.instance, snapshot.ID, s.seq)
+}
+
+// ChunkID returns the name of the chunk in flight, empty if none
+func (s *IncrementalSnapshots) ChunkID() string {
+	if s.chunk == nil {
+		return ""
+	}
+	return s.chunk.id
+}
+
+// OpenWindow starts dropping rows of chunk id changed by cdc, called at its low watermark
+func (s *IncrementalSnapshots) OpenWindow(id string) {
+	if s.chunk != nil && s.chunk.id == id {
+		s.chunk.open = true
+	}
+}
+
+// AddChunk holds rows read for chunk id of snapshot, which were read after its low watermark was written.
+// Fewer rows than chunkSize make it the last chunk, no rows complete the snapshot right away,
+// in which case false is returned as there is no need for a high watermark
+func AddChunk[Items model.Items](
+	s *IncrementalSnapshots,
+	snapshot *IncrementalSnapshot,
+	id string,
+	rows []Items,
+	lastPK []string,
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+	chunkSize int,
+) (bool, error) {
+	if len(rows) == 0 {
+		s.complete(snapshot, snapshot.LastPK)
+		return false, nil
+	}
+
+	chunk := &snapshotChunk{
+		snapshot: snapshot,
+		index:    make(map[model.TableWithPkey]int, len(rows)),
+		rows:     make([]model.Items, 0, len(rows)),
+		lastPK:   lastPK,
+		id:       id,
+		last:     len(rows) < chunkSize,
+	}
+	for _, row := range rows {
+		key, err := model.ItemsToTablePKey(tableNameSchemaMapping, snapshot.DestinationTable, row)
+		if err != nil {
+			return false, err
+		}
+		chunk.index[key] = len(chunk.rows)
+		chunk.rows = append(chunk.rows, row)
+	}
+	s.chunk = chunk
+	return true, nil
+}
+
+// DropChunkRows drops rows of the chunk in flight changed by rec, once its window is open
+func DropChunkRows[Items model.Items](
+	s *IncrementalSnapshots,
+	rec model.Record[Items],
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+) error {
+	if s == nil || s.chunk == nil || !s.chunk.open || rec.GetDestinationTableName() != s.chunk.snapshot.DestinationTable {
+		return nil
+	}
+
+	tableName := rec.GetDestinationTableName()
+	drop := func(items model.Items) error {
+		key, err := model.ItemsToTablePKey(tableNameSchemaMapping, tableName, items)
+		if err != nil {
+			return err
+		}
+		if idx, ok := s.chunk.index[key]; ok {
+			s.chunk.rows[idx] = nil
+			delete(s.chunk.index, key)
+		}
+		return nil
+	}
+	switch r := rec.(type) {
+	case *model.InsertRecord[Items]:
+		return drop(r.Items)
+	case *model.UpdateRecord[Items]:
+		// old row is only sent when the key changed, in which case the row at the old key is gone too
+		_ = drop(r.OldItems)
+		return drop(r.NewItems)
+	case *model.DeleteRecord[Items]:
+		return drop(r.Items)
+	case *model.TruncateRecord[Items]:
+		clear(s.chunk.rows)
+		clear(s.chunk.index)
+	}
+	return nil
+}
+
+// FlushChunk ends chunk id at its high watermark, returning rows left in the chunk as inserts
+func FlushChunk[Items model.Items](s *IncrementalSnapshots, id string, baseRecord model.BaseRecord) []model.Record[Items] {
+	chunk := s.chunk
+	if chunk == nil || chunk.id != id {
+		return nil
+	}
+	s.chunk = nil
+
+	records := make([]model.Record[Items], 0, len(chunk.index))
+	for _, row := range chunk.rows {
+		if row != nil {
+			records = append(records, &model.InsertRecord[Items]{
+				BaseRecord:           baseRecord,
+				Items:                row.(Items),
+				SourceTableName:      chunk.snapshot.SourceTable,
+				DestinationTableName: chunk.snapshot.DestinationTable,
+			})
+		}
+	}
+
+	// rows dropped from the chunk were synced by the changes that dropped them
+	chunk.snapshot.RowsSynced += int64(len(records))
+	if chunk.last {
+		s.complete(chunk.snapshot, chunk.lastPK)
+	} else {
+		chunk.snapshot.LastPK = chunk.lastPK
+		s.flushed = append(s.flushed, snapshotProgress{
+			lastPK:     chunk.lastPK,
+			id:         chunk.snapshot.ID,
+			rowsSynced: chunk.snapshot.RowsSynced,
+		})
+	}
+	return records
+}
+
+// TruncateBeforeChunk returns the truncate of the destination table to add to the batch before reading the first chunk,
+// nil if snapshot does not truncate its destination. The truncate is handled like one of the source table,
+// so the truncate policy of the mirror applies to it
+func TruncateBeforeChunk[Items model.Items](snapshot *IncrementalSnapshot) *model.TruncateRecord[Items] {
+	if !snapshot.TruncateDestination || snapshot.LastPK != nil {
+		return nil
+	}
+	return &model.TruncateRecord[Items]{
+		BaseRecord:           model.BaseRecord{CommitTimeNano: time.Now().UnixNano()},
+		SourceTableName:      snapshot.SourceTable,
+		DestinationTableName: snapshot.DestinationTable,
+	}
+}
+
+func (s *IncrementalSnapshots) complete(snapshot *IncrementalSnapshot, lastPK []string) {
+	s.snapshots = slices.DeleteFunc(s.snapshots, func(pending *IncrementalSnapshot) bool {
+		return pending == snapshot
+	})
+	s.flushed = append(s.flushed, snapshotProgress{
+		lastPK:     lastPK,
+		id:         snapshot.ID,
+		rowsSynced: snapshot.RowsSynced,
+		done:       true,
+	})
+}
+
+// AddIncrementalSnapshot queues snapshot of a table to be read incrementally by the running mirror,
+// a non empty filter or primary key bounds limit the snapshot to matching rows.
+// A table has at most one pending snapshot, if there is one already its id is returned
+func AddIncrementalSnapshot(
+	ctx context.Context,
+	pool shared.CatalogPool,
+	flowJobName string,
+	snapshot *IncrementalSnapshot,
+) (int64, error) {
+	var id int64
+	if err := pool.QueryRow(ctx, "WITH pending AS (SELECT id FROM "+incrementalSnapshotsTableName+
+		" WHERE job_name=$1 AND destination_table=$3 AND end_time IS NULL),"+
+		" added AS (INSERT INTO "+incrementalSnapshotsTableName+
+		"(job_name,source_table,destination_table,filter,start_pk,end_pk,truncate_destination)"+
+		" SELECT $1,$2,$3,$4,$5,$6,$7 WHERE NOT EXISTS (SELECT * FROM pending) RETURNING id)"+
+		" SELECT id FROM added UNION ALL SELECT id FROM pending",
+		flowJobName, snapshot.SourceTable, snapshot.DestinationTable, snapshot.Filter,
+		snapshot.StartPK, snapshot.EndPK, snapshot.TruncateDestination,
+	).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to add incremental snapshot of %s: %w", snapshot.SourceTable, err)
+	}
+	return id, nil
+}
+
+// GetIncrementalSnapshots returns snapshots of the mirror in the order they were added
+func GetIncrementalSnapshots(
+	ctx context.Context,
+	pool shared.CatalogPool,
+	flowJobName string,
+	pendingOnly bool,
+) ([]*IncrementalSnapshot, error) {
+	rows, err := pool.Query(ctx, "SELECT id,source_table,destination_table,filter,last_pk,start_pk,end_pk,"+
+		"truncate_destination,rows_synced FROM "+
+		incrementalSnapshotsTableName+" WHERE job_name=$1 AND (NOT $2 OR end_time IS NULL) ORDER BY id",
+		flowJobName, pendingOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incremental snapshots: %w", err)
+	}
+	snapshots, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*IncrementalSnapshot, error) {
+		var snapshot IncrementalSnapshot
+		err := row.Scan(&snapshot.ID, &snapshot.SourceTable, &snapshot.DestinationTable, &snapshot.Filter,
+			&snapshot.LastPK, &snapshot.StartPK, &snapshot.EndPK, &snapshot.TruncateDestination, &snapshot.RowsSynced)
+		return &snapshot, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incremental snapshots: %w", err)
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func TestIncrementalSnapshotChunk(t *testing.T) {
+	mapping := map[string]*protos.TableSchema{
+		"dst": {
+			Columns:           []*protos.FieldDescription{{Name: "id"}, {Name: "val"}},
+			PrimaryKeyColumns: []string{"id"},
+		},
+	}
+	row := func(id string) model.PgItems {
+		items := model.NewPgItems(2)
+		items.AddColumn("id", []byte(id))
+		items.AddColumn("val", []byte("v"+id))
+		return items
+	}
+
+	snapshots := NewIncrementalSnapshots(shared.CatalogPool{}, "flow")
+	snapshot := &IncrementalSnapshot{ID: 1, SourceTable: "public.src", DestinationTable: "dst"}
+	snapshots.snapshots = []*IncrementalSnapshot{snapshot}
+	require.Same(t, snapshot, snapshots.Next(mapping))
+
+	id := snapshots.NextChunkID(snapshot)
+	needsHighWatermark, err := AddChunk(snapshots, snapshot, id, []model.PgItems{row("1"), row("2"), row("3")},
+		[]string{"3"}, mapping, 3)
+	require.NoError(t, err)
+	require.True(t, needsHighWatermark)
+	require.Nil(t, snapshots.Next(mapping))
+
+	// changes before the low watermark do not drop rows
+	require.NoError(t, DropChunkRows[model.PgItems](snapshots,
+		&model.DeleteRecord[model.PgItems]{Items: row("1"), DestinationTableName: "dst"}, mapping))
+	snapshots.OpenWindow("stale")
+	snapshots.OpenWindow(id)
+	require.NoError(t, DropChunkRows[model.PgItems](snapshots,
+		&model.UpdateRecord[model.PgItems]{NewItems: row("2"), DestinationTableName: "dst"}, mapping))
+	require.NoError(t, DropChunkRows[model.PgItems](snapshots,
+		&model.InsertRecord[model.PgItems]{Items: row("3"), DestinationTableName: "other"}, mapping))
+
+	require.Empty(t, FlushChunk[model.PgItems](snapshots, "stale", model.BaseRecord{}))
+	records := FlushChunk[model.PgItems](snapshots, id, model.BaseRecord{})
+	require.Len(t, records, 2)
+	for i, expected := range []string{"1", "3"} {
+		insert, ok := records[i].(*model.InsertRecord[model.PgItems])
+		require.True(t, ok)
+		require.Equal(t, "dst", insert.DestinationTableName)
+		require.Equal(t, []byte(expected), insert.Items.GetColumnValue("id"))
+	}
+	require.Equal(t, []string{"3"}, snapshot.LastPK)
+	require.Equal(t, int64(2), snapshot.RowsSynced)
+	require.Equal(t, "", snapshots.ChunkID())
+
+	// reading past the last row completes the snapshot
+	require.Same(t, snapshot, snapshots.Next(mapping))
+	needsHighWatermark, err = AddChunk[model.PgItems](snapshots, snapshot, snapshots.NextChunkID(snapshot), nil,
+		nil, mapping, 3)
+	require.NoError(t, err)
+	require.False(t, needsHighWatermark)
+	require.Nil(t, snapshots.Next(mapping))
+	require.Len(t, snapshots.flushed, 2)
+	require.True(t, snapshots.flushed[1].done)
+}
+
+func TestTruncateBeforeChunk(t *testing.T) {
+	snapshot := &IncrementalSnapshot{SourceTable: "public.src", DestinationTable: "dst"}
+	require.Nil(t, TruncateBeforeChunk[model.PgItems](snapshot))
+
+	snapshot.TruncateDestination = true
+	truncate := TruncateBeforeChunk[model.PgItems](snapshot)
+	require.NotNil(t, truncate)
+	require.Equal(t, "public.src", truncate.SourceTableName)
+	require.Equal(t, "dst", truncate.DestinationTableName)
+
+	// only the first chunk is preceded by the truncate
+	snapshot.LastPK = []string{"1"}
+	require.Nil(t, TruncateBeforeChunk[model.PgItems](snapshot))
+}
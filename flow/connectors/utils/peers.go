@@ -93,6 +93,42 @@ func CreatePeerNoValidate(
 			return wrongConfigResponse, nil
 		}
 		innerConfig = mongoConfigObject.MongoConfig
+	case protos.DBType_SQLSERVER:
+		sqlServerConfigObject, ok := config.(*protos.Peer_SqlserverConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = sqlServerConfigObject.SqlserverConfig
+	case protos.DBType_ICEBERG:
+		icebergConfigObject, ok := config.(*protos.Peer_IcebergConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = icebergConfigObject.IcebergConfig
+	case protos.DBType_NATS:
+		natsConfigObject, ok := config.(*protos.Peer_NatsConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = natsConfigObject.NatsConfig
+	case protos.DBType_REDIS:
+		redisConfigObject, ok := config.(*protos.Peer_RedisConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = redisConfigObject.RedisConfig
+	case protos.DBType_WEBHOOK:
+		webhookConfigObject, ok := config.(*protos.Peer_WebhookConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = webhookConfigObject.WebhookConfig
+	case protos.DBType_DUCKDB:
+		duckdbConfigObject, ok := config.(*protos.Peer_DuckdbConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = duckdbConfigObject.DuckdbConfig
 	default:
 		return wrongConfigResponse, nil
 	}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// QRepTableSchema describes the destination table of a QRep partition for connectors that need a table schema,
+// primary key columns come from the parent mirror's table schema when there is one
+func QRepTableSchema(ctx context.Context, config *protos.QRepConfig, schema types.QRecordSchema) *protos.TableSchema {
+	tableSchema := &protos.TableSchema{TableIdentifier: config.WatermarkTable}
+	if config.ParentMirrorName != "" && config.ParentMirrorName != config.FlowJobName {
+		if pool, err := internal.GetCatalogConnectionPoolFromEnv(ctx); err == nil {
+			if parentSchema, err := internal.LoadTableSchemaFromCatalog(
+				ctx, pool, config.ParentMirrorName, config.DestinationTableIdentifier,
+			); err == nil {
+				tableSchema.PrimaryKeyColumns = parentSchema.PrimaryKeyColumns
+			} else {
+				internal.LoggerFromCtx(ctx).Warn("records will have no key, failed to load table schema",
+					"table", config.DestinationTableIdentifier, "error", err)
+			}
+		}
+	}
+	for _, field := range schema.Fields {
+		typmod := int32(-1)
+		if field.Type == types.QValueKindNumeric {
+			typmod = datatypes.MakeNumericTypmod(int32(field.Precision), int32(field.Scale))
+		}
+		tableSchema.Columns = append(tableSchema.Columns, &protos.FieldDescription{
+			Name:         field.Name,
+			Type:         string(field.Type),
+			TypeModifier: typmod,
+			Nullable:     field.Nullable,
+		})
+	}
+	return tableSchema
+}
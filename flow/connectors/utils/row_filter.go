@@ -0,0 +1,911 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/parser/opcode"
+	tidbtypes "github.com/pingcap/tidb/pkg/types"
+	_ "github.com/pingcap/tidb/pkg/types/parser_driver"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// RowFilter evaluates a TableMapping row filter outside of the source database,
+// for sources that cannot filter rows themselves, or renders it as SQL for sources that can.
+// Filters are boolean SQL predicates over columns of the source table in the subset of SQL
+// that Postgres and MySQL read the same way: identifiers may be double quoted so string literals need single quotes,
+// backslashes in literals are not escapes and operators specific to one of them like || or && are rejected.
+// Column names are case sensitive.
+// A qualified name like doc.tenant.id reads the key path tenant.id out of the JSON column doc.
+type RowFilter struct {
+	expr ast.ExprNode
+	// compiled LIKE patterns of expr
+	likes map[*ast.PatternLikeOrIlikeExpr]*regexp.Regexp
+	// root columns referenced by expr
+	columns []string
+}
+
+type RowFilterResult int8
+
+const (
+	// predicate is true
+	RowFilterMatch RowFilterResult = iota
+	// predicate is false or NULL
+	RowFilterNoMatch
+	// row lacks a referenced column, as with deletes that only carry the replica identity
+	RowFilterUnknown
+)
+
+func ParseRowFilter(predicate string) (*RowFilter, error) {
+	if err := checkRowFilterTokens(predicate); err != nil {
+		return nil, fmt.Errorf("unsupported row filter %q: %w", predicate, err)
+	}
+	p := parser.New()
+	// read || as concatenation, which is rejected, and backslashes as Postgres does
+	p.SetSQLMode(mysql.ModeANSIQuotes | mysql.ModePipesAsConcat | mysql.ModeNoBackslashEscapes)
+	stmt, err := p.ParseOneStmt("SELECT 1 FROM t WHERE "+predicate, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse row filter %q: %w", predicate, err)
+	}
+	selectStmt, ok := stmt.(*ast.SelectStmt)
+	if !ok || selectStmt.Where == nil || selectStmt.Limit != nil || selectStmt.OrderBy != nil || selectStmt.GroupBy != nil ||
+		selectStmt.Having != nil || selectStmt.WindowSpecs != nil || selectStmt.LockInfo != nil {
+		return nil, fmt.Errorf("row filter %q is not a predicate", predicate)
+	}
+	filter := &RowFilter{expr: selectStmt.Where, likes: make(map[*ast.PatternLikeOrIlikeExpr]*regexp.Regexp)}
+	if err := filter.check(selectStmt.Where, true); err != nil {
+		return nil, fmt.Errorf("unsupported row filter %q: %w", predicate, err)
+	}
+	return filter, nil
+}
+
+// AndRowFilters combines row filters, skipping empty ones. Each filter has to parse on its own,
+// so that it cannot close the parentheses around it and change how the others are read
+func AndRowFilters(filters ...string) (string, error) {
+	parts := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		if filter == "" {
+			continue
+		}
+		if _, err := ParseRowFilter(filter); err != nil {
+			return "", err
+		}
+		parts = append(parts, filter)
+	}
+	if len(parts) <= 1 {
+		return strings.Join(parts, ""), nil
+	}
+	for i, part := range parts {
+		// a trailing -- comment ends at the line break
+		parts[i] = "(" + part + "\n)"
+	}
+	return strings.Join(parts, " AND "), nil
+}
+
+// checkRowFilterTokens rejects operators that the parser reads differently from Postgres:
+// && is AND in MySQL but array overlap in Postgres, # starts a comment in MySQL but is XOR in Postgres
+func checkRowFilterTokens(predicate string) error {
+	for i := 0; i < len(predicate); i++ {
+		switch c := predicate[i]; c {
+		case '\'', '"', '`':
+			// quotes inside literals and identifiers are doubled
+			end := strings.IndexByte(predicate[i+1:], c)
+			if end == -1 {
+				return nil
+			}
+			i += end + 1
+		case '#':
+			return errors.New("operator #")
+		case '&':
+			if i+1 < len(predicate) && predicate[i+1] == '&' {
+				return errors.New("operator &&, use AND")
+			}
+		}
+	}
+	return nil
+}
+
+// check rejects expressions eval cannot handle or that are not booleans where a predicate is expected,
+// collecting referenced columns
+func (f *RowFilter) check(expr ast.ExprNode, predicate bool) error {
+	switch e := expr.(type) {
+	case ast.ValueExpr:
+		value, err := literalValue(e)
+		if err != nil {
+			return err
+		}
+		if _, isBool := value.(bool); predicate && !isBool {
+			return errors.New("not a boolean expression")
+		}
+		return nil
+	case *ast.ColumnNameExpr:
+		// boolean columns are predicates
+		if column, _ := columnPath(e.Name); !slices.Contains(f.columns, column) {
+			f.columns = append(f.columns, column)
+		}
+		return nil
+	case *ast.ParenthesesExpr:
+		return f.check(e.Expr, predicate)
+	case *ast.UnaryOperationExpr:
+		switch e.Op {
+		case opcode.Not:
+			return f.check(e.V, true)
+		case opcode.Minus, opcode.Plus:
+			if predicate {
+				return errors.New("not a boolean expression")
+			}
+			return f.check(e.V, false)
+		case opcode.Not2:
+			return errors.New("operator !, use NOT")
+		}
+		return fmt.Errorf("operator %s", e.Op)
+	case *ast.BinaryOperationExpr:
+		switch e.Op {
+		case opcode.LogicAnd, opcode.LogicOr:
+			if err := f.check(e.L, true); err != nil {
+				return err
+			}
+			return f.check(e.R, true)
+		case opcode.EQ, opcode.NE, opcode.LT, opcode.LE, opcode.GT, opcode.GE:
+			if err := f.check(e.L, false); err != nil {
+				return err
+			}
+			return f.check(e.R, false)
+		}
+		return fmt.Errorf("operator %s", e.Op)
+	case *ast.IsNullExpr:
+		return f.check(e.Expr, false)
+	case *ast.IsTruthExpr:
+		return f.check(e.Expr, true)
+	case *ast.BetweenExpr:
+		for _, operand := range []ast.ExprNode{e.Expr, e.Left, e.Right} {
+			if err := f.check(operand, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *ast.PatternInExpr:
+		if e.Sel != nil {
+			return errors.New("subqueries")
+		}
+		if err := f.check(e.Expr, false); err != nil {
+			return err
+		}
+		for _, item := range e.List {
+			if err := f.check(item, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *ast.PatternLikeOrIlikeExpr:
+		if err := f.check(e.Expr, false); err != nil {
+			return err
+		}
+		pattern, ok := e.Pattern.(ast.ValueExpr)
+		if !ok {
+			return errors.New("non literal LIKE patterns")
+		}
+		value, err := literalValue(pattern)
+		if err != nil {
+			return err
+		}
+		patternString, ok := value.(string)
+		if !ok || e.Escape == 0 {
+			return errors.New("LIKE patterns that are not strings with an escape character")
+		}
+		f.likes[e] = likeRegexp(patternString, e.Escape, !e.IsLike)
+		return nil
+	case *ast.FuncCallExpr:
+		if e.FnName.L == ast.Concat {
+			return errors.New("operator ||, which is OR in MySQL")
+		}
+		return fmt.Errorf("function %s", e.FnName.O)
+	default:
+		return fmt.Errorf("expression %T", expr)
+	}
+}
+
+// literalValue converts a literal to nil, bool, decimal or string, rejecting MySQL specific ones like bit values
+func literalValue(e ast.ValueExpr) (any, error) {
+	switch v := e.GetValue().(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		if mysql.HasIsBooleanFlag(e.GetType().GetFlag()) {
+			return v != 0, nil
+		}
+		return decimal.NewFromInt(v), nil
+	case uint64:
+		return decimal.NewFromUint64(v), nil
+	case float64:
+		return decimal.NewFromFloat(v), nil
+	case *tidbtypes.MyDecimal:
+		return decimal.NewFromString(v.String())
+	case string:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("literal %T", v)
+	}
+}
+
+// columnPath splits a column reference into the root column and a JSON key path
+func columnPath(name *ast.ColumnName) (string, []string) {
+	if name.Schema.O != "" {
+		return name.Schema.O, []string{name.Table.O, name.Name.O}
+	} else if name.Table.O != "" {
+		return name.Table.O, []string{name.Name.O}
+	}
+	return name.Name.O, nil
+}
+
+type rowFilterDialect struct {
+	quoteIdentifier func(string) string
+	quoteLiteral    func(string) string
+	// TRUE, FALSE and IS TRUE
+	booleans bool
+	ilike    bool
+	// LIKE follows the column collation unless the pattern is binary
+	binaryLike bool
+}
+
+var rowFilterDialects = map[protos.DBType]rowFilterDialect{
+	protos.DBType_POSTGRES: {
+		quoteIdentifier: QuoteIdentifier,
+		quoteLiteral: func(literal string) string {
+			return strings.TrimSpace(QuoteLiteral(literal))
+		},
+		booleans: true,
+		ilike:    true,
+	},
+	protos.DBType_MYSQL: {
+		quoteIdentifier: func(identifier string) string {
+			return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+		},
+		// source connections set NO_BACKSLASH_ESCAPES
+		quoteLiteral: func(literal string) string {
+			return "'" + strings.ReplaceAll(literal, "'", "''") + "'"
+		},
+		booleans:   true,
+		binaryLike: true,
+	},
+	protos.DBType_SQLSERVER: {
+		quoteIdentifier: func(identifier string) string {
+			return "[" + strings.ReplaceAll(identifier, "]", "]]") + "]"
+		},
+		quoteLiteral: func(literal string) string {
+			return "N'" + strings.ReplaceAll(literal, "'", "''") + "'"
+		},
+	},
+}
+
+// RowFilterSQL parses a row filter and renders it for sources of dbType
+func RowFilterSQL(predicate string, dbType protos.DBType) (string, error) {
+	filter, err := ParseRowFilter(predicate)
+	if err != nil {
+		return "", err
+	}
+	return filter.SQL(dbType)
+}
+
+// SQL renders the filter as a predicate for sources of dbType that filter rows in their queries,
+// every literal and identifier is quoted again rather than copied from the filter
+func (f *RowFilter) SQL(dbType protos.DBType) (string, error) {
+	dialect, ok := rowFilterDialects[dbType]
+	if !ok {
+		return "", fmt.Errorf("row filters cannot be rendered as SQL for %s", dbType)
+	}
+	var sql strings.Builder
+	if err := dialect.render(&sql, f.expr); err != nil {
+		return "", fmt.Errorf("row filter not supported by %s: %w", dbType, err)
+	}
+	return sql.String(), nil
+}
+
+func (d rowFilterDialect) render(sql *strings.Builder, expr ast.ExprNode) error {
+	switch e := expr.(type) {
+	case ast.ValueExpr:
+		value, err := literalValue(e)
+		if err != nil {
+			return err
+		}
+		switch v := value.(type) {
+		case nil:
+			sql.WriteString("NULL")
+		case bool:
+			if !d.booleans {
+				return errors.New("boolean literals")
+			} else if v {
+				sql.WriteString("TRUE")
+			} else {
+				sql.WriteString("FALSE")
+			}
+		case decimal.Decimal:
+			sql.WriteString(v.String())
+		case string:
+			sql.WriteString(d.quoteLiteral(v))
+		}
+	case *ast.ColumnNameExpr:
+		column, path := columnPath(e.Name)
+		if len(path) > 0 {
+			return fmt.Errorf("JSON path %s", e.Name)
+		}
+		sql.WriteString(d.quoteIdentifier(column))
+	case *ast.ParenthesesExpr:
+		return d.render(sql, e.Expr)
+	case *ast.UnaryOperationExpr:
+		switch e.Op {
+		case opcode.Not:
+			sql.WriteString("(NOT ")
+		case opcode.Minus:
+			sql.WriteString("(-")
+		default:
+			sql.WriteString("(")
+		}
+		if err := d.render(sql, e.V); err != nil {
+			return err
+		}
+		sql.WriteString(")")
+	case *ast.BinaryOperationExpr:
+		op := map[opcode.Op]string{
+			opcode.LogicAnd: " AND ", opcode.LogicOr: " OR ",
+			opcode.EQ: " = ", opcode.NE: " <> ", opcode.LT: " < ", opcode.LE: " <= ", opcode.GT: " > ", opcode.GE: " >= ",
+		}[e.Op]
+		sql.WriteString("(")
+		if err := d.render(sql, e.L); err != nil {
+			return err
+		}
+		sql.WriteString(op)
+		if err := d.render(sql, e.R); err != nil {
+			return err
+		}
+		sql.WriteString(")")
+	case *ast.IsNullExpr:
+		sql.WriteString("(")
+		if err := d.render(sql, e.Expr); err != nil {
+			return err
+		}
+		sql.WriteString(notIf(" IS ", e.Not) + "NULL)")
+	case *ast.IsTruthExpr:
+		if !d.booleans {
+			return errors.New("IS TRUE and IS FALSE")
+		}
+		sql.WriteString("(")
+		if err := d.render(sql, e.Expr); err != nil {
+			return err
+		}
+		sql.WriteString(notIf(" IS ", e.Not))
+		if e.True != 0 {
+			sql.WriteString("TRUE)")
+		} else {
+			sql.WriteString("FALSE)")
+		}
+	case *ast.BetweenExpr:
+		sql.WriteString("(")
+		if err := d.render(sql, e.Expr); err != nil {
+			return err
+		}
+		sql.WriteString(notIf(" ", e.Not) + "BETWEEN ")
+		if err := d.render(sql, e.Left); err != nil {
+			return err
+		}
+		sql.WriteString(" AND ")
+		if err := d.render(sql, e.Right); err != nil {
+			return err
+		}
+		sql.WriteString(")")
+	case *ast.PatternInExpr:
+		sql.WriteString("(")
+		if err := d.render(sql, e.Expr); err != nil {
+			return err
+		}
+		sql.WriteString(notIf(" ", e.Not) + "IN (")
+		for i, item := range e.List {
+			if i > 0 {
+				sql.WriteString(", ")
+			}
+			if err := d.render(sql, item); err != nil {
+				return err
+			}
+		}
+		sql.WriteString("))")
+	case *ast.PatternLikeOrIlikeExpr:
+		value, _ := literalValue(e.Pattern.(ast.ValueExpr))
+		pattern := d.quoteLiteral(value.(string))
+		sql.WriteString("(")
+		switch {
+		case e.IsLike && d.binaryLike:
+			pattern = "CAST(" + pattern + " AS BINARY)"
+			fallthrough
+		case e.IsLike:
+			if err := d.render(sql, e.Expr); err != nil {
+				return err
+			}
+			sql.WriteString(notIf(" ", e.Not) + "LIKE ")
+		case d.ilike:
+			if err := d.render(sql, e.Expr); err != nil {
+				return err
+			}
+			sql.WriteString(notIf(" ", e.Not) + "ILIKE ")
+		default:
+			pattern = "LOWER(" + pattern + ")"
+			sql.WriteString("LOWER(")
+			if err := d.render(sql, e.Expr); err != nil {
+				return err
+			}
+			sql.WriteString(")" + notIf(" ", e.Not) + "LIKE ")
+		}
+		sql.WriteString(pattern + " ESCAPE " + d.quoteLiteral(string(e.Escape)) + ")")
+	default:
+		return fmt.Errorf("expression %T", expr)
+	}
+	return nil
+}
+
+func notIf(prefix string, not bool) string {
+	if not {
+		return prefix + "NOT "
+	}
+	return prefix
+}
+
+// Match evaluates the filter against a row, getValue returning false for columns the row does not have
+func (f *RowFilter) Match(getValue func(column string) (any, bool)) RowFilterResult {
+	for _, column := range f.columns {
+		if _, ok := getValue(column); !ok {
+			return RowFilterUnknown
+		}
+	}
+	if truth(f.eval(f.expr, getValue)) == sqlTrue {
+		return RowFilterMatch
+	}
+	return RowFilterNoMatch
+}
+
+// MatchRecordItems evaluates the filter against items of a CDC record
+func (f *RowFilter) MatchRecordItems(items model.Items) RowFilterResult {
+	switch items := items.(type) {
+	case model.RecordItems:
+		return f.Match(func(column string) (any, bool) {
+			qv, ok := items.ColToVal[column]
+			if !ok {
+				return nil, false
+			} else if qv == nil {
+				return nil, true
+			}
+			return qv.Value(), true
+		})
+	case model.PgItems:
+		return f.Match(func(column string) (any, bool) {
+			value, ok := items.ColToVal[column]
+			if !ok {
+				return nil, false
+			} else if value == nil {
+				return nil, true
+			}
+			// text format, compared as numbers or times when the other operand is one
+			return string(value), true
+		})
+	default:
+		return RowFilterUnknown
+	}
+}
+
+// MatchQRecord evaluates the filter against a snapshot row
+func (f *RowFilter) MatchQRecord(schema types.QRecordSchema, record []types.QValue) RowFilterResult {
+	return f.Match(func(column string) (any, bool) {
+		for i, field := range schema.Fields {
+			if field.Name == column {
+				if record[i] == nil {
+					return nil, true
+				}
+				return record[i].Value(), true
+			}
+		}
+		return nil, false
+	})
+}
+
+type sqlBool int8
+
+const (
+	sqlNull sqlBool = iota
+	sqlFalse
+	sqlTrue
+)
+
+func boolOf(b bool) sqlBool {
+	if b {
+		return sqlTrue
+	}
+	return sqlFalse
+}
+
+// truth converts a value to a boolean the way WHERE does, non zero numbers being true
+func truth(value any) sqlBool {
+	switch v := value.(type) {
+	case nil:
+		return sqlNull
+	case bool:
+		return boolOf(v)
+	}
+	if d, ok := toDecimal(value); ok {
+		return boolOf(!d.IsZero())
+	}
+	if s, ok := value.(string); ok {
+		if b, err := strconv.ParseBool(s); err == nil {
+			return boolOf(b)
+		} else if s == "t" || s == "f" {
+			return boolOf(s == "t")
+		}
+	}
+	return sqlNull
+}
+
+// eval returns nil for NULL and bool for predicates
+func (f *RowFilter) eval(expr ast.ExprNode, getValue func(string) (any, bool)) any {
+	switch e := expr.(type) {
+	case ast.ValueExpr:
+		value, _ := literalValue(e)
+		return value
+	case *ast.ColumnNameExpr:
+		column, path := columnPath(e.Name)
+		value, _ := getValue(column)
+		if len(path) > 0 {
+			return jsonPathValue(value, path)
+		}
+		return value
+	case *ast.ParenthesesExpr:
+		return f.eval(e.Expr, getValue)
+	case *ast.UnaryOperationExpr:
+		value := f.eval(e.V, getValue)
+		switch e.Op {
+		case opcode.Not:
+			if b := truth(value); b != sqlNull {
+				return b == sqlFalse
+			}
+			return nil
+		case opcode.Minus:
+			if d, ok := toDecimal(value); ok {
+				return d.Neg()
+			}
+			return nil
+		}
+		return value
+	case *ast.BinaryOperationExpr:
+		switch e.Op {
+		case opcode.LogicAnd:
+			l, r := truth(f.eval(e.L, getValue)), truth(f.eval(e.R, getValue))
+			if l == sqlFalse || r == sqlFalse {
+				return false
+			} else if l == sqlNull || r == sqlNull {
+				return nil
+			}
+			return true
+		case opcode.LogicOr:
+			l, r := truth(f.eval(e.L, getValue)), truth(f.eval(e.R, getValue))
+			if l == sqlTrue || r == sqlTrue {
+				return true
+			} else if l == sqlNull || r == sqlNull {
+				return nil
+			}
+			return false
+		}
+		c, ok := compare(f.eval(e.L, getValue), f.eval(e.R, getValue))
+		if !ok {
+			return nil
+		}
+		switch e.Op {
+		case opcode.EQ:
+			return c == 0
+		case opcode.NE:
+			return c != 0
+		case opcode.LT:
+			return c < 0
+		case opcode.LE:
+			return c <= 0
+		case opcode.GT:
+			return c > 0
+		case opcode.GE:
+			return c >= 0
+		}
+		return nil
+	case *ast.IsNullExpr:
+		return (f.eval(e.Expr, getValue) == nil) != e.Not
+	case *ast.IsTruthExpr:
+		want := sqlFalse
+		if e.True != 0 {
+			want = sqlTrue
+		}
+		return (truth(f.eval(e.Expr, getValue)) == want) != e.Not
+	case *ast.BetweenExpr:
+		value := f.eval(e.Expr, getValue)
+		lower, lok := compare(value, f.eval(e.Left, getValue))
+		upper, uok := compare(value, f.eval(e.Right, getValue))
+		if !lok || !uok {
+			return nil
+		}
+		return (lower >= 0 && upper <= 0) != e.Not
+	case *ast.PatternInExpr:
+		value := f.eval(e.Expr, getValue)
+		if value == nil {
+			return nil
+		}
+		result := sqlFalse
+		for _, item := range e.List {
+			if c, ok := compare(value, f.eval(item, getValue)); !ok {
+				result = sqlNull
+			} else if c == 0 {
+				result = sqlTrue
+				break
+			}
+		}
+		if result == sqlNull {
+			return nil
+		}
+		return (result == sqlTrue) != e.Not
+	case *ast.PatternLikeOrIlikeExpr:
+		value := f.eval(e.Expr, getValue)
+		if value == nil {
+			return nil
+		}
+		return f.likes[e].MatchString(toString(value)) != e.Not
+	}
+	return nil
+}
+
+// likeRegexp translates a LIKE pattern, ILIKE matching case insensitively
+func likeRegexp(pattern string, escape byte, caseInsensitive bool) *regexp.Regexp {
+	var re strings.Builder
+	if caseInsensitive {
+		re.WriteString("(?is)^")
+	} else {
+		re.WriteString("(?s)^")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == escape && i+1 < len(pattern):
+			i++
+			re.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case c == '%':
+			re.WriteString(".*")
+		case c == '_':
+			re.WriteByte('.')
+		default:
+			re.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	re.WriteByte('$')
+	return regexp.MustCompile(re.String())
+}
+
+// jsonPathValue reads a key path out of a JSON document, objects and arrays are returned as JSON text
+func jsonPathValue(document any, path []string) any {
+	var text string
+	switch d := document.(type) {
+	case string:
+		text = d
+	case []byte:
+		text = string(d)
+	default:
+		return nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil
+	}
+	for _, key := range path {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	switch v := value.(type) {
+	case json.Number:
+		if d, err := decimal.NewFromString(v.String()); err == nil {
+			return d
+		}
+		return v.String()
+	case map[string]any, []any:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		return string(encoded)
+	}
+	return value
+}
+
+func toDecimal(value any) (decimal.Decimal, bool) {
+	switch v := value.(type) {
+	case decimal.Decimal:
+		return v, true
+	case int:
+		return decimal.NewFromInt(int64(v)), true
+	case int8:
+		return decimal.NewFromInt(int64(v)), true
+	case int16:
+		return decimal.NewFromInt(int64(v)), true
+	case int32:
+		return decimal.NewFromInt(int64(v)), true
+	case int64:
+		return decimal.NewFromInt(v), true
+	case uint8:
+		return decimal.NewFromUint64(uint64(v)), true
+	case uint16:
+		return decimal.NewFromUint64(uint64(v)), true
+	case uint32:
+		return decimal.NewFromUint64(uint64(v)), true
+	case uint64:
+		return decimal.NewFromUint64(v), true
+	case float32:
+		return decimal.NewFromFloat32(v), true
+	case float64:
+		return decimal.NewFromFloat(v), true
+	case bool:
+		if v {
+			return decimal.NewFromInt(1), true
+		}
+		return decimal.Zero, true
+	}
+	return decimal.Decimal{}, false
+}
+
+var rowFilterTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+func toTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range rowFilterTimeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func toString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case decimal.Decimal:
+		return v.String()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// compare orders two non NULL values, converting the other operand to numbers or times when one side is one
+func compare(l any, r any) (int, bool) {
+	if l == nil || r == nil {
+		return 0, false
+	}
+	if ld, ok := toDecimal(l); ok {
+		if rd, ok := toDecimal(r); ok {
+			return ld.Cmp(rd), true
+		} else if rd, err := decimal.NewFromString(toString(r)); err == nil {
+			return ld.Cmp(rd), true
+		} else if b := truth(r); b != sqlNull {
+			// booleans in text format
+			rd, _ := toDecimal(b == sqlTrue)
+			return ld.Cmp(rd), true
+		}
+		return 0, false
+	} else if _, ok := toDecimal(r); ok {
+		c, ok := compare(r, l)
+		return -c, ok
+	}
+	if lt, ok := l.(time.Time); ok {
+		if rt, ok := toTime(r); ok {
+			return lt.Compare(rt), true
+		}
+		return 0, false
+	} else if _, ok := r.(time.Time); ok {
+		c, ok := compare(r, l)
+		return -c, ok
+	}
+	return strings.Compare(toString(l), toString(r)), true
+}
+
+// filterRecord applies filter like a publication row filter: an update moving a row out of the filter
+// becomes a delete, one moving a row into it becomes an insert, nil means the record is dropped
+func filterRecord[Items model.Items](filter *RowFilter, record model.Record[Items]) model.Record[Items] {
+	switch r := record.(type) {
+	case *model.InsertRecord[Items]:
+		if filter.MatchRecordItems(r.Items) == RowFilterNoMatch {
+			return nil
+		}
+	case *model.UpdateRecord[Items]:
+		newMatch := filter.MatchRecordItems(r.NewItems)
+		oldMatch := filter.MatchRecordItems(r.OldItems)
+		if newMatch == RowFilterNoMatch {
+			if oldMatch == RowFilterNoMatch {
+				return nil
+			}
+			return &model.DeleteRecord[Items]{
+				Items:                 r.NewItems,
+				UnchangedToastColumns: r.UnchangedToastColumns,
+				SourceTableName:       r.SourceTableName,
+				DestinationTableName:  r.DestinationTableName,
+				BaseRecord:            r.BaseRecord,
+			}
+		} else if newMatch == RowFilterMatch && oldMatch == RowFilterNoMatch && len(r.UnchangedToastColumns) == 0 {
+			return &model.InsertRecord[Items]{
+				Items:                r.NewItems,
+				SourceTableName:      r.SourceTableName,
+				DestinationTableName: r.DestinationTableName,
+				BaseRecord:           r.BaseRecord,
+			}
+		}
+	case *model.DeleteRecord[Items]:
+		if filter.MatchRecordItems(r.Items) == RowFilterNoMatch {
+			return nil
+		}
+	}
+	return record
+}
+
+// FilterCDCStream applies row filters, keyed by source table, to records of stream
+func FilterCDCStream[Items model.Items](
+	ctx context.Context,
+	stream *model.CDCStream[Items],
+	filters map[string]*RowFilter,
+) *model.CDCStream[Items] {
+	outstream := model.NewCDCStream[Items](0)
+
+	go func() {
+		if stream.WaitAndCheckEmpty() {
+			outstream.SignalAsEmpty()
+			<-stream.GetRecords() // needed because empty signal comes before Close
+		} else {
+			outstream.SignalAsNotEmpty()
+			for record := range stream.GetRecords() {
+				if filter, ok := filters[record.GetSourceTableName()]; ok {
+					if record = filterRecord(filter, record); record == nil {
+						continue
+					}
+				}
+				if err := outstream.AddRecord(ctx, record); err != nil {
+					for range stream.GetRecords() {
+						// still read records to make sure input closes first
+					}
+					break
+				}
+			}
+		}
+		outstream.SchemaDeltas = stream.SchemaDeltas
+		lastCP := stream.GetLastCheckpoint()
+		outstream.UpdateLatestCheckpointID(lastCP.ID)
+		outstream.UpdateLatestCheckpointText(lastCP.Text)
+		outstream.Close()
+	}()
+	return outstream
+}
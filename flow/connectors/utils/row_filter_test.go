@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestRowFilterMatch(t *testing.T) {
+	row := map[string]any{
+		"id":      int64(7),
+		"region":  "eu-west",
+		"amount":  decimal.RequireFromString("12.50"),
+		"deleted": nil,
+		"doc":     `{"tenant": {"id": 3, "name": "acme"}}`,
+	}
+	getValue := func(column string) (any, bool) {
+		value, ok := row[column]
+		return value, ok
+	}
+
+	for predicate, expected := range map[string]RowFilterResult{
+		"id = 7":                                 RowFilterMatch,
+		"id > 7":                                 RowFilterNoMatch,
+		"region = 'eu-west' AND amount >= 10":    RowFilterMatch,
+		`"region" LIKE 'eu-%'`:                   RowFilterMatch,
+		"region ILIKE 'EU\\_%'":                  RowFilterNoMatch,
+		"region IN ('us-east', 'eu-west')":       RowFilterMatch,
+		"amount BETWEEN 1 AND 10":                RowFilterNoMatch,
+		"deleted = 1":                            RowFilterNoMatch,
+		"NOT (deleted = 1)":                      RowFilterNoMatch,
+		"deleted IS NULL OR deleted = 1":         RowFilterMatch,
+		"doc.tenant.id = 3":                      RowFilterMatch,
+		"doc.tenant.name <> 'acme'":              RowFilterNoMatch,
+		"missing = 1":                            RowFilterUnknown,
+		"id = 7 OR region = 'x' AND missing = 1": RowFilterUnknown,
+	} {
+		filter, err := ParseRowFilter(predicate)
+		require.NoError(t, err, predicate)
+		require.Equal(t, expected, filter.Match(getValue), predicate)
+	}
+
+	for _, predicate := range []string{
+		"id IN (SELECT 1)", "upper(region) = 'EU'", "id = 1; DROP TABLE t",
+		// not a single boolean expression
+		"'eu'", "-id", "id = 1 OR 2",
+		// read differently by Postgres and MySQL
+		"region || 'x' = 'eux'", "id = 7 && region = 'eu'", "!(id = 7)", "id = 7 XOR id = 8", "id <=> 7",
+		"id = 7 # AND region = 'eu'", "region = x'6575'",
+	} {
+		_, err := ParseRowFilter(predicate)
+		require.Error(t, err, predicate)
+	}
+}
+
+func TestRowFilterSQL(t *testing.T) {
+	for predicate, expected := range map[string][3]string{
+		`"Region" = 'it''s' AND NOT (id > -1.5)`: {
+			`(("Region" = 'it''s') AND (NOT ("id" > (-1.5))))`,
+			"((`Region` = 'it''s') AND (NOT (`id` > (-1.5))))",
+			`(([Region] = N'it''s') AND (NOT ([id] > (-1.5))))`,
+		},
+		`region NOT ILIKE 'EU\_%' OR region LIKE 'a\b'`: {
+			`(("region" NOT ILIKE E'EU\\_%' ESCAPE E'\\') OR ("region" LIKE E'a\\b' ESCAPE E'\\'))`,
+			"((LOWER(`region`) NOT LIKE LOWER('EU\\_%') ESCAPE '\\') OR (`region` LIKE CAST('a\\b' AS BINARY) ESCAPE '\\'))",
+			`((LOWER([region]) NOT LIKE LOWER(N'EU\_%') ESCAPE N'\') OR ([region] LIKE N'a\b' ESCAPE N'\'))`,
+		},
+		"id NOT BETWEEN 1 AND 10 AND id IN (1, 2) AND deleted IS NOT NULL": {
+			`((("id" NOT BETWEEN 1 AND 10) AND ("id" IN (1, 2))) AND ("deleted" IS NOT NULL))`,
+			"(((`id` NOT BETWEEN 1 AND 10) AND (`id` IN (1, 2))) AND (`deleted` IS NOT NULL))",
+			`((([id] NOT BETWEEN 1 AND 10) AND ([id] IN (1, 2))) AND ([deleted] IS NOT NULL))`,
+		},
+	} {
+		for i, dbType := range []protos.DBType{protos.DBType_POSTGRES, protos.DBType_MYSQL, protos.DBType_SQLSERVER} {
+			sql, err := RowFilterSQL(predicate, dbType)
+			require.NoError(t, err, predicate)
+			require.Equal(t, expected[i], sql, predicate)
+		}
+	}
+
+	_, err := RowFilterSQL("doc.tenant.id = 3", protos.DBType_POSTGRES)
+	require.Error(t, err)
+	_, err = RowFilterSQL("active IS TRUE", protos.DBType_SQLSERVER)
+	require.Error(t, err)
+	_, err = RowFilterSQL("id = 1", protos.DBType_MONGO)
+	require.Error(t, err)
+}
+
+func TestAndRowFilters(t *testing.T) {
+	filter, err := AndRowFilters("", "region = 'eu'")
+	require.NoError(t, err)
+	require.Equal(t, "region = 'eu'", filter)
+
+	filter, err = AndRowFilters("region = 'eu' OR id = 1 -- note", "id BETWEEN 5 AND 10")
+	require.NoError(t, err)
+	sql, err := RowFilterSQL(filter, protos.DBType_POSTGRES)
+	require.NoError(t, err)
+	require.Equal(t, `((("region" = 'eu') OR ("id" = 1)) AND ("id" BETWEEN 5 AND 10))`, sql)
+
+	// a filter closing the parentheses around it would escape the row filter of the table
+	_, err = AndRowFilters("region = 'eu'", "1=1) OR (1=1")
+	require.Error(t, err)
+}
+
+func TestFilterRecord(t *testing.T) {
+	filter, err := ParseRowFilter("region = 'eu'")
+	require.NoError(t, err)
+	items := func(region string) model.PgItems {
+		items := model.NewPgItems(2)
+		items.AddColumn("id", []byte("1"))
+		items.AddColumn("region", []byte(region))
+		return items
+	}
+	keyOnly := model.NewPgItems(1)
+	keyOnly.AddColumn("id", []byte("1"))
+
+	require.Nil(t, filterRecord[model.PgItems](filter, &model.InsertRecord[model.PgItems]{Items: items("us")}))
+	require.IsType(t, &model.InsertRecord[model.PgItems]{},
+		filterRecord[model.PgItems](filter, &model.InsertRecord[model.PgItems]{Items: items("eu")}))
+
+	require.IsType(t, &model.DeleteRecord[model.PgItems]{}, filterRecord[model.PgItems](filter,
+		&model.UpdateRecord[model.PgItems]{OldItems: items("eu"), NewItems: items("us")}))
+	require.IsType(t, &model.InsertRecord[model.PgItems]{}, filterRecord[model.PgItems](filter,
+		&model.UpdateRecord[model.PgItems]{OldItems: items("us"), NewItems: items("eu")}))
+	require.Nil(t, filterRecord[model.PgItems](filter,
+		&model.UpdateRecord[model.PgItems]{OldItems: items("us"), NewItems: items("us")}))
+	// old row unknown without replica identity full
+	require.IsType(t, &model.UpdateRecord[model.PgItems]{}, filterRecord[model.PgItems](filter,
+		&model.UpdateRecord[model.PgItems]{OldItems: keyOnly, NewItems: items("eu")}))
+
+	require.IsType(t, &model.DeleteRecord[model.PgItems]{},
+		filterRecord[model.PgItems](filter, &model.DeleteRecord[model.PgItems]{Items: keyOnly}))
+	require.Nil(t, filterRecord[model.PgItems](filter, &model.DeleteRecord[model.PgItems]{Items: items("us")}))
+}
+
+func TestRowFilterMatchQRecord(t *testing.T) {
+	filter, err := ParseRowFilter("amount > 10")
+	require.NoError(t, err)
+	schema := types.QRecordSchema{Fields: []types.QField{{Name: "amount", Type: types.QValueKindInt64}}}
+	require.Equal(t, RowFilterMatch, filter.MatchQRecord(schema, []types.QValue{types.QValueInt64{Val: 11}}))
+	require.Equal(t, RowFilterNoMatch, filter.MatchQRecord(schema, []types.QValue{types.QValueInt64{Val: 10}}))
+}
@@ -131,7 +131,13 @@ func recordToQRecordOrError[Items model.Items](
 		entries[5] = types.QValueString{Val: itemsJSON}
 		entries[7] = types.QValueString{Val: KeysToString(typedRecord.UnchangedToastColumns)}
 
-	case *model.MessageRecord[Items]:
+	case *model.TruncateRecord[Items]:
+		entries[3] = types.QValueString{Val: "{}"}
+		entries[4] = types.QValueInt64{Val: 3}
+		entries[5] = types.QValueString{Val: ""}
+		entries[7] = types.QValueString{Val: ""}
+
+	case *model.MessageRecord[Items], *model.RelationRecord[Items]:
 		return nil, nil
 
 	default:
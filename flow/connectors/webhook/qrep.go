@@ -0,0 +1,104 @@
+package connwebhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func (*WebhookConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+func (c *WebhookConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+	numRecords := atomic.Int64{}
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	queueCtx, queueErr := context.WithCancelCause(ctx)
+	b := &batcher{c: c, payload: payload{FlowJobName: config.FlowJobName, PartitionID: partition.PartitionId}}
+	pool, err := c.createPool(queueCtx, config.Env, config.Script, config.FlowJobName, func(records []json.RawMessage) {
+		if queueCtx.Err() != nil {
+			return
+		}
+		if err := b.add(queueCtx, records); err != nil {
+			queueErr(err)
+		}
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	defer pool.Close()
+
+	shutdown := shared.Interval(ctx, time.Minute, func() {
+		c.logger.Info(fmt.Sprintf("sent %d records", numRecords.Load()))
+	})
+	defer shutdown()
+
+Loop:
+	for {
+		select {
+		case qrecord, ok := <-stream.Records:
+			if !ok {
+				c.logger.Info("flushing batches because no more records")
+				break Loop
+			}
+
+			pool.Run(func(ls *lua.LState) []json.RawMessage {
+				items := model.NewRecordItems(len(qrecord))
+				for i, val := range qrecord {
+					items.AddColumn(schema.Fields[i].Name, val)
+				}
+				record := &model.InsertRecord[model.RecordItems]{
+					BaseRecord:           model.BaseRecord{},
+					Items:                items,
+					SourceTableName:      config.WatermarkTable,
+					DestinationTableName: config.DestinationTableIdentifier,
+					CommitID:             0,
+				}
+
+				results, err := runScript(ls, record)
+				if err != nil {
+					queueErr(err)
+					return nil
+				}
+				numRecords.Add(1)
+				return results
+			})
+
+		case <-queueCtx.Done():
+			break Loop
+		}
+	}
+
+	if err := pool.Wait(queueCtx); err != nil {
+		return 0, nil, err
+	}
+	if err := context.Cause(queueCtx); err != nil {
+		return 0, nil, fmt.Errorf("[webhook] queueCtx.Done: %w", err)
+	}
+	if err := b.flush(ctx); err != nil {
+		return 0, nil, err
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, nil, err
+	}
+	return numRecords.Load(), nil, nil
+}
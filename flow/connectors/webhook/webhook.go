@@ -0,0 +1,414 @@
+package connwebhook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/PeerDB-io/gluajson"
+	lua "github.com/yuin/gopher-lua"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/pua"
+)
+
+const (
+	// same headers as webhook alerts, so receivers can share verification
+	signatureHeader = "X-PeerDB-Signature-256"
+	timestampHeader = "X-PeerDB-Timestamp"
+
+	defaultMaxBatchSize = 1000
+	defaultMaxRetries   = 5
+	defaultTimeout      = 30 * time.Second
+	initialBackoff      = time.Second
+	maxBackoff          = 30 * time.Second
+)
+
+type WebhookConnector struct {
+	*metadataStore.PostgresMetadata
+	client *http.Client
+	config *protos.WebhookConfig
+	logger log.Logger
+}
+
+func NewWebhookConnector(
+	ctx context.Context,
+	config *protos.WebhookConfig,
+) (*WebhookConnector, error) {
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := defaultTimeout
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	return &WebhookConnector{
+		PostgresMetadata: pgMetadata,
+		client:           &http.Client{Timeout: timeout},
+		config:           config,
+		logger:           internal.LoggerFromCtx(ctx),
+	}, nil
+}
+
+func (c *WebhookConnector) Close() error {
+	if c != nil {
+		c.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// ConnectionActive does not call the webhook, receivers would have to tell checks apart from changes
+func (c *WebhookConnector) ConnectionActive(_ context.Context) error {
+	return nil
+}
+
+func (c *WebhookConnector) ValidateCheck(_ context.Context) error {
+	u, err := url.Parse(c.config.Url)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook url must be http or https, not %s", u.Scheme)
+	}
+	if c.config.BearerToken != nil && c.config.Username != nil {
+		return errors.New("webhook can authenticate with either a bearer token or basic auth, not both")
+	}
+	return nil
+}
+
+func (c *WebhookConnector) CreateRawTable(_ context.Context, _ *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+func (c *WebhookConnector) ReplayTableSchemaDeltas(_ context.Context, _ map[string]string,
+	flowJobName string, _ []*protos.TableMapping, schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	return nil
+}
+
+func (c *WebhookConnector) maxBatchSize() int {
+	if c.config.MaxBatchSize > 0 {
+		return int(c.config.MaxBatchSize)
+	}
+	return defaultMaxBatchSize
+}
+
+// payload is the body of a request, records holding what onRecord returned for each change.
+// a retried batch or partition is sent in the same chunks, so receivers can dedupe requests
+// by batch or partition id and chunk index
+type payload struct {
+	FlowJobName string            `json:"flow_job_name"`
+	PartitionID string            `json:"partition_id,omitempty"`
+	Records     []json.RawMessage `json:"records"`
+	BatchID     int64             `json:"batch_id,omitempty"`
+	ChunkIndex  int               `json:"chunk_index"`
+}
+
+// lvalueToJSON converts a value returned by onRecord, strings must already be JSON, tables are encoded
+func lvalueToJSON(ls *lua.LState, value lua.LValue) (json.RawMessage, error) {
+	switch v := value.(type) {
+	case lua.LString:
+		if !json.Valid([]byte(v)) {
+			return nil, fmt.Errorf("script returned invalid JSON: %s", v)
+		}
+		return json.RawMessage(v), nil
+	case *lua.LTable:
+		ls.Push(ls.NewFunction(gluajson.LuaJsonEncode))
+		ls.Push(v)
+		if err := ls.PCall(1, 1, nil); err != nil {
+			return nil, fmt.Errorf("failed to encode table: %w", err)
+		}
+		encoded := ls.Get(-1)
+		ls.Pop(1)
+		return json.RawMessage(lua.LVAsString(encoded)), nil
+	case *lua.LNilType:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("script returned invalid value: %s", value)
+	}
+}
+
+// runScript calls onRecord with record, returning the JSON of each value it returned
+func runScript(ls *lua.LState, record model.Record[model.RecordItems]) ([]json.RawMessage, error) {
+	lfn := ls.Env.RawGetString("onRecord")
+	fn, ok := lfn.(*lua.LFunction)
+	if !ok {
+		return nil, fmt.Errorf("script should define `onRecord` as function, not %s", lfn)
+	}
+
+	ls.Push(fn)
+	ls.Push(pua.LuaRecord.New(ls, record))
+	if err := ls.PCall(1, -1, nil); err != nil {
+		return nil, fmt.Errorf("script failed: %w", err)
+	}
+
+	args := ls.GetTop()
+	values := make([]lua.LValue, 0, args)
+	for i := range args {
+		values = append(values, ls.Get(i-args))
+	}
+	ls.SetTop(0)
+
+	results := make([]json.RawMessage, 0, len(values))
+	for _, value := range values {
+		result, err := lvalueToJSON(ls, value)
+		if err != nil {
+			return nil, err
+		}
+		if result != nil {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+func (c *WebhookConnector) createPool(
+	ctx context.Context,
+	env map[string]string,
+	script string,
+	flowJobName string,
+	merge func([]json.RawMessage),
+) (*utils.LPool[[]json.RawMessage], error) {
+	maxSize, err := internal.PeerDBQueueParallelism(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parallelism: %w", err)
+	}
+
+	return utils.LuaPool(int(maxSize), func() (*lua.LState, error) {
+		ls, err := utils.LoadScript(ctx, script, utils.LuaPrintFn(func(s string) {
+			_ = c.LogFlowInfo(ctx, flowJobName, s)
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("[webhook] error loading script: %w", err)
+		}
+		if script == "" {
+			ls.Env.RawSetString("onRecord", ls.NewFunction(utils.DefaultOnRecord))
+		}
+		return ls, nil
+	}, merge)
+}
+
+// batcher collects records, sending them once max batch size is reached
+type batcher struct {
+	c       *WebhookConnector
+	payload payload
+}
+
+func (b *batcher) add(ctx context.Context, records []json.RawMessage) error {
+	b.payload.Records = append(b.payload.Records, records...)
+	if len(b.payload.Records) >= b.c.maxBatchSize() {
+		return b.flush(ctx)
+	}
+	return nil
+}
+
+func (b *batcher) flush(ctx context.Context) error {
+	if len(b.payload.Records) == 0 {
+		return nil
+	}
+	if err := b.c.post(ctx, &b.payload); err != nil {
+		return err
+	}
+	b.payload.Records = b.payload.Records[:0]
+	b.payload.ChunkIndex += 1
+	return nil
+}
+
+type statusError struct {
+	body       []byte
+	statusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("webhook returned status %d: %s", e.statusCode, e.body)
+}
+
+// retryable is true for throttling and server errors, other statuses will not change by retrying
+func (e *statusError) retryable() bool {
+	return e.statusCode == http.StatusTooManyRequests || e.statusCode >= 500
+}
+
+// post sends payload, retrying with exponential backoff until the webhook responds with 2xx
+func (c *WebhookConnector) post(ctx context.Context, p *payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("[webhook] failed to encode payload: %w", err)
+	}
+	if c.config.Gzip {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(body); err != nil {
+			return fmt.Errorf("[webhook] failed to compress payload: %w", err)
+		}
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("[webhook] failed to compress payload: %w", err)
+		}
+		body = buf.Bytes()
+	}
+
+	maxRetries := defaultMaxRetries
+	if c.config.MaxRetries > 0 {
+		maxRetries = int(c.config.MaxRetries)
+	}
+	backoff := initialBackoff
+	for attempt := 0; ; attempt += 1 {
+		err := c.send(ctx, body)
+		if err == nil {
+			return nil
+		}
+		var statusErr *statusError
+		if attempt >= maxRetries || ctx.Err() != nil || (errors.As(err, &statusErr) && !statusErr.retryable()) {
+			return fmt.Errorf("[webhook] failed to send %d records: %w", len(p.Records), err)
+		}
+		c.logger.Warn("[webhook] request failed, retrying",
+			slog.Any("error", err), slog.Int("attempt", attempt+1), slog.Duration("backoff", backoff))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// sign computes the hex encoded HMAC-SHA256 of "<timestamp>.<body>" like webhook alerts,
+// body being the bytes sent, compressed when gzip is enabled
+func sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *WebhookConnector) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.Url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for key, value := range c.config.Headers {
+		req.Header.Set(key, value)
+	}
+	if c.config.BearerToken != nil {
+		req.Header.Set("Authorization", "Bearer "+c.config.GetBearerToken())
+	} else if c.config.Username != nil {
+		req.SetBasicAuth(c.config.GetUsername(), c.config.GetPassword())
+	}
+	if c.config.HmacSecret != nil {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(timestampHeader, timestamp)
+		req.Header.Set(signatureHeader, sign(c.config.GetHmacSecret(), timestamp, body))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &statusError{statusCode: resp.StatusCode, body: respBody}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (c *WebhookConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	numRecords := atomic.Int64{}
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	queueCtx, queueErr := context.WithCancelCause(ctx)
+	b := &batcher{c: c, payload: payload{FlowJobName: req.FlowJobName, BatchID: req.SyncBatchID}}
+
+	pool, err := c.createPool(queueCtx, req.Env, req.Script, req.FlowJobName, func(records []json.RawMessage) {
+		if queueCtx.Err() != nil {
+			return
+		}
+		if err := b.add(queueCtx, records); err != nil {
+			queueErr(err)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+
+Loop:
+	for {
+		select {
+		case record, ok := <-req.Records.GetRecords():
+			if !ok {
+				c.logger.Info("flushing batches because no more records")
+				break Loop
+			}
+
+			if _, ok := record.(*model.RelationRecord[model.RecordItems]); ok {
+				// schema changes are not passed to scripts
+				continue
+			}
+
+			pool.Run(func(ls *lua.LState) []json.RawMessage {
+				results, err := runScript(ls, record)
+				if err != nil {
+					queueErr(err)
+					return nil
+				}
+				if len(results) > 0 {
+					record.PopulateCountMap(tableNameRowsMapping)
+					numRecords.Add(1)
+				}
+				return results
+			})
+
+		case <-queueCtx.Done():
+			break Loop
+		}
+	}
+
+	if err := pool.Wait(queueCtx); err != nil {
+		return nil, fmt.Errorf("[webhook] pool.Wait error: %w", err)
+	}
+	if err := context.Cause(queueCtx); err != nil {
+		return nil, fmt.Errorf("[webhook] queueCtx.Done: %w", err)
+	}
+	if err := b.flush(ctx); err != nil {
+		return nil, err
+	}
+
+	// every record was acknowledged with 2xx
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, fmt.Errorf("[webhook] FinishBatch error: %w", err)
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:   req.SyncBatchID,
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords.Load(),
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
@@ -0,0 +1,117 @@
+package connwebhook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// recordingServer sends requests it receives to the test, responding with the next status
+func recordingServer(statuses ...int) (*httptest.Server, <-chan received) {
+	requests := make(chan received, 16)
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header.Clone(), body: body}
+		if attempt := int(attempts.Add(1)) - 1; attempt < len(statuses) {
+			w.WriteHeader(statuses[attempt])
+		}
+	}))
+	return server, requests
+}
+
+func TestPost(t *testing.T) {
+	secret := "shh"
+	bearer := "token"
+	server, requests := recordingServer(http.StatusServiceUnavailable)
+	defer server.Close()
+
+	c := &WebhookConnector{
+		client: server.Client(),
+		config: &protos.WebhookConfig{Url: server.URL, BearerToken: &bearer, HmacSecret: &secret, Gzip: true, MaxBatchSize: 2},
+		logger: log.NewStructuredLogger(slog.Default()),
+	}
+	decode := func(r received) payload {
+		t.Helper()
+		require.Equal(t, sign(secret, r.header.Get(timestampHeader), r.body), r.header.Get(signatureHeader))
+		require.Equal(t, "Bearer token", r.header.Get("Authorization"))
+		require.Equal(t, "gzip", r.header.Get("Content-Encoding"))
+		gz, err := gzip.NewReader(bytes.NewReader(r.body))
+		require.NoError(t, err)
+		var p payload
+		require.NoError(t, json.NewDecoder(gz).Decode(&p))
+		return p
+	}
+
+	b := &batcher{c: c, payload: payload{FlowJobName: "flow", BatchID: 3}}
+	require.NoError(t, b.add(t.Context(), []json.RawMessage{json.RawMessage(`{"id":1}`)}))
+	require.Empty(t, requests)
+	require.NoError(t, b.add(t.Context(), []json.RawMessage{json.RawMessage(`{"id":2}`)}))
+	require.Len(t, requests, 2)
+	// the retry sends the same chunk
+	failed, sent := decode(<-requests), decode(<-requests)
+	require.Equal(t, failed, sent)
+	require.Equal(t, "flow", sent.FlowJobName)
+	require.Equal(t, int64(3), sent.BatchID)
+	require.Equal(t, 0, sent.ChunkIndex)
+	require.Equal(t, []json.RawMessage{json.RawMessage(`{"id":1}`), json.RawMessage(`{"id":2}`)}, sent.Records)
+	require.Empty(t, b.payload.Records)
+
+	require.NoError(t, b.add(t.Context(), []json.RawMessage{json.RawMessage(`{"id":3}`)}))
+	require.NoError(t, b.flush(t.Context()))
+	sent = decode(<-requests)
+	require.Equal(t, 1, sent.ChunkIndex)
+	require.Equal(t, []json.RawMessage{json.RawMessage(`{"id":3}`)}, sent.Records)
+}
+
+func TestPostNotRetryable(t *testing.T) {
+	server, requests := recordingServer(http.StatusBadRequest)
+	defer server.Close()
+
+	c := &WebhookConnector{
+		client: server.Client(),
+		config: &protos.WebhookConfig{Url: server.URL},
+		logger: log.NewStructuredLogger(slog.Default()),
+	}
+	err := c.post(t.Context(), &payload{Records: []json.RawMessage{json.RawMessage(`1`)}})
+	require.ErrorContains(t, err, "status 400")
+	require.Len(t, requests, 1)
+}
+
+func TestLValueToJSON(t *testing.T) {
+	ls := lua.NewState()
+	defer ls.Close()
+	require.NoError(t, ls.DoString(`t = { id = 1 }`))
+
+	result, err := lvalueToJSON(ls, ls.GetGlobal("t"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1}`, string(result))
+
+	result, err = lvalueToJSON(ls, lua.LString(`[1,2]`))
+	require.NoError(t, err)
+	require.Equal(t, json.RawMessage(`[1,2]`), result)
+
+	_, err = lvalueToJSON(ls, lua.LString(`not json`))
+	require.Error(t, err)
+
+	result, err = lvalueToJSON(ls, lua.LNil)
+	require.NoError(t, err)
+	require.Nil(t, result)
+}
@@ -18,6 +18,7 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 
+	connclickhouse "github.com/PeerDB-io/peerdb/flow/connectors/clickhouse"
 	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
 	"github.com/PeerDB-io/peerdb/flow/e2e"
 	e2e_clickhouse "github.com/PeerDB-io/peerdb/flow/e2e/clickhouse"
@@ -697,6 +698,82 @@ func (s Suite) TestEditTablesBeforeResync() {
 	e2e.RequireEnvCanceled(s.t, newEnv)
 }
 
+func (s Suite) TestResyncTable() {
+	require.NoError(s.t, s.source.Exec(s.t.Context(),
+		fmt.Sprintf("CREATE TABLE %s(id int primary key, val text)", e2e.AttachSchema(s, "resync_table"))))
+	require.NoError(s.t, s.source.Exec(s.t.Context(),
+		fmt.Sprintf("INSERT INTO %s(id, val) values (1,'a'),(2,'b'),(3,'c'),(4,'d')", e2e.AttachSchema(s, "resync_table"))))
+	connectionGen := e2e.FlowConnectionGenerationConfig{
+		FlowJobName:      "resync_table_" + s.suffix,
+		TableNameMapping: map[string]string{e2e.AttachSchema(s, "resync_table"): "resync_table"},
+		Destination:      s.ch.Peer().Name,
+	}
+	flowConnConfig := connectionGen.GenerateFlowConnectionConfigs(s)
+	flowConnConfig.DoInitialSnapshot = true
+	flowConnConfig.TruncatePolicy = protos.TruncatePolicy_TRUNCATE_POLICY_APPLY
+	response, err := s.CreateCDCFlow(s.t.Context(), &protos.CreateCDCFlowRequest{ConnectionConfigs: flowConnConfig})
+	require.NoError(s.t, err)
+	require.NotNil(s.t, response)
+
+	tc := e2e.NewTemporalClient(s.t)
+	env, err := e2e.GetPeerflow(s.t.Context(), s.pg.PostgresConnector.Conn(), tc, flowConnConfig.FlowJobName)
+	require.NoError(s.t, err)
+	e2e.SetupCDCFlowStatusQuery(s.t, env, flowConnConfig)
+	e2e.EnvWaitFor(s.t, env, 3*time.Minute, "wait for initial load to finish", func() bool {
+		return env.GetFlowStatus(s.t) == protos.FlowStatus_STATUS_RUNNING
+	})
+	e2e.RequireEqualTables(s.ch, "resync_table", "id,val")
+
+	for _, req := range []*protos.ResyncTableRequest{
+		{SourceTableIdentifier: e2e.AttachSchema(s, "missing")},
+		{SourceTableIdentifier: e2e.AttachSchema(s, "resync_table"), Filter: "1=1) OR (1=1"},
+		{SourceTableIdentifier: e2e.AttachSchema(s, "resync_table"), StartPrimaryKey: []string{"1", "2"}},
+		{SourceTableIdentifier: e2e.AttachSchema(s, "resync_table"), EndPrimaryKey: []string{"'1'"}},
+		{SourceTableIdentifier: e2e.AttachSchema(s, "resync_table"), Filter: "id > 1", TruncateDestination: true},
+	} {
+		req.FlowJobName = flowConnConfig.FlowJobName
+		_, err := s.ResyncTable(s.t.Context(), req)
+		require.Error(s.t, err, "resync of %v", req)
+	}
+
+	// each resync is listed among the initial loads of the mirror once its rows are synced
+	waitForResync := func(req *protos.ResyncTableRequest, rows int64) {
+		s.t.Helper()
+		req.FlowJobName = flowConnConfig.FlowJobName
+		req.SourceTableIdentifier = e2e.AttachSchema(s, "resync_table")
+		resp, err := s.ResyncTable(s.t.Context(), req)
+		require.NoError(s.t, err)
+		name := fmt.Sprintf("%s-incremental-snapshot-%d", flowConnConfig.FlowJobName, resp.SnapshotId)
+		e2e.EnvWaitFor(s.t, env, 3*time.Minute, "wait for "+name, func() bool {
+			summary, err := s.InitialLoadSummary(s.t.Context(), &protos.InitialLoadSummaryRequest{
+				ParentMirrorName: flowConnConfig.FlowJobName,
+			})
+			if err != nil {
+				return false
+			}
+			idx := slices.IndexFunc(summary.TableSummaries, func(table *protos.CloneTableSummary) bool {
+				return table.FlowJobName == name
+			})
+			return idx != -1 && summary.TableSummaries[idx].TableName == "resync_table" &&
+				summary.TableSummaries[idx].FetchCompleted && summary.TableSummaries[idx].NumRowsSynced == rows
+		})
+	}
+	waitForResync(&protos.ResyncTableRequest{StartPrimaryKey: []string{"2"}, EndPrimaryKey: []string{"3"}}, 2)
+	waitForResync(&protos.ResyncTableRequest{Filter: "val = 'd'"}, 1)
+	e2e.RequireEqualTables(s.ch, "resync_table", "id,val")
+
+	// a row missing from the source is only removed by truncating the destination
+	ch, err := connclickhouse.Connect(s.t.Context(), nil, s.ch.Peer().GetClickhouseConfig())
+	require.NoError(s.t, err)
+	require.NoError(s.t, ch.Exec(s.t.Context(), "INSERT INTO resync_table(id, val) VALUES (5, 'stale')"))
+	require.NoError(s.t, ch.Close())
+	waitForResync(&protos.ResyncTableRequest{TruncateDestination: true}, 4)
+	e2e.EnvWaitForEqualTables(env, s.ch, "stale row removed", "resync_table", "id,val")
+
+	env.Cancel(s.t.Context())
+	e2e.RequireEnvCanceled(s.t, env)
+}
+
 func (s Suite) TestAlertConfig() {
 	create, err := s.PostAlertConfig(s.t.Context(), &protos.PostAlertConfigRequest{
 		Config: &protos.AlertConfig{
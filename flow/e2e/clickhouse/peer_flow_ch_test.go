@@ -18,9 +18,11 @@ import (
 
 	connclickhouse "github.com/PeerDB-io/peerdb/flow/connectors/clickhouse"
 	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/e2e"
 	"github.com/PeerDB-io/peerdb/flow/e2eshared"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
@@ -201,6 +203,87 @@ func (s ClickHouseSuite) Test_Addition_Removal() {
 	e2e.RequireEnvCanceled(s.t, env)
 }
 
+// incremental snapshots read the added table in chunks while changes to it stream through cdc,
+// rows changed within the window of a chunk are dropped from it so that chunks never overwrite newer changes
+func (s ClickHouseSuite) Test_Incremental_Snapshot() {
+	tc := e2e.NewTemporalClient(s.t)
+
+	srcTableName := s.attachSchemaSuffix("test_incremental")
+	addedSrcTableName := s.attachSchemaSuffix("test_incremental_added")
+	dstTableName := "test_incremental_target"
+	addedDstTableName := "test_incremental_target_added"
+
+	for _, table := range []string{srcTableName, addedSrcTableName} {
+		require.NoError(s.t, s.source.Exec(s.t.Context(), fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				id SERIAL PRIMARY KEY,
+				"key" TEXT NOT NULL
+			);
+		`, table)))
+	}
+	values := make([]string, 0, 50)
+	for i := range 50 {
+		values = append(values, fmt.Sprintf("('init%d')", i))
+	}
+	require.NoError(s.t, s.source.Exec(s.t.Context(), fmt.Sprintf(`INSERT INTO %s ("key") VALUES %s`,
+		addedSrcTableName, strings.Join(values, ","))))
+
+	flowJobName := s.attachSuffix("clickhouse_incremental")
+	connectionGen := e2e.FlowConnectionGenerationConfig{
+		FlowJobName:      flowJobName,
+		TableNameMapping: map[string]string{srcTableName: dstTableName},
+		Destination:      s.Peer().Name,
+	}
+	flowConnConfig := connectionGen.GenerateFlowConnectionConfigs(s)
+	flowConnConfig.Env = map[string]string{"PEERDB_INCREMENTAL_SNAPSHOT_CHUNK_SIZE": "7"}
+
+	env := e2e.ExecutePeerflow(s.t.Context(), tc, peerflow.CDCFlowWorkflow, flowConnConfig, nil)
+	e2e.SetupCDCFlowStatusQuery(s.t, env, flowConnConfig)
+	require.NoError(s.t, s.source.Exec(s.t.Context(), fmt.Sprintf(`INSERT INTO %s ("key") VALUES ('test')`, srcTableName)))
+	e2e.EnvWaitForEqualTablesWithNames(env, s, "first insert", "test_incremental", dstTableName, "id,\"key\"")
+
+	e2e.SignalWorkflow(s.t.Context(), env, model.FlowSignal, model.PauseSignal)
+	e2e.EnvWaitFor(s.t, env, 4*time.Minute, "pausing for add table", func() bool {
+		return env.GetFlowStatus(s.t) == protos.FlowStatus_STATUS_PAUSED
+	})
+	e2e.SignalWorkflow(s.t.Context(), env, model.CDCDynamicPropertiesSignal, &protos.CDCFlowConfigUpdate{
+		AdditionalTables: []*protos.TableMapping{{
+			SourceTableIdentifier:      addedSrcTableName,
+			DestinationTableIdentifier: addedDstTableName,
+		}},
+		IncrementalSnapshot: true,
+	})
+	e2e.EnvWaitFor(s.t, env, 4*time.Minute, "adding table", func() bool {
+		return env.GetFlowStatus(s.t) == protos.FlowStatus_STATUS_RUNNING
+	})
+
+	// changes across the whole key range race with the chunks being read
+	for i := 1; i <= 50; i += 6 {
+		require.NoError(s.t, s.source.Exec(s.t.Context(), fmt.Sprintf(`UPDATE %s SET "key"='updated' WHERE id=%d`,
+			addedSrcTableName, i)))
+		require.NoError(s.t, s.source.Exec(s.t.Context(), fmt.Sprintf(`DELETE FROM %s WHERE id=%d`,
+			addedSrcTableName, i+3)))
+	}
+	require.NoError(s.t, s.source.Exec(s.t.Context(), fmt.Sprintf(`INSERT INTO %s ("key") VALUES ('new')`, addedSrcTableName)))
+	e2e.EnvWaitForEqualTablesWithNames(env, s, "incremental snapshot with changes", "test_incremental_added",
+		addedDstTableName, "id,\"key\"")
+
+	pool, err := internal.GetCatalogConnectionPoolFromEnv(s.t.Context())
+	require.NoError(s.t, err)
+	e2e.EnvWaitFor(s.t, env, time.Minute, "incremental snapshot completed", func() bool {
+		snapshots, err := utils.GetIncrementalSnapshots(s.t.Context(), pool, flowJobName, false)
+		require.NoError(s.t, err)
+		require.Len(s.t, snapshots, 1)
+		pending, err := utils.GetIncrementalSnapshots(s.t.Context(), pool, flowJobName, true)
+		require.NoError(s.t, err)
+		// rows changed during their chunk are synced by cdc instead of the snapshot
+		return len(pending) == 0 && snapshots[0].RowsSynced <= 50
+	})
+
+	env.Cancel(s.t.Context())
+	e2e.RequireEnvCanceled(s.t, env)
+}
+
 func (s ClickHouseSuite) Test_NullableMirrorSetting() {
 	srcTableName := "test_nullable_mirror"
 	srcFullName := s.attachSchemaSuffix(srcTableName)
@@ -0,0 +1,174 @@
+//go:build duckdb
+
+package e2e_duckdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/duckdb/duckdb-go/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+
+	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
+	"github.com/PeerDB-io/peerdb/flow/e2e"
+	"github.com/PeerDB-io/peerdb/flow/e2eshared"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	peerflow "github.com/PeerDB-io/peerdb/flow/workflows"
+)
+
+type DuckDBSuite struct {
+	t      *testing.T
+	conn   *connpostgres.PostgresConnector
+	path   string
+	suffix string
+}
+
+func (s DuckDBSuite) T() *testing.T {
+	return s.t
+}
+
+func (s DuckDBSuite) Connector() *connpostgres.PostgresConnector {
+	return s.conn
+}
+
+func (s DuckDBSuite) Source() e2e.SuiteSource {
+	return &e2e.PostgresSource{PostgresConnector: s.conn}
+}
+
+func (s DuckDBSuite) Conn() *pgx.Conn {
+	return s.Connector().Conn()
+}
+
+func (s DuckDBSuite) Suffix() string {
+	return s.suffix
+}
+
+func (s DuckDBSuite) Peer() *protos.Peer {
+	ret := &protos.Peer{
+		Name: e2e.AddSuffix(s, "duckdb"),
+		Type: protos.DBType_DUCKDB,
+		Config: &protos.Peer_DuckdbConfig{
+			DuckdbConfig: &protos.DuckDBConfig{Path: s.path},
+		},
+	}
+	e2e.CreatePeer(s.t, ret)
+	return ret
+}
+
+func (s DuckDBSuite) DestinationTable(table string) string {
+	return "main." + table
+}
+
+func (s DuckDBSuite) Teardown(ctx context.Context) {
+	e2e.TearDownPostgres(ctx, s)
+	_ = os.Remove(s.path)
+}
+
+// ids reads the ids of a destination table, the worker holds the database while it writes,
+// so it is opened read only for each check
+func (s DuckDBSuite) ids(table string) ([]int64, error) {
+	db, err := sql.Open("duckdb", s.path+"?access_mode=READ_ONLY")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.QueryContext(s.t.Context(), fmt.Sprintf("SELECT id FROM main.%s ORDER BY id", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func SetupSuite(t *testing.T) DuckDBSuite {
+	t.Helper()
+
+	suffix := "duck_" + strings.ToLower(shared.RandomString(8))
+	conn, err := e2e.SetupPostgres(t, suffix)
+	require.NoError(t, err, "failed to setup postgres")
+
+	return DuckDBSuite{
+		t:      t,
+		conn:   conn.PostgresConnector,
+		path:   filepath.Join(os.TempDir(), suffix+".duckdb"),
+		suffix: suffix,
+	}
+}
+
+func Test_DuckDB(t *testing.T) {
+	e2eshared.RunSuite(t, SetupSuite)
+}
+
+func (s DuckDBSuite) TestTruncate() {
+	srcTableName := e2e.AttachSchema(s, "ducktrunc")
+
+	_, err := s.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id INT PRIMARY KEY,
+			val text
+		);
+		INSERT INTO %s (id, val) VALUES (1, 'a'), (2, 'b');
+	`, srcTableName, srcTableName))
+	require.NoError(s.t, err)
+
+	flowName := e2e.AddSuffix(s, "ducktrunc")
+	connectionGen := e2e.FlowConnectionGenerationConfig{
+		FlowJobName:      flowName,
+		TableNameMapping: map[string]string{srcTableName: s.DestinationTable("ducktrunc")},
+		Destination:      s.Peer().Name,
+	}
+	flowConnConfig := connectionGen.GenerateFlowConnectionConfigs(s)
+	flowConnConfig.DoInitialSnapshot = true
+	flowConnConfig.TruncatePolicy = protos.TruncatePolicy_TRUNCATE_POLICY_APPLY
+
+	tc := e2e.NewTemporalClient(s.t)
+	env := e2e.ExecutePeerflow(s.t.Context(), tc, peerflow.CDCFlowWorkflow, flowConnConfig, nil)
+	e2e.SetupCDCFlowStatusQuery(s.t, env, flowConnConfig)
+
+	e2e.EnvWaitFor(s.t, env, 3*time.Minute, "initial load", func() bool {
+		ids, err := s.ids("ducktrunc")
+		return err == nil && len(ids) == 2
+	})
+
+	_, err = s.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		INSERT INTO %s (id, val) VALUES (3, 'c');
+		UPDATE %s SET val = 'd' WHERE id = 1;
+		DELETE FROM %s WHERE id = 2;
+	`, srcTableName, srcTableName, srcTableName))
+	require.NoError(s.t, err)
+	e2e.EnvWaitFor(s.t, env, 3*time.Minute, "normalize changes", func() bool {
+		ids, err := s.ids("ducktrunc")
+		return err == nil && slices.Equal(ids, []int64{1, 3})
+	})
+
+	// rows from before the truncate are removed, rows inserted after it are kept
+	_, err = s.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		TRUNCATE %s;
+		INSERT INTO %s (id, val) VALUES (4, 'e');
+	`, srcTableName, srcTableName))
+	require.NoError(s.t, err)
+	e2e.EnvWaitFor(s.t, env, 3*time.Minute, "normalize truncate", func() bool {
+		ids, err := s.ids("ducktrunc")
+		return err == nil && slices.Equal(ids, []int64{4})
+	})
+
+	env.Cancel(s.t.Context())
+	e2e.RequireEnvCanceled(s.t, env)
+}
@@ -1,12 +1,14 @@
 package e2e_elasticsearch
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/PeerDB-io/peerdb/flow/e2e"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	peerflow "github.com/PeerDB-io/peerdb/flow/workflows"
 )
 
@@ -146,3 +148,81 @@ func (s elasticsearchSuite) Test_Composite_PKey_CDC_Mirror() {
 	env.Cancel(s.t.Context())
 	e2e.RequireEnvCanceled(s.t, env)
 }
+
+func (s elasticsearchSuite) Test_Mappings_CDC_Mirror() {
+	srcTableName := e2e.AttachSchema(s, "es_mappings_cdc")
+
+	_, err := s.conn.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+			c1 INT,
+			n NUMERIC(10,2),
+			val TEXT,
+			code TEXT,
+			doc JSONB,
+			loc geography(Point, 4326),
+			updated_at TIMESTAMP DEFAULT now()
+		);
+	`, srcTableName))
+	require.NoError(s.t, err, "failed creating table")
+
+	tc := e2e.NewTemporalClient(s.t)
+	connectionGen := e2e.FlowConnectionGenerationConfig{
+		FlowJobName:      e2e.AddSuffix(s, "es_mappings_cdc"),
+		TableNameMapping: map[string]string{srcTableName: srcTableName},
+		Destination:      s.Peer().Name,
+	}
+	flowConnConfig := connectionGen.GenerateFlowConnectionConfigs(s)
+	flowConnConfig.DoInitialSnapshot = true
+	flowConnConfig.TableMappings[0].Columns = []*protos.ColumnSetting{
+		{SourceName: "code", DestinationType: "keyword"},
+		{SourceName: "val", DestinationName: "value"},
+	}
+
+	_, err = s.conn.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		INSERT INTO %s(c1,n,val,code,doc,loc) VALUES(1,1.5,'val','code','{"a":1}','SRID=4326;POINT(1 2)')`, srcTableName))
+	require.NoError(s.t, err, "failed to insert row")
+
+	env := e2e.ExecutePeerflow(s.t.Context(), tc, peerflow.CDCFlowWorkflow, flowConnConfig, nil)
+	e2e.SetupCDCFlowStatusQuery(s.t, env, flowConnConfig)
+	e2e.EnvWaitFor(s.t, env, 3*time.Minute, "wait for initial snapshot", func() bool {
+		return s.countDocumentsInIndex(srcTableName) == 1
+	})
+
+	// an added column is mapped before the document that has it is indexed,
+	// geography values are indexed as geo_shape through cdc too
+	_, err = s.conn.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		ALTER TABLE %s ADD COLUMN added INT;
+		INSERT INTO %s(c1,added,loc) VALUES(2,2,'SRID=4326;POINT(3 4)')`, srcTableName, srcTableName))
+	require.NoError(s.t, err, "failed to add column")
+	e2e.EnvWaitFor(s.t, env, 3*time.Minute, "wait for added column", func() bool {
+		return s.countDocumentsInIndex(srcTableName) == 2
+	})
+
+	res, err := s.esClient.Indices.GetMapping().Index(srcTableName).Do(s.t.Context())
+	require.NoError(s.t, err, "failed to get mappings")
+	fieldTypes := make(map[string]string)
+	for name, property := range res[srcTableName].Mappings.Properties {
+		propertyJSON, err := json.Marshal(property)
+		require.NoError(s.t, err)
+		var typed struct {
+			Type string `json:"type"`
+		}
+		require.NoError(s.t, json.Unmarshal(propertyJSON, &typed))
+		fieldTypes[name] = typed.Type
+	}
+	require.Equal(s.t, map[string]string{
+		"id":         "long",
+		"c1":         "integer",
+		"n":          "double",
+		"value":      "text",
+		"added":      "integer",
+		"code":       "keyword",
+		"doc":        "flattened",
+		"loc":        "geo_shape",
+		"updated_at": "date",
+	}, fieldTypes)
+
+	env.Cancel(s.t.Context())
+	e2e.RequireEnvCanceled(s.t, env)
+}
@@ -0,0 +1,160 @@
+package e2e_nats
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/require"
+
+	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
+	"github.com/PeerDB-io/peerdb/flow/e2e"
+	"github.com/PeerDB-io/peerdb/flow/e2eshared"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	peerflow "github.com/PeerDB-io/peerdb/flow/workflows"
+)
+
+type NatsSuite struct {
+	t      *testing.T
+	conn   *connpostgres.PostgresConnector
+	suffix string
+}
+
+func (s NatsSuite) T() *testing.T {
+	return s.t
+}
+
+func (s NatsSuite) Connector() *connpostgres.PostgresConnector {
+	return s.conn
+}
+
+func (s NatsSuite) Source() e2e.SuiteSource {
+	return &e2e.PostgresSource{PostgresConnector: s.conn}
+}
+
+func (s NatsSuite) Conn() *pgx.Conn {
+	return s.Connector().Conn()
+}
+
+func (s NatsSuite) Suffix() string {
+	return s.suffix
+}
+
+func (s NatsSuite) Peer() *protos.Peer {
+	ret := &protos.Peer{
+		Name: e2e.AddSuffix(s, "nats"),
+		Type: protos.DBType_NATS,
+		Config: &protos.Peer_NatsConfig{
+			NatsConfig: &protos.NatsConfig{
+				Servers: []string{"nats://localhost:4222"},
+			},
+		},
+	}
+	e2e.CreatePeer(s.t, ret)
+	return ret
+}
+
+func (s NatsSuite) DestinationTable(table string) string {
+	return table
+}
+
+func (s NatsSuite) Teardown(ctx context.Context) {
+	e2e.TearDownPostgres(ctx, s)
+}
+
+// JetStream creates a stream capturing subject
+func (s NatsSuite) JetStream(subject string) jetstream.Stream {
+	s.t.Helper()
+	nc, err := nats.Connect("nats://localhost:4222")
+	require.NoError(s.t, err)
+	s.t.Cleanup(nc.Close)
+	js, err := jetstream.New(nc)
+	require.NoError(s.t, err)
+	stream, err := js.CreateOrUpdateStream(s.t.Context(), jetstream.StreamConfig{
+		Name:     subject,
+		Subjects: []string{subject},
+		Storage:  jetstream.MemoryStorage,
+	})
+	require.NoError(s.t, err)
+	return stream
+}
+
+func SetupSuite(t *testing.T) NatsSuite {
+	t.Helper()
+
+	suffix := "na_" + strings.ToLower(shared.RandomString(8))
+	conn, err := e2e.SetupPostgres(t, suffix)
+	require.NoError(t, err, "failed to setup postgres")
+
+	return NatsSuite{
+		t:      t,
+		conn:   conn.PostgresConnector,
+		suffix: suffix,
+	}
+}
+
+func Test_Nats(t *testing.T) {
+	e2eshared.RunSuite(t, SetupSuite)
+}
+
+func (s NatsSuite) TestSimple() {
+	srcTableName := e2e.AttachSchema(s, "nasimple")
+
+	_, err := s.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id SERIAL PRIMARY KEY,
+			val text
+		);
+	`, srcTableName))
+	require.NoError(s.t, err)
+
+	_, err = s.Conn().Exec(s.t.Context(), `insert into public.scripts (name, lang, source) values
+	('e2e_nasimple', 'lua', 'function onRecord(r) return r.row and { payload = r.row.val, headers = { id = r.row.id } } end')
+	on conflict do nothing`)
+	require.NoError(s.t, err)
+
+	flowName := e2e.AddSuffix(s, "nasimple")
+	stream := s.JetStream(flowName)
+	connectionGen := e2e.FlowConnectionGenerationConfig{
+		FlowJobName:      flowName,
+		TableNameMapping: map[string]string{srcTableName: flowName},
+		Destination:      s.Peer().Name,
+	}
+	flowConnConfig := connectionGen.GenerateFlowConnectionConfigs(s)
+	flowConnConfig.Script = "e2e_nasimple"
+
+	tc := e2e.NewTemporalClient(s.t)
+	env := e2e.ExecutePeerflow(s.t.Context(), tc, peerflow.CDCFlowWorkflow, flowConnConfig, nil)
+	e2e.SetupCDCFlowStatusQuery(s.t, env, flowConnConfig)
+
+	_, err = s.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		INSERT INTO %s (id, val) VALUES (1, 'testval')
+	`, srcTableName))
+	require.NoError(s.t, err)
+
+	consumer, err := stream.CreateOrUpdateConsumer(s.t.Context(), jetstream.ConsumerConfig{Durable: "e2e"})
+	require.NoError(s.t, err)
+	e2e.EnvWaitFor(s.t, env, 3*time.Minute, "normalize insert", func() bool {
+		batch, err := consumer.Fetch(1, jetstream.FetchMaxWait(time.Second))
+		if err != nil {
+			return false
+		}
+		for msg := range batch.Messages() {
+			require.Equal(s.t, flowName, msg.Subject())
+			require.Equal(s.t, "testval", string(msg.Data()))
+			require.Equal(s.t, "1", msg.Headers().Get("id"))
+			require.NotEmpty(s.t, msg.Headers().Get(nats.MsgIdHdr))
+			require.NoError(s.t, msg.Ack())
+			return true
+		}
+		return false
+	})
+	env.Cancel(s.t.Context())
+	e2e.RequireEnvCanceled(s.t, env)
+}
@@ -1007,6 +1007,97 @@ func (s PeerFlowE2ETestSuitePG) Test_Dynamic_Mirror_Config_Via_Signals() {
 	e2e.RequireEnvCanceled(s.t, env)
 }
 
+func (s PeerFlowE2ETestSuitePG) Test_Bidirectional_LastWriterWins() {
+	var trackCommitTimestamp string
+	require.NoError(s.t, s.Conn().QueryRow(s.t.Context(), "SHOW track_commit_timestamp").Scan(&trackCommitTimestamp))
+	if trackCommitTimestamp != "on" {
+		s.t.Skip("last writer wins needs track_commit_timestamp")
+	}
+
+	tableA := s.attachSchemaSuffix("test_bidir_a")
+	tableB := s.attachSchemaSuffix("test_bidir_b")
+	_, err := s.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		CREATE TABLE %[1]s (id INT PRIMARY KEY, val TEXT NOT NULL);
+		CREATE TABLE %[2]s (id INT PRIMARY KEY, val TEXT NOT NULL);
+	`, tableA, tableB))
+	require.NoError(s.t, err)
+
+	// a mirror in each direction between the two tables
+	tc := e2e.NewTemporalClient(s.t)
+	var envs [2]e2e.WorkflowRun
+	for i, tables := range [2][2]string{{tableA, tableB}, {tableB, tableA}} {
+		connectionGen := e2e.FlowConnectionGenerationConfig{
+			FlowJobName:      s.attachSuffix(fmt.Sprintf("test_bidir_%d", i)),
+			TableNameMapping: map[string]string{tables[0]: tables[1]},
+			Destination:      s.Peer().Name,
+		}
+		config := connectionGen.GenerateFlowConnectionConfigs(s)
+		config.SyncedAtColName = ""
+		config.Bidirectional = true
+		config.ConflictResolution = protos.ConflictResolution_CONFLICT_RESOLUTION_LAST_WRITER_WINS
+		envs[i] = e2e.ExecutePeerflow(s.t.Context(), tc, peerflow.CDCFlowWorkflow, config, nil)
+		e2e.SetupCDCFlowStatusQuery(s.t, envs[i], config)
+	}
+	val := func(table string, id int) (string, string) {
+		var val, xmin string
+		if err := s.Conn().QueryRow(s.t.Context(),
+			fmt.Sprintf("SELECT val, xmin::text FROM %s WHERE id=$1", table), id).Scan(&val, &xmin); err != nil {
+			return "", ""
+		}
+		return val, xmin
+	}
+
+	// changes are not replicated back to where they were made
+	_, err = s.Conn().Exec(s.t.Context(), fmt.Sprintf("INSERT INTO %s VALUES (1, 'a')", tableA))
+	e2e.EnvNoError(s.t, envs[0], err)
+	_, xminA := val(tableA, 1)
+	e2e.EnvWaitFor(s.t, envs[0], 3*time.Minute, "row from a in b", func() bool {
+		v, _ := val(tableB, 1)
+		return v == "a"
+	})
+	_, err = s.Conn().Exec(s.t.Context(), fmt.Sprintf("INSERT INTO %s VALUES (2, 'b')", tableB))
+	e2e.EnvNoError(s.t, envs[1], err)
+	e2e.EnvWaitFor(s.t, envs[1], 3*time.Minute, "row from b in a", func() bool {
+		v, _ := val(tableA, 2)
+		return v == "b"
+	})
+	_, xmin := val(tableA, 1)
+	require.Equal(s.t, xminA, xmin, "row inserted in a was written back to a")
+
+	// a row updated at both ends while the mirrors are paused ends up with the later update on both
+	for _, env := range envs {
+		e2e.SignalWorkflow(s.t.Context(), env, model.FlowSignal, model.PauseSignal)
+		e2e.EnvWaitFor(s.t, env, 1*time.Minute, "paused workflow", func() bool {
+			return env.GetFlowStatus(s.t) == protos.FlowStatus_STATUS_PAUSED
+		})
+	}
+	_, err = s.Conn().Exec(s.t.Context(), fmt.Sprintf("UPDATE %s SET val='earlier' WHERE id=1", tableA))
+	require.NoError(s.t, err)
+	_, err = s.Conn().Exec(s.t.Context(), fmt.Sprintf("UPDATE %s SET val='later' WHERE id=1", tableB))
+	require.NoError(s.t, err)
+	for _, env := range envs {
+		e2e.SignalWorkflow(s.t.Context(), env, model.FlowSignal, model.NoopSignal)
+	}
+	e2e.EnvWaitFor(s.t, envs[1], 3*time.Minute, "later update in a", func() bool {
+		v, _ := val(tableA, 1)
+		return v == "later"
+	})
+	// give the earlier update time to be normalized into b
+	_, err = s.Conn().Exec(s.t.Context(), fmt.Sprintf("INSERT INTO %s VALUES (3, 'c')", tableA))
+	e2e.EnvNoError(s.t, envs[0], err)
+	e2e.EnvWaitFor(s.t, envs[0], 3*time.Minute, "row after conflict in b", func() bool {
+		v, _ := val(tableB, 3)
+		return v == "c"
+	})
+	v, _ := val(tableB, 1)
+	require.Equal(s.t, "later", v)
+
+	for _, env := range envs {
+		env.Cancel(s.t.Context())
+		e2e.RequireEnvCanceled(s.t, env)
+	}
+}
+
 func (s PeerFlowE2ETestSuitePG) Test_CustomSync() {
 	srcTableName := s.attachSchemaSuffix("test_customsync")
 	dstTableName := s.attachSchemaSuffix("test_customsync_dst")
@@ -1302,6 +1393,57 @@ func (s PeerFlowE2ETestSuitePG) Test_Mixed_Case_Schema_Changes_PG() {
 	e2e.RequireEnvCanceled(s.t, env)
 }
 
+func (s PeerFlowE2ETestSuitePG) Test_Generated_Column_Not_Dropped_PG() {
+	tc := e2e.NewTemporalClient(s.t)
+
+	srcTableName := s.attachSchemaSuffix("test_generated_column")
+	dstTableName := s.attachSchemaSuffix("test_generated_column_dst")
+	_, err := s.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id INT PRIMARY KEY,
+			c1 INT,
+			g INT GENERATED ALWAYS AS (c1 * 2) STORED
+		);
+		INSERT INTO %[1]s(id, c1) VALUES (1, 1);
+	`, srcTableName))
+	require.NoError(s.t, err)
+
+	flowConnConfig := &protos.FlowConnectionConfigs{
+		FlowJobName:     s.attachSuffix("test_generated_column"),
+		DestinationName: s.Peer().Name,
+		TableMappings: []*protos.TableMapping{
+			{
+				SourceTableIdentifier:      srcTableName,
+				DestinationTableIdentifier: dstTableName,
+			},
+		},
+		SourceName:          e2e.GeneratePostgresPeer(s.t).Name,
+		MaxBatchSize:        100,
+		DoInitialSnapshot:   true,
+		DroppedColumnPolicy: protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_NULL,
+	}
+
+	env := e2e.ExecutePeerflow(s.t.Context(), tc, peerflow.CDCFlowWorkflow, flowConnConfig, nil)
+	e2e.SetupCDCFlowStatusQuery(s.t, env, flowConnConfig)
+	e2e.EnvWaitFor(s.t, env, 3*time.Minute, "initial load of generated column", func() bool {
+		return s.comparePGTables(srcTableName, dstTableName, "id,c1,g") == nil
+	})
+
+	// the relation message of this session has no generated column, which must not be taken as dropped
+	_, err = s.Conn().Exec(s.t.Context(), fmt.Sprintf("INSERT INTO %s(id, c1) VALUES (2, 2)", srcTableName))
+	e2e.EnvNoError(s.t, env, err)
+	e2e.EnvWaitFor(s.t, env, time.Minute, "cdc with generated column", func() bool {
+		return s.comparePGTables(srcTableName, dstTableName, "id,c1") == nil
+	})
+	var g pgtype.Int4
+	e2e.EnvNoError(s.t, env, s.Conn().QueryRow(s.t.Context(),
+		fmt.Sprintf("SELECT g FROM %s WHERE id = 1", dstTableName)).Scan(&g))
+	require.Equal(s.t, pgtype.Int4{Int32: 2, Valid: true}, g)
+
+	env.Cancel(s.t.Context())
+	e2e.RequireEnvCanceled(s.t, env)
+}
+
 func (s PeerFlowE2ETestSuitePG) TestResync(tableName string) {
 	srcTableName := "pgresync"
 	srcFullName := s.attachSchemaSuffix(fmt.Sprintf("\"%s\"", tableName))
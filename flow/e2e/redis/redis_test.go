@@ -0,0 +1,142 @@
+package e2e_redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
+	"github.com/PeerDB-io/peerdb/flow/e2e"
+	"github.com/PeerDB-io/peerdb/flow/e2eshared"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	peerflow "github.com/PeerDB-io/peerdb/flow/workflows"
+)
+
+type RedisSuite struct {
+	t      *testing.T
+	conn   *connpostgres.PostgresConnector
+	client *redis.Client
+	suffix string
+}
+
+func (s RedisSuite) T() *testing.T {
+	return s.t
+}
+
+func (s RedisSuite) Connector() *connpostgres.PostgresConnector {
+	return s.conn
+}
+
+func (s RedisSuite) Source() e2e.SuiteSource {
+	return &e2e.PostgresSource{PostgresConnector: s.conn}
+}
+
+func (s RedisSuite) Conn() *pgx.Conn {
+	return s.Connector().Conn()
+}
+
+func (s RedisSuite) Suffix() string {
+	return s.suffix
+}
+
+func (s RedisSuite) Peer() *protos.Peer {
+	ret := &protos.Peer{
+		Name: e2e.AddSuffix(s, "redis"),
+		Type: protos.DBType_REDIS,
+		Config: &protos.Peer_RedisConfig{
+			RedisConfig: &protos.RedisConfig{
+				Addresses: []string{"localhost:6379"},
+				StreamKey: s.suffix + ":changes",
+			},
+		},
+	}
+	e2e.CreatePeer(s.t, ret)
+	return ret
+}
+
+func (s RedisSuite) DestinationTable(table string) string {
+	return table
+}
+
+func (s RedisSuite) Teardown(ctx context.Context) {
+	_ = s.client.Close()
+	e2e.TearDownPostgres(ctx, s)
+}
+
+func SetupSuite(t *testing.T) RedisSuite {
+	t.Helper()
+
+	suffix := "re_" + strings.ToLower(shared.RandomString(8))
+	conn, err := e2e.SetupPostgres(t, suffix)
+	require.NoError(t, err, "failed to setup postgres")
+
+	return RedisSuite{
+		t:      t,
+		conn:   conn.PostgresConnector,
+		client: redis.NewClient(&redis.Options{Addr: "localhost:6379"}),
+		suffix: suffix,
+	}
+}
+
+func Test_Redis(t *testing.T) {
+	e2eshared.RunSuite(t, SetupSuite)
+}
+
+func (s RedisSuite) TestSimple() {
+	srcTableName := e2e.AttachSchema(s, "resimple")
+
+	_, err := s.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id SERIAL PRIMARY KEY,
+			val text
+		);
+	`, srcTableName))
+	require.NoError(s.t, err)
+
+	flowName := e2e.AddSuffix(s, "resimple")
+	connectionGen := e2e.FlowConnectionGenerationConfig{
+		FlowJobName:      flowName,
+		TableNameMapping: map[string]string{srcTableName: flowName},
+		Destination:      s.Peer().Name,
+	}
+	flowConnConfig := connectionGen.GenerateFlowConnectionConfigs(s)
+
+	tc := e2e.NewTemporalClient(s.t)
+	env := e2e.ExecutePeerflow(s.t.Context(), tc, peerflow.CDCFlowWorkflow, flowConnConfig, nil)
+	e2e.SetupCDCFlowStatusQuery(s.t, env, flowConnConfig)
+
+	_, err = s.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		INSERT INTO %s (id, val) VALUES (1, 'testval')
+	`, srcTableName))
+	require.NoError(s.t, err)
+
+	key := flowName + ":1"
+	e2e.EnvWaitFor(s.t, env, 3*time.Minute, "normalize insert", func() bool {
+		val, err := s.client.HGet(s.t.Context(), key, "val").Result()
+		return err == nil && val == "testval"
+	})
+
+	_, err = s.Conn().Exec(s.t.Context(), fmt.Sprintf(`DELETE FROM %s WHERE id = 1`, srcTableName))
+	require.NoError(s.t, err)
+
+	e2e.EnvWaitFor(s.t, env, 3*time.Minute, "normalize delete", func() bool {
+		exists, err := s.client.Exists(s.t.Context(), key).Result()
+		return err == nil && exists == 0
+	})
+
+	entries, err := s.client.XRange(s.t.Context(), s.suffix+":changes", "-", "+").Result()
+	require.NoError(s.t, err)
+	require.Len(s.t, entries, 2)
+	require.Equal(s.t, "insert", entries[0].Values["op"])
+	require.Equal(s.t, "delete", entries[1].Values["op"])
+
+	env.Cancel(s.t.Context())
+	e2e.RequireEnvCanceled(s.t, env)
+}
@@ -170,7 +170,16 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 	},
 	{
 		Name:             "PEERDB_QUEUE_FORCE_TOPIC_CREATION",
-		Description:      "Force auto topic creation in mirrors, applies to Kafka and PubSub mirrors",
+		Description:      "Force auto topic creation in mirrors, applies to Kafka and PubSub mirrors, NATS mirrors create a stream per subject",
+		DefaultValue:     "false",
+		ValueType:        protos.DynconfValueType_BOOL,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
+		TargetForSetting: protos.DynconfTarget_QUEUES,
+	},
+	{
+		Name: "PEERDB_KAFKA_SOURCE_START_AT_LATEST",
+		Description: "For mirrors from Kafka: start consuming topics at their latest offsets instead of the earliest retained ones, " +
+			"partitions added later are always consumed from the start",
 		DefaultValue:     "false",
 		ValueType:        protos.DynconfValueType_BOOL,
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
@@ -340,6 +349,24 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_ALL,
 	},
+	{
+		Name: "PEERDB_POSTGRES_CDC_STREAMING",
+		Description: "For Postgres CDC: use pgoutput protocol version 2 to receive large transactions while they are in progress, " +
+			"buffering them on disk until commit instead of waiting for the commit to decode them. Requires Postgres 14+",
+		DefaultValue:     "false",
+		ValueType:        protos.DynconfValueType_BOOL,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_INCREMENTAL_SNAPSHOT_CHUNK_SIZE",
+		Description: "CDC: number of rows read per chunk by incremental snapshots of Postgres and MySQL tables, " +
+			"which re-read a table by primary key while changes keep flowing",
+		DefaultValue:     "10000",
+		ValueType:        protos.DynconfValueType_UINT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 	{
 		Name:             "PEERDB_UI_MAINTENANCE_TAB_ENABLED",
 		Description:      "Enable/disable the maintenance tab in the PeerDB UI",
@@ -612,6 +639,10 @@ func PeerDBQueueForceTopicCreation(ctx context.Context, env map[string]string) (
 	return dynamicConfBool(ctx, env, "PEERDB_QUEUE_FORCE_TOPIC_CREATION")
 }
 
+func PeerDBKafkaSourceStartAtLatest(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_KAFKA_SOURCE_START_AT_LATEST")
+}
+
 // PEERDB_INTERVAL_SINCE_LAST_NORMALIZE_THRESHOLD_MINUTES, 0 disables normalize gap alerting entirely
 func PeerDBIntervalSinceLastNormalizeThresholdMinutes(ctx context.Context, env map[string]string) (uint32, error) {
 	return dynamicConfUnsigned[uint32](ctx, env, "PEERDB_INTERVAL_SINCE_LAST_NORMALIZE_THRESHOLD_MINUTES")
@@ -656,3 +687,11 @@ func PeerDBSourceSchemaAsDestinationColumn(ctx context.Context, env map[string]s
 func PeerDBPostgresCDCHandleInheritanceForNonPartitionedTables(ctx context.Context, env map[string]string) (bool, error) {
 	return dynamicConfBool(ctx, env, "PEERDB_POSTGRES_CDC_HANDLE_INHERITANCE_FOR_NON_PARTITIONED_TABLES")
 }
+
+func PeerDBPostgresCDCStreaming(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_POSTGRES_CDC_STREAMING")
+}
+
+func PeerDBIncrementalSnapshotChunkSize(ctx context.Context, env map[string]string) (uint32, error) {
+	return dynamicConfUnsigned[uint32](ctx, env, "PEERDB_INCREMENTAL_SNAPSHOT_CHUNK_SIZE")
+}
@@ -17,6 +17,10 @@ type CDCStream[T Items] struct {
 	lastCheckpointText string
 	// Schema changes from slot
 	SchemaDeltas []*protos.TableSchemaDelta
+	// destination tables of TruncateRecords in this batch
+	TruncatedTables []string
+	// policy of the mirror, stamped onto schema deltas so destinations can apply it
+	droppedColumnPolicy protos.DroppedColumnPolicy
 	// lastCheckpointID is the last ID of the commit that corresponds to this batch.
 	lastCheckpointID  int64
 	lastCheckpointSet bool
@@ -52,6 +56,10 @@ func (r *CDCStream[T]) UpdateLatestCheckpointText(val string) {
 	r.lastCheckpointText = val
 }
 
+func (r *CDCStream[T]) SetDroppedColumnPolicy(policy protos.DroppedColumnPolicy) {
+	r.droppedColumnPolicy = policy
+}
+
 func (r *CDCStream[T]) GetLastCheckpoint() CdcCheckpoint {
 	if !r.lastCheckpointSet {
 		panic("last checkpoint not set, stream is still active")
@@ -62,10 +70,13 @@ func (r *CDCStream[T]) GetLastCheckpoint() CdcCheckpoint {
 func (r *CDCStream[T]) AddRecord(ctx context.Context, record Record[T]) error {
 	if !r.needsNormalize {
 		switch record.(type) {
-		case *InsertRecord[T], *UpdateRecord[T], *DeleteRecord[T]:
+		case *InsertRecord[T], *UpdateRecord[T], *DeleteRecord[T], *TruncateRecord[T]:
 			r.needsNormalize = true
 		}
 	}
+	if rec, ok := record.(*TruncateRecord[T]); ok {
+		r.TruncatedTables = append(r.TruncatedTables, rec.DestinationTableName)
+	}
 
 	logger := internal.LoggerFromCtx(ctx)
 	ticker := time.NewTicker(10 * time.Second)
@@ -119,6 +130,7 @@ func (r *CDCStream[T]) AddSchemaDelta(
 	tableNameMapping map[string]NameAndExclude,
 	delta *protos.TableSchemaDelta,
 ) {
+	delta.DroppedColumnPolicy = r.droppedColumnPolicy
 	r.SchemaDeltas = append(r.SchemaDeltas, delta)
 }
 
@@ -90,6 +90,8 @@ type PullRecordsRequest[T Items] struct {
 	InternalVersion uint32
 	// IdleTimeout is the timeout to wait for new records.
 	IdleTimeout time.Duration
+	// skip changes written under a replication origin, see FlowConnectionConfigs.bidirectional
+	Bidirectional bool
 }
 
 type ToJSONOptions struct {
@@ -121,11 +123,19 @@ func RecToTablePKey[T Items](
 	tableNameSchemaMapping map[string]*protos.TableSchema,
 	rec Record[T],
 ) (TableWithPkey, error) {
-	tableName := rec.GetDestinationTableName()
+	return ItemsToTablePKey(tableNameSchemaMapping, rec.GetDestinationTableName(), rec.GetItems())
+}
+
+// ItemsToTablePKey is RecToTablePKey for a row of tableName that is not wrapped in a record
+func ItemsToTablePKey(
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+	tableName string,
+	items Items,
+) (TableWithPkey, error) {
 	hasher := sha256.New()
 
 	for _, pkeyCol := range tableNameSchemaMapping[tableName].PrimaryKeyColumns {
-		pkeyColBytes, err := rec.GetItems().GetBytesByColName(pkeyCol)
+		pkeyColBytes, err := items.GetBytesByColName(pkeyCol)
 		if err != nil {
 			return TableWithPkey{}, fmt.Errorf("error getting primary key column '%s' value for table '%s': %w", pkeyCol, tableName, err)
 		}
@@ -152,10 +162,14 @@ type SyncRecordsRequest[T Items] struct {
 	StagingPath string
 	// Lua script
 	Script string
+	// how queue destinations encode records
+	QueueEncoding protos.QueueEncoding
 	// source:destination mappings
-	TableMappings []*protos.TableMapping
-	SyncBatchID   int64
-	Version       uint32
+	TableMappings      []*protos.TableMapping
+	SyncBatchID        int64
+	Version            uint32
+	Bidirectional      bool
+	ConflictResolution protos.ConflictResolution
 }
 
 type NormalizeRecordsRequest struct {
@@ -167,6 +181,9 @@ type NormalizeRecordsRequest struct {
 	TableMappings          []*protos.TableMapping
 	SyncBatchID            int64
 	Version                uint32
+	TruncatePolicy         protos.TruncatePolicy
+	Bidirectional          bool
+	ConflictResolution     protos.ConflictResolution
 }
 
 //nolint:govet // no need to save on fieldalignment
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 )
 
 func TestCdcStreamGetLastCheckpointPanic(t *testing.T) {
@@ -27,6 +29,21 @@ func TestCdcStreamAddRecordCancellation(t *testing.T) {
 		stream.AddRecord(ctx, &MessageRecord[RecordItems]{Prefix: "prefix", Content: "content"}))
 }
 
+func TestCdcStreamRelationRecord(t *testing.T) {
+	stream := NewCDCStream[RecordItems](1)
+	stream.SetDroppedColumnPolicy(protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP)
+	delta := &protos.TableSchemaDelta{DstTableName: "t", DroppedColumns: []*protos.FieldDescription{{Name: "c"}}}
+	stream.AddSchemaDelta(nil, delta)
+	require.NoError(t, stream.AddRecord(t.Context(), &RelationRecord[RecordItems]{TableSchemaDelta: delta}))
+
+	// the record in the stream carries the delta synced at the end of the batch, policy included
+	relation, ok := (<-stream.GetRecords()).(*RelationRecord[RecordItems])
+	require.True(t, ok)
+	require.Same(t, stream.SchemaDeltas[0], relation.TableSchemaDelta)
+	require.Equal(t, protos.DroppedColumnPolicy_DROPPED_COLUMN_POLICY_DROP, relation.TableSchemaDelta.DroppedColumnPolicy)
+	require.False(t, stream.NeedsNormalize())
+}
+
 func TestJsonOptionsUnnestCols(t *testing.T) {
 	opts := NewToJSONOptions([]string{"column"}, false)
 	_, ok1 := opts.UnnestColumns["column"]
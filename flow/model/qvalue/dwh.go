@@ -18,6 +18,10 @@ func DetermineNumericSettingForDWH(precision int16, scale int16, dwh protos.DBTy
 		warehouseNumeric = datatypes.SnowflakeNumericCompatibility{}
 	case protos.DBType_BIGQUERY:
 		warehouseNumeric = datatypes.BigQueryNumericCompatibility{}
+	case protos.DBType_MYSQL:
+		warehouseNumeric = datatypes.MySqlNumericCompatibility{}
+	case protos.DBType_DUCKDB:
+		warehouseNumeric = datatypes.DuckDBNumericCompatibility{}
 	default:
 		warehouseNumeric = datatypes.DefaultNumericCompatibility{}
 	}
@@ -100,6 +100,30 @@ func ToDWHColumnType(
 				colType = fmt.Sprintf("Nullable(%s)", colType)
 			}
 		}
+	case protos.DBType_MYSQL:
+		if kind == types.QValueKindNumeric {
+			precision, scale := datatypes.GetNumericTypeForWarehouse(column.TypeModifier, datatypes.MySqlNumericCompatibility{})
+			colType = fmt.Sprintf("DECIMAL(%d,%d)", precision, scale)
+		} else if val, ok := types.QValueKindToMySqlTypeMap[kind]; ok {
+			colType = val
+		} else {
+			colType = "LONGTEXT"
+		}
+		if nullableEnabled && !column.Nullable {
+			colType += " NOT NULL"
+		}
+	case protos.DBType_DUCKDB:
+		if kind == types.QValueKindNumeric {
+			precision, scale := datatypes.GetNumericTypeForWarehouse(column.TypeModifier, datatypes.DuckDBNumericCompatibility{})
+			colType = fmt.Sprintf("DECIMAL(%d,%d)", precision, scale)
+		} else if val, ok := types.QValueKindToDuckDBTypeMap[kind]; ok {
+			colType = val
+		} else {
+			colType = "VARCHAR"
+		}
+		if nullableEnabled && !column.Nullable {
+			colType += " NOT NULL"
+		}
 	default:
 		return "", fmt.Errorf("unknown dwh type: %v", dwhType)
 	}
@@ -165,6 +165,35 @@ func (r *RelationRecord[T]) GetItems() T {
 func (r *RelationRecord[T]) PopulateCountMap(mapOfCounts map[string]*RecordTypeCounts) {
 }
 
+// TruncateRecord marks all rows of a source table as removed at this point of the stream
+type TruncateRecord[T Items] struct {
+	// Name of the source table
+	SourceTableName string
+	// Name of the destination table
+	DestinationTableName string
+	BaseRecord
+}
+
+func (*TruncateRecord[T]) Kind() string {
+	return "truncate"
+}
+
+func (r *TruncateRecord[T]) GetDestinationTableName() string {
+	return r.DestinationTableName
+}
+
+func (r *TruncateRecord[T]) GetSourceTableName() string {
+	return r.SourceTableName
+}
+
+func (r *TruncateRecord[T]) GetItems() T {
+	var none T
+	return none
+}
+
+func (r *TruncateRecord[T]) PopulateCountMap(mapOfCounts map[string]*RecordTypeCounts) {
+}
+
 type MessageRecord[T Items] struct {
 	Prefix  string
 	Content string
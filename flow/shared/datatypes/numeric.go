@@ -5,6 +5,8 @@ const (
 	PeerDBBigQueryScale   = 20
 	PeerDBSnowflakeScale  = 20
 	PeerDBClickHouseScale = 38
+	PeerDBMySqlScale      = 30
+	PeerDBDuckDBScale     = 20
 
 	PeerDBClickHouseMaxPrecision = 76
 	VARHDRSZ                     = 4
@@ -58,6 +60,34 @@ func (b BigQueryNumericCompatibility) DefaultPrecisionAndScale() (int16, int16)
 	return b.MaxPrecision(), PeerDBBigQueryScale
 }
 
+type MySqlNumericCompatibility struct{}
+
+func (MySqlNumericCompatibility) MaxPrecision() int16 {
+	return 65
+}
+
+func (MySqlNumericCompatibility) MaxScale() int16 {
+	return 30
+}
+
+func (m MySqlNumericCompatibility) DefaultPrecisionAndScale() (int16, int16) {
+	return m.MaxPrecision(), PeerDBMySqlScale
+}
+
+type DuckDBNumericCompatibility struct{}
+
+func (DuckDBNumericCompatibility) MaxPrecision() int16 {
+	return 38
+}
+
+func (DuckDBNumericCompatibility) MaxScale() int16 {
+	return 38
+}
+
+func (d DuckDBNumericCompatibility) DefaultPrecisionAndScale() (int16, int16) {
+	return d.MaxPrecision(), PeerDBDuckDBScale
+}
+
 type DefaultNumericCompatibility struct{}
 
 func (DefaultNumericCompatibility) MaxPrecision() int16 {
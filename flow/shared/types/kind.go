@@ -1,6 +1,7 @@
 package types
 
 import (
+	"slices"
 	"strings"
 )
 
@@ -68,6 +69,29 @@ func (kind QValueKind) IsArray() bool {
 	return strings.HasPrefix(string(kind), "array_")
 }
 
+// kinds each kind can be converted to without losing values
+var qValueKindWidenings = map[QValueKind][]QValueKind{
+	QValueKindInt8:         {QValueKindInt16, QValueKindInt32, QValueKindInt64, QValueKindNumeric},
+	QValueKindInt16:        {QValueKindInt32, QValueKindInt64, QValueKindNumeric},
+	QValueKindInt32:        {QValueKindInt64, QValueKindNumeric},
+	QValueKindInt64:        {QValueKindNumeric},
+	QValueKindUInt8:        {QValueKindUInt16, QValueKindUInt32, QValueKindUInt64, QValueKindInt16, QValueKindInt32, QValueKindInt64},
+	QValueKindUInt16:       {QValueKindUInt32, QValueKindUInt64, QValueKindInt32, QValueKindInt64},
+	QValueKindUInt32:       {QValueKindUInt64, QValueKindInt64},
+	QValueKindFloat32:      {QValueKindFloat64},
+	QValueKindDate:         {QValueKindTimestamp},
+	QValueKindQChar:        {QValueKindString},
+	QValueKindEnum:         {QValueKindString},
+	QValueKindArrayInt16:   {QValueKindArrayInt32, QValueKindArrayInt64},
+	QValueKindArrayInt32:   {QValueKindArrayInt64},
+	QValueKindArrayFloat32: {QValueKindArrayFloat64},
+}
+
+// CanWidenTo reports whether existing values of kind can be converted to target in place
+func (kind QValueKind) CanWidenTo(target QValueKind) bool {
+	return kind == target || slices.Contains(qValueKindWidenings[kind], target)
+}
+
 var QValueKindToSnowflakeTypeMap = map[QValueKind]string{
 	QValueKindBoolean:     "BOOLEAN",
 	QValueKindInt8:        "INTEGER",
@@ -162,3 +186,99 @@ var QValueKindToClickHouseTypeMap = map[QValueKind]string{
 	QValueKindArrayJSONB:       "String",
 	QValueKindArrayUUID:        "Array(UUID)",
 }
+
+var QValueKindToMySqlTypeMap = map[QValueKind]string{
+	QValueKindBoolean:     "BOOLEAN",
+	QValueKindInt8:        "TINYINT",
+	QValueKindInt16:       "SMALLINT",
+	QValueKindInt32:       "INT",
+	QValueKindInt64:       "BIGINT",
+	QValueKindUInt8:       "TINYINT UNSIGNED",
+	QValueKindUInt16:      "SMALLINT UNSIGNED",
+	QValueKindUInt32:      "INT UNSIGNED",
+	QValueKindUInt64:      "BIGINT UNSIGNED",
+	QValueKindInt256:      "DECIMAL(65,0)",
+	QValueKindUInt256:     "DECIMAL(65,0)",
+	QValueKindFloat32:     "FLOAT",
+	QValueKindFloat64:     "DOUBLE",
+	QValueKindQChar:       "CHAR(1)",
+	QValueKindString:      "LONGTEXT",
+	QValueKindEnum:        "LONGTEXT",
+	QValueKindJSON:        "JSON",
+	QValueKindJSONB:       "JSON",
+	QValueKindHStore:      "JSON",
+	QValueKindTimestamp:   "DATETIME(6)",
+	QValueKindTimestampTZ: "DATETIME(6)",
+	QValueKindTime:        "TIME(6)",
+	QValueKindTimeTZ:      "TIME(6)",
+	QValueKindDate:        "DATE",
+	QValueKindBytes:       "LONGBLOB",
+	QValueKindUUID:        "CHAR(36)",
+	QValueKindInvalid:     "LONGTEXT",
+
+	// array types will be mapped to JSON
+	QValueKindArrayFloat32:     "JSON",
+	QValueKindArrayFloat64:     "JSON",
+	QValueKindArrayInt16:       "JSON",
+	QValueKindArrayInt32:       "JSON",
+	QValueKindArrayInt64:       "JSON",
+	QValueKindArrayString:      "JSON",
+	QValueKindArrayEnum:        "JSON",
+	QValueKindArrayDate:        "JSON",
+	QValueKindArrayInterval:    "JSON",
+	QValueKindArrayTimestamp:   "JSON",
+	QValueKindArrayTimestampTZ: "JSON",
+	QValueKindArrayBoolean:     "JSON",
+	QValueKindArrayJSON:        "JSON",
+	QValueKindArrayJSONB:       "JSON",
+	QValueKindArrayUUID:        "JSON",
+	QValueKindArrayNumeric:     "JSON",
+}
+
+var QValueKindToDuckDBTypeMap = map[QValueKind]string{
+	QValueKindBoolean:     "BOOLEAN",
+	QValueKindInt8:        "TINYINT",
+	QValueKindInt16:       "SMALLINT",
+	QValueKindInt32:       "INTEGER",
+	QValueKindInt64:       "BIGINT",
+	QValueKindInt256:      "VARCHAR",
+	QValueKindUInt8:       "UTINYINT",
+	QValueKindUInt16:      "USMALLINT",
+	QValueKindUInt32:      "UINTEGER",
+	QValueKindUInt64:      "UBIGINT",
+	QValueKindUInt256:     "VARCHAR",
+	QValueKindFloat32:     "FLOAT",
+	QValueKindFloat64:     "DOUBLE",
+	QValueKindQChar:       "VARCHAR",
+	QValueKindString:      "VARCHAR",
+	QValueKindEnum:        "VARCHAR",
+	QValueKindJSON:        "JSON",
+	QValueKindJSONB:       "JSON",
+	QValueKindHStore:      "JSON",
+	QValueKindTimestamp:   "TIMESTAMP",
+	QValueKindTimestampTZ: "TIMESTAMPTZ",
+	QValueKindTime:        "TIME",
+	QValueKindTimeTZ:      "TIME",
+	QValueKindDate:        "DATE",
+	QValueKindInterval:    "JSON",
+	QValueKindBytes:       "BLOB",
+	QValueKindUUID:        "UUID",
+	QValueKindInvalid:     "VARCHAR",
+
+	QValueKindArrayFloat32:     "FLOAT[]",
+	QValueKindArrayFloat64:     "DOUBLE[]",
+	QValueKindArrayInt16:       "SMALLINT[]",
+	QValueKindArrayInt32:       "INTEGER[]",
+	QValueKindArrayInt64:       "BIGINT[]",
+	QValueKindArrayString:      "VARCHAR[]",
+	QValueKindArrayEnum:        "VARCHAR[]",
+	QValueKindArrayDate:        "DATE[]",
+	QValueKindArrayInterval:    "JSON",
+	QValueKindArrayTimestamp:   "TIMESTAMP[]",
+	QValueKindArrayTimestampTZ: "TIMESTAMPTZ[]",
+	QValueKindArrayBoolean:     "BOOLEAN[]",
+	QValueKindArrayJSON:        "JSON",
+	QValueKindArrayJSONB:       "JSON",
+	QValueKindArrayUUID:        "UUID[]",
+	QValueKindArrayNumeric:     "VARCHAR[]",
+}
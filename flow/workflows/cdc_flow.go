@@ -282,7 +282,8 @@ func processTableAdditions(
 		}
 	})
 
-	// execute the sync flow as a child workflow
+	// execute the sync flow as a child workflow,
+	// or only set up tables when they are snapshotted incrementally by this flow's cdc
 	childAddTablesCDCFlowOpts := workflow.ChildWorkflowOptions{
 		WorkflowID:        childAdditionalTablesCDCFlowID,
 		ParentClosePolicy: enums.PARENT_CLOSE_POLICY_REQUEST_CANCEL,
@@ -292,20 +293,45 @@ func processTableAdditions(
 		TypedSearchAttributes: mirrorNameSearch,
 		WaitForCancellation:   true,
 	}
-	childAddTablesCDCFlowCtx := workflow.WithChildOptions(ctx, childAddTablesCDCFlowOpts)
-	childAddTablesCDCFlowFuture := workflow.ExecuteChildWorkflow(
-		childAddTablesCDCFlowCtx,
-		CDCFlowWorkflow,
-		additionalTablesCfg,
-		nil,
-	)
-	var res *CDCFlowWorkflowResult
+	var srcTableIdNameMapping map[uint32]string
 	var addTablesFlowErr error
-	addTablesSelector.AddFuture(childAddTablesCDCFlowFuture, func(f workflow.Future) {
-		addTablesFlowErr = f.Get(childAddTablesCDCFlowCtx, &res)
-	})
+	var finished bool
+	if flowConfigUpdate.IncrementalSnapshot {
+		additionalTablesCfg.DoInitialSnapshot = false
+		childAddTablesCDCFlowOpts.WorkflowID = GetChildWorkflowID("additional-setup-flow", cfg.FlowJobName, additionalTablesUUID)
+		childAddTablesSetupFlowCtx := workflow.WithChildOptions(ctx, childAddTablesCDCFlowOpts)
+		childAddTablesSetupFlowFuture := workflow.ExecuteChildWorkflow(
+			childAddTablesSetupFlowCtx,
+			SetupFlowWorkflow,
+			additionalTablesCfg,
+		)
+		addTablesSelector.AddFuture(childAddTablesSetupFlowFuture, func(f workflow.Future) {
+			var res *protos.SetupFlowOutput
+			addTablesFlowErr = f.Get(childAddTablesSetupFlowCtx, &res)
+			if addTablesFlowErr == nil {
+				srcTableIdNameMapping = res.SrcTableIdNameMapping
+			}
+			finished = true
+		})
+	} else {
+		childAddTablesCDCFlowCtx := workflow.WithChildOptions(ctx, childAddTablesCDCFlowOpts)
+		childAddTablesCDCFlowFuture := workflow.ExecuteChildWorkflow(
+			childAddTablesCDCFlowCtx,
+			CDCFlowWorkflow,
+			additionalTablesCfg,
+			nil,
+		)
+		addTablesSelector.AddFuture(childAddTablesCDCFlowFuture, func(f workflow.Future) {
+			var res *CDCFlowWorkflowResult
+			addTablesFlowErr = f.Get(childAddTablesCDCFlowCtx, &res)
+			if addTablesFlowErr == nil {
+				srcTableIdNameMapping = res.SyncFlowOptions.SrcTableIdNameMapping
+			}
+			finished = true
+		})
+	}
 
-	for res == nil {
+	for !finished {
 		addTablesSelector.Select(ctx)
 		if state.ActiveSignal == model.TerminateSignal || state.ActiveSignal == model.ResyncSignal {
 			if state.ActiveSignal == model.ResyncSignal {
@@ -327,7 +353,21 @@ func processTableAdditions(
 		}
 	}
 
-	maps.Copy(state.SyncFlowOptions.SrcTableIdNameMapping, res.SyncFlowOptions.SrcTableIdNameMapping)
+	if flowConfigUpdate.IncrementalSnapshot {
+		addIncrementalSnapshotsCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+			StartToCloseTimeout: 5 * time.Minute,
+		})
+		if err := workflow.ExecuteActivity(
+			addIncrementalSnapshotsCtx,
+			flowable.AddIncrementalSnapshots,
+			cfg, flowConfigUpdate.AdditionalTables,
+		).Get(ctx, nil); err != nil {
+			logger.Error("failed to add incremental snapshots for additional tables", slog.Any("error", err))
+			return err
+		}
+	}
+
+	maps.Copy(state.SyncFlowOptions.SrcTableIdNameMapping, srcTableIdNameMapping)
 
 	state.SyncFlowOptions.TableMappings = append(state.SyncFlowOptions.TableMappings, flowConfigUpdate.AdditionalTables...)
 	logger.Info("additional tables added to sync flow")
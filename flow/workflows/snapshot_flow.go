@@ -46,8 +46,12 @@ func (s *SnapshotFlowExecution) setupReplication(
 	})
 
 	tblNameMapping := make(map[string]string, len(s.config.TableMappings))
+	rowFilters := make(map[string]string)
 	for _, v := range s.config.TableMappings {
 		tblNameMapping[v.SourceTableIdentifier] = v.DestinationTableIdentifier
+		if v.RowFilter != "" {
+			rowFilters[v.SourceTableIdentifier] = v.RowFilter
+		}
 	}
 
 	setupReplicationInput := &protos.SetupReplicationInput{
@@ -58,6 +62,7 @@ func (s *SnapshotFlowExecution) setupReplication(
 		ExistingPublicationName:     s.config.PublicationName,
 		ExistingReplicationSlotName: s.config.ReplicationSlotName,
 		Env:                         s.config.Env,
+		RowFilters:                  rowFilters,
 	}
 
 	res := &protos.SetupReplicationOutput{}
@@ -162,18 +167,41 @@ func (s *SnapshotFlowExecution) cloneTable(
 	// usually MySQL supports double quotes with ANSI_QUOTES, but Vitess doesn't
 	// Vitess currently only supports initial load so change here is enough
 	srcTableEscaped := parsedSrcTable.String()
-	if dbtype, err := getPeerType(ctx, s.config.SourceName); err != nil {
+	srcType, err := getPeerType(ctx, s.config.SourceName)
+	if err != nil {
 		return err
-	} else if dbtype == protos.DBType_MYSQL {
+	} else if srcType == protos.DBType_MYSQL {
 		srcTableEscaped = parsedSrcTable.MySQL()
 	}
 
+	// sources queried with SQL filter in the query, other sources filter pulled rows themselves
+	var rowFilter string
+	var whereRowFilter string
+	if mapping.RowFilter != "" {
+		switch srcType {
+		case protos.DBType_POSTGRES, protos.DBType_MYSQL, protos.DBType_SQLSERVER:
+			rowFilterSQL, err := utils.RowFilterSQL(mapping.RowFilter, srcType)
+			if err != nil {
+				return temporal.NewNonRetryableApplicationError(err.Error(), "rowFilter", err)
+			}
+			whereRowFilter = "(" + rowFilterSQL + ")"
+		default:
+			rowFilter = mapping.RowFilter
+		}
+	}
+
 	var query string
 	if mapping.PartitionKey == "" {
 		query = fmt.Sprintf("SELECT %s FROM %s", from, srcTableEscaped)
+		if whereRowFilter != "" {
+			query += " WHERE " + whereRowFilter
+		}
 	} else {
 		query = fmt.Sprintf("SELECT %s FROM %s WHERE %s BETWEEN {{.start}} AND {{.end}}",
 			from, srcTableEscaped, mapping.PartitionKey)
+		if whereRowFilter != "" {
+			query += " AND " + whereRowFilter
+		}
 	}
 
 	numWorkers := uint32(8)
@@ -194,7 +222,7 @@ func (s *SnapshotFlowExecution) cloneTable(
 	// for the same document
 	if dbtype, err := getPeerType(ctx, s.config.DestinationName); err != nil {
 		return err
-	} else if dbtype == protos.DBType_ELASTICSEARCH {
+	} else if dbtype == protos.DBType_ELASTICSEARCH || dbtype == protos.DBType_MONGO {
 		if err := initTableSchema(); err != nil {
 			return err
 		}
@@ -222,11 +250,13 @@ func (s *SnapshotFlowExecution) cloneTable(
 		WriteMode:                  snapshotWriteMode,
 		System:                     s.config.System,
 		Script:                     s.config.Script,
+		QueueEncoding:              s.config.QueueEncoding,
 		Env:                        s.config.Env,
 		ParentMirrorName:           flowName,
 		Exclude:                    mapping.Exclude,
 		Columns:                    mapping.Columns,
 		Version:                    s.config.Version,
+		RowFilter:                  rowFilter,
 	}
 
 	boundSelector.SpawnChild(childCtx, QRepFlowWorkflow, nil, config, nil)
@@ -327,6 +327,32 @@ impl StatementAnalyzer for PeerDDLAnalyzer {
                                 _ => false,
                             };
 
+                        let queue_encoding = match raw_options.remove("queue_encoding") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => s.clone(),
+                            _ => String::new(),
+                        };
+
+                        let truncate_policy = match raw_options.remove("truncate_policy") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => s.clone(),
+                            _ => String::new(),
+                        };
+
+                        let dropped_column_policy =
+                            match raw_options.remove("dropped_column_policy") {
+                                Some(Expr::Value(ast::Value::SingleQuotedString(s))) => s.clone(),
+                                _ => String::new(),
+                            };
+
+                        let bidirectional = match raw_options.remove("bidirectional") {
+                            Some(Expr::Value(ast::Value::Boolean(b))) => *b,
+                            _ => false,
+                        };
+
+                        let conflict_resolution = match raw_options.remove("conflict_resolution") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => s.clone(),
+                            _ => String::new(),
+                        };
+
                         let flow_job = FlowJob {
                             name: cdc.mirror_name.to_string().to_lowercase(),
                             source_peer: cdc.source_peer.to_string().to_lowercase(),
@@ -349,6 +375,11 @@ impl StatementAnalyzer for PeerDDLAnalyzer {
                             script,
                             system,
                             disable_peerdb_columns,
+                            queue_encoding,
+                            truncate_policy,
+                            dropped_column_policy,
+                            bidirectional,
+                            conflict_resolution,
                         };
 
                         if initial_copy_only && !do_initial_copy {
@@ -715,6 +746,14 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     .unwrap_or_default(),
                 auth_type: PostgresAuthType::PostgresPassword.into(),
                 aws_auth: None,
+                failover_hosts: opts
+                    .get("failover_hosts")
+                    .map(|s| s.split(',').map(|h| h.trim().to_string()).collect())
+                    .unwrap_or_default(),
+                failover_slots: opts
+                    .get("failover_slots")
+                    .map(|s| s.parse::<bool>().unwrap_or_default())
+                    .unwrap_or_default(),
             };
 
             Config::PostgresConfig(postgres_config)
@@ -740,6 +779,29 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     .and_then(|s| pt::peerdb_peers::AvroCodec::from_str_name(s))
                     .map(|codec| codec.into())
                     .unwrap_or_default(),
+                format: opts
+                    .get("format")
+                    .and_then(|s| match s.to_ascii_lowercase().as_str() {
+                        "avro" => Some(pt::peerdb_peers::S3Format::Avro),
+                        "parquet" => Some(pt::peerdb_peers::S3Format::Parquet),
+                        "ndjson" => Some(pt::peerdb_peers::S3Format::Ndjson),
+                        _ => pt::peerdb_peers::S3Format::from_str_name(s),
+                    })
+                    .map(|format| format.into())
+                    .unwrap_or_default(),
+                layout: opts
+                    .get("layout")
+                    .and_then(|s| match s.to_ascii_lowercase().as_str() {
+                        "raw" => Some(pt::peerdb_peers::S3Layout::Raw),
+                        "hive" => Some(pt::peerdb_peers::S3Layout::Hive),
+                        _ => pt::peerdb_peers::S3Layout::from_str_name(s),
+                    })
+                    .map(|layout| layout.into())
+                    .unwrap_or_default(),
+                partition_column: opts
+                    .get("partition_column")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
             };
             Config::S3Config(s3_config)
         }
@@ -852,6 +914,31 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     .get("disable_tls")
                     .and_then(|s| s.parse::<bool>().ok())
                     .unwrap_or_default(),
+                schema_registry_url: opts
+                    .get("schema_registry_url")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                schema_registry_username: opts
+                    .get("schema_registry_user")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                schema_registry_password: opts
+                    .get("schema_registry_password")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                schema_format: opts
+                    .get("schema_format")
+                    .and_then(|s| match s.to_ascii_lowercase().as_str() {
+                        "avro" => Some(pt::peerdb_peers::KafkaSchemaFormat::Avro),
+                        "protobuf" => Some(pt::peerdb_peers::KafkaSchemaFormat::Protobuf),
+                        _ => pt::peerdb_peers::KafkaSchemaFormat::from_str_name(s),
+                    })
+                    .map(|format| format.into())
+                    .unwrap_or_default(),
+                exactly_once: opts
+                    .get("exactly_once")
+                    .and_then(|s| s.parse::<bool>().ok())
+                    .unwrap_or_default(),
             };
             Config::KafkaConfig(kafka_config)
         }
@@ -954,6 +1041,18 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                 })
                 .ok_or_else(|| anyhow::anyhow!("missing connection addresses for Elasticsearch"))?;
 
+            let flavor = match opts.get("flavor").map(|s| s.to_lowercase()).as_deref() {
+                None | Some("elasticsearch") => {
+                    pt::peerdb_peers::ElasticsearchFlavor::Elasticsearch
+                }
+                Some("opensearch") => pt::peerdb_peers::ElasticsearchFlavor::Opensearch,
+                Some(flavor) => {
+                    return Err(anyhow::anyhow!(
+                        "unsupported flavor {flavor}, expected elasticsearch or opensearch"
+                    ));
+                }
+            };
+
             // either basic auth or API key auth, not both
             let api_key = opts.get("api_key").map(|s| s.to_string());
             let username = opts.get("username").map(|s| s.to_string());
@@ -970,6 +1069,7 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     username: None,
                     password: None,
                     api_key,
+                    flavor: flavor.into(),
                 })
             } else if username.is_some() && password.is_some() {
                 Config::ElasticsearchConfig(pt::peerdb_peers::ElasticsearchConfig {
@@ -978,6 +1078,7 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     username,
                     password,
                     api_key: None,
+                    flavor: flavor.into(),
                 })
             } else {
                 Config::ElasticsearchConfig(pt::peerdb_peers::ElasticsearchConfig {
@@ -986,6 +1087,7 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     username: None,
                     password: None,
                     api_key: None,
+                    flavor: flavor.into(),
                 })
             }
         }
@@ -1044,5 +1146,20 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
             .into(),
             aws_auth: None,
         }),
+        DbType::Iceberg => {
+            anyhow::bail!("Iceberg peers can only be created through the UI or API")
+        }
+        DbType::Nats => {
+            anyhow::bail!("NATS peers can only be created through the UI or API")
+        }
+        DbType::Redis => {
+            anyhow::bail!("Redis peers can only be created through the UI or API")
+        }
+        DbType::Webhook => {
+            anyhow::bail!("Webhook peers can only be created through the UI or API")
+        }
+        DbType::Duckdb => {
+            anyhow::bail!("DuckDB peers can only be created through the UI or API")
+        }
     }))
 }
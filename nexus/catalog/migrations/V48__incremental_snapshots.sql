@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS metadata_incremental_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    job_name TEXT NOT NULL,
+    source_table TEXT NOT NULL,
+    destination_table TEXT NOT NULL,
+    -- rows outside of the filter are not read, empty to read the whole table
+    filter TEXT NOT NULL DEFAULT '',
+    -- inclusive primary key values bounding the rows read, NULL for no bound
+    start_pk TEXT[],
+    end_pk TEXT[],
+    -- truncate the destination table before the first chunk, following the truncate policy of the mirror
+    truncate_destination BOOLEAN NOT NULL DEFAULT false,
+    -- primary key values of the last row synced as read back by the source, NULL before the first chunk
+    last_pk TEXT[],
+    rows_synced BIGINT NOT NULL DEFAULT 0,
+    start_time TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    end_time TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS metadata_incremental_snapshots_job_name_idx
+    ON metadata_incremental_snapshots (job_name);
@@ -97,6 +97,8 @@ impl CatalogConfig<'_> {
             require_tls: false,
             auth_type: PostgresAuthType::PostgresPassword.into(),
             aws_auth: None,
+            failover_hosts: Vec::new(),
+            failover_slots: false,
         }
     }
 
@@ -335,6 +337,31 @@ impl Catalog {
                         pt::peerdb_peers::MySqlConfig::decode(&options[..]).with_context(err)?;
                     Config::MysqlConfig(mysql_config)
                 }
+                DbType::Iceberg => {
+                    let iceberg_config =
+                        pt::peerdb_peers::IcebergConfig::decode(&options[..]).with_context(err)?;
+                    Config::IcebergConfig(iceberg_config)
+                }
+                DbType::Nats => {
+                    let nats_config =
+                        pt::peerdb_peers::NatsConfig::decode(&options[..]).with_context(err)?;
+                    Config::NatsConfig(nats_config)
+                }
+                DbType::Redis => {
+                    let redis_config =
+                        pt::peerdb_peers::RedisConfig::decode(&options[..]).with_context(err)?;
+                    Config::RedisConfig(redis_config)
+                }
+                DbType::Webhook => {
+                    let webhook_config =
+                        pt::peerdb_peers::WebhookConfig::decode(&options[..]).with_context(err)?;
+                    Config::WebhookConfig(webhook_config)
+                }
+                DbType::Duckdb => {
+                    let duckdb_config =
+                        pt::peerdb_peers::DuckDbConfig::decode(&options[..]).with_context(err)?;
+                    Config::DuckdbConfig(duckdb_config)
+                }
             })
         } else {
             None
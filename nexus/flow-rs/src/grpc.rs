@@ -118,6 +118,48 @@ impl FlowGrpcClient {
         let Some(system) = TypeSystem::from_str_name(&job.system) else {
             return anyhow::Result::Err(anyhow::anyhow!("invalid system {}", job.system));
         };
+        let queue_encoding = match job.queue_encoding.to_ascii_lowercase().as_str() {
+            "" | "script" => pt::peerdb_flow::QueueEncoding::Script,
+            "debezium" => pt::peerdb_flow::QueueEncoding::Debezium,
+            _ => {
+                return anyhow::Result::Err(anyhow::anyhow!(
+                    "invalid queue_encoding {}",
+                    job.queue_encoding
+                ));
+            }
+        };
+        let truncate_policy = match job.truncate_policy.to_ascii_lowercase().as_str() {
+            "" | "ignore" => pt::peerdb_flow::TruncatePolicy::Ignore,
+            "apply" => pt::peerdb_flow::TruncatePolicy::Apply,
+            "soft_delete" => pt::peerdb_flow::TruncatePolicy::SoftDelete,
+            _ => {
+                return anyhow::Result::Err(anyhow::anyhow!(
+                    "invalid truncate_policy {}",
+                    job.truncate_policy
+                ));
+            }
+        };
+        let dropped_column_policy = match job.dropped_column_policy.to_ascii_lowercase().as_str() {
+            "" | "keep" => pt::peerdb_flow::DroppedColumnPolicy::Keep,
+            "null" => pt::peerdb_flow::DroppedColumnPolicy::Null,
+            "drop" => pt::peerdb_flow::DroppedColumnPolicy::Drop,
+            _ => {
+                return anyhow::Result::Err(anyhow::anyhow!(
+                    "invalid dropped_column_policy {}",
+                    job.dropped_column_policy
+                ));
+            }
+        };
+        let conflict_resolution = match job.conflict_resolution.to_ascii_lowercase().as_str() {
+            "" | "source_priority" => pt::peerdb_flow::ConflictResolution::SourcePriority,
+            "last_writer_wins" => pt::peerdb_flow::ConflictResolution::LastWriterWins,
+            _ => {
+                return anyhow::Result::Err(anyhow::anyhow!(
+                    "invalid conflict_resolution {}",
+                    job.conflict_resolution
+                ));
+            }
+        };
 
         let mut flow_conn_cfg = pt::peerdb_flow::FlowConnectionConfigs {
             source_name: src,
@@ -145,6 +187,12 @@ impl FlowGrpcClient {
             idle_timeout_seconds: job.sync_interval.unwrap_or_default(),
             env: Default::default(),
             version: 0, // filled in by server
+            truncate_policy: truncate_policy as i32,
+            queue_encoding: queue_encoding as i32,
+            topic_settings: None,
+            dropped_column_policy: dropped_column_policy as i32,
+            bidirectional: job.bidirectional,
+            conflict_resolution: conflict_resolution as i32,
         };
 
         if job.disable_peerdb_columns {
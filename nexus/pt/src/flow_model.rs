@@ -34,6 +34,11 @@ pub struct FlowJob {
     pub script: String,
     pub system: String,
     pub disable_peerdb_columns: bool,
+    pub queue_encoding: String,
+    pub truncate_policy: String,
+    pub dropped_column_policy: String,
+    pub bidirectional: bool,
+    pub conflict_resolution: String,
 }
 
 #[derive(Debug, PartialEq, Eq, Serialize, Deserialize, Clone)]
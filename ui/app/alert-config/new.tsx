@@ -17,9 +17,10 @@ import {
   serviceConfigType,
   serviceTypeSchemaMap,
   slackConfigType,
+  webhookConfigType,
 } from './validation';
 
-export type ServiceType = 'slack' | 'email';
+export type ServiceType = 'slack' | 'email' | 'webhook';
 
 export interface AlertConfigProps {
   id?: number;
@@ -133,6 +134,104 @@ function getEmailProps(
     </>
   );
 }
+function parseHeaders(value: string): Record<string, string> {
+  const headers: Record<string, string> = {};
+  for (const line of value.split('\n')) {
+    const idx = line.indexOf(':');
+    if (idx > 0) {
+      headers[line.slice(0, idx).trim()] = line.slice(idx + 1).trim();
+    }
+  }
+  return headers;
+}
+
+function getWebhookProps(
+  config: webhookConfigType,
+  setConfig: Dispatch<SetStateAction<webhookConfigType>>
+) {
+  return (
+    <>
+      <div>
+        <p>URL</p>
+        <TextField
+          key={'url'}
+          style={{ height: '2.5rem', marginTop: '0.5rem' }}
+          variant='simple'
+          placeholder='https://'
+          value={config.url}
+          onChange={(e) => {
+            setConfig((previous) => ({
+              ...previous,
+              url: e.target.value,
+            }));
+          }}
+        />
+      </div>
+      <div>
+        <p>Headers</p>
+        <Label as='label' style={{ fontSize: 14 }}>
+          One header per line, as Name: value
+        </Label>
+        <textarea
+          key={'headers'}
+          style={{ width: '100%', height: '5rem', marginTop: '0.5rem' }}
+          placeholder='Authorization: Bearer ...'
+          value={Object.entries(config.headers ?? {})
+            .map(([name, value]) => `${name}: ${value}`)
+            .join('\n')}
+          onChange={(e) => {
+            setConfig((previous) => ({
+              ...previous,
+              headers: parseHeaders(e.target.value),
+            }));
+          }}
+        />
+      </div>
+      <div>
+        <p>Signing Secret</p>
+        <Label as='label' style={{ fontSize: 14 }}>
+          If set, requests carry an X-PeerDB-Signature-256 header with the
+          HMAC-SHA256 of the X-PeerDB-Timestamp header and the body
+        </Label>
+        <TextField
+          key={'signing_secret'}
+          style={{ height: '2.5rem', marginTop: '0.5rem' }}
+          variant='simple'
+          type='password'
+          placeholder='optional'
+          value={config.signing_secret}
+          onChange={(e) => {
+            setConfig((previous) => ({
+              ...previous,
+              signing_secret: e.target.value,
+            }));
+          }}
+        />
+      </div>
+      <div>
+        <p>Body Template</p>
+        <Label as='label' style={{ fontSize: 14 }}>
+          Go template with .Title, .Message, .DeploymentUID and .Timestamp, use
+          {' {{json .Message}} '}to quote values. Defaults to a JSON object with
+          all fields
+        </Label>
+        <textarea
+          key={'body_template'}
+          style={{ width: '100%', height: '5rem', marginTop: '0.5rem' }}
+          placeholder='optional'
+          value={config.body_template}
+          onChange={(e) => {
+            setConfig((previous) => ({
+              ...previous,
+              body_template: e.target.value,
+            }));
+          }}
+        />
+      </div>
+    </>
+  );
+}
+
 function getServiceFields<T extends serviceConfigType>(
   serviceType: ServiceType,
   config: T,
@@ -150,6 +249,11 @@ function getServiceFields<T extends serviceConfigType>(
         setConfig as Dispatch<SetStateAction<slackConfigType>>
       );
     }
+    case 'webhook':
+      return getWebhookProps(
+        config as webhookConfigType,
+        setConfig as Dispatch<SetStateAction<webhookConfigType>>
+      );
   }
 }
 
@@ -249,6 +353,10 @@ export function NewConfig(alertProps: AlertConfigProps) {
               value: 'email',
               label: 'Email',
             },
+            {
+              value: 'webhook',
+              label: 'Webhook',
+            },
           ]}
           placeholder='Select provider'
           defaultValue={{
@@ -47,6 +47,7 @@ export default function AlertConfigPage() {
       email_addresses: [''],
       auth_token: '',
       channel_ids: [''],
+      url: '',
       open_connections_alert_threshold: 20,
       slot_lag_mb_alert_threshold: 5000,
     },
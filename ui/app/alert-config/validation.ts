@@ -42,13 +42,27 @@ export const emailServiceConfigSchema = z.intersection(
   })
 );
 
+export const webhookServiceConfigSchema = z.intersection(
+  baseServiceConfigSchema,
+  z.object({
+    url: z.url({
+      protocol: /^https?$/,
+      error: () => 'Webhook URL must be a valid http(s) URL',
+    }),
+    headers: z.record(z.string(), z.string()).optional(),
+    signing_secret: z.string().optional(),
+    body_template: z.string().optional(),
+  })
+);
+
 export const serviceConfigSchema = z.union([
   slackServiceConfigSchema,
   emailServiceConfigSchema,
+  webhookServiceConfigSchema,
 ]);
 export const alertConfigReqSchema = z.object({
   id: z.optional(z.number({ error: () => 'ID must be a valid number' })),
-  serviceType: z.enum(['slack', 'email'], {
+  serviceType: z.enum(['slack', 'email', 'webhook'], {
     error: () => ({ message: 'Invalid service type' }),
   }),
   serviceConfig: serviceConfigSchema,
@@ -59,6 +73,7 @@ export type baseServiceConfigType = z.infer<typeof baseServiceConfigSchema>;
 
 export type slackConfigType = z.infer<typeof slackServiceConfigSchema>;
 export type emailConfigType = z.infer<typeof emailServiceConfigSchema>;
+export type webhookConfigType = z.infer<typeof webhookServiceConfigSchema>;
 
 export type serviceConfigType = z.infer<typeof serviceConfigSchema>;
 
@@ -67,4 +82,5 @@ export type alertConfigType = z.infer<typeof alertConfigReqSchema>;
 export const serviceTypeSchemaMap = {
   slack: slackServiceConfigSchema,
   email: emailServiceConfigSchema,
+  webhook: webhookServiceConfigSchema,
 };
@@ -13,8 +13,9 @@ import {
 } from '@/grpc_generated/route';
 import { Button } from '@/lib/Button';
 import { Label } from '@/lib/Label';
-import { RowWithTextField } from '@/lib/Layout';
+import { RowWithSwitch, RowWithTextField } from '@/lib/Layout';
 import { ProgressCircle } from '@/lib/ProgressCircle';
+import { Switch } from '@/lib/Switch';
 import { TextField } from '@/lib/TextField';
 import { Callout } from '@tremor/react';
 import { useRouter } from 'next/navigation';
@@ -57,6 +58,7 @@ export default function EditMirror({ params: { mirrorId } }: EditMirrorProps) {
     snapshotNumRowsPerPartition: defaultSnapshotNumRowsPerPartition,
     snapshotMaxParallelWorkers: defaultSnapshotMaxParallelWorkers,
     snapshotNumTablesInParallel: defaultSnapshotNumTablesInParallel,
+    incrementalSnapshot: false,
   });
   const { push } = useRouter();
 
@@ -84,6 +86,7 @@ export default function EditMirror({ params: { mirrorId } }: EditMirrorProps) {
       snapshotNumTablesInParallel:
         (res as MirrorStatusResponse).cdcStatus?.config
           ?.snapshotNumTablesInParallel || defaultSnapshotNumTablesInParallel,
+      incrementalSnapshot: false,
     });
   }, [
     mirrorId,
@@ -294,22 +297,48 @@ export default function EditMirror({ params: { mirrorId } }: EditMirrorProps) {
       <Label variant='action' as='label' style={{ marginTop: '1rem' }}>
         Adding Tables
       </Label>
-      {!isNotPaused && rows.some((row) => row.selected) && (
-        <Callout
-          title='Note on adding tables'
-          color={'gray'}
-          style={{ marginTop: '1rem' }}
-        >
-          CDC will be put on hold until initial load for these added tables have
-          been completed.
-          <br></br>
-          The <b>replication slot will grow</b> during this period.
-          <br></br>
-          For custom publications, ensure that the tables are part of the
-          publication you provided. This can be done with ALTER PUBLICATION
-          pubname ADD TABLE table1, table2;
-        </Callout>
-      )}
+      <RowWithSwitch
+        label={<Label>{'Snapshot Added Tables Incrementally'}</Label>}
+        action={
+          <Switch
+            checked={config.incrementalSnapshot}
+            onCheckedChange={(state: boolean) =>
+              setConfig({ ...config, incrementalSnapshot: state })
+            }
+          />
+        }
+      />
+      {!isNotPaused &&
+        config.incrementalSnapshot &&
+        rows.some((row) => row.selected) && (
+          <Callout
+            title='Note on incremental snapshots'
+            color={'gray'}
+            style={{ marginTop: '1rem' }}
+          >
+            CDC resumes right away, added tables are read in primary key chunks
+            alongside changes. Only Postgres 14+ primaries and MySQL sources
+            are supported, and added tables need a primary key.
+          </Callout>
+        )}
+      {!isNotPaused &&
+        !config.incrementalSnapshot &&
+        rows.some((row) => row.selected) && (
+          <Callout
+            title='Note on adding tables'
+            color={'gray'}
+            style={{ marginTop: '1rem' }}
+          >
+            CDC will be put on hold until initial load for these added tables
+            have been completed.
+            <br></br>
+            The <b>replication slot will grow</b> during this period.
+            <br></br>
+            For custom publications, ensure that the tables are part of the
+            publication you provided. This can be done with ALTER PUBLICATION
+            pubname ADD TABLE table1, table2;
+          </Callout>
+        )}
 
       <TablePicker
         sourcePeerName={mirrorState.cdcStatus?.config?.sourceName ?? ''}
@@ -116,6 +116,13 @@ export default function SchemaBox({
     setRows(newRows);
   };
 
+  const updateRowFilter = (source: string, rowFilter: string) => {
+    const newRows = [...rows];
+    const index = newRows.findIndex((row) => row.source === source);
+    newRows[index] = { ...newRows[index], rowFilter };
+    setRows(newRows);
+  };
+
   const updateEngine = (source: string, engine: TableEngine) => {
     const newRows = [...rows];
     const index = newRows.findIndex((row) => row.source === source);
@@ -228,6 +235,7 @@ export default function SchemaBox({
               row.partitionKey = existingRow.partitionKey;
               row.shardingKey = existingRow.shardingKey;
               row.policyName = existingRow.policyName;
+              row.rowFilter = existingRow.rowFilter;
               row.exclude = new Set(existingRow.exclude ?? []);
               row.destination = existingRow.destinationTableIdentifier;
               addTableColumns(row.source);
@@ -403,6 +411,23 @@ export default function SchemaBox({
                           />
                         </div>
 
+                        <div style={{ width: '30%', fontSize: 12 }}>
+                          Row Filter:
+                          <TextField
+                            disabled={row.editingDisabled}
+                            style={{
+                              marginTop: '0.5rem',
+                              cursor: 'pointer',
+                            }}
+                            variant='simple'
+                            placeholder='Enter optional filter, e.g. tenant_id = 42'
+                            value={row.rowFilter}
+                            onChange={(
+                              e: React.ChangeEvent<HTMLInputElement>
+                            ) => updateRowFilter(row.source, e.target.value)}
+                          />
+                        </div>
+
                         {peerType?.toString() ===
                           DBType[DBType.CLICKHOUSE].toString() && (
                           <>
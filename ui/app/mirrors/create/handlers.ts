@@ -36,7 +36,8 @@ export function IsQueuePeer(peerType?: DBType): boolean {
     !!peerType &&
     (peerType === DBType.KAFKA ||
       peerType === DBType.PUBSUB ||
-      peerType === DBType.EVENTHUBS)
+      peerType === DBType.EVENTHUBS ||
+      peerType === DBType.NATS)
   );
 }
 
@@ -188,6 +189,7 @@ export function reformattedTableMapping(
       engine: row.engine,
       shardingKey: row.shardingKey,
       policyName: row.policyName,
+      rowFilter: row.rowFilter,
     }));
 }
 
@@ -222,6 +224,7 @@ export function changesToTablesMapping(
           exclude: Array.from(row.exclude),
           columns: row.columns,
           engine: row.engine,
+          rowFilter: row.rowFilter,
         }) as TableMapping
     );
   return mapping;
@@ -455,6 +458,7 @@ export async function fetchTables(
         engine: TableEngine.CH_ENGINE_REPLACING_MERGE_TREE,
         shardingKey: '',
         policyName: '',
+        rowFilter: '',
       });
     }
   }
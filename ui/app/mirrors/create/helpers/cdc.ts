@@ -1,6 +1,26 @@
-import { TypeSystem } from '@/grpc_generated/flow';
+import {
+  QueueEncoding,
+  TopicSettings,
+  TypeSystem,
+} from '@/grpc_generated/flow';
 import { CDCConfig } from '../../../dto/MirrorsDTO';
 import { AdvancedSettingType, blankCDCSetting, MirrorSetting } from './common';
+
+const withTopicSettings = (
+  curr: CDCConfig,
+  update: Partial<TopicSettings>
+): CDCConfig => ({
+  ...curr,
+  topicSettings: {
+    partitions: 0,
+    replicationFactor: 0,
+    retentionMs: 0,
+    minInsyncReplicas: 0,
+    ...curr.topicSettings,
+    ...update,
+  },
+});
+
 export const cdcSettings: MirrorSetting[] = [
   {
     label: 'Initial Copy',
@@ -177,6 +197,87 @@ export const cdcSettings: MirrorSetting[] = [
     tips: 'Associate PeerDB script with this mirror.',
     advanced: AdvancedSettingType.ALL,
   },
+  {
+    label: 'Debezium Envelope',
+    stateHandler: (value, setter) =>
+      setter(
+        (curr: CDCConfig): CDCConfig => ({
+          ...curr,
+          queueEncoding:
+            value === true
+              ? QueueEncoding.QUEUE_ENCODING_DEBEZIUM
+              : QueueEncoding.QUEUE_ENCODING_SCRIPT,
+        })
+      ),
+    type: 'switch',
+    default: false,
+    tips: 'Publish Debezium-compatible change events to Kafka, Pub/Sub or Event Hubs without a script. Overrides the script.',
+    advanced: AdvancedSettingType.QUEUE,
+  },
+  {
+    label: 'Topic Partitions',
+    stateHandler: (value, setter) =>
+      setter(
+        (curr: CDCConfig): CDCConfig =>
+          withTopicSettings(curr, {
+            partitions: parseInt(value as string, 10) || 0,
+          })
+      ),
+    type: 'number',
+    tips: 'Number of partitions of Kafka topics created by PeerDB. Defaults to the broker setting.',
+    advanced: AdvancedSettingType.QUEUE,
+  },
+  {
+    label: 'Topic Replication Factor',
+    stateHandler: (value, setter) =>
+      setter(
+        (curr: CDCConfig): CDCConfig =>
+          withTopicSettings(curr, {
+            replicationFactor: parseInt(value as string, 10) || 0,
+          })
+      ),
+    type: 'number',
+    tips: 'Replication factor of Kafka topics created by PeerDB. Defaults to the broker setting.',
+    advanced: AdvancedSettingType.QUEUE,
+  },
+  {
+    label: 'Compacted Topics',
+    stateHandler: (value, setter) =>
+      setter(
+        (curr: CDCConfig): CDCConfig =>
+          withTopicSettings(curr, { compact: value === true ? true : undefined })
+      ),
+    type: 'switch',
+    default: false,
+    tips: 'Create Kafka topics with cleanup.policy=compact, keeping the latest record per primary key so new consumers can bootstrap from the topic.',
+    advanced: AdvancedSettingType.QUEUE,
+  },
+  {
+    label: 'Topic Retention (ms)',
+    stateHandler: (value, setter) =>
+      setter(
+        (curr: CDCConfig): CDCConfig =>
+          withTopicSettings(curr, {
+            retentionMs: parseInt(value as string, 10) || 0,
+          })
+      ),
+    type: 'number',
+    tips: 'retention.ms of Kafka topics created by PeerDB, -1 retains records forever. Defaults to the broker setting.',
+    advanced: AdvancedSettingType.QUEUE,
+  },
+  {
+    label: 'Topic Min In-Sync Replicas',
+    stateHandler: (value, setter) =>
+      setter(
+        (curr: CDCConfig): CDCConfig =>
+          withTopicSettings(curr, {
+            minInsyncReplicas: parseInt(value as string, 10) || 0,
+          })
+      ),
+    type: 'number',
+    tips: 'min.insync.replicas of Kafka topics created by PeerDB. Defaults to the broker setting.',
+    advanced: AdvancedSettingType.QUEUE,
+  },
   {
     label: 'Use Postgres type system',
     stateHandler: (value, setter) =>
@@ -1,5 +1,5 @@
 import { CDCConfig } from '@/app/dto/MirrorsDTO';
-import { QRepConfig, TypeSystem } from '@/grpc_generated/flow';
+import { QRepConfig, QueueEncoding, TypeSystem } from '@/grpc_generated/flow';
 
 export enum AdvancedSettingType {
   QUEUE = 'queue',
@@ -40,6 +40,7 @@ export const blankCDCSetting: CDCConfig = {
   initialSnapshotOnly: false,
   idleTimeoutSeconds: 60,
   script: '',
+  queueEncoding: QueueEncoding.QUEUE_ENCODING_SCRIPT,
   system: TypeSystem.Q,
   disablePeerDBColumns: false,
   env: {},
@@ -67,6 +68,7 @@ export const blankQRepSetting: QRepConfig = {
   softDeleteColName: '_PEERDB_IS_DELETED',
   syncedAtColName: '',
   script: '',
+  queueEncoding: QueueEncoding.QUEUE_ENCODING_SCRIPT,
   system: TypeSystem.Q,
   env: {},
   version: 0,
@@ -2,6 +2,8 @@ import {
   ElasticsearchAuthType,
   elasticsearchAuthTypeFromJSON,
   ElasticsearchConfig,
+  ElasticsearchFlavor,
+  elasticsearchFlavorFromJSON,
 } from '@/grpc_generated/peers';
 import { PeerSetting } from './common';
 
@@ -11,6 +13,20 @@ export const esSetting: PeerSetting[] = [
     stateHandler: (value, setter) =>
       setter((curr) => ({ ...curr, addresses: (value as string).split(',') })),
   },
+  {
+    label: 'Flavor',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({
+        ...curr,
+        flavor: elasticsearchFlavorFromJSON(value),
+      })),
+    type: 'select',
+    placeholder: 'Elasticsearch',
+    options: [
+      { value: 'ELASTICSEARCH_FLAVOR_ELASTICSEARCH', label: 'Elasticsearch' },
+      { value: 'ELASTICSEARCH_FLAVOR_OPENSEARCH', label: 'OpenSearch' },
+    ],
+  },
   {
     label: 'Authentication type',
     stateHandler: (value, setter) =>
@@ -55,4 +71,5 @@ export const blankElasticsearchSetting: ElasticsearchConfig = {
   username: '',
   password: '',
   apiKey: '',
+  flavor: ElasticsearchFlavor.ELASTICSEARCH_FLAVOR_ELASTICSEARCH,
 };
@@ -1,4 +1,8 @@
-import { KafkaConfig } from '@/grpc_generated/peers';
+import {
+  KafkaConfig,
+  KafkaSchemaFormat,
+  kafkaSchemaFormatFromJSON,
+} from '@/grpc_generated/peers';
 import { PeerSetting } from './common';
 
 export const kaSetting: PeerSetting[] = [
@@ -61,6 +65,54 @@ export const kaSetting: PeerSetting[] = [
     tips: 'If you are using a non-TLS connection for Kafka server, check this box.',
     optional: true,
   },
+  {
+    label: 'Exactly Once?',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, exactlyOnce: value as boolean })),
+    type: 'switch',
+    tips: 'Produce each CDC batch in a transaction together with its checkpoint. Consumers must read with isolation.level=read_committed.',
+    helpfulLink:
+      'https://pkg.go.dev/github.com/twmb/franz-go/pkg/kgo#TransactionalID',
+    optional: true,
+  },
+  {
+    label: 'Schema Registry URL',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, schemaRegistryUrl: value as string })),
+    tips: 'When set, rows are serialized with schemas registered per topic instead of by the mirror script.',
+    helpfulLink:
+      'https://docs.confluent.io/platform/current/schema-registry/fundamentals/serdes-develop/index.html#wire-format',
+    optional: true,
+  },
+  {
+    label: 'Schema Registry Username',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, schemaRegistryUsername: value as string })),
+    optional: true,
+  },
+  {
+    label: 'Schema Registry Password',
+    type: 'password',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, schemaRegistryPassword: value as string })),
+    optional: true,
+  },
+  {
+    label: 'Schema Format',
+    field: 'schemaFormat',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({
+        ...curr,
+        schemaFormat: kafkaSchemaFormatFromJSON(value),
+      })),
+    type: 'select',
+    placeholder: 'Select schema format',
+    options: [
+      { value: 'KAFKA_SCHEMA_FORMAT_AVRO', label: 'Avro' },
+      { value: 'KAFKA_SCHEMA_FORMAT_PROTOBUF', label: 'Protobuf' },
+    ],
+    optional: true,
+  },
 ];
 
 export const blankKafkaSetting: KafkaConfig = {
@@ -70,4 +122,9 @@ export const blankKafkaSetting: KafkaConfig = {
   sasl: 'PLAIN',
   partitioner: '',
   disableTls: false,
+  schemaRegistryUrl: '',
+  schemaRegistryUsername: '',
+  schemaRegistryPassword: '',
+  schemaFormat: KafkaSchemaFormat.KAFKA_SCHEMA_FORMAT_AVRO,
+  exactlyOnce: false,
 };
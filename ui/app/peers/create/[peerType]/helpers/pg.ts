@@ -84,6 +84,27 @@ export const postgresSetting: PeerSetting[] = [
     tips: 'Overrides expected hostname during tls cert verification.',
     optional: true,
   },
+  {
+    label: 'Failover Hosts',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({
+        ...curr,
+        failoverHosts: (value as string)
+          .split(',')
+          .map((host) => host.trim())
+          .filter((host) => host !== ''),
+      })),
+    tips: 'Comma separated host or host:port of standbys that may be promoted. Connections go to whichever host is the primary, so mirrors continue from PG17 failover slots after a failover.',
+    optional: true,
+  },
+  {
+    label: 'Failover Slots?',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, failoverSlots: value as boolean })),
+    type: 'switch',
+    tips: 'On PG17+ create replication slots as failover slots and make existing slots failover slots, so they are synchronized to standbys with sync_replication_slots.',
+    optional: true,
+  },
   {
     label: 'Authentication type',
     field: 'authType',
@@ -208,4 +229,6 @@ export const blankPostgresSetting: PostgresConfig = {
     authType: AwsIAMAuthConfigType.IAM_AUTH_AUTOMATIC,
   },
   tlsHost: '',
+  failoverHosts: [],
+  failoverSlots: false,
 };
@@ -1,4 +1,12 @@
-import { AvroCodec, S3Config, avroCodecFromJSON } from '@/grpc_generated/peers';
+import {
+  AvroCodec,
+  S3Config,
+  S3Format,
+  S3Layout,
+  avroCodecFromJSON,
+  s3FormatFromJSON,
+  s3LayoutFromJSON,
+} from '@/grpc_generated/peers';
 import { PeerSetting } from './common';
 
 export const s3Setting: PeerSetting[] = [
@@ -75,12 +83,55 @@ export const s3Setting: PeerSetting[] = [
     optional: true,
   },
   {
-    label: 'Avro Codec',
+    label: 'Format',
+    field: 'format',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, format: s3FormatFromJSON(value) })),
+    type: 'select',
+    placeholder: 'Select file format',
+    tips: 'Avro and Parquet files are compressed with the selected codec, NDJSON files are always gzip compressed.',
+    options: [
+      { value: 'S3_FORMAT_AVRO', label: 'Avro' },
+      { value: 'S3_FORMAT_PARQUET', label: 'Parquet' },
+      { value: 'S3_FORMAT_NDJSON', label: 'NDJSON' },
+    ],
+  },
+  {
+    label: 'Layout',
+    field: 'layout',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, layout: s3LayoutFromJSON(value) })),
+    type: 'select',
+    placeholder: 'Select object layout',
+    tips: 'Raw writes each CDC batch to a single object. Hive splits each batch by table into dt=YYYY-MM-DD/hr=HH partitions.',
+    options: [
+      { value: 'S3_LAYOUT_RAW', label: 'Raw' },
+      { value: 'S3_LAYOUT_HIVE', label: 'Hive partitioned' },
+    ],
+  },
+  {
+    label: 'Partition Column',
+    field: 'partitionColumn',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, partitionColumn: value as string })),
+    tips: 'Timestamp or date column used to partition records with the Hive layout. Defaults to the commit time.',
+    optional: true,
+  },
+  {
+    label: 'Prefix with mirror name?',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, prefixMirrorName: value as boolean })),
+    type: 'switch',
+    tips: 'Enable to write Hive partitions under <prefix>/<mirror>/<table>, for mirrors sharing a prefix and table names.',
+    optional: true,
+  },
+  {
+    label: 'Codec',
     field: 'codec',
     stateHandler: (value, setter) =>
       setter((curr) => ({ ...curr, codec: avroCodecFromJSON(value) })),
     type: 'select',
-    placeholder: 'Select avro codec',
+    placeholder: 'Select codec',
     options: [
       { value: 'Null', label: 'Null' },
       { value: 'Deflate', label: 'Deflate' },
@@ -100,4 +151,8 @@ export const blankS3Setting: S3Config = {
   rootCa: undefined,
   tlsHost: '',
   codec: AvroCodec.Null,
+  format: S3Format.S3_FORMAT_AVRO,
+  layout: S3Layout.S3_LAYOUT_RAW,
+  partitionColumn: '',
+  prefixMirrorName: false,
 };
@@ -2,8 +2,12 @@ import { ehSchema } from '@/components/PeerForms/Eventhubs/schema';
 import {
   AvroCodec,
   ElasticsearchAuthType,
+  ElasticsearchFlavor,
+  KafkaSchemaFormat,
   MySqlFlavor,
   MySqlReplicationMechanism,
+  S3Format,
+  S3Layout,
 } from '@/grpc_generated/peers';
 import * as z from 'zod/v4';
 
@@ -123,6 +127,10 @@ export const pgSchema = z.object({
     .optional()
     .transform((e) => (e === '' ? undefined : e)),
   tlsHost: z.string(),
+  failoverHosts: z
+    .array(z.string().min(1, 'Failover host cannot be empty'))
+    .optional(),
+  failoverSlots: z.boolean().optional(),
   sshConfig: sshSchema,
 });
 export const mySchema = z.object({
@@ -478,6 +486,20 @@ export const kaSchema = z.object({
     )
     .optional(),
   disableTls: z.boolean().optional(),
+  schemaRegistryUrl: z
+    .union([
+      z.url({ error: () => 'Schema registry URL must be a URL' }),
+      z.literal(''),
+    ])
+    .optional(),
+  schemaRegistryUsername: z.string().optional(),
+  schemaRegistryPassword: z.string().optional(),
+  schemaFormat: z
+    .enum(KafkaSchemaFormat, {
+      error: () => ({ message: 'Schema format must be one of [Avro,Protobuf]' }),
+    })
+    .optional(),
+  exactlyOnce: z.boolean().optional(),
 });
 
 const urlSchema = z
@@ -533,6 +555,20 @@ export const s3Schema = z.object({
         ? 'Avro codec is required'
         : 'Avro codec must be one of [Null,Deflate,Snappy,ZStandard]',
   }),
+  format: z.enum(S3Format, {
+    error: (issue) =>
+      issue.input === undefined
+        ? 'Format is required'
+        : 'Format must be one of [Avro,Parquet,NDJSON]',
+  }),
+  layout: z.enum(S3Layout, {
+    error: (issue) =>
+      issue.input === undefined
+        ? 'Layout is required'
+        : 'Layout must be one of [Raw,Hive]',
+  }),
+  partitionColumn: z.string().optional(),
+  prefixMirrorName: z.boolean().optional(),
 });
 
 export const psSchema = z.object({
@@ -651,7 +687,16 @@ export const esSchema = z
     username: z.string({ error: () => 'Username must be a string' }).optional(),
     password: z.string({ error: () => 'Password must be a string' }).optional(),
     apiKey: z.string({ error: () => 'API key must be a string' }).optional(),
+    flavor: z.enum(ElasticsearchFlavor).optional(),
   })
+  .refine(
+    (esSchema) =>
+      esSchema.flavor !== ElasticsearchFlavor.ELASTICSEARCH_FLAVOR_OPENSEARCH ||
+      esSchema.authType !== ElasticsearchAuthType.APIKEY,
+    {
+      message: 'OpenSearch does not support API key authentication',
+    }
+  )
   .refine(
     (esSchema) => {
       if (esSchema.authType === ElasticsearchAuthType.BASIC) {